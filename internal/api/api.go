@@ -34,6 +34,8 @@ import (
 type APIError struct {
 	err        error
 	StatusCode int
+	// The delay requested by the server via the Retry-After header
+	RetryAfter time.Duration
 }
 
 func (e APIError) Error() string {
@@ -53,12 +55,27 @@ func (e APIError) Unwrap() error {
 }
 
 // responseToAPIError converts a non-200 response to an APIError with the
-// response status code and message.
+// response status code and message. The message starts with the kind of
+// failure, so that users can tell whether they have to log in again,
+// wait for their quota or just retry.
 func responseToAPIError(resp *http.Response) error {
 	msg := resp.Status
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		msg = "Authentication failed, the API token is invalid or expired (" + msg + ")"
+	case resp.StatusCode == http.StatusForbidden:
+		msg = "Permission denied, the API token doesn't grant access (" + msg + ")"
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusPaymentRequired:
+		msg = "Rate limit or quota exceeded (" + msg + ")"
+	case isTransientStatus(resp.StatusCode):
+		msg = "Temporary server error (" + msg + ")"
+	}
+	apiErr := &APIError{StatusCode: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return &APIError{StatusCode: resp.StatusCode, err: errors.New(msg)}
+		apiErr.err = errors.New(msg)
+		return apiErr
 	}
 	apiResp := struct {
 		Code    int
@@ -66,9 +83,11 @@ func responseToAPIError(resp *http.Response) error {
 	}{}
 	err = json.Unmarshal(body, &apiResp)
 	if err != nil {
-		return &APIError{StatusCode: resp.StatusCode, err: errors.Errorf("%s: %s", msg, string(body))}
+		apiErr.err = errors.Errorf("%s: %s", msg, string(body))
+		return apiErr
 	}
-	return &APIError{StatusCode: resp.StatusCode, err: errors.Errorf("%s: %s", msg, apiResp.Message)}
+	apiErr.err = errors.Errorf("%s: %s", msg, apiResp.Message)
+	return apiErr
 }
 
 // ConnectionError is returned when a REST request fails to connect to the API
@@ -159,7 +178,9 @@ func ConvertProjectNameForUseWithAPIV3(projectName string) (string, error) {
 	return projectName, nil
 }
 
-func (client *APIClient) UploadBundle(path string, projectName string, token string) (*Artifact, error) {
+// importBundle uploads the bundle in a single request, which is used if
+// the server doesn't support resumable uploads
+func (client *APIClient) importBundle(path string, projectName string, token string) (*Artifact, error) {
 
 	projectName = ConvertProjectNameForUseWithAPIV1V2(projectName)
 
@@ -245,7 +266,11 @@ func (client *APIClient) UploadBundle(path string, projectName string, token str
 		req.Header.Set("Content-Type", m.FormDataContentType())
 		req.Header.Add("Authorization", "Bearer "+token)
 
-		httpClient := &http.Client{Transport: getCustomTransport()}
+		transport, err := getCustomTransport()
+		if err != nil {
+			return err
+		}
+		httpClient := &http.Client{Transport: transport}
 		resp, err := httpClient.Do(req)
 		if err != nil {
 			return errors.WithStack(err)
@@ -329,31 +354,51 @@ func (client *APIClient) sendRequest(method string, endpoint string, body []byte
 }
 
 // sendRequestWithTimeout sends a request to the API server with a timeout.
+// Requests which fail because of a transient error are retried with
+// exponential backoff, see retry. If the request still fails, an
+// APIError is returned instead of the response.
 func (client *APIClient) sendRequestWithTimeout(method string, endpoint string, body []byte, token string, timeout time.Duration) (*http.Response, error) {
 	url, err := url.JoinPath(client.Server, endpoint)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), method, url, bytes.NewReader(body))
+	transport, err := getCustomTransport()
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, err
 	}
+	httpClient := &http.Client{Transport: transport, Timeout: timeout}
+
+	var resp *http.Response
+	err = retry(context.Background(), isIdempotent(method), func(bool) error {
+		req, err := http.NewRequestWithContext(context.Background(), method, url, bytes.NewReader(body))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		req.Header.Set("User-Agent", client.UserAgent)
+		req.Header.Add("Authorization", "Bearer "+token)
+		req.Header.Add("Accept", "application/json")
+		req.Header.Add("Content-Type", "application/json")
 
-	req.Header.Set("User-Agent", client.UserAgent)
-	req.Header.Add("Authorization", "Bearer "+token)
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Content-Type", "application/json")
+		log.Debugf("Sending HTTP request: %s %s\n%s", method, endpoint, body)
+		resp, err = httpClient.Do(req)
+		if err != nil {
+			return WrapConnectionError(errors.WithStack(err))
+		}
 
-	log.Debugf("Sending HTTP request: %s %s\n%s", method, endpoint, body)
-	httpClient := &http.Client{Transport: getCustomTransport(), Timeout: timeout}
-	resp, err := httpClient.Do(req)
+		log.Debugf("Received response for HTTP request: %d %s", resp.StatusCode, endpoint)
+
+		if isTransientStatus(resp.StatusCode) {
+			defer resp.Body.Close()
+			return responseToAPIError(resp)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, WrapConnectionError(errors.WithStack(err))
+		return nil, err
 	}
 
-	log.Debugf("Received response for HTTP request: %d %s", resp.StatusCode, endpoint)
-
 	return resp, nil
 }
 
@@ -411,7 +456,15 @@ func ValidateAndNormalizeServerURL(server string) (string, error) {
 	return url, nil
 }
 
-func getCustomTransport() *http.Transport {
+// getCustomTransport returns the transport used for requests to the API
+// server, which connects via the proxy configured in the environment and
+// uses the certificates configured via TLSConfigFromViper
+func getCustomTransport() (*http.Transport, error) {
+	tlsConfig, err := TLSConfigFromViper().Load()
+	if err != nil {
+		return nil, err
+	}
+
 	// it is not possible to use the default Proxy Environment because
 	// of https://github.com/golang/go/issues/24135
 	dialer := proxy.FromEnvironment()
@@ -426,5 +479,5 @@ func getCustomTransport() *http.Transport {
 		}
 		return conn, nil
 	}
-	return &http.Transport{DialContext: dialContext}
+	return &http.Transport{DialContext: dialContext, TLSClientConfig: tlsConfig}, nil
 }
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
 	"time"
 
@@ -180,3 +181,42 @@ func createMetricsForCampaignRun(firstMetrics *report.FuzzingMetric, lastMetrics
 
 	return metricsList
 }
+
+// GetCampaignRun returns the campaign run with the given name, e.g.
+// "projects/my-project-c170bc17/campaign_runs/my-campaign-run".
+func (client *APIClient) GetCampaignRun(name string, token string) (*CampaignRun, error) {
+	url, err := url.JoinPath("/v1", name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	resp, err := client.sendRequest("GET", url, nil, token)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, responseToAPIError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	campaignRun := &CampaignRun{}
+	err = json.Unmarshal(body, campaignRun)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse response from get campaign run API call")
+	}
+	return campaignRun, nil
+}
+
+// IsFinished returns true if the campaign run stopped, successfully or
+// not
+func (c *CampaignRun) IsFinished() bool {
+	switch report.RunStatus(c.Status) {
+	case report.RunStatusSucceeded, report.RunStatusFailed, report.RunStatusStopped, report.RunStatusFailedToStart:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+
+	"code-intelligence.com/cifuzz/internal/cmd/remoterun/progress"
+)
+
+// DownloadCorpus downloads the corpus which remote runs accumulated for
+// the fuzz test as a zip archive to dest. It returns false if the fuzz
+// test doesn't have a corpus on CI Sense.
+func (client *APIClient) DownloadCorpus(project string, fuzzTest string, token string, dest string) (bool, error) {
+	project = ConvertProjectNameForUseWithAPIV1V2(project)
+	fuzzTargetId := base64.URLEncoding.EncodeToString([]byte(fuzzTest))
+
+	ctx := context.Background()
+	var resp *http.Response
+	err := retry(ctx, true, func(bool) error {
+		var err error
+		resp, err = client.sendRequestWithContext(ctx, "GET",
+			[]string{"v1", project, "fuzz_targets", fuzzTargetId, "corpus"}, nil, token,
+			func(req *http.Request) {
+				req.Header.Set("Accept", "application/zip")
+			})
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+			defer resp.Body.Close()
+			return responseToAPIError(resp)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	var body io.Reader = resp.Body
+	if resp.ContentLength > 0 && term.IsTerminal(int(os.Stdout.Fd())) {
+		body = progress.NewReader(resp.Body, resp.ContentLength, "Download completed")
+	}
+	_, err = io.Copy(f, body)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return true, errors.WithStack(f.Close())
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadCorpus(t *testing.T) {
+	fuzzTargetId := base64.URLEncoding.EncodeToString([]byte("com.example.FuzzTest::fuzz"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		if r.URL.Path != "/v1/projects/my_project/fuzz_targets/"+fuzzTargetId+"/corpus" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("zip"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	dest := filepath.Join(t.TempDir(), "corpus.zip")
+	found, err := client.DownloadCorpus("my_project", "com.example.FuzzTest::fuzz", "token", dest)
+	require.NoError(t, err)
+	require.True(t, found)
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "zip", string(data))
+
+	found, err = client.DownloadCorpus("my_project", "unknown", "token", dest)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
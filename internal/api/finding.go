@@ -2,13 +2,16 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 
 	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
 )
 
 type Findings struct {
@@ -67,6 +70,45 @@ type Severity struct {
 	Score       float32 `json:"score,omitempty"`
 }
 
+// ToLocalFinding converts the finding of the given project on CI Sense
+// to the representation which is used for local findings
+func (f *Finding) ToLocalFinding(project string) (*finding.Finding, error) {
+	timeStamp, err := time.Parse(time.RFC3339, f.Timestamp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not parse timestamp %s", f.Timestamp)
+	}
+	displayName := ConvertProjectNameForUseWithAPIV1V2(project)
+	localFinding := &finding.Finding{
+		Origin:    "CI Sense",
+		Name:      strings.TrimPrefix(f.Name, fmt.Sprintf("%s/findings/", displayName)),
+		CreatedAt: timeStamp,
+		FuzzTest:  f.FuzzTargetDisplayName,
+	}
+	if f.ErrorReport == nil {
+		return localFinding, nil
+	}
+	localFinding.Type = finding.ErrorType(f.ErrorReport.Type)
+	localFinding.InputData = f.ErrorReport.InputData
+	localFinding.Logs = f.ErrorReport.Logs
+	localFinding.Details = f.ErrorReport.Details
+	localFinding.HumanReadableInput = string(f.ErrorReport.InputData)
+	localFinding.MoreDetails = f.ErrorReport.MoreDetails
+	localFinding.Tag = f.ErrorReport.Tag
+	if f.ErrorReport.DebuggingInfo != nil && len(f.ErrorReport.DebuggingInfo.BreakPoints) > 0 {
+		breakPoint := f.ErrorReport.DebuggingInfo.BreakPoints[0]
+		frame := &stacktrace.StackFrame{
+			Function:   breakPoint.Function,
+			SourceFile: breakPoint.SourceFilePath,
+		}
+		if breakPoint.Location != nil {
+			frame.Line = breakPoint.Location.Line
+			frame.Column = breakPoint.Location.Column
+		}
+		localFinding.StackTrace = []*stacktrace.StackFrame{frame}
+	}
+	return localFinding, nil
+}
+
 // DownloadRemoteFindings downloads all remote findings for a given project from CI Sense.
 func (client *APIClient) DownloadRemoteFindings(project string, token string) (Findings, error) {
 	project = ConvertProjectNameForUseWithAPIV1V2(project)
@@ -104,9 +146,17 @@ func (client *APIClient) DownloadRemoteFindings(project string, token string) (F
 	return remoteFindings, nil
 }
 
-func (client *APIClient) UploadFinding(project string, fuzzTarget string, campaignRunName string, fuzzingRunName string, finding *finding.Finding, token string) error {
+// UploadFinding uploads the finding of the project in projectDir. The
+// complete crashing input is uploaded, even if the finding only contains
+// a preview of it.
+func (client *APIClient) UploadFinding(project string, fuzzTarget string, campaignRunName string, fuzzingRunName string, projectDir string, finding *finding.Finding, token string) error {
 	project = ConvertProjectNameForUseWithAPIV1V2(project)
 
+	inputData, err := finding.ReadInput(projectDir)
+	if err != nil {
+		return err
+	}
+
 	// loop through the stack trace and create a list of breakpoints
 	breakPoints := []*BreakPoint{}
 	for _, stackFrame := range finding.StackTrace {
@@ -132,7 +182,7 @@ func (client *APIClient) UploadFinding(project string, fuzzTarget string, campai
 					Logs:      finding.Logs,
 					Details:   finding.Details,
 					Type:      string(finding.Type),
-					InputData: finding.InputData,
+					InputData: inputData,
 					DebuggingInfo: &DebuggingInfo{
 						BreakPoints: breakPoints,
 					},
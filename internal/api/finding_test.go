@@ -0,0 +1,45 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/finding"
+)
+
+func TestUploadFinding_LargeInput(t *testing.T) {
+	var uploaded Findings
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/projects/my_project/findings", r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &uploaded))
+	}))
+	defer server.Close()
+
+	// Inputs which are larger than 1 MiB are stored compressed, with
+	// only a preview in the finding
+	projectDir := t.TempDir()
+	input := bytes.Repeat([]byte("A"), 2<<20)
+	f := &finding.Finding{
+		Name:      "large_input",
+		InputData: input,
+	}
+	err := f.SaveWithInput(projectDir, input)
+	require.NoError(t, err)
+	require.True(t, f.IsInputTruncated())
+
+	client := NewClient(server.URL)
+	err = client.UploadFinding("projects/my_project", "my_fuzz_test", "campaign_run", "fuzzing_run", projectDir, f, "token")
+	require.NoError(t, err)
+
+	require.Len(t, uploaded.Findings, 1)
+	assert.Equal(t, input, uploaded.Findings[0].ErrorReport.InputData)
+}
@@ -1,5 +1,15 @@
 package api
 
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
 type FuzzingRun struct {
 	Name                    string                   `json:"name"`
 	DisplayName             string                   `json:"display_name"`
@@ -48,3 +58,40 @@ type FuzzerRunConfigurations struct {
 	Engine       string `json:"engine"`
 	NumberOfJobs int64  `json:"number_of_jobs"`
 }
+
+type FuzzingRunLogs struct {
+	Lines []string `json:"lines"`
+	// The token to pass to the next request to get the lines which
+	// were logged since this request
+	NextPageToken string `json:"next_page_token"`
+}
+
+// GetFuzzingRunLogs returns the output of the fuzzer of the fuzzing run
+// with the given name, e.g.
+// "projects/my-project-c170bc17/fuzzing_runs/my-fuzzing-run". Only the
+// lines which were logged after the request which returned the page
+// token are returned.
+func (client *APIClient) GetFuzzingRunLogs(name string, pageToken string, token string) (*FuzzingRunLogs, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	resp, err := client.sendRequestWithContext(ctx, "GET", []string{"v1", name, "logs"}, nil, token, func(req *http.Request) {
+		if pageToken != "" {
+			req.URL.RawQuery = url.Values{"page_token": {pageToken}}.Encode()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, responseToAPIError(resp)
+	}
+
+	logs := &FuzzingRunLogs{}
+	err = json.NewDecoder(resp.Body).Decode(logs)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse response from get fuzzing run logs API call")
+	}
+	return logs, nil
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+type oidcTokenExchangeBody struct {
+	IDToken string `json:"id_token"`
+}
+
+type oidcTokenExchangeResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+// ExchangeOIDCToken exchanges an OpenID Connect ID token, which was
+// issued to a CI job by its CI provider (e.g. GitHub Actions or GitLab
+// CI), for a short-lived API access token. The server has to be
+// configured to trust the issuer and subject of the ID token.
+func (client *APIClient) ExchangeOIDCToken(idToken string) (string, error) {
+	body, err := json.Marshal(&oidcTokenExchangeBody{IDToken: idToken})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	resp, err := client.sendRequest("POST", "/v1/auth/oidc/token", body, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", responseToAPIError(resp)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	var tokenResp oidcTokenExchangeResponse
+	err = json.Unmarshal(respBody, &tokenResp)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to parse response from OIDC token exchange API call")
+	}
+	if tokenResp.AccessToken == "" {
+		return "", errors.New("Server response doesn't include an access token")
+	}
+	return tokenResp.AccessToken, nil
+}
@@ -132,3 +132,22 @@ func (client *APIClient) CreateProject(name string, token string) (*Project, err
 
 	return &projectBody.Project, nil
 }
+
+// DeleteProject deletes the project with the given name and all of its
+// campaign runs and findings
+func (client *APIClient) DeleteProject(name string, token string) error {
+	url, err := url.JoinPath("/v1", ConvertProjectNameForUseWithAPIV1V2(name))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	resp, err := client.sendRequest("DELETE", url, nil, token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return responseToAPIError(resp)
+	}
+	return nil
+}
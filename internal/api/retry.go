@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// The maximum number of times a failed request is retried in a row if
+// it's not configured via the api-max-retries setting or the
+// CIFUZZ_API_MAX_RETRIES environment variable
+const defaultMaxRetries = 5
+
+var (
+	// The delay before the first retry of a failed request, which is
+	// doubled with each further retry
+	retryBaseDelay = 1 * time.Second
+	// The maximum delay between two retries, unless the server asks for
+	// a longer delay via the Retry-After header
+	retryMaxDelay = 1 * time.Minute
+	// The maximum delay requested via the Retry-After header which is
+	// honored, so that a misconfigured server can't block cifuzz
+	maxRetryAfter = 10 * time.Minute
+)
+
+// retry calls fn until it succeeds, returns an error which can't be
+// retried or failed more often in a row than the configured maximum
+// number of retries. Requests which are not idempotent are only retried
+// if the server didn't process them, see isRetryableError. The argument
+// of fn is true if fn is called again after a failed call.
+func retry(ctx context.Context, idempotent bool, fn func(retry bool) error) error {
+	limit := maxRetries()
+	for retries := 0; ; retries++ {
+		err := fn(retries > 0)
+		if err == nil || !isRetryableError(err, idempotent) || ctx.Err() != nil {
+			return err
+		}
+		if retries >= limit {
+			if limit == 0 {
+				return err
+			}
+			return errors.WithMessagef(err, "Request failed after %d retries", retries)
+		}
+		delay := retryDelay(retries, err)
+		log.Warnf("API request failed: %v\nRetrying in %s (%d/%d)", err, delay.Round(time.Millisecond), retries+1, limit)
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+func maxRetries() int {
+	if !viper.IsSet("api-max-retries") {
+		return defaultMaxRetries
+	}
+	return max(viper.GetInt("api-max-retries"), 0)
+}
+
+// retryDelay returns the delay before the next retry. It's the delay
+// requested by the server via the Retry-After header or an exponential
+// backoff with jitter, so that clients which failed at the same time
+// don't retry at the same time.
+func retryDelay(retries int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return min(apiErr.RetryAfter, maxRetryAfter)
+	}
+	delay := retryBaseDelay << retries
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// isRetryableError returns true if the request might succeed when it's
+// sent again. Requests which are not idempotent, like the creation of
+// a campaign run, are only retried if the server rejected them because
+// of rate limiting or maintenance or if the connection couldn't be
+// established, because otherwise the server might have processed them.
+func isRetryableError(err error, idempotent bool) bool {
+	var connErr *ConnectionError
+	if errors.As(err, &connErr) {
+		// Sending the request again doesn't fix invalid certificates,
+		// which are either rejected by us or by the server, in which
+		// case the server sends a TLS alert ("remote error")
+		var certErr *tls.CertificateVerificationError
+		var opErr *net.OpError
+		isOpErr := errors.As(err, &opErr)
+		if errors.As(err, &certErr) || (isOpErr && opErr.Op == "remote error") {
+			return false
+		}
+		return idempotent || (isOpErr && opErr.Op == "dial")
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return true
+		}
+		return idempotent && isTransientStatus(apiErr.StatusCode)
+	}
+	return false
+}
+
+// isTransientStatus returns true if the status code indicates a failure
+// which doesn't depend on the request, so that the request might
+// succeed when it's sent again
+func isTransientStatus(statusCode int) bool {
+	return statusCode == http.StatusRequestTimeout ||
+		statusCode == http.StatusTooManyRequests ||
+		(statusCode >= 500 && statusCode != http.StatusNotImplemented)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is
+// either a number of seconds or an HTTP date. It returns zero if the
+// value is empty or invalid.
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0
+	}
+	return max(time.Until(date), 0)
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendRequest_Retry(t *testing.T) {
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = 1 * time.Second })
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			// The delay is short enough to not slow down the test
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			fmt.Fprint(w, `{"projects": []}`)
+		}
+	}))
+	defer server.Close()
+
+	projects, err := NewClient(server.URL).ListProjects("token")
+	require.NoError(t, err)
+	assert.Empty(t, projects)
+	assert.Equal(t, 3, requests)
+}
+
+func TestSendRequest_NotIdempotent(t *testing.T) {
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = 1 * time.Second })
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"code": 13, "message": "internal error"}`)
+	}))
+	defer server.Close()
+
+	// The server might have processed the POST request, so it's not
+	// sent again
+	_, err := NewClient(server.URL).sendRequest("POST", "v1/projects", nil, "token")
+	require.Error(t, err)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+	assert.Equal(t, "Temporary server error (500 Internal Server Error): internal error", err.Error())
+	assert.Equal(t, 1, requests)
+}
+
+func TestSendRequest_MaxRetries(t *testing.T) {
+	retryBaseDelay = time.Millisecond
+	viper.Set("api-max-retries", 2)
+	t.Cleanup(func() {
+		retryBaseDelay = 1 * time.Second
+		viper.Set("api-max-retries", nil)
+	})
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).ListProjects("token")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Request failed after 2 retries")
+	assert.Equal(t, 3, requests)
+}
+
+func TestResponseToAPIError(t *testing.T) {
+	for status, prefix := range map[int]string{
+		http.StatusUnauthorized:    "Authentication failed",
+		http.StatusForbidden:       "Permission denied",
+		http.StatusTooManyRequests: "Rate limit or quota exceeded",
+		http.StatusBadGateway:      "Temporary server error",
+		http.StatusNotFound:        "404 Not Found",
+	} {
+		resp := httptest.NewRecorder()
+		resp.Header().Set("Retry-After", "30")
+		resp.WriteHeader(status)
+		err := responseToAPIError(resp.Result())
+		assert.ErrorContains(t, err, prefix)
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, 30*time.Second, apiErr.RetryAfter)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, 120*time.Second, parseRetryAfter("120"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("invalid"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)))
+	delay := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.InDelta(t, time.Hour.Seconds(), delay.Seconds(), 5)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/config"
+)
+
+// TLSConfig configures how the connection to the API server is secured,
+// for servers which use certificates of a private CA, e.g. because of
+// TLS interception by a corporate proxy, or which require the client to
+// authenticate with a certificate (mTLS)
+type TLSConfig struct {
+	// Path to a PEM file with CA certificates which are trusted in
+	// addition to the system's certificates
+	CACert string
+	// Paths to the PEM files with the client certificate and its private
+	// key. If no key is specified, the key is read from the certificate
+	// file.
+	ClientCert string
+	ClientKey  string
+}
+
+// TLSConfigFromViper returns the TLS config set via cifuzz.yaml or the
+// CIFUZZ_CA_CERT, CIFUZZ_CLIENT_CERT and CIFUZZ_CLIENT_KEY environment
+// variables
+func TLSConfigFromViper() *TLSConfig {
+	return &TLSConfig{
+		CACert:     pathFromViper("ca-cert"),
+		ClientCert: pathFromViper("client-cert"),
+		ClientKey:  pathFromViper("client-key"),
+	}
+}
+
+// pathFromViper returns the path set for the key. Relative paths from
+// cifuzz.yaml are resolved against the directory containing it, like
+// the other paths in cifuzz.yaml, while relative paths from environment
+// variables are relative to the working directory.
+func pathFromViper(key string) string {
+	path := viper.GetString(key)
+	envVar := "CIFUZZ_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+	if _, isSetInEnv := os.LookupEnv(envVar); isSetInEnv || !viper.InConfig(key) || viper.ConfigFileUsed() == "" {
+		return path
+	}
+	return config.ResolvePath(filepath.Dir(viper.ConfigFileUsed()), path)
+}
+
+// Load returns the tls.Config for the configured certificates or nil if
+// no certificates are configured, in which case the defaults of the
+// HTTP client are used
+func (c *TLSConfig) Load() (*tls.Config, error) {
+	if c.CACert == "" && c.ClientCert == "" && c.ClientKey == "" {
+		return nil, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.CACert != "" {
+		pem, err := os.ReadFile(c.CACert)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to read CA certificate")
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			// The system's certificates are not available on all
+			// platforms, in which case only the CA certificates are
+			// trusted
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("Failed to read CA certificate: No PEM encoded certificates found in %s", c.CACert)
+		}
+		config.RootCAs = pool
+	}
+
+	if c.ClientCert == "" && c.ClientKey != "" {
+		return nil, errors.New("A client key was specified without a client certificate")
+	}
+	if c.ClientCert != "" {
+		keyFile := c.ClientKey
+		if keyFile == "" {
+			keyFile = c.ClientCert
+		}
+		cert, err := tls.LoadX509KeyPair(c.ClientCert, keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to load client certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
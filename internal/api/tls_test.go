@@ -0,0 +1,120 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestCustomCACertAndClientCert(t *testing.T) {
+	testDir := testutil.MkdirTemp(t, "", "tls-test-")
+	t.Cleanup(func() {
+		viper.Set("ca-cert", "")
+		viper.Set("client-cert", "")
+	})
+
+	// A self-signed client certificate, stored together with its key
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "cifuzz"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientCertDER, err := x509.CreateCertificate(rand.Reader, template, template, &clientKey.PublicKey, clientKey)
+	require.NoError(t, err)
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	require.NoError(t, err)
+	clientCertPath := filepath.Join(testDir, "client.pem")
+	clientPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientCertDER})
+	clientPEM = append(clientPEM, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: clientKeyDER})...)
+	require.NoError(t, os.WriteFile(clientCertPath, clientPEM, 0o600))
+	clientCert, err := x509.ParseCertificate(clientCertDER)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"projects": []}`)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	caCertPath := filepath.Join(testDir, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caCertPath, caPEM, 0o644))
+
+	client := NewClient(server.URL)
+
+	// The certificate of the server is not trusted by default
+	_, err = client.ListProjects("token")
+	var connErr *ConnectionError
+	require.ErrorAs(t, err, &connErr)
+
+	// The server requires a client certificate
+	viper.Set("ca-cert", caCertPath)
+	_, err = client.ListProjects("token")
+	require.ErrorAs(t, err, &connErr)
+
+	viper.Set("client-cert", clientCertPath)
+	projects, err := client.ListProjects("token")
+	require.NoError(t, err)
+	assert.Empty(t, projects)
+}
+
+func TestTLSConfig_Load(t *testing.T) {
+	config, err := (&TLSConfig{}).Load()
+	require.NoError(t, err)
+	assert.Nil(t, config)
+
+	invalidPath := filepath.Join(testutil.MkdirTemp(t, "", "tls-test-"), "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("not a certificate"), 0o644))
+	_, err = (&TLSConfig{CACert: invalidPath}).Load()
+	assert.ErrorContains(t, err, "No PEM encoded certificates found")
+
+	_, err = (&TLSConfig{ClientKey: invalidPath}).Load()
+	assert.Error(t, err)
+}
+
+func TestTLSConfigFromViper_RelativeToConfigDir(t *testing.T) {
+	configDir := testutil.MkdirTemp(t, "", "tls-test-")
+	configFile := filepath.Join(configDir, "cifuzz.yaml")
+	clientCertPath := filepath.Join(testutil.MkdirTemp(t, "", "tls-test-"), "client.pem")
+	require.NoError(t, os.WriteFile(configFile, []byte("ca-cert: certs/ca.pem\nclient-cert: "+clientCertPath+"\n"), 0o644))
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.SetConfigFile(configFile)
+	require.NoError(t, viper.ReadInConfig())
+	viper.Set("client-key", "client-key.pem")
+
+	// The paths from cifuzz.yaml must not depend on the working
+	// directory
+	testutil.ChdirToTempDir(t, "tls-test-cwd-")
+
+	tlsConfig := TLSConfigFromViper()
+	assert.Equal(t, filepath.Join(configDir, "certs", "ca.pem"), tlsConfig.CACert)
+	assert.Equal(t, clientCertPath, tlsConfig.ClientCert)
+	// Paths which are not set in cifuzz.yaml are kept as they are
+	assert.Equal(t, "client-key.pem", tlsConfig.ClientKey)
+}
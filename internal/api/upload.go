@@ -0,0 +1,320 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+
+	"code-intelligence.com/cifuzz/internal/cmd/remoterun/progress"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// The size of the chunks in which bundles are uploaded
+var uploadChunkSize int64 = 8 * 1024 * 1024
+
+var errResumableUploadNotSupported = errors.New("the server doesn't support resumable uploads")
+
+type uploadSession struct {
+	Name string `json:"name"`
+}
+
+type uploadStatus struct {
+	// The number of bytes the server has received
+	Offset *int64 `json:"offset"`
+}
+
+// UploadBundle uploads the bundle in chunks. If a chunk fails to upload
+// because of a transient error, like a connection error or a 5xx
+// response, the upload is resumed from the offset the server has
+// received. The requests which upload the chunks and complete the
+// upload can be retried, because the server identifies the data by the
+// upload session and its offset. Servers which don't support resumable
+// uploads are sent the bundle in a single request.
+func (client *APIClient) UploadBundle(path string, projectName string, token string) (*Artifact, error) {
+	artifact, err := client.uploadBundleResumable(path, projectName, token)
+	if errors.Is(err, errResumableUploadNotSupported) {
+		log.Debugf("Server %s doesn't support resumable uploads, uploading the bundle in a single request", client.Server)
+		return client.importBundle(path, projectName, token)
+	}
+	return artifact, err
+}
+
+func (client *APIClient) uploadBundleResumable(path string, projectName string, token string) (*Artifact, error) {
+	projectName = ConvertProjectNameForUseWithAPIV1V2(projectName)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	fileInfo, err := f.Stat()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	size := fileInfo.Size()
+
+	// Cancel the upload when receiving a termination signal
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signalErr := make(chan error, 1)
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+	defer signal.Stop(sigs)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case s := <-sigs:
+			log.Warnf("Received %s", s.String())
+			signalErr <- cmdutils.NewSignalError(s.(syscall.Signal))
+			cancel()
+		}
+	}()
+	withSignalErr := func(err error) error {
+		select {
+		case sigErr := <-signalErr:
+			return sigErr
+		default:
+			return err
+		}
+	}
+
+	// Creating the upload session is not idempotent, so it's only
+	// retried if the server didn't process the request
+	var session *uploadSession
+	err = retry(ctx, false, func(bool) error {
+		var err error
+		session, err = client.createUploadSession(ctx, projectName, filepath.Base(path), size, token)
+		return err
+	})
+	if err != nil {
+		return nil, withSignalErr(err)
+	}
+	log.Debugf("Created upload session %s", session.Name)
+
+	var bar *progress.Bar
+	onProgress := func(int64) {}
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Println("Uploading...")
+		bar = progress.NewBar(size, "Upload complete")
+		onProgress = bar.Set
+	}
+
+	var offset int64
+	for offset < size {
+		err = retry(ctx, true, func(resume bool) error {
+			if resume {
+				// Continue with the bytes which the server hasn't
+				// received yet
+				var err error
+				offset, err = client.uploadOffset(ctx, session.Name, offset, token)
+				if err != nil {
+					return err
+				}
+				log.Debugf("Resuming upload at offset %d of %d", offset, size)
+				if offset >= size {
+					return nil
+				}
+			}
+			newOffset, err := client.uploadChunk(ctx, session.Name, f, offset, size, token, onProgress)
+			if err != nil {
+				return err
+			}
+			offset = newOffset
+			return nil
+		})
+		if err != nil {
+			return nil, withSignalErr(err)
+		}
+		onProgress(offset)
+	}
+	if bar != nil {
+		bar.Done()
+	}
+
+	var artifact *Artifact
+	err = retry(ctx, true, func(bool) error {
+		var err error
+		artifact, err = client.completeUpload(ctx, session.Name, token)
+		return err
+	})
+	if err != nil {
+		return nil, withSignalErr(err)
+	}
+	return artifact, nil
+}
+
+func (client *APIClient) createUploadSession(ctx context.Context, projectName string, fileName string, size int64, token string) (*uploadSession, error) {
+	body, err := json.Marshal(map[string]any{"file-name": fileName, "size": size})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	resp, err := client.sendRequestWithContext(ctx, "POST", []string{"v2", projectName, "artifacts", "uploads"}, bytes.NewReader(body), token, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return nil, errResumableUploadNotSupported
+	default:
+		return nil, responseToAPIError(resp)
+	}
+
+	session := &uploadSession{}
+	err = json.NewDecoder(resp.Body).Decode(session)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse response from create upload API call")
+	}
+	if session.Name == "" {
+		return nil, errors.New("Server response doesn't include the name of the upload session")
+	}
+	return session, nil
+}
+
+// uploadChunk sends the chunk of the file which starts at the offset and
+// returns the offset of the next chunk
+func (client *APIClient) uploadChunk(ctx context.Context, sessionName string, f *os.File, offset int64, size int64, token string, onProgress func(int64)) (int64, error) {
+	chunkSize := min(uploadChunkSize, size-offset)
+	reader := &progressReader{
+		Reader:     io.NewSectionReader(f, offset, chunkSize),
+		offset:     offset,
+		onProgress: onProgress,
+	}
+	resp, err := client.sendRequestWithContext(ctx, "PUT", []string{"v2", sessionName}, reader, token, func(req *http.Request) {
+		req.ContentLength = chunkSize
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+chunkSize-1, size))
+		req.Header.Set("Content-Type", "application/octet-stream")
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, responseToAPIError(resp)
+	}
+
+	newOffset := offset + chunkSize
+	status := &uploadStatus{}
+	err = json.NewDecoder(resp.Body).Decode(status)
+	if err == nil && status.Offset != nil {
+		newOffset = *status.Offset
+	}
+	if newOffset <= offset {
+		return 0, errors.Errorf("Server didn't accept the upload at offset %d", offset)
+	}
+	return newOffset, nil
+}
+
+// uploadOffset returns the number of bytes the server has received,
+// which is where a failed upload is resumed
+func (client *APIClient) uploadOffset(ctx context.Context, sessionName string, lastOffset int64, token string) (int64, error) {
+	resp, err := client.sendRequestWithContext(ctx, "GET", []string{"v2", sessionName}, nil, token, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, responseToAPIError(resp)
+	}
+
+	status := &uploadStatus{}
+	err = json.NewDecoder(resp.Body).Decode(status)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to parse response from upload status API call")
+	}
+	if status.Offset == nil {
+		// Without an offset, we can only send the last chunk again
+		return lastOffset, nil
+	}
+	return *status.Offset, nil
+}
+
+func (client *APIClient) completeUpload(ctx context.Context, sessionName string, token string) (*Artifact, error) {
+	resp, err := client.sendRequestWithContext(ctx, "POST", []string{"v2", sessionName + ":complete"}, nil, token, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseToAPIError(resp)
+	}
+
+	artifact := &Artifact{}
+	err = json.NewDecoder(resp.Body).Decode(artifact)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse response from complete upload API call")
+	}
+	return artifact, nil
+}
+
+// sendRequestWithContext sends a request which can be cancelled via the
+// context. Unlike sendRequest, it has no timeout, because the duration
+// of requests which transfer large files, like the chunks of a
+// resumable upload, depends on the bandwidth. It doesn't retry failed
+// requests, because the body can't be sent again in general, callers
+// have to use retry instead.
+// The prepare function can be used to modify the request before it's
+// sent.
+func (client *APIClient) sendRequestWithContext(ctx context.Context, method string, pathElems []string, body io.Reader, token string, prepare func(req *http.Request)) (*http.Response, error) {
+	url, err := url.JoinPath(client.Server, pathElems...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("User-Agent", client.UserAgent)
+	req.Header.Add("Authorization", "Bearer "+token)
+	req.Header.Add("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if prepare != nil {
+		prepare(req)
+	}
+
+	log.Debugf("Sending HTTP request: %s %s", method, url)
+	transport, err := getCustomTransport()
+	if err != nil {
+		return nil, err
+	}
+	httpClient := &http.Client{Transport: transport}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.WithStack(ctx.Err())
+		}
+		return nil, WrapConnectionError(errors.WithStack(err))
+	}
+	log.Debugf("Received response for HTTP request: %d %s", resp.StatusCode, url)
+	return resp, nil
+}
+
+// progressReader reports the offset in the uploaded file after each
+// read
+type progressReader struct {
+	io.Reader
+	offset     int64
+	onProgress func(int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.offset += int64(n)
+	r.onProgress(r.offset)
+	//nolint:wrapcheck
+	return n, err
+}
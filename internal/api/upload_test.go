@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestUploadBundle_Resume(t *testing.T) {
+	uploadChunkSize = 4
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() {
+		uploadChunkSize = 8 * 1024 * 1024
+		retryBaseDelay = 1 * time.Second
+	})
+
+	content := "0123456789abcdefghij"
+	bundlePath := filepath.Join(testutil.MkdirTemp(t, "", "upload-test-"), "fuzz_tests.tar.gz")
+	require.NoError(t, os.WriteFile(bundlePath, []byte(content), 0o644))
+
+	var mutex sync.Mutex
+	var received []byte
+	var chunkRequests int
+	completed := false
+	const sessionPath = "/v2/projects/my_project/artifacts/uploads/1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/v2/projects/my_project/artifacts/uploads":
+			fmt.Fprint(w, `{"name": "projects/my_project/artifacts/uploads/1"}`)
+		case r.Method == "PUT" && r.URL.Path == sessionPath:
+			var start, end, total int
+			_, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total)
+			require.NoError(t, err)
+			assert.Equal(t, len(received), start)
+			assert.Equal(t, len(content), total)
+			data, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Len(t, data, end-start+1)
+			chunkRequests++
+			switch chunkRequests {
+			case 2:
+				// The chunk is rejected
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			case 4:
+				// The chunk is received, but the response is lost
+				received = append(received, data...)
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			received = append(received, data...)
+			fmt.Fprintf(w, `{"offset": %d}`, len(received))
+		case r.Method == "GET" && r.URL.Path == sessionPath:
+			fmt.Fprintf(w, `{"offset": %d}`, len(received))
+		case r.Method == "POST" && r.URL.Path == sessionPath+":complete":
+			completed = true
+			fmt.Fprint(w, `{"display-name": "my-artifact", "resource-name": "projects/my_project/artifacts/my-artifact"}`)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	artifact, err := client.UploadBundle(bundlePath, "my_project", "token")
+	require.NoError(t, err)
+	assert.Equal(t, "projects/my_project/artifacts/my-artifact", artifact.ResourceName)
+	assert.Equal(t, content, string(received))
+	assert.True(t, completed)
+	// 5 chunks, of which one was rejected
+	assert.Equal(t, 6, chunkRequests)
+}
+
+func TestUploadBundle_NotTransient(t *testing.T) {
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = 1 * time.Second })
+
+	bundlePath := filepath.Join(testutil.MkdirTemp(t, "", "upload-test-"), "fuzz_tests.tar.gz")
+	require.NoError(t, os.WriteFile(bundlePath, []byte("content"), 0o644))
+
+	var chunkRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			fmt.Fprint(w, `{"name": "projects/my_project/artifacts/uploads/1"}`)
+			return
+		}
+		chunkRequests++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	_, err := client.UploadBundle(bundlePath, "my_project", "token")
+	require.Error(t, err)
+	assert.Equal(t, 1, chunkRequests)
+}
+
+func TestUploadBundle_CreateSessionNotRetried(t *testing.T) {
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = 1 * time.Second })
+
+	bundlePath := filepath.Join(testutil.MkdirTemp(t, "", "upload-test-"), "fuzz_tests.tar.gz")
+	require.NoError(t, os.WriteFile(bundlePath, []byte("content"), 0o644))
+
+	// The server might have created the upload session, so the request
+	// isn't sent again
+	var createRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		createRequests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	_, err := client.UploadBundle(bundlePath, "my_project", "token")
+	require.Error(t, err)
+	assert.Equal(t, 1, createRequests)
+}
+
+func TestUploadBundle_Fallback(t *testing.T) {
+	bundlePath := filepath.Join(testutil.MkdirTemp(t, "", "upload-test-"), "fuzz_tests.tar.gz")
+	require.NoError(t, os.WriteFile(bundlePath, []byte("content"), 0o644))
+
+	var imported string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/projects/my_project/artifacts/import" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.True(t, strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data"))
+		file, _, err := r.FormFile("fuzzing-artifacts")
+		require.NoError(t, err)
+		data, err := io.ReadAll(file)
+		require.NoError(t, err)
+		imported = string(data)
+		err = json.NewEncoder(w).Encode(&Artifact{ResourceName: "projects/my_project/artifacts/my-artifact"})
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	artifact, err := client.UploadBundle(bundlePath, "my_project", "token")
+	require.NoError(t, err)
+	assert.Equal(t, "projects/my_project/artifacts/my-artifact", artifact.ResourceName)
+	assert.Equal(t, "content", imported)
+}
@@ -81,13 +81,16 @@ func NewBuilder(opts *BuilderOptions) (*Builder, error) {
 // labels of targets of the cc_fuzz_test rule provided by rules_fuzzing:
 // https://github.com/bazelbuild/rules_fuzzing/blob/master/docs/cc-fuzzing-rules.md#cc_fuzz_test
 //
-// TODO: Unfortunately, the cc_fuzz_test rule currently doesn't
-// support combining sanitizers, so we can't build with both ASan
-// and UBSan. Therefore, we only build with ASan and plan to upstream
-// support for combining sanitizers.
-func (b *Builder) BuildForRun(fuzzTests []string) ([]*build.BuildResult, error) {
+// Note that rules_fuzzing only supports a subset of the sanitizer
+// combinations, in particular it doesn't support TSan.
+func (b *Builder) BuildForRun(sanitizers []string, fuzzTests []string) ([]*build.BuildResult, error) {
 	var err error
 
+	sanitizerFlagValue, err := rulesFuzzingSanitizer(sanitizers)
+	if err != nil {
+		return nil, err
+	}
+
 	var binLabels []string
 	for i := range fuzzTests {
 		// The cc_fuzz_test rule defines multiple bazel targets: If the
@@ -155,8 +158,8 @@ func (b *Builder) BuildForRun(fuzzTests []string) ([]*build.BuildResult, error)
 		// Build with libFuzzer
 		"--@rules_fuzzing//fuzzing:cc_engine=@rules_fuzzing//fuzzing/engines:libfuzzer",
 		"--@rules_fuzzing//fuzzing:cc_engine_instrumentation=libfuzzer",
-		// Build with ASan and UBSan instrumentation
-		"--@rules_fuzzing//fuzzing:cc_engine_sanitizer=asan-ubsan",
+		// Build with the requested sanitizer instrumentation
+		"--@rules_fuzzing//fuzzing:cc_engine_sanitizer=" + sanitizerFlagValue,
 		// Link in our additional libFuzzer logic that dumps inputs for non-fatal crashes.
 		"--@cifuzz//:__internal_has_libfuzzer",
 		"--verbose_failures",
@@ -218,7 +221,14 @@ func (b *Builder) BuildForBundle(sanitizers []string, fuzzTests []string) ([]*bu
 		return nil, err
 	}
 
-	env, err = b.setLibFuzzerEnv(env)
+	if !(len(sanitizers) == 1 && sanitizers[0] == "coverage") {
+		err = build.ValidateSanitizers(sanitizers)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	env, err = b.setLibFuzzerEnv(env, sanitizers)
 	if err != nil {
 		return nil, err
 	}
@@ -282,8 +292,8 @@ func (b *Builder) BuildForBundle(sanitizers []string, fuzzTests []string) ([]*bu
 			"--@rules_fuzzing//fuzzing:cc_engine_instrumentation=oss-fuzz")
 		for _, sanitizer := range sanitizers {
 			switch sanitizer {
-			case "address", "undefined":
-				// ASan and UBSan are already enabled above by the call
+			case "address", "undefined", "thread", "memory":
+				// The sanitizers are already enabled above by the call
 				// to b.setLibFuzzerEnv, which sets the respective flags
 				// via the FUZZING_CFLAGS environment variable. These
 				// variables are then picked up by the OSS-Fuzz engine
@@ -402,11 +412,17 @@ func (b *Builder) BuildForBundle(sanitizers []string, fuzzTests []string) ([]*bu
 	return results, nil
 }
 
-func (b *Builder) setLibFuzzerEnv(env []string) ([]string, error) {
+func (b *Builder) setLibFuzzerEnv(env []string, sanitizers []string) ([]string, error) {
 	var err error
 
+	// Coverage builds are configured via bazel flags, so we use the
+	// default sanitizers for the FUZZING_CFLAGS in that case
+	if len(sanitizers) == 1 && sanitizers[0] == "coverage" {
+		sanitizers = build.DefaultSanitizers
+	}
+
 	// Set FUZZING_CFLAGS and FUZZING_CXXFLAGS.
-	cflags := build.LibFuzzerCFlags()
+	cflags := build.LibFuzzerCFlags(sanitizers)
 	env, err = envutil.Setenv(env, "FUZZING_CFLAGS", strings.Join(cflags, " "))
 	if err != nil {
 		return nil, err
@@ -426,6 +442,30 @@ func (b *Builder) setLibFuzzerEnv(env []string) ([]string, error) {
 	return env, nil
 }
 
+// rulesFuzzingSanitizer returns the value of the cc_engine_sanitizer
+// flag of rules_fuzzing which corresponds to the given sanitizers, see
+// https://github.com/bazelbuild/rules_fuzzing/blob/master/fuzzing/BUILD
+func rulesFuzzingSanitizer(sanitizers []string) (string, error) {
+	err := build.ValidateSanitizers(sanitizers)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.Join(sanitizers, "+") {
+	case "address+undefined", "undefined+address":
+		return "asan-ubsan", nil
+	case "address":
+		return "asan", nil
+	case "undefined":
+		return "ubsan", nil
+	case "memory":
+		return "msan", nil
+	default:
+		return "", errors.Errorf("Building with the sanitizers %s is not supported with Bazel",
+			strings.Join(sanitizers, ", "))
+	}
+}
+
 // PathFromLabel turns a bazel label into a valid path, which can for
 // example be used to create the fuzz test's corpus directory.
 // Flags which should be passed to the `bazel query` command can be
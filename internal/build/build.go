@@ -3,12 +3,22 @@ package build
 import (
 	"os"
 	"runtime"
+	"strings"
 
 	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
 
 	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/sliceutil"
 )
 
+// DefaultSanitizers are the sanitizers which fuzz tests are built with
+// if the user didn't specify any
+var DefaultSanitizers = []string{"address", "undefined"}
+
+// The sanitizers which can be selected by the user
+var supportedSanitizers = []string{"address", "undefined", "thread", "memory"}
+
 // BuildResult contains fields which are needed to run the fuzz test
 type BuildResult struct {
 	// Canonical path of the fuzz test executable
@@ -42,7 +52,52 @@ type JavaBuildResult struct {
 	*BuildResult
 }
 
+// ValidateSanitizers checks that the given sanitizers are supported on
+// the current platform and can be combined with each other
+func ValidateSanitizers(sanitizers []string) error {
+	if len(sanitizers) == 0 {
+		return errors.New("At least one sanitizer must be specified")
+	}
+
+	// ASan, TSan and MSan all use their own shadow memory and can't be
+	// combined, only UBSan can be combined with each of them.
+	var exclusive []string
+	for _, sanitizer := range sanitizers {
+		if !sliceutil.Contains(supportedSanitizers, sanitizer) {
+			return errors.Errorf("Unsupported sanitizer %q, supported sanitizers are: %s",
+				sanitizer, strings.Join(supportedSanitizers, ", "))
+		}
+		if sanitizer != "undefined" && !sliceutil.Contains(exclusive, sanitizer) {
+			exclusive = append(exclusive, sanitizer)
+		}
+	}
+	if len(exclusive) > 1 {
+		return errors.Errorf("The sanitizers %s can't be combined", strings.Join(exclusive, " and "))
+	}
+
+	if sliceutil.Contains(sanitizers, "memory") && runtime.GOOS != "linux" {
+		return errors.New("The memory sanitizer is only supported on Linux")
+	}
+	if sliceutil.Contains(sanitizers, "thread") && runtime.GOOS == "windows" {
+		return errors.New("The thread sanitizer is not supported on Windows")
+	}
+
+	return nil
+}
+
 func CommonBuildEnv() ([]string, error) {
+	return commonBuildEnv(true)
+}
+
+// SystemCompilerBuildEnv is like CommonBuildEnv, but uses the C/C++
+// compiler which is configured in the environment instead of clang.
+// This is used for builds without fuzzing instrumentation, e.g. gcov
+// coverage builds of projects which must be built with GCC.
+func SystemCompilerBuildEnv() ([]string, error) {
+	return commonBuildEnv(false)
+}
+
+func commonBuildEnv(useClang bool) ([]string, error) {
 	var err error
 	env := os.Environ()
 
@@ -58,7 +113,7 @@ func CommonBuildEnv() ([]string, error) {
 	// variables to be set correctly. Thus, we assume users to run cifuzz from
 	// a developer command prompt anyway and thus don't need to set the
 	// compiler explicitly.
-	if runtime.GOOS != "windows" {
+	if useClang && runtime.GOOS != "windows" {
 		// Set the C/C++ compiler to clang/clang++ (if not already set),
 		// which is needed to build a  binary with fuzzing instrumentation
 		// gcc doesn't have -fsanitize=fuzzer.
@@ -100,33 +155,50 @@ var commonCFlags = []string{
 	"-UNDEBUG",
 }
 
-func LibFuzzerCFlags() []string {
+func LibFuzzerCFlags(sanitizers []string) []string {
 	// These flags must not contain spaces, because the environment
 	// variables that are set to these flags are space separated.
 	// Note: Keep in sync with share/cmake/cifuzz-functions.cmake
-	return append(commonCFlags, []string{
+	cflags := append(commonCFlags, []string{
 		// ----- Flags used to build with libFuzzer -----
 		// Compile with edge coverage and compare instrumentation. We
 		// use fuzzer-no-link here instead of -fsanitize=fuzzer because
 		// CFLAGS are often also passed to the linker, which would cause
 		// errors if the build includes tools which have a main function.
 		"-fsanitize=fuzzer-no-link",
-
-		// ----- Flags used to build with ASan -----
-		// Build with instrumentation for ASan and UBSan and link in
-		// their runtime
-		"-fsanitize=address,undefined",
-		// To support recovering from ASan findings
-		"-fsanitize-recover=address",
-		// Use additional error detectors for use-after-scope bugs
-		// TODO: Evaluate the slow down caused by this flag
-		// TODO: Check if there are other additional error detectors
-		//       which we want to use
-		"-fsanitize-address-use-after-scope",
-		// Disable source fortification, which is currently not supported
-		// in combination with ASan, see https://github.com/google/sanitizers/issues/247
-		"-U_FORTIFY_SOURCE",
 	}...)
+
+	// ----- Flags used to build with sanitizers -----
+	// Build with instrumentation for the sanitizers and link in their
+	// runtime
+	cflags = append(cflags, "-fsanitize="+strings.Join(sanitizers, ","))
+	for _, sanitizer := range sanitizers {
+		switch sanitizer {
+		case "address":
+			cflags = append(cflags,
+				// To support recovering from ASan findings
+				"-fsanitize-recover=address",
+				// Use additional error detectors for use-after-scope bugs
+				// TODO: Evaluate the slow down caused by this flag
+				// TODO: Check if there are other additional error detectors
+				//       which we want to use
+				"-fsanitize-address-use-after-scope",
+				// Disable source fortification, which is currently not supported
+				// in combination with ASan, see https://github.com/google/sanitizers/issues/247
+				"-U_FORTIFY_SOURCE",
+			)
+		case "memory":
+			cflags = append(cflags,
+				// Report where uninitialized values originate from
+				"-fsanitize-memory-track-origins",
+				// Source fortification causes false positives with MSan
+				// because the fortified libc functions are not
+				// instrumented
+				"-U_FORTIFY_SOURCE",
+			)
+		}
+	}
+	return cflags
 }
 
 func CoverageCFlags(clangVersion *semver.Version) []string {
@@ -37,3 +37,27 @@ func TestCommonBuildEnv_ClangDontOverwrite(t *testing.T) {
 	assert.Equal(t, "/my/clang", envutil.Getenv(env, "CC"))
 	assert.Equal(t, "/my/clang++", envutil.Getenv(env, "CXX"))
 }
+
+func TestValidateSanitizers(t *testing.T) {
+	require.NoError(t, ValidateSanitizers(DefaultSanitizers))
+	require.NoError(t, ValidateSanitizers([]string{"undefined"}))
+
+	assert.Error(t, ValidateSanitizers(nil))
+	assert.Error(t, ValidateSanitizers([]string{"leak"}))
+	assert.Error(t, ValidateSanitizers([]string{"address", "memory"}))
+	assert.Error(t, ValidateSanitizers([]string{"thread", "undefined", "address"}))
+
+	if runtime.GOOS == "linux" {
+		require.NoError(t, ValidateSanitizers([]string{"memory", "undefined"}))
+	}
+}
+
+func TestLibFuzzerCFlags(t *testing.T) {
+	flags := LibFuzzerCFlags([]string{"thread", "undefined"})
+	assert.Contains(t, flags, "-fsanitize=thread,undefined")
+	assert.NotContains(t, flags, "-fsanitize-recover=address")
+
+	flags = LibFuzzerCFlags(DefaultSanitizers)
+	assert.Contains(t, flags, "-fsanitize=address,undefined")
+	assert.Contains(t, flags, "-fsanitize-recover=address")
+}
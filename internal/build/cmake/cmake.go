@@ -53,8 +53,17 @@ type BuilderOptions struct {
 	BuildOnly  bool
 
 	FindRuntimeDeps bool
+	// The fuzzing engine which the fuzz tests are linked with, either
+	// EngineLibFuzzer (the default) or EngineReplayer. Fuzz tests which
+	// are built with the replayer don't require clang.
+	Engine string
 }
 
+const (
+	EngineLibFuzzer = "libfuzzer"
+	EngineReplayer  = "replayer"
+)
+
 func (opts *BuilderOptions) Validate() error {
 	// Check that the project dir is set
 	if opts.ProjectDir == "" {
@@ -91,7 +100,11 @@ func NewBuilder(opts *BuilderOptions) (*Builder, error) {
 		return nil, errors.WithStack(err)
 	}
 
-	b.env, err = build.CommonBuildEnv()
+	if b.engine() == EngineReplayer {
+		b.env, err = build.SystemCompilerBuildEnv()
+	} else {
+		b.env, err = build.CommonBuildEnv()
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +112,13 @@ func NewBuilder(opts *BuilderOptions) (*Builder, error) {
 	return b, nil
 }
 
+func (b *Builder) engine() string {
+	if b.Engine == "" {
+		return EngineLibFuzzer
+	}
+	return b.Engine
+}
+
 func (b *Builder) Opts() *BuilderOptions {
 	return b.BuilderOptions
 }
@@ -138,7 +158,7 @@ func (b *Builder) BuildDir() (string, error) {
 		buildDir = fmt.Sprintf("%s-%s", sanitizersSegment, hashString)
 	}
 
-	buildDir = filepath.Join(b.ProjectDir, ".cifuzz-build", "libfuzzer", buildDir)
+	buildDir = filepath.Join(b.ProjectDir, ".cifuzz-build", b.engine(), buildDir)
 
 	return buildDir, nil
 }
@@ -159,7 +179,7 @@ func (b *Builder) Configure() error {
 	}
 
 	cacheArgs := []string{
-		"-DCIFUZZ_ENGINE=libfuzzer",
+		"-DCIFUZZ_ENGINE=" + b.engine(),
 		"-DCIFUZZ_SANITIZERS=" + strings.Join(b.Sanitizers, ";"),
 		"-DCIFUZZ_TESTING:BOOL=ON",
 	}
@@ -100,12 +100,14 @@ func NewBuilder(opts *BuilderOptions) (*Builder, error) {
 	if len(opts.Sanitizers) == 1 && opts.Sanitizers[0] == "coverage" {
 		b.env, err = SetCoverageEnv(b.env, b.RunfilesFinder)
 	} else {
-		for _, sanitizer := range opts.Sanitizers {
-			if sanitizer != "address" && sanitizer != "undefined" {
-				panic(fmt.Sprintf("Invalid sanitizer: %q", sanitizer))
-			}
+		if len(opts.Sanitizers) == 0 {
+			opts.Sanitizers = build.DefaultSanitizers
+		}
+		err = build.ValidateSanitizers(opts.Sanitizers)
+		if err != nil {
+			panic(fmt.Sprintf("Invalid sanitizers: %v", err))
 		}
-		b.env, err = SetLibFuzzerEnv(b.env, b.RunfilesFinder)
+		b.env, err = SetLibFuzzerEnv(b.env, opts.Sanitizers, b.RunfilesFinder)
 	}
 	if err != nil {
 		return nil, err
@@ -230,7 +232,7 @@ func (b *Builder) setCleanCommandEnv() error {
 	return nil
 }
 
-func SetLibFuzzerEnv(env []string, finder runfiles.RunfilesFinder) ([]string, error) {
+func SetLibFuzzerEnv(env []string, sanitizers []string, finder runfiles.RunfilesFinder) ([]string, error) {
 	var err error
 	env, err = setEnvWithDebugMsg(env, EnvBuildStep, "fuzzing")
 	if err != nil {
@@ -238,7 +240,7 @@ func SetLibFuzzerEnv(env []string, finder runfiles.RunfilesFinder) ([]string, er
 	}
 
 	// Set CFLAGS and CXXFLAGS
-	cflags := build.LibFuzzerCFlags()
+	cflags := build.LibFuzzerCFlags(sanitizers)
 	env, err = setEnvWithDebugMsg(env, "CFLAGS", strings.Join(cflags, " "))
 	if err != nil {
 		return nil, err
@@ -249,9 +251,9 @@ func SetLibFuzzerEnv(env []string, finder runfiles.RunfilesFinder) ([]string, er
 	}
 
 	ldflags := []string{
-		// ----- Flags used to build with ASan -----
-		// Link ASan and UBSan runtime
-		"-fsanitize=address,undefined",
+		// ----- Flags used to build with sanitizers -----
+		// Link the sanitizer runtimes
+		"-fsanitize=" + strings.Join(sanitizers, ","),
 	}
 	env, err = setEnvWithDebugMsg(env, "LDFLAGS", strings.Join(ldflags, " "))
 	if err != nil {
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"code-intelligence.com/cifuzz/internal/build"
 	"code-intelligence.com/cifuzz/internal/builder"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/pkg/mocks"
@@ -88,7 +89,7 @@ func TestNoQuotesOnEnv(t *testing.T) {
 	require.NoError(t, err)
 
 	var env []string
-	env, err = SetLibFuzzerEnv(env, finder)
+	env, err = SetLibFuzzerEnv(env, build.DefaultSanitizers, finder)
 	require.NoError(t, err)
 	assert.NotContains(t, envutil.Getenv(env, EnvFuzzTestCFlags), "'")
 	assert.NotContains(t, envutil.Getenv(env, EnvFuzzTestCXXFlags), "'")
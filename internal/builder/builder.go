@@ -480,6 +480,14 @@ func (i *CIFuzzBuilder) CopyFiles() error {
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	err = copy.Copy(filepath.Join(i.projectDir, "tools", "dataflow"), i.srcDir(), opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = copy.Copy(filepath.Join(i.projectDir, "tools", "recorder"), i.srcDir(), opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
 
 	// Copy .jar's needed for generating coverage reports
 	err = copy.Copy(filepath.Join(i.projectDir, "tools", "jacoco"), filepath.Join(i.shareDir(), "java"), opts)
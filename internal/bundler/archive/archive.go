@@ -7,6 +7,8 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -55,6 +57,21 @@ type TarArchiveWriter struct {
 	manifest   map[string]string
 	headers    []*tar.Header
 	gzipWriter *gzip.Writer
+
+	// If set, the entries are only written when the writer is closed,
+	// sorted by name and with normalized headers, so that the archive
+	// doesn't depend on the order in which the entries were added, the
+	// timestamps of the files or the users on the system
+	reproducible bool
+	modTime      time.Time
+	pending      []*pendingEntry
+}
+
+// pendingEntry is an entry of a reproducible archive which is written
+// when the writer is closed
+type pendingEntry struct {
+	header     *tar.Header
+	sourcePath string
 }
 
 func NewTarArchiveWriter(w io.Writer, compress bool) *TarArchiveWriter {
@@ -75,10 +92,28 @@ func NewTarArchiveWriter(w io.Writer, compress bool) *TarArchiveWriter {
 	}
 }
 
+// NewReproducibleTarArchiveWriter returns a TarArchiveWriter which
+// creates byte-identical archives for identical file contents. The
+// entries are sorted by name, their modification time is set to
+// modTime and the owner and permissions are normalized.
+func NewReproducibleTarArchiveWriter(w io.Writer, compress bool, modTime time.Time) *TarArchiveWriter {
+	writer := NewTarArchiveWriter(w, compress)
+	writer.reproducible = true
+	writer.modTime = modTime.UTC().Truncate(time.Second)
+	return writer
+}
+
 // Close closes the tar writer and the gzip writer. It does not close
 // the underlying io.Writer.
 func (w *TarArchiveWriter) Close() error {
 	var err error
+	if w.reproducible {
+		err = w.writePendingEntries()
+		if err != nil {
+			return err
+		}
+	}
+
 	err = w.Writer.Close()
 	if err != nil {
 		return errors.WithStack(err)
@@ -141,6 +176,23 @@ func (w *TarArchiveWriter) writeFileOrEmptyDir(archivePath string, sourcePath st
 		return errors.WithStack(err)
 	}
 	header.Name = archivePath
+
+	if !info.IsDir() && !info.Mode().IsRegular() {
+		return errors.Errorf("not a regular file: %s", sourcePath)
+	}
+
+	if w.reproducible {
+		w.normalizeHeader(header)
+		w.headers = append(w.headers, header)
+		if !info.IsDir() {
+			w.pending = append(w.pending, &pendingEntry{header: header, sourcePath: sourcePath})
+			w.manifest[archivePath] = sourcePath
+		} else {
+			w.pending = append(w.pending, &pendingEntry{header: header})
+		}
+		return nil
+	}
+
 	err = w.WriteHeader(header)
 	if err != nil {
 		return errors.WithStack(err)
@@ -150,9 +202,6 @@ func (w *TarArchiveWriter) writeFileOrEmptyDir(archivePath string, sourcePath st
 	if info.IsDir() {
 		return nil
 	}
-	if !info.Mode().IsRegular() {
-		return errors.Errorf("not a regular file: %s", sourcePath)
-	}
 
 	_, err = io.Copy(w.Writer, f)
 	if err != nil {
@@ -177,6 +226,12 @@ func (w *TarArchiveWriter) WriteHardLink(target string, linkname string) error {
 		Name:     linkname,
 		Linkname: target,
 	}
+	if w.reproducible {
+		w.normalizeHeader(header)
+		w.pending = append(w.pending, &pendingEntry{header: header})
+		w.manifest[target] = linkname
+		return nil
+	}
 	err := w.WriteHeader(header)
 	if err != nil {
 		return errors.WithStack(err)
@@ -227,6 +282,74 @@ func (w *TarArchiveWriter) Headers() []*tar.Header {
 	return w.headers
 }
 
+// normalizeHeader removes all information from the header which
+// depends on the system the archive is created on
+func (w *TarArchiveWriter) normalizeHeader(header *tar.Header) {
+	header.ModTime = w.modTime
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	header.Uid = 0
+	header.Gid = 0
+	header.Uname = ""
+	header.Gname = ""
+	header.Devmajor = 0
+	header.Devminor = 0
+	header.PAXRecords = nil
+	// Only keep whether the file is executable
+	switch {
+	case header.Typeflag == tar.TypeDir || header.Mode&0o111 != 0:
+		header.Mode = 0o755
+	default:
+		header.Mode = 0o644
+	}
+}
+
+// writePendingEntries writes the entries of a reproducible archive
+// sorted by name. Hard links are written after the entries they point
+// to, because they can only be extracted if the target exists.
+func (w *TarArchiveWriter) writePendingEntries() error {
+	sort.SliceStable(w.pending, func(i, j int) bool {
+		li := w.pending[i].header.Typeflag == tar.TypeLink
+		lj := w.pending[j].header.Typeflag == tar.TypeLink
+		if li != lj {
+			return lj
+		}
+		return w.pending[i].header.Name < w.pending[j].header.Name
+	})
+
+	for _, entry := range w.pending {
+		err := w.WriteHeader(entry.header)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if entry.sourcePath == "" {
+			continue
+		}
+		err = w.copyFile(entry)
+		if err != nil {
+			return err
+		}
+	}
+	w.pending = nil
+	return nil
+}
+
+func (w *TarArchiveWriter) copyFile(entry *pendingEntry) error {
+	f, err := os.Open(entry.sourcePath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	// The size in the header was determined when the file was added,
+	// so the tar writer fails if the file was modified since then
+	_, err = io.Copy(w.Writer, f)
+	if err != nil {
+		return errors.Wrapf(err, "failed to add file to archive: %s", entry.sourcePath)
+	}
+	return nil
+}
+
 // Extract extracts the gzip-compressed tar archive bundle into dir.
 func Extract(bundle, dir string) error {
 	f, err := os.Open(bundle)
@@ -3,8 +3,10 @@ package archive
 import (
 	"archive/tar"
 	"bufio"
+	"bytes"
 	"compress/gzip"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"os/exec"
@@ -12,6 +14,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/otiai10/copy"
 	"github.com/pkg/errors"
@@ -235,3 +238,76 @@ func createArchive(t *testing.T, files []fileEntry) *os.File {
 	t.Logf("Created archive at: %s", archiveFile.Name())
 	return archiveFile
 }
+
+// TestReproducibleArchive verifies that reproducible archives are
+// byte-identical regardless of the order in which the files are added
+// and of their modification times.
+func TestReproducibleArchive(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "reproducible-archive-test-*")
+	err := copy.Copy(filepath.Join("testdata", "archive_test"), dir)
+	require.NoError(t, err)
+	testFile := filepath.Join(dir, "dir1", "dir2", "test.txt")
+	modTime := time.Unix(1700000000, 0)
+
+	createReproducibleArchive := func(reverse bool) []byte {
+		buf := &bytes.Buffer{}
+		archiveWriter := NewReproducibleTarArchiveWriter(buf, true, modTime)
+		if reverse {
+			err = archiveWriter.WriteFile("test.txt", testFile)
+			require.NoError(t, err)
+			err = archiveWriter.WriteDir("dir", filepath.Join(dir, "dir1", "dir2"))
+			require.NoError(t, err)
+		} else {
+			err = archiveWriter.WriteDir("dir", filepath.Join(dir, "dir1", "dir2"))
+			require.NoError(t, err)
+			err = archiveWriter.WriteFile("test.txt", testFile)
+			require.NoError(t, err)
+		}
+		err = archiveWriter.WriteHardLink("dir/test.txt", "a_hardlink")
+		require.NoError(t, err)
+		err = archiveWriter.Close()
+		require.NoError(t, err)
+		return buf.Bytes()
+	}
+
+	first := createReproducibleArchive(false)
+	err = os.Chtimes(testFile, time.Now(), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	second := createReproducibleArchive(true)
+	require.Equal(t, first, second)
+
+	// Verify that the entries are sorted and normalized
+	gr, err := gzip.NewReader(bytes.NewReader(first))
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+		require.True(t, header.ModTime.Equal(modTime))
+		require.Zero(t, header.Uid)
+		require.Zero(t, header.Gid)
+	}
+	require.Equal(t, []string{"dir", "dir/test.sh", "dir/test.txt", "test.txt", "a_hardlink"}, names)
+}
+
+func TestMetadata_ToYaml_WindowsPaths(t *testing.T) {
+	metadata := &Metadata{Fuzzers: []*Fuzzer{{
+		Path:         filepath.FromSlash("libfuzzer/address/my_fuzz_test/bin/my_fuzz_test.exe"),
+		Engine:       "LIBFUZZER",
+		ProjectDir:   `C:\Users\dev\project`,
+		Dictionary:   filepath.FromSlash("libfuzzer/address/my_fuzz_test/dict"),
+		Seeds:        filepath.FromSlash("libfuzzer/address/my_fuzz_test/seeds"),
+		RuntimePaths: []string{`C:\Program Files\LLVM\bin`},
+	}}}
+
+	out, err := metadata.ToYaml()
+	require.NoError(t, err)
+	require.NotContains(t, string(out), `\`)
+	require.Contains(t, string(out), "build_dir: C:/Users/dev/project")
+	require.Contains(t, string(out), "- C:/Program Files/LLVM/bin")
+}
@@ -0,0 +1,224 @@
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+)
+
+// The engines which are supported in the metadata of a bundle
+var knownEngines = []string{"LIBFUZZER", "LLVM_COV", "JAVA_LIBFUZZER", "JAVASCRIPT_LIBFUZZER"}
+
+// Problem is an issue in a bundle which prevents the bundle, or one of
+// its fuzzers, from being run
+type Problem struct {
+	// The name of the fuzzer which the problem affects, or empty if
+	// the problem affects the whole bundle
+	Fuzzer  string `json:"fuzzer,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p *Problem) String() string {
+	if p.Fuzzer == "" {
+		return p.Message
+	}
+	return fmt.Sprintf("%s: %s", p.Fuzzer, p.Message)
+}
+
+// Lint checks the unpacked bundle in the given directory: whether the
+// bundle.yaml only contains known fields, whether the required fields
+// are set and whether all paths it references exist in the bundle. The
+// metadata is returned if the bundle.yaml could be parsed.
+func Lint(bundleDir string) (*Metadata, []*Problem, error) {
+	data, err := os.ReadFile(filepath.Join(bundleDir, MetadataFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, []*Problem{{Message: fmt.Sprintf("The bundle doesn't contain a %s", MetadataFileName)}}, nil
+	}
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	metadata, problems := lintMetadata(data)
+	if metadata == nil {
+		return nil, problems, nil
+	}
+
+	pathProblems, err := lintPaths(metadata, bundleDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	return metadata, append(problems, pathProblems...), nil
+}
+
+// lintMetadata parses the bundle.yaml and validates it against the
+// structure of Metadata, which is the schema of the file
+func lintMetadata(data []byte) (*Metadata, []*Problem) {
+	var problems []*Problem
+
+	metadata := &Metadata{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err := decoder.Decode(metadata)
+	if err != nil {
+		// Report unknown fields and type errors, but continue with the
+		// metadata which could be parsed
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, []*Problem{{Message: fmt.Sprintf("Failed to parse %s: %v", MetadataFileName, err)}}
+		}
+		for _, msg := range typeErr.Errors {
+			problems = append(problems, &Problem{Message: fmt.Sprintf("Invalid %s: %s", MetadataFileName, msg)})
+		}
+	}
+
+	if metadata.RunEnvironment == nil || metadata.RunEnvironment.Docker == "" {
+		problems = append(problems, &Problem{Message: "No Docker image specified in run_environment"})
+	}
+	if len(metadata.Fuzzers) == 0 {
+		problems = append(problems, &Problem{Message: "The bundle doesn't contain any fuzzers"})
+	}
+
+	seen := map[string]bool{}
+	for i, fuzzer := range metadata.Fuzzers {
+		name := FuzzerName(fuzzer, i)
+		// Java fuzzers are run from their class path, all other fuzzers
+		// from their executable or test file
+		if fuzzer.Engine == "JAVA_LIBFUZZER" {
+			if len(fuzzer.RuntimePaths) == 0 {
+				problems = append(problems, &Problem{Fuzzer: name, Message: "No runtime paths specified"})
+			}
+		} else if fuzzer.Path == "" {
+			problems = append(problems, &Problem{Fuzzer: name, Message: "No path specified"})
+		}
+		if fuzzer.Engine == "" {
+			problems = append(problems, &Problem{Fuzzer: name, Message: "No engine specified"})
+		} else if !sliceutil.Contains(knownEngines, fuzzer.Engine) {
+			problems = append(problems, &Problem{
+				Fuzzer:  name,
+				Message: fmt.Sprintf("Unknown engine %q, valid engines are: %s", fuzzer.Engine, strings.Join(knownEngines, ", ")),
+			})
+		}
+		// The same fuzz test can be contained once per engine and
+		// sanitizer
+		key := strings.Join([]string{fuzzer.Name, fuzzer.Target, fuzzer.Engine, fuzzer.Sanitizer}, "\x00")
+		if seen[key] {
+			problems = append(problems, &Problem{Fuzzer: name, Message: "The fuzzer is contained more than once"})
+		}
+		seen[key] = true
+	}
+
+	return metadata, problems
+}
+
+// lintPaths checks that the paths which are referenced in the metadata
+// exist in the bundle and don't point outside of it
+func lintPaths(metadata *Metadata, bundleDir string) ([]*Problem, error) {
+	var problems []*Problem
+
+	exists, err := fileutil.Exists(filepath.Join(bundleDir, "work_dir"))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		problems = append(problems, &Problem{Message: "The bundle doesn't contain the work_dir directory"})
+	}
+
+	for i, fuzzer := range metadata.Fuzzers {
+		name := FuzzerName(fuzzer, i)
+		check := func(field string, p string, wantDir bool) error {
+			if p == "" {
+				return nil
+			}
+			problem, err := lintPath(bundleDir, p, wantDir)
+			if err != nil {
+				return err
+			}
+			if problem != "" {
+				problems = append(problems, &Problem{Fuzzer: name, Message: fmt.Sprintf("Invalid %s %q: %s", field, p, problem)})
+			}
+			return nil
+		}
+
+		err = check("path", fuzzer.Path, false)
+		if err != nil {
+			return nil, err
+		}
+		err = check("dictionary", fuzzer.Dictionary, false)
+		if err != nil {
+			return nil, err
+		}
+		err = check("seeds", fuzzer.Seeds, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, libraryPath := range fuzzer.LibraryPaths {
+			err = check("library path", libraryPath, true)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, runtimePath := range fuzzer.RuntimePaths {
+			// Runtime paths are either jars or directories of classes
+			err = check("runtime path", runtimePath, fileutil.IsDir(filepath.Join(bundleDir, filepath.FromSlash(runtimePath))))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return problems, nil
+}
+
+// lintPath returns a description of the problem with the path in the
+// bundle or an empty string if the path is valid
+func lintPath(bundleDir string, p string, wantDir bool) (string, error) {
+	if path.IsAbs(p) || filepath.IsAbs(p) {
+		return "the path must be relative to the root of the bundle", nil
+	}
+	if cleaned := path.Clean(p); cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "the path points outside of the bundle", nil
+	}
+
+	info, err := os.Stat(filepath.Join(bundleDir, filepath.FromSlash(p)))
+	if errors.Is(err, os.ErrNotExist) {
+		return "no such file or directory in the bundle", nil
+	}
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if wantDir && !info.IsDir() {
+		return "not a directory", nil
+	}
+	if !wantDir && info.IsDir() {
+		return "not a file", nil
+	}
+	return "", nil
+}
+
+// FuzzerName returns the name by which the fuzzer at the given index of
+// the metadata is referred to in messages
+func FuzzerName(fuzzer *Fuzzer, index int) string {
+	name := fuzzer.Name
+	if name == "" {
+		name = fuzzer.Target
+	}
+	if name == "" {
+		name = fmt.Sprintf("fuzzer #%d", index+1)
+	}
+	if fuzzer.Engine != "" {
+		name += " (" + fuzzer.Engine
+		if fuzzer.Sanitizer != "" {
+			name += "+" + fuzzer.Sanitizer
+		}
+		name += ")"
+	}
+	return name
+}
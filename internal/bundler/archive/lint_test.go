@@ -0,0 +1,63 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	bundleDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(bundleDir, "work_dir"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(bundleDir, "libfuzzer", "address", "my_fuzz_test", "bin"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(bundleDir, "libfuzzer", "address", "my_fuzz_test", "bin", "my_fuzz_test"), nil, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(bundleDir, "dict"), nil, 0o644))
+
+	metadata := `run_environment:
+  docker: ubuntu:rolling
+fuzzers:
+  - name: my_fuzz_test
+    path: libfuzzer/address/my_fuzz_test/bin/my_fuzz_test
+    engine: LIBFUZZER
+    sanitizer: ADDRESS
+    dictionary: dict
+    seeds: libfuzzer/address/my_fuzz_test/seeds
+    library_paths:
+      - ../lib
+  - name: com.example.FuzzTest
+    engine: JAVA_LIBFUZZER
+    unknown_field: foo
+  - name: other_fuzz_test
+    path: dict
+    engine: AFL
+`
+	require.NoError(t, os.WriteFile(filepath.Join(bundleDir, MetadataFileName), []byte(metadata), 0o644))
+
+	m, problems, err := Lint(bundleDir)
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	require.Len(t, m.Fuzzers, 3)
+
+	var messages []string
+	for _, p := range problems {
+		messages = append(messages, p.String())
+	}
+	assert.ElementsMatch(t, []string{
+		"Invalid bundle.yaml: line 14: field unknown_field not found in type archive.Fuzzer",
+		"com.example.FuzzTest (JAVA_LIBFUZZER): No runtime paths specified",
+		`other_fuzz_test (AFL): Unknown engine "AFL", valid engines are: LIBFUZZER, LLVM_COV, JAVA_LIBFUZZER, JAVASCRIPT_LIBFUZZER`,
+		`my_fuzz_test (LIBFUZZER+ADDRESS): Invalid seeds "libfuzzer/address/my_fuzz_test/seeds": no such file or directory in the bundle`,
+		`my_fuzz_test (LIBFUZZER+ADDRESS): Invalid library path "../lib": the path points outside of the bundle`,
+	}, messages)
+}
+
+func TestLint_NoMetadata(t *testing.T) {
+	m, problems, err := Lint(t.TempDir())
+	require.NoError(t, err)
+	assert.Nil(t, m)
+	require.Len(t, problems, 1)
+	assert.Equal(t, "The bundle doesn't contain a bundle.yaml", problems[0].Message)
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
+
+	"code-intelligence.com/cifuzz/util/fileutil"
 )
 
 // MetadataFileName is the name of the meta information yaml file within an artifact archive.
@@ -14,7 +16,10 @@ const MetadataFileName = "bundle.yaml"
 type Metadata struct {
 	*RunEnvironment `yaml:"run_environment"`
 	CodeRevision    *CodeRevision `yaml:"code_revision,omitempty"`
-	Fuzzers         []*Fuzzer     `yaml:"fuzzers"`
+	// Custom labels of the bundle, e.g. the team or component the
+	// fuzzers belong to, as specified via --label or in cifuzz.yaml
+	Labels  map[string]string `yaml:"labels,omitempty"`
+	Fuzzers []*Fuzzer         `yaml:"fuzzers"`
 }
 
 // Fuzzer specifies the type and locations of fuzzers contained in the archive.
@@ -34,6 +39,20 @@ type Fuzzer struct {
 	RuntimePaths  []string      `yaml:"runtime_paths,omitempty"`
 	EngineOptions EngineOptions `yaml:"engine_options,omitempty"`
 	MaxRunTime    uint          `yaml:"max_run_time,omitempty"`
+	// The team or person which owns the fuzzer, as configured in the
+	// "fuzz-tests" section of cifuzz.yaml
+	Owner string `yaml:"owner,omitempty"`
+	// The results of the checks of the fuzzer's quality which were run
+	// when the bundle was created
+	Health []*HealthCheck `yaml:"health,omitempty"`
+}
+
+// HealthCheck is the result of a single check of a fuzzer's quality,
+// e.g. whether it's instrumented or has a non-empty seed corpus.
+type HealthCheck struct {
+	Name    string `yaml:"name"`
+	Passed  bool   `yaml:"passed"`
+	Message string `yaml:"message,omitempty"`
 }
 
 // RunEnvironment specifies the environment in which the fuzzers are to be run.
@@ -57,6 +76,9 @@ type EngineOptions struct {
 }
 
 func (a *Metadata) ToYaml() ([]byte, error) {
+	for _, fuzzer := range a.Fuzzers {
+		fuzzer.normalizePaths()
+	}
 	out, err := yaml.Marshal(a)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to marshal metadata to YAML: %+v", *a)
@@ -65,6 +87,24 @@ func (a *Metadata) ToYaml() ([]byte, error) {
 	return out, nil
 }
 
+// normalizePaths converts the paths inside the archive to forward
+// slashes, so that bundles created on Windows can be used on Linux.
+// The project directory is the absolute path on the host which created
+// the bundle, which is needed to map the paths in the debug info to the
+// sources, so it's only converted to forward slashes.
+func (f *Fuzzer) normalizePaths() {
+	f.Path = fileutil.ToSlash(f.Path)
+	f.ProjectDir = fileutil.ToSlash(f.ProjectDir)
+	f.Dictionary = fileutil.ToSlash(f.Dictionary)
+	f.Seeds = fileutil.ToSlash(f.Seeds)
+	for i := range f.LibraryPaths {
+		f.LibraryPaths[i] = fileutil.ToSlash(f.LibraryPaths[i])
+	}
+	for i := range f.RuntimePaths {
+		f.RuntimePaths[i] = fileutil.ToSlash(f.RuntimePaths[i])
+	}
+}
+
 func (a *Metadata) FromYaml(data []byte) error {
 	err := yaml.Unmarshal(data, a)
 	if err != nil {
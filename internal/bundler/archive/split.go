@@ -0,0 +1,121 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ReadEntries returns the headers of all entries of the bundle and its
+// metadata
+func ReadEntries(bundle string) ([]*tar.Header, *Metadata, error) {
+	var headers []*tar.Header
+	var metadata *Metadata
+	err := readBundle(bundle, func(header *tar.Header, r io.Reader) error {
+		headers = append(headers, header)
+		if header.Name != MetadataFileName {
+			return nil
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		metadata = &Metadata{}
+		return metadata.FromYaml(data)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if metadata == nil {
+		return nil, nil, errors.Errorf("The bundle %s doesn't contain a %s", bundle, MetadataFileName)
+	}
+	return headers, metadata, nil
+}
+
+// Filter creates a bundle at outputPath which contains the entries of
+// the given bundle for which include returns true, in the same order
+// and with the same headers, so that filtering a reproducible bundle
+// results in a reproducible bundle. The bundle.yaml is replaced by the
+// given metadata.
+func Filter(bundle string, outputPath string, metadata *Metadata, include func(header *tar.Header) bool) error {
+	metadataYamlContent, err := metadata.ToYaml()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	err = readBundle(bundle, func(header *tar.Header, r io.Reader) error {
+		if header.Name == MetadataFileName {
+			metadataHeader := *header
+			metadataHeader.Size = int64(len(metadataYamlContent))
+			err := tw.WriteHeader(&metadataHeader)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			_, err = tw.Write(metadataYamlContent)
+			return errors.WithStack(err)
+		}
+		if !include(header) {
+			return nil
+		}
+		err := tw.WriteHeader(header)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		_, err = io.Copy(tw, r)
+		return errors.WithStack(err)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = gw.Close()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(f.Close())
+}
+
+// readBundle calls fn for each entry of the bundle with a reader of the
+// entry's content
+func readBundle(bundle string, fn func(header *tar.Header, r io.Reader) error) error {
+	f, err := os.Open(bundle)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to read bundle %s", bundle)
+		}
+		err = fn(header, tr)
+		if err != nil {
+			return err
+		}
+	}
+}
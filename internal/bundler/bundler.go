@@ -5,14 +5,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/mattn/go-zglob"
 	"github.com/pkg/errors"
 
 	"code-intelligence.com/cifuzz/internal/bundler/archive"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/corpus"
 	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/tracing"
 	"code-intelligence.com/cifuzz/pkg/vcs"
 	"code-intelligence.com/cifuzz/util/fileutil"
 	"code-intelligence.com/cifuzz/util/sliceutil"
@@ -32,6 +37,8 @@ func New(opts *Opts) *Bundler {
 
 func (b *Bundler) Bundle() (string, error) {
 	var err error
+	span := tracing.Start("bundle", "cifuzz.build_system", b.opts.BuildSystem)
+	defer func() { span.End(err) }()
 
 	// Create temp dir
 	b.opts.tempDir, err = os.MkdirTemp("", "cifuzz-bundle-")
@@ -56,7 +63,17 @@ func (b *Bundler) Bundle() (string, error) {
 
 	// Create archive writer
 	bufWriter := bufio.NewWriter(bundle)
-	archiveWriter := archive.NewTarArchiveWriter(bufWriter, true)
+	timestamp := time.Now()
+	var archiveWriter *archive.TarArchiveWriter
+	if b.opts.Reproducible {
+		timestamp, err = sourceDateEpoch()
+		if err != nil {
+			return "", err
+		}
+		archiveWriter = archive.NewReproducibleTarArchiveWriter(bufWriter, true, timestamp)
+	} else {
+		archiveWriter = archive.NewTarArchiveWriter(bufWriter, true)
+	}
 
 	var fuzzers []*archive.Fuzzer
 	switch b.opts.BuildSystem {
@@ -64,6 +81,8 @@ func (b *Bundler) Bundle() (string, error) {
 		fuzzers, err = newLibfuzzerBundler(b.opts, archiveWriter).bundle()
 	case config.BuildSystemMaven, config.BuildSystemGradle:
 		fuzzers, err = newJazzerBundler(b.opts, archiveWriter).bundle()
+	case config.BuildSystemNodeJS:
+		fuzzers, err = newJazzerJSBundler(b.opts, archiveWriter).bundle()
 	default:
 		err = errors.Errorf("Unknown build system for bundler: %s", b.opts.BuildSystem)
 	}
@@ -77,6 +96,11 @@ func (b *Bundler) Bundle() (string, error) {
 		return "", err
 	}
 
+	err = b.createReplayScriptInArchive(fuzzers, archiveWriter)
+	if err != nil {
+		return "", err
+	}
+
 	err = b.createWorkDirInArchive(archiveWriter)
 	if err != nil {
 		return "", err
@@ -87,7 +111,16 @@ func (b *Bundler) Bundle() (string, error) {
 		return "", err
 	}
 
-	if b.opts.BundleBuildLogFile != "" {
+	err = b.createSBOMInArchive(archiveWriter, timestamp)
+	if err != nil {
+		return "", err
+	}
+
+	if b.opts.BundleBuildLogFile != "" && b.opts.Reproducible {
+		// The build log contains timestamps and paths of the build
+		// environment
+		log.Debugf("Not adding the build log to the reproducible bundle")
+	} else if b.opts.BundleBuildLogFile != "" {
 		err = archiveWriter.WriteFile("build.log", b.opts.BundleBuildLogFile)
 		if err != nil {
 			return "", errors.WithStack(err)
@@ -126,6 +159,22 @@ func (b *Bundler) Bundle() (string, error) {
 	return bundle.Name(), nil
 }
 
+// sourceDateEpoch returns the timestamp specified by the
+// SOURCE_DATE_EPOCH environment variable, see
+// https://reproducible-builds.org/docs/source-date-epoch/, or the Unix
+// epoch if it's not set
+func sourceDateEpoch() (time.Time, error) {
+	value := os.Getenv("SOURCE_DATE_EPOCH")
+	if value == "" {
+		return time.Unix(0, 0), nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Errorf("Invalid value of SOURCE_DATE_EPOCH %q, expected a Unix timestamp", value)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
 func (b *Bundler) createEmptyBundle() (*os.File, error) {
 	archiveExt := ".tar.gz"
 
@@ -162,6 +211,9 @@ func (b *Bundler) determineDockerImageForBundle() string {
 		case config.BuildSystemMaven, config.BuildSystemGradle:
 			// Maven and Gradle should use a Docker image with Java
 			dockerImageUsedInBundle = "eclipse-temurin:20"
+		case config.BuildSystemNodeJS:
+			// Jazzer.js fuzz tests are run with Node.js
+			dockerImageUsedInBundle = "node:lts"
 		}
 	}
 
@@ -178,6 +230,7 @@ func (b *Bundler) createMetadataFileInArchive(fuzzers []*archive.Fuzzer, archive
 			Docker: dockerImageUsedInBundle,
 		},
 		CodeRevision: b.getCodeRevision(),
+		Labels:       b.labels(),
 	}
 
 	metadataYamlContent, err := metadata.ToYaml()
@@ -241,9 +294,91 @@ func (b *Bundler) copyAdditionalFilesToArchive(archiveWriter archive.ArchiveWrit
 		}
 	}
 
+	for _, addFiles := range b.opts.AddFiles {
+		err := b.addFilesToArchive(archiveWriter, addFiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addFilesToArchive adds the files matching an entry of the "add-files"
+// setting to the archive
+func (b *Bundler) addFilesToArchive(archiveWriter archive.ArchiveWriter, addFiles *AddFiles) error {
+	pattern := addFiles.Source
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(b.opts.ProjectDir, pattern)
+	}
+	dest := addFiles.Dest
+	if dest == "" {
+		dest = archiveWorkDirPath
+	}
+
+	// The matches keep their path relative to the part of the pattern
+	// without wildcards, so "data/**/*.json" adds "data/a/b.json" as
+	// "<dest>/a/b.json". A file or directory without wildcards is
+	// added as "<dest>/<basename>".
+	baseDir := globBaseDir(pattern)
+	if baseDir == pattern {
+		baseDir = filepath.Dir(pattern)
+	}
+
+	matches, err := zglob.Glob(pattern)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.WithStack(err)
+	}
+	if len(matches) == 0 {
+		return errors.Errorf("The source %q of the \"add-files\" setting doesn't match any files", addFiles.Source)
+	}
+
+	for _, match := range matches {
+		relPath, err := filepath.Rel(baseDir, match)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		target := filepath.Join(dest, relPath)
+		log.Debugf("Adding %s to the bundle as %s", match, target)
+
+		if fileutil.IsDir(match) {
+			err = archiveWriter.WriteDir(target, match)
+		} else {
+			err = archiveWriter.WriteFile(target, match)
+		}
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// globBaseDir returns the longest leading part of the pattern which
+// doesn't contain any wildcards
+func globBaseDir(pattern string) string {
+	i := strings.IndexAny(pattern, "*?[{")
+	if i == -1 {
+		return pattern
+	}
+	return filepath.Dir(pattern[:i+1])
+}
+
+// labels returns the labels of the bundle as a map. If a key is
+// specified multiple times, the last value is used, so that labels
+// specified via --label override the ones from cifuzz.yaml.
+func (b *Bundler) labels() map[string]string {
+	allLabels := append(append([]string{}, b.opts.Labels...), b.opts.LabelArgs...)
+	if len(allLabels) == 0 {
+		return nil
+	}
+	labels := make(map[string]string)
+	for _, label := range allLabels {
+		key, value, _ := strings.Cut(label, "=")
+		labels[strings.TrimSpace(key)] = value
+	}
+	return labels
+}
+
 // getCodeRevision returns the code revision of the project, if it can be
 // determined. If it cannot be determined, nil is returned.
 func (b *Bundler) getCodeRevision() *archive.CodeRevision {
@@ -265,7 +400,16 @@ func (b *Bundler) getCodeRevision() *archive.CodeRevision {
 	return revision
 }
 
-func prepareSeeds(seedCorpusDirs []string, archiveSeedsDir string, archiveWriter archive.ArchiveWriter) error {
+func prepareSeeds(seedCorpusDirs []string, archiveSeedsDir string, archiveWriter archive.ArchiveWriter, tempDir string) error {
+	// Large crashing inputs are stored compressed in the seed corpus,
+	// so they are decompressed. The decompressed copies are stored in
+	// the temporary directory of the bundle, because reproducible
+	// archives only read the files when they are closed.
+	seedCorpusDirs, err := corpus.ExpandCompressedEntries(seedCorpusDirs, tempDir)
+	if err != nil {
+		return err
+	}
+
 	var targetDirs []string
 	for _, sourceDir := range seedCorpusDirs {
 		// Put the seeds into subdirectories of the "seeds" directory
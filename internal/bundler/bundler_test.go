@@ -1,6 +1,9 @@
 package bundler
 
 import (
+	"archive/tar"
+	"io"
+	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -9,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
 	"code-intelligence.com/cifuzz/internal/testutil"
 )
 
@@ -93,3 +97,83 @@ func TestRemoveBundleOnError(t *testing.T) {
 
 	assert.NoFileExists(t, bundlePath)
 }
+
+func TestAddFilesToArchive(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, "", "add-files-*")
+	for _, path := range []string{
+		filepath.Join("data", "a.json"),
+		filepath.Join("data", "sub", "b.json"),
+		filepath.Join("data", "sub", "c.txt"),
+		filepath.Join("models", "model.onnx"),
+		filepath.Join("config", "app.conf"),
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(projectDir, path)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, path), nil, 0o644))
+	}
+
+	b := New(&Opts{
+		ProjectDir: projectDir,
+		AddFiles: []*AddFiles{
+			{Source: "data/**/*.json", Dest: "work_dir/data"},
+			{Source: "models/model.onnx"},
+			{Source: "config", Dest: "etc"},
+		},
+	})
+	archiveWriter := archive.NewTarArchiveWriter(io.Discard, false)
+	t.Cleanup(func() { archiveWriter.Close() })
+	err := b.copyAdditionalFilesToArchive(archiveWriter)
+	require.NoError(t, err)
+
+	var files []string
+	for _, h := range archiveWriter.Headers() {
+		if h.Typeflag == tar.TypeReg {
+			files = append(files, h.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"work_dir/data/a.json",
+		"work_dir/data/sub/b.json",
+		"work_dir/model.onnx",
+		"etc/config/app.conf",
+	}, files)
+
+	b.opts.AddFiles = []*AddFiles{{Source: "data/**/*.bin"}}
+	err = b.copyAdditionalFilesToArchive(archiveWriter)
+	require.ErrorContains(t, err, "doesn't match any files")
+}
+
+func TestLabels(t *testing.T) {
+	b := New(&Opts{})
+	assert.Nil(t, b.labels())
+
+	opts := &Opts{
+		Labels:    []string{"team=security", "component=parser", "ticket="},
+		LabelArgs: []string{"component=lexer", "url=https://example.com/?a=b"},
+	}
+	require.NoError(t, opts.Validate())
+	b = New(opts)
+	assert.Equal(t, map[string]string{
+		"team":      "security",
+		"component": "lexer",
+		"ticket":    "",
+		"url":       "https://example.com/?a=b",
+	}, b.labels())
+
+	for _, label := range []string{"team", "=security"} {
+		opts = &Opts{LabelArgs: []string{label}}
+		require.ErrorContains(t, opts.Validate(), "labels must have the format <key>=<value>")
+	}
+}
+
+func TestLSanSuppressionsRelativeToConfigDir(t *testing.T) {
+	configDir := testutil.ChdirToTempDir(t, "bundler-lsan-")
+	err := os.WriteFile(filepath.Join(configDir, "lsan.supp"), []byte("leak:libfoo.so\n"), 0o644)
+	require.NoError(t, err)
+	subDir := filepath.Join(configDir, "sub")
+	require.NoError(t, os.Mkdir(subDir, 0o755))
+	require.NoError(t, os.Chdir(subDir))
+
+	opts := &Opts{ConfigDir: configDir, LSanSuppressions: "lsan.supp"}
+	require.NoError(t, opts.Validate())
+	assert.Equal(t, filepath.Join(configDir, "lsan.supp"), opts.LSanSuppressions)
+}
@@ -0,0 +1,169 @@
+package bundler
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+const (
+	healthCheckInstrumented = "instrumented"
+	healthCheckDictionary   = "dictionary"
+	healthCheckSeeds        = "seeds"
+	healthCheckMaxLen       = "max_len"
+)
+
+// Symbols which are only contained in executables which were built
+// with the respective instrumentation
+var instrumentationSymbols = map[string]string{
+	"fuzzer":    "__sanitizer_cov_",
+	"address":   "__asan_init",
+	"undefined": "__ubsan_",
+	"memory":    "__msan_init",
+	"thread":    "__tsan_init",
+}
+
+type healthCheckOptions struct {
+	fuzzTest string
+	// The fuzz test executable, which is only checked for
+	// instrumentation if it's set
+	executable     string
+	sanitizers     []string
+	dictionary     string
+	seedCorpusDirs []string
+	engineArgs     []string
+}
+
+// checkHealth runs fast checks of the quality of a fuzz test, so that
+// bundles with fuzz tests which won't find anything are noticed before
+// they are run on the fuzzing cluster. A warning is printed for each
+// failed check.
+func checkHealth(opts *healthCheckOptions) ([]*archive.HealthCheck, error) {
+	var checks []*archive.HealthCheck
+
+	if opts.executable != "" {
+		check, err := checkInstrumentation(opts.executable, opts.sanitizers)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+
+	check := &archive.HealthCheck{Name: healthCheckDictionary, Passed: opts.dictionary != ""}
+	if !check.Passed {
+		check.Message = "No dictionary is used. A dictionary helps the fuzzer to generate inputs for parsers of structured formats."
+	}
+	checks = append(checks, check)
+
+	seedSizes, err := seedSizes(opts.seedCorpusDirs)
+	if err != nil {
+		return nil, err
+	}
+	check = &archive.HealthCheck{Name: healthCheckSeeds, Passed: len(seedSizes) > 0}
+	if !check.Passed {
+		check.Message = "The seed corpus is empty. Seeds help the fuzzer to reach deeper parts of the code faster."
+	}
+	checks = append(checks, check)
+
+	if maxLen, ok := maxLenEngineArg(opts.engineArgs); ok {
+		var numTooLarge int
+		for _, size := range seedSizes {
+			if size > maxLen {
+				numTooLarge++
+			}
+		}
+		check = &archive.HealthCheck{Name: healthCheckMaxLen, Passed: numTooLarge == 0}
+		if !check.Passed {
+			check.Message = fmt.Sprintf("%d of %d seeds are larger than -max_len=%d and are truncated by the fuzzer.",
+				numTooLarge, len(seedSizes), maxLen)
+		}
+		checks = append(checks, check)
+	}
+
+	for _, check := range checks {
+		if !check.Passed {
+			log.Warnf("Bundle health check %q failed for %s: %s", check.Name, opts.fuzzTest, check.Message)
+		}
+	}
+	return checks, nil
+}
+
+func checkInstrumentation(executable string, sanitizers []string) (*archive.HealthCheck, error) {
+	// Fuzz test executables are usually small enough to search them in
+	// memory.
+	content, err := os.ReadFile(executable)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var missing []string
+	for _, instrumentation := range append([]string{"fuzzer"}, sanitizers...) {
+		symbol, ok := instrumentationSymbols[instrumentation]
+		if !ok {
+			continue
+		}
+		if !bytes.Contains(content, []byte(symbol)) {
+			missing = append(missing, instrumentation)
+		}
+	}
+
+	check := &archive.HealthCheck{Name: healthCheckInstrumented, Passed: len(missing) == 0}
+	if !check.Passed {
+		check.Message = fmt.Sprintf("The fuzz test executable is not instrumented for: %s. "+
+			"Make sure that the build flags of cifuzz are not overridden by the build system.",
+			strings.Join(missing, ", "))
+	}
+	return check, nil
+}
+
+func seedSizes(dirs []string) ([]int64, error) {
+	var sizes []int64
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			sizes = append(sizes, info.Size())
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sizes, nil
+}
+
+// maxLenEngineArg returns the value of the last -max_len engine
+// argument, if there is one
+func maxLenEngineArg(engineArgs []string) (int64, bool) {
+	var maxLen int64
+	var found bool
+	for _, arg := range engineArgs {
+		value, ok := strings.CutPrefix(strings.TrimLeft(arg, "-"), "max_len=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		maxLen, found = n, true
+	}
+	return maxLen, found
+}
@@ -0,0 +1,55 @@
+package bundler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+)
+
+func TestCheckHealth(t *testing.T) {
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "fuzz_test")
+	require.NoError(t, os.WriteFile(executable, []byte("...__sanitizer_cov_trace_pc_guard...__asan_init..."), 0o755))
+	seedsDir := filepath.Join(dir, "seeds")
+	require.NoError(t, os.Mkdir(seedsDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(seedsDir, "small"), []byte("1234"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(seedsDir, "large"), []byte("12345678"), 0o644))
+
+	checks, err := checkHealth(&healthCheckOptions{
+		fuzzTest:       "fuzz_test",
+		executable:     executable,
+		sanitizers:     []string{"address", "undefined"},
+		dictionary:     "dict",
+		seedCorpusDirs: []string{seedsDir},
+		engineArgs:     []string{"-max_len=4"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*archive.HealthCheck{
+		{
+			Name:    healthCheckInstrumented,
+			Passed:  false,
+			Message: "The fuzz test executable is not instrumented for: undefined. Make sure that the build flags of cifuzz are not overridden by the build system.",
+		},
+		{Name: healthCheckDictionary, Passed: true},
+		{Name: healthCheckSeeds, Passed: true},
+		{
+			Name:    healthCheckMaxLen,
+			Passed:  false,
+			Message: "1 of 2 seeds are larger than -max_len=4 and are truncated by the fuzzer.",
+		},
+	}, checks)
+}
+
+func TestMaxLenEngineArg(t *testing.T) {
+	_, ok := maxLenEngineArg([]string{"-runs=10"})
+	assert.False(t, ok)
+
+	maxLen, ok := maxLenEngineArg([]string{"-max_len=100", "--max_len=200"})
+	assert.True(t, ok)
+	assert.Equal(t, int64(200), maxLen)
+}
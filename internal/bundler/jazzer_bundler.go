@@ -23,6 +23,7 @@ import (
 	"code-intelligence.com/cifuzz/pkg/java/sourcemap"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/options"
+	"code-intelligence.com/cifuzz/pkg/tracing"
 	"code-intelligence.com/cifuzz/util/sliceutil"
 )
 
@@ -50,7 +51,9 @@ func (b *jazzerBundler) bundle() ([]*archive.Fuzzer, error) {
 		return nil, err
 	}
 
+	span := tracing.Start("build")
 	buildResult, err := b.runBuild()
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -60,6 +63,10 @@ func (b *jazzerBundler) bundle() ([]*archive.Fuzzer, error) {
 		return nil, err
 	}
 
+	if b.opts.MinimizeSeedCorpus {
+		log.Warn("Minimizing the seed corpus is only supported for C/C++ fuzz tests, adding all seeds to the bundle")
+	}
+
 	log.Info("Creating bundle...")
 
 	return b.assembleArtifacts(fuzzTests, targetMethods, buildResult.RuntimeDeps)
@@ -206,6 +213,18 @@ func (b *jazzerBundler) assembleArtifacts(fuzzTests []string, targetMethods []st
 			}
 		}
 
+		// Jazzer instruments the classes at runtime, so there is no
+		// executable which could be checked for instrumentation
+		health, err := checkHealth(&healthCheckOptions{
+			fuzzTest:       fuzzTestName,
+			dictionary:     archiveDict,
+			seedCorpusDirs: b.opts.SeedCorpusDirs,
+			engineArgs:     b.opts.EngineArgs,
+		})
+		if err != nil {
+			return nil, err
+		}
+
 		fuzzer := &archive.Fuzzer{
 			Name:         fuzzTestName,
 			Engine:       "JAVA_LIBFUZZER",
@@ -218,6 +237,8 @@ func (b *jazzerBundler) assembleArtifacts(fuzzTests []string, targetMethods []st
 				Flags: b.opts.EngineArgs,
 			},
 			MaxRunTime: uint(b.opts.Timeout.Seconds()),
+			Owner:      config.FuzzTestOwner(b.opts.FuzzTestConfigs, fuzzTestName),
+			Health:     health,
 		}
 
 		fuzzers = append(fuzzers, fuzzer)
@@ -232,7 +253,7 @@ func (b *jazzerBundler) copySeeds() (string, error) {
 	var archiveSeedsDir string
 	if len(b.opts.SeedCorpusDirs) > 0 {
 		archiveSeedsDir = "seeds"
-		err := prepareSeeds(b.opts.SeedCorpusDirs, archiveSeedsDir, b.archiveWriter)
+		err := prepareSeeds(b.opts.SeedCorpusDirs, archiveSeedsDir, b.archiveWriter, b.opts.tempDir)
 		if err != nil {
 			return "", err
 		}
@@ -77,6 +77,18 @@ func TestAssembleArtifactsJava_Fuzzing(t *testing.T) {
 			Env:   b.opts.Env,
 			Flags: b.opts.EngineArgs,
 		},
+		Health: []*archive.HealthCheck{
+			{
+				Name:    "dictionary",
+				Passed:  false,
+				Message: "No dictionary is used. A dictionary helps the fuzzer to generate inputs for parsers of structured formats.",
+			},
+			{
+				Name:    "seeds",
+				Passed:  false,
+				Message: "The seed corpus is empty. Seeds help the fuzzer to reach deeper parts of the code faster.",
+			},
+		},
 	}
 	require.Equal(t, 2, len(fuzzers))
 	require.Equal(t, *expectedFuzzer, *fuzzers[0])
@@ -0,0 +1,261 @@
+package bundler
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattn/go-zglob"
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/dependencies"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/tracing"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+// The directory inside the fuzzing artifact which contains the Node.js
+// project, including its node_modules directory. The fuzz tests are run
+// via jest from within this directory.
+const nodeProjectPath = "project"
+
+// The commands which install the dependencies of a Node.js project
+// exactly as specified in its lockfile
+var nodeInstallCommands = []struct {
+	lockfile string
+	command  []string
+}{
+	{"package-lock.json", []string{"npm", "ci"}},
+	{"npm-shrinkwrap.json", []string{"npm", "ci"}},
+	{"yarn.lock", []string{"yarn", "install", "--frozen-lockfile"}},
+	{"pnpm-lock.yaml", []string{"pnpm", "install", "--frozen-lockfile"}},
+}
+
+type jazzerJSBundler struct {
+	opts          *Opts
+	archiveWriter archive.ArchiveWriter
+}
+
+func newJazzerJSBundler(opts *Opts, archiveWriter archive.ArchiveWriter) *jazzerJSBundler {
+	if opts.BuildStderr == nil {
+		opts.BuildStderr = os.Stderr
+	}
+	if opts.BuildStdout == nil {
+		opts.BuildStdout = os.Stdout
+	}
+	return &jazzerJSBundler{opts, archiveWriter}
+}
+
+func (b *jazzerJSBundler) bundle() ([]*archive.Fuzzer, error) {
+	err := dependencies.Check([]dependencies.Key{dependencies.Node}, b.opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	span := tracing.Start("build")
+	err = b.installDependencies()
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+
+	fuzzTests := b.opts.FuzzTests
+	if len(fuzzTests) == 0 {
+		// If bundle is called without any arguments, we want to bundle
+		// every fuzz test
+		fuzzTests, err = cmdutils.ListNodeFuzzTestsByRegex(b.opts.ProjectDir, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(fuzzTests) == 0 {
+			return nil, cmdutils.WrapIncorrectUsageError(
+				errors.Errorf("No fuzz test could be found in the project directory '%s'", b.opts.ProjectDir),
+			)
+		}
+	}
+
+	if b.opts.MinimizeSeedCorpus {
+		log.Warn("Minimizing the seed corpus is only supported for C/C++ fuzz tests, adding all seeds to the bundle")
+	}
+
+	log.Info("Creating bundle...")
+
+	return b.assembleArtifacts(fuzzTests)
+}
+
+// installDependencies installs the dependencies of the project from
+// its lockfile if the node_modules directory doesn't exist yet, so that
+// the bundle contains exactly the versions the project was tested with
+func (b *jazzerJSBundler) installDependencies() error {
+	exists, err := fileutil.Exists(filepath.Join(b.opts.ProjectDir, "node_modules"))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	for _, install := range nodeInstallCommands {
+		exists, err := fileutil.Exists(filepath.Join(b.opts.ProjectDir, install.lockfile))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+
+		log.Infof("Installing the dependencies from %s", install.lockfile)
+		cmd := exec.Command(install.command[0], install.command[1:]...)
+		cmd.Dir = b.opts.ProjectDir
+		cmd.Stdout = b.opts.BuildStdout
+		cmd.Stderr = b.opts.BuildStderr
+		log.Debugf("Command: %s", strings.Join(stringutil.QuotedStrings(cmd.Args), " "))
+		err = cmd.Run()
+		if err != nil {
+			return cmdutils.WrapExecError(errors.WithStack(err), cmd)
+		}
+		return nil
+	}
+
+	return errors.Errorf("The project directory '%s' contains neither a node_modules directory nor a lockfile, please install the dependencies of the project", b.opts.ProjectDir)
+}
+
+func (b *jazzerJSBundler) assembleArtifacts(fuzzTests []string) ([]*archive.Fuzzer, error) {
+	err := b.copyProject()
+	if err != nil {
+		return nil, err
+	}
+
+	var archiveDict string
+	if b.opts.Dictionary != "" {
+		archiveDict = "dict"
+		err := b.archiveWriter.WriteFile(archiveDict, b.opts.Dictionary)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var archiveSeedsDir string
+	if len(b.opts.SeedCorpusDirs) > 0 {
+		archiveSeedsDir = "seeds"
+		err := prepareSeeds(b.opts.SeedCorpusDirs, archiveSeedsDir, b.archiveWriter, b.opts.tempDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var fuzzers []*archive.Fuzzer
+	for _, fuzzTest := range fuzzTests {
+		// The fuzz test is specified as <test path pattern> or
+		// <test path pattern>:<test name pattern>, like for 'cifuzz run'
+		testPathPattern, testNamePattern, _ := strings.Cut(fuzzTest, ":")
+		testNamePattern = strings.ReplaceAll(testNamePattern, "\"", "")
+
+		testFile, err := b.findTestFile(testPathPattern)
+		if err != nil {
+			return nil, err
+		}
+
+		// Jazzer.js instruments the code at runtime, so there is no
+		// executable which could be checked for instrumentation
+		health, err := checkHealth(&healthCheckOptions{
+			fuzzTest:       fuzzTest,
+			dictionary:     archiveDict,
+			seedCorpusDirs: b.opts.SeedCorpusDirs,
+			engineArgs:     b.opts.EngineArgs,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		fuzzers = append(fuzzers, &archive.Fuzzer{
+			// The test name pattern which selects the fuzz test in the
+			// test file, if the file contains multiple fuzz tests
+			Target:     testNamePattern,
+			Name:       fuzzTest,
+			Path:       filepath.Join(nodeProjectPath, testFile),
+			Engine:     "JAVASCRIPT_LIBFUZZER",
+			ProjectDir: b.opts.ProjectDir,
+			Dictionary: archiveDict,
+			Seeds:      archiveSeedsDir,
+			EngineOptions: archive.EngineOptions{
+				Env:   b.opts.Env,
+				Flags: b.opts.EngineArgs,
+			},
+			MaxRunTime: uint(b.opts.Timeout.Seconds()),
+			Owner:      config.FuzzTestOwner(b.opts.FuzzTestConfigs, fuzzTest),
+			Health:     health,
+		})
+	}
+	return fuzzers, nil
+}
+
+// copyProject adds the project directory to the archive, including the
+// node_modules directory but without the VCS metadata and the
+// directories created by cifuzz
+func (b *jazzerJSBundler) copyProject() error {
+	outputPath, err := filepath.Abs(b.opts.OutputPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = filepath.WalkDir(b.opts.ProjectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		relPath, err := filepath.Rel(b.opts.ProjectDir, path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if relPath == "." {
+			return nil
+		}
+		if d.IsDir() && (d.Name() == ".git" || strings.HasPrefix(d.Name(), ".cifuzz")) {
+			return fs.SkipDir
+		}
+		if absPath, err := filepath.Abs(path); err == nil && absPath == outputPath {
+			// Don't add the bundle which is being created
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 && fileutil.IsDir(path) {
+			// Symlinks to directories, e.g. of linked workspace
+			// packages, are not followed
+			log.Debugf("Skipping symlink to directory %s", path)
+			return nil
+		}
+		return b.archiveWriter.WriteFile(filepath.Join(nodeProjectPath, relPath), path)
+	})
+	return errors.WithMessagef(err, "Failed to add the project directory %s to the bundle", b.opts.ProjectDir)
+}
+
+// findTestFile returns the path of the test file of the fuzz test,
+// relative to the project directory
+func (b *jazzerJSBundler) findTestFile(testPathPattern string) (string, error) {
+	// use zglob to support globbing in windows
+	matches, err := zglob.Glob(filepath.Join(b.opts.ProjectDir, "**", testPathPattern+".fuzz.*"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", errors.WithStack(err)
+	}
+	for _, match := range matches {
+		relPath, err := filepath.Rel(b.opts.ProjectDir, match)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		if strings.HasPrefix(relPath, "node_modules"+string(filepath.Separator)) {
+			continue
+		}
+		return relPath, nil
+	}
+	return "", cmdutils.WrapIncorrectUsageError(
+		errors.Errorf("No test file found for fuzz test '%s' in the project directory '%s'", testPathPattern, b.opts.ProjectDir),
+	)
+}
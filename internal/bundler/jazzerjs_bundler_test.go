@@ -0,0 +1,84 @@
+package bundler
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestAssembleArtifactsNodeJS(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, "", "bundle-project-*")
+	for _, path := range []string{
+		"package.json",
+		"package-lock.json",
+		filepath.Join("src", "parser.js"),
+		filepath.Join("test", "parser.fuzz.js"),
+		filepath.Join("node_modules", "@jazzer.js", "jest-runner", "index.js"),
+		filepath.Join("node_modules", "other", "parser.fuzz.js"),
+		filepath.Join(".git", "HEAD"),
+		filepath.Join(".cifuzz-corpus", "parser", "input"),
+	} {
+		path = filepath.Join(projectDir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("content"), 0o644))
+	}
+
+	bundle, err := os.CreateTemp("", "bundle-archive-")
+	require.NoError(t, err)
+	bufWriter := bufio.NewWriter(bundle)
+	archiveWriter := archive.NewTarArchiveWriter(bufWriter, true)
+
+	b := newJazzerJSBundler(&Opts{
+		Env:        []string{"FOO=foo"},
+		ProjectDir: projectDir,
+		OutputPath: bundle.Name(),
+	}, archiveWriter)
+	fuzzers, err := b.assembleArtifacts([]string{"parser", `parser:"parses headers"`})
+	require.NoError(t, err)
+
+	require.NoError(t, archiveWriter.Close())
+	require.NoError(t, bufWriter.Flush())
+	require.NoError(t, bundle.Close())
+
+	require.Len(t, fuzzers, 2)
+	require.Equal(t, "parser", fuzzers[0].Name)
+	require.Equal(t, "", fuzzers[0].Target)
+	require.Equal(t, filepath.Join("project", "test", "parser.fuzz.js"), fuzzers[0].Path)
+	require.Equal(t, "JAVASCRIPT_LIBFUZZER", fuzzers[0].Engine)
+	require.Equal(t, []string{"FOO=foo"}, fuzzers[0].EngineOptions.Env)
+	require.Equal(t, `parser:"parses headers"`, fuzzers[1].Name)
+	require.Equal(t, "parses headers", fuzzers[1].Target)
+
+	out := testutil.MkdirTemp(t, "", "bundler-test-*")
+	require.NoError(t, archive.Extract(bundle.Name(), out))
+	actualContents, err := listFilesRecursively(filepath.Join(out, "project"))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		".",
+		"node_modules",
+		filepath.Join("node_modules", "@jazzer.js"),
+		filepath.Join("node_modules", "@jazzer.js", "jest-runner"),
+		filepath.Join("node_modules", "@jazzer.js", "jest-runner", "index.js"),
+		filepath.Join("node_modules", "other"),
+		filepath.Join("node_modules", "other", "parser.fuzz.js"),
+		"package-lock.json",
+		"package.json",
+		"src",
+		filepath.Join("src", "parser.js"),
+		"test",
+		filepath.Join("test", "parser.fuzz.js"),
+	}, actualContents)
+}
+
+func TestFindTestFileNodeJS_NotFound(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, "", "bundle-project-*")
+	b := newJazzerJSBundler(&Opts{ProjectDir: projectDir}, &archive.NullArchiveWriter{})
+	_, err := b.findTestFile("missing")
+	require.Error(t, err)
+}
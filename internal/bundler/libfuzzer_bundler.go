@@ -4,10 +4,13 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 
@@ -21,14 +24,21 @@ import (
 	"code-intelligence.com/cifuzz/internal/build/cmake"
 	"code-intelligence.com/cifuzz/internal/build/other"
 	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/ldd"
 	"code-intelligence.com/cifuzz/pkg/dependencies"
 	"code-intelligence.com/cifuzz/pkg/log"
+	fuzzer_runner "code-intelligence.com/cifuzz/pkg/runner"
+	"code-intelligence.com/cifuzz/pkg/tracing"
 	"code-intelligence.com/cifuzz/util/envutil"
 	"code-intelligence.com/cifuzz/util/fileutil"
 	"code-intelligence.com/cifuzz/util/sliceutil"
 )
 
+// The path of the LeakSanitizer suppressions file in the bundle
+const lsanSuppressionsArchivePath = "lsan_suppressions.txt"
+
 type configureVariant struct {
 	Sanitizers []string
 }
@@ -52,6 +62,15 @@ func versionedLibraryRegexp(unversionedBasename string) *regexp.Regexp {
 type libfuzzerBundler struct {
 	opts          *Opts
 	archiveWriter archive.ArchiveWriter
+
+	// Maps the names of fuzz tests to the directory containing their
+	// minimized seed corpus
+	minimizedSeedCorpora map[string]string
+
+	// The shared libraries which were added to the runtime
+	// dependencies because the fuzz test executables are linked
+	// against them, but the build system didn't report them
+	sharedLibraryDeps map[string]bool
 }
 
 func newLibfuzzerBundler(opts *Opts, archiveWriter archive.ArchiveWriter) *libfuzzerBundler {
@@ -61,7 +80,7 @@ func newLibfuzzerBundler(opts *Opts, archiveWriter archive.ArchiveWriter) *libfu
 	if opts.BuildStdout == nil {
 		opts.BuildStdout = os.Stdout
 	}
-	return &libfuzzerBundler{opts, archiveWriter}
+	return &libfuzzerBundler{opts: opts, archiveWriter: archiveWriter, sharedLibraryDeps: map[string]bool{}}
 }
 
 func (b *libfuzzerBundler) bundle() ([]*archive.Fuzzer, error) {
@@ -70,18 +89,41 @@ func (b *libfuzzerBundler) bundle() ([]*archive.Fuzzer, error) {
 		return nil, err
 	}
 
+	span := tracing.Start("build")
 	buildResults, err := b.buildAllVariants()
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
 
+	if b.opts.MinimizeSeedCorpus {
+		span := tracing.Start("minimize seed corpus")
+		err = b.minimizeSeedCorpora(buildResults)
+		span.End(err)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	log.Info("Creating bundle...")
 
+	if b.opts.LSanSuppressions != "" {
+		log.Debugf("Adding LeakSanitizer suppressions %s", b.opts.LSanSuppressions)
+		err = b.archiveWriter.WriteFile(lsanSuppressionsArchivePath, b.opts.LSanSuppressions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Add all fuzz test artifacts to the archive. There will be one "Fuzzer" metadata object for each pair of fuzz test
 	// and Builder instance.
 	var fuzzers []*archive.Fuzzer
 	deduplicatedSystemDeps := make(map[string]struct{})
 	for _, buildResult := range buildResults {
+		err = b.addSharedLibraryDeps(buildResult)
+		if err != nil {
+			return nil, err
+		}
 		fuzzTestFuzzers, systemDeps, err := b.assembleArtifacts(buildResult)
 		if err != nil {
 			return nil, err
@@ -355,6 +397,57 @@ func (b *libfuzzerBundler) copyArtifactsToTempdir(buildResult *build.CBuildResul
 	return nil
 }
 
+// addSharedLibraryDeps adds the non-system shared libraries which the
+// fuzz test executable is linked against to its runtime dependencies,
+// if they are not already part of them. Not all build systems report
+// all of them, e.g. for Bazel, only the files in the runfiles directory
+// are runtime dependencies, so that libraries which are linked from
+// outside of the Bazel workspace would be missing on the runner.
+func (b *libfuzzerBundler) addSharedLibraryDeps(buildResult *build.CBuildResult) error {
+	// The ldd package only resolves the actual dependencies of the
+	// executable on these systems
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "freebsd" {
+		return nil
+	}
+
+	sharedLibraries, err := ldd.NonSystemSharedLibraries(buildResult.Executable)
+	if err != nil {
+		// The libraries could still be provided by the Docker image, so
+		// we don't fail the bundle
+		log.Warnf("Failed to determine the shared libraries of %s, the bundle might be missing some of them: %v",
+			buildResult.Executable, err)
+		return nil
+	}
+
+	// Runtime dependencies are often symlinks, e.g. to the libraries
+	// in the Bazel runfiles directory, so compare the resolved paths
+	resolvedDeps := make(map[string]bool)
+	for _, dep := range buildResult.RuntimeDeps {
+		resolvedDeps[resolveSymlinks(dep)] = true
+	}
+	for _, sharedLibrary := range sharedLibraries {
+		resolvedPath := resolveSymlinks(sharedLibrary)
+		if resolvedDeps[resolvedPath] {
+			continue
+		}
+		log.Debugf("Adding shared library %s which is not a runtime dependency reported by the build system", sharedLibrary)
+		resolvedDeps[resolvedPath] = true
+		buildResult.RuntimeDeps = append(buildResult.RuntimeDeps, sharedLibrary)
+		b.sharedLibraryDeps[sharedLibrary] = true
+	}
+	return nil
+}
+
+// resolveSymlinks returns the path with all symlinks resolved, or the
+// path itself if that fails
+func resolveSymlinks(path string) string {
+	resolvedPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+	return resolvedPath
+}
+
 func (b *libfuzzerBundler) checkDependencies() error {
 	var deps []dependencies.Key
 	switch b.opts.BuildSystem {
@@ -439,7 +532,12 @@ depsLoop:
 				return
 			}
 
-			if b.opts.BuildSystem == config.BuildSystemOther {
+			// The run paths of the fuzz test executable only contain
+			// the directories of the runtime dependencies reported by
+			// CMake and Bazel, so the directories of the shared
+			// libraries which were added in addSharedLibraryDeps must
+			// be added to the library search path
+			if b.opts.BuildSystem == config.BuildSystemOther || b.sharedLibraryDeps[dep] {
 				libraryPath := filepath.Join(buildArtifactsPrefix, filepath.Dir(buildDirRelPath))
 				if !sliceutil.Contains(libraryPaths, libraryPath) {
 					libraryPaths = append(libraryPaths, libraryPath)
@@ -511,22 +609,16 @@ depsLoop:
 		}
 	}
 
-	if b.opts.Dictionary == "" {
-		var exists bool
-		exists, err = fileutil.Exists(buildResult.Dictionary)
-		if err != nil {
-			return
-		}
-		if exists {
-			b.opts.Dictionary = buildResult.Dictionary
-		}
+	dict, err := b.dictionary(buildResult)
+	if err != nil {
+		return
 	}
 	// Add dictionary to archive
 	var archiveDict string
-	if b.opts.Dictionary != "" {
-		log.Debugf("Adding dictionary %s", b.opts.Dictionary)
+	if dict != "" {
+		log.Debugf("Adding dictionary %s", dict)
 		archiveDict = filepath.Join(fuzzTestPrefix(buildResult), "dict")
-		err = b.archiveWriter.WriteFile(archiveDict, b.opts.Dictionary)
+		err = b.archiveWriter.WriteFile(archiveDict, dict)
 		if err != nil {
 			return
 		}
@@ -535,7 +627,7 @@ depsLoop:
 	// Add seeds from user-specified seed corpus dirs (if any) and the
 	// default seed corpus (if it exists) to the seeds directory in the
 	// archive
-	seedCorpusDirs := b.opts.SeedCorpusDirs
+	seedCorpusDirs := b.seedCorpusDirs(buildResult.Name)
 	exists, err := fileutil.Exists(buildResult.SeedCorpus)
 	if err != nil {
 		return
@@ -544,11 +636,14 @@ depsLoop:
 		log.Debugf("Adding user-provided seeds to seed corpus from %s", seedCorpusDirs)
 		seedCorpusDirs = append([]string{buildResult.SeedCorpus}, seedCorpusDirs...)
 	}
+	if minimizedSeedCorpus, ok := b.minimizedSeedCorpora[buildResult.Name]; ok {
+		seedCorpusDirs = []string{minimizedSeedCorpus}
+	}
 	var archiveSeedsDir string
 	if len(seedCorpusDirs) > 0 {
 		archiveSeedsDir = filepath.Join(fuzzTestPrefix(buildResult), "seeds")
 
-		err = prepareSeeds(seedCorpusDirs, archiveSeedsDir, b.archiveWriter)
+		err = prepareSeeds(seedCorpusDirs, archiveSeedsDir, b.archiveWriter, b.opts.tempDir)
 		if err != nil {
 			return
 		}
@@ -560,6 +655,17 @@ depsLoop:
 	if err != nil {
 		return
 	}
+	if b.opts.LSanSuppressions != "" {
+		// Relative suppressions paths are resolved by the sanitizer
+		// runtime relative to the working directory, which is the root
+		// directory of the bundle.
+		lsanOptions := config.MergeSanitizerOptions(envutil.Getenv(env, "LSAN_OPTIONS"),
+			map[string]string{"suppressions": lsanSuppressionsArchivePath})
+		env, err = envutil.Setenv(env, "LSAN_OPTIONS", lsanOptions)
+		if err != nil {
+			return
+		}
+	}
 
 	baseFuzzerInfo := archive.Fuzzer{
 		Target:     buildResult.Name,
@@ -572,6 +678,7 @@ depsLoop:
 			Flags: b.opts.EngineArgs,
 		},
 		MaxRunTime: uint(b.opts.Timeout.Seconds()),
+		Owner:      config.FuzzTestOwner(b.opts.FuzzTestConfigs, buildResult.Name),
 	}
 
 	if externalLibrariesPrefix != "" {
@@ -579,6 +686,22 @@ depsLoop:
 	}
 	baseFuzzerInfo.LibraryPaths = libraryPaths
 
+	// Coverage builds are not instrumented for fuzzing and only run on
+	// the corpus, so the health checks only apply to fuzzing builds
+	if !isCoverageBuild(buildResult.Sanitizers) {
+		baseFuzzerInfo.Health, err = checkHealth(&healthCheckOptions{
+			fuzzTest:       buildResult.Name,
+			executable:     fuzzTestExecutableAbsPath,
+			sanitizers:     buildResult.Sanitizers,
+			dictionary:     archiveDict,
+			seedCorpusDirs: seedCorpusDirs,
+			engineArgs:     b.opts.EngineArgs,
+		})
+		if err != nil {
+			return
+		}
+	}
+
 	if isCoverageBuild(buildResult.Sanitizers) {
 		fuzzer := baseFuzzerInfo
 		fuzzer.Engine = "LLVM_COV"
@@ -607,8 +730,154 @@ depsLoop:
 	return
 }
 
+// minimizeSeedCorpora runs libFuzzer's merge mode on the seed corpora
+// of all fuzz tests to remove seeds which don't add any coverage. The
+// minimized seed corpora are used by assembleArtifacts instead of the
+// original seed corpus directories.
+func (b *libfuzzerBundler) minimizeSeedCorpora(buildResults []*build.CBuildResult) error {
+	b.minimizedSeedCorpora = make(map[string]string)
+
+	for _, buildResult := range buildResults {
+		// The merge mode uses the coverage feedback of the fuzzing
+		// instrumentation, which coverage builds don't have. The
+		// minimized seed corpus of the fuzzing build is also used for
+		// the coverage build.
+		if isCoverageBuild(buildResult.Sanitizers) {
+			continue
+		}
+		if _, ok := b.minimizedSeedCorpora[buildResult.Name]; ok {
+			continue
+		}
+
+		seedCorpusDirs := b.seedCorpusDirs(buildResult.Name)
+		exists, err := fileutil.Exists(buildResult.SeedCorpus)
+		if err != nil {
+			return err
+		}
+		if exists {
+			seedCorpusDirs = append([]string{buildResult.SeedCorpus}, seedCorpusDirs...)
+		}
+		if len(seedCorpusDirs) == 0 {
+			continue
+		}
+
+		outputDir := filepath.Join(b.opts.tempDir, "minimized-seeds", buildResult.Name, "seeds")
+		err = os.MkdirAll(outputDir, 0o755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		log.Infof("Minimizing seed corpus of %s", buildResult.Name)
+		err = b.runMerge(buildResult, outputDir, seedCorpusDirs)
+		if err != nil {
+			return err
+		}
+
+		numSeedsBefore, err := countFiles(seedCorpusDirs...)
+		if err != nil {
+			return err
+		}
+		numSeedsAfter, err := countFiles(outputDir)
+		if err != nil {
+			return err
+		}
+		log.Infof("Minimized seed corpus of %s from %d to %d seeds", buildResult.Name, numSeedsBefore, numSeedsAfter)
+
+		b.minimizedSeedCorpora[buildResult.Name] = outputDir
+	}
+
+	return nil
+}
+
+func (b *libfuzzerBundler) runMerge(buildResult *build.CBuildResult, outputDir string, seedCorpusDirs []string) error {
+	// libFuzzer emits crashing inputs in merge mode, which we don't
+	// want to end up in the project directory, so we write them to a
+	// temporary directory which is thrown away.
+	artifactsDir, err := os.MkdirTemp(b.opts.tempDir, "merge-artifacts-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	args := []string{"-merge=1", "-artifact_prefix=" + artifactsDir + "/", outputDir}
+	args = append(args, seedCorpusDirs...)
+
+	env, err := envutil.Copy(os.Environ(), b.opts.Env)
+	if err != nil {
+		return err
+	}
+	// Leaks and other findings in the seeds should not make the
+	// minimization fail, they will be reported by the fuzzing run.
+	// Other sanitizer options set by the user are kept.
+	env, err = fuzzer_runner.SetASANOptions(env, nil, map[string]string{"detect_leaks": "0"})
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS == "linux" {
+		libraryPaths, err := ldd.LibraryPaths(buildResult.Executable)
+		if err != nil {
+			return err
+		}
+		env, err = envutil.Setenv(env, "LD_LIBRARY_PATH", envutil.AppendToPathList(envutil.Getenv(env, "LD_LIBRARY_PATH"), libraryPaths...))
+		if err != nil {
+			return err
+		}
+	}
+
+	cmd := exec.Command(buildResult.Executable, args...)
+	cmd.Env = env
+	cmd.Stdout = b.opts.BuildStdout
+	cmd.Stderr = b.opts.BuildStderr
+	log.Debugf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		return cmdutils.WrapExecError(errors.WithStack(err), cmd)
+	}
+	return nil
+}
+
+func countFiles(dirs ...string) (int, error) {
+	var n int
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if !d.IsDir() {
+				n++
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
 // fuzzTestPrefix returns the path in the resulting artifact archive under which fuzz test specific files should be
 // added.
+// dictionary returns the dictionary of the fuzz test, which is the one
+// specified via --dict, the one from the fuzz test's settings in
+// cifuzz.yaml or the one of the build result, in that order, or an
+// empty string if there is none
+func (b *libfuzzerBundler) dictionary(buildResult *build.CBuildResult) (string, error) {
+	if b.opts.Dictionary != "" {
+		return b.opts.Dictionary, nil
+	}
+	fuzzTestConfig := config.FindFuzzTestConfig(b.opts.FuzzTestConfigs, buildResult.Name)
+	if fuzzTestConfig != nil && fuzzTestConfig.Dict != "" {
+		return fuzzTestConfig.AbsDict(b.opts.ProjectDir), nil
+	}
+	exists, err := fileutil.Exists(buildResult.Dictionary)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return buildResult.Dictionary, nil
+	}
+	return "", nil
+}
+
 func fuzzTestPrefix(buildResult *build.CBuildResult) string {
 	sanitizerSegment := strings.Join(buildResult.Sanitizers, "+")
 	if sanitizerSegment == "" {
@@ -645,3 +914,15 @@ func sha256sum(filename string) (string, error) {
 
 	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
+
+// seedCorpusDirs returns the user-specified seed corpus dirs of the fuzz
+// test, which are the global ones and the ones from the config of the
+// fuzz test
+func (b *libfuzzerBundler) seedCorpusDirs(fuzzTest string) []string {
+	seedCorpusDirs := b.opts.SeedCorpusDirs
+	fuzzTestConfig := config.FindFuzzTestConfig(b.opts.FuzzTestConfigs, fuzzTest)
+	if fuzzTestConfig != nil {
+		seedCorpusDirs = append(slices.Clone(seedCorpusDirs), fuzzTestConfig.AbsSeedCorpusDirs(b.opts.ProjectDir)...)
+	}
+	return seedCorpusDirs
+}
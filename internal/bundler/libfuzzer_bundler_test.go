@@ -2,6 +2,7 @@ package bundler
 
 import (
 	"bufio"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 
 	"code-intelligence.com/cifuzz/internal/build"
 	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/internal/testutil"
 	"code-intelligence.com/cifuzz/pkg/log"
 )
@@ -80,6 +82,15 @@ func TestAssembleArtifacts_Fuzzing(t *testing.T) {
 		Dictionary:    filepath.Join("libfuzzer", "address", "some_fuzz_test", "dict"),
 		LibraryPaths:  []string{filepath.Join("libfuzzer", "address", "some_fuzz_test", "external_libs")},
 		EngineOptions: archive.EngineOptions{Env: []string{"FOO=foo", "NO_CIFUZZ=1"}},
+		Health: []*archive.HealthCheck{
+			{
+				Name:    "instrumented",
+				Passed:  false,
+				Message: "The fuzz test executable is not instrumented for: fuzzer, address. Make sure that the build flags of cifuzz are not overridden by the build system.",
+			},
+			{Name: "dictionary", Passed: true},
+			{Name: "seeds", Passed: true},
+		},
 	}, *fuzzers[0])
 
 	if runtime.GOOS != "windows" {
@@ -141,3 +152,123 @@ func TestAssembleArtifacts_Fuzzing(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expectedContents, actualContents)
 }
+
+func TestDictionary(t *testing.T) {
+	projectDir := t.TempDir()
+	buildDict := filepath.Join(projectDir, "build.dict")
+	require.NoError(t, os.WriteFile(buildDict, []byte("\"foo\"\n"), 0o644))
+
+	buildResult := func(name string) *build.CBuildResult {
+		return &build.CBuildResult{
+			Name:        name,
+			BuildResult: &build.BuildResult{Dictionary: buildDict},
+		}
+	}
+	opts := &Opts{
+		ProjectDir: projectDir,
+		FuzzTestConfigs: []*config.FuzzTestConfig{
+			{Name: "with_dict", Dict: "config.dict"},
+		},
+	}
+	b := newLibfuzzerBundler(opts, nil)
+
+	// The dictionary of one fuzz test must not be used for the
+	// following fuzz tests
+	dict, err := b.dictionary(buildResult("with_dict"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(projectDir, "config.dict"), dict)
+	dict, err = b.dictionary(buildResult("without_dict"))
+	require.NoError(t, err)
+	assert.Equal(t, buildDict, dict)
+	assert.Empty(t, opts.Dictionary)
+
+	// --dict takes precedence over the settings in cifuzz.yaml
+	opts.Dictionary = filepath.Join(projectDir, "flag.dict")
+	dict, err = b.dictionary(buildResult("with_dict"))
+	require.NoError(t, err)
+	assert.Equal(t, opts.Dictionary, dict)
+}
+
+// A fake fuzz test which keeps the first seed in merge mode and
+// records the ASAN_OPTIONS it was run with
+const fakeMergeFuzzer = `#!/bin/sh
+echo "$ASAN_OPTIONS" > "$(dirname "$0")/asan_options"
+# The arguments are -merge=1, -artifact_prefix, the output directory
+# and the seed corpus directories
+shift 2
+cp "$2/first" "$1/"
+`
+
+func TestMinimizeSeedCorpora(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The fake fuzz test is a shell script")
+	}
+	t.Setenv("ASAN_OPTIONS", "allocator_may_return_null=1")
+
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "my_fuzz_test")
+	require.NoError(t, os.WriteFile(executable, []byte(fakeMergeFuzzer), 0o755))
+	seedCorpus := filepath.Join(dir, "seeds")
+	require.NoError(t, os.Mkdir(seedCorpus, 0o755))
+	for _, seed := range []string{"first", "second"} {
+		require.NoError(t, os.WriteFile(filepath.Join(seedCorpus, seed), []byte(seed), 0o644))
+	}
+
+	b := newLibfuzzerBundler(&Opts{tempDir: testutil.MkdirTemp(t, "", "bundle-*")}, nil)
+	err := b.minimizeSeedCorpora([]*build.CBuildResult{
+		{
+			Name:        "my_fuzz_test",
+			BuildResult: &build.BuildResult{Executable: executable, SeedCorpus: seedCorpus},
+			Sanitizers:  []string{"address"},
+		},
+		{
+			// Coverage builds are not run in merge mode
+			Name:        "my_fuzz_test",
+			BuildResult: &build.BuildResult{Executable: filepath.Join(dir, "does_not_exist"), SeedCorpus: seedCorpus},
+			Sanitizers:  []string{"coverage"},
+		},
+	})
+	require.NoError(t, err)
+
+	minimized, ok := b.minimizedSeedCorpora["my_fuzz_test"]
+	require.True(t, ok)
+	seeds, err := os.ReadDir(minimized)
+	require.NoError(t, err)
+	require.Len(t, seeds, 1)
+	assert.Equal(t, "first", seeds[0].Name())
+
+	// The sanitizer options of the user are kept
+	asanOptions, err := os.ReadFile(filepath.Join(dir, "asan_options"))
+	require.NoError(t, err)
+	assert.Contains(t, string(asanOptions), "allocator_may_return_null=1")
+	assert.Contains(t, string(asanOptions), "detect_leaks=0")
+}
+
+func TestAssembleArtifacts_SharedLibraryDeps(t *testing.T) {
+	projectDir, err := filepath.Abs(filepath.Join("testdata", "libfuzzer", "project"))
+	require.NoError(t, err)
+	buildDir := filepath.Join(projectDir, "build")
+	helperLib := filepath.Join(buildDir, "lib", "helper.so")
+
+	archiveWriter := archive.NewTarArchiveWriter(io.Discard, false)
+	t.Cleanup(func() { archiveWriter.Close() })
+	b := newLibfuzzerBundler(&Opts{tempDir: testutil.MkdirTemp(t, "", "bundle-*")}, archiveWriter)
+	// The library was found in the executable instead of being
+	// reported by the build system
+	b.sharedLibraryDeps[helperLib] = true
+
+	buildResult := &build.CBuildResult{
+		Name:       "some_fuzz_test",
+		Sanitizers: []string{"address"},
+		ProjectDir: projectDir,
+		BuildResult: &build.BuildResult{
+			Executable:  filepath.Join(buildDir, "some_fuzz_test"),
+			BuildDir:    buildDir,
+			RuntimeDeps: []string{helperLib},
+		},
+	}
+	fuzzers, _, err := b.assembleArtifacts(buildResult)
+	require.NoError(t, err)
+	require.Len(t, fuzzers, 1)
+	assert.Equal(t, []string{filepath.Join("libfuzzer", "address", "some_fuzz_test", "bin", "lib")}, fuzzers[0].LibraryPaths)
+}
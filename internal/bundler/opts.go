@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -30,12 +31,22 @@ type Opts struct {
 	ProjectDir      string        `mapstructure:"project-dir"`
 	ConfigDir       string        `mapstructure:"config-dir"`
 	AdditionalFiles []string      `mapstructure:"add"`
+	AddFiles        []*AddFiles   `mapstructure:"add-files"`
+	Labels          []string      `mapstructure:"labels"`
+	LabelArgs       []string      `mapstructure:"label"`
+
+	MinimizeSeedCorpus bool   `mapstructure:"minimize-seed-corpus"`
+	LSanSuppressions   string `mapstructure:"lsan-suppressions"`
+	Reproducible       bool   `mapstructure:"reproducible"`
+
+	FuzzTestConfigs []*config.FuzzTestConfig `mapstructure:"fuzz-tests"`
 
 	// Fields which are not configurable via viper (i.e. via cifuzz.yaml
 	// and CIFUZZ_* environment variables), by setting
 	// mapstructure:"-"
 	FuzzTests       []string  `mapstructure:"-"`
 	OutputPath      string    `mapstructure:"-"`
+	Split           bool      `mapstructure:"-"`
 	BuildSystemArgs []string  `mapstructure:"-"`
 	ContainerArgs   []string  `mapstructure:"-"`
 	Stdout          io.Writer `mapstructure:"-"`
@@ -49,6 +60,20 @@ type Opts struct {
 	BundleBuildLogFile    string
 }
 
+// AddFiles is an entry of the "add-files" section of cifuzz.yaml, which
+// specifies files which are added to the bundle, e.g. data files or
+// configs which the fuzz tests read at runtime.
+type AddFiles struct {
+	// A file, directory or glob pattern, which supports "**" to match
+	// any number of directories. Relative paths are relative to the
+	// project directory.
+	Source string `mapstructure:"source"`
+	// The directory in the bundle to which the files are added, the
+	// work_dir by default. The files keep their path relative to the
+	// part of the pattern before the first wildcard.
+	Dest string `mapstructure:"dest"`
+}
+
 func (opts *Opts) Validate() error {
 	var err error
 
@@ -68,6 +93,39 @@ func (opts *Opts) Validate() error {
 		}
 	}
 
+	for _, addFiles := range opts.AddFiles {
+		if addFiles == nil || addFiles.Source == "" {
+			msg := "Entries of the \"add-files\" setting must specify a source"
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+		if filepath.IsAbs(addFiles.Dest) {
+			msg := fmt.Sprintf("The dest %q of the \"add-files\" setting has to be a relative path", addFiles.Dest)
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+		if dest := filepath.ToSlash(filepath.Clean(addFiles.Dest)); dest == ".." || strings.HasPrefix(dest, "../") {
+			msg := fmt.Sprintf("The dest %q of the \"add-files\" setting points outside of the bundle", addFiles.Dest)
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+	}
+
+	for _, label := range append(append([]string{}, opts.Labels...), opts.LabelArgs...) {
+		key, _, found := strings.Cut(label, "=")
+		if !found || strings.TrimSpace(key) == "" {
+			msg := fmt.Sprintf("Invalid label %q, labels must have the format <key>=<value>", label)
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+	}
+
+	if opts.LSanSuppressions != "" {
+		// The path in cifuzz.yaml is relative to the config dir
+		opts.LSanSuppressions = config.ResolvePath(opts.ConfigDir, opts.LSanSuppressions)
+		// Check if the suppressions file exists and can be accessed
+		_, err = os.Stat(opts.LSanSuppressions)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to access LeakSanitizer suppressions file %s", opts.LSanSuppressions)
+		}
+	}
+
 	if opts.BuildSystem == config.BuildSystemBazel {
 		// We don't support building a bundle with bazel without any
 		// specified fuzz tests
@@ -126,7 +184,12 @@ system type "other"`
 		// Use the variable with the value from the current environment
 		env = append(env, fmt.Sprintf("%s=%s", e, os.Getenv(e)))
 	}
-	opts.Env = env
+	// Add the sanitizer runtime options from cifuzz.yaml, so that
+	// they are also used when the fuzz tests are executed remotely
+	opts.Env, err = config.SetSanitizerOptionsEnv(env)
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -0,0 +1,188 @@
+package bundler
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/options"
+)
+
+// The path of the replayer in the bundle
+const replayScriptName = "replay.sh"
+
+//go:embed replay.sh.tmpl
+var replayScriptTemplate string
+
+// The libFuzzer flags which are passed to the fuzzers when replaying
+// inputs. Other flags only affect fuzzing or, like -runs, change how
+// often the inputs are executed.
+var replayFlagPrefixes = []string{"-timeout=", "-rss_limit_mb=", "-malloc_limit_mb="}
+
+type replayFuzzTest struct {
+	Name string
+	// A file which only exists if the fuzz test is in the bundle, which
+	// is not the case for all fuzz tests in split bundles
+	Marker string
+	Runs   []*replayRun
+}
+
+type replayRun struct {
+	Description string
+	Seeds       string
+	// The shell-quoted command which replays an input
+	Command string
+}
+
+// createReplayScriptInArchive adds a shell script to the bundle which
+// executes the seed inputs of the fuzzers without fuzzing, so that the
+// bundle can be used to run regression tests in environments in which
+// fuzzing is not allowed. Fuzzers of engines which can't replay inputs
+// via the command line are not included.
+func (b *Bundler) createReplayScriptInArchive(fuzzers []*archive.Fuzzer, archiveWriter archive.ArchiveWriter) error {
+	fuzzTests := replayFuzzTests(fuzzers)
+	if len(fuzzTests) == 0 {
+		log.Debugf("Not adding %s to the bundle, none of the fuzzers can be replayed", replayScriptName)
+		return nil
+	}
+
+	script, err := replayScript(fuzzTests)
+	if err != nil {
+		return err
+	}
+	scriptPath := filepath.Join(b.opts.tempDir, replayScriptName)
+	err = os.WriteFile(scriptPath, []byte(script), 0o755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s", replayScriptName)
+	}
+	return archiveWriter.WriteFile(replayScriptName, scriptPath)
+}
+
+func replayScript(fuzzTests []*replayFuzzTest) (string, error) {
+	t, err := template.New(replayScriptName).Funcs(template.FuncMap{"quote": shellQuote}).Parse(replayScriptTemplate)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	var script strings.Builder
+	err = t.Execute(&script, map[string]any{"FuzzTests": fuzzTests})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return script.String(), nil
+}
+
+// replayFuzzTests returns the fuzz tests with the commands which replay
+// their inputs, in the order of the fuzzers
+func replayFuzzTests(fuzzers []*archive.Fuzzer) []*replayFuzzTest {
+	var fuzzTests []*replayFuzzTest
+	byName := make(map[string]*replayFuzzTest)
+	for _, fuzzer := range fuzzers {
+		var run *replayRun
+		var marker string
+		switch fuzzer.Engine {
+		case "LIBFUZZER":
+			run = &replayRun{
+				Description: fuzzer.Sanitizer,
+				Command:     libfuzzerReplayCommand(fuzzer),
+			}
+			marker = fuzzer.Path
+		case "JAVA_LIBFUZZER":
+			if len(fuzzer.RuntimePaths) == 0 {
+				continue
+			}
+			run = &replayRun{
+				Description: "Jazzer",
+				Command:     jazzerReplayCommand(fuzzer),
+			}
+			// The manifest jar of the fuzz test
+			marker = fuzzer.RuntimePaths[0]
+		default:
+			// Coverage fuzzers don't replay the inputs with sanitizers and
+			// Jazzer.js fuzz tests are run via Jest, which requires the
+			// Node.js project
+			continue
+		}
+		run.Seeds = filepath.ToSlash(fuzzer.Seeds)
+
+		// libFuzzer fuzzers are identified by the target, Jazzer fuzzers
+		// by the name
+		name := fuzzer.Target
+		if name == "" {
+			name = fuzzer.Name
+		}
+		fuzzTest, ok := byName[name]
+		if !ok {
+			fuzzTest = &replayFuzzTest{Name: name, Marker: filepath.ToSlash(marker)}
+			byName[name] = fuzzTest
+			fuzzTests = append(fuzzTests, fuzzTest)
+		}
+		fuzzTest.Runs = append(fuzzTest.Runs, run)
+	}
+	return fuzzTests
+}
+
+func libfuzzerReplayCommand(fuzzer *archive.Fuzzer) string {
+	args := []string{"env"}
+	if len(fuzzer.LibraryPaths) > 0 {
+		args = append(args, "LD_LIBRARY_PATH="+joinPaths(fuzzer.LibraryPaths))
+	}
+	args = append(args, fuzzer.EngineOptions.Env...)
+	args = append(args, "./"+filepath.ToSlash(fuzzer.Path))
+	args = append(args, replayFlags(fuzzer.EngineOptions.Flags)...)
+	return shellQuoteArgs(args)
+}
+
+func jazzerReplayCommand(fuzzer *archive.Fuzzer) string {
+	args := []string{"env"}
+	args = append(args, fuzzer.EngineOptions.Env...)
+	args = append(args, "java", "-cp", joinPaths(fuzzer.RuntimePaths), options.JazzerMainClass)
+	targetClass, targetMethod := cmdutils.SeparateTargetClassAndMethod(fuzzer.Name)
+	args = append(args, options.JazzerTargetClassFlag(targetClass))
+	if targetMethod != "" {
+		args = append(args, options.JazzerTargetMethodFlag(targetMethod))
+	}
+	args = append(args, replayFlags(fuzzer.EngineOptions.Flags)...)
+	return shellQuoteArgs(args)
+}
+
+func replayFlags(flags []string) []string {
+	var result []string
+	for _, flag := range flags {
+		for _, prefix := range replayFlagPrefixes {
+			if strings.HasPrefix(flag, prefix) {
+				result = append(result, flag)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func joinPaths(paths []string) string {
+	var slashPaths []string
+	for _, path := range paths {
+		slashPaths = append(slashPaths, filepath.ToSlash(path))
+	}
+	return strings.Join(slashPaths, ":")
+}
+
+func shellQuoteArgs(args []string) string {
+	var quoted []string
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(s, "'", `'\''`))
+}
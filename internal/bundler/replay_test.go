@@ -0,0 +1,101 @@
+package bundler
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestReplayScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The replayer is a POSIX shell script")
+	}
+	bundleDir := testutil.MkdirTemp(t, "", "bundle-")
+
+	// The fake fuzzer fails on inputs containing "crash" and records
+	// its arguments and environment
+	fakeFuzzer := `#!/bin/sh
+echo "$FAKE_ENV $*" >> ` + filepath.Join(bundleDir, "calls") + `
+for arg; do
+  case "$arg" in
+    -*) ;;
+    *) if grep -q crash "$arg"; then exit 1; fi ;;
+  esac
+done
+`
+	for _, name := range []string{"passing", "failing"} {
+		dir := filepath.Join(bundleDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "seeds", "a"), 0o755))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", name), []byte(fakeFuzzer), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "seeds", "a", "input"), []byte("ok"), 0o644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(bundleDir, "failing", "seeds", "crash"), []byte("crash"), 0o644))
+
+	fuzzers := []*archive.Fuzzer{
+		libfuzzerReplayFuzzer("passing"),
+		libfuzzerReplayFuzzer("failing"),
+		{Target: "passing", Engine: "LLVM_COV", Path: "passing/bin/passing"},
+		// Not in the bundle, like the fuzzers of other fuzz tests in
+		// split bundles
+		libfuzzerReplayFuzzer("missing"),
+		{Name: "jazzerjs", Engine: "JAVASCRIPT_LIBFUZZER"},
+	}
+	fuzzTests := replayFuzzTests(fuzzers)
+	require.Len(t, fuzzTests, 3)
+	script, err := replayScript(fuzzTests)
+	require.NoError(t, err)
+	scriptPath := filepath.Join(bundleDir, replayScriptName)
+	require.NoError(t, os.WriteFile(scriptPath, []byte(script), 0o755))
+
+	replay := func(args ...string) (string, int) {
+		out, err := exec.Command(scriptPath, args...).CombinedOutput()
+		var exitErr *exec.ExitError
+		if err != nil {
+			require.ErrorAs(t, err, &exitErr)
+			return string(out), exitErr.ExitCode()
+		}
+		return string(out), 0
+	}
+
+	out, exitCode := replay("passing")
+	assert.Equal(t, 0, exitCode, out)
+	assert.Contains(t, out, "PASSED: passing")
+	calls, err := os.ReadFile(filepath.Join(bundleDir, "calls"))
+	require.NoError(t, err)
+	assert.Equal(t, "bar -timeout=25 passing/seeds/a/input\n", string(calls))
+
+	// All fuzz tests in the bundle are replayed by default
+	out, exitCode = replay()
+	assert.Equal(t, 1, exitCode, out)
+	assert.Contains(t, out, "PASSED: passing")
+	assert.Contains(t, out, "FAILED: failing")
+	assert.NotContains(t, out, "missing")
+
+	out, exitCode = replay("missing")
+	assert.Equal(t, 2, exitCode, out)
+	assert.Contains(t, out, "Fuzz test missing is not in this bundle")
+}
+
+func libfuzzerReplayFuzzer(name string) *archive.Fuzzer {
+	return &archive.Fuzzer{
+		Name:         name,
+		Engine:       "LIBFUZZER",
+		Sanitizer:    "ADDRESS",
+		Path:         name + "/bin/" + name,
+		Seeds:        name + "/seeds",
+		LibraryPaths: []string{name + "/lib"},
+		EngineOptions: archive.EngineOptions{
+			Env:   []string{"FAKE_ENV=bar"},
+			Flags: []string{"-runs=100", "-timeout=25"},
+		},
+	}
+}
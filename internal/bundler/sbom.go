@@ -0,0 +1,325 @@
+package bundler
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/version"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// The name of the CycloneDX SBOM inside the fuzzing artifact archive
+const sbomFileName = "sbom.cdx.json"
+
+var (
+	// e.g. "node_modules/@types/node/package.json", but not
+	// "node_modules/foo/lib/package.json"
+	npmPackageRegex = regexp.MustCompile(`(^|/)node_modules/((@[^/]+/)?[^/@]+)/package\.json$`)
+	// e.g. "libfoo.so", "libfoo.so.1.2" or "libfoo.1.2.dylib"
+	sharedLibraryRegex = regexp.MustCompile(`^(.+?)(\.so((\.\d+)*)|((\.\d+)*)\.dylib)$`)
+	// e.g. "commons-lang3-3.12.0.jar"
+	jarFileNameRegex = regexp.MustCompile(`^(.+?)-(\d[^-]*(-.+)?)\.jar$`)
+)
+
+// The subset of the CycloneDX 1.5 JSON format which is used for the
+// SBOM of a bundle, see https://cyclonedx.org/docs/1.5/json/
+type cycloneDXBOM struct {
+	BOMFormat   string                `json:"bomFormat"`
+	SpecVersion string                `json:"specVersion"`
+	Version     int                   `json:"version"`
+	Metadata    *cycloneDXMetadata    `json:"metadata"`
+	Components  []*cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string              `json:"timestamp"`
+	Tools     *cycloneDXTools     `json:"tools"`
+	Component *cycloneDXComponent `json:"component,omitempty"`
+}
+
+type cycloneDXTools struct {
+	Components []*cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	Type       string               `json:"type"`
+	BOMRef     string               `json:"bom-ref,omitempty"`
+	Group      string               `json:"group,omitempty"`
+	Name       string               `json:"name"`
+	Version    string               `json:"version,omitempty"`
+	PURL       string               `json:"purl,omitempty"`
+	Hashes     []*cycloneDXHash     `json:"hashes,omitempty"`
+	Properties []*cycloneDXProperty `json:"properties,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// createSBOMInArchive adds a CycloneDX SBOM of the jars, shared
+// libraries and npm packages which were added to the archive
+func (b *Bundler) createSBOMInArchive(archiveWriter archive.ArchiveWriter, timestamp time.Time) error {
+	bom, err := b.createSBOM(archiveWriter, timestamp)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	sbomPath := filepath.Join(b.opts.tempDir, sbomFileName)
+	err = os.WriteFile(sbomPath, content, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s", sbomFileName)
+	}
+	log.Debugf("Adding SBOM with %d components to the bundle", len(bom.Components))
+	return archiveWriter.WriteFile(sbomFileName, sbomPath)
+}
+
+func (b *Bundler) createSBOM(archiveWriter archive.ArchiveWriter, timestamp time.Time) (*cycloneDXBOM, error) {
+	bom := &cycloneDXBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Metadata: &cycloneDXMetadata{
+			Timestamp: timestamp.UTC().Format(time.RFC3339),
+			Tools: &cycloneDXTools{
+				Components: []*cycloneDXComponent{{
+					Type:    "application",
+					Name:    "cifuzz",
+					Version: version.Version,
+				}},
+			},
+		},
+		Components: []*cycloneDXComponent{},
+	}
+	if b.opts.ProjectDir != "" {
+		bom.Metadata.Component = &cycloneDXComponent{
+			Type: "application",
+			Name: filepath.Base(b.opts.ProjectDir),
+		}
+	}
+
+	for _, header := range archiveWriter.Headers() {
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		sourcePath := archiveWriter.GetSourcePath(header.Name)
+		if sourcePath == "" {
+			continue
+		}
+		// Files which were created by cifuzz, e.g. the manifest jar,
+		// are not dependencies
+		if b.opts.tempDir != "" {
+			absSourcePath, err := filepath.Abs(sourcePath)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			isBelow, err := fileutil.IsBelow(absSourcePath, b.opts.tempDir)
+			if err != nil {
+				return nil, err
+			}
+			if isBelow {
+				continue
+			}
+		}
+
+		component, err := sbomComponent(header.Name, sourcePath)
+		if err != nil {
+			return nil, err
+		}
+		if component == nil {
+			continue
+		}
+
+		hash, err := sha256sum(sourcePath)
+		if err != nil {
+			return nil, err
+		}
+		component.BOMRef = header.Name
+		component.Hashes = []*cycloneDXHash{{Algorithm: "SHA-256", Content: hash}}
+		component.Properties = []*cycloneDXProperty{{Name: "cifuzz:bundle:path", Value: header.Name}}
+		bom.Components = append(bom.Components, component)
+	}
+
+	// The headers are in the order in which the files were added,
+	// which isn't necessarily the same for the same inputs
+	sort.Slice(bom.Components, func(i, j int) bool {
+		return bom.Components[i].BOMRef < bom.Components[j].BOMRef
+	})
+	return bom, nil
+}
+
+// sbomComponent returns the component of the file at the given path in
+// the archive, or nil if the file is not a dependency
+func sbomComponent(archivePath string, sourcePath string) (*cycloneDXComponent, error) {
+	fileName := path.Base(archivePath)
+
+	if npmPackageRegex.MatchString(archivePath) {
+		return npmComponent(sourcePath)
+	}
+
+	if strings.HasSuffix(fileName, ".jar") {
+		return jarComponent(fileName, sourcePath)
+	}
+
+	if match := sharedLibraryRegex.FindStringSubmatch(fileName); match != nil {
+		// The version is part of either the ".so" or the ".dylib"
+		// suffix
+		libVersion := strings.TrimPrefix(match[3]+match[5], ".")
+		return &cycloneDXComponent{
+			Type:    "library",
+			Name:    match[1],
+			Version: libVersion,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func npmComponent(packageJSONPath string) (*cycloneDXComponent, error) {
+	content, err := os.ReadFile(packageJSONPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var packageJSON struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	err = json.Unmarshal(content, &packageJSON)
+	if err != nil || packageJSON.Name == "" {
+		// Not every package.json in node_modules describes a package,
+		// e.g. test fixtures of packages
+		log.Debugf("Skipping %s in SBOM: not a valid package.json", packageJSONPath)
+		return nil, nil
+	}
+
+	component := &cycloneDXComponent{Type: "library", Version: packageJSON.Version}
+	purlName := url.PathEscape(packageJSON.Name)
+	if scope, name, found := strings.Cut(packageJSON.Name, "/"); found {
+		component.Group = scope
+		component.Name = name
+		// The "@" of the scope has to be percent-encoded in purls
+		purlName = "%40" + url.PathEscape(strings.TrimPrefix(scope, "@")) + "/" + url.PathEscape(name)
+	} else {
+		component.Name = packageJSON.Name
+	}
+	component.PURL = "pkg:npm/" + purlName
+	if packageJSON.Version != "" {
+		component.PURL += "@" + url.PathEscape(packageJSON.Version)
+	}
+	return component, nil
+}
+
+// jarComponent returns the component of a jar, which is identified by
+// the Maven coordinates in the pom.properties of the jar, if it
+// contains one, or by the file name otherwise
+func jarComponent(fileName string, jarPath string) (*cycloneDXComponent, error) {
+	coordinates, err := mavenCoordinates(fileName, jarPath)
+	if err != nil {
+		return nil, err
+	}
+	if coordinates != nil {
+		return &cycloneDXComponent{
+			Type:    "library",
+			Group:   coordinates["groupId"],
+			Name:    coordinates["artifactId"],
+			Version: coordinates["version"],
+			PURL: fmt.Sprintf("pkg:maven/%s/%s@%s",
+				url.PathEscape(coordinates["groupId"]),
+				url.PathEscape(coordinates["artifactId"]),
+				url.PathEscape(coordinates["version"])),
+		}, nil
+	}
+
+	component := &cycloneDXComponent{Type: "library", Name: strings.TrimSuffix(fileName, ".jar")}
+	if match := jarFileNameRegex.FindStringSubmatch(fileName); match != nil {
+		component.Name = match[1]
+		component.Version = match[2]
+	}
+	return component, nil
+}
+
+// mavenCoordinates returns the properties of the pom.properties file in
+// the jar. Jars which bundle their dependencies contain the
+// pom.properties of each, in which case the one matching the file name
+// is used.
+func mavenCoordinates(fileName string, jarPath string) (map[string]string, error) {
+	r, err := zip.OpenReader(jarPath)
+	if err != nil {
+		// Not all files with a .jar extension are valid zip files
+		log.Debugf("Failed to open %s: %v", jarPath, err)
+		return nil, nil
+	}
+	defer r.Close()
+
+	var candidates []map[string]string
+	for _, f := range r.File {
+		if !strings.HasPrefix(f.Name, "META-INF/maven/") || path.Base(f.Name) != "pom.properties" {
+			continue
+		}
+		properties, err := readProperties(f)
+		if err != nil {
+			return nil, err
+		}
+		if properties["groupId"] == "" || properties["artifactId"] == "" || properties["version"] == "" {
+			continue
+		}
+		if strings.HasPrefix(fileName, properties["artifactId"]+"-"+properties["version"]) {
+			return properties, nil
+		}
+		candidates = append(candidates, properties)
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	return nil, nil
+}
+
+// readProperties parses a Java properties file, without support for
+// line continuations and escape sequences, which are not used in
+// pom.properties files
+func readProperties(f *zip.File) (map[string]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer rc.Close()
+
+	properties := map[string]string{}
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		properties[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return properties, errors.WithStack(scanner.Err())
+}
@@ -0,0 +1,73 @@
+package bundler
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestCreateSBOM(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, "", "sbom-test-*")
+	writeFile := func(relPath string, content string) {
+		path := filepath.Join(projectDir, relPath)
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		require.NoError(t, err)
+		err = os.WriteFile(path, []byte(content), 0o644)
+		require.NoError(t, err)
+	}
+	writeFile(filepath.Join("node_modules", "@types", "node", "package.json"), `{"name": "@types/node", "version": "20.4.2"}`)
+	writeFile(filepath.Join("node_modules", "foo", "package.json"), `{"name": "foo", "version": "1.0.0"}`)
+	// Not the package.json of a package
+	writeFile(filepath.Join("node_modules", "foo", "lib", "package.json"), `{"type": "module"}`)
+	writeFile(filepath.Join("lib", "libbar.so.1.2"), "")
+	writeFile(filepath.Join("lib", "not-a-zip-1.2.3.jar"), "")
+	writeFile("fuzz_test", "")
+
+	// A jar with Maven coordinates
+	jarPath := filepath.Join(projectDir, "lib", "renamed.jar")
+	jarFile, err := os.Create(jarPath)
+	require.NoError(t, err)
+	zipWriter := zip.NewWriter(jarFile)
+	w, err := zipWriter.Create("META-INF/maven/org.example/example-lib/pom.properties")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("#Generated by Maven\ngroupId=org.example\nartifactId=example-lib\nversion=2.0.1\n"))
+	require.NoError(t, err)
+	require.NoError(t, zipWriter.Close())
+	require.NoError(t, jarFile.Close())
+
+	archiveWriter := archive.NewTarArchiveWriter(io.Discard, false)
+	t.Cleanup(func() { archiveWriter.Close() })
+	err = archiveWriter.WriteDir("project", projectDir)
+	require.NoError(t, err)
+
+	b := New(&Opts{ProjectDir: projectDir})
+	bom, err := b.createSBOM(archiveWriter, time.Unix(0, 0))
+	require.NoError(t, err)
+
+	assert.Equal(t, "CycloneDX", bom.BOMFormat)
+	assert.Equal(t, "1970-01-01T00:00:00Z", bom.Metadata.Timestamp)
+
+	type component struct{ group, name, version, purl string }
+	var components []component
+	for _, c := range bom.Components {
+		components = append(components, component{c.Group, c.Name, c.Version, c.PURL})
+		require.Len(t, c.Hashes, 1)
+		assert.Equal(t, "SHA-256", c.Hashes[0].Algorithm)
+	}
+	assert.Equal(t, []component{
+		{"", "libbar", "1.2", ""},
+		{"", "not-a-zip", "1.2.3", ""},
+		{"org.example", "example-lib", "2.0.1", "pkg:maven/org.example/example-lib@2.0.1"},
+		{"@types", "node", "20.4.2", "pkg:npm/%40types/node@20.4.2"},
+		{"", "foo", "1.0.0", "pkg:npm/foo@1.0.0"},
+	}, components)
+}
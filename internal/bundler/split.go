@@ -0,0 +1,185 @@
+package bundler
+
+import (
+	"archive/tar"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+)
+
+// Characters which are replaced in the file names of split bundles
+var unsafeFileNameCharsRegex = regexp.MustCompile(`[^\w.+-]+`)
+
+// BundleSplit creates one bundle per fuzz test instead of a single
+// bundle, so that the bundles can be uploaded in parallel and each fuzz
+// test can be scheduled separately. The bundles are created in the
+// directory specified as the output path, or in the current working
+// directory. It returns the paths of the created bundles.
+func (b *Bundler) BundleSplit() ([]string, error) {
+	outputDir := b.opts.OutputPath
+	if outputDir == "" {
+		outputDir = "."
+	}
+	err := os.MkdirAll(outputDir, 0o755)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "cifuzz-bundle-split-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tempDir)
+
+	b.opts.OutputPath = filepath.Join(tempDir, "fuzz_tests.tar.gz")
+	bundlePath, err := b.Bundle()
+	if err != nil {
+		return nil, err
+	}
+	return splitBundle(bundlePath, outputDir)
+}
+
+// splitBundle creates one bundle per fuzz test in outputDir from the
+// entries of the given bundle. The bundle of a fuzz test contains its
+// fuzzers for all engines and sanitizers, the files referenced by them
+// and all files which are not referenced by any fuzzer, like the
+// work_dir and the files added via the "add-files" setting.
+func splitBundle(bundlePath string, outputDir string) ([]string, error) {
+	headers, metadata, err := archive.ReadEntries(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group the fuzzers by fuzz test, in the order of the metadata
+	var fuzzTests []string
+	fuzzersByFuzzTest := make(map[string][]*archive.Fuzzer)
+	for _, fuzzer := range metadata.Fuzzers {
+		fuzzTest := fuzzer.Name
+		if fuzzTest == "" {
+			fuzzTest = fuzzer.Target
+		}
+		if _, ok := fuzzersByFuzzTest[fuzzTest]; !ok {
+			fuzzTests = append(fuzzTests, fuzzTest)
+		}
+		fuzzersByFuzzTest[fuzzTest] = append(fuzzersByFuzzTest[fuzzTest], fuzzer)
+	}
+	if len(fuzzTests) == 0 {
+		return nil, errors.New("The bundle doesn't contain any fuzz tests which could be split")
+	}
+
+	referencedPaths := make(map[string][]string)
+	for _, fuzzTest := range fuzzTests {
+		for _, fuzzer := range fuzzersByFuzzTest[fuzzTest] {
+			referencedPaths[fuzzTest] = append(referencedPaths[fuzzTest], fuzzerPaths(fuzzer)...)
+		}
+	}
+
+	// The fuzz tests which reference each entry. Entries which are not
+	// referenced by any fuzz test are added to all bundles. Hard links
+	// point to the content-addressed storage ("cas" directory), so the
+	// targets of hard links are added to the bundles of their links.
+	owners := make(map[string][]string)
+	for _, header := range headers {
+		for _, fuzzTest := range fuzzTests {
+			if isBelowAny(header.Name, referencedPaths[fuzzTest]) {
+				owners[header.Name] = append(owners[header.Name], fuzzTest)
+			}
+		}
+	}
+	linkTargetOwners := make(map[string][]string)
+	for _, header := range headers {
+		if header.Typeflag != tar.TypeLink {
+			continue
+		}
+		linkOwners := owners[header.Name]
+		if len(linkOwners) == 0 {
+			linkOwners = fuzzTests
+		}
+		for _, fuzzTest := range linkOwners {
+			if !sliceutil.Contains(linkTargetOwners[header.Linkname], fuzzTest) {
+				linkTargetOwners[header.Linkname] = append(linkTargetOwners[header.Linkname], fuzzTest)
+			}
+		}
+	}
+
+	var bundlePaths []string
+	fileNames := make(map[string]string)
+	for _, fuzzTest := range fuzzTests {
+		fileName := unsafeFileNameCharsRegex.ReplaceAllString(strings.ReplaceAll(fuzzTest, "::", "_"), "_") + ".tar.gz"
+		if other, ok := fileNames[fileName]; ok {
+			return nil, errors.Errorf("The bundles of the fuzz tests %q and %q would have the same file name %s", other, fuzzTest, fileName)
+		}
+		fileNames[fileName] = fuzzTest
+
+		fuzzTestMetadata := *metadata
+		fuzzTestMetadata.Fuzzers = fuzzersByFuzzTest[fuzzTest]
+		outputPath := filepath.Join(outputDir, fileName)
+		err = archive.Filter(bundlePath, outputPath, &fuzzTestMetadata, func(header *tar.Header) bool {
+			if linkOwners, ok := linkTargetOwners[header.Name]; ok {
+				return sliceutil.Contains(linkOwners, fuzzTest)
+			}
+			entryOwners := owners[header.Name]
+			return len(entryOwners) == 0 || sliceutil.Contains(entryOwners, fuzzTest)
+		})
+		if err != nil {
+			return nil, errors.WithMessagef(err, "Failed to create the bundle of fuzz test %s", fuzzTest)
+		}
+		log.Debugf("Created bundle %s for fuzz test %s", outputPath, fuzzTest)
+		bundlePaths = append(bundlePaths, outputPath)
+	}
+	return bundlePaths, nil
+}
+
+// fuzzerPaths returns the paths in the bundle which belong to the
+// fuzzer
+func fuzzerPaths(fuzzer *archive.Fuzzer) []string {
+	paths := []string{fuzzer.Path, fuzzer.Dictionary, fuzzer.Seeds}
+	if fuzzer.Path != "" {
+		// The debug information on macOS
+		paths = append(paths, fuzzer.Path+".dSYM")
+	}
+	paths = append(paths, fuzzer.LibraryPaths...)
+	paths = append(paths, fuzzer.RuntimePaths...)
+
+	// The artifacts of C/C++ fuzz tests are stored below a prefix, see
+	// fuzzTestPrefix, which also contains runtime dependencies which
+	// are found via the run path instead of the library paths
+	if fuzzer.Engine == "LIBFUZZER" || fuzzer.Engine == "LLVM_COV" {
+		pathComponents := strings.Split(fuzzer.Path, "/")
+		targetComponents := strings.Split(fuzzer.Target, "/")
+		n := 2 + len(targetComponents)
+		if fuzzer.Target != "" && len(pathComponents) > n &&
+			path.Join(pathComponents[2:n]...) == path.Join(targetComponents...) {
+			paths = append(paths, path.Join(pathComponents[:n]...))
+		}
+	}
+
+	var nonEmptyPaths []string
+	for _, p := range paths {
+		if p != "" {
+			nonEmptyPaths = append(nonEmptyPaths, path.Clean(p))
+		}
+	}
+	return nonEmptyPaths
+}
+
+// isBelowAny returns true if the archive path is one of the given paths
+// or below one of them
+func isBelowAny(archivePath string, paths []string) bool {
+	archivePath = path.Clean(archivePath)
+	for _, p := range paths {
+		if archivePath == p || strings.HasPrefix(archivePath, p+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package bundler
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestSplitBundle(t *testing.T) {
+	testDir := testutil.MkdirTemp(t, "", "bundle-split-test-*")
+	sourceFile := func(name string) string {
+		path := filepath.Join(testDir, "src", name)
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		require.NoError(t, err)
+		err = os.WriteFile(path, []byte(name), 0o644)
+		require.NoError(t, err)
+		return path
+	}
+
+	bundlePath := filepath.Join(testDir, "fuzz_tests.tar.gz")
+	bundle, err := os.Create(bundlePath)
+	require.NoError(t, err)
+	bufWriter := bufio.NewWriter(bundle)
+	archiveWriter := archive.NewTarArchiveWriter(bufWriter, true)
+
+	var fuzzers []*archive.Fuzzer
+	for _, fuzzTest := range []string{"parser_fuzz_test", "lexer_fuzz_test"} {
+		err = archiveWriter.WriteFile("cas/"+fuzzTest+"/libhelper.so", sourceFile(fuzzTest+"-libhelper.so"))
+		require.NoError(t, err)
+		for _, prefix := range []string{"libfuzzer/address+undefined/", "replayer/coverage/"} {
+			prefix += fuzzTest
+			err = archiveWriter.WriteFile(prefix+"/bin/"+fuzzTest, sourceFile(fuzzTest))
+			require.NoError(t, err)
+			err = archiveWriter.WriteFile(prefix+"/seeds/seed", sourceFile("seed"))
+			require.NoError(t, err)
+			// A runtime dependency which is found via the run path
+			err = archiveWriter.WriteHardLink("cas/"+fuzzTest+"/libhelper.so", prefix+"/bin/lib/libhelper.so")
+			require.NoError(t, err)
+			engine := "LIBFUZZER"
+			if prefix[0] == 'r' {
+				engine = "LLVM_COV"
+			}
+			fuzzers = append(fuzzers, &archive.Fuzzer{
+				Target: fuzzTest,
+				Path:   prefix + "/bin/" + fuzzTest,
+				Engine: engine,
+				Seeds:  prefix + "/seeds",
+			})
+		}
+	}
+	err = archiveWriter.WriteFile("work_dir/data.json", sourceFile("data.json"))
+	require.NoError(t, err)
+
+	metadata := &archive.Metadata{
+		RunEnvironment: &archive.RunEnvironment{Docker: "ubuntu:rolling"},
+		Labels:         map[string]string{"team": "security"},
+		Fuzzers:        fuzzers,
+	}
+	metadataYaml, err := metadata.ToYaml()
+	require.NoError(t, err)
+	metadataPath := filepath.Join(testDir, archive.MetadataFileName)
+	err = os.WriteFile(metadataPath, metadataYaml, 0o644)
+	require.NoError(t, err)
+	err = archiveWriter.WriteFile(archive.MetadataFileName, metadataPath)
+	require.NoError(t, err)
+
+	require.NoError(t, archiveWriter.Close())
+	require.NoError(t, bufWriter.Flush())
+	require.NoError(t, bundle.Close())
+
+	outputDir := filepath.Join(testDir, "out")
+	require.NoError(t, os.Mkdir(outputDir, 0o755))
+	bundlePaths, err := splitBundle(bundlePath, outputDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(outputDir, "parser_fuzz_test.tar.gz"),
+		filepath.Join(outputDir, "lexer_fuzz_test.tar.gz"),
+	}, bundlePaths)
+
+	headers, splitMetadata, err := archive.ReadEntries(bundlePaths[1])
+	require.NoError(t, err)
+	var names []string
+	for _, header := range headers {
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{
+		"bundle.yaml",
+		"cas/lexer_fuzz_test/libhelper.so",
+		"libfuzzer/address+undefined/lexer_fuzz_test/bin/lexer_fuzz_test",
+		"libfuzzer/address+undefined/lexer_fuzz_test/bin/lib/libhelper.so",
+		"libfuzzer/address+undefined/lexer_fuzz_test/seeds/seed",
+		"replayer/coverage/lexer_fuzz_test/bin/lexer_fuzz_test",
+		"replayer/coverage/lexer_fuzz_test/bin/lib/libhelper.so",
+		"replayer/coverage/lexer_fuzz_test/seeds/seed",
+		"work_dir/data.json",
+	}, names)
+
+	assert.Equal(t, "ubuntu:rolling", splitMetadata.Docker)
+	assert.Equal(t, map[string]string{"team": "security"}, splitMetadata.Labels)
+	require.Len(t, splitMetadata.Fuzzers, 2)
+	for _, fuzzer := range splitMetadata.Fuzzers {
+		assert.Equal(t, "lexer_fuzz_test", fuzzer.Target)
+	}
+
+	// The split bundle can be extracted, which requires that the
+	// targets of the hard links are contained
+	extractDir := filepath.Join(testDir, "extracted")
+	err = archive.Extract(bundlePaths[1], extractDir)
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(extractDir, "replayer", "coverage", "lexer_fuzz_test", "bin", "lib", "libhelper.so"))
+	require.NoError(t, err)
+	assert.Equal(t, "lexer_fuzz_test-libhelper.so", string(content))
+}
@@ -0,0 +1,408 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/build/cmake"
+	llvmCoverage "code-intelligence.com/cifuzz/internal/cmd/coverage/llvm"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/logging"
+	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/coverage"
+	"code-intelligence.com/cifuzz/pkg/dataflow"
+	"code-intelligence.com/cifuzz/pkg/dependencies"
+	"code-intelligence.com/cifuzz/pkg/log"
+	parser "code-intelligence.com/cifuzz/pkg/parser/coverage"
+	"code-intelligence.com/cifuzz/pkg/reachability"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+type options struct {
+	BuildSystem    string   `mapstructure:"build-system"`
+	NumBuildJobs   uint     `mapstructure:"build-jobs"`
+	SeedCorpusDirs []string `mapstructure:"seed-corpus-dirs"`
+	ProjectDir     string   `mapstructure:"project-dir"`
+	ConfigDir      string   `mapstructure:"config-dir"`
+
+	ResolveSourceFilePath bool
+
+	Dataflow     bool   `mapstructure:"-"`
+	Reachability bool   `mapstructure:"-"`
+	OutputPath   string `mapstructure:"-"`
+
+	fuzzTest   string
+	argsToPass []string
+}
+
+type analyzeCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "analyze [flags] <fuzz test> [--] [<build system arg>...]",
+		Short: "Analyze how the inputs of a fuzz test are processed",
+		Long: `This command analyzes how the fuzz test processes the inputs of its
+seed corpus and generated corpus.
+
+With --dataflow, the fuzz test is built with data-flow tracing
+(-fsanitize=dataflow) and run on each input to collect which input
+bytes influence the comparisons in each function. The result is
+written as an influence map in JSON format to
+
+    .cifuzz-build/dataflow/<fuzz test>.json
+
+unless a different path is specified via --output. The influence map
+shows which parts of the inputs the fuzzer has to mutate to reach new
+code in a function. It can be used to generate a dictionary, see
+'cifuzz dict generate --dataflow'.
+
+With --reachability, the fuzz test is built with coverage
+instrumentation and run on its corpus. The functions which were
+executed are combined with the static call graph of the fuzz test to
+find functions which are reachable from the fuzz test but were never
+covered. Of these, the functions which are called by covered functions
+are listed as gaps, sorted by the number of uncovered functions which
+are only reachable through them. A gap points at a condition which the
+fuzzer couldn't satisfy or at a part of the API which the fuzz test
+doesn't exercise, so it's a good place to improve the fuzz test, e.g.
+by adding seed inputs or a dictionary. The call graph only contains
+direct calls, functions which are only called via function pointers or
+virtual methods are not considered reachable. The report is printed
+to stdout, or written in JSON format to the path specified via
+--output.
+
+The analyses are currently only supported for CMake projects on Linux
+and macOS.`,
+		ValidArgsFunction: completion.ValidFuzzTests,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+
+			var lenFuzzTestArgs int
+			if cmd.ArgsLenAtDash() != -1 {
+				lenFuzzTestArgs = cmd.ArgsLenAtDash()
+				opts.argsToPass = args[cmd.ArgsLenAtDash():]
+				args = args[:cmd.ArgsLenAtDash()]
+			} else {
+				lenFuzzTestArgs = len(args)
+			}
+			if lenFuzzTestArgs != 1 {
+				msg := fmt.Sprintf("Exactly one <fuzz test> argument must be provided, got %d", lenFuzzTestArgs)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
+			if !opts.Dataflow && !opts.Reachability {
+				msg := "No analysis specified, please specify --dataflow or --reachability"
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			if opts.Dataflow && opts.Reachability {
+				msg := "Only one of --dataflow and --reachability can be specified"
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			if opts.BuildSystem == "" {
+				opts.BuildSystem, err = config.DetermineBuildSystem(opts.ProjectDir)
+				if err != nil {
+					return err
+				}
+			}
+			err = config.ValidateBuildSystem(opts.BuildSystem)
+			if err != nil {
+				return err
+			}
+			if opts.BuildSystem != config.BuildSystemCMake {
+				return errors.Errorf(config.NotSupportedErrorMessage(opts.analysis(), opts.BuildSystem))
+			}
+			if runtime.GOOS == "windows" {
+				return errors.Errorf(config.NotSupportedErrorMessage(opts.analysis(), runtime.GOOS))
+			}
+
+			opts.SeedCorpusDirs, err = cmdutils.ValidateCorpusDirs(opts.SeedCorpusDirs)
+			if err != nil {
+				return err
+			}
+
+			fuzzTests, err := resolve.FuzzTestArguments(opts.ResolveSourceFilePath, args, opts.BuildSystem, opts.ProjectDir)
+			if err != nil {
+				return err
+			}
+			opts.fuzzTest = fuzzTests[0]
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := analyzeCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via cifuzz.yaml as well,
+	// bind it to viper in the PreRunE function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddBuildJobsFlag,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddResolveSourceFileFlag,
+		cmdutils.AddSeedCorpusFlag,
+	)
+	cmd.Flags().BoolVar(&opts.Dataflow, "dataflow", false,
+		"Collect which input bytes influence the comparisons in each function of the fuzz test.")
+	cmd.Flags().BoolVar(&opts.Reachability, "reachability", false,
+		"Report the functions which are reachable from the fuzz test but were never covered.")
+	cmd.Flags().StringVarP(&opts.OutputPath, "output", "o", "",
+		"Write the influence map or the reachability report to the specified `file`.")
+
+	return cmd
+}
+
+// analysis returns the name of the analysis for error messages
+func (opts *options) analysis() string {
+	if opts.Reachability {
+		return "analyze --reachability"
+	}
+	return "analyze --dataflow"
+}
+
+func (c *analyzeCmd) run() error {
+	deps := []dependencies.Key{
+		dependencies.CMake,
+		dependencies.Clang,
+	}
+	if c.opts.Reachability {
+		deps = append(deps, dependencies.LLVMCov, dependencies.LLVMProfData)
+	} else {
+		deps = append(deps, dependencies.LLVMSymbolizer)
+	}
+	err := dependencies.Check(deps, c.opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	buildStdout := c.OutOrStdout()
+	buildStderr := c.OutOrStderr()
+	if logging.ShouldLogBuildToFile() {
+		buildStdout, err = logging.BuildOutputToFile(c.opts.ProjectDir, []string{c.opts.fuzzTest})
+		if err != nil {
+			return err
+		}
+		buildStderr = buildStdout
+	}
+
+	if c.opts.Reachability {
+		return c.runReachability(buildStdout, buildStderr)
+	}
+	return c.runDataflow(buildStdout, buildStderr)
+}
+
+func (c *analyzeCmd) runDataflow(buildStdout, buildStderr io.Writer) error {
+	log.Infof("Building %s with data-flow tracing", c.opts.fuzzTest)
+	builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
+		ProjectDir: c.opts.ProjectDir,
+		Args:       c.opts.argsToPass,
+		Sanitizers: []string{"dataflow"},
+		Parallel: cmake.ParallelOptions{
+			Enabled: viper.IsSet("build-jobs"),
+			NumJobs: c.opts.NumBuildJobs,
+		},
+		Stdout: buildStdout,
+		Stderr: buildStderr,
+	})
+	if err != nil {
+		return err
+	}
+	err = builder.Configure()
+	if err != nil {
+		return err
+	}
+	buildResults, err := builder.Build([]string{c.opts.fuzzTest})
+	if err != nil {
+		return err
+	}
+	buildResult := buildResults[0]
+
+	corpusDirs := append(c.opts.SeedCorpusDirs, buildResult.SeedCorpus, buildResult.GeneratedCorpus)
+	log.Infof("Collecting data-flow traces of %s", c.opts.fuzzTest)
+	m, err := dataflow.Collect(context.Background(), &dataflow.CollectOptions{
+		Executable: buildResult.Executable,
+		FuzzTest:   c.opts.fuzzTest,
+		ProjectDir: c.opts.ProjectDir,
+		CorpusDirs: corpusDirs,
+	})
+	if err != nil {
+		return err
+	}
+
+	outputPath := c.opts.OutputPath
+	if outputPath == "" {
+		outputPath = filepath.Join(c.opts.ProjectDir, ".cifuzz-build", "dataflow", c.opts.fuzzTest+".json")
+	}
+	err = m.Save(outputPath)
+	if err != nil {
+		return err
+	}
+
+	log.Successf("Wrote the data-flow influence map of %d functions to %s", len(m.Functions), fileutil.PrettifyPath(outputPath))
+	return nil
+}
+
+func (c *analyzeCmd) runReachability(buildStdout, buildStderr io.Writer) error {
+	log.Infof("Building %s with coverage instrumentation", c.opts.fuzzTest)
+	builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
+		ProjectDir: c.opts.ProjectDir,
+		Args:       c.opts.argsToPass,
+		Sanitizers: []string{"coverage"},
+		Parallel: cmake.ParallelOptions{
+			Enabled: viper.IsSet("build-jobs"),
+			NumJobs: c.opts.NumBuildJobs,
+		},
+		Stdout: buildStdout,
+		Stderr: buildStderr,
+		// The call graph includes the shared libraries of the project
+		// which the fuzz test is linked against
+		FindRuntimeDeps: true,
+	})
+	if err != nil {
+		return err
+	}
+	err = builder.Configure()
+	if err != nil {
+		return err
+	}
+	buildResults, err := builder.Build([]string{c.opts.fuzzTest})
+	if err != nil {
+		return err
+	}
+	buildResult := buildResults[0]
+
+	lcovReport, err := c.functionCoverage(buildResult, buildStdout, buildStderr)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Creating the call graph of %s", c.opts.fuzzTest)
+	binaries := []string{buildResult.Executable}
+	for _, dep := range buildResult.RuntimeDeps {
+		isBelow, err := fileutil.IsBelow(dep, c.opts.ProjectDir)
+		if err != nil {
+			return err
+		}
+		if isBelow {
+			binaries = append(binaries, dep)
+		}
+	}
+	graph, err := reachability.Disassemble(context.Background(), binaries)
+	if err != nil {
+		return err
+	}
+	if _, ok := graph[reachability.EntryFunction]; !ok {
+		return errors.Errorf("Function %s not found in the fuzz test %s", reachability.EntryFunction, c.opts.fuzzTest)
+	}
+
+	report := reachability.Analyze(c.opts.fuzzTest, graph, lcovReport, c.opts.ProjectDir)
+	if c.opts.OutputPath != "" {
+		err = report.Save(c.opts.OutputPath)
+		if err != nil {
+			return err
+		}
+		log.Successf("Wrote the reachability report of %s to %s", c.opts.fuzzTest, fileutil.PrettifyPath(c.opts.OutputPath))
+		return nil
+	}
+	return c.printReachabilityReport(report)
+}
+
+// functionCoverage runs the coverage build of the fuzz test on its
+// corpus and returns the resulting lcov report
+func (c *analyzeCmd) functionCoverage(buildResult *build.CBuildResult, buildStdout, buildStderr io.Writer) (*parser.LCOVReport, error) {
+	tmpDir, err := os.MkdirTemp("", "analyze-reachability-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tmpDir)
+
+	gen := &llvmCoverage.CoverageGenerator{
+		OutputFormat: coverage.FormatLCOV,
+		OutputPath:   filepath.Join(tmpDir, "coverage.lcov"),
+		BuildSystem:  config.BuildSystemCMake,
+		CorpusDirs:   c.opts.SeedCorpusDirs,
+		FuzzTest:     c.opts.fuzzTest,
+		ProjectDir:   c.opts.ProjectDir,
+		Stderr:       c.OutOrStderr(),
+		BuildStdout:  buildStdout,
+		BuildStderr:  buildStderr,
+		BuildResult:  buildResult,
+	}
+	err = gen.BuildFuzzTestForCoverage()
+	if err != nil {
+		return nil, err
+	}
+	lcovPath, err := gen.GenerateCoverageReport()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(lcovPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return parser.ParseLCOVFileIntoLCOVReport(f)
+}
+
+func (c *analyzeCmd) printReachabilityReport(report *reachability.Report) error {
+	out := c.OutOrStdout()
+	percentage := 100.0
+	if report.ReachableFunctions > 0 {
+		percentage = float64(report.CoveredFunctions) / float64(report.ReachableFunctions) * 100
+	}
+	_, err := fmt.Fprintf(out, "%d of %d functions reachable from %s were covered (%.1f%%)\n",
+		report.CoveredFunctions, report.ReachableFunctions, report.FuzzTest, percentage)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(report.Gaps) == 0 {
+		return nil
+	}
+
+	_, err = fmt.Fprint(out, "\nUncovered functions which are called by covered functions:\n\n")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	data := pterm.TableData{{"Function", "Location", "Called By", "Blocked Functions"}}
+	for _, gap := range report.Gaps {
+		data = append(data, []string{
+			gap.Function,
+			fmt.Sprintf("%s:%d", gap.SourceFile, gap.Line),
+			strings.Join(gap.CalledBy, ", "),
+			strconv.Itoa(gap.BlockedFunctions),
+		})
+	}
+	return errors.WithStack(pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(out).Render())
+}
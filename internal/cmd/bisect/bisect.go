@@ -0,0 +1,179 @@
+package bisect
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/otiai10/copy"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmd/run/adapter"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/logging"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/vcs"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+type bisectCmd struct {
+	*cobra.Command
+	opts *adapter.RunOptions
+
+	good string
+	bad  string
+}
+
+func New() *cobra.Command {
+	opts := &adapter.RunOptions{}
+	var good, bad string
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "bisect [flags] --good=<revision> <finding>",
+		Short: "Find the commit which introduced a finding",
+		Long: `This command uses 'git bisect' to find the first commit between the
+revisions specified via --good and --bad (by default HEAD) in which the
+crashing input of the finding triggers a crash.
+
+In each step, the fuzz test which found the finding is built in the
+same configuration as when the finding was found and run once on the
+crashing input. Commits in which the fuzz test can't be built or run
+are skipped.
+
+The working tree must not contain uncommitted changes to tracked files.
+After the bisection, the previously checked out commit is restored.
+
+Bisecting findings is supported for C/C++ and Java projects.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ValidFindings,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			if good == "" {
+				msg := "No good revision specified, please specify a revision in which the finding doesn't reproduce via --good"
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
+			f, err := finding.LoadFinding(opts.ProjectDir, args[0], nil)
+			if finding.IsNotExistError(err) {
+				return errors.WithMessagef(err, "Finding %s does not exist", args[0])
+			}
+			if err != nil {
+				return err
+			}
+			err = opts.ApplyFindingConfig(f)
+			if err != nil {
+				return err
+			}
+
+			opts.BuildStdout = cmd.OutOrStdout()
+			opts.BuildStderr = cmd.OutOrStderr()
+			opts.Stdout = cmd.OutOrStdout()
+			opts.Stderr = cmd.OutOrStderr()
+
+			if logging.ShouldLogBuildToFile() {
+				opts.BuildStdout, err = logging.BuildOutputToFile(opts.ProjectDir, []string{opts.FuzzTest})
+				if err != nil {
+					return err
+				}
+				opts.BuildStderr = opts.BuildStdout
+			}
+
+			return opts.Validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := bisectCmd{Command: c, opts: opts, good: good, bad: bad}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via cifuzz.yaml as well,
+	// bind it to viper in the PreRunE function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddCleanCommandFlag,
+		cmdutils.AddBuildJobsFlag,
+		cmdutils.AddProjectDirFlag,
+	)
+	cmd.Flags().StringVar(&good, "good", "",
+		"A revision in which the finding doesn't reproduce, e.g. the last release.")
+	cmd.Flags().StringVar(&bad, "bad", "HEAD",
+		"A revision in which the finding reproduces.")
+	return cmd
+}
+
+func (c *bisectCmd) run() error {
+	a, err := adapter.NewAdapter(c.opts)
+	if err != nil {
+		return err
+	}
+	defer a.Cleanup()
+
+	err = a.CheckDependencies(c.opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	// The crashing input might not exist in the checked out commits,
+	// so we copy it to a temporary directory
+	tmpDir, err := os.MkdirTemp("", "cifuzz-bisect-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tmpDir)
+	input := filepath.Join(tmpDir, filepath.Base(c.opts.ReproduceInput))
+	err = copy.Copy(c.opts.ReproduceInput, input)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	c.opts.ReproduceInput = input
+
+	firstBad, err := vcs.GitBisect(c.opts.ProjectDir, c.good, c.bad, c.test)
+	if err != nil {
+		return err
+	}
+	log.Successf("The first commit in which the finding reproduces is %s", firstBad)
+	return nil
+}
+
+// test builds the fuzz test in the checked out commit and runs it on
+// the crashing input
+func (c *bisectCmd) test(commit string) (vcs.BisectVerdict, error) {
+	log.Infof("Testing commit %s", commit)
+
+	// The adapters modify the options, so each step gets a copy
+	opts := *c.opts
+	a, err := adapter.NewAdapter(&opts)
+	if err != nil {
+		return "", err
+	}
+	defer a.Cleanup()
+
+	reportHandler, err := a.Run(&opts)
+	if err != nil {
+		var signalErr *cmdutils.SignalError
+		if errors.As(err, &signalErr) {
+			return "", err
+		}
+		log.Warnf("Skipping commit %s, because the fuzz test could not be built or run: %v", commit, err)
+		return vcs.BisectSkip, nil
+	}
+
+	if len(reportHandler.Findings) > 0 {
+		log.Infof("The finding reproduces in commit %s", commit)
+		return vcs.BisectBad, nil
+	}
+	log.Infof("The finding does not reproduce in commit %s", commit)
+	return vcs.BisectGood, nil
+}
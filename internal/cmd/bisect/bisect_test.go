@@ -0,0 +1,22 @@
+package bisect
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestGoodRevisionMissing(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "bisect-cmd-test", config.BuildSystemCMake)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "my_finding")
+	require.Error(t, err)
+	var usageErr *cmdutils.IncorrectUsageError
+	assert.ErrorAs(t, err, &usageErr)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"code-intelligence.com/cifuzz/internal/bundler"
+	bundleLintCmd "code-intelligence.com/cifuzz/internal/cmd/bundle/lint"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/cmdutils/logging"
 	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
@@ -31,10 +32,6 @@ func (opts *options) Validate() error {
 		return err
 	}
 
-	if opts.BuildSystem == config.BuildSystemNodeJS && !config.AllowUnsupportedPlatforms() {
-		return errors.Errorf(config.NotSupportedErrorMessage("bundle", opts.BuildSystem))
-	}
-
 	return opts.Opts.Validate()
 }
 
@@ -67,6 +64,46 @@ configured for the project.
 This command will select an appropriate Docker image for execution based
 on the build system. This can be overridden with a docker-image flag.
 
+Before the bundle is created, fast checks of the quality of the fuzz
+tests are run: whether the fuzz test executable is instrumented, whether
+a dictionary is used, whether the seed corpus is empty and whether seeds
+are larger than the -max_len engine argument. Failed checks are printed
+as warnings and stored in the "health" section of the bundle.yaml.
+
+For C/C++ fuzz tests, the shared libraries which the fuzz test
+executables are linked against are added to the bundle, unless they are
+system libraries, and their directories are added to the library search
+path of the fuzz tests. System libraries which are not available in all
+Docker images are printed as a warning.
+
+The bundle contains a CycloneDX SBOM (sbom.cdx.json) which lists the
+jars, shared libraries and npm packages added to the bundle, with their
+versions, if known, and SHA-256 hashes.
+
+The bundle contains a replay.sh script which executes the seed corpus
+inputs of the C/C++ and Java fuzz tests once, without fuzzing. With the
+crashing inputs of findings in the seed corpus, a bundle can be used to
+run regression tests in environments which don't allow fuzzing:
+
+    tar -xzf fuzz_tests.tar.gz && ./replay.sh [<fuzz test>...]
+
+Use 'cifuzz bundle lint <bundle>' to check that a bundle can be run.
+
+With --split, one bundle per fuzz test is created instead of a single
+bundle, so that the bundles can be uploaded in parallel and each fuzz
+test can be scheduled separately. Each bundle contains the fuzzers of
+one fuzz test for all sanitizers and the files which are shared by all
+fuzz tests, like the work_dir. The bundles are named after the fuzz
+tests and created in the directory specified via --output, or in the
+current working directory.
+
+With --reproducible, the same inputs produce a byte-identical bundle, so
+that a bundle can be verified by building it again from the same
+sources. The entries of the bundle are sorted, their owners and
+permissions are normalized and their timestamps are set to the value of
+the SOURCE_DATE_EPOCH environment variable, or to the Unix epoch if it's
+not set. The build log is not added to reproducible bundles.
+
 ` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("CMake") + `
   <fuzz test> is the name of the fuzz test defined in the add_fuzz_test
   command in your CMakeLists.txt.
@@ -116,6 +153,19 @@ on the build system. This can be overridden with a docker-image flag.
 
   If no fuzz tests are specified, all fuzz tests are added to the bundle.
 
+` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("Node.js") + `
+  <fuzz test> is the name of the test file without the ".fuzz.js" or
+  ".fuzz.ts" extension, optionally followed by a colon and the name of
+  the fuzz test in the file, like for 'cifuzz run'.
+
+  The project directory is added to the bundle including its
+  node_modules directory. If node_modules doesn't exist, the
+  dependencies are installed from the lockfile (package-lock.json,
+  yarn.lock or pnpm-lock.yaml) first. Because node_modules can contain
+  native modules, the bundle has to be created on Linux.
+
+  If no fuzz tests are specified, all fuzz tests are added to the bundle.
+
 ` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("Other build systems") + `
   <fuzz test> is either the path or basename of the fuzz test executable
   created by the build command. If it's the basename, it will be searched
@@ -202,6 +252,20 @@ on the build system. This can be overridden with a docker-image flag.
 		RunE: func(c *cobra.Command, args []string) error {
 			buildPrinter := logging.NewBuildPrinter(os.Stdout, log.BundleInProgressMsg)
 
+			if opts.Split {
+				bundlePaths, err := bundler.New(&opts.Opts).BundleSplit()
+				if err != nil {
+					buildPrinter.StopOnError(log.BundleInProgressErrorMsg)
+					return err
+				}
+
+				buildPrinter.StopOnSuccess(log.BundleInProgressSuccessMsg, true)
+				for _, bundlePath := range bundlePaths {
+					log.Successf("Successfully created bundle: %s", bundlePath)
+				}
+				return nil
+			}
+
 			_, err := bundler.New(&opts.Opts).Bundle()
 			if err != nil {
 				buildPrinter.StopOnError(log.BundleInProgressErrorMsg)
@@ -226,12 +290,19 @@ on the build system. This can be overridden with a docker-image flag.
 		cmdutils.AddDockerImageFlagForBundleCommand,
 		cmdutils.AddEngineArgFlag,
 		cmdutils.AddEnvFlag,
+		cmdutils.AddLabelFlag,
+		cmdutils.AddMinimizeSeedCorpusFlag,
 		cmdutils.AddProjectDirFlag,
+		cmdutils.AddReproducibleFlag,
 		cmdutils.AddSeedCorpusFlag,
 		cmdutils.AddTimeoutFlag,
 		cmdutils.AddResolveSourceFileFlag,
 	)
-	cmd.Flags().StringVarP(&opts.OutputPath, "output", "o", "", "Output path of the bundle (.tar.gz)")
+	cmd.Flags().StringVarP(&opts.OutputPath, "output", "o", "", "Output path of the bundle (.tar.gz), or the output directory with --split")
+	cmd.Flags().BoolVar(&opts.Split, "split", false,
+		"Create one bundle per fuzz test instead of a single bundle, named after the fuzz test.")
+
+	cmd.AddCommand(bundleLintCmd.New())
 
 	return cmd
 }
@@ -0,0 +1,259 @@
+package lint
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/options"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	fuzzer_runner "code-intelligence.com/cifuzz/pkg/runner"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+type lintOpts struct {
+	PrintJSON bool `mapstructure:"print-json"`
+
+	DryRun        bool          `mapstructure:"-"`
+	DryRunTimeout time.Duration `mapstructure:"-"`
+}
+
+type lintCmd struct {
+	*cobra.Command
+	opts *lintOpts
+}
+
+// result is the output of the command with --json
+type result struct {
+	Problems []*archive.Problem `json:"problems"`
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&lintOpts{})
+}
+
+func newWithOptions(opts *lintOpts) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "lint [flags] <bundle>",
+		Short: "Check a bundle for problems",
+		Long: `This command unpacks a bundle created by 'cifuzz bundle' and checks
+whether it can be run:
+
+  * The bundle.yaml only contains known fields and all required fields,
+    like the engine of each fuzzer, are set.
+  * All files and directories referenced in the bundle.yaml, like the
+    fuzz test executables, dictionaries, seed corpora, library paths
+    and class paths, exist in the bundle.
+
+With --dry-run, each fuzz test of the bundle is started without
+fuzzing, which detects missing runtime dependencies and fuzz tests which
+fail during initialization. The fuzz tests are run on the host, so
+bundles should be linted with --dry-run in the Docker image specified in
+the bundle.yaml. LLVM_COV fuzzers are not run.
+
+The command exits with a non-zero exit code if any problems are found.`,
+		Args: cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			opts.PrintJSON = viper.GetBool("print-json")
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := lintCmd{Command: c, opts: opts}
+			return cmd.run(args[0])
+		},
+	}
+
+	cmdutils.DisableConfigCheck(cmd)
+
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false,
+		"Start each fuzz test of the bundle without fuzzing to check that it can be run.")
+	cmd.Flags().DurationVar(&opts.DryRunTimeout, "dry-run-timeout", 2*time.Minute,
+		"Maximum time to wait for a single fuzz test to finish with --dry-run.")
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddPrintJSONFlag,
+	)
+
+	return cmd
+}
+
+func (c *lintCmd) run(bundlePath string) error {
+	bundleDir, err := os.MkdirTemp("", "cifuzz-bundle-lint-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(bundleDir)
+
+	err = archive.Extract(bundlePath, bundleDir)
+	if err != nil {
+		return errors.WithMessagef(err, "Failed to unpack bundle %s", bundlePath)
+	}
+
+	metadata, problems, err := archive.Lint(bundleDir)
+	if err != nil {
+		return err
+	}
+
+	// Fuzz tests can only be run if the bundle itself is valid
+	if c.opts.DryRun && metadata != nil && len(problems) == 0 {
+		for i, fuzzer := range metadata.Fuzzers {
+			problem, err := c.dryRun(bundleDir, fuzzer)
+			if err != nil {
+				return err
+			}
+			if problem != "" {
+				problems = append(problems, &archive.Problem{Fuzzer: archive.FuzzerName(fuzzer, i), Message: problem})
+			}
+		}
+	}
+
+	if c.opts.PrintJSON {
+		if problems == nil {
+			problems = []*archive.Problem{}
+		}
+		s, err := stringutil.ToJSONString(&result{Problems: problems})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(c.OutOrStdout(), s)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	} else {
+		for _, problem := range problems {
+			log.Warn(problem.String())
+		}
+	}
+
+	if len(problems) > 0 {
+		return cmdutils.WrapSilentError(errors.Errorf("Found %d problems in bundle %s", len(problems), bundlePath))
+	}
+	if !c.opts.PrintJSON {
+		log.Successf("No problems found in bundle %s", bundlePath)
+	}
+	return nil
+}
+
+// dryRun runs the fuzzer without fuzzing and returns a description of
+// the problem if it fails
+func (c *lintCmd) dryRun(bundleDir string, fuzzer *archive.Fuzzer) (string, error) {
+	args, env, dir, err := dryRunCommand(bundleDir, fuzzer)
+	if err != nil {
+		return "", err
+	}
+	if args == nil {
+		log.Infof("Skipping dry run of %s: not supported for engine %s", fuzzer.Name, fuzzer.Engine)
+		return "", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.DryRunTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = env
+	log.Debugf("Command: %s", envutil.QuotedCommandWithEnv(cmd.Args, fuzzer.EngineOptions.Env))
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return fmt.Sprintf("The dry run didn't finish within %s", c.opts.DryRunTimeout), nil
+	}
+	if err != nil {
+		log.Debugf("Output of the dry run of %s:\n%s", fuzzer.Name, string(output))
+		return fmt.Sprintf("The dry run failed: %v\n%s", err, lastLines(string(output), 10)), nil
+	}
+	log.Debugf("Dry run of %s succeeded", fuzzer.Name)
+	return "", nil
+}
+
+// dryRunCommand returns the command, environment and working directory
+// which run the fuzzer once without fuzzing, or nil if the engine of
+// the fuzzer doesn't support it
+func dryRunCommand(bundleDir string, fuzzer *archive.Fuzzer) ([]string, []string, string, error) {
+	env, err := envutil.Copy(os.Environ(), fuzzer.EngineOptions.Env)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	env, err = envutil.Setenv(env, "NO_CIFUZZ", "1")
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	bundlePath := func(p string) string {
+		return filepath.Join(bundleDir, filepath.FromSlash(p))
+	}
+
+	switch fuzzer.Engine {
+	case "LIBFUZZER":
+		var libraryDirs []string
+		for _, p := range fuzzer.LibraryPaths {
+			libraryDirs = append(libraryDirs, bundlePath(p))
+		}
+		env, err = fuzzer_runner.SetLDLibraryPath(env, libraryDirs)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		// With -runs=0, libFuzzer only runs the empty input
+		return []string{bundlePath(fuzzer.Path), "-runs=0"}, env, bundleDir, nil
+
+	case "JAVA_LIBFUZZER":
+		javaBin, err := runfiles.Finder.JavaPath()
+		if err != nil {
+			return nil, nil, "", err
+		}
+		var classPath []string
+		for _, p := range fuzzer.RuntimePaths {
+			classPath = append(classPath, bundlePath(p))
+		}
+		targetClass, targetMethod, _ := strings.Cut(fuzzer.Name, "::")
+		args := []string{
+			javaBin,
+			"-cp", strings.Join(classPath, string(os.PathListSeparator)),
+			options.JazzerMainClass,
+			options.JazzerTargetClassFlag(targetClass),
+		}
+		if targetMethod != "" {
+			args = append(args, options.JazzerTargetMethodFlag(targetMethod))
+		}
+		args = append(args, "-runs=0")
+		return args, env, bundleDir, nil
+
+	case "JAVASCRIPT_LIBFUZZER":
+		// Without JAZZER_FUZZ, Jest runs the fuzz tests in regression
+		// mode, i.e. only on the seed inputs
+		testFile := strings.TrimPrefix(fuzzer.Path, "project/")
+		args := []string{"npx", "jest", "--testPathPattern", regexp.QuoteMeta(testFile)}
+		if fuzzer.Target != "" {
+			args = append(args, "--testNamePattern", fuzzer.Target)
+		}
+		return args, env, bundlePath("project"), nil
+	}
+	return nil, nil, "", nil
+}
+
+// lastLines returns the last n lines of the output, which contain the
+// error message of the fuzz test
+func lastLines(output string, n int) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
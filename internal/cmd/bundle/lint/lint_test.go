@@ -0,0 +1,104 @@
+package lint
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+)
+
+// createBundle creates a bundle with the given metadata and files,
+// which are specified by their path in the bundle and their content
+func createBundle(t *testing.T, metadata string, files map[string]string) string {
+	dir := t.TempDir()
+	srcDir := filepath.Join(dir, "src")
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "work_dir"), 0o755))
+	files[archive.MetadataFileName] = metadata
+	for path, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(srcDir, path)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(srcDir, path), []byte(content), 0o755))
+	}
+
+	bundlePath := filepath.Join(dir, "bundle.tar.gz")
+	f, err := os.Create(bundlePath)
+	require.NoError(t, err)
+	defer f.Close()
+	bufWriter := bufio.NewWriter(f)
+	archiveWriter := archive.NewTarArchiveWriter(bufWriter, true)
+	require.NoError(t, archiveWriter.WriteDir("", srcDir))
+	require.NoError(t, archiveWriter.Close())
+	require.NoError(t, bufWriter.Flush())
+	return bundlePath
+}
+
+// newCommand returns the command without the usage message, which the
+// root command disables
+func newCommand() *cobra.Command {
+	cmd := New()
+	cmd.SilenceUsage = true
+	return cmd
+}
+
+func TestLint(t *testing.T) {
+	bundlePath := createBundle(t, `run_environment:
+  docker: ubuntu:rolling
+fuzzers:
+  - name: my_fuzz_test
+    path: bin/my_fuzz_test
+    engine: LIBFUZZER
+    dictionary: my_fuzz_test.dict
+`, map[string]string{"bin/my_fuzz_test": ""})
+
+	stdout, _, err := cmdutils.ExecuteCommand(t, newCommand(), os.Stdin, "--json", bundlePath)
+	require.Error(t, err)
+	assert.JSONEq(t, `{"problems": [{
+		"fuzzer": "my_fuzz_test (LIBFUZZER)",
+		"message": "Invalid dictionary \"my_fuzz_test.dict\": no such file or directory in the bundle"
+	}]}`, stdout)
+}
+
+func TestLint_DryRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The fuzz tests are shell scripts")
+	}
+
+	bundlePath := createBundle(t, `run_environment:
+  docker: ubuntu:rolling
+fuzzers:
+  - name: good_fuzz_test
+    path: bin/good_fuzz_test
+    engine: LIBFUZZER
+  - name: bad_fuzz_test
+    path: bin/bad_fuzz_test
+    engine: LIBFUZZER
+`, map[string]string{
+		"bin/good_fuzz_test": "#!/bin/sh\ntest \"$1\" = -runs=0\n",
+		"bin/bad_fuzz_test":  "#!/bin/sh\necho 'error while loading shared libraries: libfoo.so'\nexit 127\n",
+	})
+
+	stdout, _, err := cmdutils.ExecuteCommand(t, newCommand(), os.Stdin, "--json", "--dry-run", bundlePath)
+	require.Error(t, err)
+	assert.JSONEq(t, `{"problems": [{
+		"fuzzer": "bad_fuzz_test (LIBFUZZER)",
+		"message": "The dry run failed: exit status 127\nerror while loading shared libraries: libfoo.so"
+	}]}`, stdout)
+
+	// Without the failing fuzz test, no problems are found
+	bundlePath = createBundle(t, `run_environment:
+  docker: ubuntu:rolling
+fuzzers:
+  - name: good_fuzz_test
+    path: bin/good_fuzz_test
+    engine: LIBFUZZER
+`, map[string]string{"bin/good_fuzz_test": "#!/bin/sh\ntest \"$1\" = -runs=0\n"})
+	_, _, err = cmdutils.ExecuteCommand(t, newCommand(), os.Stdin, "--dry-run", bundlePath)
+	require.NoError(t, err)
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	configMigrateCmd "code-intelligence.com/cifuzz/internal/cmd/config/migrate"
+)
+
+func New() *cobra.Command {
+	return newWithOptions()
+}
+
+func newWithOptions() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the cifuzz.yaml project config",
+		RunE: func(c *cobra.Command, args []string) error {
+			_ = c.Help()
+			return nil
+		},
+	}
+
+	cmd.AddCommand(configMigrateCmd.New())
+
+	return cmd
+}
@@ -0,0 +1,98 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+type options struct {
+	DryRun bool
+}
+
+type migrateCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade the cifuzz.yaml to the schema of this cifuzz version",
+		Long: `This command upgrades a cifuzz.yaml which was written for an older
+version of cifuzz to the schema of this version, for example by
+renaming keys which were renamed since then. The changes are printed
+as a diff.
+
+Comments and formatting of the cifuzz.yaml are preserved.
+
+Use --dry-run to only print the diff without changing the file.`,
+		Args: cobra.NoArgs,
+		// We don't parse the project config here, because parsing fails
+		// for some of the configs which this command migrates
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := migrateCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Only print the changes without writing them to the cifuzz.yaml")
+
+	return cmd
+}
+
+func (c *migrateCmd) run() error {
+	configDir, err := config.FindConfigDir()
+	if err != nil {
+		return err
+	}
+	configPath := filepath.Join(configDir, config.ProjectConfigFile)
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	migrated, changes := config.MigrateProjectConfig(string(content))
+	if len(changes) == 0 {
+		log.Successf("%s is already up to date", config.ProjectConfigFile)
+		return nil
+	}
+
+	diff, err := config.UnifiedDiff(config.ProjectConfigFile, string(content), migrated)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(c.OutOrStdout(), diff)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, change := range changes {
+		log.Info(change)
+	}
+
+	if c.opts.DryRun || migrated == string(content) {
+		return nil
+	}
+
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.WriteFile(configPath, []byte(migrated), info.Mode())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	log.Successf("Migrated %s", configPath)
+	return nil
+}
@@ -0,0 +1,208 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/docker/distribution/reference"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/bundler"
+	"code-intelligence.com/cifuzz/internal/cmd/bundle"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/logging"
+	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/container"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+type containerBuildOpts struct {
+	bundler.Opts      `mapstructure:",squash"`
+	ContainerPackages []string `mapstructure:"container-packages"`
+
+	Tags         []string `mapstructure:"-"`
+	Push         bool     `mapstructure:"-"`
+	ImageArchive string   `mapstructure:"-"`
+}
+
+type containerBuildCmd struct {
+	*cobra.Command
+	opts *containerBuildOpts
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&containerBuildOpts{})
+}
+
+func (opts *containerBuildOpts) Validate() error {
+	for _, tag := range opts.Tags {
+		_, err := reference.ParseNormalizedNamed(tag)
+		if err != nil {
+			return cmdutils.WrapIncorrectUsageError(errors.Errorf("Invalid image tag %q: %v", tag, err))
+		}
+	}
+	if opts.Push && len(opts.Tags) == 0 {
+		msg := "The --push flag requires at least one --tag which specifies the registry and repository"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	err := container.ValidatePackages(opts.ContainerPackages)
+	if err != nil {
+		return err
+	}
+	return opts.Opts.Validate()
+}
+
+func newWithOptions(opts *containerBuildOpts) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "build [flags] [<fuzz test>]... [--] [<build system arg>...]",
+		Short: "Build a container image of fuzz tests",
+		Long: `This command builds a container image which contains the bundle of
+the given fuzz tests, i.e. the fuzz tests, their runtime dependencies
+and seed corpora (see 'cifuzz bundle'), and cifuzz as entrypoint. When
+the container is started, it runs the fuzz tests of the bundle via
+'cifuzz execute', so the image is ready to run on any container
+runtime or CI system:
+
+    cifuzz container build my_fuzz_test --tag ghcr.io/my-org/my-fuzz-tests:latest --push
+    docker run ghcr.io/my-org/my-fuzz-tests:latest my_fuzz_test
+
+The base image is selected based on the build system, it can be
+overridden with the --docker-image flag or the docker-image setting in
+cifuzz.yaml. System packages which the fuzz tests need at runtime can be
+installed into the image via the container-packages setting. A Docker
+daemon is required to build the image.
+
+The image is always tagged "cifuzz", additional tags can be specified
+via --tag. With --push, the image is pushed to the registries of the
+tags, using the credentials of the Docker config file, like for
+'docker push'. With --output, the image is written as a tar archive,
+which can be loaded via 'docker load' or copied to a registry with
+tools like skopeo or crane.
+
+If no fuzz tests are specified, all fuzz tests are added to the image,
+see the help of 'cifuzz bundle' for the supported build systems.`,
+		ValidArgsFunction: completion.ValidFuzzTests,
+		Args:              cobra.ArbitraryArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+
+			var argsToPass []string
+			if cmd.ArgsLenAtDash() != -1 {
+				argsToPass = args[cmd.ArgsLenAtDash():]
+				args = args[:cmd.ArgsLenAtDash()]
+			}
+
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			fuzzTests, err := resolve.FuzzTestArguments(opts.ResolveSourceFilePath, args, opts.BuildSystem, opts.ProjectDir)
+			if err != nil {
+				return err
+			}
+			opts.FuzzTests = fuzzTests
+			opts.BuildSystemArgs = argsToPass
+
+			return opts.Validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := &containerBuildCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddAdditionalFilesFlag,
+		cmdutils.AddBranchFlag,
+		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddCleanCommandFlag,
+		cmdutils.AddBuildJobsFlag,
+		cmdutils.AddCommitFlag,
+		cmdutils.AddDictFlag,
+		cmdutils.AddDockerImageFlagForContainerCommand,
+		cmdutils.AddEngineArgFlag,
+		cmdutils.AddEnvFlag,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddSeedCorpusFlag,
+		cmdutils.AddTimeoutFlag,
+		cmdutils.AddResolveSourceFileFlag,
+	)
+	cmd.Flags().StringArrayVarP(&opts.Tags, "tag", "t", nil,
+		"Tag the image with the specified `name`, e.g. ghcr.io/my-org/my-fuzz-tests:latest. This flag can be used multiple times.")
+	cmd.Flags().BoolVar(&opts.Push, "push", false,
+		"Push the image to the registries of the tags.")
+	cmd.Flags().StringVarP(&opts.ImageArchive, "output", "o", "",
+		"Write the image as a tar archive to the specified `file`.")
+
+	return cmd
+}
+
+func (c *containerBuildCmd) run() error {
+	// The bundle is only needed to build the image
+	tempDir, err := os.MkdirTemp("", "cifuzz-container-build-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tempDir)
+	c.opts.OutputPath = filepath.Join(tempDir, "bundle.tar.gz")
+
+	err = bundle.SetUpBundleLogging(c.OutOrStdout(), c.ErrOrStderr(), &c.opts.Opts)
+	if err != nil {
+		return err
+	}
+
+	buildPrinter := logging.NewBuildPrinter(c.OutOrStdout(), log.ContainerBuildInProgressMsg)
+	imageID, err := c.buildImage()
+	if err != nil {
+		buildPrinter.StopOnError(log.ContainerBuildInProgressErrorMsg)
+		return err
+	}
+	buildPrinter.StopOnSuccess(log.ContainerBuildInProgressSuccessMsg, false)
+	log.Successf("Built container image %s", imageID)
+
+	if c.opts.ImageArchive != "" {
+		// Saving the image by its tag keeps the tag in the archive
+		image := imageID
+		if len(c.opts.Tags) > 0 {
+			image = c.opts.Tags[0]
+		}
+		err = container.SaveImage(image, c.opts.ImageArchive)
+		if err != nil {
+			return err
+		}
+		log.Successf("Wrote the container image to %s", c.opts.ImageArchive)
+	}
+
+	if c.opts.Push {
+		for _, tag := range c.opts.Tags {
+			err = container.PushImage(tag)
+			if err != nil {
+				return errors.WithMessagef(err, "Failed to push the container image to %s", tag)
+			}
+			log.Successf("Pushed the container image to %s", tag)
+		}
+	}
+
+	return nil
+}
+
+func (c *containerBuildCmd) buildImage() (string, error) {
+	bundlePath, err := bundler.New(&c.opts.Opts).Bundle()
+	if err != nil {
+		return "", errors.WithMessage(err, "Failed to create bundle")
+	}
+	return container.BuildImageFromBundle(bundlePath, &container.ImageOptions{
+		Tags:     c.opts.Tags,
+		Packages: c.opts.ContainerPackages,
+	})
+}
@@ -0,0 +1,54 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+)
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		opts          containerBuildOpts
+		expectedError string
+		usageError    bool
+	}{
+		{name: "no tags"},
+		{
+			name: "valid tags",
+			opts: containerBuildOpts{Tags: []string{"fuzz-tests", "ghcr.io/my-org/fuzz-tests:v1"}, Push: true},
+		},
+		{
+			name:          "invalid tag",
+			opts:          containerBuildOpts{Tags: []string{"Invalid:Tag:"}},
+			expectedError: `Invalid image tag "Invalid:Tag:"`,
+			usageError:    true,
+		},
+		{
+			name:          "push without tag",
+			opts:          containerBuildOpts{Push: true},
+			expectedError: "The --push flag requires at least one --tag",
+			usageError:    true,
+		},
+		{
+			name:          "invalid package",
+			opts:          containerBuildOpts{ContainerPackages: []string{"libssl3; rm -rf /"}},
+			expectedError: `Invalid package name "libssl3; rm -rf /"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.opts.Validate()
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.expectedError)
+			var usageErr *cmdutils.IncorrectUsageError
+			assert.Equal(t, tc.usageError, errors.As(err, &usageErr))
+		})
+	}
+}
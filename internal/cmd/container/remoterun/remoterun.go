@@ -27,6 +27,8 @@ type containerRemoteRunOpts struct {
 	PrintJSON    bool   `mapstructure:"print-json"`
 	Project      string `mapstructure:"project"` // CI Sense
 	Registry     string `mapstructure:"registry"`
+
+	ContainerPackages []string `mapstructure:"container-packages"`
 }
 
 type containerRemoteRunCmd struct {
@@ -39,6 +41,14 @@ func New() *cobra.Command {
 	return newWithOptions(&containerRemoteRunOpts{})
 }
 
+func (opts *containerRemoteRunOpts) Validate() error {
+	err := container.ValidatePackages(opts.ContainerPackages)
+	if err != nil {
+		return err
+	}
+	return opts.Opts.Validate()
+}
+
 func newWithOptions(opts *containerRemoteRunOpts) *cobra.Command {
 	var bindFlags func()
 
@@ -207,5 +217,5 @@ func (c *containerRemoteRunCmd) buildImage() (string, error) {
 		return "", err
 	}
 
-	return container.BuildImageFromBundle(bundlePath)
+	return container.BuildImageFromBundle(bundlePath, &container.ImageOptions{Packages: c.opts.ContainerPackages})
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/docker/go-units"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
@@ -23,13 +24,19 @@ import (
 )
 
 type containerRunOpts struct {
-	bundler.Opts  `mapstructure:",squash"`
-	PrintJSON     bool     `mapstructure:"print-json"`
-	Interactive   bool     `mapstructure:"interactive"`
-	Server        string   `mapstructure:"server"`
-	ContainerPath string   `mapstructure:"container"`
-	BindMounts    []string `mapstructure:"bind-mounts"`
-	BuildOnly     bool     `mapstructure:"build-only"`
+	bundler.Opts      `mapstructure:",squash"`
+	PrintJSON         bool     `mapstructure:"print-json"`
+	Interactive       bool     `mapstructure:"interactive"`
+	Server            string   `mapstructure:"server"`
+	ContainerPath     string   `mapstructure:"container"`
+	BindMounts        []string `mapstructure:"bind-mounts"`
+	BuildOnly         bool     `mapstructure:"build-only"`
+	ContainerPackages []string `mapstructure:"container-packages"`
+	Memory            string   `mapstructure:"-"`
+	CPUs              float64  `mapstructure:"-"`
+	NoNetwork         bool     `mapstructure:"-"`
+
+	memoryBytes int64
 }
 
 type containerRunCmd struct {
@@ -42,6 +49,22 @@ func New() *cobra.Command {
 }
 
 func (opts *containerRunOpts) Validate() error {
+	if opts.Memory != "" {
+		var err error
+		opts.memoryBytes, err = units.RAMInBytes(opts.Memory)
+		if err != nil || opts.memoryBytes <= 0 {
+			msg := fmt.Sprintf("Invalid memory limit %q, valid limits are e.g. 512m or 4g", opts.Memory)
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+	}
+	if opts.CPUs < 0 {
+		msg := fmt.Sprintf("Invalid number of CPUs %v, it must not be negative", opts.CPUs)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	err := container.ValidatePackages(opts.ContainerPackages)
+	if err != nil {
+		return err
+	}
 	return opts.Opts.Validate()
 }
 
@@ -53,7 +76,27 @@ func newWithOptions(opts *containerRunOpts) *cobra.Command {
 		Short: "Build and run a Fuzz Test container image locally",
 		Long: `This command builds and runs a Fuzz Test container image locally.
 It can be used as a containerized version of the 'cifuzz bundle' command, where the
-container is built and run locally instead of being pushed to a CI Sense server.`,
+container is built and run locally instead of being pushed to a CI Sense server.
+
+The image is based on the Docker image of the bundle (see --docker-image),
+so the fuzz test runs in the same environment as remote runs on CI Sense,
+isolated from the host. Only the current working directory is mounted
+into the container, so that findings and the generated corpus are stored
+on the host. The container is removed after the run.
+
+Use --memory and --cpus to limit the resources of the container and
+--no-network to run the fuzz test without network access.
+
+The container is run via the Docker daemon configured via DOCKER_HOST or
+the default Docker socket. If the Docker daemon is not running, the
+Docker-compatible API of Podman is used, which is provided by the
+podman.socket systemd unit or by 'podman system service' (or the Podman
+machine on macOS), preferring the socket of rootless Podman. The socket
+can also be set via CONTAINER_HOST.
+
+With rootless Podman, the user is mapped into the container, so that the
+files written to the working directory are owned by the user. Memory and CPU
+limits require cgroup v2 with rootless engines.`,
 		ValidArgsFunction: completion.ValidFuzzTests,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			// Bind viper keys to flags. We can't do this in the New
@@ -128,6 +171,9 @@ container is built and run locally instead of being pushed to a CI Sense server.
 	cmd.Flags().StringArrayVar(&opts.BindMounts, "bind", nil, "Bind mount a directory from the host into the container. "+
 		"Format: --bind <src-path>:<dest-path>")
 	cmd.Flags().BoolVar(&opts.BuildOnly, "build-only", false, "Only build the container image, don't run it.")
+	cmd.Flags().StringVar(&opts.Memory, "memory", "", "Memory limit of the container, e.g. 4g. By default, the memory is not limited.")
+	cmd.Flags().Float64Var(&opts.CPUs, "cpus", 0, "Number of CPUs the container can use, e.g. 1.5. By default, the CPUs are not limited.")
+	cmd.Flags().BoolVar(&opts.NoNetwork, "no-network", false, "Run the container without network access.")
 
 	// For now the --bind flag is only used for tests, so we hide it from the help output.
 	err := cmd.Flags().MarkHidden("bind")
@@ -171,10 +217,23 @@ func (c *containerRunCmd) run() error {
 		return nil
 	}
 
-	containerID, err := container.Create(imageID, c.opts.PrintJSON, c.opts.BindMounts, c.opts.ContainerArgs)
+	containerID, err := container.Create(imageID, &container.CreateOptions{
+		PrintJSON:  c.opts.PrintJSON,
+		BindMounts: c.opts.BindMounts,
+		Args:       c.opts.ContainerArgs,
+		Memory:     c.opts.memoryBytes,
+		CPUs:       c.opts.CPUs,
+		NoNetwork:  c.opts.NoNetwork,
+	})
 	if err != nil {
 		return err
 	}
+	defer func() {
+		err := container.Remove(containerID)
+		if err != nil {
+			log.Warnf("Failed to remove container %s: %v", containerID, err)
+		}
+	}()
 
 	err = container.Run(containerID, c.OutOrStdout(), c.ErrOrStderr())
 	if err != nil {
@@ -196,5 +255,5 @@ func (c *containerRunCmd) buildContainerImage(buildOutput io.Writer) (string, er
 		return "", errors.WithMessage(err, "Failed to create bundle")
 	}
 
-	return container.BuildImageFromBundle(bundlePath)
+	return container.BuildImageFromBundle(bundlePath, &container.ImageOptions{Packages: c.opts.ContainerPackages})
 }
@@ -0,0 +1,36 @@
+package corpus
+
+import (
+	"github.com/spf13/cobra"
+
+	corpusExtractCmd "code-intelligence.com/cifuzz/internal/cmd/corpus/extract"
+	corpusImportCmd "code-intelligence.com/cifuzz/internal/cmd/corpus/import"
+	corpusPruneCmd "code-intelligence.com/cifuzz/internal/cmd/corpus/prune"
+	corpusPullCmd "code-intelligence.com/cifuzz/internal/cmd/corpus/pull"
+	corpusStatsCmd "code-intelligence.com/cifuzz/internal/cmd/corpus/stats"
+)
+
+func New() *cobra.Command {
+	return newWithOptions()
+}
+
+func newWithOptions() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "corpus",
+		Short: "Manage the corpus of fuzz tests",
+		Long: `Manage the generated corpus which cifuzz stores for each fuzz test
+in the .cifuzz-corpus directory.`,
+		RunE: func(c *cobra.Command, args []string) error {
+			_ = c.Help()
+			return nil
+		},
+	}
+
+	cmd.AddCommand(corpusExtractCmd.New())
+	cmd.AddCommand(corpusImportCmd.New())
+	cmd.AddCommand(corpusPruneCmd.New())
+	cmd.AddCommand(corpusPullCmd.New())
+	cmd.AddCommand(corpusStatsCmd.New())
+
+	return cmd
+}
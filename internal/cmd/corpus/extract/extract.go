@@ -0,0 +1,286 @@
+package extract
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/corpus"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// The recorder defines a function with the given name, so it must be a
+// valid C identifier (which mangled C++ names are as well)
+var functionNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type options struct {
+	BuildSystem string `mapstructure:"build-system"`
+	ProjectDir  string `mapstructure:"project-dir"`
+	ConfigDir   string `mapstructure:"config-dir"`
+
+	ResolveSourceFilePath bool
+
+	Function    string `mapstructure:"-"`
+	TestCommand string `mapstructure:"-"`
+	Output      string `mapstructure:"-"`
+	MaxSize     uint   `mapstructure:"-"`
+
+	fuzzTest string
+}
+
+type extractCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "extract [flags] <fuzz test>",
+		Short: "Extract seed inputs for a fuzz test from the unit tests",
+		Long: `This command runs the unit tests of the project and records the
+inputs which the tests pass to the function under test, so that they
+can be used as seed inputs of the fuzz test. The recorded inputs are
+added to the generated corpus of the fuzz test in the .cifuzz-corpus
+directory, or to the directory specified via --output, for example
+the seed corpus directory of the fuzz test. Inputs which already exist
+there are skipped.
+
+The function is specified via --function. It must take a pointer to
+the data as its first and the size of the data as its second argument,
+like the fuzz test function, and it must be defined in a shared library,
+because the inputs are recorded by a library which is preloaded into
+the tests via LD_PRELOAD. For C++ functions, the mangled name must be
+used. The command which runs the unit tests is specified via
+--test-command, for example:
+
+    cifuzz corpus extract --function=parse_request \
+        --test-command="ctest --test-dir build" my_fuzz_test
+
+Extracting seed inputs is supported on Linux for C/C++ projects built
+with CMake or other build systems. It's not supported for Java and
+JavaScript projects.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ValidFuzzTests,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			err = checkSupported(opts.BuildSystem, runtime.GOOS)
+			if err != nil {
+				return err
+			}
+
+			if opts.Function == "" {
+				msg := "No function specified, please specify the function whose inputs are recorded via --function"
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			if !functionNameRegex.MatchString(opts.Function) {
+				msg := fmt.Sprintf("Invalid function name %q", opts.Function)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			if opts.TestCommand == "" {
+				msg := "No test command specified, please specify the command which runs the unit tests via --test-command"
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
+			fuzzTests, err := resolve.FuzzTestArguments(opts.ResolveSourceFilePath, args, opts.BuildSystem, opts.ProjectDir)
+			if err != nil {
+				return err
+			}
+			opts.fuzzTest = fuzzTests[0]
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := extractCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via cifuzz.yaml as well,
+	// bind it to viper in the PreRunE function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddResolveSourceFileFlag,
+	)
+	cmd.Flags().StringVar(&opts.Function, "function", "",
+		"The function whose inputs are recorded. It must take the data and its size as the first two arguments.")
+	cmd.Flags().StringVar(&opts.TestCommand, "test-command", "",
+		"The command which runs the unit tests, it's executed in the project directory.")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "",
+		"The directory to which the recorded inputs are added.\n"+
+			"By default, they are added to the generated corpus of the fuzz test.")
+	cmd.Flags().UintVar(&opts.MaxSize, "max-size", 0,
+		"Don't record inputs which are larger than the specified number of bytes.")
+
+	return cmd
+}
+
+// checkSupported returns an error if extracting seed inputs is not
+// supported for the build system on the OS
+func checkSupported(buildSystem, goos string) error {
+	switch buildSystem {
+	case config.BuildSystemCMake, config.BuildSystemOther:
+	case config.BuildSystemMaven, config.BuildSystemGradle:
+		// The inputs are recorded by a shared library which is preloaded
+		// into the tests, which doesn't work for functions executed by
+		// the JVM
+		return errors.New(`Extracting seed inputs is not supported for Java projects.
+Please add inputs to the seed corpus directory of the fuzz test instead.`)
+	default:
+		return errors.New(config.NotSupportedErrorMessage("corpus extract", buildSystem))
+	}
+	if goos != "linux" {
+		return errors.New(config.NotSupportedErrorMessage("corpus extract", goos))
+	}
+	return nil
+}
+
+func (c *extractCmd) run() error {
+	outputDir := c.opts.Output
+	if outputDir == "" {
+		outputDir = filepath.Join(c.opts.ProjectDir, ".cifuzz-corpus", c.opts.fuzzTest)
+	}
+
+	compiler := envutil.GetEnvWithPathSubstring(os.Environ(), "CC", "clang")
+	if compiler == "" {
+		var err error
+		compiler, err = runfiles.Finder.ClangPath()
+		if err != nil {
+			return err
+		}
+	}
+	recorderSource, err := runfiles.Finder.RecorderSourcePath()
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "cifuzz-corpus-extract-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tmpDir)
+
+	recorder := filepath.Join(tmpDir, "librecorder.so")
+	err = compileRecorder(compiler, recorderSource, c.opts.Function, recorder)
+	if err != nil {
+		return err
+	}
+
+	recordedDir := filepath.Join(tmpDir, "inputs")
+	err = os.Mkdir(recordedDir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	log.Infof("Running the unit tests to record the inputs of %s", c.opts.Function)
+	err = runTests(c.opts.TestCommand, c.opts.ProjectDir, recorder, recordedDir, c.opts.MaxSize)
+	if err != nil {
+		// Inputs recorded before a test failed are still useful as
+		// seeds, so we only print a warning
+		log.Warnf("The unit tests failed: %v", err)
+	}
+
+	numImported, numSkipped, err := corpus.Import(recordedDir, outputDir)
+	if err != nil {
+		return err
+	}
+	if numImported+numSkipped == 0 {
+		log.Warnf(`No inputs of %s were recorded. Make sure that the unit tests call the
+function and that it's defined in a shared library.`, c.opts.Function)
+		return nil
+	}
+	log.Successf("Added %d inputs to %s (%d inputs already existed)",
+		numImported, fileutil.PrettifyPath(outputDir), numSkipped)
+	return nil
+}
+
+// compileRecorder compiles the recorder into a shared library which
+// records the inputs of the given function
+func compileRecorder(compiler, source, function, dest string) error {
+	cmd := exec.Command(compiler, "-shared", "-fPIC", "-O1",
+		"-DCIFUZZ_RECORDED_FUNCTION="+function,
+		"-o", dest, source, "-ldl")
+	cmd.Stderr = os.Stderr
+	log.Debugf("Command: %s", cmd.String())
+	err := cmd.Run()
+	if err != nil {
+		return cmdutils.WrapExecError(errors.WithStack(err), cmd)
+	}
+	return nil
+}
+
+// runTests runs the test command with the recorder preloaded, which
+// writes the recorded inputs to outputDir
+func runTests(testCommand, projectDir, recorder, outputDir string, maxSize uint) error {
+	env := os.Environ()
+	env, err := envutil.Setenv(env, "LD_PRELOAD", envutil.AppendToPathList(os.Getenv("LD_PRELOAD"), recorder))
+	if err != nil {
+		return err
+	}
+	env, err = envutil.Setenv(env, "CIFUZZ_RECORDER_OUTPUT_DIR", outputDir)
+	if err != nil {
+		return err
+	}
+	if maxSize > 0 {
+		env, err = envutil.Setenv(env, "CIFUZZ_RECORDER_MAX_SIZE", strconv.FormatUint(uint64(maxSize), 10))
+		if err != nil {
+			return err
+		}
+	}
+	// Pass the sanitizer options from cifuzz.yaml to the tests
+	sanitizerOptions, err := config.SanitizerOptionsEnvVars()
+	if err != nil {
+		return err
+	}
+	env, err = envutil.Copy(env, sanitizerOptions)
+	if err != nil {
+		return err
+	}
+	// The AddressSanitizer runtime must be loaded before all other
+	// libraries, which is not the case if the tests are instrumented
+	// and the recorder is preloaded
+	asanOptions := config.MergeSanitizerOptions(envutil.Getenv(env, "ASAN_OPTIONS"),
+		map[string]string{"verify_asan_link_order": "0"})
+	env, err = envutil.Setenv(env, "ASAN_OPTIONS", asanOptions)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("/bin/sh", "-c", testCommand)
+	cmd.Dir = projectDir
+	cmd.Env = env
+	// The output of the tests is not the output of this command
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	log.Debugf("Command: %s", envutil.QuotedCommandWithEnv(cmd.Args, []string{"LD_PRELOAD=" + recorder}))
+	err = cmd.Run()
+	if err != nil {
+		return cmdutils.WrapExecError(errors.WithStack(err), cmd)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package extract
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const libSource = `
+#include <stddef.h>
+#include <stdint.h>
+
+int parse(const uint8_t *data, size_t size, int flags) {
+  return size > 0 && data[0] == 'x' ? flags : 0;
+}
+`
+
+const testSource = `
+#include <stddef.h>
+#include <stdint.h>
+#include <stdlib.h>
+
+int parse(const uint8_t *data, size_t size, int flags);
+
+int main(void) {
+  if (parse((const uint8_t *) "xyz", 3, 42) != 42) abort();
+  if (parse((const uint8_t *) "abcdef", 6, 42) != 0) abort();
+  if (parse((const uint8_t *) "xyz", 3, 42) != 42) abort();
+  return 0;
+}
+`
+
+func TestRecordInputs(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Extracting inputs is only supported on Linux")
+	}
+	compiler, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("No C compiler found")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib.c"), []byte(libSource), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test.c"), []byte(testSource), 0o644))
+	out, err := exec.Command(compiler, "-shared", "-fPIC", "-o", filepath.Join(dir, "libparse.so"),
+		filepath.Join(dir, "lib.c")).CombinedOutput()
+	require.NoError(t, err, string(out))
+	out, err = exec.Command(compiler, "-o", filepath.Join(dir, "unit_test"), filepath.Join(dir, "test.c"),
+		"-L"+dir, "-lparse", "-Wl,-rpath,"+dir).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	recorderSource, err := filepath.Abs(filepath.Join("..", "..", "..", "..", "tools", "recorder", "recorder.c"))
+	require.NoError(t, err)
+	recorder := filepath.Join(dir, "librecorder.so")
+	err = compileRecorder(compiler, recorderSource, "parse", recorder)
+	require.NoError(t, err)
+
+	recordedDir := filepath.Join(dir, "inputs")
+	require.NoError(t, os.Mkdir(recordedDir, 0o755))
+	err = runTests("./unit_test", dir, recorder, recordedDir, 0)
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(recordedDir)
+	require.NoError(t, err)
+	var inputs []string
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(recordedDir, entry.Name()))
+		require.NoError(t, err)
+		inputs = append(inputs, string(data))
+	}
+	assert.ElementsMatch(t, []string{"xyz", "abcdef", "xyz"}, inputs)
+
+	// Inputs larger than the maximum size are not recorded
+	require.NoError(t, os.RemoveAll(recordedDir))
+	require.NoError(t, os.Mkdir(recordedDir, 0o755))
+	err = runTests("./unit_test", dir, recorder, recordedDir, 3)
+	require.NoError(t, err)
+	entries, err = os.ReadDir(recordedDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestCheckSupported(t *testing.T) {
+	assert.NoError(t, checkSupported("cmake", "linux"))
+	assert.NoError(t, checkSupported("other", "linux"))
+	assert.Error(t, checkSupported("cmake", "darwin"))
+	assert.Error(t, checkSupported("nodejs", "linux"))
+
+	for _, buildSystem := range []string{"maven", "gradle"} {
+		err := checkSupported(buildSystem, "linux")
+		assert.ErrorContains(t, err, "not supported for Java projects")
+	}
+}
@@ -0,0 +1,173 @@
+package corpusimport
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmd/remoterun/progress"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/corpus"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// The public corpus backups of OSS-Fuzz projects, which are created
+// daily by ClusterFuzz. The placeholders are the OSS-Fuzz project, the
+// project again and the fuzz target.
+var ossFuzzCorpusURL = "https://storage.googleapis.com/%s-backup.clusterfuzz-external.appspot.com/corpus/libFuzzer/%s_%s/public.zip"
+
+type options struct {
+	BuildSystem string `mapstructure:"build-system"`
+	ProjectDir  string `mapstructure:"project-dir"`
+	ConfigDir   string `mapstructure:"config-dir"`
+
+	ResolveSourceFilePath bool
+
+	OSSFuzz string `mapstructure:"-"`
+
+	fuzzTest string
+}
+
+type importCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "import [flags] <fuzz test>",
+		Short: "Import inputs into the generated corpus of a fuzz test",
+		Long: `This command imports inputs from an external corpus into the
+generated corpus of the fuzz test in the .cifuzz-corpus directory,
+so that fuzzing continues from there. Inputs which already exist in
+the generated corpus are skipped.
+
+` + "`--oss-fuzz=<project>/<target>`" + ` imports the public corpus of the fuzz target
+<target> of the OSS-Fuzz project <project>, for example:
+
+    cifuzz corpus import --oss-fuzz=libpng/libpng_read_fuzzer my_fuzz_test
+
+Importing a corpus is supported for C/C++ projects built with CMake
+or other build systems and for Java projects.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ValidFuzzTests,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			if opts.OSSFuzz == "" {
+				msg := "No corpus specified, currently only --oss-fuzz is supported"
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			project, target, ok := strings.Cut(opts.OSSFuzz, "/")
+			if !ok || project == "" || target == "" || strings.Contains(target, "/") {
+				msg := fmt.Sprintf("Invalid value %q for --oss-fuzz, the format is <project>/<target>", opts.OSSFuzz)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
+			switch opts.BuildSystem {
+			case config.BuildSystemBazel, config.BuildSystemNodeJS:
+				return errors.Errorf(config.NotSupportedErrorMessage("corpus import", opts.BuildSystem))
+			}
+
+			fuzzTests, err := resolve.FuzzTestArguments(opts.ResolveSourceFilePath, args, opts.BuildSystem, opts.ProjectDir)
+			if err != nil {
+				return err
+			}
+			opts.fuzzTest = fuzzTests[0]
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := importCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via cifuzz.yaml as well,
+	// bind it to viper in the PreRunE function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddResolveSourceFileFlag,
+	)
+	cmd.Flags().StringVar(&opts.OSSFuzz, "oss-fuzz", "",
+		"Import the public corpus of an OSS-Fuzz fuzz target, specified as <project>/<target>.")
+
+	return cmd
+}
+
+func (c *importCmd) run() error {
+	tmpDir, err := os.MkdirTemp("", "cifuzz-corpus-import-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tmpDir)
+
+	project, target, _ := strings.Cut(c.opts.OSSFuzz, "/")
+	zipPath := filepath.Join(tmpDir, "public.zip")
+	err = downloadOSSFuzzCorpus(project, target, zipPath)
+	if err != nil {
+		return err
+	}
+	corpusDir, numImported, numSkipped, err := corpus.ImportZip(zipPath, c.opts.ProjectDir, c.opts.fuzzTest)
+	if err != nil {
+		return err
+	}
+	log.Successf("Imported %d inputs into %s (%d inputs already existed)",
+		numImported, fileutil.PrettifyPath(corpusDir), numSkipped)
+	return nil
+}
+
+func downloadOSSFuzzCorpus(project, target, dest string) error {
+	url := fmt.Sprintf(ossFuzzCorpusURL, project, project, target)
+	log.Infof("Downloading %s", url)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		return errors.Errorf("No public corpus found for the fuzz target %s of the OSS-Fuzz project %s", target, project)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("Failed to download %s: %s", url, resp.Status)
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	var body io.Reader = resp.Body
+	if resp.ContentLength > 0 {
+		body = progress.NewReader(resp.Body, resp.ContentLength, "Download completed")
+	}
+	_, err = io.Copy(f, body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(f.Close())
+}
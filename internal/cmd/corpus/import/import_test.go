@@ -0,0 +1,48 @@
+package corpusimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadOSSFuzzCorpus(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("entry")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("data"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/libpng/libpng/libpng_read_fuzzer/public.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	origURL := ossFuzzCorpusURL
+	defer func() { ossFuzzCorpusURL = origURL }()
+	ossFuzzCorpusURL = server.URL + "/%s/%s/%s/public.zip"
+
+	dest := filepath.Join(t.TempDir(), "public.zip")
+	err = downloadOSSFuzzCorpus("libpng", "libpng_read_fuzzer", dest)
+	require.NoError(t, err)
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, buf.Bytes(), data)
+
+	err = downloadOSSFuzzCorpus("libpng", "unknown_fuzzer", dest)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "No public corpus found")
+}
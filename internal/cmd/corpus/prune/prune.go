@@ -0,0 +1,129 @@
+package prune
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmd/run/adapter"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/logging"
+	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+)
+
+func New() *cobra.Command {
+	opts := &adapter.RunOptions{PruneCorpus: true}
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "prune [flags] <fuzz test> [--] [<build system arg>...]",
+		Short: "Remove redundant inputs from the generated corpus of a fuzz test",
+		Long: `This command builds the fuzz test and runs libFuzzer's merge mode on
+its generated corpus, which only keeps the inputs which add coverage.
+This keeps the corpus small, which makes fuzzing and coverage runs
+faster. The number and size of the corpus entries before and after
+pruning are printed.
+
+The seed corpus is not modified.
+
+The <fuzz test> argument is the same as for 'cifuzz run'. Pruning
+the corpus is supported for C/C++ and Java projects.`,
+		ValidArgsFunction: completion.ValidFuzzTests,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+
+			// Check correct number of fuzz test args (exactly one)
+			var lenFuzzTestArgs int
+			var argsToPass []string
+			if cmd.ArgsLenAtDash() != -1 {
+				lenFuzzTestArgs = cmd.ArgsLenAtDash()
+				argsToPass = args[cmd.ArgsLenAtDash():]
+				args = args[:cmd.ArgsLenAtDash()]
+			} else {
+				lenFuzzTestArgs = len(args)
+			}
+			if lenFuzzTestArgs != 1 {
+				msg := fmt.Sprintf("Exactly one <fuzz test> argument must be provided, got %d", lenFuzzTestArgs)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			if sliceutil.Contains(
+				[]string{config.BuildSystemMaven, config.BuildSystemGradle},
+				opts.BuildSystem,
+			) {
+				// Check if the fuzz test is a method of a class
+				// And remove method from fuzz test argument
+				if strings.Contains(args[0], "::") {
+					split := strings.Split(args[0], "::")
+					args[0], opts.TargetMethod = split[0], split[1]
+				}
+			}
+
+			fuzzTests, err := resolve.FuzzTestArguments(opts.ResolveSourceFilePath, args, opts.BuildSystem, opts.ProjectDir)
+			if err != nil {
+				return err
+			}
+			opts.FuzzTest = fuzzTests[0]
+			opts.ApplyFuzzTestConfig(cmd.Flags())
+
+			opts.ArgsToPass = argsToPass
+
+			opts.BuildStdout = cmd.OutOrStdout()
+			opts.BuildStderr = cmd.OutOrStderr()
+			opts.Stdout = cmd.OutOrStdout()
+			opts.Stderr = cmd.OutOrStderr()
+
+			if logging.ShouldLogBuildToFile() {
+				opts.BuildStdout, err = logging.BuildOutputToFile(opts.ProjectDir, []string{opts.FuzzTest})
+				if err != nil {
+					return err
+				}
+				opts.BuildStderr = opts.BuildStdout
+			}
+
+			return opts.Validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			a, err := adapter.NewAdapter(opts)
+			if err != nil {
+				return err
+			}
+			defer a.Cleanup()
+
+			err = a.CheckDependencies(opts.ProjectDir)
+			if err != nil {
+				return err
+			}
+
+			_, err = a.Run(opts)
+			return err
+		},
+	}
+
+	// Note: If a flag should be configurable via cifuzz.yaml as well,
+	// bind it to viper in the PreRunE function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddCleanCommandFlag,
+		cmdutils.AddBuildJobsFlag,
+		cmdutils.AddEngineArgFlag,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddSanitizerFlag,
+		cmdutils.AddUseSandboxFlag,
+		cmdutils.AddResolveSourceFileFlag,
+	)
+	return cmd
+}
@@ -0,0 +1,144 @@
+package pull
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/api"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/auth"
+	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/corpus"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+type options struct {
+	BuildSystem string `mapstructure:"build-system"`
+	ProjectDir  string `mapstructure:"project-dir"`
+	ConfigDir   string `mapstructure:"config-dir"`
+	Server      string `mapstructure:"server"`
+
+	ResolveSourceFilePath bool
+
+	project  string
+	fuzzTest string
+}
+
+type pullCmd struct {
+	*cobra.Command
+	opts      *options
+	apiClient *api.APIClient
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "pull [flags] <project>/<fuzz test>",
+		Short: "Download the corpus of a fuzz test from CI Sense",
+		Long: `This command downloads the corpus which remote runs on CI Sense
+accumulated for the fuzz test in the CI Sense project and merges it into
+the generated corpus of the fuzz test in the .cifuzz-corpus directory,
+so that local fuzzing continues from there. Inputs which already exist
+in the generated corpus are skipped.
+
+    cifuzz corpus pull my_project/my_fuzz_test
+
+Pulling a corpus is supported for C/C++ projects built with CMake
+or other build systems and for Java projects.
+
+This command needs a token to access the API of CI Sense. You can
+specify this token via the CIFUZZ_API_TOKEN environment variable or by
+running 'cifuzz login' first.`,
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			project, fuzzTest, ok := strings.Cut(args[0], "/")
+			if !ok || project == "" || fuzzTest == "" {
+				msg := fmt.Sprintf("Invalid argument %q, the format is <project>/<fuzz test>", args[0])
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			opts.project = project
+
+			switch opts.BuildSystem {
+			case config.BuildSystemBazel, config.BuildSystemNodeJS:
+				return errors.Errorf(config.NotSupportedErrorMessage("corpus pull", opts.BuildSystem))
+			}
+
+			fuzzTests, err := resolve.FuzzTestArguments(opts.ResolveSourceFilePath, []string{fuzzTest}, opts.BuildSystem, opts.ProjectDir)
+			if err != nil {
+				return err
+			}
+			opts.fuzzTest = fuzzTests[0]
+
+			opts.Server = viper.GetString("server")
+			opts.Server, err = api.ValidateAndNormalizeServerURL(opts.Server)
+			return err
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := pullCmd{Command: c, opts: opts, apiClient: api.NewClient(opts.Server)}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via cifuzz.yaml as well,
+	// bind it to viper in the PreRunE function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddResolveSourceFileFlag,
+		cmdutils.AddServerFlag,
+	)
+
+	return cmd
+}
+
+func (c *pullCmd) run() error {
+	token, err := auth.GetValidToken(c.opts.Server)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "cifuzz-corpus-pull-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tmpDir)
+
+	log.Infof("Downloading the corpus of %s from project %s", c.opts.fuzzTest, c.opts.project)
+	zipPath := filepath.Join(tmpDir, "corpus.zip")
+	found, err := c.apiClient.DownloadCorpus(c.opts.project, c.opts.fuzzTest, token, zipPath)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.Errorf("No corpus found for fuzz test %s in project %s on %s",
+			c.opts.fuzzTest, c.opts.project, c.opts.Server)
+	}
+	corpusDir, numImported, numSkipped, err := corpus.ImportZip(zipPath, c.opts.ProjectDir, c.opts.fuzzTest)
+	if err != nil {
+		return err
+	}
+	log.Successf("Pulled %d inputs into %s (%d inputs already existed)",
+		numImported, fileutil.PrettifyPath(corpusDir), numSkipped)
+	return nil
+}
@@ -0,0 +1,307 @@
+package stats
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmd/run/adapter"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/corpus"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+type options struct {
+	adapter.RunOptions `mapstructure:",squash"`
+
+	Format   string `mapstructure:"-"`
+	Coverage bool   `mapstructure:"-"`
+}
+
+type statsCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+type fuzzTestStats struct {
+	FuzzTest string `json:"fuzz_test"`
+	Dir      string `json:"dir"`
+	*corpus.Stats
+	// The number of entries which add coverage, only set if the
+	// --coverage flag is used
+	CoverageEntries *int `json:"coverage_entries,omitempty"`
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "stats [flags] [<fuzz test>...]",
+		Short: "Show statistics of the generated corpus of fuzz tests",
+		Long: `This command shows the number of entries, the total size and the
+age distribution of the generated corpus of the specified fuzz tests,
+or of all fuzz tests which have a generated corpus in the
+.cifuzz-corpus directory if no fuzz test is specified.
+
+With the --coverage flag, the fuzz tests are built and run in
+libFuzzer's merge mode to determine how many of the corpus entries add
+coverage, without modifying the corpus. The remaining entries can be
+removed with 'cifuzz corpus prune'. This is supported for C/C++ and
+Java projects.
+
+Use --format=json to get machine-readable output, for example for
+dashboards.`,
+		ValidArgsFunction: completion.ValidFuzzTests,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			if opts.Format != formatText && opts.Format != formatJSON {
+				msg := fmt.Sprintf("Invalid format %q, valid formats are: %s, %s", opts.Format, formatText, formatJSON)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
+			if opts.BuildSystem == config.BuildSystemBazel {
+				// Bazel fuzz tests store their generated corpus next to
+				// the fuzz test instead of in .cifuzz-corpus
+				return errors.Errorf(config.NotSupportedErrorMessage("corpus stats", opts.BuildSystem))
+			}
+
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := statsCmd{Command: c, opts: opts}
+			return cmd.run(args)
+		},
+	}
+
+	// Note: If a flag should be configurable via cifuzz.yaml as well,
+	// bind it to viper in the PreRunE function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddCleanCommandFlag,
+		cmdutils.AddBuildJobsFlag,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddSanitizerFlag,
+		cmdutils.AddUseSandboxFlag,
+		cmdutils.AddResolveSourceFileFlag,
+	)
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", formatText,
+		fmt.Sprintf("Output format of the statistics (%s/%s).", formatText, formatJSON))
+	cmd.Flags().BoolVar(&opts.Coverage, "coverage", false,
+		"Build and run the fuzz tests to determine how many corpus entries add coverage.")
+
+	return cmd
+}
+
+func (c *statsCmd) run(args []string) error {
+	corpusRoot := filepath.Join(c.opts.ProjectDir, ".cifuzz-corpus")
+	allStats, err := findCorpusDirs(corpusRoot, c.opts.BuildSystem)
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		fuzzTests, err := resolve.FuzzTestArguments(c.opts.ResolveSourceFilePath, args, c.opts.BuildSystem, c.opts.ProjectDir)
+		if err != nil {
+			return err
+		}
+		allStats = filterFuzzTests(allStats, fuzzTests, corpusRoot, c.opts.BuildSystem)
+	}
+
+	now := time.Now()
+	for _, s := range allStats {
+		s.Stats, err = corpus.ComputeStats(s.Dir, now)
+		if err != nil {
+			return err
+		}
+		if c.opts.Coverage {
+			s.CoverageEntries, err = c.coverageEntries(s.FuzzTest)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if c.opts.Format == formatJSON {
+		s, err := stringutil.ToJSONString(allStats)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(c.OutOrStdout(), s)
+		return errors.WithStack(err)
+	}
+
+	if len(allStats) == 0 {
+		log.Print("No generated corpus found in " + fileutil.PrettifyPath(corpusRoot))
+		return nil
+	}
+
+	header := []string{"Fuzz Test", "Entries", "Size", "< 1 Day", "< 1 Week", "< 1 Month", "Older"}
+	if c.opts.Coverage {
+		header = append(header, "Adding Coverage")
+	}
+	data := [][]string{header}
+	for _, s := range allStats {
+		row := []string{
+			s.FuzzTest,
+			strconv.Itoa(s.Entries),
+			formatSize(s.TotalSize),
+			strconv.Itoa(s.Age.LastDay),
+			strconv.Itoa(s.Age.LastWeek),
+			strconv.Itoa(s.Age.LastMonth),
+			strconv.Itoa(s.Age.Older),
+		}
+		if s.CoverageEntries != nil {
+			percent := 100.0
+			if s.Entries > 0 {
+				percent = float64(*s.CoverageEntries) / float64(s.Entries) * 100
+			}
+			row = append(row, fmt.Sprintf("%d (%.0f%%)", *s.CoverageEntries, percent))
+		}
+		data = append(data, row)
+	}
+	err = pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(c.OutOrStdout()).Render()
+	return errors.WithStack(err)
+}
+
+// coverageEntries builds the fuzz test and runs it in libFuzzer's merge
+// mode on its generated corpus, like `cifuzz corpus prune` does, and
+// returns the number of entries which would be kept
+func (c *statsCmd) coverageEntries(fuzzTest string) (*int, error) {
+	// The adapters modify the options, so each fuzz test gets its
+	// own copy
+	opts := c.opts.RunOptions
+	opts.SeedCorpusDirs = append([]string{}, c.opts.SeedCorpusDirs...)
+	opts.FuzzTest, opts.TargetMethod, _ = strings.Cut(fuzzTest, "::")
+	opts.PruneCorpus = true
+	opts.PruneDryRun = true
+	opts.ApplyFuzzTestConfig(c.Flags())
+
+	// Only the statistics are printed to stdout
+	opts.BuildStdout = c.ErrOrStderr()
+	opts.BuildStderr = c.ErrOrStderr()
+	opts.Stdout = c.ErrOrStderr()
+	opts.Stderr = c.ErrOrStderr()
+
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := adapter.NewAdapter(&opts)
+	if err != nil {
+		return nil, err
+	}
+	defer a.Cleanup()
+
+	err = a.CheckDependencies(opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = a.Run(&opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.PruneResult == nil {
+		return nil, errors.Errorf("Failed to determine the coverage of the corpus of %s", fuzzTest)
+	}
+	return &opts.PruneResult.After.Entries, nil
+}
+
+// findCorpusDirs returns the generated corpus directories in the corpus
+// root directory. Java fuzz tests store their corpus in
+// <class>/<method>, which is named <class>::<method> like the fuzz test
+// argument of `cifuzz run`.
+func findCorpusDirs(corpusRoot string, buildSystem string) ([]*fuzzTestStats, error) {
+	dirs, err := corpus.FindDirs(corpusRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*fuzzTestStats
+	for _, dir := range dirs {
+		name, err := filepath.Rel(corpusRoot, dir)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		name = filepath.ToSlash(name)
+		if isJava(buildSystem) {
+			name = strings.Replace(name, "/", "::", 1)
+		}
+		result = append(result, &fuzzTestStats{FuzzTest: name, Dir: dir})
+	}
+	return result, nil
+}
+
+// filterFuzzTests returns the stats of the specified fuzz tests. A Java
+// fuzz test without a method matches all its methods. Fuzz tests which
+// don't have a generated corpus yet are included with their default
+// corpus directory.
+func filterFuzzTests(allStats []*fuzzTestStats, fuzzTests []string, corpusRoot string, buildSystem string) []*fuzzTestStats {
+	separator := "/"
+	if isJava(buildSystem) {
+		separator = "::"
+	}
+
+	var result []*fuzzTestStats
+	for _, fuzzTest := range fuzzTests {
+		var found bool
+		for _, s := range allStats {
+			if s.FuzzTest == fuzzTest || strings.HasPrefix(s.FuzzTest, fuzzTest+separator) {
+				result = append(result, s)
+				found = true
+			}
+		}
+		if !found {
+			dir := filepath.Join(corpusRoot, filepath.FromSlash(strings.Replace(fuzzTest, "::", "/", 1)))
+			result = append(result, &fuzzTestStats{FuzzTest: fuzzTest, Dir: dir})
+		}
+	}
+	return result
+}
+
+func isJava(buildSystem string) bool {
+	return sliceutil.Contains([]string{config.BuildSystemMaven, config.BuildSystemGradle}, buildSystem)
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
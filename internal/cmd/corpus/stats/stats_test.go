@@ -0,0 +1,53 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/config"
+)
+
+func TestFindCorpusDirs(t *testing.T) {
+	corpusRoot := t.TempDir()
+	for _, path := range []string{
+		"com.example.FuzzTestA/fuzzA/entry",
+		"com.example.FuzzTestA/fuzzB/entry",
+		"com.example.FuzzTestB/entry",
+		".prune-123/entry",
+	} {
+		path = filepath.Join(corpusRoot, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0o644))
+	}
+
+	allStats, err := findCorpusDirs(corpusRoot, config.BuildSystemMaven)
+	require.NoError(t, err)
+	var names []string
+	for _, s := range allStats {
+		names = append(names, s.FuzzTest)
+	}
+	assert.Equal(t, []string{
+		"com.example.FuzzTestA::fuzzA",
+		"com.example.FuzzTestA::fuzzB",
+		"com.example.FuzzTestB",
+	}, names)
+
+	// A class matches all its methods and fuzz tests without a
+	// generated corpus are included
+	filtered := filterFuzzTests(allStats, []string{"com.example.FuzzTestA", "com.example.FuzzTestC::fuzz"}, corpusRoot, config.BuildSystemMaven)
+	require.Len(t, filtered, 3)
+	assert.Equal(t, "com.example.FuzzTestA::fuzzA", filtered[0].FuzzTest)
+	assert.Equal(t, "com.example.FuzzTestA::fuzzB", filtered[1].FuzzTest)
+	assert.Equal(t, "com.example.FuzzTestC::fuzz", filtered[2].FuzzTest)
+	assert.Equal(t, filepath.Join(corpusRoot, "com.example.FuzzTestC", "fuzz"), filtered[2].Dir)
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", formatSize(512))
+	assert.Equal(t, "1.5 KiB", formatSize(1536))
+	assert.Equal(t, "2.0 MiB", formatSize(2*1024*1024))
+}
@@ -14,8 +14,9 @@ import (
 	"code-intelligence.com/cifuzz/internal/build"
 	"code-intelligence.com/cifuzz/internal/build/bazel"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/coverage"
 	"code-intelligence.com/cifuzz/pkg/log"
-	"code-intelligence.com/cifuzz/pkg/parser/coverage"
+	parser "code-intelligence.com/cifuzz/pkg/parser/coverage"
 	"code-intelligence.com/cifuzz/pkg/runfiles"
 	"code-intelligence.com/cifuzz/util/envutil"
 	"code-intelligence.com/cifuzz/util/fileutil"
@@ -211,11 +212,37 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
+
+	// The paths of source files which are not part of the project, like
+	// files of external repositories and generated files, are relative
+	// to the execution root, so we resolve them to be able to show the
+	// files in the report
+	cmd = exec.Command("bazel", "info", "execution_root")
+	out, err = cmd.Output()
+	if err != nil {
+		return "", cmdutils.WrapExecError(errors.WithStack(err), cmd)
+	}
+	lcovReportContent = resolveSourceFilePaths(lcovReportContent, cov.ProjectDir, strings.TrimSpace(string(out)))
+
+	// Write the report with the resolved paths to a temporary directory,
+	// the files created by bazel are read-only
+	tmpDir, err := os.MkdirTemp("", "bazel-coverage-")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tmpDir)
+	reportPath = filepath.Join(tmpDir, "coverage.lcov")
+	err = os.WriteFile(reportPath, lcovReportContent, 0o644)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
 	reportReader := strings.NewReader(string(lcovReportContent))
-	summary, err := coverage.ParseLCOVReportIntoSummary(reportReader)
+	summary, err := parser.ParseLCOVReportIntoSummary(reportReader)
 	if err != nil {
 		return "", err
 	}
+	summary.NormalizePaths(cov.ProjectDir)
 	summary.PrintTable(cov.Stderr)
 
 	commonFlags, err := cov.getBazelCommandFlags()
@@ -232,15 +259,7 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 			name := strings.ReplaceAll(path, "/", "-")
 			cov.OutputPath = name + ".coverage.lcov"
 		}
-		// We don't use copy.Copy here to be able to set the permissions
-		// to 0o644 before umask - copy.Copy just copies the permissions
-		// from the source file, which has permissions 555 like all
-		// files created by bazel.
-		content, err := os.ReadFile(reportPath)
-		if err != nil {
-			return "", errors.WithStack(err)
-		}
-		err = os.WriteFile(cov.OutputPath, content, 0o644)
+		err = os.WriteFile(cov.OutputPath, lcovReportContent, 0o644)
 		if err != nil {
 			return "", errors.WithStack(err)
 		}
@@ -262,13 +281,10 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 	}
 
 	// Create an HTML report via genhtml
-	genHTML, err := runfiles.Finder.GenHTMLPath()
+	cmd, err = coverage.GenHTMLCommand(reportPath, cov.OutputPath)
 	if err != nil {
 		return "", err
 	}
-	args := []string{"--output", cov.OutputPath, reportPath}
-
-	cmd = exec.Command(genHTML, args...)
 	cmd.Dir = cov.ProjectDir
 	cmd.Stderr = os.Stderr
 	log.Debugf("Command: %s", cmd.String())
@@ -315,3 +331,31 @@ func (cov *CoverageGenerator) getBazelCommandFlags() ([]string, error) {
 
 	return flags, nil
 }
+
+// resolveSourceFilePaths replaces the paths of source files in the lcov
+// report which don't exist in the project directory but in the
+// execution root, e.g. "external/<repo>/..." and "bazel-out/...", with
+// absolute paths
+func resolveSourceFilePaths(lcovReport []byte, projectDir, executionRoot string) []byte {
+	lines := strings.Split(string(lcovReport), "\n")
+	for i, line := range lines {
+		path, ok := strings.CutPrefix(line, "SF:")
+		if !ok || filepath.IsAbs(path) {
+			continue
+		}
+		exists, err := fileutil.Exists(filepath.Join(projectDir, path))
+		if err != nil || exists {
+			continue
+		}
+		// The directories of external repositories in the execution
+		// root are symlinks into the output base, which we resolve to
+		// get paths that stay valid
+		resolved, err := filepath.EvalSymlinks(filepath.Join(executionRoot, path))
+		if err != nil {
+			log.Debugf("Failed to resolve source file %s: %v", path, err)
+			continue
+		}
+		lines[i] = "SF:" + resolved
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,35 @@
+package bazel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSourceFilePaths(t *testing.T) {
+	projectDir := t.TempDir()
+	executionRoot := t.TempDir()
+	outputBase := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "src"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "src", "parser.cpp"), nil, 0o644))
+	// External repositories are symlinked into the execution root
+	require.NoError(t, os.MkdirAll(filepath.Join(outputBase, "external", "zlib"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(outputBase, "external", "zlib", "inflate.c"), nil, 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(executionRoot, "external"), 0o755))
+	require.NoError(t, os.Symlink(filepath.Join(outputBase, "external", "zlib"), filepath.Join(executionRoot, "external", "zlib")))
+
+	report := "SF:src/parser.cpp\nDA:1,1\nend_of_record\n" +
+		"SF:external/zlib/inflate.c\nDA:1,0\nend_of_record\n" +
+		"SF:missing.c\nend_of_record\n"
+	resolved := string(resolveSourceFilePaths([]byte(report), projectDir, executionRoot))
+
+	inflate, err := filepath.EvalSymlinks(filepath.Join(outputBase, "external", "zlib", "inflate.c"))
+	require.NoError(t, err)
+	assert.Equal(t, "SF:src/parser.cpp\nDA:1,1\nend_of_record\n"+
+		"SF:"+inflate+"\nDA:1,0\nend_of_record\n"+
+		"SF:missing.c\nend_of_record\n", resolved)
+}
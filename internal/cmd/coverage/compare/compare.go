@@ -0,0 +1,165 @@
+package compare
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	parser "code-intelligence.com/cifuzz/pkg/parser/coverage"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+type options struct {
+	Format string
+}
+
+type compareCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compare [flags] <old report> <new report>",
+		Short: "Compare the coverage of two coverage reports",
+		Long: `This command compares two coverage reports, for example the reports
+created by 'cifuzz coverage --format=lcov' before and after a change to
+the corpus or the fuzz test, and shows the lines and functions which
+are only covered by one of them. This helps to evaluate whether a
+change actually improved the coverage.
+
+The reports can be lcov trace files or JaCoCo XML reports (files with
+the extension .xml). Source files are matched by their path in the
+reports, so both reports should be created in the same project
+directory.
+
+Use --format=json to get machine-readable output.`,
+		Args: cobra.ExactArgs(2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Format != formatText && opts.Format != formatJSON {
+				msg := fmt.Sprintf("Invalid format %q, valid formats are: %s, %s", opts.Format, formatText, formatJSON)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := compareCmd{Command: c, opts: opts}
+			return cmd.run(args[0], args[1])
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", formatText,
+		fmt.Sprintf("Output format of the comparison (%s/%s).", formatText, formatJSON))
+
+	return cmd
+}
+
+func (c *compareCmd) run(oldPath, newPath string) error {
+	oldReport, err := parseReport(oldPath)
+	if err != nil {
+		return err
+	}
+	newReport, err := parseReport(newPath)
+	if err != nil {
+		return err
+	}
+	comparison := parser.CompareLCOVReports(oldReport, newReport)
+
+	if c.opts.Format == formatJSON {
+		s, err := stringutil.ToJSONString(comparison)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(c.OutOrStdout(), s)
+		return errors.WithStack(err)
+	}
+	return c.printComparison(comparison)
+}
+
+func (c *compareCmd) printComparison(comparison *parser.Comparison) error {
+	_, err := fmt.Fprintf(c.OutOrStdout(), "Lines:     %s -> %s\nFunctions: %s -> %s\n\n",
+		ratio(comparison.Old.LinesHit, comparison.Old.LinesFound),
+		ratio(comparison.New.LinesHit, comparison.New.LinesFound),
+		ratio(comparison.Old.FunctionsHit, comparison.Old.FunctionsFound),
+		ratio(comparison.New.FunctionsHit, comparison.New.FunctionsFound))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if len(comparison.Files) == 0 {
+		log.Info("Both reports cover the same lines and functions")
+		return nil
+	}
+
+	var gainedLines, lostLines int
+	data := [][]string{{"File", "Gained Lines", "Lost Lines", "Gained Functions", "Lost Functions"}}
+	for _, f := range comparison.Files {
+		gainedLines += len(f.GainedLines)
+		lostLines += len(f.LostLines)
+		data = append(data, []string{
+			f.Name,
+			lineChanges(f.GainedLines),
+			lineChanges(f.LostLines),
+			strings.Join(f.GainedFunctions, ", "),
+			strings.Join(f.LostFunctions, ", "),
+		})
+	}
+	err = pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(c.OutOrStdout()).Render()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = fmt.Fprintf(c.OutOrStdout(), "\n%d lines gained, %d lines lost\n", gainedLines, lostLines)
+	return errors.WithStack(err)
+}
+
+// lineChanges formats the number of lines and their ranges, e.g.
+// "4 (3-5, 8)"
+func lineChanges(lines []int) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d (%s)", len(lines), parser.LineRanges(lines))
+}
+
+func ratio(hit, found int) string {
+	if found == 0 {
+		return "0/0"
+	}
+	return fmt.Sprintf("%d/%d (%s%%)", hit, found, strconv.FormatFloat(float64(hit)*100/float64(found), 'f', 1, 64))
+}
+
+func parseReport(path string) (*parser.LCOVReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	var report *parser.LCOVReport
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		report, err = parser.ParseJacocoXMLIntoLCOVReport(f)
+	} else {
+		report, err = parser.ParseLCOVFileIntoLCOVReport(f)
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Failed to parse coverage report %s", path)
+	}
+	return report, nil
+}
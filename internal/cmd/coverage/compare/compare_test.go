@@ -0,0 +1,70 @@
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+)
+
+const oldReport = `SF:src/parser.cpp
+FN:1,parse
+FN:10,parse_header
+FNDA:1,parse
+FNDA:0,parse_header
+FNF:2
+FNH:1
+DA:2,1
+DA:3,1
+DA:11,0
+DA:12,0
+LF:4
+LH:2
+end_of_record
+`
+
+const newReport = `SF:src/parser.cpp
+FN:1,parse
+FN:10,parse_header
+FNDA:0,parse
+FNDA:3,parse_header
+FNF:2
+FNH:1
+DA:2,0
+DA:3,1
+DA:11,3
+DA:12,3
+LF:4
+LH:3
+end_of_record
+`
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.lcov")
+	newPath := filepath.Join(dir, "new.lcov")
+	require.NoError(t, os.WriteFile(oldPath, []byte(oldReport), 0o644))
+	require.NoError(t, os.WriteFile(newPath, []byte(newReport), 0o644))
+
+	stdout, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, oldPath, newPath)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Lines:     2/4 (50.0%) -> 3/4 (75.0%)")
+	assert.Contains(t, stdout, "src/parser.cpp")
+	assert.Contains(t, stdout, "2 (11-12)")
+	assert.Contains(t, stdout, "parse_header")
+	assert.Contains(t, stdout, "2 lines gained, 1 lines lost")
+
+	stdout, _, err = cmdutils.ExecuteCommand(t, New(), os.Stdin, "--format=json", oldPath, newPath)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, `"GainedLines": [`)
+}
+
+func TestCompareInvalidFormat(t *testing.T) {
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--format=xml", "old.lcov", "new.lcov")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid format")
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/browser"
@@ -14,9 +15,13 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/build/cmake"
 	"code-intelligence.com/cifuzz/internal/build/java/gradle"
 	"code-intelligence.com/cifuzz/internal/build/java/maven"
 	bazelCoverage "code-intelligence.com/cifuzz/internal/cmd/coverage/bazel"
+	coverageCompareCmd "code-intelligence.com/cifuzz/internal/cmd/coverage/compare"
+	gcovCoverage "code-intelligence.com/cifuzz/internal/cmd/coverage/gcov"
 	javaCoverage "code-intelligence.com/cifuzz/internal/cmd/coverage/java"
 	llvmCoverage "code-intelligence.com/cifuzz/internal/cmd/coverage/llvm"
 	nodeCoverage "code-intelligence.com/cifuzz/internal/cmd/coverage/node"
@@ -28,6 +33,9 @@ import (
 	"code-intelligence.com/cifuzz/internal/coverage"
 	"code-intelligence.com/cifuzz/pkg/dependencies"
 	"code-intelligence.com/cifuzz/pkg/log"
+	parser "code-intelligence.com/cifuzz/pkg/parser/coverage"
+	"code-intelligence.com/cifuzz/pkg/vcs"
+	"code-intelligence.com/cifuzz/util/fileutil"
 	"code-intelligence.com/cifuzz/util/sliceutil"
 	"code-intelligence.com/cifuzz/util/stringutil"
 )
@@ -38,15 +46,26 @@ type Generator interface {
 }
 
 type coverageOptions struct {
-	OutputFormat string   `mapstructure:"format"`
-	OutputPath   string   `mapstructure:"output"`
-	BuildSystem  string   `mapstructure:"build-system"`
-	BuildCommand string   `mapstructure:"build-command"`
-	CleanCommand string   `mapstructure:"clean-command"`
-	NumBuildJobs uint     `mapstructure:"build-jobs"`
-	CorpusDirs   []string `mapstructure:"corpus-dirs"`
-	UseSandbox   bool     `mapstructure:"use-sandbox"`
-	EngineArgs   []string `mapstructure:"engine-args"`
+	OutputFormat     string   `mapstructure:"format"`
+	OutputPath       string   `mapstructure:"output"`
+	JacocoXML        string   `mapstructure:"jacoco-xml"`
+	All              bool     `mapstructure:"-"`
+	Diff             string   `mapstructure:"-"`
+	Serve            string   `mapstructure:"-"`
+	NoCache          bool     `mapstructure:"-"`
+	PerTestOutput    string   `mapstructure:"-"`
+	CoverageProvider string   `mapstructure:"coverage-provider"`
+	CoverageTool     string   `mapstructure:"coverage-tool"`
+	CoverageInclude  []string `mapstructure:"coverage-include"`
+	CoverageExclude  []string `mapstructure:"coverage-exclude"`
+	PathMappings     []string `mapstructure:"coverage-path-mapping"`
+	BuildSystem      string   `mapstructure:"build-system"`
+	BuildCommand     string   `mapstructure:"build-command"`
+	CleanCommand     string   `mapstructure:"clean-command"`
+	NumBuildJobs     uint     `mapstructure:"build-jobs"`
+	CorpusDirs       []string `mapstructure:"corpus-dirs"`
+	UseSandbox       bool     `mapstructure:"use-sandbox"`
+	EngineArgs       []string `mapstructure:"engine-args"`
 
 	ResolveSourceFilePath bool
 	Preset                string
@@ -54,12 +73,18 @@ type coverageOptions struct {
 
 	fuzzTest        string
 	targetMethod    string
+	changedLines    map[string][]int
+	pathMappings    []*parser.PathMapping
 	testNamePattern string
 	argsToPass      []string
 	buildStdout     io.Writer
 	buildStderr     io.Writer
 }
 
+// The address on which the report is served if --serve is used without
+// an address
+const defaultServeAddress = "localhost:8080"
+
 func (opts *coverageOptions) validate() error {
 	var err error
 
@@ -100,12 +125,124 @@ func (opts *coverageOptions) validate() error {
 		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
 	}
 
+	if opts.All {
+		switch opts.BuildSystem {
+		case config.BuildSystemCMake, config.BuildSystemMaven, config.BuildSystemGradle, config.BuildSystemNodeJS:
+		default:
+			msg := `Flag 'all' is only applicable for build system types 'CMake', 'Maven', 'Gradle' and 'Node.js'`
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+		if opts.OutputFormat == coverage.FormatJacocoXML || opts.JacocoXML != "" {
+			msg := `A combined report can't be created in the JaCoCo format`
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+	}
+
+	if opts.PerTestOutput != "" {
+		if !opts.All {
+			msg := `Flag 'per-test-output' can only be used together with flag 'all'`
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+		if opts.Diff != "" {
+			msg := `Flag 'per-test-output' can't be used together with flag 'diff'`
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+	}
+
+	if opts.Serve != "" {
+		if opts.OutputFormat != coverage.FormatHTML {
+			msg := `Flag 'serve' can only be used with the HTML format`
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+		if opts.Diff != "" {
+			msg := `Flag 'serve' can't be used together with flag 'diff'`
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+	}
+
+	if opts.filtersSourceFiles() {
+		if opts.OutputFormat == coverage.FormatJacocoXML {
+			msg := `The settings 'coverage-include' and 'coverage-exclude' are not supported for the JaCoCo format`
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+		for _, patterns := range [][]string{opts.CoverageInclude, opts.CoverageExclude} {
+			err = parser.ValidatePathPatterns(patterns)
+			if err != nil {
+				return cmdutils.WrapIncorrectUsageError(err)
+			}
+		}
+	}
+
+	opts.pathMappings, err = parser.ParsePathMappings(opts.PathMappings, opts.ProjectDir)
+	if err != nil {
+		return cmdutils.WrapIncorrectUsageError(err)
+	}
+
+	if opts.CoverageProvider != "" {
+		if opts.BuildSystem != config.BuildSystemNodeJS {
+			msg := `Flag 'coverage-provider' is only applicable for build system type 'Node.js'`
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+		if !stringutil.Contains(nodeCoverage.CoverageProviders, opts.CoverageProvider) {
+			msg := fmt.Sprintf("Flag \"coverage-provider\" must be %s", strings.Join(nodeCoverage.CoverageProviders, " or "))
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+	}
+
+	if opts.CoverageTool != "" {
+		if !stringutil.Contains(coverage.Tools, opts.CoverageTool) {
+			msg := fmt.Sprintf("Flag \"coverage-tool\" must be %s", strings.Join(coverage.Tools, " or "))
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+		if opts.CoverageTool == coverage.ToolGcov {
+			if opts.BuildSystem != config.BuildSystemCMake {
+				msg := `Coverage tool 'gcov' is only supported for build system type 'CMake'`
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			if runtime.GOOS == "windows" {
+				msg := `Coverage tool 'gcov' is not supported on Windows`
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+		}
+	}
+
+	if opts.JacocoXML != "" &&
+		opts.BuildSystem != config.BuildSystemMaven &&
+		opts.BuildSystem != config.BuildSystemGradle {
+		msg := `Flag 'jacoco-xml' is only applicable for build system types 'Maven' and 'Gradle'`
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
 	return nil
 }
 
+// createdFromLCOVReport returns true if the output is created from the
+// LCOV report instead of being created by the coverage generator
+func (opts *coverageOptions) createdFromLCOVReport() bool {
+	return opts.OutputFormat == coverage.FormatCobertura ||
+		opts.OutputFormat == coverage.FormatSonarQube ||
+		opts.Diff != "" ||
+		opts.filtersSourceFiles() ||
+		(opts.OutputFormat == coverage.FormatLCOV && len(opts.PathMappings) > 0)
+}
+
+func (opts *coverageOptions) filtersSourceFiles() bool {
+	return len(opts.CoverageInclude) > 0 || len(opts.CoverageExclude) > 0
+}
+
+func (opts *coverageOptions) usesGcov() bool {
+	return opts.CoverageTool == coverage.ToolGcov
+}
+
 type coverageCmd struct {
 	*cobra.Command
 	opts *coverageOptions
+
+	jvmDeps []string
+	// The fuzz tests which were already built by runAll
+	cmakeBuildResults map[string]*build.CBuildResult
+	// The directory of the HTML report which is served via --serve
+	htmlReportDir string
 }
 
 func New() *cobra.Command {
@@ -113,7 +250,7 @@ func New() *cobra.Command {
 	var bindFlags func()
 
 	cmd := &cobra.Command{
-		Use:   "coverage [flags] <fuzz test>",
+		Use:   "coverage [flags] <fuzz test>|--all",
 		Short: "Generate coverage report for fuzz test",
 		Long: `This command generates a coverage report for a fuzz test.
 
@@ -126,8 +263,56 @@ Additional arguments for CMake and Bazel can be passed after a "--".
 
 The flag 'build-jobs' is only applicable for CMake, Bazel and 'other'.
 
+With the flag 'all', a coverage report is created for each fuzz test of
+the project and the reports are merged into a single combined report.
+This is supported for CMake, Maven, Gradle and Node.js. With the flag
+'per-test-output', the report of each fuzz test is written to the given
+directory in addition to the combined report. For CMake, all fuzz tests
+are built at once.
+
+For CMake and 'other', the coverage of the corpus entries is cached in
+the .cifuzz-build directory. If the fuzz test and its dependencies
+didn't change since the last run, only new corpus entries are executed.
+Use the flag 'no-cache' to execute all corpus entries.
+
+With the flag 'diff', no report is created. Instead, the coverage of
+the lines which were changed compared to the merge base of the given
+Git ref and HEAD is shown, including uncommitted changes, so that you
+can check whether new code is covered by the fuzz tests.
+
+Source files, e.g. third-party or generated code, can be excluded from
+the reports of all languages via the flags (or the cifuzz.yaml settings)
+'coverage-include' and 'coverage-exclude', which take glob patterns
+relative to the project directory. A pattern which matches a directory
+matches all files in the directory.
+
+The paths of the source files in lcov reports can be remapped via the
+flag (or the cifuzz.yaml setting) 'coverage-path-mapping', which takes
+mappings of the form <from>=<to>. A relative <from> path is relative to
+the project directory, so that '.=' makes the paths of all source files
+in the project relative to it, which IDE integrations like Coverage
+Gutters resolve against the workspace.
+
+For CMake, the coverage is collected via clang's source-based code
+coverage by default. Projects which must be built with GCC can use
+gcov instead by setting the flag (or the cifuzz.yaml setting)
+'coverage-tool' to 'gcov'. The fuzz tests are then built with the
+configured compiler and without libFuzzer, and the report is created
+via gcovr (version 7.0 or higher).
+
+For Node.js, the coverage is collected by Jest, either via Istanbul or
+via the coverage collection built into V8, which can be selected with the
+flag 'coverage-provider'. The coverage of TypeScript code is mapped back
+to the TypeScript sources via source maps.
+
+For Maven and Gradle, the flag 'jacoco-xml' writes the raw JaCoCo XML
+report in addition to the report in the selected format, which can be
+merged with the JaCoCo reports of unit tests.
+
 The output can be displayed in the browser or written as a HTML
-or a lcov trace file.
+report, a lcov trace file, a Cobertura XML report, which can be
+consumed by Jenkins, Azure DevOps and GitLab, or a SonarQube generic
+coverage report.
 
 ` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("Browser") + `
     cifuzz coverage <fuzz test>
@@ -140,7 +325,33 @@ or a lcov trace file.
 
 ` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("XML (Jacoco Report)") + `
     cifuzz coverage --format=jacocoxml <fuzz test>
+
+` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("Combined Report of All Fuzz Tests") + `
+    cifuzz coverage --all --format=lcov
+
+` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("Coverage of Changed Lines") + `
+    cifuzz coverage --diff=origin/main <fuzz test>
+
+` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("Compare the Coverage of Two Reports") + `
+    cifuzz coverage compare old.lcov new.lcov
+
+` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("Serve the HTML Report and Regenerate It on Changes") + `
+    cifuzz coverage --serve <fuzz test>
+    cifuzz coverage --serve=localhost:9000 <fuzz test>
+
+` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("HTML and Jacoco Report") + `
+    cifuzz coverage --output coverage-report --jacoco-xml jacoco-fuzzing.xml <fuzz test>
+
+` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("XML (Cobertura Report)") + `
+    cifuzz coverage --format=cobertura --output coverage.xml <fuzz test>
+
+` + pterm.Style{pterm.Reset, pterm.Bold}.Sprint("XML (SonarQube Generic Coverage Report)") + `
+    cifuzz coverage --format=sonarqube --output sonar-coverage.xml <fuzz test>
 `,
+		// The number of arguments is checked in PreRunE. Without
+		// setting Args, arguments which are not the name of a
+		// subcommand would be rejected as unknown commands.
+		Args:              cobra.ArbitraryArgs,
 		ValidArgsFunction: completion.ValidFuzzTests,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			// Bind viper keys to flags. We can't do this in the New
@@ -149,6 +360,12 @@ or a lcov trace file.
 			bindFlags()
 			cmdutils.ViperMustBindPFlag("format", cmd.Flags().Lookup("format"))
 			cmdutils.ViperMustBindPFlag("output", cmd.Flags().Lookup("output"))
+			cmdutils.ViperMustBindPFlag("jacoco-xml", cmd.Flags().Lookup("jacoco-xml"))
+			cmdutils.ViperMustBindPFlag("coverage-provider", cmd.Flags().Lookup("coverage-provider"))
+			cmdutils.ViperMustBindPFlag("coverage-tool", cmd.Flags().Lookup("coverage-tool"))
+			cmdutils.ViperMustBindPFlag("coverage-include", cmd.Flags().Lookup("coverage-include"))
+			cmdutils.ViperMustBindPFlag("coverage-exclude", cmd.Flags().Lookup("coverage-exclude"))
+			cmdutils.ViperMustBindPFlag("coverage-path-mapping", cmd.Flags().Lookup("coverage-path-mapping"))
 
 			var lenFuzzTestArgs int
 			var argsToPass []string
@@ -159,7 +376,11 @@ or a lcov trace file.
 			} else {
 				lenFuzzTestArgs = len(args)
 			}
-			if lenFuzzTestArgs != 1 {
+			if opts.All && lenFuzzTestArgs != 0 {
+				msg := "Flag 'all' can't be used together with a <fuzz test> argument"
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			if !opts.All && lenFuzzTestArgs != 1 {
 				msg := fmt.Sprintf("Exactly one <fuzz test> argument must be provided, got %d", lenFuzzTestArgs)
 				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
 			}
@@ -168,6 +389,20 @@ or a lcov trace file.
 			if err != nil {
 				return err
 			}
+			opts.argsToPass = argsToPass
+
+			opts.buildStdout = cmd.OutOrStdout()
+			opts.buildStderr = cmd.OutOrStderr()
+			if opts.All {
+				if logging.ShouldLogBuildToFile() {
+					opts.buildStdout, err = logging.BuildOutputToFile(opts.ProjectDir, []string{"all"})
+					if err != nil {
+						return err
+					}
+					opts.buildStderr = opts.buildStdout
+				}
+				return opts.validate()
+			}
 
 			if sliceutil.Contains(
 				[]string{config.BuildSystemMaven, config.BuildSystemGradle},
@@ -192,10 +427,7 @@ or a lcov trace file.
 				return err
 			}
 			opts.fuzzTest = fuzzTest[0]
-			opts.argsToPass = argsToPass
 
-			opts.buildStdout = cmd.OutOrStdout()
-			opts.buildStderr = cmd.OutOrStderr()
 			if logging.ShouldLogBuildToFile() {
 				opts.buildStdout, err = logging.BuildOutputToFile(opts.ProjectDir, []string{opts.fuzzTest})
 				if err != nil {
@@ -230,22 +462,41 @@ or a lcov trace file.
 	if err != nil {
 		panic(err)
 	}
-	cmd.Flags().StringP("format", "f", "html", "Output format of the coverage report (html/lcov).")
+	cmd.Flags().StringP("format", "f", "html", "Output format of the coverage report (html/lcov/jacocoxml/cobertura/sonarqube).")
 	cmd.Flags().StringP("output", "o", "", "Output path of the coverage report.")
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Create a combined coverage report of all fuzz tests of the project.")
+	cmd.Flags().StringVar(&opts.PerTestOutput, "per-test-output", "",
+		"With --all, also write the report of each fuzz test to this directory.")
+	cmd.Flags().StringVar(&opts.Serve, "serve", "",
+		fmt.Sprintf("Serve the HTML report on this address (default %s) and regenerate it when files in the project change.", defaultServeAddress))
+	cmd.Flags().Lookup("serve").NoOptDefVal = defaultServeAddress
+	cmd.Flags().BoolVar(&opts.NoCache, "no-cache", false,
+		"Run all corpus entries instead of reusing the coverage of the last run (CMake and other build systems only).")
+	cmd.Flags().StringVar(&opts.Diff, "diff", "", "Only show the coverage of the lines changed compared to this Git ref, e.g. origin/main.")
+	cmd.Flags().String("coverage-provider", "",
+		fmt.Sprintf("Coverage provider which is used by Jest (%s). By default, the provider configured for Jest is used (Node.js only).",
+			strings.Join(nodeCoverage.CoverageProviders, "/")))
+	cmd.Flags().String("coverage-tool", "",
+		fmt.Sprintf("Tool which collects the coverage (%s). Use gcov for projects which must be built with GCC (CMake only, default llvm).",
+			strings.Join(coverage.Tools, "/")))
+	cmd.Flags().StringSlice("coverage-include", nil,
+		"Only include source files matching this glob pattern (relative to the project directory) in the report. This flag can be used multiple times.")
+	cmd.Flags().StringSlice("coverage-exclude", nil,
+		"Exclude source files matching this glob pattern (relative to the project directory) from the report. This flag can be used multiple times.")
+	cmd.Flags().StringSlice("coverage-path-mapping", nil,
+		"Replace the path prefix <from> of source files in lcov reports with <to>, in the form <from>=<to>. This flag can be used multiple times.")
+	cmd.Flags().String("jacoco-xml", "", "Also write the raw JaCoCo XML report to this file (Maven/Gradle only).")
 	err = cmd.RegisterFlagCompletionFunc("format", completion.ValidCoverageOutputFormat)
 	if err != nil {
 		panic(err)
 	}
 
+	cmd.AddCommand(coverageCompareCmd.New())
+
 	return cmd
 }
 
 func (c *coverageCmd) run() error {
-	err := c.checkDependencies()
-	if err != nil {
-		return err
-	}
-
 	if c.opts.Preset == "vscode" {
 		var format string
 		var output string
@@ -265,13 +516,93 @@ func (c *coverageCmd) run() error {
 		c.opts.OutputPath = output
 	}
 
+	// The dependencies depend on the output format, which is set by
+	// the preset
+	err := c.checkDependencies()
+	if err != nil {
+		return err
+	}
+
+	if c.opts.Diff != "" {
+		// Determine the changed lines first, so that an invalid base
+		// ref is reported before the fuzz tests are built
+		c.opts.changedLines, err = vcs.GitChangedLines(c.opts.ProjectDir, c.opts.Diff)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.opts.Serve != "" {
+		return c.serveReport()
+	}
+	return c.createReport()
+}
+
+// createReport creates the coverage report of the fuzz test, or the
+// combined report of all fuzz tests if --all is used
+func (c *coverageCmd) createReport() error {
+	if c.opts.All {
+		return c.runAll()
+	}
+
+	// Cobertura and SonarQube reports and the coverage of changed lines
+	// are created from the LCOV report, which is created in a temporary
+	// directory
+	outputFormat := c.opts.OutputFormat
+	outputPath := c.opts.OutputPath
+	if c.opts.createdFromLCOVReport() {
+		tempDir, err := os.MkdirTemp("", "cifuzz-coverage-")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer fileutil.Cleanup(tempDir)
+		outputFormat = coverage.FormatLCOV
+		outputPath = tempDir
+		switch c.opts.BuildSystem {
+		case config.BuildSystemBazel, config.BuildSystemCMake, config.BuildSystemOther:
+			// These generators expect the path of the lcov file
+			// instead of a directory
+			outputPath = filepath.Join(tempDir, "coverage.lcov")
+		}
+	}
+
+	reportPath, err := c.generateReport(c.opts.fuzzTest, c.opts.targetMethod, c.opts.testNamePattern, outputFormat, outputPath)
+	if err != nil {
+		return err
+	}
+
+	if c.opts.createdFromLCOVReport() {
+		lcovReport, err := parseLCOVReport(reportPath)
+		if err != nil {
+			return err
+		}
+		return c.writeLCOVBasedReport(lcovReport, c.opts.fuzzTest)
+	}
+
+	switch c.opts.OutputFormat {
+	case coverage.FormatHTML:
+		return c.handleHTMLReport(reportPath)
+	case coverage.FormatLCOV:
+		log.Successf("Created coverage lcov report: %s", reportPath)
+		return nil
+	case coverage.FormatJacocoXML:
+		log.Successf("Created jacoco.xml coverage report: %s", reportPath)
+		return nil
+	default:
+		return errors.Errorf("Unsupported output format")
+	}
+}
+
+// generateReport builds the fuzz test and creates its coverage report
+// in the given format
+func (c *coverageCmd) generateReport(fuzzTest, targetMethod, testNamePattern, outputFormat, outputPath string) (string, error) {
 	var gen Generator
 	switch c.opts.BuildSystem {
 	case config.BuildSystemBazel:
 		gen = &bazelCoverage.CoverageGenerator{
-			FuzzTest:        c.opts.fuzzTest,
-			OutputFormat:    c.opts.OutputFormat,
-			OutputPath:      c.opts.OutputPath,
+			FuzzTest:        fuzzTest,
+			OutputFormat:    outputFormat,
+			OutputPath:      outputPath,
 			BuildSystemArgs: c.opts.argsToPass,
 			ProjectDir:      c.opts.ProjectDir,
 			Engine:          "libfuzzer",
@@ -291,9 +622,26 @@ func (c *coverageCmd) run() error {
 			}
 		}
 
+		if c.opts.usesGcov() {
+			gen = &gcovCoverage.CoverageGenerator{
+				OutputFormat:    outputFormat,
+				OutputPath:      outputPath,
+				BuildSystemArgs: c.opts.argsToPass,
+				NumBuildJobs:    c.opts.NumBuildJobs,
+				CorpusDirs:      c.opts.CorpusDirs,
+				BuildResult:     c.cmakeBuildResults[fuzzTest],
+				FuzzTest:        fuzzTest,
+				ProjectDir:      c.opts.ProjectDir,
+				Stderr:          c.OutOrStderr(),
+				BuildStdout:     c.opts.buildStdout,
+				BuildStderr:     c.opts.buildStderr,
+			}
+			break
+		}
+
 		gen = &llvmCoverage.CoverageGenerator{
-			OutputFormat:    c.opts.OutputFormat,
-			OutputPath:      c.opts.OutputPath,
+			OutputFormat:    outputFormat,
+			OutputPath:      outputPath,
 			BuildSystem:     c.opts.BuildSystem,
 			BuildCommand:    c.opts.BuildCommand,
 			BuildSystemArgs: c.opts.argsToPass,
@@ -301,7 +649,9 @@ func (c *coverageCmd) run() error {
 			NumBuildJobs:    c.opts.NumBuildJobs,
 			CorpusDirs:      c.opts.CorpusDirs,
 			UseSandbox:      c.opts.UseSandbox,
-			FuzzTest:        c.opts.fuzzTest,
+			NoCache:         c.opts.NoCache,
+			BuildResult:     c.cmakeBuildResults[fuzzTest],
+			FuzzTest:        fuzzTest,
 			ProjectDir:      c.opts.ProjectDir,
 			Stderr:          c.OutOrStderr(),
 			BuildStdout:     c.opts.buildStdout,
@@ -313,37 +663,30 @@ func (c *coverageCmd) run() error {
 				"These arguments are ignored: %s", strings.Join(c.opts.argsToPass, " "))
 		}
 
-		var deps []string
-		if c.opts.BuildSystem == config.BuildSystemGradle {
-			deps, err = gradle.GetDependencies(c.opts.ProjectDir)
-		} else {
-			deps, err = maven.GetDependencies(c.opts.ProjectDir, maven.ParallelOptions{
-				Enabled: viper.IsSet("build-jobs"),
-				NumJobs: c.opts.NumBuildJobs,
-			})
-		}
+		deps, err := c.jvmDependencies()
 		if err != nil {
-			return err
+			return "", err
 		}
 
-		err = cmdutils.ValidateJVMFuzzTest(c.opts.fuzzTest, &c.opts.targetMethod, deps)
+		err = cmdutils.ValidateJVMFuzzTest(fuzzTest, &targetMethod, deps)
 		if err != nil {
-			return err
+			return "", err
 		}
 
 		gen = &javaCoverage.CoverageGenerator{
-			BuildSystem:  c.opts.BuildSystem,
-			OutputFormat: c.opts.OutputFormat,
-			OutputPath:   c.opts.OutputPath,
-			FuzzTest:     c.opts.fuzzTest,
-			TargetMethod: c.opts.targetMethod,
-			ProjectDir:   c.opts.ProjectDir,
-			Deps:         deps,
-			CorpusDirs:   c.opts.CorpusDirs,
-			EngineArgs:   c.opts.EngineArgs,
-			BuildStdout:  c.opts.buildStdout,
-			BuildStderr:  c.opts.buildStderr,
-			Stderr:       c.OutOrStderr(),
+			BuildSystem:   c.opts.BuildSystem,
+			OutputFormat:  outputFormat,
+			OutputPath:    outputPath,
+			JacocoXMLPath: c.opts.JacocoXML,
+			FuzzTest:      fuzzTest,
+			TargetMethod:  targetMethod,
+			ProjectDir:    c.opts.ProjectDir,
+			Deps:          deps,
+			CorpusDirs:    c.opts.CorpusDirs,
+			EngineArgs:    c.opts.EngineArgs,
+			BuildStdout:   c.opts.buildStdout,
+			BuildStderr:   c.opts.buildStderr,
+			Stderr:        c.OutOrStderr(),
 		}
 	case config.BuildSystemNodeJS:
 		if len(c.opts.argsToPass) > 0 {
@@ -351,58 +694,405 @@ func (c *coverageCmd) run() error {
 				"These arguments are ignored: %s", strings.Join(c.opts.argsToPass, " "))
 		}
 
-		err = cmdutils.ValidateNodeFuzzTest(c.opts.ProjectDir, c.opts.fuzzTest, c.opts.testNamePattern)
+		err := cmdutils.ValidateNodeFuzzTest(c.opts.ProjectDir, fuzzTest, testNamePattern)
 		if err != nil {
-			return err
+			return "", err
 		}
 
 		gen = &nodeCoverage.CoverageGenerator{
-			OutputPath:      c.opts.OutputPath,
-			OutputFormat:    c.opts.OutputFormat,
-			TestPathPattern: c.opts.fuzzTest,
-			TestNamePattern: c.opts.testNamePattern,
-			ProjectDir:      c.opts.ProjectDir,
-			Stderr:          c.OutOrStderr(),
-			BuildStdout:     c.opts.buildStdout,
-			BuildStderr:     c.opts.buildStderr,
+			OutputPath:       outputPath,
+			OutputFormat:     outputFormat,
+			TestPathPattern:  fuzzTest,
+			TestNamePattern:  testNamePattern,
+			ProjectDir:       c.opts.ProjectDir,
+			CoverageProvider: c.opts.CoverageProvider,
+			Stderr:           c.OutOrStderr(),
+			BuildStdout:      c.opts.buildStdout,
+			BuildStderr:      c.opts.buildStderr,
 		}
 	default:
-		return errors.Errorf("Unsupported build system \"%s\"", c.opts.BuildSystem)
+		return "", errors.Errorf("Unsupported build system \"%s\"", c.opts.BuildSystem)
 	}
 
 	if c.opts.BuildSystem != config.BuildSystemNodeJS {
 		buildPrinter := logging.NewBuildPrinter(os.Stdout, log.BuildInProgressMsg)
-		log.Infof("Building %s", pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprint(c.opts.fuzzTest))
+		log.Infof("Building %s", pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprint(fuzzTest))
 
-		err = gen.BuildFuzzTestForCoverage()
+		err := gen.BuildFuzzTestForCoverage()
 		if err != nil {
 			buildPrinter.StopOnError(log.BuildInProgressErrorMsg)
-			return err
+			return "", err
 		}
 
 		buildPrinter.StopOnSuccess(log.BuildInProgressSuccessMsg, true)
 	}
 
-	reportPath, err := gen.GenerateCoverageReport()
+	return gen.GenerateCoverageReport()
+}
+
+// jvmDependencies returns the dependencies of the Maven or Gradle
+// project, which are only determined once
+func (c *coverageCmd) jvmDependencies() ([]string, error) {
+	if c.jvmDeps != nil {
+		return c.jvmDeps, nil
+	}
+	var err error
+	if c.opts.BuildSystem == config.BuildSystemGradle {
+		c.jvmDeps, err = gradle.GetDependencies(c.opts.ProjectDir)
+	} else {
+		c.jvmDeps, err = maven.GetDependencies(c.opts.ProjectDir, maven.ParallelOptions{
+			Enabled: viper.IsSet("build-jobs"),
+			NumJobs: c.opts.NumBuildJobs,
+		})
+	}
+	return c.jvmDeps, err
+}
+
+// writeConvertedReport converts the lcov report to a Cobertura or
+// SonarQube XML report at the output path
+func (c *coverageCmd) writeConvertedReport(lcovReport *parser.LCOVReport, name, outputPath string) (string, error) {
+	if outputPath == "" {
+		// Like lcov reports, the report is created in the current
+		// working directory if no output path is specified
+		outputPath = reportName(name) + ".coverage.xml"
+	}
+
+	var err error
+	if c.opts.OutputFormat == coverage.FormatSonarQube {
+		err = lcovReport.WriteSonarQubeReportToFile(outputPath, c.opts.ProjectDir)
+		if err != nil {
+			return "", err
+		}
+		log.Successf("Created SonarQube coverage report: %s", outputPath)
+		return outputPath, nil
+	}
+	err = lcovReport.WriteCoberturaReportToFile(outputPath, c.opts.ProjectDir)
+	if err != nil {
+		return "", err
+	}
+	log.Successf("Created Cobertura coverage report: %s", outputPath)
+	return outputPath, nil
+}
+
+// runAll creates a coverage report for each fuzz test of the project
+// and merges them into a single report
+func (c *coverageCmd) runAll() error {
+	fuzzTests, err := c.listFuzzTests()
+	if err != nil {
+		return err
+	}
+	if len(fuzzTests) == 0 {
+		return errors.New("No fuzz tests were found in the project")
+	}
+	log.Infof("Creating a combined coverage report for %d fuzz tests", len(fuzzTests))
+
+	if c.opts.BuildSystem == config.BuildSystemCMake {
+		// Build all fuzz tests at once instead of building each fuzz
+		// test separately when its report is created
+		err = c.buildCMakeFuzzTests(fuzzTests)
+		if err != nil {
+			return err
+		}
+	}
+	if c.opts.PerTestOutput != "" {
+		err = os.MkdirAll(c.opts.PerTestOutput, 0o755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	tempDir, err := os.MkdirTemp("", "cifuzz-coverage-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tempDir)
+
+	var reports []*parser.LCOVReport
+	for i, name := range fuzzTests {
+		fuzzTest := name
+		var targetMethod, testNamePattern string
+		switch c.opts.BuildSystem {
+		case config.BuildSystemMaven, config.BuildSystemGradle:
+			fuzzTest, targetMethod = cmdutils.SeparateTargetClassAndMethod(fuzzTest)
+		case config.BuildSystemNodeJS:
+			if strings.Contains(fuzzTest, ":") {
+				split := strings.Split(fuzzTest, ":")
+				fuzzTest, testNamePattern = split[0], strings.ReplaceAll(split[1], "\"", "")
+			}
+		}
+
+		// The Java and Node.js generators expect a directory as
+		// output path, the others the path of the lcov file
+		outputPath := filepath.Join(tempDir, strconv.Itoa(i))
+		if c.opts.BuildSystem == config.BuildSystemCMake {
+			outputPath += ".lcov"
+		}
+		reportPath, err := c.generateReport(fuzzTest, targetMethod, testNamePattern, coverage.FormatLCOV, outputPath)
+		if err != nil {
+			return err
+		}
+		report, err := parseLCOVReport(reportPath)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+	}
+
+	return c.writeAllReports(fuzzTests, reports)
+}
+
+// writeAllReports writes the reports of the fuzz tests to the directory
+// specified via --per-test-output, if any, and the merged report
+func (c *coverageCmd) writeAllReports(fuzzTests []string, reports []*parser.LCOVReport) error {
+	if c.opts.PerTestOutput != "" {
+		for i, report := range reports {
+			err := c.writePerTestReport(report, fuzzTests[i])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return c.writeLCOVBasedReport(parser.MergeLCOVReports(reports...), "all")
+}
+
+// writeLCOVBasedReport creates the output from the lcov report, after
+// removing the source files which are excluded via the
+// coverage-include and coverage-exclude settings. The paths in lcov
+// reports are remapped via the coverage-path-mapping setting. The name
+// is used for the default output path.
+func (c *coverageCmd) writeLCOVBasedReport(lcovReport *parser.LCOVReport, name string) error {
+	lcovReport, err := lcovReport.FilterSourceFiles(c.opts.CoverageInclude, c.opts.CoverageExclude, c.opts.ProjectDir)
 	if err != nil {
 		return err
 	}
 
+	if c.opts.Diff != "" {
+		return c.printDiffCoverage(lcovReport)
+	}
+
+	reportPath, err := c.writeFilteredReport(lcovReport, name, c.opts.OutputPath)
+	if err != nil {
+		return err
+	}
+	if c.opts.OutputFormat == coverage.FormatHTML {
+		return c.handleHTMLReport(reportPath)
+	}
+	return nil
+}
+
+// writePerTestReport writes the report of a single fuzz test to the
+// directory specified via --per-test-output
+func (c *coverageCmd) writePerTestReport(lcovReport *parser.LCOVReport, fuzzTest string) error {
+	lcovReport, err := lcovReport.FilterSourceFiles(c.opts.CoverageInclude, c.opts.CoverageExclude, c.opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	outputPath := filepath.Join(c.opts.PerTestOutput, reportName(fuzzTest)+perTestReportExtensions[c.opts.OutputFormat])
+	reportPath, err := c.writeFilteredReport(lcovReport, fuzzTest, outputPath)
+	if err != nil {
+		return err
+	}
+	if c.opts.OutputFormat == coverage.FormatHTML {
+		log.Successf("Created coverage HTML report: %s", reportPath)
+	}
+	return nil
+}
+
+// The file extensions of the reports written to the directory specified
+// via --per-test-output. HTML reports are directories.
+var perTestReportExtensions = map[string]string{
+	coverage.FormatHTML:      "",
+	coverage.FormatLCOV:      ".lcov",
+	coverage.FormatCobertura: ".cobertura.xml",
+	coverage.FormatSonarQube: ".sonarqube.xml",
+}
+
+// writeFilteredReport writes the lcov report in the output format to the
+// output path, or to a default path if it's empty, and returns the path
+// of the written report
+func (c *coverageCmd) writeFilteredReport(lcovReport *parser.LCOVReport, name, outputPath string) (string, error) {
 	switch c.opts.OutputFormat {
 	case coverage.FormatHTML:
-		return c.handleHTMLReport(reportPath)
+		tempDir, err := os.MkdirTemp("", "cifuzz-coverage-")
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		defer fileutil.Cleanup(tempDir)
+		lcovPath := filepath.Join(tempDir, "coverage.lcov")
+		err = lcovReport.WriteLCOVReportToFile(lcovPath)
+		if err != nil {
+			return "", err
+		}
+		reportPath := outputPath
+		if reportPath == "" {
+			// If no output path is specified, we create the output in a
+			// temporary directory.
+			outputDir, err := os.MkdirTemp("", "coverage-")
+			if err != nil {
+				return "", errors.WithStack(err)
+			}
+			reportPath = filepath.Join(outputDir, reportName(name))
+		}
+		err = c.runGenHTML(lcovPath, reportPath)
+		if err != nil {
+			return "", err
+		}
+		return reportPath, nil
 	case coverage.FormatLCOV:
+		reportPath := outputPath
+		if reportPath == "" {
+			reportPath = reportName(name) + ".coverage.lcov"
+		}
+		lcovReport.RemapPaths(c.opts.pathMappings)
+		err := lcovReport.WriteLCOVReportToFile(reportPath)
+		if err != nil {
+			return "", err
+		}
 		log.Successf("Created coverage lcov report: %s", reportPath)
+		return reportPath, nil
+	case coverage.FormatCobertura, coverage.FormatSonarQube:
+		return c.writeConvertedReport(lcovReport, name, outputPath)
+	default:
+		return "", errors.Errorf("Unsupported output format")
+	}
+}
+
+// printDiffCoverage prints the coverage of the lines which were changed
+// compared to the base ref
+func (c *coverageCmd) printDiffCoverage(lcovReport *parser.LCOVReport) error {
+	diff := lcovReport.DiffCoverage(c.opts.changedLines, c.opts.ProjectDir)
+	if diff.LinesValid == 0 {
+		log.Infof("No changes to code covered by the coverage report since %s", c.opts.Diff)
 		return nil
-	case coverage.FormatJacocoXML:
-		log.Successf("Created jacoco.xml coverage report: %s", reportPath)
-		return nil
+	}
+
+	data := [][]string{{"File", "Covered Lines", "Changed Lines", "Coverage", "Uncovered Lines"}}
+	for _, f := range diff.Files {
+		data = append(data, []string{
+			f.Path,
+			strconv.Itoa(f.LinesCovered),
+			strconv.Itoa(f.LinesValid),
+			percentage(f.LinesCovered, f.LinesValid),
+			parser.LineRanges(f.UncoveredLines),
+		})
+	}
+	err := pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(c.OutOrStdout()).Render()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = fmt.Fprintf(c.OutOrStdout(), "\nCoverage of the lines changed since %s: %s (%d/%d)\n",
+		c.opts.Diff, percentage(diff.LinesCovered, diff.LinesValid), diff.LinesCovered, diff.LinesValid)
+	return errors.WithStack(err)
+}
+
+func percentage(covered, valid int) string {
+	return fmt.Sprintf("%.1f%%", float64(covered)*100/float64(valid))
+}
+
+// listFuzzTests returns all fuzz tests of the project
+func (c *coverageCmd) listFuzzTests() ([]string, error) {
+	switch c.opts.BuildSystem {
+	case config.BuildSystemCMake:
+		builder, err := c.cmakeBuilder()
+		if err != nil {
+			return nil, err
+		}
+		return builder.ListFuzzTests()
+	case config.BuildSystemMaven, config.BuildSystemGradle:
+		testDirs := []string{filepath.Join(c.opts.ProjectDir, "src", "test")}
+		return cmdutils.ListJVMFuzzTestsByRegex(testDirs, "")
+	case config.BuildSystemNodeJS:
+		return cmdutils.ListNodeFuzzTestsByRegex(c.opts.ProjectDir, "")
 	default:
-		return errors.Errorf("Unsupported output format")
+		return nil, errors.Errorf("Unsupported build system \"%s\"", c.opts.BuildSystem)
+	}
+}
+
+// cmakeBuilder returns a builder which is configured to build the fuzz
+// tests with coverage instrumentation
+func (c *coverageCmd) cmakeBuilder() (*cmake.Builder, error) {
+	opts := &cmake.BuilderOptions{
+		ProjectDir: c.opts.ProjectDir,
+		Args:       c.opts.argsToPass,
+		Sanitizers: []string{"coverage"},
+		Parallel: cmake.ParallelOptions{
+			Enabled: viper.IsSet("build-jobs"),
+			NumJobs: c.opts.NumBuildJobs,
+		},
+		Stdout: c.opts.buildStdout,
+		Stderr: c.opts.buildStderr,
+		// The runtime deps are passed to llvm-cov
+		FindRuntimeDeps: true,
+	}
+	if c.opts.usesGcov() {
+		opts.Sanitizers = []string{"gcov"}
+		opts.Engine = cmake.EngineReplayer
+		opts.FindRuntimeDeps = false
+	}
+	builder, err := cmake.NewBuilder(opts)
+	if err != nil {
+		return nil, err
 	}
+	err = builder.Configure()
+	if err != nil {
+		return nil, err
+	}
+	return builder, nil
+}
+
+// buildCMakeFuzzTests builds the fuzz tests in a single build, the
+// results are used by the coverage generators of the fuzz tests
+func (c *coverageCmd) buildCMakeFuzzTests(fuzzTests []string) error {
+	builder, err := c.cmakeBuilder()
+	if err != nil {
+		return err
+	}
+	results, err := builder.Build(fuzzTests)
+	if err != nil {
+		return err
+	}
+	c.cmakeBuildResults = map[string]*build.CBuildResult{}
+	for i, result := range results {
+		c.cmakeBuildResults[fuzzTests[i]] = result
+	}
+	return nil
+}
+
+// runGenHTML creates an HTML report from the lcov report
+func (c *coverageCmd) runGenHTML(lcovPath, outputPath string) error {
+	cmd, err := coverage.GenHTMLCommand(lcovPath, outputPath)
+	if err != nil {
+		return err
+	}
+	cmd.Dir = c.opts.ProjectDir
+	cmd.Stderr = c.opts.buildStderr
+	log.Debugf("Command: %s", cmd.String())
+	return errors.WithStack(cmd.Run())
+}
+
+func parseLCOVReport(path string) (*parser.LCOVReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	return parser.ParseLCOVFileIntoLCOVReport(f)
+}
+
+// reportName returns a file name for the report of the fuzz test
+func reportName(fuzzTest string) string {
+	return strings.NewReplacer("/", "-", ":", "-").Replace(strings.TrimLeft(fuzzTest, "/"))
 }
 
 func (c *coverageCmd) handleHTMLReport(reportPath string) error {
+	if c.opts.Serve != "" {
+		// The report is opened by serveReport once the server is
+		// running
+		c.htmlReportDir = reportPath
+		return nil
+	}
+
 	htmlFile := filepath.Join(reportPath, "index.html")
 
 	// Open the browser if no output path was specified
@@ -451,21 +1141,24 @@ func (c *coverageCmd) checkDependencies() error {
 	case config.BuildSystemBazel:
 		deps = []dependencies.Key{
 			dependencies.Bazel,
-			dependencies.GenHTML,
+			dependencies.LLVMCov,
+			dependencies.LLVMProfData,
 		}
 	case config.BuildSystemCMake:
+		if c.opts.usesGcov() {
+			deps = []dependencies.Key{dependencies.CMake, dependencies.Gcovr}
+			break
+		}
 		deps = []dependencies.Key{
 			dependencies.CMake,
-			dependencies.LLVMSymbolizer,
 			dependencies.LLVMCov,
 			dependencies.LLVMProfData,
-			dependencies.GenHTML,
 		}
 		switch runtime.GOOS {
 		case "linux", "darwin":
 			deps = append(deps, dependencies.Clang)
 		case "windows":
-			deps = append(deps, dependencies.VisualStudio, dependencies.Perl)
+			deps = append(deps, dependencies.VisualStudio)
 		}
 	case config.BuildSystemMaven:
 		deps = []dependencies.Key{dependencies.Maven}
@@ -476,14 +1169,32 @@ func (c *coverageCmd) checkDependencies() error {
 	case config.BuildSystemOther:
 		deps = []dependencies.Key{
 			dependencies.Clang,
-			dependencies.LLVMSymbolizer,
 			dependencies.LLVMCov,
 			dependencies.LLVMProfData,
-			dependencies.GenHTML,
 		}
 	default:
 		return errors.Errorf("Unsupported build system \"%s\"", c.opts.BuildSystem)
 	}
+
+	// genhtml is only needed to create HTML reports of C/C++ projects
+	// and HTML reports which are created from the lcov report, so that
+	// other formats can be created without it
+	switch c.opts.BuildSystem {
+	case config.BuildSystemBazel, config.BuildSystemCMake, config.BuildSystemOther:
+		if c.opts.OutputFormat == coverage.FormatHTML && c.opts.Diff == "" {
+			deps = append(deps, dependencies.GenHTML)
+			if runtime.GOOS == "windows" {
+				deps = append(deps, dependencies.Perl)
+			}
+		}
+	default:
+		if (c.opts.All || c.opts.filtersSourceFiles()) && c.opts.OutputFormat == coverage.FormatHTML && c.opts.Diff == "" {
+			deps = append(deps, dependencies.GenHTML)
+			if runtime.GOOS == "windows" {
+				deps = append(deps, dependencies.Perl)
+			}
+		}
+	}
 	err := dependencies.Check(deps, c.opts.ProjectDir)
 	if err != nil {
 		return err
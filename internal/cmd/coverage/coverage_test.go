@@ -3,7 +3,9 @@ package coverage
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/spf13/viper"
@@ -12,8 +14,10 @@ import (
 
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/coverage"
 	"code-intelligence.com/cifuzz/internal/testutil"
 	"code-intelligence.com/cifuzz/pkg/dependencies"
+	parser "code-intelligence.com/cifuzz/pkg/parser/coverage"
 )
 
 func TestMain(m *testing.M) {
@@ -91,6 +95,23 @@ func TestLlvmCovVersion(t *testing.T) {
 		fmt.Sprintf(dependencies.MessageVersion, "llvm-cov", dep.MinVersion.String(), version))
 }
 
+func TestGenHTMLMissing(t *testing.T) {
+	dependencies.TestMockAllDeps(t)
+	dependencies.OverwriteUninstalled(dependencies.GetDep(dependencies.GenHTML))
+
+	// clone the example project because this command needs to parse an actual
+	// project config... if there is none it will fail before the dependency check
+	testutil.BootstrapExampleProjectForTest(t, "coverage-cmd-test", config.BuildSystemCMake)
+
+	_, stdErr, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "my_fuzz_test")
+	require.Error(t, err)
+	assert.Contains(t, stdErr, fmt.Sprintf(dependencies.MessageMissing, "genhtml"))
+
+	// genhtml is only needed for HTML reports
+	_, stdErr, _ = cmdutils.ExecuteCommand(t, New(), os.Stdin, "--format=lcov", "my_fuzz_test")
+	assert.NotContains(t, stdErr, fmt.Sprintf(dependencies.MessageMissing, "genhtml"))
+}
+
 func TestNodeMissing(t *testing.T) {
 	dependencies.TestMockAllDeps(t)
 	dependencies.OverwriteUninstalled(dependencies.GetDep(dependencies.Node))
@@ -104,3 +125,125 @@ func TestNodeMissing(t *testing.T) {
 
 	assert.Contains(t, stdErr, fmt.Sprintf(dependencies.MessageMissing, "node"))
 }
+
+func TestJacocoXMLOnlyForJava(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "coverage-cmd-test", config.BuildSystemCMake)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--jacoco-xml", "jacoco.xml", "my_fuzz_test")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Flag 'jacoco-xml' is only applicable for build system types 'Maven' and 'Gradle'")
+}
+
+func TestAllWithFuzzTest(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "coverage-cmd-test", config.BuildSystemCMake)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--all", "my_fuzz_test")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Flag 'all' can't be used together with a <fuzz test> argument")
+}
+
+func TestAllUnsupportedBuildSystem(t *testing.T) {
+	projectDir := testutil.BootstrapExampleProjectForTest(t, "coverage-cmd-test", config.BuildSystemCMake)
+	f, err := os.OpenFile(filepath.Join(projectDir, "cifuzz.yaml"), os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString("\nbuild-system: other\nbuild-command: make\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, _, err = cmdutils.ExecuteCommand(t, New(), os.Stdin, "--all")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Flag 'all' is only applicable for build system types")
+}
+
+func TestCoverageProviderOnlyForNode(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "coverage-cmd-test", config.BuildSystemCMake)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--coverage-provider", "v8", "my_fuzz_test")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Flag 'coverage-provider' is only applicable for build system type 'Node.js'")
+}
+
+func TestCoverageExcludeNotSupportedForJacoco(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "coverage-cmd-test", config.BuildSystemMaven)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--coverage-exclude", "third_party", "--format", "jacocoxml", "com.example.FuzzTestCase")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "The settings 'coverage-include' and 'coverage-exclude' are not supported for the JaCoCo format")
+}
+
+func TestServeOnlyForHTML(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "coverage-cmd-test", config.BuildSystemCMake)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--serve", "--format", "lcov", "my_fuzz_test")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Flag 'serve' can only be used with the HTML format")
+}
+
+func TestInvalidCoveragePathMapping(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "coverage-cmd-test", config.BuildSystemCMake)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--coverage-path-mapping", "/build/src", "my_fuzz_test")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid path mapping \"/build/src\"")
+}
+
+func TestPerTestOutputRequiresAll(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "coverage-cmd-test", config.BuildSystemCMake)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--per-test-output", "reports", "my_fuzz_test")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Flag 'per-test-output' can only be used together with flag 'all'")
+}
+
+func TestGcovOnlyForCMake(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "coverage-cmd-test", config.BuildSystemMaven)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--coverage-tool", "gcov", "com.example.FuzzTestCase")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Coverage tool 'gcov' is only supported for build system type 'CMake'")
+}
+
+func TestWriteAllReports_PerTestOutputWithPathMapping(t *testing.T) {
+	projectDir := t.TempDir()
+	pathMappings, err := parser.ParsePathMappings([]string{"/build/src=src"}, projectDir)
+	require.NoError(t, err)
+	outputDir := t.TempDir()
+	c := &coverageCmd{opts: &coverageOptions{
+		OutputFormat:    coverage.FormatLCOV,
+		OutputPath:      filepath.Join(outputDir, "all.lcov"),
+		PerTestOutput:   filepath.Join(outputDir, "per-test"),
+		CoverageInclude: []string{"/build/src/**"},
+		ProjectDir:      projectDir,
+		pathMappings:    pathMappings,
+	}}
+	require.NoError(t, os.MkdirAll(c.opts.PerTestOutput, 0o755))
+
+	var reports []*parser.LCOVReport
+	for _, line := range []string{"DA:1,1", "DA:2,1"} {
+		report, err := parser.ParseLCOVFileIntoLCOVReport(strings.NewReader("SF:/build/src/a.c\n" + line + "\nend_of_record\n"))
+		require.NoError(t, err)
+		reports = append(reports, report)
+	}
+	err = c.writeAllReports([]string{"first_fuzz_test", "second_fuzz_test"}, reports)
+	require.NoError(t, err)
+
+	readReport := func(path string) *parser.LCOVReport {
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		report, err := parser.ParseLCOVFileIntoLCOVReport(f)
+		require.NoError(t, err)
+		return report
+	}
+	for _, fuzzTest := range []string{"first_fuzz_test", "second_fuzz_test"} {
+		report := readReport(filepath.Join(c.opts.PerTestOutput, reportName(fuzzTest)+".lcov"))
+		require.Len(t, report.SourceFiles, 1)
+		assert.Equal(t, filepath.Join("src", "a.c"), report.SourceFiles[0].Name)
+	}
+	// Remapping the paths of the per-test reports doesn't affect the
+	// merged report, which is filtered by the original paths
+	merged := readReport(c.opts.OutputPath)
+	require.Len(t, merged.SourceFiles, 1)
+	assert.Equal(t, filepath.Join("src", "a.c"), merged.SourceFiles[0].Name)
+	assert.Len(t, merged.SourceFiles[0].LineInformation, 2)
+}
@@ -0,0 +1,355 @@
+package gcov
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/otiai10/copy"
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/build/cmake"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/coverage"
+	"code-intelligence.com/cifuzz/pkg/log"
+	parser "code-intelligence.com/cifuzz/pkg/parser/coverage"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/executil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+// CoverageGenerator creates coverage reports with gcov instead of
+// clang's source-based coverage, so that it can be used for projects
+// which must be built with GCC. The fuzz tests are built with the
+// replayer instead of libFuzzer, which runs the inputs of the corpus,
+// and the coverage data is converted to an lcov report via gcovr.
+type CoverageGenerator struct {
+	OutputFormat    string
+	OutputPath      string
+	BuildSystemArgs []string
+	NumBuildJobs    uint
+	CorpusDirs      []string
+	FuzzTest        string
+	ProjectDir      string
+	Stderr          io.Writer
+	BuildStdout     io.Writer
+	BuildStderr     io.Writer
+	// If set, the fuzz test was already built and is not built again
+	BuildResult *build.CBuildResult
+
+	executable string
+	buildDir   string
+	tmpDir     string
+}
+
+func (cov *CoverageGenerator) BuildFuzzTestForCoverage() error {
+	var err error
+	cov.tmpDir, err = os.MkdirTemp("", "gcov-coverage-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if cov.BuildResult != nil {
+		return cov.useBuildResult(cov.BuildResult)
+	}
+
+	builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
+		ProjectDir: cov.ProjectDir,
+		Args:       cov.BuildSystemArgs,
+		Sanitizers: []string{"gcov"},
+		Engine:     cmake.EngineReplayer,
+		Parallel: cmake.ParallelOptions{
+			Enabled: viper.IsSet("build-jobs"),
+			NumJobs: cov.NumBuildJobs,
+		},
+		Stdout: cov.BuildStdout,
+		Stderr: cov.BuildStderr,
+	})
+	if err != nil {
+		return err
+	}
+	err = builder.Configure()
+	if err != nil {
+		return err
+	}
+	buildResults, err := builder.Build([]string{cov.FuzzTest})
+	if err != nil {
+		return err
+	}
+	return cov.useBuildResult(buildResults[0])
+}
+
+func (cov *CoverageGenerator) useBuildResult(buildResult *build.CBuildResult) error {
+	cov.executable = buildResult.Executable
+	cov.buildDir = buildResult.BuildDir
+
+	// Use the seed corpus directory and generated corpus directory if
+	// they exist.
+	for _, path := range []string{buildResult.SeedCorpus, buildResult.GeneratedCorpus} {
+		exists, err := fileutil.Exists(path)
+		if err != nil {
+			return err
+		}
+		if exists {
+			cov.CorpusDirs = append(cov.CorpusDirs, path)
+		}
+	}
+
+	return nil
+}
+
+func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
+	log.Infof("Running %s on corpus", pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprint(cov.FuzzTest))
+	log.Debugf("Executable: %s", cov.executable)
+
+	ctx := context.Background()
+	defer fileutil.Cleanup(cov.tmpDir)
+
+	// The coverage data of previous runs is accumulated by gcov, so it
+	// has to be removed before the fuzz test is run
+	err := removeCoverageData(cov.buildDir)
+	if err != nil {
+		return "", err
+	}
+	err = cov.run(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	lcovPath, err := cov.generateLcovReport(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	reportFile, err := os.Open(lcovPath)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	summary, err := parser.ParseLCOVReportIntoSummary(reportFile)
+	reportFile.Close()
+	if err != nil {
+		return "", err
+	}
+	summary.NormalizePaths(cov.ProjectDir)
+	summary.PrintTable(cov.Stderr)
+
+	switch cov.OutputFormat {
+	case coverage.FormatHTML:
+		return cov.generateHTMLReport(lcovPath)
+	case coverage.FormatLCOV:
+		outputPath := cov.OutputPath
+		if outputPath == "" {
+			// Like for the llvm-cov based reports, the lcov report is
+			// created in the current working directory if no output
+			// path is specified
+			outputPath = cov.executableName() + ".coverage.lcov"
+		}
+		err = copy.Copy(lcovPath, outputPath)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		log.Debugf("Created lcov trace file: %s", outputPath)
+		return outputPath, nil
+	}
+
+	return "", errors.Errorf("undefined output format: %s", cov.OutputFormat)
+}
+
+// run executes the fuzz test on the empty input and the inputs in the
+// corpus directories. If the fuzz test crashes on an input, the
+// replayer stops and the coverage data of the run is lost, so the
+// inputs are then executed one by one, which only loses the coverage of
+// the crashing inputs.
+func (cov *CoverageGenerator) run(ctx context.Context) error {
+	emptyInput := filepath.Join(cov.tmpDir, "empty_input")
+	err := fileutil.Touch(emptyInput)
+	if err != nil {
+		return err
+	}
+
+	err = cov.runFuzzTest(ctx, append([]string{emptyInput}, cov.CorpusDirs...))
+	if err == nil {
+		return nil
+	}
+	log.Debugf("Running the fuzz test on all inputs failed: %v", err)
+	log.Info("The fuzz test failed on an input of the corpus, running the inputs one by one")
+
+	err = removeCoverageData(cov.buildDir)
+	if err != nil {
+		return err
+	}
+	inputs, err := corpusInputs(cov.CorpusDirs)
+	if err != nil {
+		return err
+	}
+	for _, input := range append([]string{emptyInput}, inputs...) {
+		err = cov.runFuzzTest(ctx, []string{input})
+		if err != nil {
+			log.Debugf("Failed to run the fuzz test on %s: %v", input, err)
+		}
+	}
+	return nil
+}
+
+func (cov *CoverageGenerator) runFuzzTest(ctx context.Context, inputs []string) error {
+	var err error
+	cmd := executil.CommandContext(ctx, cov.executable, inputs...)
+	cmd.Env, err = envutil.Setenv(os.Environ(), "NO_CIFUZZ", "1")
+	if err != nil {
+		return err
+	}
+	// Pass the sanitizer options from cifuzz.yaml to the fuzz test
+	sanitizerOptions, err := config.SanitizerOptionsEnvVars()
+	if err != nil {
+		return err
+	}
+	cmd.Env, err = envutil.Copy(cmd.Env, sanitizerOptions)
+	if err != nil {
+		return err
+	}
+
+	errStream := &bytes.Buffer{}
+	if viper.GetBool("verbose") {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	} else {
+		cmd.Stderr = errStream
+	}
+
+	log.Debugf("Command: %s", strings.Join(stringutil.QuotedStrings(cmd.Args), " "))
+	err = cmd.Run()
+	if err != nil {
+		// Add stderr output of the fuzz test to provide users with
+		// the context of this error even without verbose mode.
+		if !viper.GetBool("verbose") {
+			err = fmt.Errorf("%w\n%s", err, errStream.String())
+		}
+		return cmdutils.WrapExecError(errors.WithStack(err), cmd.Cmd)
+	}
+	return nil
+}
+
+// generateLcovReport converts the coverage data in the build directory
+// into an lcov report via gcovr. Only source files in the project
+// directory are included in the report.
+func (cov *CoverageGenerator) generateLcovReport(ctx context.Context) (string, error) {
+	gcovr, err := runfiles.Finder.GcovrPath()
+	if err != nil {
+		return "", err
+	}
+
+	lcovPath := filepath.Join(cov.tmpDir, "coverage.lcov")
+	args := []string{"--root", cov.ProjectDir, "--lcov", lcovPath}
+	// gcovr uses the GCOV environment variable if it's set
+	if _, set := os.LookupEnv("GCOV"); !set {
+		if gcov := gcovExecutable(os.Getenv("CC")); gcov != "" {
+			args = append(args, "--gcov-executable", gcov)
+		}
+	}
+	args = append(args, cov.buildDir)
+
+	cmd := exec.CommandContext(ctx, gcovr, args...)
+	cmd.Dir = cov.ProjectDir
+	cmd.Stdout = cov.BuildStdout
+	cmd.Stderr = os.Stderr
+	log.Debugf("Command: %s", strings.Join(stringutil.QuotedStrings(cmd.Args), " "))
+	err = cmd.Run()
+	if err != nil {
+		return "", cmdutils.WrapExecError(errors.WithStack(err), cmd)
+	}
+	return lcovPath, nil
+}
+
+func (cov *CoverageGenerator) generateHTMLReport(lcovPath string) (string, error) {
+	if cov.OutputPath == "" {
+		// If no output path is specified, we create the output in a
+		// temporary directory.
+		outputDir, err := os.MkdirTemp("", "coverage-")
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		cov.OutputPath = filepath.Join(outputDir, cov.executableName())
+	}
+
+	// Create an HTML report via genhtml
+	cmd, err := coverage.GenHTMLCommand(lcovPath, cov.OutputPath)
+	if err != nil {
+		return "", err
+	}
+	cmd.Dir = cov.ProjectDir
+	cmd.Stderr = os.Stderr
+	log.Debugf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return cov.OutputPath, nil
+}
+
+func (cov *CoverageGenerator) executableName() string {
+	return filepath.Base(cov.executable)
+}
+
+// gcovExecutable returns the gcov tool which matches the C compiler,
+// because gcov can't read the coverage data of other GCC versions. For
+// example, gcov-12 is used for gcc-12 and llvm-cov's gcov mode is used
+// for clang. An empty string is returned if the compiler is unknown.
+func gcovExecutable(cc string) string {
+	dir, name := filepath.Split(cc)
+	switch {
+	case strings.Contains(name, "clang"):
+		return filepath.Join(dir, strings.Replace(name, "clang", "llvm-cov", 1)) + " gcov"
+	case strings.Contains(name, "gcc"):
+		return filepath.Join(dir, strings.Replace(name, "gcc", "gcov", 1))
+	default:
+		return ""
+	}
+}
+
+// removeCoverageData removes the .gcda files of previous runs from the
+// build directory
+func removeCoverageData(buildDir string) error {
+	err := filepath.WalkDir(buildDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".gcda" {
+			return os.Remove(path)
+		}
+		return nil
+	})
+	return errors.WithStack(err)
+}
+
+// corpusInputs returns the paths of all inputs in the corpus directories
+func corpusInputs(corpusDirs []string) ([]string, error) {
+	var inputs []string
+	for _, dir := range corpusDirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				inputs = append(inputs, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return inputs, nil
+}
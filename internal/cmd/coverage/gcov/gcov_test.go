@@ -0,0 +1,57 @@
+package gcov
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGcovExecutable(t *testing.T) {
+	assert.Equal(t, "gcov", gcovExecutable("gcc"))
+	assert.Equal(t, "gcov-12", gcovExecutable("gcc-12"))
+	assert.Equal(t, filepath.Join("/usr", "bin", "x86_64-linux-gnu-gcov-12"), gcovExecutable("/usr/bin/x86_64-linux-gnu-gcc-12"))
+	assert.Equal(t, "llvm-cov gcov", gcovExecutable("clang"))
+	assert.Equal(t, "llvm-cov-15 gcov", gcovExecutable("clang-15"))
+	assert.Empty(t, gcovExecutable(""))
+	assert.Empty(t, gcovExecutable("cc"))
+}
+
+func TestRemoveCoverageData(t *testing.T) {
+	buildDir := t.TempDir()
+	objectsDir := filepath.Join(buildDir, "CMakeFiles", "my_fuzz_test.dir")
+	require.NoError(t, os.MkdirAll(objectsDir, 0o755))
+	for _, name := range []string{"my_fuzz_test.cpp.gcda", "my_fuzz_test.cpp.gcno", "my_fuzz_test.cpp.o"} {
+		require.NoError(t, os.WriteFile(filepath.Join(objectsDir, name), nil, 0o644))
+	}
+
+	require.NoError(t, removeCoverageData(buildDir))
+
+	entries, err := os.ReadDir(objectsDir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	// The notes files, which are created by the compiler, are kept
+	assert.Equal(t, []string{"my_fuzz_test.cpp.gcno", "my_fuzz_test.cpp.o"}, names)
+}
+
+func TestCorpusInputs(t *testing.T) {
+	seedCorpus := t.TempDir()
+	generatedCorpus := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(seedCorpus, "nested"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(seedCorpus, "a"), []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(seedCorpus, "nested", "b"), []byte("b"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(generatedCorpus, "c"), []byte("c"), 0o644))
+
+	inputs, err := corpusInputs([]string{seedCorpus, generatedCorpus})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(seedCorpus, "a"),
+		filepath.Join(seedCorpus, "nested", "b"),
+		filepath.Join(generatedCorpus, "c"),
+	}, inputs)
+}
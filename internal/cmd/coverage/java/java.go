@@ -28,9 +28,12 @@ type CoverageGenerator struct {
 	BuildSystem  string
 	OutputFormat string
 	OutputPath   string
-	FuzzTest     string
-	TargetMethod string
-	ProjectDir   string
+	// If set, the JaCoCo XML report is also copied to this path,
+	// independent of the output format
+	JacocoXMLPath string
+	FuzzTest      string
+	TargetMethod  string
+	ProjectDir    string
 
 	Deps       []string
 	CorpusDirs []string
@@ -77,9 +80,13 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 	if cov.BuildSystem == config.BuildSystemGradle {
 		classFilesDir = filepath.Join(cov.ProjectDir, "build", "classes")
 	}
+	classFilesDirs, err := cov.classFilesDirs(classFilesDir)
+	if err != nil {
+		return "", err
+	}
 
 	htmlPath := filepath.Join(cov.OutputPath, "html")
-	jacocoXMLPath, err := cov.runJacocoCommand(cliJar, cov.jacocoExecFilePath(), htmlPath, classFilesDir)
+	jacocoXMLPath, err := cov.runJacocoCommand(cliJar, cov.jacocoExecFilePath(), htmlPath, classFilesDirs...)
 	if err != nil {
 		return "", err
 	}
@@ -94,6 +101,13 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 	// for lcov parsing if needed
 	jacocoReport.Close()
 
+	if cov.JacocoXMLPath != "" {
+		err = copyJacocoXMLReport(jacocoXMLPath, cov.JacocoXMLPath)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	switch cov.OutputFormat {
 	case coverage.FormatJacocoXML:
 		return jacocoXMLPath, nil
@@ -124,6 +138,19 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 	return "", fmt.Errorf("undefined output format: %s", cov.OutputFormat)
 }
 
+func copyJacocoXMLReport(src, dest string) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.WriteFile(dest, content, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	log.Successf("Created jacoco.xml coverage report: %s", dest)
+	return nil
+}
+
 func (cov *CoverageGenerator) BuildFuzzTestForContainerCoverage(jacocoExecFilePath string) error {
 	log.Info("Creating coverage report")
 
@@ -231,14 +258,71 @@ func (cov *CoverageGenerator) jacocoExecFilePath() string {
 	return filepath.Join(cov.OutputPath, fmt.Sprintf("jacoco_%s_%s.exec", cov.FuzzTest, cov.TargetMethod))
 }
 
-func (cov *CoverageGenerator) runJacocoCommand(cliJar, jacocoExecPath, htmlPath, classFilesDir string) (string, error) {
+// classFilesDirs returns the directories containing the class files
+// which are analyzed for the report. In addition to the class files of
+// the project itself, these are the class directories of all other
+// modules of the project on the class path of the fuzz test, so that
+// the coverage of other modules of multi-module projects isn't dropped.
+func (cov *CoverageGenerator) classFilesDirs(projectClassFilesDir string) ([]string, error) {
+	var dirs []string
+	exists, err := fileutil.Exists(projectClassFilesDir)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		dirs = append(dirs, projectClassFilesDir)
+	}
+
+	for _, dep := range cov.Deps {
+		if !filepath.IsAbs(dep) || !fileutil.IsDir(dep) {
+			continue
+		}
+		// The test classes of other modules are not of interest
+		if filepath.Base(dep) == "test-classes" {
+			continue
+		}
+		isBelow, err := fileutil.IsBelow(dep, cov.ProjectDir)
+		if err != nil {
+			return nil, err
+		}
+		if !isBelow {
+			continue
+		}
+		// JaCoCo fails if the same class is found twice, so we skip
+		// directories which are already included
+		included := false
+		for _, dir := range dirs {
+			included, err = fileutil.IsBelow(dep, dir)
+			if err != nil {
+				return nil, err
+			}
+			if included {
+				break
+			}
+		}
+		if !included {
+			dirs = append(dirs, dep)
+		}
+	}
+
+	if len(dirs) == 0 {
+		// Let JaCoCo report the missing class files
+		dirs = append(dirs, projectClassFilesDir)
+	}
+	log.Debugf("Class files directories: %s", strings.Join(dirs, ", "))
+	return dirs, nil
+}
+
+func (cov *CoverageGenerator) runJacocoCommand(cliJar, jacocoExecPath, htmlPath string, classFilesDirs ...string) (string, error) {
 	jacocoXMLPath := filepath.Join(cov.OutputPath, "jacoco.xml")
 
 	args := []string{
 		"-jar", cliJar,
 		"report", jacocoExecPath,
 		"--xml", jacocoXMLPath,
-		"--classfiles", classFilesDir,
+	}
+	for _, dir := range classFilesDirs {
+		args = append(args, "--classfiles", dir)
 	}
 	// Set html output path if needed
 	if cov.OutputFormat == coverage.FormatHTML {
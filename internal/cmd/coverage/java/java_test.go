@@ -0,0 +1,76 @@
+package java
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassFilesDirs_MultiModule(t *testing.T) {
+	projectDir := t.TempDir()
+	otherDir := t.TempDir()
+	for _, dir := range []string{
+		filepath.Join(projectDir, "target", "classes"),
+		filepath.Join(projectDir, "target", "test-classes"),
+		filepath.Join(projectDir, "module-a", "target", "classes"),
+		filepath.Join(projectDir, "module-a", "target", "test-classes"),
+		filepath.Join(projectDir, "module-b", "target", "classes"),
+		filepath.Join(otherDir, "classes"),
+	} {
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+	}
+	jar := filepath.Join(projectDir, "module-c", "target", "module-c.jar")
+	require.NoError(t, os.MkdirAll(filepath.Dir(jar), 0o755))
+	require.NoError(t, os.WriteFile(jar, nil, 0o644))
+
+	cov := &CoverageGenerator{
+		ProjectDir: projectDir,
+		Deps: []string{
+			filepath.Join(projectDir, "target", "test-classes"),
+			filepath.Join(projectDir, "target", "classes"),
+			filepath.Join(projectDir, "module-a", "target", "classes"),
+			filepath.Join(projectDir, "module-a", "target", "test-classes"),
+			filepath.Join(projectDir, "module-b", "target", "classes"),
+			filepath.Join(otherDir, "classes"),
+			jar,
+		},
+	}
+	dirs, err := cov.classFilesDirs(filepath.Join(projectDir, "target", "classes"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(projectDir, "target", "classes"),
+		filepath.Join(projectDir, "module-a", "target", "classes"),
+		filepath.Join(projectDir, "module-b", "target", "classes"),
+	}, dirs)
+}
+
+func TestClassFilesDirs_Gradle(t *testing.T) {
+	projectDir := t.TempDir()
+	for _, dir := range []string{
+		filepath.Join(projectDir, "build", "classes", "java", "main"),
+		filepath.Join(projectDir, "build", "classes", "java", "test"),
+		filepath.Join(projectDir, "lib", "build", "classes", "java", "main"),
+	} {
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+	}
+
+	cov := &CoverageGenerator{
+		ProjectDir: projectDir,
+		Deps: []string{
+			filepath.Join(projectDir, "build", "classes", "java", "test"),
+			filepath.Join(projectDir, "build", "classes", "java", "main"),
+			filepath.Join(projectDir, "lib", "build", "classes", "java", "main"),
+		},
+	}
+	dirs, err := cov.classFilesDirs(filepath.Join(projectDir, "build", "classes"))
+	require.NoError(t, err)
+	// The class directories of the project itself are already included
+	// via the build/classes directory
+	assert.Equal(t, []string{
+		filepath.Join(projectDir, "build", "classes"),
+		filepath.Join(projectDir, "lib", "build", "classes", "java", "main"),
+	}, dirs)
+}
@@ -0,0 +1,254 @@
+package llvm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// The directory in which the coverage of the corpus entries is cached
+// per fuzz test, relative to the project directory
+var profileCacheDir = filepath.Join(".cifuzz-build", "coverage-cache")
+
+const (
+	profileCacheFile     = "cache.json"
+	profileCacheDataFile = "coverage.profdata"
+)
+
+// profileCache describes the indexed profile of the last coverage run
+// of a fuzz test, which is stored next to it
+type profileCache struct {
+	// The digest of the fuzz test executable, its runtime dependencies
+	// and the instrumented shared objects which it might load at
+	// runtime, which change when the instrumented sources change.
+	// The cached profile is only valid for the same digest.
+	BinaryDigest string `json:"binary_digest"`
+	// The digests of the corpus entries which were executed to create
+	// the cached profile
+	Inputs []string `json:"inputs"`
+}
+
+// newInputs returns the corpus entries (keyed by digest) which have to
+// be executed in addition to the ones of the cached profile. If the
+// cached profile can't be used, because the fuzz test changed or
+// corpus entries were removed, ok is false.
+func (c *profileCache) newInputs(binaryDigest string, inputs map[string]string) (newInputs map[string]string, ok bool) {
+	if c == nil || c.BinaryDigest != binaryDigest {
+		return nil, false
+	}
+	cached := make(map[string]bool, len(c.Inputs))
+	for _, digest := range c.Inputs {
+		if _, exists := inputs[digest]; !exists {
+			return nil, false
+		}
+		cached[digest] = true
+	}
+	newInputs = map[string]string{}
+	for digest, path := range inputs {
+		if !cached[digest] {
+			newInputs[digest] = path
+		}
+	}
+	return newInputs, true
+}
+
+// collectCoverage runs the fuzz test on the corpus and creates the
+// indexed profile. If the fuzz test didn't change since the last
+// coverage run, only the corpus entries which were added since then
+// are executed and their coverage is merged with the cached profile.
+func (cov *CoverageGenerator) collectCoverage(ctx context.Context) error {
+	if cov.NoCache {
+		err := cov.run(ctx, cov.CorpusDirs, true)
+		if err != nil {
+			return err
+		}
+		return cov.indexRawProfile(ctx)
+	}
+
+	binaryFiles := append([]string{cov.coverageBinary}, cov.runtimeDeps...)
+	binaryDigest, err := digestFiles(append(binaryFiles, cov.sharedObjects...))
+	if err != nil {
+		return err
+	}
+	inputs, err := corpusInputs(cov.CorpusDirs)
+	if err != nil {
+		return err
+	}
+
+	cacheDir := cov.profileCacheDir()
+	cache, err := loadProfileCache(cacheDir)
+	if err != nil {
+		// The cache is only an optimization, so we run all corpus
+		// entries instead
+		log.Debugf("Failed to load the coverage cache: %v", err)
+	}
+
+	newInputs, ok := cache.newInputs(binaryDigest, inputs)
+	switch {
+	case !ok:
+		err = cov.run(ctx, cov.CorpusDirs, true)
+		if err != nil {
+			return err
+		}
+		err = cov.indexRawProfile(ctx)
+		if err != nil {
+			return err
+		}
+	case len(newInputs) == 0:
+		log.Info("Reusing the coverage of the last run, the fuzz test and its corpus didn't change")
+		err = copyFile(filepath.Join(cacheDir, profileCacheDataFile), cov.indexedProfilePath())
+		if err != nil {
+			return err
+		}
+	default:
+		log.Infof("Reusing the coverage of the last run, running %d new corpus entries", len(newInputs))
+		newInputsDir := filepath.Join(cov.outputDir, "new-inputs")
+		err = os.Mkdir(newInputsDir, 0o755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for digest, path := range newInputs {
+			err = copyFile(path, filepath.Join(newInputsDir, digest))
+			if err != nil {
+				return err
+			}
+		}
+		err = cov.run(ctx, []string{newInputsDir}, false)
+		if err != nil {
+			return err
+		}
+		err = cov.indexRawProfile(ctx, filepath.Join(cacheDir, profileCacheDataFile))
+		if err != nil {
+			return err
+		}
+	}
+
+	cache = &profileCache{BinaryDigest: binaryDigest}
+	for digest := range inputs {
+		cache.Inputs = append(cache.Inputs, digest)
+	}
+	sort.Strings(cache.Inputs)
+	err = cache.save(cacheDir, cov.indexedProfilePath())
+	if err != nil {
+		log.Debugf("Failed to store the coverage cache: %v", err)
+	}
+	return nil
+}
+
+func (cov *CoverageGenerator) profileCacheDir() string {
+	name := strings.NewReplacer("/", "-", ":", "-", "\\", "-").Replace(strings.TrimLeft(cov.FuzzTest, "/"))
+	return filepath.Join(cov.ProjectDir, profileCacheDir, name)
+}
+
+// loadProfileCache returns the cache in the directory, or nil if there
+// is no valid cache
+func loadProfileCache(dir string) (*profileCache, error) {
+	bytes, err := os.ReadFile(filepath.Join(dir, profileCacheFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var cache profileCache
+	err = json.Unmarshal(bytes, &cache)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	_, err = os.Stat(filepath.Join(dir, profileCacheDataFile))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &cache, nil
+}
+
+// save stores the cache and the indexed profile in the directory
+func (c *profileCache) save(dir, indexedProfile string) error {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Remove the old description first, so that an interrupted save
+	// doesn't leave a description of a different profile
+	err = os.Remove(filepath.Join(dir, profileCacheFile))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	err = copyFile(indexedProfile, filepath.Join(dir, profileCacheDataFile))
+	if err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(filepath.Join(dir, profileCacheFile), bytes, 0o644))
+}
+
+// corpusInputs returns the paths of the files in the corpus
+// directories, keyed by the digest of their content. Files with the
+// same content are only included once.
+func corpusInputs(corpusDirs []string) (map[string]string, error) {
+	inputs := map[string]string{}
+	for _, dir := range corpusDirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if d.IsDir() {
+				return nil
+			}
+			digest, err := digestFiles([]string{path})
+			if err != nil {
+				return err
+			}
+			if _, exists := inputs[digest]; !exists {
+				inputs[digest] = path
+			}
+			return nil
+		})
+		// filepath.WalkDir returns an error created by us so it already
+		// has a stack trace and we don't want to add another one here
+		// nolint: wrapcheck
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inputs, nil
+}
+
+// digestFiles returns the SHA-256 digest of the content of the files
+func digestFiles(paths []string) (string, error) {
+	hash := sha256.New()
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		_, err = io.Copy(hash, f)
+		f.Close()
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func copyFile(src, dst string) error {
+	bytes, err := os.ReadFile(src)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(dst, bytes, 0o644))
+}
@@ -0,0 +1,66 @@
+package llvm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileCache_NewInputs(t *testing.T) {
+	cache := &profileCache{BinaryDigest: "binary", Inputs: []string{"a", "b"}}
+
+	newInputs, ok := cache.newInputs("binary", map[string]string{"a": "corpus/a", "b": "corpus/b", "c": "corpus/c"})
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"c": "corpus/c"}, newInputs)
+
+	newInputs, ok = cache.newInputs("binary", map[string]string{"a": "corpus/a", "b": "corpus/b"})
+	require.True(t, ok)
+	assert.Empty(t, newInputs)
+
+	// The cached profile can't be used if the fuzz test changed ...
+	_, ok = cache.newInputs("changed", map[string]string{"a": "corpus/a", "b": "corpus/b"})
+	assert.False(t, ok)
+
+	// ... or if a corpus entry was removed
+	_, ok = cache.newInputs("binary", map[string]string{"a": "corpus/a", "c": "corpus/c"})
+	assert.False(t, ok)
+
+	// Without a cache, all corpus entries have to be executed
+	_, ok = (*profileCache)(nil).newInputs("binary", map[string]string{"a": "corpus/a"})
+	assert.False(t, ok)
+}
+
+func TestProfileCache_SaveAndLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	cache, err := loadProfileCache(dir)
+	require.NoError(t, err)
+	assert.Nil(t, cache)
+
+	profile := filepath.Join(t.TempDir(), "fuzz_test.profdata")
+	require.NoError(t, os.WriteFile(profile, []byte("profile"), 0o644))
+	cache = &profileCache{BinaryDigest: "binary", Inputs: []string{"a"}}
+	require.NoError(t, cache.save(dir, profile))
+
+	loaded, err := loadProfileCache(dir)
+	require.NoError(t, err)
+	assert.Equal(t, cache, loaded)
+	content, err := os.ReadFile(filepath.Join(dir, profileCacheDataFile))
+	require.NoError(t, err)
+	assert.Equal(t, "profile", string(content))
+}
+
+func TestCorpusInputs(t *testing.T) {
+	corpusDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(corpusDir, "a"), []byte("input"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(corpusDir, "b"), []byte("input"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(corpusDir, "dir"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(corpusDir, "dir", "c"), []byte("other input"), 0o644))
+
+	inputs, err := corpusInputs([]string{corpusDir})
+	require.NoError(t, err)
+	// Files with the same content are only included once
+	assert.Len(t, inputs, 2)
+}
@@ -22,10 +22,11 @@ import (
 	"code-intelligence.com/cifuzz/internal/build/other"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/coverage"
 	"code-intelligence.com/cifuzz/pkg/binary"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/minijail"
-	"code-intelligence.com/cifuzz/pkg/parser/coverage"
+	parser "code-intelligence.com/cifuzz/pkg/parser/coverage"
 	"code-intelligence.com/cifuzz/pkg/runfiles"
 	fuzzer_runner "code-intelligence.com/cifuzz/pkg/runner"
 	"code-intelligence.com/cifuzz/util/envutil"
@@ -49,10 +50,19 @@ type CoverageGenerator struct {
 	Stderr          io.Writer
 	BuildStdout     io.Writer
 	BuildStderr     io.Writer
+	// Run all corpus entries instead of reusing the coverage of the
+	// last run
+	NoCache bool
+	// If set, the fuzz test was already built and is not built again
+	BuildResult *build.CBuildResult
 
 	coverageBinary string
 	libraryDirs    []string
 	runtimeDeps    []string
+	buildDir       string
+	// Instrumented shared objects in the build directory which aren't
+	// runtime dependencies of the fuzz test
+	sharedObjects  []string
 	tmpDir         string
 	outputDir      string
 	runfilesFinder runfiles.RunfilesFinder
@@ -75,12 +85,10 @@ func (cov *CoverageGenerator) BuildFuzzTestForCoverage() error {
 		return errors.WithStack(err)
 	}
 
-	err = cov.build()
-	if err != nil {
-		return err
+	if cov.BuildResult != nil {
+		return cov.useBuildResult(cov.BuildResult)
 	}
-
-	return nil
+	return cov.build()
 }
 
 func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
@@ -90,7 +98,13 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 	ctx := context.Background()
 	defer fileutil.Cleanup(cov.tmpDir)
 
-	err := cov.run(ctx)
+	var err error
+	cov.sharedObjects, err = cov.findSharedObjects()
+	if err != nil {
+		return "", err
+	}
+
+	err = cov.collectCoverage(ctx)
 	if err != nil {
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) && cov.UseSandbox {
@@ -99,6 +113,14 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 		return "", err
 	}
 
+	// Include the coverage of the shared objects which were loaded at
+	// runtime, e.g. via dlopen
+	loadedSharedObjects, err := cov.loadedSharedObjects(ctx)
+	if err != nil {
+		return "", err
+	}
+	cov.runtimeDeps = append(cov.runtimeDeps, loadedSharedObjects...)
+
 	reportPath, err := cov.report(ctx)
 	if err != nil {
 		return "", err
@@ -154,7 +176,7 @@ func (cov *CoverageGenerator) GenerateCoverageReportInFuzzContainer(ctx context.
 		}
 	}
 
-	err = cov.run(ctx)
+	err = cov.run(ctx, cov.CorpusDirs, true)
 	if err != nil {
 		return err
 	}
@@ -240,8 +262,13 @@ func (cov *CoverageGenerator) build() error {
 		return errors.New("unknown build system")
 	}
 
+	return cov.useBuildResult(buildResult)
+}
+
+func (cov *CoverageGenerator) useBuildResult(buildResult *build.CBuildResult) error {
 	cov.coverageBinary = buildResult.Executable
 	cov.runtimeDeps = buildResult.RuntimeDeps
+	cov.buildDir = buildResult.BuildDir
 
 	// Use the seed corpus directory and generated corpus directory if
 	// they exist.
@@ -258,11 +285,11 @@ func (cov *CoverageGenerator) build() error {
 	return nil
 }
 
-func (cov *CoverageGenerator) run(ctx context.Context) error {
+// run executes the fuzz test on the inputs in the corpus directories.
+// If runEmptyInput is true, the empty input is executed as well.
+func (cov *CoverageGenerator) run(ctx context.Context, corpusDirs []string, runEmptyInput bool) error {
 	var err error
 
-	corpusDirs := cov.CorpusDirs
-
 	// Ensure that symlinks are resolved to be able to add minijail
 	// bindings for the corpus dirs.
 	for i, dir := range corpusDirs {
@@ -282,6 +309,15 @@ func (cov *CoverageGenerator) run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	// Pass the sanitizer options from cifuzz.yaml to the fuzz test
+	sanitizerOptions, err := config.SanitizerOptionsEnvVars()
+	if err != nil {
+		return err
+	}
+	env, err = envutil.Copy(env, sanitizerOptions)
+	if err != nil {
+		return err
+	}
 	if len(cov.libraryDirs) > 0 {
 		env, err = fuzzer_runner.SetLDLibraryPath(env, cov.libraryDirs)
 		if err != nil {
@@ -289,16 +325,6 @@ func (cov *CoverageGenerator) run(ctx context.Context) error {
 		}
 	}
 
-	dirWithEmptyFile := filepath.Join(cov.outputDir, "empty-file-corpus")
-	err = os.Mkdir(dirWithEmptyFile, 0o755)
-	if err != nil {
-		return errors.WithStack(err)
-	}
-	err = fileutil.Touch(filepath.Join(dirWithEmptyFile, "empty_file"))
-	if err != nil {
-		return err
-	}
-
 	emptyDir := filepath.Join(cov.outputDir, "merge-target")
 	err = os.Mkdir(emptyDir, 0o755)
 	if err != nil {
@@ -320,7 +346,18 @@ func (cov *CoverageGenerator) run(ctx context.Context) error {
 	// always logs any error we encounter.
 	// This line is responsible for empty inputs being skipped:
 	// https://github.com/llvm/llvm-project/blob/c7c0ce7d9ebdc0a49313bc77e14d1e856794f2e0/compiler-rt/lib/fuzzer/FuzzerIO.cpp#L127
-	_ = cov.runFuzzer(ctx, append(args, "-runs=0"), []string{dirWithEmptyFile}, env)
+	if runEmptyInput {
+		dirWithEmptyFile := filepath.Join(cov.outputDir, "empty-file-corpus")
+		err = os.Mkdir(dirWithEmptyFile, 0o755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = fileutil.Touch(filepath.Join(dirWithEmptyFile, "empty_file"))
+		if err != nil {
+			return err
+		}
+		_ = cov.runFuzzer(ctx, append(args, "-runs=0"), []string{dirWithEmptyFile}, env)
+	}
 
 	// We use libFuzzer's crash-resistant merge mode to merge all corpus directories into an empty directory, which
 	// makes libFuzzer go over all inputs in a subprocess that is restarted in case it crashes. With LLVM's continuous
@@ -392,20 +429,16 @@ func (cov *CoverageGenerator) runFuzzer(ctx context.Context, preCorpusArgs []str
 }
 
 func (cov *CoverageGenerator) report(ctx context.Context) (string, error) {
-	err := cov.indexRawProfile(ctx)
-	if err != nil {
-		return "", err
-	}
-
 	lcovReportSummary, err := cov.lcovReportSummary(ctx)
 	if err != nil {
 		return "", err
 	}
 	reportReader := strings.NewReader(lcovReportSummary)
-	summary, err := coverage.ParseLCOVReportIntoSummary(reportReader)
+	summary, err := parser.ParseLCOVReportIntoSummary(reportReader)
 	if err != nil {
 		return "", err
 	}
+	summary.NormalizePaths(cov.ProjectDir)
 	summary.PrintTable(cov.Stderr)
 
 	reportPath := ""
@@ -426,7 +459,9 @@ func (cov *CoverageGenerator) report(ctx context.Context) (string, error) {
 	return reportPath, nil
 }
 
-func (cov *CoverageGenerator) indexRawProfile(ctx context.Context) error {
+// indexRawProfile merges the raw profiles of the fuzz test and the
+// given indexed profiles into the indexed profile
+func (cov *CoverageGenerator) indexRawProfile(ctx context.Context, indexedProfiles ...string) error {
 	rawProfileFiles, err := cov.rawProfileFiles()
 	if err != nil {
 		return err
@@ -443,6 +478,7 @@ func (cov *CoverageGenerator) indexRawProfile(ctx context.Context) error {
 	}
 
 	args := append([]string{"merge", "-sparse", "-o", cov.indexedProfilePath()}, rawProfileFiles...)
+	args = append(args, indexedProfiles...)
 	cmd := exec.CommandContext(ctx, llvmProfData, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -504,26 +540,10 @@ func (cov *CoverageGenerator) generateHTMLReport(ctx context.Context) (string, e
 	}
 
 	// Create an HTML report via genhtml
-	genHTML, err := runfiles.Finder.GenHTMLPath()
+	cmd, err := coverage.GenHTMLCommand(lcovReport, cov.OutputPath)
 	if err != nil {
 		return "", err
 	}
-	args = []string{"--output", cov.OutputPath, lcovReport}
-
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		// genHTML is a perl script, which has to be started like
-		// "perl /path/to/genhtml args..." on Windows
-		args = append([]string{genHTML}, args...)
-		perl, err := runfiles.Finder.PerlPath()
-		if err != nil {
-			return "", err
-		}
-		cmd = exec.Command(perl, args...)
-	} else {
-		cmd = exec.Command(genHTML, args...)
-	}
-
 	cmd.Dir = cov.ProjectDir
 	cmd.Stderr = os.Stderr
 	log.Debugf("Command: %s", cmd.String())
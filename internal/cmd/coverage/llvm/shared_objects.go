@@ -0,0 +1,164 @@
+package llvm
+
+import (
+	"bufio"
+	"context"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/binary"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+var (
+	sharedObjectRegex = regexp.MustCompile(`\.(so(\.\d+)*|dylib)$`)
+	binaryIDRegex     = regexp.MustCompile(`^\s*([0-9a-fA-F]+)\s*$`)
+)
+
+// findSharedObjects returns the shared objects in the build directory
+// which are instrumented for coverage but aren't linked to the fuzz
+// test, e.g. plugins which the fuzz test loads via dlopen. Their
+// coverage is only included in the report if llvm-cov is passed the
+// objects, which ldd and the CMake integration don't know about.
+func (cov *CoverageGenerator) findSharedObjects() ([]string, error) {
+	if cov.buildDir == "" {
+		return nil, nil
+	}
+
+	known := map[string]bool{}
+	for _, path := range append([]string{cov.coverageBinary}, cov.runtimeDeps...) {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			resolved = path
+		}
+		known[resolved] = true
+	}
+
+	var sharedObjects []string
+	err := filepath.WalkDir(cov.buildDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return fs.SkipDir
+		}
+		// Symlinks to shared objects, e.g. libfoo.so -> libfoo.so.1,
+		// are skipped to include each shared object only once
+		if !d.Type().IsRegular() || !sharedObjectRegex.MatchString(d.Name()) {
+			return nil
+		}
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !known[resolved] && binary.HasCoverageMapping(path) {
+			sharedObjects = append(sharedObjects, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return sharedObjects, nil
+}
+
+// loadedSharedObjects returns the shared objects found by
+// findSharedObjects which were loaded while the fuzz test was run. They
+// are identified via the binary IDs which LLVM records in the profiles.
+// If the binary IDs are not available, e.g. because the shared objects
+// were linked without a build ID, all shared objects are returned.
+func (cov *CoverageGenerator) loadedSharedObjects(ctx context.Context) ([]string, error) {
+	if len(cov.sharedObjects) == 0 {
+		return nil, nil
+	}
+
+	binaryIDs, err := cov.profileBinaryIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(binaryIDs) == 0 {
+		log.Debugf("No binary IDs found in the profiles, including all instrumented shared objects: %s",
+			strings.Join(cov.sharedObjects, ", "))
+		return cov.sharedObjects, nil
+	}
+
+	var loaded []string
+	for _, path := range cov.sharedObjects {
+		id, err := binary.BuildID(path)
+		if err != nil {
+			log.Debugf("Failed to read the build ID of %s: %v", path, err)
+			// Without a build ID we can't tell whether it was loaded
+			loaded = append(loaded, path)
+			continue
+		}
+		if id == "" || sliceutil.Contains(binaryIDs, id) {
+			loaded = append(loaded, path)
+		}
+	}
+	if len(loaded) > 0 {
+		log.Debugf("Including the coverage of shared objects loaded at runtime: %s", strings.Join(loaded, ", "))
+	}
+	return loaded, nil
+}
+
+// profileBinaryIDs returns the binary IDs which are recorded in the raw
+// profiles and the indexed profile
+func (cov *CoverageGenerator) profileBinaryIDs(ctx context.Context) ([]string, error) {
+	llvmProfData, err := cov.runfilesFinder.LLVMProfDataPath()
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := cov.rawProfileFiles()
+	if err != nil {
+		return nil, err
+	}
+	profiles = append(profiles, cov.indexedProfilePath())
+
+	var binaryIDs []string
+	for _, profile := range profiles {
+		cmd := exec.CommandContext(ctx, llvmProfData, "show", "--binary-ids", profile)
+		log.Debugf("Command: %s", strings.Join(stringutil.QuotedStrings(cmd.Args), " "))
+		output, err := cmd.Output()
+		if err != nil {
+			// Older versions of llvm-profdata don't support
+			// --binary-ids and indexed profiles of older versions
+			// don't contain binary IDs
+			log.Debugf("Failed to read the binary IDs of %s: %v", profile, err)
+			continue
+		}
+		binaryIDs = append(binaryIDs, parseBinaryIDs(string(output))...)
+	}
+	return sliceutil.RemoveDuplicates(binaryIDs), nil
+}
+
+// parseBinaryIDs parses the output of 'llvm-profdata show --binary-ids',
+// which lists the binary IDs after a "Binary IDs:" line
+func parseBinaryIDs(output string) []string {
+	var binaryIDs []string
+	inBinaryIDs := false
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Binary IDs:") {
+			inBinaryIDs = true
+			continue
+		}
+		if !inBinaryIDs {
+			continue
+		}
+		match := binaryIDRegex.FindStringSubmatch(line)
+		if match == nil {
+			inBinaryIDs = false
+			continue
+		}
+		binaryIDs = append(binaryIDs, strings.ToLower(match[1]))
+	}
+	return binaryIDs
+}
@@ -0,0 +1,24 @@
+package llvm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBinaryIDs(t *testing.T) {
+	output := `Instrumentation level: Front-end
+Total functions: 12
+Maximum function count: 42
+Maximum internal block count: 7
+Binary IDs:
+  5F0B0662BC2CF8A4E1E3D2C1B0A99887
+  0123456789abcdef0123456789abcdef
+`
+	assert.Equal(t, []string{
+		"5f0b0662bc2cf8a4e1e3d2c1b0a99887",
+		"0123456789abcdef0123456789abcdef",
+	}, parseBinaryIDs(output))
+
+	assert.Empty(t, parseBinaryIDs("Instrumentation level: Front-end\nTotal functions: 12\n"))
+}
@@ -20,12 +20,26 @@ import (
 	"code-intelligence.com/cifuzz/util/stringutil"
 )
 
+// The coverage providers supported by Jest. Istanbul instruments the
+// code via Babel, V8 uses the coverage collection built into Node.js.
+// Both map the coverage of transpiled code, e.g. TypeScript, back to
+// the original sources via source maps.
+const (
+	CoverageProviderIstanbul = "istanbul"
+	CoverageProviderV8       = "v8"
+)
+
+var CoverageProviders = []string{CoverageProviderIstanbul, CoverageProviderV8}
+
 type CoverageGenerator struct {
 	OutputFormat    string
 	OutputPath      string
 	TestPathPattern string
 	TestNamePattern string
 	ProjectDir      string
+	// One of CoverageProviders. If it's empty, the coverage provider
+	// configured in the Jest config is used.
+	CoverageProvider string
 
 	Stderr      io.Writer
 	BuildStdout io.Writer
@@ -59,6 +73,13 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 	args = append(args, options.JazzerJSCoverageDirectoryFlag(cov.OutputPath))
 	// the lcov coverage reporter generates both the lcov.info and an html report
 	args = append(args, options.JazzerJSCoverageReportersFlag(coverage.FormatLCOV))
+	switch cov.CoverageProvider {
+	case CoverageProviderIstanbul:
+		// Jest calls the Istanbul provider "babel"
+		args = append(args, options.JestCoverageProviderFlag("babel"))
+	case CoverageProviderV8:
+		args = append(args, options.JestCoverageProviderFlag("v8"))
+	}
 
 	err = cov.runNPXCommand(args, cov.BuildStdout, cov.BuildStderr)
 	if err != nil {
@@ -76,6 +97,7 @@ func (cov *CoverageGenerator) GenerateCoverageReport() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	summary.NormalizePaths(cov.ProjectDir)
 	summary.PrintTable(cov.Stderr)
 
 	// the index.html file is located in the subfolder lcov-report
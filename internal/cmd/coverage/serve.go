@@ -0,0 +1,218 @@
+package coverage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/pkg/browser"
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// The interval in which the project is checked for changes
+var watchInterval = 2 * time.Second
+
+// Directories which contain build output or dependencies and are not
+// watched for changes
+var unwatchedDirs = []string{".git", ".cifuzz-build", ".gradle", "build", "node_modules", "target"}
+
+// The path under which the version of the report is served, which is
+// polled by the served HTML pages to reload when the report changed
+const versionPath = "/.cifuzz/version"
+
+// The script which is added to the served HTML pages
+var reloadScript = []byte(`<script>
+(function() {
+  var version;
+  setInterval(function() {
+    fetch("` + versionPath + `").then(function(r) { return r.text(); }).then(function(v) {
+      if (version !== undefined && v !== version) { location.reload(); }
+      version = v;
+    }).catch(function() {});
+  }, 2000);
+})();
+</script>
+`)
+
+// serveReport creates the HTML report and serves it on the address
+// specified via --serve. The report is regenerated when files in the
+// project change, e.g. when new corpus entries are added, until the
+// command is interrupted.
+func (c *coverageCmd) serveReport() error {
+	if c.opts.OutputPath == "" {
+		// The report must be created in the same directory every time,
+		// so that the server can keep serving it
+		tempDir, err := os.MkdirTemp("", "cifuzz-coverage-")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer fileutil.Cleanup(tempDir)
+		c.opts.OutputPath = filepath.Join(tempDir, "report")
+	}
+
+	err := c.createReport()
+	if err != nil {
+		return err
+	}
+	server := &reportServer{}
+	server.dir.Store(c.htmlReportDir)
+
+	listener, err := net.Listen("tcp", c.opts.Serve)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	httpServer := &http.Server{Handler: server, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		err := httpServer.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error(errors.WithStack(err))
+		}
+	}()
+	defer httpServer.Close()
+
+	url := "http://" + listener.Addr().String()
+	log.Successf("Serving the coverage report on %s", url)
+	log.Info("The report is regenerated when files in the project change. Press Ctrl+C to stop.")
+	browser.Stdout = io.Discard
+	browser.Stderr = io.Discard
+	err = browser.OpenURL(url)
+	if err != nil {
+		log.Debugf("Failed to open the report in the browser: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	excluded := []string{c.opts.OutputPath}
+	fingerprint := projectFingerprint(c.opts.ProjectDir, excluded)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		newFingerprint := projectFingerprint(c.opts.ProjectDir, excluded)
+		if newFingerprint == fingerprint {
+			continue
+		}
+		fingerprint = newFingerprint
+
+		log.Info("Files in the project changed, regenerating the coverage report")
+		err = c.createReport()
+		if err != nil {
+			// Keep serving the previous report, the next change might
+			// fix the error
+			log.Error(err)
+			continue
+		}
+		server.dir.Store(c.htmlReportDir)
+		server.version.Add(1)
+		// Changes made while the report was regenerated, e.g. by the
+		// build, don't trigger another regeneration
+		fingerprint = projectFingerprint(c.opts.ProjectDir, excluded)
+	}
+}
+
+type reportServer struct {
+	// The directory of the report, which is stored atomically because
+	// it's updated while requests are served
+	dir     atomic.Value
+	version atomic.Int64
+}
+
+func (s *reportServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == versionPath {
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = fmt.Fprint(w, s.version.Load())
+		return
+	}
+
+	dir := s.dir.Load().(string)
+	name := path.Clean("/" + r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+	if path.Ext(name) != ".html" {
+		http.FileServer(http.Dir(dir)).ServeHTTP(w, r)
+		return
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	// Add the script which reloads the page when the report changed
+	if i := bytes.LastIndex(content, []byte("</body>")); i != -1 {
+		content = append(content[:i:i], append(reloadScript, content[i:]...)...)
+	} else {
+		content = append(content, reloadScript...)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(content)
+}
+
+// projectFingerprint returns a string which changes when files in the
+// project directory are added, removed or modified
+func projectFingerprint(projectDir string, excluded []string) string {
+	var count int
+	var size int64
+	var latest time.Time
+	_ = filepath.WalkDir(projectDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files can be removed while walking the directory
+			return nil
+		}
+		if d.IsDir() {
+			if p != projectDir && isUnwatched(d.Name(), p, excluded) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		count++
+		size += info.Size()
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return strconv.Itoa(count) + ":" + strconv.FormatInt(size, 10) + ":" + strconv.FormatInt(latest.UnixNano(), 10)
+}
+
+func isUnwatched(name, p string, excluded []string) bool {
+	for _, dir := range unwatchedDirs {
+		if name == dir {
+			return true
+		}
+	}
+	for _, dir := range excluded {
+		if filepath.Clean(dir) == filepath.Clean(p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package coverage
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportServer(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html><body>report</body></html>"), 0o644)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, "style.css"), []byte("body {}"), 0o644)
+	require.NoError(t, err)
+
+	server := &reportServer{}
+	server.dir.Store(dir)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	get := func(path string) string {
+		resp, err := http.Get(ts.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	// The reload script is added to HTML pages
+	index := get("/")
+	assert.Contains(t, index, "report")
+	assert.Contains(t, index, versionPath)
+	assert.Regexp(t, `(?s)report<script>.*</script>\s*</body></html>$`, index)
+
+	// Other files are served unchanged
+	assert.Equal(t, "body {}", get("/style.css"))
+
+	assert.Equal(t, "0", get(versionPath))
+	server.version.Add(1)
+	assert.Equal(t, "1", get(versionPath))
+}
+
+func TestProjectFingerprint(t *testing.T) {
+	projectDir := t.TempDir()
+	outputDir := filepath.Join(projectDir, "report")
+	for _, dir := range []string{outputDir, filepath.Join(projectDir, ".cifuzz-build"), filepath.Join(projectDir, "corpus")} {
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+	}
+	excluded := []string{outputDir}
+	fingerprint := projectFingerprint(projectDir, excluded)
+
+	// Changes in the output directory and build directories are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "index.html"), []byte("report"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".cifuzz-build", "fuzz_test"), []byte("binary"), 0o644))
+	assert.Equal(t, fingerprint, projectFingerprint(projectDir, excluded))
+
+	// New corpus entries change the fingerprint
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "corpus", "input"), []byte("input"), 0o644))
+	assert.NotEqual(t, fingerprint, projectFingerprint(projectDir, excluded))
+}
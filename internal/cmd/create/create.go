@@ -3,8 +3,10 @@ package create
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -17,12 +19,24 @@ import (
 	"code-intelligence.com/cifuzz/pkg/dependencies"
 	"code-intelligence.com/cifuzz/pkg/dialog"
 	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/messaging"
 	"code-intelligence.com/cifuzz/pkg/stubs"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
 )
 
 type createOpts struct {
-	BuildSystem string `mapstructure:"build-system"`
-	Interactive bool   `mapstructure:"interactive"`
+	BuildSystem  string `mapstructure:"build-system"`
+	Interactive  bool   `mapstructure:"interactive"`
+	ProjectDir   string `mapstructure:"project-dir"`
+	FuzzTestType string `mapstructure:"fuzz-test-type"`
+	FuzzTestDir  string `mapstructure:"fuzz-test-dir"`
+	Format       string `mapstructure:"-"`
+	Template     string `mapstructure:"-"`
 
 	outputPath string
 	testType   config.FuzzTestType
@@ -34,10 +48,30 @@ func (opts *createOpts) Validate() error {
 		return err
 	}
 
+	if opts.Format != formatText && opts.Format != formatJSON {
+		msg := fmt.Sprintf("Invalid format %q, valid formats are: %s, %s", opts.Format, formatText, formatJSON)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	if opts.Template != "" && !slices.Contains(stubs.TemplateNames(), opts.Template) {
+		msg := fmt.Sprintf("Invalid template %q, valid templates are: %s",
+			opts.Template, strings.Join(stubs.TemplateNames(), ", "))
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
 	if opts.Interactive {
 		opts.Interactive = term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
 	}
 
+	// Use the default test type from the config if none was specified
+	if opts.testType == "" && opts.FuzzTestType != "" {
+		if !slices.Contains(maps.Values(config.SupportedTestTypes), opts.FuzzTestType) {
+			return errors.Errorf("Invalid fuzz-test-type %q in %s, valid values: %s",
+				opts.FuzzTestType, config.ProjectConfigFile, strings.Join(maps.Values(config.SupportedTestTypes), ", "))
+		}
+		opts.testType = config.FuzzTestType(opts.FuzzTestType)
+	}
+
 	if !opts.Interactive && opts.testType == "" {
 		err := errors.New(fmt.Sprintf("Missing argument [%s]", strings.Join(maps.Values(config.SupportedTestTypes), "|")))
 		return cmdutils.WrapIncorrectUsageError(err)
@@ -65,7 +99,19 @@ func newWithOptions(opts *createOpts) *cobra.Command {
 		Long: `This command creates a new templated fuzz test source file in the current directory.
 After running this command, you should edit the created file in order to
 make it call the functions you want to fuzz. You can then execute the
-fuzz test via 'cifuzz run'.`,
+fuzz test via 'cifuzz run'.
+
+The default type of the fuzz test and the directory in which it's
+created can be configured via the fuzz-test-type and fuzz-test-dir
+settings in cifuzz.yaml.
+
+Instead of the minimal stub, a more complete harness can be created
+from a template via --template:
+` + templateHelp() + `
+
+With --format=json, the path of the fuzz test and the instructions to
+build it are printed to stdout as JSON, with the file to change, the
+insertion point and the snippet of each step.`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			// Bind viper keys to flags. We can't do this in the New
 			// function, because that would re-bind viper keys which
@@ -98,10 +144,29 @@ fuzz test via 'cifuzz run'.`,
 		cmdutils.AddInteractiveFlag,
 	)
 	cmd.Flags().StringVarP(&opts.outputPath, "output", "o", "", "File path of new fuzz test")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", formatText,
+		fmt.Sprintf("Output format of the instructions to build the fuzz test (%s/%s).", formatText, formatJSON))
+	cmd.Flags().StringVar(&opts.Template, "template", "",
+		fmt.Sprintf("Template of the fuzz test (%s).", strings.Join(stubs.TemplateNames(), "/")))
+	err := cmd.RegisterFlagCompletionFunc("template", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return stubs.TemplateNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+	if err != nil {
+		panic(err)
+	}
 
 	return cmd
 }
 
+// templateHelp returns the list of templates for the help message
+func templateHelp() string {
+	var lines []string
+	for _, t := range stubs.Templates {
+		lines = append(lines, fmt.Sprintf("  %-15s %s", t.Name, t.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (c *createCmd) run() error {
 	var err error
 	// get test type
@@ -113,7 +178,21 @@ func (c *createCmd) run() error {
 	}
 	log.Debugf("Selected fuzz test type: %s", c.opts.testType)
 
-	if c.opts.outputPath == "" {
+	if c.opts.outputPath == "" && c.opts.FuzzTestDir != "" {
+		// The directory in which the fuzz tests of the project live
+		dir := c.opts.FuzzTestDir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(c.opts.ProjectDir, dir)
+		}
+		err = os.MkdirAll(dir, 0o755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		c.opts.outputPath, err = stubs.FuzzTestFilenameInDir(dir, c.opts.testType)
+		if err != nil {
+			return err
+		}
+	} else if c.opts.outputPath == "" {
 		c.opts.outputPath, err = stubs.FuzzTestFilename(c.opts.testType)
 		if err != nil {
 			return err
@@ -124,13 +203,16 @@ func (c *createCmd) run() error {
 	c.checkDependencies()
 
 	// create stub
-	err = stubs.Create(c.opts.outputPath, c.opts.testType)
+	err = stubs.CreateFromTemplate(c.opts.outputPath, c.opts.testType, c.opts.Template)
 	if err != nil {
 		return errors.WithMessagef(err, "Failed to create fuzz test stub %s", c.opts.outputPath)
 	}
 
 	// show success message
 	log.Successf("Created fuzz test stub %s", c.opts.outputPath)
+	if c.opts.Format == formatJSON {
+		return c.printJSON()
+	}
 	log.Print(`
 Note: Fuzz tests can be put anywhere in your repository, but it makes sense
 to keep them close to the tested code - just like regular unit tests.`)
@@ -149,8 +231,39 @@ func (c *createCmd) getTestType() (config.FuzzTestType, error) {
 	return config.FuzzTestType(userSelectedType), nil
 }
 
+const bazelSnippet = `load("@rules_fuzzing//fuzzing:cc_defs.bzl", "cc_fuzz_test")
+
+cc_fuzz_test(
+    name = "%[1]s",
+    srcs = ["%[2]s"],
+    corpus = glob(
+        ["%[1]s_inputs/**"],
+        allow_empty = True,
+    ) + select({
+        "@cifuzz//:collect_coverage": glob([".%[1]s_cifuzz_corpus/**"], allow_empty = True),
+        "//conditions:default": [],
+    }),
+    deps = ["@cifuzz"],
+)
+`
+
+const cmakeSnippet = "add_fuzz_test(%s %s)\n"
+
+const otherBuildSystemText = `It seems like you're not using a build system which cifuzz has special
+integration support for, so you'll have to configure your build system
+yourself in order to build the fuzz test and specify the command which
+produces the fuzz test executable via the '--build-command' flag or the
+'build-command' option in the cifuzz.yaml. See 'cifuzz run --help' for
+more information about the build command.
+
+The FUZZ_TEST_CFLAGS and FUZZ_TEST_LDFLAGS environment variables are
+set by cifuzz when building the fuzz test, please make sure that
+$FUZZ_TEST_CFLAGS is passed as a command-line argument to the compiler
+and $FUZZ_TEST_LDFLAGS to the linker.`
+
 func (c *createCmd) printBuildSystemInstructions() {
 	filename := filepath.Base(c.opts.outputPath)
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
 	// Printing build system instructions is best-effort: Do not fail on errors.
 	switch c.opts.BuildSystem {
 	case config.BuildSystemBazel:
@@ -158,45 +271,90 @@ func (c *createCmd) printBuildSystemInstructions() {
 Define a bazel target for the fuzz test by adding the following to the
 BUILD.bazel file:
 
-    load("@rules_fuzzing//fuzzing:cc_defs.bzl", "cc_fuzz_test")
-
-    cc_fuzz_test(
-        name = "%[1]s",
-        srcs = ["%[2]s"],
-        corpus = glob(
-            ["%[1]s_inputs/**"],
-            allow_empty = True,
-        ) + select({
-            "@cifuzz//:collect_coverage": glob([".%[1]s_cifuzz_corpus/**"], allow_empty = True),
-            "//conditions:default": [],
-        }),
-        deps = ["@cifuzz"],
-    )
-
-`, strings.TrimSuffix(filename, filepath.Ext(filename)), filename)
+%s
+`, indent(fmt.Sprintf(bazelSnippet, name, filename)))
 	case config.BuildSystemCMake:
 		log.Printf(`
 Create a CMake target for the fuzz test as follows - it behaves just like
 a regular add_executable(...):
 
-    add_fuzz_test(%s %s)
+%s
+`, indent(fmt.Sprintf(cmakeSnippet, name, filename)))
 
-`, strings.TrimSuffix(filename, filepath.Ext(filename)), filename)
+	case config.BuildSystemOther:
+		log.Print("\n" + otherBuildSystemText)
+	}
+}
 
+// buildSystemInstructions returns the instructions to build the fuzz
+// test as structured data, see --format=json
+func (c *createCmd) buildSystemInstructions() []*messaging.Instruction {
+	filename := filepath.Base(c.opts.outputPath)
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	// The build file is in the directory of the fuzz test
+	dir := c.relativePath(filepath.Dir(c.opts.outputPath))
+	switch c.opts.BuildSystem {
+	case config.BuildSystemBazel:
+		return []*messaging.Instruction{{
+			Description: "Define a Bazel target for the fuzz test",
+			File:        path.Join(dir, "BUILD.bazel"),
+			Insert:      messaging.InsertAtEnd,
+			Snippet:     fmt.Sprintf(bazelSnippet, name, filename),
+		}}
+	case config.BuildSystemCMake:
+		return []*messaging.Instruction{{
+			Description: "Create a CMake target for the fuzz test",
+			File:        path.Join(dir, "CMakeLists.txt"),
+			Insert:      messaging.InsertAtEnd,
+			Snippet:     fmt.Sprintf(cmakeSnippet, name, filename),
+		}}
 	case config.BuildSystemOther:
-		log.Printf(`
-It seems like you're not using a build system which cifuzz has special
-integration support for, so you'll have to configure your build system
-yourself in order to build the fuzz test and specify the command which
-produces the fuzz test executable via the '--build-command' flag or the
-'build-command' option in the cifuzz.yaml. See 'cifuzz run --help' for
-more information about the build command.
+		return []*messaging.Instruction{{Description: otherBuildSystemText}}
+	default:
+		return []*messaging.Instruction{}
+	}
+}
 
-The FUZZ_TEST_CFLAGS and FUZZ_TEST_LDFLAGS environment variables are
-set by cifuzz when building the fuzz test, please make sure that
-$FUZZ_TEST_CFLAGS is passed as a command-line argument to the compiler
-and $FUZZ_TEST_LDFLAGS to the linker.`)
+func (c *createCmd) printJSON() error {
+	output := struct {
+		FuzzTest     string                   `json:"fuzz_test"`
+		Instructions []*messaging.Instruction `json:"instructions"`
+	}{
+		FuzzTest:     c.relativePath(c.opts.outputPath),
+		Instructions: c.buildSystemInstructions(),
+	}
+	s, err := stringutil.ToJSONString(output)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.OutOrStdout(), s)
+	return errors.WithStack(err)
+}
+
+// relativePath returns the path relative to the project directory with
+// forward slashes, or the absolute path if it's outside of the project
+// directory
+func (c *createCmd) relativePath(p string) string {
+	absPath, err := filepath.Abs(p)
+	if err != nil {
+		return filepath.ToSlash(p)
+	}
+	rel, err := filepath.Rel(c.opts.ProjectDir, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(absPath)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// indent indents the non-empty lines of the snippet by four spaces
+func indent(snippet string) string {
+	lines := strings.Split(snippet, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "    " + line
+		}
 	}
+	return strings.Join(lines, "\n")
 }
 
 func (c *createCmd) checkDependencies() {
@@ -1,6 +1,7 @@
 package create
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,6 +16,7 @@ import (
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/internal/testutil"
 	"code-intelligence.com/cifuzz/pkg/dependencies"
+	"code-intelligence.com/cifuzz/pkg/messaging"
 )
 
 func TestMain(m *testing.M) {
@@ -87,6 +89,45 @@ func TestOkTypeScript(t *testing.T) {
 	require.FileExists(t, outputFile)
 }
 
+func TestFuzzTestTypeAndDirFromConfig(t *testing.T) {
+	testDir := testutil.BootstrapExampleProjectForTest(t, "create-cmd-test", config.BuildSystemCMake)
+	configPath := filepath.Join(testDir, "cifuzz.yaml")
+	content, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	content = []byte(config.EnsureConfigEntry(string(content), "fuzz-test-type", "cpp"))
+	content = []byte(config.EnsureConfigEntry(string(content), "fuzz-test-dir", "fuzz-tests"))
+	err = os.WriteFile(configPath, content, 0o644)
+	require.NoError(t, err)
+
+	_, _, err = cmdutils.ExecuteCommand(t, New(), os.Stdin)
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(testDir, "fuzz-tests", "my_fuzz_test_1.cpp"))
+}
+
+func TestJSONFormat(t *testing.T) {
+	testDir := testutil.BootstrapExampleProjectForTest(t, "create-cmd-test", config.BuildSystemCMake)
+
+	args := []string{
+		"cpp",
+		"--output", filepath.Join(testDir, "src", "parser", "parser_fuzz_test.cpp"),
+		"--format=json",
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(testDir, "src", "parser"), 0o755))
+	stdOut, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, args...)
+	require.NoError(t, err)
+
+	var output struct {
+		FuzzTest     string                   `json:"fuzz_test"`
+		Instructions []*messaging.Instruction `json:"instructions"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdOut), &output))
+	assert.Equal(t, "src/parser/parser_fuzz_test.cpp", output.FuzzTest)
+	require.Len(t, output.Instructions, 1)
+	assert.Equal(t, "src/parser/CMakeLists.txt", output.Instructions[0].File)
+	assert.Equal(t, messaging.InsertAtEnd, output.Instructions[0].Insert)
+	assert.Equal(t, "add_fuzz_test(parser_fuzz_test parser_fuzz_test.cpp)\n", output.Instructions[0].Snippet)
+}
+
 func TestInvalidType(t *testing.T) {
 	args := []string{
 		"foo",
@@ -95,6 +136,37 @@ func TestInvalidType(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestTemplate(t *testing.T) {
+	testDir := testutil.BootstrapExampleProjectForTest(t, "create-cmd-test", config.BuildSystemMaven)
+
+	outputFile := filepath.Join(testDir, "DeserializerFuzzTest.java")
+	args := []string{
+		"java",
+		"--output", outputFile,
+		"--template", "deserializer",
+	}
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, args...)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "class DeserializerFuzzTest")
+	assert.Contains(t, string(content), "void deserializerFuzzTest(byte[] data)")
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "create-cmd-test", config.BuildSystemCMake)
+
+	args := []string{
+		"cpp",
+		"--template", "foo",
+	}
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, args...)
+	require.Error(t, err)
+	var usageErr *cmdutils.IncorrectUsageError
+	assert.ErrorAs(t, err, &usageErr)
+}
+
 func TestCreateCmd_OutDir(t *testing.T) {
 	t.Skip()
 }
@@ -0,0 +1,137 @@
+package debug
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmd/run/adapter"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/logging"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+)
+
+func New() *cobra.Command {
+	opts := &adapter.RunOptions{}
+	debugOpts := &adapter.DebugOptions{}
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "debug [flags] <finding>",
+		Short: "Debug the crashing input of a finding",
+		Long: `This command builds the fuzz test which found the finding in the same
+configuration as when the finding was found and runs it on the crashing
+input of the finding in a debugger.
+
+C/C++ fuzz tests are launched in gdb (lldb on macOS) with the crashing
+input as argument, select a different debugger via --debugger. The
+sanitizers abort on the first error, so that the debugger stops at the
+crash.
+
+Java fuzz tests are started in a JVM which waits for a debugger to
+attach via JDWP on the port specified via --port before it runs the
+crashing input.
+
+With --ide, a VS Code launch configuration is printed to stdout, which
+can be added to .vscode/launch.json. For C/C++ fuzz tests, the debugger
+is not launched then. For Java fuzz tests, the fuzz test is started
+and waits for VS Code to attach.
+
+Debugging findings is supported for C/C++ and Java projects, C/C++
+projects are only supported on Linux and macOS.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ValidFindings,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			isJava := sliceutil.Contains([]string{config.BuildSystemMaven, config.BuildSystemGradle}, opts.BuildSystem)
+			if runtime.GOOS == "windows" && !isJava {
+				return errors.Errorf(config.NotSupportedErrorMessage("debug", runtime.GOOS))
+			}
+			if debugOpts.Debugger != "" && !sliceutil.Contains(adapter.Debuggers, debugOpts.Debugger) {
+				msg := fmt.Sprintf("Invalid debugger %q, valid debuggers are: %s", debugOpts.Debugger,
+					strings.Join(adapter.Debuggers, ", "))
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
+			f, err := finding.LoadFinding(opts.ProjectDir, args[0], nil)
+			if finding.IsNotExistError(err) {
+				return errors.WithMessagef(err, "Finding %s does not exist", args[0])
+			}
+			if err != nil {
+				return err
+			}
+			err = opts.ApplyFindingConfig(f)
+			if err != nil {
+				return err
+			}
+			debugOpts.Output = cmd.OutOrStdout()
+			opts.Debug = debugOpts
+
+			if debugOpts.IDE {
+				// We only want the launch configuration on stdout, so we
+				// print the build output to stderr.
+				opts.BuildStdout = cmd.ErrOrStderr()
+			} else {
+				opts.BuildStdout = cmd.OutOrStdout()
+			}
+			opts.BuildStderr = cmd.OutOrStderr()
+			opts.Stdout = cmd.OutOrStdout()
+			opts.Stderr = cmd.OutOrStderr()
+
+			if logging.ShouldLogBuildToFile() {
+				opts.BuildStdout, err = logging.BuildOutputToFile(opts.ProjectDir, []string{opts.FuzzTest})
+				if err != nil {
+					return err
+				}
+				opts.BuildStderr = opts.BuildStdout
+			}
+
+			return opts.Validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			a, err := adapter.NewAdapter(opts)
+			if err != nil {
+				return err
+			}
+			defer a.Cleanup()
+
+			err = a.CheckDependencies(opts.ProjectDir)
+			if err != nil {
+				return err
+			}
+
+			_, err = a.Run(opts)
+			return err
+		},
+	}
+
+	// Note: If a flag should be configurable via cifuzz.yaml as well,
+	// bind it to viper in the PreRunE function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddCleanCommandFlag,
+		cmdutils.AddBuildJobsFlag,
+		cmdutils.AddProjectDirFlag,
+	)
+	cmd.Flags().StringVar(&debugOpts.Debugger, "debugger", "",
+		fmt.Sprintf("The debugger which is used for C/C++ fuzz tests (%s).", strings.Join(adapter.Debuggers, ", ")))
+	cmd.Flags().IntVar(&debugOpts.JDWPPort, "port", 5005,
+		"The port on which Java fuzz tests wait for the debugger to attach.")
+	cmd.Flags().BoolVar(&debugOpts.IDE, "ide", false,
+		"Print a VS Code launch configuration instead of launching the debugger.")
+	return cmd
+}
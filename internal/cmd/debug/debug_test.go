@@ -0,0 +1,30 @@
+package debug
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestInvalidDebugger(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "debug-cmd-test", config.BuildSystemCMake)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--debugger=windbg", "my_finding")
+	require.Error(t, err)
+	var usageErr *cmdutils.IncorrectUsageError
+	assert.ErrorAs(t, err, &usageErr)
+}
+
+func TestFindingDoesNotExist(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "debug-cmd-test", config.BuildSystemCMake)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "no_such_finding")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Finding no_such_finding does not exist")
+}
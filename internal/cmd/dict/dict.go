@@ -0,0 +1,26 @@
+package dict
+
+import (
+	"github.com/spf13/cobra"
+
+	dictGenerateCmd "code-intelligence.com/cifuzz/internal/cmd/dict/generate"
+)
+
+func New() *cobra.Command {
+	return newWithOptions()
+}
+
+func newWithOptions() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dict",
+		Short: "Dictionary related commands",
+		RunE: func(c *cobra.Command, args []string) error {
+			_ = c.Help()
+			return nil
+		},
+	}
+
+	cmd.AddCommand(dictGenerateCmd.New())
+
+	return cmd
+}
@@ -0,0 +1,141 @@
+package generate
+
+import (
+	"io"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/dataflow"
+	"code-intelligence.com/cifuzz/pkg/dictionary"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+)
+
+type options struct {
+	ProjectDir string `mapstructure:"project-dir"`
+	ConfigDir  string `mapstructure:"config-dir"`
+	OutputPath string
+	Dataflow   string
+
+	SourceDirs []string
+}
+
+type generateCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "generate [flags] [<directory>...]",
+		Short: "Generate a dictionary from the project sources",
+		Long: `This command scans the source files of the project for string
+literals, magic constants, protobuf field names and JSON keys and
+produces a libFuzzer dictionary from them. Using such a dictionary
+helps the fuzzer to reach deeper code paths early in a fuzzing run.
+
+If no directory is specified, the project directory is scanned.
+
+The dictionary is printed to stdout unless an output file is specified
+via --output. To use it automatically for a CMake or other fuzz test,
+write it to <fuzz test>.dict next to the fuzz test, for example:
+
+    cifuzz dict generate -o my_fuzz_test.dict
+
+The byte sequences of the corpus inputs which influence comparisons in
+the fuzz test can be added to the dictionary by passing the influence
+map created by 'cifuzz analyze --dataflow' via --dataflow.
+
+See https://llvm.org/docs/LibFuzzer.html#dictionaries`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			opts.SourceDirs = args
+			if len(opts.SourceDirs) == 0 {
+				opts.SourceDirs = []string{opts.ProjectDir}
+			}
+			for _, dir := range opts.SourceDirs {
+				if !fileutil.IsDir(dir) {
+					return cmdutils.WrapIncorrectUsageError(errors.Errorf("%s is not a directory", dir))
+				}
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := generateCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via viper as well (i.e.
+	//       via cifuzz.yaml and CIFUZZ_* environment variables), bind
+	//       it to viper in the PreRun function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+	)
+	cmd.Flags().StringVarP(&opts.OutputPath, "output", "o", "",
+		"Write the dictionary to the specified `file` instead of stdout.")
+	cmd.Flags().StringVar(&opts.Dataflow, "dataflow", "",
+		"Add the input bytes which influence comparisons according to the data-flow influence map `file`.")
+
+	return cmd
+}
+
+func (c *generateCmd) run() error {
+	entries, err := dictionary.Generate(c.opts.SourceDirs...)
+	if err != nil {
+		return err
+	}
+
+	if c.opts.Dataflow != "" {
+		m, err := dataflow.Load(c.opts.Dataflow)
+		if err != nil {
+			return err
+		}
+		tokens, err := m.Tokens(c.opts.ProjectDir)
+		if err != nil {
+			return err
+		}
+		entries = sliceutil.RemoveDuplicates(append(entries, tokens...))
+		sort.Strings(entries)
+	}
+
+	var out io.Writer = c.OutOrStdout()
+	if c.opts.OutputPath != "" {
+		f, err := os.Create(c.opts.OutputPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	err = dictionary.Write(out, entries)
+	if err != nil {
+		return err
+	}
+
+	if c.opts.OutputPath != "" {
+		log.Successf("Wrote %d dictionary entries to %s", len(entries), fileutil.PrettifyPath(c.opts.OutputPath))
+	}
+	return nil
+}
@@ -39,6 +39,7 @@ type executeOpts struct {
 	JSONOutputFilePath  string `mapstructure:"json-output-file"`
 	GeneratedCorpusDir  string `mapstructure:"generated-corpus-dir"`
 	CoverageOutputPath  string `mapstructure:"coverage-output-path"`
+	ResultFile          string `mapstructure:"result-file"`
 
 	name string
 }
@@ -46,6 +47,11 @@ type executeOpts struct {
 type executeCmd struct {
 	*cobra.Command
 	opts *executeOpts
+
+	startedAt             time.Time
+	fuzzer                *archive.Fuzzer
+	reportHandler         *reporthandler.ReportHandler
+	coverageReportCreated bool
 }
 
 func New() *cobra.Command {
@@ -73,14 +79,16 @@ It is currently only intended for use with the 'cifuzz container' subcommand.
 			cmdutils.ViperMustBindPFlag("stop-signal-file", cmd.Flags().Lookup("stop-signal-file"))
 			cmdutils.ViperMustBindPFlag("json-output-file", cmd.Flags().Lookup("json-output-file"))
 			cmdutils.ViperMustBindPFlag("generated-corpus-dir", cmd.Flags().Lookup("generated-corpus-dir"))
+			cmdutils.ViperMustBindPFlag("result-file", cmd.Flags().Lookup("result-file"))
 			opts.SingleFuzzTest = viper.GetBool("single-fuzz-test")
 			opts.PrintBundleMetadata = viper.GetBool("print-bundle-metadata")
 			opts.CoverageOutputPath = viper.GetString("coverage-output-path")
 			opts.PrintJSON = viper.GetBool("print-json")
 			opts.JSONOutputFilePath = viper.GetString("json-output-file")
 			opts.GeneratedCorpusDir = viper.GetString("generated-corpus-dir")
+			opts.ResultFile = viper.GetString("result-file")
 		},
-		RunE: func(c *cobra.Command, args []string) error {
+		RunE: func(c *cobra.Command, args []string) (err error) {
 			if signalFile := viper.GetString("stop-signal-file"); signalFile != "" {
 				defer func() {
 					_, err := os.Create(signalFile)
@@ -90,6 +98,22 @@ It is currently only intended for use with the 'cifuzz container' subcommand.
 				}()
 			}
 
+			cmd := &executeCmd{Command: c, opts: opts, startedAt: time.Now()}
+			// The result file is written before the stop signal file
+			// is created, so that it's complete when the stop signal
+			// file exists
+			if opts.ResultFile != "" {
+				defer func() {
+					resultErr := cmd.writeResultFile(err)
+					if resultErr != nil {
+						log.Errorf(resultErr, "Failed to write result file: %v", resultErr)
+						if err == nil {
+							err = cmdutils.WrapSilentError(resultErr)
+						}
+					}
+				}()
+			}
+
 			metadata, err := getMetadata()
 			if err != nil {
 				return err
@@ -109,7 +133,6 @@ It is currently only intended for use with the 'cifuzz container' subcommand.
 				opts.name = args[0]
 			}
 
-			cmd := executeCmd{Command: c, opts: opts}
 			return cmd.run(metadata)
 		},
 	}
@@ -122,6 +145,7 @@ It is currently only intended for use with the 'cifuzz container' subcommand.
 	cmd.Flags().String("stop-signal-file", "", "CI Fuzz will create a file 'cifuzz-execution-finished' upon exit")
 	cmd.Flags().String("json-output-file", "", "Print output as JSON to the specified file (implies --json)")
 	cmd.Flags().String("generated-corpus-dir", "/tmp/generated-corpus", "The directory where inputs which increased the coverage are stored. The user running the container must have write access to this directory.")
+	cmd.Flags().String("result-file", "", "Write the result of the execution as JSON to the specified file, including the findings, the metrics and why the fuzz test exited.")
 
 	// Note: If a flag should be configurable via viper as well (i.e.
 	//       via cifuzz.yaml and CIFUZZ_* environment variables), bind
@@ -177,6 +201,7 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 	if err != nil {
 		return err
 	}
+	c.fuzzer = fuzzer
 
 	err = os.MkdirAll(container.ManagedSeedCorpusDir, 0o755)
 	if err != nil {
@@ -203,6 +228,8 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 	if err != nil {
 		return err
 	}
+	defer reportHandler.WaitForNotifications()
+	c.reportHandler = reportHandler
 
 	runnerOpts := &libfuzzer.RunnerOptions{
 		FuzzTarget:         fuzzer.Path,
@@ -343,6 +370,7 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 		if err != nil {
 			return err
 		}
+		c.coverageReportCreated = true
 
 		return nil
 	default:
@@ -359,8 +387,13 @@ func (c *executeCmd) run(metadata *archive.Metadata) error {
 			CorpusDirs:   seedCorpusDirs,
 			Stderr:       os.Stderr,
 		}
-		return gen.GenerateCoverageReportInFuzzContainer(context.Background(), coverageBinary.Path,
+		err = gen.GenerateCoverageReportInFuzzContainer(context.Background(), coverageBinary.Path,
 			c.opts.CoverageOutputPath, coverageBinary.LibraryPaths)
+		if err != nil {
+			return err
+		}
+		c.coverageReportCreated = true
+		return nil
 	}
 }
 
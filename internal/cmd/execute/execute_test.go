@@ -3,18 +3,24 @@
 package execute
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"reflect"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"code-intelligence.com/cifuzz/internal/bundler/archive"
+	"code-intelligence.com/cifuzz/internal/cmd/run/reporthandler"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/report"
 )
 
 func Test_getFuzzer(t *testing.T) {
@@ -203,3 +209,55 @@ func TestStopSignalFile(t *testing.T) {
 	cmdutils.ExecuteCommand(t, New(), os.Stdin, "my_fuzz_test", "--stop-signal-file=test")
 	assert.FileExists(t, filepath.Join(dir, "test"), "--stop-signal-file flag did not create the file 'cifuzz-execution-finished'on exit")
 }
+
+func TestResultFile(t *testing.T) {
+	dir := testutil.BootstrapExampleProjectForTest(t, "execute-result-file-test", config.BuildSystemCMake)
+
+	// The result file is written even if the fuzz test can't be executed
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "my_fuzz_test", "--result-file=result.json")
+	require.Error(t, err)
+
+	bytes, err := os.ReadFile(filepath.Join(dir, "result.json"))
+	require.NoError(t, err)
+	var result executionResult
+	require.NoError(t, json.Unmarshal(bytes, &result))
+	assert.Equal(t, outcomeError, result.Outcome)
+	assert.Equal(t, 1, result.ExitCode)
+	assert.Contains(t, result.Error, "bundle metadata file")
+	assert.Empty(t, result.Findings)
+}
+
+func TestWriteResultFile_Findings(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "execute-result-file-test-")
+	resultFile := filepath.Join(dir, "result.json")
+
+	c := &executeCmd{
+		opts:          &executeOpts{ResultFile: resultFile},
+		startedAt:     time.Now(),
+		fuzzer:        &archive.Fuzzer{Target: "my_fuzz_test", Engine: "LIBFUZZER"},
+		reportHandler: &reporthandler.ReportHandler{ReportHandlerOptions: &reporthandler.ReportHandlerOptions{}},
+	}
+	c.reportHandler.Findings = []*finding.Finding{{Name: "funky_frog"}}
+	c.reportHandler.LastMetrics = &report.FuzzingMetric{TotalExecutions: 1000}
+	require.NoError(t, c.writeResultFile(nil))
+
+	bytes, err := os.ReadFile(resultFile)
+	require.NoError(t, err)
+	var result executionResult
+	require.NoError(t, json.Unmarshal(bytes, &result))
+	assert.Equal(t, "my_fuzz_test", result.FuzzTest)
+	assert.Equal(t, outcomeFindings, result.Outcome)
+	assert.Equal(t, 0, result.ExitCode)
+	require.Len(t, result.Findings, 1)
+	assert.Equal(t, "funky_frog", result.Findings[0].Name)
+	require.NotNil(t, result.Stats)
+	assert.Equal(t, uint64(1000), result.Stats.LastMetrics.TotalExecutions)
+
+	// Signals are classified as interruptions
+	require.NoError(t, c.writeResultFile(cmdutils.NewSignalError(syscall.SIGTERM)))
+	bytes, err = os.ReadFile(resultFile)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(bytes, &result))
+	assert.Equal(t, outcomeInterrupted, result.Outcome)
+	assert.Equal(t, 128+int(syscall.SIGTERM), result.ExitCode)
+}
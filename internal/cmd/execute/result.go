@@ -0,0 +1,114 @@
+//go:build !windows
+
+package execute
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+// The outcomes of an execution, which classify why the fuzz test exited
+const (
+	// The fuzz test ran until the timeout or the maximum number of runs
+	// without findings
+	outcomeNoFindings = "no_findings"
+	// The fuzz test found at least one finding
+	outcomeFindings = "findings"
+	// The execution was stopped by a signal
+	outcomeInterrupted = "interrupted"
+	// The fuzz test could not be executed or exited unexpectedly
+	outcomeError = "error"
+)
+
+// executionResult is the result of the execution of a fuzz test, which
+// is written to the file specified via --result-file, so that tools
+// which execute bundles don't have to parse the output
+type executionResult struct {
+	FuzzTest  string        `json:"fuzz_test,omitempty"`
+	Engine    string        `json:"engine,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+
+	Outcome string `json:"outcome"`
+	// The exit code of cifuzz execute
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+
+	Stats    *executionStats    `json:"stats,omitempty"`
+	Findings []*finding.Finding `json:"findings"`
+
+	// The path of the coverage report, if one was created
+	CoverageReport string `json:"coverage_report,omitempty"`
+}
+
+type executionStats struct {
+	FirstMetrics *report.FuzzingMetric `json:"first_metrics,omitempty"`
+	LastMetrics  *report.FuzzingMetric `json:"last_metrics,omitempty"`
+
+	// The number of entries of the seed and generated corpus at the
+	// start and end of the execution
+	CorpusEntriesAtStart uint `json:"corpus_entries_at_start"`
+	CorpusEntries        uint `json:"corpus_entries"`
+}
+
+// writeResultFile writes the result of the execution, which failed with
+// the given error if it's not nil, to the result file
+func (c *executeCmd) writeResultFile(execErr error) error {
+	result := &executionResult{
+		StartedAt: c.startedAt,
+		Duration:  time.Since(c.startedAt),
+		Outcome:   outcomeNoFindings,
+		Findings:  []*finding.Finding{},
+	}
+	if c.fuzzer != nil {
+		result.FuzzTest = getFuzzerName(c.fuzzer)
+		result.Engine = c.fuzzer.Engine
+	}
+
+	if c.reportHandler != nil {
+		err := c.reportHandler.CountCorpusEntries()
+		if err != nil {
+			return err
+		}
+		atStart, atEnd := c.reportHandler.CorpusEntries()
+		result.Stats = &executionStats{
+			FirstMetrics:         c.reportHandler.FirstMetrics,
+			LastMetrics:          c.reportHandler.LastMetrics,
+			CorpusEntriesAtStart: atStart,
+			CorpusEntries:        atEnd,
+		}
+		if len(c.reportHandler.Findings) > 0 {
+			result.Findings = c.reportHandler.Findings
+			result.Outcome = outcomeFindings
+		}
+	}
+
+	// The exit codes are the ones of the root command
+	if execErr != nil {
+		result.Error = execErr.Error()
+		result.Outcome = outcomeError
+		result.ExitCode = 1
+		var signalErr *cmdutils.SignalError
+		if errors.As(execErr, &signalErr) {
+			result.Outcome = outcomeInterrupted
+			result.ExitCode = 128 + int(signalErr.Signal)
+		}
+	}
+
+	if c.coverageReportCreated {
+		result.CoverageReport = c.opts.CoverageOutputPath
+	}
+
+	bytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(c.opts.ResultFile, bytes, 0o644))
+}
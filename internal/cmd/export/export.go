@@ -0,0 +1,29 @@
+package export
+
+import (
+	"github.com/spf13/cobra"
+
+	exportOSSFuzzCmd "code-intelligence.com/cifuzz/internal/cmd/export/ossfuzz"
+)
+
+func New() *cobra.Command {
+	return newWithOptions()
+}
+
+func newWithOptions() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the fuzz tests to other fuzzing services",
+		Long: `Create the files which are needed to build and run the fuzz tests of
+the project on other fuzzing services, using the existing cifuzz
+configuration.`,
+		RunE: func(c *cobra.Command, args []string) error {
+			_ = c.Help()
+			return nil
+		},
+	}
+
+	cmd.AddCommand(exportOSSFuzzCmd.New())
+
+	return cmd
+}
@@ -0,0 +1,204 @@
+package ossfuzz
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/ossfuzz"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/vcs"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+type options struct {
+	BuildSystem string   `mapstructure:"build-system"`
+	ProjectDir  string   `mapstructure:"project-dir"`
+	ConfigDir   string   `mapstructure:"config-dir"`
+	Sanitizers  []string `mapstructure:"sanitizers"`
+
+	Name           string
+	MainRepo       string
+	Homepage       string
+	PrimaryContact string
+	OutputDir      string
+}
+
+func (opts *options) validate() error {
+	err := ossfuzz.ValidateBuildSystem(opts.BuildSystem)
+	if err != nil {
+		return cmdutils.WrapIncorrectUsageError(err)
+	}
+
+	if opts.Name == "" {
+		opts.Name = ossfuzz.ProjectName(opts.ProjectDir)
+	}
+
+	// OSS-Fuzz clones the main repository of the project to build it,
+	// which is the origin remote by default
+	if opts.MainRepo == "" {
+		remoteURL, err := vcs.GitRemoteURL("origin")
+		if err != nil {
+			log.Debugf("Failed to get the URL of the origin remote: %v", err)
+		} else {
+			opts.MainRepo = ossfuzz.HTTPSRepoURL(remoteURL)
+		}
+	}
+	if opts.MainRepo == "" {
+		msg := `Failed to determine the main repository of the project, please specify
+it via --main-repo`
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	if opts.Homepage == "" {
+		opts.Homepage = strings.TrimSuffix(opts.MainRepo, ".git")
+	}
+
+	if opts.OutputDir == "" {
+		opts.OutputDir = filepath.Join("projects", opts.Name)
+	}
+
+	return nil
+}
+
+type ossFuzzCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "oss-fuzz",
+		Short: "Create an OSS-Fuzz project for the fuzz tests",
+		Long: `This command creates the directory of an OSS-Fuzz project, which
+contains the Dockerfile, the build script and the project.yaml which
+OSS-Fuzz needs to build and run the fuzz tests of the project. The fuzz
+tests are built with the cifuzz CMake integration or the cifuzz Bazel
+rules, so that they are built the same way as by 'cifuzz run'. Only
+CMake and Bazel projects are supported.
+
+The directory is created in projects/<name> below the current working
+directory, unless another directory is specified via --output. To add
+the project to OSS-Fuzz, copy the directory to the projects directory
+of the OSS-Fuzz repository and open a pull request, see
+https://google.github.io/oss-fuzz/getting-started/accepting-new-projects/
+
+The main repository, which OSS-Fuzz clones to build the project,
+defaults to the URL of the origin remote of the git repository.
+The sanitizers which the fuzz tests are built with are taken from
+the cifuzz.yaml, as far as OSS-Fuzz supports them.`,
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			return opts.validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := ossFuzzCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via viper as well (i.e.
+	//       via cifuzz.yaml and CIFUZZ_* environment variables), bind
+	//       it to viper in the PreRun function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+	)
+	cmd.Flags().StringVar(&opts.Name, "name", "",
+		"The name of the OSS-Fuzz project.\n"+
+			"Defaults to the name of the project directory.")
+	cmd.Flags().StringVar(&opts.MainRepo, "main-repo", "",
+		"The URL of the repository which OSS-Fuzz clones to build the project.\n"+
+			"Defaults to the URL of the origin remote.")
+	cmd.Flags().StringVar(&opts.Homepage, "homepage", "",
+		"The homepage of the project.\n"+
+			"Defaults to the URL of the main repository.")
+	cmd.Flags().StringVar(&opts.PrimaryContact, "primary-contact", "",
+		"The e-mail address which OSS-Fuzz sends bug reports to.")
+	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "",
+		"The directory in which the files are created.\n"+
+			"Defaults to projects/<name>.")
+
+	return cmd
+}
+
+func (c *ossFuzzCmd) run() error {
+	files, err := ossfuzz.Files(&ossfuzz.Options{
+		BuildSystem:    c.opts.BuildSystem,
+		Name:           c.opts.Name,
+		Homepage:       c.opts.Homepage,
+		PrimaryContact: c.opts.PrimaryContact,
+		MainRepo:       c.opts.MainRepo,
+		Sanitizers:     c.opts.Sanitizers,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Check all files first, so that we don't create some of the files
+	// and then fail
+	for _, file := range files {
+		path := filepath.Join(c.opts.OutputDir, filepath.FromSlash(file.Path))
+		exists, err := fileutil.Exists(path)
+		if err != nil {
+			return err
+		}
+		if exists {
+			err = errors.Errorf("%s already exists", path)
+			log.Error(err)
+			return cmdutils.WrapSilentError(err)
+		}
+	}
+
+	err = os.MkdirAll(c.opts.OutputDir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, file := range files {
+		path := filepath.Join(c.opts.OutputDir, filepath.FromSlash(file.Path))
+		mode := os.FileMode(0o644)
+		if file.Executable {
+			mode = 0o755
+		}
+		err = os.WriteFile(path, file.Content, mode)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		log.Successf("Created %s", path)
+	}
+
+	if c.opts.PrimaryContact == "" {
+		log.Warn("No primary contact was specified via --primary-contact, please add it to the project.yaml")
+	}
+
+	log.Printf(`
+Copy the directory to the projects directory of the OSS-Fuzz repository
+and test the build with:
+
+    python infra/helper.py build_image %[1]s
+    python infra/helper.py build_fuzzers %[1]s
+    python infra/helper.py check_build %[1]s
+
+Then open a pull request to add the project to OSS-Fuzz.`, c.opts.Name)
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package ossfuzz
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestExportOSSFuzz(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "export-oss-fuzz-cmd-test", config.BuildSystemCMake)
+
+	args := []string{"--name", "my-project", "--main-repo", "https://github.com/example/my-project.git"}
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, args...)
+	require.NoError(t, err)
+
+	for _, file := range []string{"Dockerfile", "build.sh", "project.yaml"} {
+		assert.FileExists(t, filepath.Join("projects", "my-project", file))
+	}
+	projectYAML, err := os.ReadFile(filepath.Join("projects", "my-project", "project.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(projectYAML), `main_repo: "https://github.com/example/my-project.git"`)
+	assert.Contains(t, string(projectYAML), `homepage: "https://github.com/example/my-project"`)
+
+	// Existing files are not overwritten
+	_, _, err = cmdutils.ExecuteCommand(t, New(), os.Stdin, args...)
+	require.Error(t, err)
+}
+
+func TestExportOSSFuzz_UnsupportedBuildSystem(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "export-oss-fuzz-cmd-test", config.BuildSystemNodeJS)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--main-repo", "https://github.com/example/my-project.git")
+	require.Error(t, err)
+	var usageErr *cmdutils.IncorrectUsageError
+	assert.ErrorAs(t, err, &usageErr)
+}
@@ -3,9 +3,10 @@ package finding
 import (
 	"fmt"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 	"text/tabwriter"
-	"time"
 
 	"github.com/pkg/errors"
 	"github.com/pterm/pterm"
@@ -14,14 +15,19 @@ import (
 	"golang.org/x/term"
 
 	"code-intelligence.com/cifuzz/internal/api"
+	findingPullCmd "code-intelligence.com/cifuzz/internal/cmd/finding/pull"
+	findingReportCmd "code-intelligence.com/cifuzz/internal/cmd/finding/report"
+	findingSetStateCmd "code-intelligence.com/cifuzz/internal/cmd/finding/setstate"
+	findingToTestCmd "code-intelligence.com/cifuzz/internal/cmd/finding/totest"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/cmdutils/auth"
 	"code-intelligence.com/cifuzz/internal/completion"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/version"
 	"code-intelligence.com/cifuzz/pkg/dialog"
 	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/inputformat"
 	"code-intelligence.com/cifuzz/pkg/log"
-	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
 	"code-intelligence.com/cifuzz/util/stringutil"
 )
 
@@ -32,8 +38,24 @@ type options struct {
 	Interactive bool   `mapstructure:"interactive"`
 	Server      string `mapstructure:"server"`
 	Project     string `mapstructure:"project"`
+
+	FuzzTestConfigs []*config.FuzzTestConfig `mapstructure:"fuzz-tests"`
+
+	Owner  string   `mapstructure:"-"`
+	States []string `mapstructure:"-"`
+	Sort   string   `mapstructure:"-"`
+	Format string   `mapstructure:"-"`
 }
 
+const (
+	sortByDate  = "date"
+	sortByState = "state"
+
+	formatText  = "text"
+	formatJSON  = "json"
+	formatSARIF = "sarif"
+)
+
 type findingCmd struct {
 	*cobra.Command
 	opts *options
@@ -61,6 +83,24 @@ func newWithOptions(opts *options) *cobra.Command {
 			if err != nil {
 				return err
 			}
+			for _, state := range opts.States {
+				_, err = finding.ParseState(state)
+				if err != nil {
+					return cmdutils.WrapIncorrectUsageError(err)
+				}
+			}
+			if opts.Sort != sortByDate && opts.Sort != sortByState {
+				msg := fmt.Sprintf("Invalid sort order %q, valid orders are: %s, %s", opts.Sort, sortByDate, sortByState)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			switch opts.Format {
+			case formatText, formatSARIF:
+			case formatJSON:
+				opts.PrintJSON = true
+			default:
+				msg := fmt.Sprintf("Invalid format %q, valid formats are: %s, %s, %s", opts.Format, formatText, formatJSON, formatSARIF)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
 			return nil
 		},
 		RunE: func(c *cobra.Command, args []string) error {
@@ -92,6 +132,20 @@ func newWithOptions(opts *options) *cobra.Command {
 		cmdutils.AddServerFlag,
 		cmdutils.AddProjectFlag,
 	)
+	cmd.Flags().StringVar(&opts.Owner, "owner", "",
+		"Only list findings of fuzz tests with this owner, as configured in cifuzz.yaml.")
+	cmd.Flags().StringSliceVar(&opts.States, "state", nil,
+		"Only list local findings in one of these states (new, triaged, fixed, wontfix).")
+	cmd.Flags().StringVar(&opts.Sort, "sort", sortByDate,
+		fmt.Sprintf("Sort the findings by %s (newest first) or by %s.", sortByDate, sortByState))
+	cmd.Flags().StringVar(&opts.Format, "format", formatText,
+		fmt.Sprintf("The output format (%s, %s or %s). SARIF can be uploaded to GitHub code scanning.",
+			formatText, formatJSON, formatSARIF))
+
+	cmd.AddCommand(findingPullCmd.New())
+	cmd.AddCommand(findingReportCmd.New())
+	cmd.AddCommand(findingSetStateCmd.New())
+	cmd.AddCommand(findingToTestCmd.New())
 
 	return cmd
 }
@@ -151,40 +205,30 @@ Skipping remote findings because running in non-interactive mode.`)
 	var remoteFindings []*finding.Finding
 	for i := range remoteAPIFindings.Findings {
 		// we access the element via index to avoid copying the struct
-		rf := remoteAPIFindings.Findings[i]
-
-		timeStamp, err := time.Parse(time.RFC3339, rf.Timestamp)
+		rf, err := remoteAPIFindings.Findings[i].ToLocalFinding(cmd.opts.Project)
 		if err != nil {
-			return errors.Wrapf(err, "Could not parse timestamp %s", rf.Timestamp)
+			return err
 		}
-		displayName := api.ConvertProjectNameForUseWithAPIV1V2(cmd.opts.Project)
-		remoteFindings = append(remoteFindings, &finding.Finding{
-			Origin:             "CI Sense",
-			Name:               strings.TrimPrefix(rf.Name, fmt.Sprintf("%s/findings/", displayName)),
-			Type:               finding.ErrorType(rf.ErrorReport.Type),
-			InputData:          rf.ErrorReport.InputData,
-			Logs:               rf.ErrorReport.Logs,
-			Details:            rf.ErrorReport.Details,
-			HumanReadableInput: string(rf.ErrorReport.InputData),
-			MoreDetails:        rf.ErrorReport.MoreDetails,
-			Tag:                rf.ErrorReport.Tag,
-			CreatedAt:          timeStamp,
-			FuzzTest:           rf.FuzzTargetDisplayName,
-			StackTrace: []*stacktrace.StackFrame{
-				{
-					Function:   rf.ErrorReport.DebuggingInfo.BreakPoints[0].Function,
-					SourceFile: rf.ErrorReport.DebuggingInfo.BreakPoints[0].SourceFilePath,
-					Line:       rf.ErrorReport.DebuggingInfo.BreakPoints[0].Location.Line,
-					Column:     rf.ErrorReport.DebuggingInfo.BreakPoints[0].Location.Column,
-				},
-			},
-		})
+		remoteFindings = append(remoteFindings, rf)
 	}
 
 	if len(args) == 0 {
 		// If called without arguments, `cifuzz findings` lists short
 		// descriptions of all findings
-		allFindings := append(localFindings, remoteFindings...)
+		allFindings := localFindings
+		for _, rf := range remoteFindings {
+			// Remote findings which were stored locally via `cifuzz
+			// finding pull` are only listed once, as local findings
+			if !slices.ContainsFunc(localFindings, func(f *finding.Finding) bool { return f.Name == rf.Name }) {
+				allFindings = append(allFindings, rf)
+			}
+		}
+		allFindings = cmd.filterByState(allFindings)
+		allFindings = cmd.groupByOwner(allFindings)
+
+		if cmd.opts.Format == formatSARIF {
+			return finding.WriteSARIF(cmd.OutOrStdout(), allFindings, cmd.opts.ProjectDir, version.Version)
+		}
 
 		if cmd.opts.PrintJSON {
 			s, err := stringutil.ToJSONString(allFindings)
@@ -202,9 +246,20 @@ Skipping remote findings because running in non-interactive mode.`)
 
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 1, ' ', 0)
 
-		data := [][]string{
-			{"Origin", "Severity", "Name", "Description", "Fuzz Test", "Location"},
+		// The owner column is only shown if owners are configured
+		var hasOwners bool
+		for _, f := range allFindings {
+			if f.Owner != "" {
+				hasOwners = true
+				break
+			}
+		}
+
+		header := []string{"Origin", "State", "Severity", "Name", "Description", "Fuzz Test", "Location"}
+		if hasOwners {
+			header = append([]string{"Owner"}, header...)
 		}
+		data := [][]string{header}
 
 		for _, f := range allFindings {
 			score := "n/a"
@@ -217,8 +272,14 @@ Skipping remote findings because running in non-interactive mode.`)
 					score = colorFunc(fmt.Sprintf("%.1f", f.MoreDetails.Severity.Score))
 				}
 			}
-			data = append(data, []string{
+			// The state is only tracked for local findings
+			state := "n/a"
+			if f.Origin == "Local" {
+				state = string(f.GetState())
+			}
+			row := []string{
 				f.Origin,
+				state,
 				score,
 				f.Name,
 				// FIXME: replace f.ShortDescriptionColumns()[0] with
@@ -227,7 +288,11 @@ Skipping remote findings because running in non-interactive mode.`)
 				f.ShortDescriptionColumns()[0],
 				f.FuzzTest,
 				locationInfo,
-			})
+			}
+			if hasOwners {
+				row = append([]string{f.Owner}, row...)
+			}
+			data = append(data, row)
 		}
 		err = pterm.DefaultTable.WithHasHeader().WithData(data).Render()
 		if err != nil {
@@ -264,8 +329,65 @@ Skipping remote findings because running in non-interactive mode.`)
 	return cmd.printFinding(f)
 }
 
+// filterByState returns the findings which are in one of the states
+// specified via --state, sorted by state if --sort=state is set.
+// Remote findings have no state, so they are only returned if --state
+// is not set.
+func (cmd *findingCmd) filterByState(findings []*finding.Finding) []*finding.Finding {
+	result := []*finding.Finding{}
+	for _, f := range findings {
+		if len(cmd.opts.States) > 0 {
+			hasState := f.Origin == "Local" && slices.ContainsFunc(cmd.opts.States, func(s string) bool {
+				return strings.EqualFold(s, string(f.GetState()))
+			})
+			if !hasState {
+				continue
+			}
+		}
+		result = append(result, f)
+	}
+	if cmd.opts.Sort == sortByState {
+		sort.SliceStable(result, func(i, j int) bool {
+			return slices.Index(finding.States, result[i].GetState()) < slices.Index(finding.States, result[j].GetState())
+		})
+	}
+	return result
+}
+
+// groupByOwner sets the owners of the findings from the config of their
+// fuzz tests and sorts them by owner, with findings of fuzz tests
+// without an owner last. If the --owner flag is set, only the findings
+// of that owner are returned.
+func (cmd *findingCmd) groupByOwner(findings []*finding.Finding) []*finding.Finding {
+	result := []*finding.Finding{}
+	for _, f := range findings {
+		f.Owner = config.FuzzTestOwner(cmd.opts.FuzzTestConfigs, f.FuzzTest)
+		if cmd.opts.Owner != "" && f.Owner != cmd.opts.Owner {
+			continue
+		}
+		result = append(result, f)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Owner == "" || result[j].Owner == "" {
+			return result[j].Owner == "" && result[i].Owner != ""
+		}
+		return result[i].Owner < result[j].Owner
+	})
+	return result
+}
+
 func (cmd *findingCmd) printFinding(f *finding.Finding) error {
+	decodedInput := cmd.decodeInput(f)
+	f.Owner = config.FuzzTestOwner(cmd.opts.FuzzTestConfigs, f.FuzzTest)
+
+	if cmd.opts.Format == formatSARIF {
+		return finding.WriteSARIF(cmd.OutOrStdout(), []*finding.Finding{f}, cmd.opts.ProjectDir, version.Version)
+	}
+
 	if cmd.opts.PrintJSON {
+		if decodedInput != "" {
+			f.HumanReadableInput = decodedInput
+		}
 		s, err := stringutil.ToJSONString(f)
 		if err != nil {
 			return err
@@ -277,16 +399,68 @@ func (cmd *findingCmd) printFinding(f *finding.Finding) error {
 	} else {
 		s := pterm.Style{pterm.Reset, pterm.Bold}.Sprint(f.ShortDescriptionWithName())
 		s += fmt.Sprintf("\nDate: %s\n", f.CreatedAt)
+		if f.Owner != "" {
+			s += fmt.Sprintf("Owner: %s\n", f.Owner)
+		}
+		if f.Origin == "Local" {
+			s += fmt.Sprintf("State: %s\n", f.GetState())
+		}
+		if !f.LastReproduced.IsZero() {
+			s += fmt.Sprintf("Last reproduced: %s\n", f.LastReproduced)
+		}
+		if signature := f.Signature(); signature != "" {
+			s += fmt.Sprintf("Signature: %s\n", signature)
+		}
+		if f.IssueURL != "" {
+			s += fmt.Sprintf("Issue: %s\n", f.IssueURL)
+		}
 		s += fmt.Sprintf("\n  %s\n", strings.Join(f.Logs, "\n  "))
+		if decodedInput != "" {
+			title := "Crashing input:"
+			if f.IsInputTruncated() {
+				title = fmt.Sprintf("Crashing input (first %d of %d bytes, the complete input is stored compressed in %s):",
+					len(f.InputData), f.InputSize, f.InputFile)
+			}
+			s += "\n" + pterm.Style{pterm.Reset, pterm.Bold}.Sprint(title) + "\n"
+			s += fmt.Sprintf("\n  %s\n", strings.Join(strings.Split(strings.TrimRight(decodedInput, "\n"), "\n"), "\n  "))
+		}
+		if len(f.AdditionalInputFiles) > 0 {
+			title := "Further crashing inputs with the same root cause:"
+			s += "\n" + pterm.Style{pterm.Reset, pterm.Bold}.Sprint(title) + "\n"
+			s += fmt.Sprintf("\n  %s\n", strings.Join(f.AdditionalInputFiles, "\n  "))
+		}
 		_, err := fmt.Fprint(cmd.OutOrStdout(), s)
 		if err != nil {
 			return errors.WithStack(err)
 		}
 		PrintMoreDetails(f)
+		PrintEnvironmentDiff(f)
 	}
 	return nil
 }
 
+// decodeInput returns the crashing input of the finding decoded
+// according to the input format configured for the fuzz test in
+// cifuzz.yaml, or an empty string if no input format is configured.
+// Of large inputs, only a hex dump of the preview is returned, because
+// parts of an input can't be decoded.
+func (cmd *findingCmd) decodeInput(f *finding.Finding) string {
+	if f.IsInputTruncated() {
+		return inputformat.HexDump(f.InputData)
+	}
+	fuzzTestConfig := config.FindFuzzTestConfig(cmd.opts.FuzzTestConfigs, f.FuzzTest)
+	if fuzzTestConfig == nil || fuzzTestConfig.InputFormat == "" || len(f.InputData) == 0 {
+		return ""
+	}
+
+	decoded, err := inputformat.Format(f.InputData, fuzzTestConfig.InputFormat)
+	if err != nil {
+		log.Warnf("Failed to decode the crashing input as %s: %v", fuzzTestConfig.InputFormat, err)
+		return inputformat.HexDump(f.InputData)
+	}
+	return decoded
+}
+
 func PrintMoreDetails(f *finding.Finding) {
 	if f.MoreDetails == nil {
 		return
@@ -341,6 +515,23 @@ func PrintMoreDetails(f *finding.Finding) {
 	}
 }
 
+// PrintEnvironmentDiff warns if the current environment differs from
+// the one in which the finding was found, because that's a common
+// reason for findings which don't reproduce
+func PrintEnvironmentDiff(f *finding.Finding) {
+	if f.Environment == nil {
+		// The finding was created by an older version of cifuzz
+		return
+	}
+	diffs := f.Environment.Diff(finding.CaptureEnvironment())
+	if len(diffs) == 0 {
+		return
+	}
+	log.Warnf(`The current environment differs from the one in which this finding was found,
+so it might not be reproducible:
+  %s`, strings.Join(diffs, "\n  "))
+}
+
 func getColorFunctionForSeverity(severity float32) func(a ...interface{}) string {
 	switch {
 	case severity >= 7.0:
@@ -1,9 +1,12 @@
 package finding
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -66,6 +69,91 @@ func TestListFindings(t *testing.T) {
 	require.Equal(t, jsonString, stdOut)
 }
 
+func TestListFindings_Owner(t *testing.T) {
+	projectDir := testutil.BootstrapEmptyProject(t, "test-list-findings-owner-")
+	err := os.WriteFile(filepath.Join(projectDir, "cifuzz.yaml"), []byte(`fuzz-tests:
+  - name: parser_fuzz_test
+    owner: team-parsers
+  - name: com.example.NetworkFuzzTest
+    owner: team-network
+`), 0o644)
+	require.NoError(t, err)
+	opts := &options{
+		ProjectDir: projectDir,
+		ConfigDir:  projectDir,
+	}
+
+	for _, f := range []*finding.Finding{
+		{Name: "finding_a", Origin: "Local", FuzzTest: "other_fuzz_test"},
+		{Name: "finding_b", Origin: "Local", FuzzTest: "parser_fuzz_test"},
+		{Name: "finding_c", Origin: "Local", FuzzTest: "com.example.NetworkFuzzTest::fuzz"},
+	} {
+		require.NoError(t, f.Save(projectDir))
+	}
+
+	// Findings are grouped by owner, findings without an owner are
+	// listed last
+	stdOut, _, err := cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "--json", "--interactive=false")
+	require.NoError(t, err)
+	var findings []*finding.Finding
+	require.NoError(t, json.Unmarshal([]byte(stdOut), &findings))
+	require.Len(t, findings, 3)
+	assert.Equal(t, "finding_c", findings[0].Name)
+	assert.Equal(t, "team-network", findings[0].Owner)
+	assert.Equal(t, "finding_b", findings[1].Name)
+	assert.Equal(t, "team-parsers", findings[1].Owner)
+	assert.Equal(t, "finding_a", findings[2].Name)
+	assert.Empty(t, findings[2].Owner)
+
+	// Only the findings of the owner are listed with --owner
+	opts = &options{
+		ProjectDir: projectDir,
+		ConfigDir:  projectDir,
+	}
+	stdOut, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "--json", "--interactive=false", "--owner=team-parsers")
+	require.NoError(t, err)
+	findings = nil
+	require.NoError(t, json.Unmarshal([]byte(stdOut), &findings))
+	require.Len(t, findings, 1)
+	assert.Equal(t, "finding_b", findings[0].Name)
+}
+
+func TestListFindings_State(t *testing.T) {
+	projectDir := testutil.BootstrapEmptyProject(t, "test-list-findings-state-")
+	opts := &options{
+		ProjectDir: projectDir,
+		ConfigDir:  projectDir,
+	}
+
+	now := time.Now()
+	for i, f := range []*finding.Finding{
+		{Name: "finding_a", Origin: "Local", State: finding.StateFixed},
+		{Name: "finding_b", Origin: "Local"},
+		{Name: "finding_c", Origin: "Local", State: finding.StateTriaged},
+	} {
+		f.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, f.Save(projectDir))
+	}
+
+	// Findings without a state are new
+	stdOut, _, err := cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin,
+		"--json", "--interactive=false", "--state=new,triaged", "--sort=state")
+	require.NoError(t, err)
+	var findings []*finding.Finding
+	require.NoError(t, json.Unmarshal([]byte(stdOut), &findings))
+	require.Len(t, findings, 2)
+	assert.Equal(t, "finding_b", findings[0].Name)
+	assert.Equal(t, "finding_c", findings[1].Name)
+
+	opts = &options{
+		ProjectDir: projectDir,
+		ConfigDir:  projectDir,
+	}
+	_, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "--interactive=false", "--state=open")
+	var usageErr *cmdutils.IncorrectUsageError
+	require.ErrorAs(t, err, &usageErr)
+}
+
 func TestListFindings_Authenticated(t *testing.T) {
 	t.Setenv("CIFUZZ_API_TOKEN", "token")
 	server := mockserver.New(t)
@@ -151,6 +239,34 @@ func TestPrintFinding(t *testing.T) {
 	require.NotContains(t, stdErr, "cifuzz found more extensive information about this finding:")
 }
 
+func TestPrintFinding_InputFormat(t *testing.T) {
+	f := &finding.Finding{
+		Origin:    "Local",
+		Name:      "test_finding",
+		FuzzTest:  "my_fuzz_test",
+		InputData: []byte(`{"key":"value"}`),
+	}
+
+	projectDir := testutil.BootstrapEmptyProject(t, "test-print-finding-")
+	err := os.WriteFile(filepath.Join(projectDir, "cifuzz.yaml"), []byte(`fuzz-tests:
+  - name: my_fuzz_test
+    input-format: json
+`), 0o644)
+	require.NoError(t, err)
+	opts := &options{
+		ProjectDir: projectDir,
+		ConfigDir:  projectDir,
+	}
+
+	err = f.Save(projectDir)
+	require.NoError(t, err)
+
+	stdOut, _, err := cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, f.Name, "--interactive=false")
+	require.NoError(t, err)
+	assert.Contains(t, stdOut, "Crashing input:")
+	assert.Contains(t, stdOut, "  {\n    \"key\": \"value\"\n  }")
+}
+
 func TestPrintUsageWarning(t *testing.T) {
 	projectDir := testutil.BootstrapEmptyProject(t, "test-list-findings-")
 	opts := &options{
@@ -0,0 +1,141 @@
+package pull
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/api"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/auth"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+)
+
+type options struct {
+	ProjectDir string `mapstructure:"project-dir"`
+	ConfigDir  string `mapstructure:"config-dir"`
+	Server     string `mapstructure:"server"`
+	Project    string `mapstructure:"project"`
+}
+
+type pullCmd struct {
+	*cobra.Command
+	opts      *options
+	apiClient *api.APIClient
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "pull [flags]",
+		Short: "Store the findings of remote runs locally",
+		Long: `This command downloads the findings which remote runs found in the
+CI Sense project and stores them in the .cifuzz-findings directory,
+together with their crashing inputs. Pulled findings can be shown with
+'cifuzz finding <finding>', reproduced with 'cifuzz reproduce' and
+debugged with 'cifuzz debug' like local findings.
+
+Findings which already exist locally are skipped, so that their state
+and other local changes are kept.
+
+The project is specified via --project (or 'project' in cifuzz.yaml).
+This command needs a token to access the API of CI Sense. You can
+specify this token via the CIFUZZ_API_TOKEN environment variable or by
+running 'cifuzz login' first.`,
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+			if opts.Project == "" {
+				return cmdutils.WrapIncorrectUsageError(errors.New("Flag \"project\" must be set"))
+			}
+			opts.Server = viper.GetString("server")
+			opts.Server, err = api.ValidateAndNormalizeServerURL(opts.Server)
+			return err
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := pullCmd{Command: c, opts: opts, apiClient: api.NewClient(opts.Server)}
+			return cmd.run()
+		},
+	}
+
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddProjectFlag,
+		cmdutils.AddServerFlag,
+	)
+
+	return cmd
+}
+
+func (c *pullCmd) run() error {
+	token, err := auth.GetValidToken(c.opts.Server)
+	if err != nil {
+		return err
+	}
+
+	remoteFindings, err := c.apiClient.DownloadRemoteFindings(c.opts.Project, token)
+	if err != nil {
+		return err
+	}
+
+	var numPulled, numSkipped int
+	for i := range remoteFindings.Findings {
+		f, err := remoteFindings.Findings[i].ToLocalFinding(c.opts.Project)
+		if err != nil {
+			return err
+		}
+		pulled, err := pullFinding(c.opts.ProjectDir, f)
+		if err != nil {
+			return err
+		}
+		if pulled {
+			numPulled++
+		} else {
+			numSkipped++
+		}
+	}
+	log.Successf("Pulled %d findings from project %s (%d findings already existed)",
+		numPulled, c.opts.Project, numSkipped)
+	return nil
+}
+
+// pullFinding stores the remote finding in the findings directory of the
+// project, unless a finding of the same name already exists there
+func pullFinding(projectDir string, f *finding.Finding) (bool, error) {
+	exists, err := f.Exists(projectDir)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		log.Debugf("Skipping finding %s, which already exists", f.Name)
+		return false, nil
+	}
+
+	f.StackHash = stacktrace.StackHash(string(f.Type), f.StackTrace)
+	if len(f.InputData) == 0 {
+		log.Warnf("Finding %s doesn't contain a crashing input, so it can't be reproduced", f.Name)
+		err = f.Save(projectDir)
+	} else {
+		err = f.SaveWithInput(projectDir, f.InputData)
+	}
+	if err != nil {
+		return false, err
+	}
+	log.Debugf("Stored finding %s", f.Name)
+	return true, nil
+}
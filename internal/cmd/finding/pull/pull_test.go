@@ -0,0 +1,54 @@
+package pull
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+)
+
+func TestPullFinding(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, "", "finding-pull-test-")
+
+	remoteFinding := func() *finding.Finding {
+		return &finding.Finding{
+			Origin:    "CI Sense",
+			Name:      "pensive_flamingo",
+			Type:      finding.ErrorTypeCrash,
+			InputData: []byte("crashing input"),
+			FuzzTest:  "my_fuzz_test",
+			StackTrace: []*stacktrace.StackFrame{
+				{Function: "exploreMe", SourceFile: "src/explore_me.cpp", Line: 13},
+			},
+		}
+	}
+
+	pulled, err := pullFinding(projectDir, remoteFinding())
+	require.NoError(t, err)
+	assert.True(t, pulled)
+
+	f, err := finding.LoadFinding(projectDir, "pensive_flamingo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "my_fuzz_test", f.FuzzTest)
+	assert.NotEmpty(t, f.StackHash)
+	require.NotEmpty(t, f.InputFile)
+	input, err := os.ReadFile(filepath.Join(projectDir, filepath.FromSlash(f.InputFile)))
+	require.NoError(t, err)
+	assert.Equal(t, "crashing input", string(input))
+
+	// Findings which already exist locally are not overwritten
+	f.State = finding.StateTriaged
+	require.NoError(t, f.Save(projectDir))
+	pulled, err = pullFinding(projectDir, remoteFinding())
+	require.NoError(t, err)
+	assert.False(t, pulled)
+	f, err = finding.LoadFinding(projectDir, "pensive_flamingo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, finding.StateTriaged, f.State)
+}
@@ -0,0 +1,274 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/github"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/vcs"
+)
+
+type options struct {
+	ProjectDir string `mapstructure:"project-dir"`
+	ConfigDir  string `mapstructure:"config-dir"`
+	GitHubRepo string `mapstructure:"github-repo"`
+
+	GitHub bool     `mapstructure:"-"`
+	Labels []string `mapstructure:"-"`
+	DryRun bool     `mapstructure:"-"`
+}
+
+type reportCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "report [flags] --github <finding>",
+		Short: "Create an issue for a finding",
+		Long: `This command creates a GitHub issue for a local finding, which
+contains the stack trace of the finding, the command to reproduce it
+and a link to its crashing input. The URL of the issue is stored in
+the finding and shown by 'cifuzz finding <finding>'.
+
+Source file paths in the stack trace are made relative to the project
+directory, paths outside of the project directory are reduced to the
+file name, so that no details about the local system end up in the
+issue. The link to the crashing input only works if the finding
+directory is committed and pushed.
+
+The repository is specified via --github-repo (or 'github-repo' in
+cifuzz.yaml), by default it's the GITHUB_REPOSITORY environment
+variable in GitHub Actions or the repository of the 'origin' Git
+remote. The token which is used to create the issue is read from the
+CIFUZZ_GITHUB_TOKEN or the GITHUB_TOKEN environment variable. For
+GitHub Enterprise Server, set GITHUB_API_URL and GITHUB_SERVER_URL.
+
+Use --dry-run to print the issue instead of creating it.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ValidFindings,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			if !opts.GitHub {
+				err := errors.New("Please specify where to create the issue, currently only --github is supported")
+				return cmdutils.WrapIncorrectUsageError(err)
+			}
+			if opts.GitHubRepo != "" {
+				err = github.ValidateRepo(opts.GitHubRepo)
+				if err != nil {
+					return cmdutils.WrapIncorrectUsageError(err)
+				}
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := reportCmd{Command: c, opts: opts}
+			return cmd.run(args[0])
+		},
+	}
+
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddGitHubRepoFlag,
+		cmdutils.AddProjectDirFlag,
+	)
+	cmd.Flags().BoolVar(&opts.GitHub, "github", false,
+		"Create a GitHub issue for the finding.")
+	cmd.Flags().StringSliceVar(&opts.Labels, "label", nil,
+		"A label to add to the issue. This flag can be used multiple times.")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false,
+		"Print the issue instead of creating it.")
+
+	return cmd
+}
+
+func (c *reportCmd) run(name string) error {
+	f, err := finding.LoadFinding(c.opts.ProjectDir, name, nil)
+	if finding.IsNotExistError(err) {
+		return errors.WithMessagef(err, "Finding %s does not exist", name)
+	}
+	if err != nil {
+		return err
+	}
+	if f.IssueURL != "" && !c.opts.DryRun {
+		return errors.Errorf("An issue was already created for finding %s: %s", f.Name, f.IssueURL)
+	}
+
+	repo, err := c.gitHubRepo()
+	if err != nil {
+		return err
+	}
+	issue := &github.Issue{
+		Title:  issueTitle(f),
+		Body:   issueBody(f, c.opts.ProjectDir, inputFileURL(f, c.opts.ProjectDir, repo)),
+		Labels: c.opts.Labels,
+	}
+
+	if c.opts.DryRun {
+		_, err = fmt.Fprintf(c.OutOrStdout(), "%s\n\n%s", issue.Title, issue.Body)
+		return errors.WithStack(err)
+	}
+
+	token := viper.GetString("github-token")
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token == "" {
+		return errors.New("No GitHub token found, please set the CIFUZZ_GITHUB_TOKEN or GITHUB_TOKEN environment variable")
+	}
+
+	f.IssueURL, err = github.CreateIssue(github.APIURL(), token, repo, issue)
+	if err != nil {
+		return err
+	}
+	err = f.Save(c.opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+	log.Successf("Created issue %s for finding %s", f.IssueURL, f.Name)
+	return nil
+}
+
+// gitHubRepo returns the repository in which the issue is created
+func (c *reportCmd) gitHubRepo() (string, error) {
+	if c.opts.GitHubRepo != "" {
+		return c.opts.GitHubRepo, nil
+	}
+	if repo := os.Getenv("GITHUB_REPOSITORY"); repo != "" {
+		return repo, nil
+	}
+	remoteURL, err := vcs.GitRemoteURL("origin")
+	if err != nil {
+		log.Debugf("Failed to get the URL of the 'origin' Git remote: %v", err)
+		err = errors.New("Failed to determine the GitHub repository, please specify it via --github-repo")
+		return "", cmdutils.WrapIncorrectUsageError(err)
+	}
+	return github.RepoFromRemoteURL(remoteURL)
+}
+
+func issueTitle(f *finding.Finding) string {
+	// The other columns contain the location, which might be an
+	// absolute path
+	title := f.ShortDescriptionColumns()[0]
+	if f.FuzzTest != "" {
+		title += " in " + f.FuzzTest
+	}
+	return fmt.Sprintf("%s (%s)", title, f.Name)
+}
+
+func issueBody(f *finding.Finding, projectDir, inputURL string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Finding:** `%s`\n", f.Name)
+	if f.FuzzTest != "" {
+		fmt.Fprintf(&b, "**Fuzz test:** `%s`\n", f.FuzzTest)
+	}
+	fmt.Fprintf(&b, "**Description:** %s\n", f.ShortDescriptionColumns()[0])
+	if len(f.StackTrace) > 0 {
+		frame := f.StackTrace[0]
+		fmt.Fprintf(&b, "**Location:** `%s:%d`\n", sanitizePath(frame.SourceFile, projectDir), frame.Line)
+	}
+	if f.MoreDetails != nil && f.MoreDetails.Severity != nil {
+		if level := f.MoreDetails.Severity.EffectiveLevel(); level != "" {
+			fmt.Fprintf(&b, "**Severity:** %s\n", level)
+		}
+	}
+
+	if len(f.StackTrace) > 0 {
+		b.WriteString("\n### Stack trace\n\n```\n")
+		for _, frame := range f.StackTrace {
+			fmt.Fprintf(&b, "#%d %s in %s:%d", frame.FrameNumber, frame.Function, sanitizePath(frame.SourceFile, projectDir), frame.Line)
+			if frame.Column != 0 {
+				fmt.Fprintf(&b, ":%d", frame.Column)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("```\n")
+	}
+
+	b.WriteString("\n### Reproduce\n\n")
+	if f.InputFile != "" {
+		fmt.Fprintf(&b, "With the finding directory `%s` checked out, run in the project directory:\n\n", filepath.ToSlash(filepath.Dir(f.InputFile)))
+	} else {
+		b.WriteString("Run in the project directory:\n\n")
+	}
+	fmt.Fprintf(&b, "```sh\ncifuzz reproduce %s\n```\n", f.Name)
+
+	if f.InputFile != "" {
+		b.WriteString("\n### Crashing input\n\n")
+		if inputURL != "" {
+			fmt.Fprintf(&b, "[`%s`](%s)\n", f.InputFile, inputURL)
+		} else {
+			fmt.Fprintf(&b, "`%s`\n", f.InputFile)
+		}
+	}
+
+	b.WriteString("\n---\n_This issue was created by `cifuzz finding report`._\n")
+	return b.String()
+}
+
+// sanitizePath returns the path relative to the project directory, or
+// only the file name if the path is outside of the project directory
+func sanitizePath(path, projectDir string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(projectDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Base(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// inputFileURL returns the URL of the crashing input in the GitHub
+// repository at the current commit, or an empty string if it can't be
+// determined
+func inputFileURL(f *finding.Finding, projectDir, repo string) string {
+	if f.InputFile == "" {
+		return ""
+	}
+	rootDir, err := vcs.GitRootDir()
+	if err != nil {
+		log.Debugf("Failed to determine the Git root directory: %v", err)
+		return ""
+	}
+	commit, err := vcs.GitCommit()
+	if err != nil {
+		log.Debugf("Failed to determine the Git commit: %v", err)
+		return ""
+	}
+	absProjectDir, err := filepath.EvalSymlinks(projectDir)
+	if err != nil {
+		log.Debugf("Failed to resolve the project directory: %v", err)
+		return ""
+	}
+	path, err := filepath.Rel(rootDir, filepath.Join(absProjectDir, f.InputFile))
+	if err != nil || strings.HasPrefix(path, "..") {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/blob/%s/%s", github.ServerURL(), repo, commit, filepath.ToSlash(path))
+}
@@ -0,0 +1,86 @@
+package report
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/github"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+)
+
+func TestReport(t *testing.T) {
+	projectDir := testutil.BootstrapEmptyProject(t, "test-finding-report-")
+	f := &finding.Finding{
+		Name:     "funny_elephant",
+		Type:     finding.ErrorTypeCrash,
+		Details:  "heap-buffer-overflow",
+		FuzzTest: "my_fuzz_test",
+		StackTrace: []*stacktrace.StackFrame{
+			{Function: "exploreMe", SourceFile: filepath.Join(projectDir, "src", "explore_me.cpp"), Line: 13, Column: 11},
+			{Function: "main", SourceFile: filepath.Join(os.TempDir(), "libfuzzer", "FuzzerMain.cpp"), Line: 20, FrameNumber: 1},
+		},
+	}
+	require.NoError(t, f.Save(projectDir))
+	opts := &options{
+		ProjectDir: projectDir,
+		ConfigDir:  projectDir,
+	}
+
+	var issue github.Issue
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/issues", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&issue))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"html_url": "https://github.com/owner/repo/issues/1"}`))
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_API_URL", server.URL)
+	t.Setenv("GITHUB_TOKEN", "my-token")
+
+	// The target must be specified
+	_, _, err := cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "funny_elephant")
+	var usageErr *cmdutils.IncorrectUsageError
+	require.ErrorAs(t, err, &usageErr)
+
+	_, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin,
+		"--github", "--github-repo=owner/repo", "--label=fuzzing", "funny_elephant")
+	require.NoError(t, err)
+	assert.Equal(t, "heap buffer overflow in my_fuzz_test (funny_elephant)", issue.Title)
+	assert.Equal(t, []string{"fuzzing"}, issue.Labels)
+	assert.Contains(t, issue.Body, "#0 exploreMe in src/explore_me.cpp:13:11\n")
+	assert.Contains(t, issue.Body, "#1 main in FuzzerMain.cpp:20\n")
+	assert.Contains(t, issue.Body, "cifuzz reproduce funny_elephant\n")
+	assert.NotContains(t, issue.Body, projectDir)
+
+	f, err = finding.LoadFinding(projectDir, "funny_elephant", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/owner/repo/issues/1", f.IssueURL)
+
+	// An issue is only created once
+	_, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin,
+		"--github", "--github-repo=owner/repo", "funny_elephant")
+	require.Error(t, err)
+
+	// But it can still be printed
+	stdout, _, err := cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin,
+		"--github", "--github-repo=owner/repo", "--dry-run", "funny_elephant")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "heap buffer overflow in my_fuzz_test (funny_elephant)")
+}
+
+func TestSanitizePath(t *testing.T) {
+	projectDir := filepath.Join(string(filepath.Separator), "home", "user", "project")
+	assert.Equal(t, "src/a.cpp", sanitizePath(filepath.Join(projectDir, "src", "a.cpp"), projectDir))
+	assert.Equal(t, "a.cpp", sanitizePath(filepath.Join(projectDir, "..", "other", "a.cpp"), projectDir))
+	assert.Equal(t, "src/a.cpp", sanitizePath(filepath.Join("src", "a.cpp"), projectDir))
+}
@@ -0,0 +1,86 @@
+package setstate
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+type options struct {
+	ProjectDir string `mapstructure:"project-dir"`
+	ConfigDir  string `mapstructure:"config-dir"`
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "set-state [flags] <finding> <state>",
+		Short: "Set the state of a finding",
+		Long: `This command sets the lifecycle state of a local finding, which is
+one of:
+
+  new       The finding was not triaged yet (the default)
+  triaged   The finding was triaged and should be fixed
+  fixed     The bug was fixed
+  wontfix   The finding won't be fixed, e.g. because it's a false positive
+
+The state is shown by 'cifuzz finding'. 'cifuzz reproduce' sets the
+state to "fixed" if the crashing input doesn't trigger a crash anymore.`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return completion.ValidFindings(cmd, args, toComplete)
+			}
+			var states []string
+			for _, state := range finding.States {
+				states = append(states, string(state))
+			}
+			return states, cobra.ShellCompDirectiveNoFileComp
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			return config.FindAndParseProjectConfig(opts)
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			state, err := finding.ParseState(args[1])
+			if err != nil {
+				return cmdutils.WrapIncorrectUsageError(err)
+			}
+
+			f, err := finding.LoadFinding(opts.ProjectDir, args[0], nil)
+			if finding.IsNotExistError(err) {
+				return errors.WithMessagef(err, "Finding %s does not exist", args[0])
+			}
+			if err != nil {
+				return err
+			}
+
+			f.State = state
+			err = f.Save(opts.ProjectDir)
+			if err != nil {
+				return err
+			}
+			log.Successf("Set the state of finding %s to %s", f.Name, state)
+			return nil
+		},
+	}
+
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+	)
+
+	return cmd
+}
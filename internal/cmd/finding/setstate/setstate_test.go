@@ -0,0 +1,36 @@
+package setstate
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/finding"
+)
+
+func TestSetState(t *testing.T) {
+	projectDir := testutil.BootstrapEmptyProject(t, "test-set-state-")
+	f := &finding.Finding{Name: "test_finding"}
+	require.NoError(t, f.Save(projectDir))
+	opts := &options{
+		ProjectDir: projectDir,
+		ConfigDir:  projectDir,
+	}
+
+	_, _, err := cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "test_finding", "wontfix")
+	require.NoError(t, err)
+	f, err = finding.LoadFinding(projectDir, "test_finding", nil)
+	require.NoError(t, err)
+	assert.Equal(t, finding.StateWontFix, f.State)
+
+	_, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "test_finding", "invalid")
+	var usageErr *cmdutils.IncorrectUsageError
+	require.ErrorAs(t, err, &usageErr)
+
+	_, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "other_finding", "fixed")
+	require.Error(t, err)
+}
@@ -0,0 +1,281 @@
+package totest
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+const (
+	frameworkGoogleTest = "googletest"
+	frameworkDoctest    = "doctest"
+)
+
+//go:embed regression_test.cpp.tmpl
+var googleTestTemplate string
+
+//go:embed regression_test_doctest.cpp.tmpl
+var doctestTemplate string
+
+//go:embed RegressionTest.java.tmpl
+var junitTemplate string
+
+//go:embed regression.test.js.tmpl
+var jestTemplate string
+
+type options struct {
+	ProjectDir  string `mapstructure:"project-dir"`
+	ConfigDir   string `mapstructure:"config-dir"`
+	BuildSystem string `mapstructure:"build-system"`
+
+	Output    string `mapstructure:"-"`
+	Framework string `mapstructure:"-"`
+	Method    string `mapstructure:"-"`
+}
+
+type toTestCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "to-test [flags] <finding>",
+		Short: "Generate a regression unit test from a finding",
+		Long: `This command generates a unit test which runs the fuzz test on the
+crashing input of the finding, so that it can be committed to make
+sure that the bug stays fixed. The crashing input is embedded in the
+test.
+
+The test framework is chosen based on the build system:
+  * C/C++ projects: GoogleTest (default) or doctest, see --framework
+  * Java projects: JUnit 5
+  * Node.js projects: Jest
+
+The test is printed to stdout unless the --output flag is used.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ValidFindings,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+			if opts.Framework != frameworkGoogleTest && opts.Framework != frameworkDoctest {
+				msg := fmt.Sprintf("Invalid framework %q, supported frameworks are: %s, %s",
+					opts.Framework, frameworkGoogleTest, frameworkDoctest)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := toTestCmd{Command: c, opts: opts}
+			return cmd.run(args[0])
+		},
+	}
+
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+	)
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "Write the test to the specified file instead of stdout")
+	cmd.Flags().StringVar(&opts.Framework, "framework", frameworkGoogleTest,
+		fmt.Sprintf("The test framework of C/C++ tests (%s or %s)", frameworkGoogleTest, frameworkDoctest))
+	cmd.Flags().StringVar(&opts.Method, "method", "",
+		"The fuzz test method of Java fuzz tests, if the finding doesn't specify it.\n"+
+			"By default, the static fuzzerTestOneInput method is called.")
+
+	return cmd
+}
+
+func (c *toTestCmd) run(findingName string) error {
+	f, err := finding.LoadFinding(c.opts.ProjectDir, findingName, nil)
+	if finding.IsNotExistError(err) {
+		return errors.WithMessagef(err, "Finding %s does not exist", findingName)
+	}
+	if err != nil {
+		return err
+	}
+	// The finding only contains a preview of large inputs
+	f.InputData, err = f.ReadInput(c.opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	test, err := generateTest(f, c.opts)
+	if err != nil {
+		return err
+	}
+
+	if c.opts.Output == "" {
+		_, err = fmt.Fprint(c.OutOrStdout(), test)
+		return errors.WithStack(err)
+	}
+
+	exists, err := fileutil.Exists(c.opts.Output)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.Errorf("%s already exists", c.opts.Output)
+	}
+	err = os.WriteFile(c.opts.Output, []byte(test), 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	log.Successf("Created regression test %s", c.opts.Output)
+	return nil
+}
+
+type testData struct {
+	FindingName string
+	FuzzTest    string
+	Description string
+	SuiteName   string
+	TestName    string
+	Size        int
+	CArray      string
+	Base64      string
+	// Only used for Java tests
+	Package string
+	Call    string
+	// Only used for Node.js tests
+	RequirePath string
+}
+
+var nonIdentifierRegex = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+func generateTest(f *finding.Finding, opts *options) (string, error) {
+	data := &testData{
+		FindingName: f.Name,
+		FuzzTest:    f.FuzzTest,
+		// The description is used in comments, so we only use the first
+		// line and make sure that it doesn't end a block comment
+		Description: strings.ReplaceAll(strings.SplitN(f.ShortDescription(), "\n", 2)[0], "*/", "* /"),
+		TestName:    identifier(f.Name),
+		Size:        len(f.InputData),
+		CArray:      cArray(f.InputData),
+	}
+
+	var tmpl string
+	switch opts.BuildSystem {
+	case config.BuildSystemMaven, config.BuildSystemGradle:
+		tmpl = junitTemplate
+		data.Base64 = base64Lines(f.InputData, "        ")
+		fuzzTest, method, _ := strings.Cut(f.FuzzTest, "::")
+		if method == "" {
+			method = opts.Method
+		}
+		className := fuzzTest
+		if i := strings.LastIndex(fuzzTest, "."); i != -1 {
+			data.Package = fuzzTest[:i]
+			className = fuzzTest[i+1:]
+		}
+		data.SuiteName = className + "RegressionTest"
+		if method == "" {
+			data.Call = className + ".fuzzerTestOneInput"
+		} else {
+			data.Call = fmt.Sprintf("new %s().%s", className, method)
+		}
+	case config.BuildSystemNodeJS:
+		tmpl = jestTemplate
+		data.Base64 = base64Lines(f.InputData, "\t")
+		// The fuzz test of Node.js findings is the test path pattern
+		// passed to `cifuzz run`, which is usually the name of the fuzz
+		// test file without the extension
+		requirePath := strings.TrimSuffix(f.FuzzTest, filepath.Ext(f.FuzzTest))
+		if !strings.HasSuffix(requirePath, ".fuzz") {
+			requirePath += ".fuzz"
+		}
+		if !strings.HasPrefix(requirePath, ".") && !filepath.IsAbs(requirePath) {
+			requirePath = "./" + requirePath
+		}
+		data.RequirePath = filepath.ToSlash(requirePath)
+	default:
+		tmpl = googleTestTemplate
+		if opts.Framework == frameworkDoctest {
+			tmpl = doctestTemplate
+		}
+		data.SuiteName = identifier(filepath.Base(f.FuzzTest)) + "RegressionTest"
+	}
+
+	t, err := template.New("test").Parse(tmpl)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return buf.String(), nil
+}
+
+// identifier converts the name into a valid C++, Java and JavaScript
+// identifier
+func identifier(name string) string {
+	id := nonIdentifierRegex.ReplaceAllString(name, "_")
+	if id == "" || (id[0] >= '0' && id[0] <= '9') {
+		id = "_" + id
+	}
+	return id
+}
+
+// cArray returns the elements of a C array initializer for the data,
+// with 12 bytes per line. Empty arrays are not valid in C++, so a
+// single zero byte is used for empty data.
+func cArray(data []byte) string {
+	if len(data) == 0 {
+		return "    0x00,"
+	}
+	var lines []string
+	for i := 0; i < len(data); i += 12 {
+		end := min(i+12, len(data))
+		var elems []string
+		for _, b := range data[i:end] {
+			elems = append(elems, fmt.Sprintf("0x%02x,", b))
+		}
+		lines = append(lines, "    "+strings.Join(elems, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// base64Lines returns the base64 encoding of the data as a
+// concatenation of string literals with 64 characters each, which is
+// valid Java and JavaScript
+func base64Lines(data []byte, indent string) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	if encoded == "" {
+		return indent + `""`
+	}
+	var lines []string
+	for i := 0; i < len(encoded); i += 64 {
+		end := min(i+64, len(encoded))
+		lines = append(lines, fmt.Sprintf(`%s"%s"`, indent, encoded[i:end]))
+	}
+	return strings.Join(lines, " +\n")
+}
@@ -0,0 +1,62 @@
+package totest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+)
+
+func TestGenerateTest(t *testing.T) {
+	f := &finding.Finding{
+		Name:      "funny_elephant",
+		Type:      finding.ErrorTypeCrash,
+		Details:   "heap buffer overflow",
+		InputData: []byte("FUZZ\x00\xff"),
+	}
+
+	f.FuzzTest = "my_fuzz_test"
+	test, err := generateTest(f, &options{BuildSystem: config.BuildSystemCMake, Framework: frameworkGoogleTest})
+	require.NoError(t, err)
+	assert.Contains(t, test, "#include <gtest/gtest.h>")
+	assert.Contains(t, test, "    0x46, 0x55, 0x5a, 0x5a, 0x00, 0xff,\n")
+	assert.Contains(t, test, "const size_t kInputSize = 6;")
+	assert.Contains(t, test, "TEST(my_fuzz_testRegressionTest, funny_elephant) {")
+
+	test, err = generateTest(f, &options{BuildSystem: config.BuildSystemCMake, Framework: frameworkDoctest})
+	require.NoError(t, err)
+	assert.Contains(t, test, "#include <doctest/doctest.h>")
+	assert.Contains(t, test, `TEST_CASE("my_fuzz_testRegressionTest.funny_elephant") {`)
+
+	f.FuzzTest = "com.example.FuzzTestCase::myFuzzTest"
+	test, err = generateTest(f, &options{BuildSystem: config.BuildSystemMaven})
+	require.NoError(t, err)
+	assert.Contains(t, test, "package com.example;\n")
+	assert.Contains(t, test, "class FuzzTestCaseRegressionTest {")
+	assert.Contains(t, test, `        "RlVaWgD/");`)
+	assert.Contains(t, test, "new FuzzTestCase().myFuzzTest(data);")
+
+	f.FuzzTest = "FuzzTestCase"
+	test, err = generateTest(f, &options{BuildSystem: config.BuildSystemGradle})
+	require.NoError(t, err)
+	assert.NotContains(t, test, "package")
+	assert.Contains(t, test, "FuzzTestCase.fuzzerTestOneInput(data);")
+
+	f.FuzzTest = "FuzzTestCase"
+	test, err = generateTest(f, &options{BuildSystem: config.BuildSystemNodeJS})
+	require.NoError(t, err)
+	assert.Contains(t, test, "\t\"RlVaWgD/\",\n")
+	assert.Contains(t, test, `require("./FuzzTestCase.fuzz");`)
+}
+
+func TestBase64Lines(t *testing.T) {
+	assert.Equal(t, `  ""`, base64Lines(nil, "  "))
+
+	data := make([]byte, 60)
+	lines := base64Lines(data, "  ")
+	assert.Equal(t, `  "`+strings.Repeat("A", 64)+`" +`+"\n"+`  "`+strings.Repeat("A", 16)+`"`, lines)
+}
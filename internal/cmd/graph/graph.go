@@ -0,0 +1,371 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/build/bazel"
+	"code-intelligence.com/cifuzz/internal/build/cmake"
+	"code-intelligence.com/cifuzz/internal/build/java/gradle"
+	"code-intelligence.com/cifuzz/internal/build/java/maven"
+	"code-intelligence.com/cifuzz/internal/build/other"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/depgraph"
+	"code-intelligence.com/cifuzz/pkg/binary"
+	"code-intelligence.com/cifuzz/pkg/dependencies"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+)
+
+type options struct {
+	BuildSystem           string   `mapstructure:"build-system"`
+	BuildCommand          string   `mapstructure:"build-command"`
+	CleanCommand          string   `mapstructure:"clean-command"`
+	NumBuildJobs          uint     `mapstructure:"build-jobs"`
+	ProjectDir            string   `mapstructure:"project-dir"`
+	ConfigDir             string   `mapstructure:"config-dir"`
+	ResolveSourceFilePath bool     `mapstructure:"resolve-source-file-path"`
+	Sanitizers            []string `mapstructure:"sanitizers"`
+
+	Format     string
+	OutputPath string
+
+	fuzzTest string
+}
+
+func (opts *options) validate() error {
+	if !sliceutil.Contains(depgraph.Formats, opts.Format) {
+		msg := fmt.Sprintf("Invalid format %q, valid formats are: %s", opts.Format, strings.Join(depgraph.Formats, ", "))
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	if opts.BuildSystem == config.BuildSystemOther && opts.BuildCommand == "" {
+		msg := `Flag "build-command" must be set when using build system type "other"`
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	switch opts.BuildSystem {
+	case config.BuildSystemCMake, config.BuildSystemBazel, config.BuildSystemOther:
+		if len(opts.Sanitizers) == 0 {
+			opts.Sanitizers = build.DefaultSanitizers
+		}
+		err := build.ValidateSanitizers(opts.Sanitizers)
+		if err != nil {
+			return cmdutils.WrapIncorrectUsageError(err)
+		}
+	}
+
+	return nil
+}
+
+type graphCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "graph [flags] <fuzz test>",
+		Short: "Export the dependency graph of a fuzz test",
+		Long: `This command builds the specified fuzz test and prints the graph of
+its build and runtime dependencies. For C/C++ fuzz tests, the graph
+contains the fuzz test executable, the source files from the project
+which were compiled into it and the shared libraries it is linked
+against. For Java fuzz tests, it contains the jars and class directories
+on the runtime classpath.
+
+The graph can be used for change-impact analysis, for example to
+determine which fuzz tests are affected by the files changed in a pull
+request.
+
+Supported formats are:
+  dot   Graphviz DOT (default)
+  json  JSON with a list of nodes and edges
+
+The graph is printed to stdout unless an output file is specified via
+--output, the build output is printed to stderr.`,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			if opts.BuildSystem == config.BuildSystemNodeJS {
+				return errors.Errorf(config.NotSupportedErrorMessage("graph", opts.BuildSystem))
+			}
+
+			fuzzTests, err := resolve.FuzzTestArguments(opts.ResolveSourceFilePath, args, opts.BuildSystem, opts.ProjectDir)
+			if err != nil {
+				return err
+			}
+			opts.fuzzTest = fuzzTests[0]
+
+			return opts.validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := graphCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via viper as well (i.e.
+	//       via cifuzz.yaml and CIFUZZ_* environment variables), bind
+	//       it to viper in the PreRun function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddBuildJobsFlag,
+		cmdutils.AddCleanCommandFlag,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddResolveSourceFileFlag,
+		cmdutils.AddSanitizerFlag,
+	)
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", depgraph.FormatDOT,
+		fmt.Sprintf("Output format of the graph (%s).", strings.Join(depgraph.Formats, "/")))
+	cmd.Flags().StringVarP(&opts.OutputPath, "output", "o", "",
+		"Write the graph to the specified `file` instead of stdout.")
+
+	return cmd
+}
+
+func (c *graphCmd) run() error {
+	err := c.checkDependencies()
+	if err != nil {
+		return err
+	}
+
+	g := depgraph.New(c.opts.fuzzTest, c.opts.ProjectDir)
+
+	switch c.opts.BuildSystem {
+	case config.BuildSystemBazel, config.BuildSystemCMake, config.BuildSystemOther:
+		result, err := c.buildC()
+		if err != nil {
+			return err
+		}
+		err = addCBuildResult(g, result)
+		if err != nil {
+			return err
+		}
+	case config.BuildSystemMaven, config.BuildSystemGradle:
+		result, err := c.buildJava()
+		if err != nil {
+			return err
+		}
+		addJavaBuildResult(g, result)
+	default:
+		return errors.Errorf(config.NotSupportedErrorMessage("graph", c.opts.BuildSystem))
+	}
+
+	var out io.Writer = c.OutOrStdout()
+	if c.opts.OutputPath != "" {
+		f, err := os.Create(c.opts.OutputPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	err = g.Write(out, c.opts.Format)
+	if err != nil {
+		return err
+	}
+
+	if c.opts.OutputPath != "" {
+		log.Successf("Wrote dependency graph of %s to %s", c.opts.fuzzTest, fileutil.PrettifyPath(c.opts.OutputPath))
+	}
+	return nil
+}
+
+func (c *graphCmd) buildC() (*build.CBuildResult, error) {
+	sanitizers := c.opts.Sanitizers
+
+	// The graph is printed to stdout, so we print the build output
+	// to stderr
+	buildOutput := c.ErrOrStderr()
+
+	switch c.opts.BuildSystem {
+	case config.BuildSystemBazel:
+		tempDir, err := os.MkdirTemp("", "cifuzz-graph-")
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		defer fileutil.Cleanup(tempDir)
+
+		builder, err := bazel.NewBuilder(&bazel.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			NumJobs:    c.opts.NumBuildJobs,
+			Stdout:     buildOutput,
+			Stderr:     buildOutput,
+			TempDir:    tempDir,
+			Verbose:    viper.GetBool("verbose"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		results, err := builder.BuildForBundle(sanitizers, []string{c.opts.fuzzTest})
+		if err != nil {
+			return nil, err
+		}
+		return results[0], nil
+
+	case config.BuildSystemCMake:
+		builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Sanitizers: sanitizers,
+			Parallel: cmake.ParallelOptions{
+				Enabled: viper.IsSet("build-jobs"),
+				NumJobs: c.opts.NumBuildJobs,
+			},
+			Stdout:          buildOutput,
+			Stderr:          buildOutput,
+			FindRuntimeDeps: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = builder.Configure()
+		if err != nil {
+			return nil, err
+		}
+		results, err := builder.Build([]string{c.opts.fuzzTest})
+		if err != nil {
+			return nil, err
+		}
+		return results[0], nil
+
+	default:
+		builder, err := other.NewBuilder(&other.BuilderOptions{
+			ProjectDir:   c.opts.ProjectDir,
+			BuildCommand: c.opts.BuildCommand,
+			CleanCommand: c.opts.CleanCommand,
+			Sanitizers:   sanitizers,
+			Stdout:       buildOutput,
+			Stderr:       buildOutput,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return builder.Build(c.opts.fuzzTest)
+	}
+}
+
+func (c *graphCmd) buildJava() (*build.BuildResult, error) {
+	buildOutput := c.ErrOrStderr()
+
+	if c.opts.BuildSystem == config.BuildSystemMaven {
+		builder, err := maven.NewBuilder(&maven.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Parallel: maven.ParallelOptions{
+				Enabled: viper.IsSet("build-jobs"),
+				NumJobs: c.opts.NumBuildJobs,
+			},
+			Stdout: buildOutput,
+			Stderr: buildOutput,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return builder.Build()
+	}
+
+	builder, err := gradle.NewBuilder(&gradle.BuilderOptions{
+		ProjectDir: c.opts.ProjectDir,
+		Parallel: gradle.ParallelOptions{
+			Enabled: viper.IsSet("build-jobs"),
+			NumJobs: c.opts.NumBuildJobs,
+		},
+		Stdout: buildOutput,
+		Stderr: buildOutput,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return builder.Build()
+}
+
+func addCBuildResult(g *depgraph.Graph, result *build.CBuildResult) error {
+	exe := g.AddFile(g.Root(), depgraph.NodeKindExecutable, result.Executable)
+
+	sourceFiles, err := binary.SourceFiles(result.Executable)
+	if errors.Is(err, binary.ErrNoDWARF) {
+		// The graph is still useful without the source files, e.g.
+		// for executables built with MSVC
+		log.Warnf("%s doesn't contain DWARF debug information, the graph doesn't include its source files",
+			fileutil.PrettifyPath(result.Executable))
+	} else if err != nil {
+		return err
+	}
+	for _, sourceFile := range sourceFiles {
+		// Only files from the project are relevant for change-impact
+		// analysis, system headers and the like would only add noise
+		if !g.IsInProject(sourceFile) {
+			continue
+		}
+		g.AddFile(exe, depgraph.NodeKindSourceFile, sourceFile)
+	}
+
+	for _, dep := range result.RuntimeDeps {
+		g.AddFile(exe, depgraph.NodeKindLibrary, dep)
+	}
+	return nil
+}
+
+func addJavaBuildResult(g *depgraph.Graph, result *build.BuildResult) {
+	for _, dep := range result.RuntimeDeps {
+		kind := depgraph.NodeKindJar
+		if fileutil.IsDir(dep) {
+			kind = depgraph.NodeKindClassDir
+		}
+		g.AddFile(g.Root(), kind, dep)
+	}
+}
+
+func (c *graphCmd) checkDependencies() error {
+	var deps []dependencies.Key
+	switch c.opts.BuildSystem {
+	case config.BuildSystemBazel:
+		deps = []dependencies.Key{dependencies.Bazel}
+	case config.BuildSystemCMake:
+		deps = []dependencies.Key{dependencies.CMake}
+		switch runtime.GOOS {
+		case "linux", "darwin":
+			deps = append(deps, dependencies.Clang)
+		case "windows":
+			deps = append(deps, dependencies.VisualStudio)
+		}
+	case config.BuildSystemMaven:
+		deps = []dependencies.Key{dependencies.Maven}
+	case config.BuildSystemGradle:
+		deps = []dependencies.Key{dependencies.Gradle}
+	case config.BuildSystemOther:
+		deps = []dependencies.Key{dependencies.Clang}
+	default:
+		return errors.Errorf("Unsupported build system \"%s\"", c.opts.BuildSystem)
+	}
+	return dependencies.Check(deps, c.opts.ProjectDir)
+}
@@ -0,0 +1,255 @@
+package importcmd
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+)
+
+// harness is a libFuzzer fuzz target which is not a cifuzz fuzz test yet
+type harness struct {
+	// The absolute path of the source file which defines
+	// LLVMFuzzerTestOneInput
+	Source string
+	// The name of the fuzz test, which is the name of the fuzzer in the
+	// OSS-Fuzz build script if it's built there
+	Name string
+	// The absolute path of a directory or zip file containing the seed
+	// corpus, or an empty string if the harness has no seed corpus
+	SeedCorpus string
+	// The absolute path of the dictionary, or an empty string if the
+	// harness has no dictionary
+	Dict string
+}
+
+// ossFuzzFuzzer is a fuzzer which is built by an OSS-Fuzz build script
+type ossFuzzFuzzer struct {
+	Name string
+	// The absolute paths of the C/C++ source files which are compiled
+	// into the fuzzer
+	Sources    []string
+	SeedCorpus string
+	Dict       string
+}
+
+var (
+	harnessRegex = regexp.MustCompile(`(?m)^[ \t]*(?:extern[ \t]+"C"[ \t]+)?int[ \t]+LLVMFuzzerTestOneInput[ \t]*\(`)
+	// Fuzz tests which use the FUZZ_TEST macro of cifuzz are already
+	// cifuzz fuzz tests
+	cifuzzHeaderRegex = regexp.MustCompile(`#include[ \t]*[<"]cifuzz/cifuzz\.h[>"]`)
+
+	// Files which OSS-Fuzz build scripts copy to $OUT, where the name
+	// of the file determines the fuzzer it belongs to
+	outFileRegex = regexp.MustCompile(`^\$(?:OUT|\{OUT\})/(.*)$`)
+	// Characters which are not allowed in the name of a CMake target
+	invalidTargetNameChars = regexp.MustCompile(`[^A-Za-z0-9_.+-]+`)
+)
+
+var sourceExtensions = []string{".c", ".cc", ".cpp", ".cxx"}
+
+// Directories which contain build outputs or third-party code, whose
+// harnesses shouldn't be imported
+var skippedDirs = []string{"build", "third_party", "third-party", "vendor", "node_modules", "bazel-out"}
+
+// findHarnesses returns the absolute paths of the C/C++ source files
+// below dir which define LLVMFuzzerTestOneInput
+func findHarnesses(dir string) ([]string, error) {
+	var harnesses []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if d.IsDir() {
+			if path != dir && isSkippedDir(path, d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isSourceFile(path) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if harnessRegex.Match(content) && !cifuzzHeaderRegex.Match(content) {
+			harnesses = append(harnesses, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return harnesses, nil
+}
+
+func isSkippedDir(path, name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "cmake-build-") {
+		return true
+	}
+	if sliceutil.Contains(skippedDirs, name) {
+		return true
+	}
+	// CMake build directories can have any name
+	exists, _ := fileutil.Exists(filepath.Join(path, "CMakeCache.txt"))
+	return exists
+}
+
+func isSourceFile(path string) bool {
+	return sliceutil.Contains(sourceExtensions, filepath.Ext(path))
+}
+
+// newHarness returns a harness for the source file with the name,
+// seed corpus and dictionary from the OSS-Fuzz build scripts or, if
+// the harness isn't built there, from the files next to it which follow
+// the naming conventions of OSS-Fuzz
+func newHarness(source string, fuzzers []*ossFuzzFuzzer) *harness {
+	base := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	h := &harness{
+		Source: source,
+		Name:   invalidTargetNameChars.ReplaceAllString(base, "_"),
+	}
+
+	for _, fuzzer := range fuzzers {
+		if sliceutil.Contains(fuzzer.Sources, source) {
+			h.Name = fuzzer.Name
+			h.SeedCorpus = fuzzer.SeedCorpus
+			h.Dict = fuzzer.Dict
+			break
+		}
+	}
+
+	dir := filepath.Dir(source)
+	if h.SeedCorpus == "" {
+		h.SeedCorpus = firstExisting(
+			filepath.Join(dir, base+"_seed_corpus"),
+			filepath.Join(dir, base+"_seed_corpus.zip"),
+			filepath.Join(dir, base+"_corpus"),
+			filepath.Join(dir, "corpus", base),
+		)
+	}
+	if h.Dict == "" {
+		h.Dict = firstExisting(filepath.Join(dir, base+".dict"))
+	}
+	return h
+}
+
+func firstExisting(paths ...string) string {
+	for _, path := range paths {
+		exists, _ := fileutil.Exists(path)
+		if exists {
+			return path
+		}
+	}
+	return ""
+}
+
+// parseBuildScript returns the fuzzers which the OSS-Fuzz build script
+// builds, with the seed corpora and dictionaries it copies to $OUT.
+// The script isn't executed, so only the compiler, zip and cp commands
+// with paths which don't depend on other variables than $SRC and $OUT
+// are taken into account. Paths are resolved against the project
+// directory, which is the working directory of the build script.
+func parseBuildScript(content, projectDir string) []*ossFuzzFuzzer {
+	var fuzzers []*ossFuzzFuzzer
+	fuzzer := func(name string) *ossFuzzFuzzer {
+		for _, f := range fuzzers {
+			if f.Name == name {
+				return f
+			}
+		}
+		f := &ossFuzzFuzzer{Name: name}
+		fuzzers = append(fuzzers, f)
+		return f
+	}
+
+	// Join continued lines
+	content = strings.ReplaceAll(content, "\\\n", " ")
+	for _, line := range strings.Split(content, "\n") {
+		line, _, _ = strings.Cut(line, " #")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		for i, field := range fields {
+			fields[i] = strings.Trim(field, `"'`)
+		}
+
+		switch filepath.Base(fields[0]) {
+		case "zip", "cp":
+			// The target is the $OUT file, the source the first
+			// argument which is not an option
+			var target, source string
+			for _, arg := range fields[1:] {
+				if strings.HasPrefix(arg, "-") {
+					continue
+				}
+				if m := outFileRegex.FindStringSubmatch(arg); m != nil {
+					target = m[1]
+				} else if source == "" {
+					source = arg
+				}
+			}
+			source = resolveScriptPath(source, projectDir)
+			if source == "" || strings.Contains(target, "$") {
+				continue
+			}
+			if target == "" {
+				// The file is copied to $OUT without being renamed
+				target = filepath.Base(source)
+			}
+			if name, ok := strings.CutSuffix(target, "_seed_corpus.zip"); ok {
+				fuzzer(name).SeedCorpus = strings.TrimSuffix(source, string(filepath.Separator)+"*")
+			} else if name, ok := strings.CutSuffix(target, ".dict"); ok {
+				fuzzer(name).Dict = source
+			}
+		default:
+			// A compiler command which links the fuzzer
+			var name string
+			var sources []string
+			for i, arg := range fields {
+				if arg == "-o" && i+1 < len(fields) {
+					if m := outFileRegex.FindStringSubmatch(fields[i+1]); m != nil {
+						name = m[1]
+					}
+				} else if isSourceFile(arg) {
+					if source := resolveScriptPath(arg, projectDir); source != "" {
+						sources = append(sources, source)
+					}
+				}
+			}
+			if name != "" && !strings.ContainsAny(name, "/$") && len(sources) > 0 {
+				f := fuzzer(name)
+				f.Sources = append(f.Sources, sources...)
+			}
+		}
+	}
+	return fuzzers
+}
+
+// resolveScriptPath returns the absolute path of a path in an OSS-Fuzz
+// build script, which is either relative to the project directory or
+// below $SRC/<project>, or an empty string if the path depends on other
+// variables
+func resolveScriptPath(path, projectDir string) string {
+	for _, src := range []string{"$SRC/", "${SRC}/"} {
+		if rest, ok := strings.CutPrefix(path, src); ok {
+			_, path, ok = strings.Cut(rest, "/")
+			if !ok {
+				return ""
+			}
+			break
+		}
+	}
+	if path == "" || strings.Contains(path, "$") || filepath.IsAbs(path) {
+		return ""
+	}
+	return filepath.Join(projectDir, filepath.FromSlash(path))
+}
@@ -0,0 +1,471 @@
+package importcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/messaging"
+	"code-intelligence.com/cifuzz/util/archiveutil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// The paths of OSS-Fuzz and ClusterFuzzLite build scripts relative to
+// the project directory, which are used if no build script is specified
+var defaultBuildScripts = []string{
+	"build.sh",
+	filepath.Join(".clusterfuzzlite", "build.sh"),
+	filepath.Join("oss-fuzz", "build.sh"),
+	filepath.Join("fuzz", "build.sh"),
+}
+
+type options struct {
+	BuildSystem     string                   `mapstructure:"build-system"`
+	ProjectDir      string                   `mapstructure:"project-dir"`
+	ConfigDir       string                   `mapstructure:"config-dir"`
+	FuzzTestConfigs []*config.FuzzTestConfig `mapstructure:"fuzz-tests"`
+
+	BuildScripts []string
+	DryRun       bool
+
+	dirs []string
+}
+
+func (opts *options) validate() error {
+	if opts.BuildSystem != config.BuildSystemCMake {
+		return errors.New(config.NotSupportedErrorMessage("import", opts.BuildSystem))
+	}
+
+	var err error
+	opts.ProjectDir, err = filepath.Abs(opts.ProjectDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, buildScript := range opts.BuildScripts {
+		exists, err := fileutil.Exists(buildScript)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			msg := fmt.Sprintf("The build script %s does not exist", buildScript)
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+	}
+
+	for i, dir := range opts.dirs {
+		if !fileutil.IsDir(dir) {
+			msg := fmt.Sprintf("%s is not a directory", dir)
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+		opts.dirs[i], err = filepath.Abs(dir)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if len(opts.dirs) == 0 {
+		opts.dirs = []string{opts.ProjectDir}
+	}
+
+	return nil
+}
+
+type importCmd struct {
+	*cobra.Command
+	opts *options
+
+	// The new content of the files which are changed, by path
+	changedFiles map[string]string
+	// The original content of the changed files
+	originalFiles map[string]string
+	// The seed corpora which are extracted, by zip file
+	extractedSeedCorpora map[string]string
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "import [flags] [<dir>...]",
+		Short: "Import existing libFuzzer harnesses as fuzz tests",
+		Long: `This command turns existing libFuzzer harnesses, which define the
+LLVMFuzzerTestOneInput function, into cifuzz fuzz tests, so that they
+can be run with 'cifuzz run', for example harnesses which were written
+for OSS-Fuzz. Only CMake projects are supported.
+
+The specified directories (by default the project directory) are
+searched for C/C++ source files which define LLVMFuzzerTestOneInput.
+For each harness, an add_fuzz_test(...) call is added to the closest
+CMakeLists.txt. Harnesses which were already imported are skipped.
+
+If the project has an OSS-Fuzz or ClusterFuzzLite build script, the
+fuzz tests get the names of the fuzzers it builds and the seed corpora
+and dictionaries it copies to $OUT. Otherwise, seed corpora and
+dictionaries next to the harness which follow the naming conventions
+of OSS-Fuzz are used:
+
+    <harness>_seed_corpus/ or <harness>_seed_corpus.zip
+    <harness>.dict
+
+Zipped seed corpora are extracted to the default seed corpus directory
+of the fuzz test. Other seed corpus directories and dictionaries are
+added to the "fuzz-tests" section of the cifuzz.yaml.
+
+The build scripts are not executed, so the fuzz tests still have to be
+linked against the libraries they test via target_link_libraries(...).
+
+Use --dry-run to only print the changes without applying them.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			opts.dirs = args
+			return opts.validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := importCmd{
+				Command:              c,
+				opts:                 opts,
+				changedFiles:         map[string]string{},
+				originalFiles:        map[string]string{},
+				extractedSeedCorpora: map[string]string{},
+			}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via viper as well (i.e.
+	//       via cifuzz.yaml and CIFUZZ_* environment variables), bind
+	//       it to viper in the PreRun function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+	)
+	cmd.Flags().StringArrayVar(&opts.BuildScripts, "build-script", nil,
+		"An OSS-Fuzz build script which builds the harnesses.\n"+
+			"Defaults to the build.sh in the project directory or in the\n"+
+			".clusterfuzzlite, oss-fuzz or fuzz directory, if it exists.\n"+
+			"This flag can be used multiple times.")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Only print the changes without applying them")
+
+	return cmd
+}
+
+func (c *importCmd) run() error {
+	fuzzers, err := c.parseBuildScripts()
+	if err != nil {
+		return err
+	}
+
+	var sources []string
+	for _, dir := range c.opts.dirs {
+		found, err := findHarnesses(dir)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, found...)
+	}
+	if len(sources) == 0 {
+		log.Info("No libFuzzer harnesses found")
+		return nil
+	}
+
+	var newConfigs []*config.FuzzTestConfig
+	var imported []string
+	names := map[string]string{}
+	for _, source := range sources {
+		h := newHarness(source, fuzzers)
+		relSource := fileutil.ProjectRelativePath(c.opts.ProjectDir, h.Source)
+		if other, ok := names[h.Name]; ok {
+			log.Warnf("Not importing %s as fuzz test %s, because %s has the same name", relSource, h.Name, other)
+			continue
+		}
+		names[h.Name] = relSource
+
+		fuzzTestConfig, err := c.importHarness(h)
+		if err != nil {
+			return err
+		}
+		if fuzzTestConfig == nil {
+			continue
+		}
+		if fuzzTestConfig.SeedCorpusDirs != nil || fuzzTestConfig.Dict != "" {
+			newConfigs = append(newConfigs, fuzzTestConfig)
+		}
+		imported = append(imported, h.Name)
+	}
+
+	if len(newConfigs) > 0 {
+		err = c.addFuzzTestConfigs(newConfigs)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(imported) == 0 {
+		log.Success("All libFuzzer harnesses are already imported")
+		return nil
+	}
+
+	err = c.applyChanges()
+	if err != nil {
+		return err
+	}
+	if c.opts.DryRun {
+		return nil
+	}
+
+	rootCMakeLists, err := os.ReadFile(filepath.Join(c.opts.ProjectDir, "CMakeLists.txt"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !strings.Contains(string(rootCMakeLists), "enable_fuzz_testing") {
+		log.Print(messaging.Instructions(config.BuildSystemCMake))
+	}
+	log.Printf(`
+Link the fuzz tests against the libraries they test, like in the build
+script which built the harnesses before:
+
+    target_link_libraries(%s PRIVATE <library>)
+
+Then run them with:
+
+    cifuzz run %s
+`, imported[0], imported[0])
+
+	return nil
+}
+
+// parseBuildScripts returns the fuzzers which are built by the
+// specified build scripts or, if none were specified, by the default
+// build scripts which exist
+func (c *importCmd) parseBuildScripts() ([]*ossFuzzFuzzer, error) {
+	buildScripts := c.opts.BuildScripts
+	if len(buildScripts) == 0 {
+		for _, buildScript := range defaultBuildScripts {
+			path := filepath.Join(c.opts.ProjectDir, buildScript)
+			exists, err := fileutil.Exists(path)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				buildScripts = append(buildScripts, path)
+			}
+		}
+	}
+
+	var fuzzers []*ossFuzzFuzzer
+	for _, buildScript := range buildScripts {
+		content, err := os.ReadFile(buildScript)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		found := parseBuildScript(string(content), c.opts.ProjectDir)
+		log.Debugf("Found %d fuzzers in %s", len(found), buildScript)
+		fuzzers = append(fuzzers, found...)
+	}
+	return fuzzers, nil
+}
+
+// importHarness adds a CMake target for the harness and returns the
+// fuzz test config with its seed corpus and dictionary, or nil if the
+// harness was already imported
+func (c *importCmd) importHarness(h *harness) (*config.FuzzTestConfig, error) {
+	relSource := fileutil.ProjectRelativePath(c.opts.ProjectDir, h.Source)
+	cmakeDir, err := c.findCMakeDir(filepath.Dir(h.Source))
+	if err != nil {
+		return nil, err
+	}
+	cmakeLists := filepath.Join(cmakeDir, "CMakeLists.txt")
+	content, err := c.readFile(cmakeLists)
+	if err != nil {
+		return nil, err
+	}
+	if targetRegex("add_fuzz_test", h.Name).MatchString(content) {
+		log.Debugf("Skipping %s, because fuzz test %s already exists", relSource, h.Name)
+		return nil, nil
+	}
+	// Harnesses which are already built by CMake are usually built by
+	// a target with the same name, which we don't want to replace
+	if targetRegex("add_executable", h.Name).MatchString(content) {
+		h.Name += "_cifuzz"
+		if targetRegex("add_fuzz_test", h.Name).MatchString(content) {
+			log.Debugf("Skipping %s, because fuzz test %s already exists", relSource, h.Name)
+			return nil, nil
+		}
+	}
+	if config.FindFuzzTestConfig(c.opts.FuzzTestConfigs, h.Name) != nil {
+		log.Warnf("Not importing %s as fuzz test %s, because the cifuzz.yaml already has settings for it", relSource, h.Name)
+		return nil, nil
+	}
+
+	cmakeSource, err := filepath.Rel(cmakeDir, h.Source)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	c.changedFiles[cmakeLists] = ensureTrailingNewline(content) + fmt.Sprintf("add_fuzz_test(%s %s)\n", h.Name, filepath.ToSlash(cmakeSource))
+	if c.opts.DryRun {
+		log.Infof("Would import %s as fuzz test %s", relSource, h.Name)
+	} else {
+		log.Successf("Imported %s as fuzz test %s", relSource, h.Name)
+	}
+
+	// The default seed corpus and dictionary of the fuzz test, which are
+	// used without being configured
+	defaultSeedCorpus := filepath.Join(cmakeDir, h.Name+"_inputs")
+	defaultDict := filepath.Join(cmakeDir, h.Name+".dict")
+
+	fuzzTestConfig := &config.FuzzTestConfig{Name: h.Name}
+	switch {
+	case h.SeedCorpus == "", h.SeedCorpus == defaultSeedCorpus:
+	case strings.HasSuffix(h.SeedCorpus, ".zip"):
+		exists, err := fileutil.Exists(defaultSeedCorpus)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			log.Warnf("Not extracting %s, because %s already exists",
+				fileutil.ProjectRelativePath(c.opts.ProjectDir, h.SeedCorpus),
+				fileutil.ProjectRelativePath(c.opts.ProjectDir, defaultSeedCorpus))
+		} else {
+			c.extractedSeedCorpora[h.SeedCorpus] = defaultSeedCorpus
+		}
+	default:
+		fuzzTestConfig.SeedCorpusDirs = []string{fileutil.ProjectRelativePath(c.opts.ProjectDir, h.SeedCorpus)}
+	}
+	if h.Dict != "" && h.Dict != defaultDict {
+		fuzzTestConfig.Dict = fileutil.ProjectRelativePath(c.opts.ProjectDir, h.Dict)
+	}
+	return fuzzTestConfig, nil
+}
+
+// findCMakeDir returns the closest directory, starting with dir, which
+// contains a CMakeLists.txt and is below the project directory
+func (c *importCmd) findCMakeDir(dir string) (string, error) {
+	for {
+		exists, err := fileutil.Exists(filepath.Join(dir, "CMakeLists.txt"))
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return dir, nil
+		}
+		if dir == c.opts.ProjectDir || dir == filepath.Dir(dir) {
+			return "", errors.Errorf("No CMakeLists.txt found in %s or its parent directories", dir)
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func (c *importCmd) addFuzzTestConfigs(configs []*config.FuzzTestConfig) error {
+	configDir := c.opts.ConfigDir
+	if configDir == "" {
+		var err error
+		configDir, err = config.FindConfigDir()
+		if err != nil {
+			return err
+		}
+	}
+	configPath := filepath.Join(configDir, config.ProjectConfigFile)
+	content, err := c.readFile(configPath)
+	if err != nil {
+		return err
+	}
+	c.changedFiles[configPath] = config.AddFuzzTestConfigs(content, configs)
+	return nil
+}
+
+// readFile returns the content of the file including the changes made
+// so far
+func (c *importCmd) readFile(path string) (string, error) {
+	if content, ok := c.changedFiles[path]; ok {
+		return content, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	c.originalFiles[path] = string(content)
+	return string(content), nil
+}
+
+// applyChanges writes the changed files and extracts the seed corpora
+// or, with --dry-run, prints the changes
+func (c *importCmd) applyChanges() error {
+	paths := make([]string, 0, len(c.changedFiles))
+	for path := range c.changedFiles {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		relPath := fileutil.ProjectRelativePath(c.opts.ProjectDir, path)
+		if c.opts.DryRun {
+			diff, err := config.UnifiedDiff(relPath, c.originalFiles[path], c.changedFiles[path])
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprint(c.OutOrStdout(), diff)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = os.WriteFile(path, []byte(c.changedFiles[path]), info.Mode())
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		log.Debugf("Updated %s", relPath)
+	}
+
+	for zipFile, dir := range c.extractedSeedCorpora {
+		relZipFile := fileutil.ProjectRelativePath(c.opts.ProjectDir, zipFile)
+		relDir := fileutil.ProjectRelativePath(c.opts.ProjectDir, dir)
+		if c.opts.DryRun {
+			log.Infof("Would extract %s to %s", relZipFile, relDir)
+			continue
+		}
+		err := archiveutil.Unzip(zipFile, dir)
+		if err != nil {
+			return err
+		}
+		log.Infof("Extracted %s to %s", relZipFile, relDir)
+	}
+	return nil
+}
+
+// targetRegex matches a call of the CMake command which creates a
+// target with the given name
+func targetRegex(command, name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^[ \t]*` + command + `[ \t]*\([ \t\n]*"?` + regexp.QuoteMeta(name) + `"?[ \t\n)]`)
+}
+
+func ensureTrailingNewline(s string) string {
+	if s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
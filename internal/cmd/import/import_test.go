@@ -0,0 +1,107 @@
+package importcmd
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+const harnessSource = `#include <stddef.h>
+#include <stdint.h>
+
+extern "C" int LLVMFuzzerTestOneInput(const uint8_t *data, size_t size) {
+  return 0;
+}
+`
+
+func TestParseBuildScript(t *testing.T) {
+	projectDir := filepath.FromSlash("/src/project")
+	fuzzers := parseBuildScript(`#!/bin/bash -eu
+$CXX $CXXFLAGS -Iinclude fuzz/parse.cc \
+    -o $OUT/parse_fuzzer $LIB_FUZZING_ENGINE libparser.a
+zip -j $OUT/parse_fuzzer_seed_corpus.zip $SRC/project/fuzz/corpus/*
+cp fuzz/parser.dict $OUT/parse_fuzzer.dict
+cp fuzz/other_fuzzer.dict $OUT/
+$CXX $CXXFLAGS $SRC/other/fuzz.cc -o $OUT/$FUZZER
+`, projectDir)
+
+	require.Len(t, fuzzers, 2)
+	assert.Equal(t, &ossFuzzFuzzer{
+		Name:       "parse_fuzzer",
+		Sources:    []string{filepath.Join(projectDir, "fuzz", "parse.cc")},
+		SeedCorpus: filepath.Join(projectDir, "fuzz", "corpus"),
+		Dict:       filepath.Join(projectDir, "fuzz", "parser.dict"),
+	}, fuzzers[0])
+	assert.Equal(t, &ossFuzzFuzzer{
+		Name: "other_fuzzer",
+		Dict: filepath.Join(projectDir, "fuzz", "other_fuzzer.dict"),
+	}, fuzzers[1])
+}
+
+func TestImport(t *testing.T) {
+	projectDir := testutil.BootstrapExampleProjectForTest(t, "import-cmd-test", config.BuildSystemCMake)
+
+	// A harness which is built by an OSS-Fuzz build script with a seed
+	// corpus outside of the default seed corpus directory
+	fuzzDir := filepath.Join(projectDir, "fuzz")
+	require.NoError(t, os.MkdirAll(filepath.Join(fuzzDir, "corpus"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(fuzzDir, "parse.cc"), []byte(harnessSource), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "build.sh"), []byte(`
+$CXX $CXXFLAGS fuzz/parse.cc -o $OUT/parse_fuzzer $LIB_FUZZING_ENGINE
+zip -j $OUT/parse_fuzzer_seed_corpus.zip fuzz/corpus/*
+`), 0o755))
+
+	// A harness in a directory with its own CMakeLists.txt and a zipped
+	// seed corpus next to it
+	srcDir := filepath.Join(projectDir, "src")
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "explore_fuzzer.cpp"), []byte(harnessSource), 0o644))
+	zipFile, err := os.Create(filepath.Join(srcDir, "explore_fuzzer_seed_corpus.zip"))
+	require.NoError(t, err)
+	zipWriter := zip.NewWriter(zipFile)
+	_, err = zipWriter.Create("seed")
+	require.NoError(t, err)
+	require.NoError(t, zipWriter.Close())
+	require.NoError(t, zipFile.Close())
+
+	// The dry run doesn't change anything
+	rootCMakeLists, err := os.ReadFile(filepath.Join(projectDir, "CMakeLists.txt"))
+	require.NoError(t, err)
+	_, _, err = cmdutils.ExecuteCommand(t, New(), os.Stdin, "--dry-run")
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(projectDir, "CMakeLists.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, string(rootCMakeLists), string(content))
+
+	_, _, err = cmdutils.ExecuteCommand(t, New(), os.Stdin)
+	require.NoError(t, err)
+
+	content, err = os.ReadFile(filepath.Join(projectDir, "CMakeLists.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "add_fuzz_test(parse_fuzzer fuzz/parse.cc)\n")
+	content, err = os.ReadFile(filepath.Join(srcDir, "CMakeLists.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "add_fuzz_test(explore_fuzzer explore_fuzzer.cpp)\n")
+	assert.FileExists(t, filepath.Join(srcDir, "explore_fuzzer_inputs", "seed"))
+
+	opts := &options{}
+	require.NoError(t, config.ParseProjectConfig(projectDir, opts))
+	fuzzTestConfig := config.FindFuzzTestConfig(opts.FuzzTestConfigs, "parse_fuzzer")
+	require.NotNil(t, fuzzTestConfig)
+	assert.Equal(t, []string{"fuzz/corpus"}, fuzzTestConfig.SeedCorpusDirs)
+	assert.Nil(t, config.FindFuzzTestConfig(opts.FuzzTestConfigs, "explore_fuzzer"))
+
+	// Importing the harnesses again doesn't change anything
+	_, _, err = cmdutils.ExecuteCommand(t, New(), os.Stdin)
+	require.NoError(t, err)
+	contentAgain, err := os.ReadFile(filepath.Join(srcDir, "CMakeLists.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, string(content), string(contentAgain))
+}
@@ -0,0 +1,370 @@
+package init
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/dependencies"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/messaging"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// The suffix of the backups of the build files which are edited by
+// --apply
+const backupSuffix = ".cifuzz.bak"
+
+var (
+	cmakeEnableFuzzTestingRegex      = regexp.MustCompile(`(?mi)^[ \t]*enable_fuzz_testing[ \t]*\(`)
+	cmakeProjectRegex                = regexp.MustCompile(`(?msi)^[ \t]*project[ \t]*\(.*?\)`)
+	cmakeMinimumRequiredRegex        = regexp.MustCompile(`(?msi)^[ \t]*cmake_minimum_required[ \t]*\(.*?\)`)
+	bazelCIFuzzRepositoryRegex       = regexp.MustCompile(`name\s*=\s*"cifuzz"`)
+	bazelRulesFuzzingRepositoryRegex = regexp.MustCompile(`name\s*=\s*"rules_fuzzing"`)
+	gradlePluginsBlockRegex          = regexp.MustCompile(`(?m)^plugins[ \t]*\{`)
+	gradleDependenciesBlockRegex     = regexp.MustCompile(`(?m)^dependencies[ \t]*\{`)
+	gradleDependencyRegex            = regexp.MustCompile(`["']([^"':]+:[^"':]+)[^"']*["']`)
+	jsonObjectRegex                  = regexp.MustCompile(`"devDependencies"\s*:\s*\{`)
+	jsonIndentRegex                  = regexp.MustCompile(`\n([ \t]+)"`)
+)
+
+// applyBuildSystemIntegration edits the build files in dir to set up
+// the build system integration of cifuzz, instead of printing the
+// instructions to do so. Files which are already set up are not
+// changed and a backup is created of the other files.
+func applyBuildSystemIntegration(dir string, buildSystem string, testLang string) error {
+	switch buildSystem {
+	case config.BuildSystemCMake:
+		return editBuildFile(filepath.Join(dir, "CMakeLists.txt"), false, patchCMakeLists)
+	case config.BuildSystemBazel:
+		workspace := filepath.Join(dir, "WORKSPACE.bazel")
+		if exists, _ := fileutil.Exists(workspace); !exists {
+			workspace = filepath.Join(dir, "WORKSPACE")
+		}
+		return editBuildFile(workspace, false, patchWORKSPACE)
+	case config.BuildSystemMaven:
+		err := editBuildFile(filepath.Join(dir, ".mvn", "extensions.xml"), true, patchMavenExtensions)
+		if err != nil {
+			return err
+		}
+		log.Print(`
+In a multi-project, mark the project that contains the fuzz tests by
+setting the cifuzz.fuzztests property in its pom.xml.`)
+		return nil
+	case config.BuildSystemGradle:
+		gradleBuildLanguage, err := config.DetermineGradleBuildLanguage(dir)
+		if err != nil {
+			return err
+		}
+		isGradleMultiProject, err := config.IsGradleMultiProject(dir)
+		if err != nil {
+			return err
+		}
+		if isGradleMultiProject {
+			log.Warn(GradleMultiProjectWarningMsg)
+		}
+		buildFile := "build.gradle"
+		if gradleBuildLanguage == config.GradleKotlin {
+			buildFile = "build.gradle.kts"
+		}
+		return editBuildFile(filepath.Join(dir, buildFile), false, func(content string) (string, error) {
+			return patchGradleBuild(content, gradleBuildLanguage)
+		})
+	case config.BuildSystemNodeJS:
+		if testLang == "" {
+			var err error
+			testLang, err = getNodeProjectLang()
+			if err != nil {
+				return err
+			}
+		}
+		instructions := messaging.Instructions(config.BuildSystemNodeJS)
+		if testLang == "ts" {
+			instructions = messaging.Instructions("nodets")
+		}
+		err := editBuildFile(filepath.Join(dir, "package.json"), false, func(content string) (string, error) {
+			return patchPackageJSON(content, instructions)
+		})
+		if err != nil {
+			return err
+		}
+		log.Print(`
+Run 'npm install' or 'yarn install' to install the added dependencies.`)
+		// The Jest config is not edited, because it's code
+		if i := strings.Index(instructions, "To integrate with your existing jest setup"); i != -1 {
+			log.Print("\n" + instructions[i:])
+		}
+		return nil
+	default:
+		// There are no build files to edit
+		setUpAndMentionBuildSystemIntegrations(dir, buildSystem, testLang)
+		return nil
+	}
+}
+
+// editBuildFile changes the content of the build file via patch. If the
+// content is changed, the original file is backed up, unless a backup
+// already exists. If create is true, the file is created if it doesn't
+// exist.
+func editBuildFile(path string, create bool, patch func(content string) (string, error)) error {
+	prettyPath := fileutil.PrettifyPath(path)
+	exists, err := fileutil.Exists(path)
+	if err != nil {
+		return err
+	}
+	if !exists && !create {
+		return errors.Errorf("Can't set up the cifuzz integration, %s doesn't exist", prettyPath)
+	}
+
+	var content []byte
+	mode := os.FileMode(0o644)
+	if exists {
+		content, err = os.ReadFile(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		mode = info.Mode().Perm()
+	}
+
+	updated, err := patch(string(content))
+	if err != nil {
+		return errors.WithMessagef(err, "Failed to edit %s, please set up the cifuzz integration manually", prettyPath)
+	}
+	if updated == string(content) {
+		log.Infof("%s is already set up for cifuzz", prettyPath)
+		return nil
+	}
+
+	if exists {
+		backup := path + backupSuffix
+		backupExists, err := fileutil.Exists(backup)
+		if err != nil {
+			return err
+		}
+		// Keep the backup of the original file
+		if !backupExists {
+			err = os.WriteFile(backup, content, mode)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	} else {
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	err = os.WriteFile(path, []byte(updated), mode)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if exists {
+		log.Successf("Set up cifuzz in %s (backup: %s)", prettyPath, fileutil.PrettifyPath(path+backupSuffix))
+	} else {
+		log.Successf("Created %s", prettyPath)
+	}
+	return nil
+}
+
+// patchCMakeLists adds the commands which enable fuzz testing after the
+// project() call, because they have to be called before any targets are
+// defined
+func patchCMakeLists(content string) (string, error) {
+	if cmakeEnableFuzzTestingRegex.MatchString(content) {
+		return content, nil
+	}
+	snippet := strings.TrimSuffix(instructionsSnippet(messaging.Instructions(config.BuildSystemCMake), 0), "\n")
+
+	loc := cmakeProjectRegex.FindStringIndex(content)
+	if loc == nil {
+		loc = cmakeMinimumRequiredRegex.FindStringIndex(content)
+	}
+	if loc == nil {
+		return snippet + "\n\n" + content, nil
+	}
+	return content[:loc[1]] + "\n\n" + snippet + content[loc[1]:], nil
+}
+
+// patchWORKSPACE appends the repositories of rules_fuzzing and of the
+// cifuzz Bazel rules
+func patchWORKSPACE(content string) (string, error) {
+	if bazelCIFuzzRepositoryRegex.MatchString(content) {
+		return content, nil
+	}
+	if bazelRulesFuzzingRepositoryRegex.MatchString(content) {
+		return "", errors.New("the WORKSPACE already defines the rules_fuzzing repository")
+	}
+	instructions := fmt.Sprintf(messaging.Instructions(config.BuildSystemBazel),
+		dependencies.RulesFuzzingWORKSPACEContent, dependencies.CIFuzzBazelCommit)
+	return appendSnippet(content, instructionsSnippet(instructions, 0)), nil
+}
+
+// patchMavenExtensions adds the cifuzz Maven extension to the content
+// of the .mvn/extensions.xml file
+func patchMavenExtensions(content string) (string, error) {
+	if strings.Contains(content, "cifuzz-maven-extension") {
+		return content, nil
+	}
+	snippet := instructionsSnippet(messaging.Instructions(config.BuildSystemMaven), 0)
+	if strings.TrimSpace(content) == "" {
+		return snippet, nil
+	}
+
+	end := strings.LastIndex(content, "</extensions>")
+	if end == -1 {
+		return "", errors.New("the <extensions> element is missing")
+	}
+	// The <extension> element of the snippet
+	start := strings.Index(snippet, "  <extension>")
+	extension := snippet[start : strings.LastIndex(snippet, "</extension>")+len("</extension>\n")]
+	lineStart := strings.LastIndex(content[:end], "\n") + 1
+	if strings.TrimSpace(content[lineStart:end]) != "" {
+		return content[:end] + "\n" + extension + content[end:], nil
+	}
+	return content[:lineStart] + extension + content[lineStart:], nil
+}
+
+// patchGradleBuild adds the cifuzz Gradle plugin, the JUnit and Jazzer
+// dependencies and the JUnit platform to the build file
+func patchGradleBuild(content string, language config.GradleBuildLanguage) (string, error) {
+	instructions := messaging.Instructions(string(language))
+	plugin := strings.TrimSpace(instructionsSnippet(instructions, 0))
+	dependenciesBlock, testBlock, _ := strings.Cut(instructionsSnippet(instructions, 1), "\n\n")
+
+	if !strings.Contains(content, "com.code-intelligence.cifuzz") {
+		loc := gradlePluginsBlockRegex.FindStringIndex(content)
+		switch {
+		case loc != nil:
+			content = insertLines(content, loc[1], []string{"    " + plugin})
+		case strings.Contains(content, "buildscript"):
+			// The plugins block must follow the buildscript block
+			return "", errors.New("the plugins block is missing")
+		default:
+			content = "plugins {\n    " + plugin + "\n}\n\n" + content
+		}
+	}
+
+	var missingDependencies []string
+	for _, line := range strings.Split(dependenciesBlock, "\n") {
+		m := gradleDependencyRegex.FindStringSubmatch(line)
+		if m != nil && !strings.Contains(content, m[1]) {
+			missingDependencies = append(missingDependencies, line)
+		}
+	}
+	if len(missingDependencies) > 0 {
+		if loc := gradleDependenciesBlockRegex.FindStringIndex(content); loc != nil {
+			content = insertLines(content, loc[1], missingDependencies)
+		} else {
+			content = appendSnippet(content, "dependencies {\n"+strings.Join(missingDependencies, "\n")+"\n}\n")
+		}
+	}
+
+	if !strings.Contains(content, "useJUnitPlatform") {
+		content = appendSnippet(content, testBlock)
+	}
+	return content, nil
+}
+
+// patchPackageJSON adds the packages which the installation command in
+// the instructions installs to the devDependencies of the package.json.
+// The content is edited as text to keep its formatting.
+func patchPackageJSON(content string, instructions string) (string, error) {
+	var entries []string
+	for _, line := range strings.Split(instructionsSnippet(instructions, 0), "\n") {
+		args, ok := strings.CutPrefix(line, "npm install --save-dev ")
+		if !ok {
+			continue
+		}
+		for _, pkg := range strings.Fields(args) {
+			name, version := pkg, "latest"
+			if i := strings.LastIndex(pkg, "@"); i > 0 {
+				name, version = pkg[:i], pkg[i+1:]
+			}
+			if !strings.Contains(content, fmt.Sprintf("%q", name)) {
+				entries = append(entries, fmt.Sprintf("%q: %q", name, version))
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return content, nil
+	}
+
+	indent := "  "
+	if m := jsonIndentRegex.FindStringSubmatch(content); m != nil {
+		indent = m[1]
+	}
+
+	var updated string
+	if loc := jsonObjectRegex.FindStringIndex(content); loc != nil {
+		rest := content[loc[1]:]
+		if strings.HasPrefix(strings.TrimSpace(rest), "}") {
+			// The devDependencies are empty
+			rest = strings.TrimLeft(rest, " \t\r\n")
+			updated = content[:loc[1]] + "\n" + indent + indent + strings.Join(entries, ",\n"+indent+indent) + "\n" + indent + rest
+		} else {
+			updated = content[:loc[1]] + "\n" + indent + indent + strings.Join(entries, ",\n"+indent+indent) + "," + rest
+		}
+	} else {
+		end := strings.LastIndex(content, "}")
+		if end == -1 {
+			return "", errors.New("package.json doesn't contain a JSON object")
+		}
+		head := strings.TrimRight(content[:end], " \t\r\n")
+		separator := ","
+		if strings.HasSuffix(head, "{") {
+			separator = ""
+		}
+		updated = head + separator + "\n" + indent + `"devDependencies": {` + "\n" +
+			indent + indent + strings.Join(entries, ",\n"+indent+indent) + "\n" + indent + "}\n" + content[end:]
+	}
+
+	if !json.Valid([]byte(updated)) {
+		return "", errors.New("package.json is not valid JSON")
+	}
+	return updated, nil
+}
+
+// instructionsSnippet returns the snippet of the instructions with the
+// given index
+func instructionsSnippet(instructions string, index int) string {
+	blocks := messaging.CodeBlocks(instructions)
+	if index >= len(blocks) {
+		return ""
+	}
+	return blocks[index]
+}
+
+// insertLines inserts the lines after the opening brace of a block at
+// the given offset
+func insertLines(content string, offset int, lines []string) string {
+	insertion := "\n" + strings.Join(lines, "\n")
+	rest := content[offset:]
+	if !strings.HasPrefix(rest, "\n") && !strings.HasPrefix(rest, "\r\n") {
+		// The block is on a single line
+		insertion += "\n"
+	}
+	return content[:offset] + insertion + rest
+}
+
+// appendSnippet appends the snippet to the content, separated by an
+// empty line
+func appendSnippet(content string, snippet string) string {
+	if content == "" {
+		return snippet
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + "\n" + snippet
+}
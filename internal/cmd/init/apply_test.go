@@ -0,0 +1,165 @@
+package init
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/messaging"
+)
+
+func TestPatchCMakeLists(t *testing.T) {
+	content := `cmake_minimum_required(VERSION 3.16)
+project(example
+        LANGUAGES CXX)
+
+add_subdirectory(src)
+`
+	patched, err := patchCMakeLists(content)
+	require.NoError(t, err)
+	assert.Equal(t, `cmake_minimum_required(VERSION 3.16)
+project(example
+        LANGUAGES CXX)
+
+find_package(cifuzz NO_SYSTEM_ENVIRONMENT_PATH)
+enable_fuzz_testing()
+
+add_subdirectory(src)
+`, patched)
+
+	// The file is only edited once
+	patchedAgain, err := patchCMakeLists(patched)
+	require.NoError(t, err)
+	assert.Equal(t, patched, patchedAgain)
+}
+
+func TestPatchGradleBuild(t *testing.T) {
+	content := `plugins {
+    id 'java'
+}
+
+dependencies {
+    testImplementation 'org.junit.jupiter:junit-jupiter:5.9.0'
+}
+`
+	patched, err := patchGradleBuild(content, config.GradleGroovy)
+	require.NoError(t, err)
+	assert.Equal(t, `plugins {
+    id "com.code-intelligence.cifuzz" version "1.9.0"
+    id 'java'
+}
+
+dependencies {
+    testImplementation(platform("org.junit:junit-bom:5.10.0"))
+    testImplementation('com.code-intelligence:jazzer-junit:0.21.1')
+    testImplementation 'org.junit.jupiter:junit-jupiter:5.9.0'
+}
+
+tasks.test {
+    useJUnitPlatform()
+    testLogging {
+        events("passed", "skipped", "failed")
+    }
+}
+`, patched)
+
+	patchedAgain, err := patchGradleBuild(patched, config.GradleGroovy)
+	require.NoError(t, err)
+	assert.Equal(t, patched, patchedAgain)
+
+	// Build files without plugins block
+	patched, err = patchGradleBuild("", config.GradleKotlin)
+	require.NoError(t, err)
+	assert.Contains(t, patched, "plugins {\n    id(\"com.code-intelligence.cifuzz\") version \"1.9.0\"\n}\n")
+	assert.Contains(t, patched, "\ndependencies {\n    testImplementation(platform(\"org.junit:junit-bom:5.10.0\"))\n")
+
+	_, err = patchGradleBuild("buildscript {\n}\n", config.GradleGroovy)
+	assert.ErrorContains(t, err, "the plugins block is missing")
+}
+
+func TestPatchMavenExtensions(t *testing.T) {
+	patched, err := patchMavenExtensions("")
+	require.NoError(t, err)
+	assert.Contains(t, patched, "<artifactId>cifuzz-maven-extension</artifactId>")
+
+	content := `<extensions>
+  <extension>
+    <groupId>org.example</groupId>
+    <artifactId>other</artifactId>
+    <version>1.0</version>
+  </extension>
+</extensions>
+`
+	patched, err = patchMavenExtensions(content)
+	require.NoError(t, err)
+	assert.Contains(t, patched, "  </extension>\n  <extension>\n    <groupId>com.code-intelligence</groupId>")
+	assert.Contains(t, patched, "  </extension>\n</extensions>\n")
+
+	patchedAgain, err := patchMavenExtensions(patched)
+	require.NoError(t, err)
+	assert.Equal(t, patched, patchedAgain)
+}
+
+func TestPatchPackageJSON(t *testing.T) {
+	for name, content := range map[string]string{
+		"no devDependencies":    "{\n    \"name\": \"example\"\n}\n",
+		"empty devDependencies": "{\n    \"name\": \"example\",\n    \"devDependencies\": {}\n}\n",
+		"devDependencies":       "{\n    \"name\": \"example\",\n    \"devDependencies\": {\n        \"jest\": \"29.0.0\",\n        \"ts-node\": \"10.0.0\"\n    }\n}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			patched, err := patchPackageJSON(content, messaging.Instructions("nodets"))
+			require.NoError(t, err)
+			assert.Contains(t, patched, "\n        \"@jazzer.js/jest-runner\": \"2.1.0\"")
+
+			var packageJSON struct {
+				Name            string
+				DevDependencies map[string]string
+			}
+			require.NoError(t, json.Unmarshal([]byte(patched), &packageJSON))
+			assert.Equal(t, "example", packageJSON.Name)
+			assert.Equal(t, "2.1.0", packageJSON.DevDependencies["@jazzer.js/jest-runner"])
+			assert.Contains(t, packageJSON.DevDependencies, "ts-jest")
+			assert.Contains(t, packageJSON.DevDependencies, "ts-node")
+
+			patchedAgain, err := patchPackageJSON(patched, messaging.Instructions("nodets"))
+			require.NoError(t, err)
+			assert.Equal(t, patched, patchedAgain)
+		})
+	}
+}
+
+func TestInitCmdWithApply(t *testing.T) {
+	testDir := testutil.BootstrapExampleProjectForTest(t, "init-cmd-test", config.BuildSystemCMake)
+	err := os.Remove(filepath.Join(testDir, "cifuzz.yaml"))
+	require.NoError(t, err)
+	cmakeLists := filepath.Join(testDir, "CMakeLists.txt")
+	original := "cmake_minimum_required(VERSION 3.16)\nproject(example)\n"
+	err = os.WriteFile(cmakeLists, []byte(original), 0o644)
+	require.NoError(t, err)
+
+	_, stdErr, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--apply")
+	require.NoError(t, err)
+	assert.NotContains(t, stdErr, "Enable fuzz testing in your CMake project")
+	content, err := os.ReadFile(cmakeLists)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "\nenable_fuzz_testing()\n")
+	backup, err := os.ReadFile(cmakeLists + backupSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(backup))
+
+	// The build file isn't edited again
+	require.NoError(t, os.Remove(filepath.Join(testDir, "cifuzz.yaml")))
+	_, stdErr, err = cmdutils.ExecuteCommand(t, New(), os.Stdin, "--apply")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "CMakeLists.txt is already set up for cifuzz")
+	contentAgain, err := os.ReadFile(cmakeLists)
+	require.NoError(t, err)
+	assert.Equal(t, string(content), string(contentAgain))
+}
@@ -0,0 +1,63 @@
+package init
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/ossfuzz"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// createClusterFuzzLiteConfig creates the Dockerfile, the build script
+// and the project.yaml of ClusterFuzzLite and a GitHub workflow which
+// runs ClusterFuzzLite on pull requests. Existing files are not
+// overwritten.
+func createClusterFuzzLiteConfig(dir string, buildSystem string) error {
+	files, err := ossfuzz.Files(&ossfuzz.Options{
+		BuildSystem:     buildSystem,
+		Name:            ossfuzz.ProjectName(dir),
+		ClusterFuzzLite: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	var created int
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file.Path))
+		exists, err := fileutil.Exists(path)
+		if err != nil {
+			return err
+		}
+		if exists {
+			log.Warnf("Not creating %s, because it already exists", file.Path)
+			continue
+		}
+
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		mode := os.FileMode(0o644)
+		if file.Executable {
+			mode = 0o755
+		}
+		err = os.WriteFile(path, file.Content, mode)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		log.Successf("Created %s", file.Path)
+		created++
+	}
+
+	if created > 0 {
+		log.Print(`
+Commit the created files to run the fuzz tests with ClusterFuzzLite on
+pull requests. You can test the build locally with the helper script of
+OSS-Fuzz, see https://google.github.io/clusterfuzzlite/build-integration/#testing-locally`)
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package init
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -13,17 +15,24 @@ import (
 	"code-intelligence.com/cifuzz/internal/api"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/ossfuzz"
 	"code-intelligence.com/cifuzz/pkg/dependencies"
 	"code-intelligence.com/cifuzz/pkg/dialog"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/messaging"
 	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
 )
 
 const (
 	GradleMultiProjectWarningMsg = "For multi-project builds, you should setup cifuzz in the subprojects containing the fuzz tests."
 )
 
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
 type options struct {
 	Dir         string
 	BuildSystem string
@@ -31,6 +40,23 @@ type options struct {
 	Server      string `mapstructure:"server"`
 	Project     string `mapstructure:"project"`
 	testLang    string
+	// Create the configuration to run the fuzz tests under
+	// ClusterFuzzLite, see --clusterfuzzlite
+	ClusterFuzzLite bool
+	// Edit the build files instead of printing the instructions, see
+	// --apply
+	Apply bool
+	// The output format of the instructions, see --format
+	Format string
+
+	stdout io.Writer
+
+	// The build systems which were detected in the project directory
+	buildSystems []string
+	// The type of the fuzz tests and the directory in which
+	// `cifuzz create` creates them, as selected in the wizard
+	fuzzTestType string
+	fuzzTestDir  string
 }
 
 func New() *cobra.Command {
@@ -40,7 +66,34 @@ func New() *cobra.Command {
 		Use:   fmt.Sprintf("init [%s]", strings.Join(supportedInitTestTypes, "|")),
 		Short: "Set up a project for use with cifuzz",
 		Long: `This command sets up a project for use with cifuzz, creating a
-'cifuzz.yaml' config file.`,
+'cifuzz.yaml' config file.
+
+If the project uses multiple build systems, e.g. both CMake and Bazel,
+and the command runs in a terminal, a wizard asks which build system to
+set up, which type of fuzz tests to create and in which directory
+'cifuzz create' should create them. The answers are stored in the
+'cifuzz.yaml' config file. Outside of a terminal, the preferred build
+system is set up.
+
+By default, the command prints instructions to set up the build system
+integration. With --apply, it edits the build files instead: the
+top-level CMakeLists.txt, the WORKSPACE, .mvn/extensions.xml, the
+build.gradle(.kts) or the package.json. Files which are already set up
+are not changed and a backup with the suffix .cifuzz.bak is created of
+the edited files.
+
+With --format=json, the instructions are printed to stdout as JSON
+instead, with the file to change, the insertion point and the snippet
+of each step, so that they can be applied by IDE plugins and scripts.
+
+With --clusterfuzzlite, it also creates the files which are needed to
+run the fuzz tests of the project under ClusterFuzzLite: the Dockerfile,
+build script and project.yaml in the .clusterfuzzlite directory and a
+GitHub workflow which fuzzes pull requests. The build script builds the
+fuzz tests via the CMake integration or the Bazel rules of cifuzz. This
+is supported for CMake and Bazel projects. Existing files are not
+overwritten, so this can also be used in projects which were already set
+up for cifuzz.`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			// Bind viper keys to flags. We can't do this in the New
 			// function, because that would re-bind viper keys which
@@ -48,6 +101,14 @@ func New() *cobra.Command {
 			bindFlags()
 			opts.Interactive = viper.GetBool("interactive")
 
+			if opts.Format != formatText && opts.Format != formatJSON {
+				msg := fmt.Sprintf("Invalid format %q, valid formats are: %s, %s", opts.Format, formatText, formatJSON)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			if opts.Format == formatJSON && opts.Apply {
+				return cmdutils.WrapIncorrectUsageError(errors.New("--apply can't be used with --format=json"))
+			}
+
 			var err error
 			if opts.Dir == "" {
 				opts.Dir, err = os.Getwd()
@@ -60,6 +121,10 @@ func New() *cobra.Command {
 				opts.testLang = args[0]
 			}
 
+			if opts.Interactive {
+				opts.Interactive = term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+			}
+
 			// Override detected build system if test language is specified.
 			if opts.testLang != "" {
 				// cobra checks for us that opts.testLang is in supportedInitTestTypes
@@ -67,19 +132,36 @@ func New() *cobra.Command {
 				opts.BuildSystem = supportedInitTestTypesMap[opts.testLang]
 			} else {
 				// Detect and validate buildSystem only when testLang is not specified by the user.
-				opts.BuildSystem, err = config.DetermineBuildSystem(opts.Dir)
+				opts.buildSystems, err = detectBuildSystems(opts.Dir)
 				if err != nil {
 					return err
 				}
 
+				if len(opts.buildSystems) > 1 && opts.Interactive {
+					err = runWizard(opts)
+					if err != nil {
+						return err
+					}
+				} else {
+					opts.BuildSystem = opts.buildSystems[0]
+					if len(opts.buildSystems) > 1 {
+						log.Infof(`Detected multiple build systems (%s), setting up %s.
+Run 'cifuzz init' in a terminal to select the build system.`,
+							strings.Join(opts.buildSystems, ", "), opts.BuildSystem)
+					}
+				}
+
 				err = config.ValidateBuildSystem(opts.BuildSystem)
 				if err != nil {
 					return err
 				}
 			}
 
-			if opts.Interactive {
-				opts.Interactive = term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+			if opts.ClusterFuzzLite {
+				err = ossfuzz.ValidateBuildSystem(opts.BuildSystem)
+				if err != nil {
+					return cmdutils.WrapIncorrectUsageError(err)
+				}
 			}
 
 			if !opts.Interactive && opts.BuildSystem == config.BuildSystemNodeJS && opts.testLang == "" {
@@ -97,6 +179,7 @@ func New() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			opts.stdout = cmd.OutOrStdout()
 			return run(opts)
 		},
 		Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
@@ -113,12 +196,36 @@ func New() *cobra.Command {
 		cmdutils.AddProjectFlag,
 		cmdutils.AddServerFlag,
 	)
+	cmd.Flags().BoolVar(&opts.ClusterFuzzLite, "clusterfuzzlite", false,
+		"Also create the files to run the fuzz tests under ClusterFuzzLite on GitHub pull requests.")
+	cmd.Flags().BoolVar(&opts.Apply, "apply", false,
+		"Edit the build files to set up the build system integration instead of printing instructions.")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", formatText,
+		fmt.Sprintf("Output format of the instructions (%s/%s).", formatText, formatJSON))
 
 	return cmd
 }
 
 func run(opts *options) error {
-	setUpAndMentionBuildSystemIntegrations(opts.Dir, opts.BuildSystem, opts.testLang)
+	if opts.ClusterFuzzLite {
+		exists, err := fileutil.Exists(filepath.Join(opts.Dir, config.ProjectConfigFile))
+		if err != nil {
+			return err
+		}
+		if exists {
+			// The project is already set up for cifuzz
+			return createClusterFuzzLiteConfig(opts.Dir, opts.BuildSystem)
+		}
+	}
+
+	if opts.Apply {
+		err := applyBuildSystemIntegration(opts.Dir, opts.BuildSystem, opts.testLang)
+		if err != nil {
+			return err
+		}
+	} else if opts.Format == formatText {
+		setUpAndMentionBuildSystemIntegrations(opts.Dir, opts.BuildSystem, opts.testLang)
+	}
 	log.Debugf("Creating config file in directory: %s", opts.Dir)
 
 	configpath, err := config.CreateProjectConfig(opts.Dir, opts.Server, opts.Project)
@@ -132,13 +239,51 @@ func run(opts *options) error {
 		return err
 	}
 
+	err = storeWizardChoices(configpath, opts)
+	if err != nil {
+		return err
+	}
+
 	log.Successf("Configuration saved in %s", fileutil.PrettifyPath(configpath))
 
+	if opts.ClusterFuzzLite {
+		err = createClusterFuzzLiteConfig(opts.Dir, opts.BuildSystem)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Format == formatJSON {
+		return printInstructionsJSON(opts, configpath)
+	}
+
 	log.Print(`
 Use 'cifuzz create' to create your first fuzz test.`)
 	return nil
 }
 
+func printInstructionsJSON(opts *options, configPath string) error {
+	instructions, err := buildSystemInstructions(opts.Dir, opts.BuildSystem, opts.testLang)
+	if err != nil {
+		return err
+	}
+	output := struct {
+		ConfigFile   string                   `json:"config_file"`
+		BuildSystem  string                   `json:"build_system"`
+		Instructions []*messaging.Instruction `json:"instructions"`
+	}{
+		ConfigFile:   configPath,
+		BuildSystem:  opts.BuildSystem,
+		Instructions: instructions,
+	}
+	s, err := stringutil.ToJSONString(output)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(opts.stdout, s)
+	return errors.WithStack(err)
+}
+
 func setUpAndMentionBuildSystemIntegrations(dir string, buildSystem string, testLang string) {
 	switch buildSystem {
 	case config.BuildSystemBazel:
@@ -1,8 +1,10 @@
 package init
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"testing"
 
@@ -14,6 +16,7 @@ import (
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/messaging"
 )
 
 func TestMain(m *testing.M) {
@@ -66,6 +69,84 @@ func TestInitCmdForNodeWithTSLanguageArg(t *testing.T) {
 	assert.FileExists(t, filepath.Join(testDir, "cifuzz.yaml"))
 }
 
+func TestInitCmd_MultipleBuildSystems(t *testing.T) {
+	testDir := testutil.BootstrapExampleProjectForTest(t, "init-cmd-test", config.BuildSystemCMake)
+	err := os.Remove(filepath.Join(testDir, "cifuzz.yaml"))
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(testDir, "pom.xml"), []byte{}, 0o644)
+	require.NoError(t, err)
+
+	// Without a terminal, the preferred build system is set up and
+	// stored in the config
+	_, stdErr, err := cmdutils.ExecuteCommand(t, New(), os.Stdin)
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Detected multiple build systems (cmake, maven), setting up cmake")
+	content, err := os.ReadFile(filepath.Join(testDir, "cifuzz.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "\nbuild-system: cmake\n")
+	assert.Contains(t, string(content), "\n#fuzz-test-type: cpp\n")
+}
+
+func TestInitCmdWithJSONFormat(t *testing.T) {
+	testDir := testutil.BootstrapExampleProjectForTest(t, "init-cmd-test", config.BuildSystemCMake)
+	err := os.Remove(filepath.Join(testDir, "cifuzz.yaml"))
+	require.NoError(t, err)
+
+	stdOut, stdErr, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--format=json")
+	require.NoError(t, err)
+	assert.NotContains(t, stdErr, "Enable fuzz testing in your CMake project")
+
+	var output struct {
+		ConfigFile   string                   `json:"config_file"`
+		BuildSystem  string                   `json:"build_system"`
+		Instructions []*messaging.Instruction `json:"instructions"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdOut), &output))
+	assert.Equal(t, filepath.Join(testDir, "cifuzz.yaml"), output.ConfigFile)
+	assert.Equal(t, config.BuildSystemCMake, output.BuildSystem)
+	require.Len(t, output.Instructions, 1)
+	assert.Equal(t, "CMakeLists.txt", output.Instructions[0].File)
+	assert.Equal(t, messaging.InsertAfterCMakeProject, output.Instructions[0].Insert)
+	assert.Equal(t, "find_package(cifuzz NO_SYSTEM_ENVIRONMENT_PATH)\nenable_fuzz_testing()\n", output.Instructions[0].Snippet)
+
+	_, _, err = cmdutils.ExecuteCommand(t, New(), os.Stdin, "--format=json", "--apply")
+	var usageErr *cmdutils.IncorrectUsageError
+	assert.ErrorAs(t, err, &usageErr)
+}
+
+func TestBuildSystemInstructions_Gradle(t *testing.T) {
+	instructions, err := buildSystemInstructions(t.TempDir(), config.BuildSystemGradle, "")
+	require.NoError(t, err)
+	require.Len(t, instructions, 3)
+	assert.Equal(t, "build.gradle", instructions[0].File)
+	assert.Equal(t, "plugins", instructions[0].Block)
+	assert.Equal(t, "id \"com.code-intelligence.cifuzz\" version \"1.9.0\"\n", instructions[0].Snippet)
+	assert.Equal(t, "dependencies", instructions[1].Block)
+	assert.Equal(t, `testImplementation(platform("org.junit:junit-bom:5.10.0"))
+testImplementation('org.junit.jupiter:junit-jupiter')
+testImplementation('com.code-intelligence:jazzer-junit:0.21.1')
+`, instructions[1].Snippet)
+	assert.Equal(t, messaging.InsertAtEnd, instructions[2].Insert)
+	assert.Contains(t, instructions[2].Snippet, "useJUnitPlatform()")
+}
+
+func TestValidateFuzzTestDir(t *testing.T) {
+	projectDir := filepath.Join(string(filepath.Separator), "project")
+	for dir, expected := range map[string]string{
+		"":                                     "",
+		".":                                    "",
+		"fuzz/../fuzz-tests/":                  "fuzz-tests",
+		filepath.Join(projectDir, "fuzz", "a"): "fuzz/a",
+	} {
+		actual, err := validateFuzzTestDir(projectDir, dir)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual, dir)
+	}
+
+	_, err := validateFuzzTestDir(projectDir, filepath.Join("..", "other"))
+	assert.ErrorContains(t, err, "is not in the project directory")
+}
+
 func TestSupportedInitTestTypes(t *testing.T) {
 	// Test that the supportedInitTestTypesMap and supportedInitTestTypes are in sync.
 	initTestTypes := supportedInitTestTypes
@@ -76,3 +157,47 @@ func TestSupportedInitTestTypes(t *testing.T) {
 
 	assert.Equal(t, initTestTypesKeys, initTestTypes)
 }
+
+func TestInitCmdWithClusterFuzzLite(t *testing.T) {
+	testDir := testutil.BootstrapExampleProjectForTest(t, "init-cmd-test", config.BuildSystemCMake)
+
+	// The project is already set up for cifuzz, so only the
+	// ClusterFuzzLite files are created
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--clusterfuzzlite")
+	require.NoError(t, err)
+
+	dockerfile, err := os.ReadFile(filepath.Join(testDir, ".clusterfuzzlite", "Dockerfile"))
+	require.NoError(t, err)
+	assert.Contains(t, string(dockerfile), "COPY . $SRC/"+filepath.Base(testDir)+"\n")
+	assert.Contains(t, string(dockerfile), "install.sh")
+	buildScript := filepath.Join(testDir, ".clusterfuzzlite", "build.sh")
+	content, err := os.ReadFile(buildScript)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "-DCIFUZZ_ENGINE=libfuzzer")
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(buildScript)
+		require.NoError(t, err)
+		assert.NotZero(t, info.Mode()&0o100, "build.sh is not executable")
+	}
+	assert.FileExists(t, filepath.Join(testDir, ".clusterfuzzlite", "project.yaml"))
+	workflow, err := os.ReadFile(filepath.Join(testDir, ".github", "workflows", "cflite_pr.yml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(workflow), "${{ matrix.sanitizer }}")
+
+	// Existing files are not overwritten
+	require.NoError(t, os.WriteFile(buildScript, []byte("custom"), 0o755))
+	_, stdErr, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--clusterfuzzlite")
+	require.NoError(t, err)
+	assert.Contains(t, stdErr, "Not creating .clusterfuzzlite/build.sh, because it already exists")
+	content, err = os.ReadFile(buildScript)
+	require.NoError(t, err)
+	assert.Equal(t, "custom", string(content))
+}
+
+func TestInitCmdWithClusterFuzzLite_UnsupportedBuildSystem(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "init-cmd-test", config.BuildSystemMaven)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "--clusterfuzzlite")
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "only supported for CMake and Bazel projects")
+}
@@ -0,0 +1,146 @@
+package init
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/dependencies"
+	"code-intelligence.com/cifuzz/pkg/messaging"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// buildSystemInstructions returns the instructions to set up the build
+// system integration as structured data, see --format=json
+func buildSystemInstructions(dir string, buildSystem string, testLang string) ([]*messaging.Instruction, error) {
+	switch buildSystem {
+	case config.BuildSystemCMake:
+		return []*messaging.Instruction{{
+			Description: "Enable fuzz testing in the top-level CMakeLists.txt",
+			File:        "CMakeLists.txt",
+			Insert:      messaging.InsertAfterCMakeProject,
+			Snippet:     instructionsSnippet(messaging.Instructions(config.BuildSystemCMake), 0),
+		}}, nil
+	case config.BuildSystemBazel:
+		workspace := "WORKSPACE.bazel"
+		if exists, _ := fileutil.Exists(filepath.Join(dir, workspace)); !exists {
+			workspace = "WORKSPACE"
+		}
+		instructions := fmt.Sprintf(messaging.Instructions(config.BuildSystemBazel),
+			dependencies.RulesFuzzingWORKSPACEContent, dependencies.CIFuzzBazelCommit)
+		return []*messaging.Instruction{{
+			Description: "Add the rules_fuzzing and cifuzz repositories to the WORKSPACE",
+			File:        workspace,
+			Insert:      messaging.InsertAtEnd,
+			Snippet:     instructionsSnippet(instructions, 0),
+		}}, nil
+	case config.BuildSystemMaven:
+		instructions := messaging.Instructions(config.BuildSystemMaven)
+		return []*messaging.Instruction{
+			{
+				Description: "Apply the cifuzz Maven extension",
+				File:        ".mvn/extensions.xml",
+				Insert:      messaging.InsertMerge,
+				Snippet:     instructionsSnippet(instructions, 0),
+			},
+			{
+				Description: "In a multi-project, mark the project that contains the fuzz tests",
+				File:        "pom.xml",
+				Insert:      messaging.InsertMerge,
+				Snippet:     instructionsSnippet(instructions, 1),
+			},
+		}, nil
+	case config.BuildSystemGradle:
+		gradleBuildLanguage, err := config.DetermineGradleBuildLanguage(dir)
+		if err != nil {
+			return nil, err
+		}
+		buildFile := "build.gradle"
+		if gradleBuildLanguage == config.GradleKotlin {
+			buildFile = "build.gradle.kts"
+		}
+		instructions := messaging.Instructions(string(gradleBuildLanguage))
+		dependenciesBlock, testBlock, _ := strings.Cut(instructionsSnippet(instructions, 1), "\n\n")
+		return []*messaging.Instruction{
+			{
+				Description: "Apply the cifuzz Gradle plugin",
+				File:        buildFile,
+				Insert:      messaging.InsertIntoBlock,
+				Block:       "plugins",
+				Snippet:     instructionsSnippet(instructions, 0),
+			},
+			{
+				Description: "Add the JUnit and Jazzer dependencies",
+				File:        buildFile,
+				Insert:      messaging.InsertIntoBlock,
+				Block:       "dependencies",
+				Snippet:     blockContent(dependenciesBlock),
+			},
+			{
+				Description: "Run the tests on the JUnit platform",
+				File:        buildFile,
+				Insert:      messaging.InsertAtEnd,
+				Snippet:     testBlock,
+			},
+		}, nil
+	case config.BuildSystemNodeJS:
+		if testLang == "" {
+			var err error
+			testLang, err = getNodeProjectLang()
+			if err != nil {
+				return nil, err
+			}
+		}
+		instructions := messaging.Instructions(config.BuildSystemNodeJS)
+		jestConfig := "jest.config.js"
+		if testLang == "ts" {
+			instructions = messaging.Instructions("nodets")
+			jestConfig = "jest.config.ts"
+		}
+		var command string
+		for _, line := range strings.Split(instructionsSnippet(instructions, 0), "\n") {
+			if strings.HasPrefix(line, "npm ") {
+				command = line
+				break
+			}
+		}
+		result := []*messaging.Instruction{
+			{
+				Description: "Add a dev-dependency to @jazzer.js/jest-runner",
+				Command:     command,
+			},
+			{
+				Description: "Integrate Jazzer.js with the Jest setup",
+				File:        jestConfig,
+				Insert:      messaging.InsertMerge,
+				Snippet:     instructionsSnippet(instructions, 1),
+			},
+		}
+		if testLang == "ts" {
+			result = append(result, &messaging.Instruction{
+				Description: "Introduce the fuzz function types globally",
+				File:        "globals.d.ts",
+				Insert:      messaging.InsertAtEnd,
+				Snippet:     instructionsSnippet(instructions, 2),
+			})
+		}
+		return result, nil
+	default:
+		return []*messaging.Instruction{}, nil
+	}
+}
+
+// blockContent returns the lines between the first and the last line of
+// a block, without indentation
+func blockContent(block string) string {
+	lines := strings.Split(strings.TrimSuffix(block, "\n"), "\n")
+	if len(lines) < 2 {
+		return block
+	}
+	var content []string
+	for _, line := range lines[1 : len(lines)-1] {
+		content = append(content, strings.TrimPrefix(line, "    "))
+	}
+	return strings.Join(content, "\n") + "\n"
+}
@@ -0,0 +1,151 @@
+package init
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/dialog"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// The labels of the build systems in the wizard
+var buildSystemLabels = map[string]string{
+	config.BuildSystemBazel:  "Bazel",
+	config.BuildSystemCMake:  "CMake",
+	config.BuildSystemMaven:  "Maven",
+	config.BuildSystemGradle: "Gradle",
+	config.BuildSystemNodeJS: "Node.js",
+}
+
+// The types of fuzz tests which can be created for the build systems
+var buildSystemTestTypes = map[string][]config.FuzzTestType{
+	config.BuildSystemBazel:  {config.CPP},
+	config.BuildSystemCMake:  {config.CPP},
+	config.BuildSystemMaven:  {config.Java, config.Kotlin},
+	config.BuildSystemGradle: {config.Java, config.Kotlin},
+	config.BuildSystemNodeJS: {config.JavaScript, config.TypeScript},
+}
+
+// detectBuildSystems returns the build systems which are used in the
+// directory and supported on this platform, or the first unsupported
+// one, so that the user is told that it's not supported
+func detectBuildSystems(dir string) ([]string, error) {
+	detected, err := config.DetectBuildSystems(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(detected) == 0 {
+		return []string{config.BuildSystemOther}, nil
+	}
+
+	var buildSystems []string
+	for _, buildSystem := range detected {
+		if config.ValidateBuildSystem(buildSystem) == nil {
+			buildSystems = append(buildSystems, buildSystem)
+		}
+	}
+	if len(buildSystems) == 0 {
+		return detected[:1], nil
+	}
+	return buildSystems, nil
+}
+
+// runWizard asks the user which of the detected build systems to set
+// up, which type of fuzz tests to create and in which directory
+// `cifuzz create` should create them
+func runWizard(opts *options) error {
+	items := map[string]string{}
+	for _, buildSystem := range opts.buildSystems {
+		items[buildSystemLabels[buildSystem]] = buildSystem
+	}
+	var err error
+	opts.BuildSystem, err = dialog.Select("Found multiple build systems, which one should be set up?", items, true)
+	if err != nil {
+		return err
+	}
+
+	testTypes := buildSystemTestTypes[opts.BuildSystem]
+	if len(testTypes) > 1 {
+		items = map[string]string{}
+		for label, testType := range config.SupportedTestTypes {
+			for _, t := range testTypes {
+				if string(t) == testType {
+					items[label] = testType
+				}
+			}
+		}
+		opts.fuzzTestType, err = dialog.Select("Which type of fuzz tests do you want to create?", items, true)
+		if err != nil {
+			return err
+		}
+	} else if len(testTypes) == 1 {
+		opts.fuzzTestType = string(testTypes[0])
+	}
+	if opts.BuildSystem == config.BuildSystemNodeJS {
+		// The instructions depend on the language
+		opts.testLang = opts.fuzzTestType
+	}
+
+	dir, err := dialog.Input("In which directory should the fuzz tests be created (relative to the project, empty for the current directory)?")
+	if err != nil {
+		return err
+	}
+	opts.fuzzTestDir, err = validateFuzzTestDir(opts.Dir, dir)
+	if err != nil {
+		log.Warnf("%v, fuzz tests are created in the current directory", err)
+	}
+	return nil
+}
+
+// validateFuzzTestDir returns the directory relative to the project
+// directory with forward slashes, so that it can be stored in the
+// config
+func validateFuzzTestDir(projectDir, dir string) (string, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return "", nil
+	}
+	if filepath.IsAbs(dir) {
+		rel, err := filepath.Rel(projectDir, dir)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		dir = rel
+	}
+	dir = filepath.Clean(dir)
+	if dir == ".." || strings.HasPrefix(dir, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("The directory %s is not in the project directory", dir)
+	}
+	if dir == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(dir), nil
+}
+
+// storeWizardChoices stores the build system, if multiple were
+// detected, and the choices of the wizard in the config
+func storeWizardChoices(configPath string, opts *options) error {
+	if len(opts.buildSystems) <= 1 && opts.fuzzTestType == "" && opts.fuzzTestDir == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	updated := string(content)
+	if len(opts.buildSystems) > 1 {
+		updated = config.EnsureConfigEntry(updated, "build-system", opts.BuildSystem)
+	}
+	if opts.fuzzTestType != "" {
+		updated = config.EnsureConfigEntry(updated, "fuzz-test-type", opts.fuzzTestType)
+	}
+	if opts.fuzzTestDir != "" {
+		updated = config.EnsureConfigEntry(updated, "fuzz-test-dir", opts.fuzzTestDir)
+	}
+	return errors.WithStack(os.WriteFile(configPath, []byte(updated), 0o644))
+}
@@ -35,7 +35,24 @@ func New() *cobra.Command {
 		Use:   "login",
 		Short: "Authenticate with CI Sense",
 		Long: `This command is used to authenticate with CI Sense.
-To learn more, visit https://www.code-intelligence.com.`,
+To learn more, visit https://www.code-intelligence.com.
+
+The API access token is stored in the credential manager of the
+operating system: the Keychain on macOS, the Secret Service (via
+secret-tool) on Linux and the Credential Manager on Windows. If the
+credential manager is not available, the token is stored in the
+access_tokens.json file in the cifuzz configuration directory. Tokens
+which were stored in that file before are moved to the credential
+manager when they are used.
+
+CI jobs don't need to log in: cifuzz uses the API access token from the
+CIFUZZ_API_TOKEN environment variable or, if the job has an OpenID
+Connect ID token, exchanges the ID token for a short-lived access token.
+ID tokens are requested automatically in GitHub Actions workflows with
+the "id-token: write" permission. In other CI systems, like GitLab CI,
+the ID token can be passed via the CIFUZZ_OIDC_TOKEN environment
+variable. The audience of the ID token is the server URL by default and
+can be changed via CIFUZZ_OIDC_AUDIENCE.`,
 		Example: "$ cifuzz login",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			// Bind viper keys to flags. We can't do this in the New
@@ -109,11 +126,11 @@ in interactive mode. You can generate a token here:
 		return err
 	}
 
-	tokenFilePath, err := tokenstorage.GetTokenFilePath()
+	tokenLocation, err := tokenstorage.Location(c.opts.Server)
 	if err != nil {
 		return err
 	}
-	log.Infof("Your API access token is stored in %s", tokenFilePath)
+	log.Infof("Your API access token is stored in %s", tokenLocation)
 
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"code-intelligence.com/cifuzz/internal/build"
 	"code-intelligence.com/cifuzz/internal/build/other"
 	"code-intelligence.com/cifuzz/pkg/runfiles"
 	"code-intelligence.com/cifuzz/util/envutil"
@@ -44,7 +45,7 @@ func addCommand(flags string) *cobra.Command {
 					return err
 				}
 			} else {
-				env, err = other.SetLibFuzzerEnv(env, runfiles.Finder)
+				env, err = other.SetLibFuzzerEnv(env, build.DefaultSanitizers, runfiles.Finder)
 				if err != nil {
 					return err
 				}
@@ -0,0 +1,153 @@
+package create
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+
+	"code-intelligence.com/cifuzz/internal/api"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/auth"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/dialog"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+type options struct {
+	Interactive bool   `mapstructure:"interactive"`
+	Server      string `mapstructure:"server"`
+	Format      string `mapstructure:"-"`
+}
+
+type createCmd struct {
+	*cobra.Command
+	opts      *options
+	apiClient *api.APIClient
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "create [flags] [<display name>]",
+		Short: "Create a project on CI Sense",
+		Long: `This command creates a project with the given display name on
+CI Sense and prints its name, which CI Sense derives from the display
+name. If no display name is specified, it's asked for interactively.
+
+When run interactively in a cifuzz project, the command offers to
+add the new project as 'project' to the cifuzz.yaml, so that it's used
+by 'cifuzz remote-run' and 'cifuzz run'.
+
+    cifuzz project create "My Project"
+
+Use --format=json to get machine-readable output.`,
+		Args: cobra.MaximumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+
+			if opts.Format != formatText && opts.Format != formatJSON {
+				msg := fmt.Sprintf("Invalid format %q, valid formats are: %s, %s", opts.Format, formatText, formatJSON)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
+			opts.Interactive = viper.GetBool("interactive")
+			// The command should not be interactive when stdin is not
+			// a terminal
+			if opts.Interactive {
+				opts.Interactive = term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+			}
+			if len(args) == 0 && !opts.Interactive {
+				msg := "No display name specified, please specify the display name of the project to create"
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
+			var err error
+			opts.Server, err = api.ValidateAndNormalizeServerURL(viper.GetString("server"))
+			return err
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := createCmd{Command: c, opts: opts, apiClient: api.NewClient(opts.Server)}
+			return cmd.run(args)
+		},
+	}
+
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddInteractiveFlag,
+		cmdutils.AddServerFlag,
+	)
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", formatText,
+		fmt.Sprintf("Output format of the created project (%s/%s).", formatText, formatJSON))
+
+	return cmd
+}
+
+func (c *createCmd) run(args []string) error {
+	token, err := auth.GetValidToken(c.opts.Server)
+	if err != nil {
+		return err
+	}
+
+	var displayName string
+	if len(args) == 1 {
+		displayName = args[0]
+	} else {
+		displayName, err = dialog.Input("Enter the display name of the project you want to create")
+		if err != nil {
+			return err
+		}
+	}
+	displayName = strings.TrimSpace(displayName)
+	if displayName == "" {
+		return cmdutils.WrapIncorrectUsageError(errors.New("The display name of the project must not be empty"))
+	}
+
+	project, err := c.apiClient.CreateProject(displayName, token)
+	if err != nil {
+		return err
+	}
+	project.Name, err = api.ConvertProjectNameFromAPI(project.Name)
+	if err != nil {
+		return err
+	}
+
+	if c.opts.Format == formatJSON {
+		s, err := stringutil.ToJSONString(project)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(c.OutOrStdout(), s)
+		return errors.WithStack(err)
+	}
+	log.Successf("Created project %s with the name %s", project.DisplayName, project.Name)
+
+	if c.opts.Interactive {
+		exists, err := fileutil.Exists(config.ProjectConfigFile)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return dialog.AskToPersistProjectChoice(project.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package delete
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+
+	"code-intelligence.com/cifuzz/internal/api"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/auth"
+	"code-intelligence.com/cifuzz/pkg/dialog"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+type options struct {
+	Interactive bool   `mapstructure:"interactive"`
+	Server      string `mapstructure:"server"`
+	Format      string `mapstructure:"-"`
+	Yes         bool   `mapstructure:"-"`
+}
+
+type deleteCmd struct {
+	*cobra.Command
+	opts      *options
+	apiClient *api.APIClient
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "delete [flags] [<project>]",
+		Short: "Delete a project on CI Sense",
+		Long: `This command deletes the project with the given name or display name
+on CI Sense, including its campaign runs and findings. If no project is
+specified, the project can be selected interactively.
+
+The command asks for confirmation before deleting the project. Use
+--yes to skip the confirmation, which is required in non-interactive
+mode.
+
+Use --format=json to get machine-readable output.`,
+		Args: cobra.MaximumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+
+			if opts.Format != formatText && opts.Format != formatJSON {
+				msg := fmt.Sprintf("Invalid format %q, valid formats are: %s, %s", opts.Format, formatText, formatJSON)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
+			opts.Interactive = viper.GetBool("interactive")
+			// The command should not be interactive when stdin is not
+			// a terminal
+			if opts.Interactive {
+				opts.Interactive = term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+			}
+			if !opts.Interactive {
+				if len(args) == 0 {
+					msg := "No project specified, please specify the project to delete"
+					return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+				}
+				if !opts.Yes {
+					msg := "Deleting a project in non-interactive mode requires --yes"
+					return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+				}
+			}
+
+			var err error
+			opts.Server, err = api.ValidateAndNormalizeServerURL(viper.GetString("server"))
+			return err
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := deleteCmd{Command: c, opts: opts, apiClient: api.NewClient(opts.Server)}
+			return cmd.run(args)
+		},
+	}
+
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddInteractiveFlag,
+		cmdutils.AddServerFlag,
+	)
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", formatText,
+		fmt.Sprintf("Output format of the deleted project (%s/%s).", formatText, formatJSON))
+	cmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false,
+		"Delete the project without asking for confirmation.")
+
+	return cmd
+}
+
+func (c *deleteCmd) run(args []string) error {
+	token, err := auth.GetValidToken(c.opts.Server)
+	if err != nil {
+		return err
+	}
+
+	projects, err := c.apiClient.ListProjects(token)
+	if err != nil {
+		return err
+	}
+
+	var project *api.Project
+	if len(args) == 1 {
+		project, err = findProject(projects, args[0])
+		if err != nil {
+			return err
+		}
+	} else {
+		if len(projects) == 0 {
+			return errors.Errorf("There are no projects on %s", c.opts.Server)
+		}
+		name, err := dialog.ProjectPicker(projects, "Select the project you want to delete:")
+		if err != nil {
+			return err
+		}
+		if name == "<<cancel>>" {
+			return nil
+		}
+		project, err = findProject(projects, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !c.opts.Yes {
+		msg := fmt.Sprintf("Do you really want to delete the project %s (%s) with all of its campaign runs and findings?",
+			project.DisplayName, project.Name)
+		confirmed, err := dialog.Confirm(msg, false)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			log.Info("The project was not deleted")
+			return nil
+		}
+	}
+
+	err = c.apiClient.DeleteProject(project.Name, token)
+	if err != nil {
+		return err
+	}
+
+	if c.opts.Format == formatJSON {
+		s, err := stringutil.ToJSONString(project)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(c.OutOrStdout(), s)
+		return errors.WithStack(err)
+	}
+	log.Successf("Deleted project %s (%s)", project.DisplayName, project.Name)
+	return nil
+}
+
+// findProject returns the project with the given name or, if no project
+// has that name, the project with the given display name
+func findProject(projects []*api.Project, nameOrDisplayName string) (*api.Project, error) {
+	nameOrDisplayName = strings.TrimPrefix(nameOrDisplayName, "projects/")
+	for _, p := range projects {
+		if p.Name == nameOrDisplayName {
+			return p, nil
+		}
+	}
+
+	var matches []*api.Project
+	for _, p := range projects {
+		if p.DisplayName == nameOrDisplayName {
+			matches = append(matches, p)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, errors.Errorf("Project %q not found, see 'cifuzz project list' for the available projects", nameOrDisplayName)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, errors.Errorf("There are multiple projects with the display name %q, please specify the name of the project instead", nameOrDisplayName)
+	}
+}
@@ -0,0 +1,36 @@
+package delete
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/api"
+)
+
+func TestFindProject(t *testing.T) {
+	projects := []*api.Project{
+		{Name: "my-project-1a2b3c4d", DisplayName: "My Project"},
+		{Name: "other-5e6f7a8b", DisplayName: "Other"},
+		{Name: "other-9c0d1e2f", DisplayName: "Other"},
+	}
+
+	project, err := findProject(projects, "my-project-1a2b3c4d")
+	require.NoError(t, err)
+	assert.Equal(t, "My Project", project.DisplayName)
+
+	project, err = findProject(projects, "projects/other-5e6f7a8b")
+	require.NoError(t, err)
+	assert.Equal(t, "other-5e6f7a8b", project.Name)
+
+	project, err = findProject(projects, "My Project")
+	require.NoError(t, err)
+	assert.Equal(t, "my-project-1a2b3c4d", project.Name)
+
+	_, err = findProject(projects, "Other")
+	assert.ErrorContains(t, err, "multiple projects")
+
+	_, err = findProject(projects, "unknown")
+	assert.ErrorContains(t, err, "not found")
+}
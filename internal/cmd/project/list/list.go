@@ -0,0 +1,120 @@
+package list
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/api"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/auth"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+type options struct {
+	Server string `mapstructure:"server"`
+	Format string `mapstructure:"-"`
+}
+
+type listCmd struct {
+	*cobra.Command
+	opts      *options
+	apiClient *api.APIClient
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the projects on CI Sense",
+		Long: `This command lists the names and display names of the projects on
+CI Sense which the API token has access to. The name is what is set as
+'project' in cifuzz.yaml or passed via --project.
+
+Use --format=json to get machine-readable output.`,
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+
+			if opts.Format != formatText && opts.Format != formatJSON {
+				msg := fmt.Sprintf("Invalid format %q, valid formats are: %s, %s", opts.Format, formatText, formatJSON)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
+			var err error
+			opts.Server, err = api.ValidateAndNormalizeServerURL(viper.GetString("server"))
+			return err
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := listCmd{Command: c, opts: opts, apiClient: api.NewClient(opts.Server)}
+			return cmd.run()
+		},
+	}
+
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddServerFlag,
+	)
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", formatText,
+		fmt.Sprintf("Output format of the projects (%s/%s).", formatText, formatJSON))
+
+	return cmd
+}
+
+func (c *listCmd) run() error {
+	token, err := auth.GetValidToken(c.opts.Server)
+	if err != nil {
+		return err
+	}
+
+	projects, err := c.apiClient.ListProjects(token)
+	if err != nil {
+		return err
+	}
+	if projects == nil {
+		// Print an empty list instead of null with --format=json
+		projects = []*api.Project{}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return strings.ToLower(projects[i].DisplayName) < strings.ToLower(projects[j].DisplayName)
+	})
+
+	if c.opts.Format == formatJSON {
+		s, err := stringutil.ToJSONString(projects)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(c.OutOrStdout(), s)
+		return errors.WithStack(err)
+	}
+
+	if len(projects) == 0 {
+		log.Printf("There are no projects on %s yet, create one with 'cifuzz project create'", c.opts.Server)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.OutOrStdout(), 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Name\tDisplay Name")
+	for _, p := range projects {
+		_, _ = fmt.Fprintf(w, "%s\t%s\n", p.Name, p.DisplayName)
+	}
+	return errors.WithStack(w.Flush())
+}
@@ -0,0 +1,41 @@
+package project
+
+import (
+	"github.com/spf13/cobra"
+
+	projectCreateCmd "code-intelligence.com/cifuzz/internal/cmd/project/create"
+	projectDeleteCmd "code-intelligence.com/cifuzz/internal/cmd/project/delete"
+	projectListCmd "code-intelligence.com/cifuzz/internal/cmd/project/list"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+)
+
+func New() *cobra.Command {
+	return newWithOptions()
+}
+
+func newWithOptions() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "project",
+		Aliases: []string{"projects"},
+		Short:   "Manage projects on CI Sense",
+		Long: `List, create and delete the projects on CI Sense to which remote runs
+and findings belong, without having to use the web interface.
+
+These commands need a token to access the API of CI Sense. You can
+specify this token via the CIFUZZ_API_TOKEN environment variable or by
+running 'cifuzz login' first.`,
+		RunE: func(c *cobra.Command, args []string) error {
+			_ = c.Help()
+			return nil
+		},
+	}
+
+	// Projects can be managed outside of a cifuzz project
+	cmdutils.DisableConfigCheck(cmd)
+
+	cmd.AddCommand(projectListCmd.New())
+	cmd.AddCommand(projectCreateCmd.New())
+	cmd.AddCommand(projectDeleteCmd.New())
+
+	return cmd
+}
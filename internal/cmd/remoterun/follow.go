@@ -0,0 +1,225 @@
+package remoterun
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/api"
+	"code-intelligence.com/cifuzz/internal/cmd/remoterun/poll"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+// The interval in which the status and logs of the campaign run are
+// polled with --follow
+var followPollInterval = 10 * time.Second
+
+// The value of --fail-on which makes the command fail if the campaign
+// run reported any findings
+const failOnFinding = "finding"
+
+// follower waits for a campaign run to finish and prints its progress
+type follower struct {
+	apiClient   *api.APIClient
+	token       string
+	projectName string
+	campaignRun string
+	// The fuzzer logs are written to out if printLogs is true
+	out       io.Writer
+	printLogs bool
+	// The maximum time to wait, or zero to wait until the campaign run
+	// has finished
+	timeout time.Duration
+	// If true, an error is returned if the campaign run reported any
+	// findings
+	failOnFinding bool
+
+	campaignStatus string
+	runStatuses    map[string]string
+	pageTokens     map[string]string
+	logsSupported  bool
+	findings       map[string]bool
+}
+
+func newFollower(apiClient *api.APIClient, token, projectName, campaignRun string, out io.Writer) *follower {
+	return &follower{
+		apiClient:     apiClient,
+		token:         token,
+		projectName:   projectName,
+		campaignRun:   campaignRun,
+		out:           out,
+		printLogs:     true,
+		runStatuses:   map[string]string{},
+		pageTokens:    map[string]string{},
+		logsSupported: true,
+		findings:      map[string]bool{},
+	}
+}
+
+// follow polls the campaign run and prints status changes of its
+// fuzzing runs, their fuzzer logs and new findings until the campaign
+// run has finished or the timeout has passed. An error is returned if
+// the campaign run failed or, with failOnFinding, reported findings or
+// its findings couldn't be retrieved.
+func (f *follower) follow() error {
+	poller := &poll.Poller{
+		APIClient:   f.apiClient,
+		Token:       f.token,
+		ProjectName: f.projectName,
+		CampaignRun: f.campaignRun,
+		Interval:    followPollInterval,
+		Timeout:     f.timeout,
+	}
+	log.Infof("Waiting for campaign run %s to finish...", f.campaignRun)
+	err := poller.Run(f.handle)
+	var signalErr *cmdutils.SignalError
+	if errors.As(err, &signalErr) {
+		log.Infof("Stopped following campaign run %s, which continues to run remotely", f.campaignRun)
+	}
+	return err
+}
+
+// handle prints the changes of the campaign run since the last poll and
+// checks the result of the campaign run on the last poll
+func (f *follower) handle(state *poll.State) error {
+	if state.CampaignRun != nil {
+		err := f.printChanges(state.CampaignRun)
+		if err != nil {
+			return err
+		}
+	}
+
+	if state.FindingsErr == nil {
+		f.printNewFindings(state.Findings)
+	} else if !state.Finished && !state.TimedOut {
+		// The findings are retrieved again with the next poll
+		log.Debug(state.FindingsErr.Error())
+	}
+
+	if state.Finished {
+		msg := fmt.Sprintf("Campaign run %s finished with status %s (%d findings)",
+			f.campaignRun, state.CampaignRun.Status, len(f.findings))
+		switch report.RunStatus(state.CampaignRun.Status) {
+		case report.RunStatusFailed, report.RunStatusFailedToStart:
+			err := errors.New(msg)
+			log.Error(err)
+			return cmdutils.WrapSilentError(err)
+		}
+		err := f.checkFindings(state.FindingsErr)
+		if err != nil {
+			return err
+		}
+		log.Success(msg)
+	} else if state.TimedOut {
+		log.Warnf("Campaign run %s did not finish within %s, it continues to run remotely", f.campaignRun, f.timeout)
+		return f.checkFindings(state.FindingsErr)
+	}
+	return nil
+}
+
+// printChanges prints the status changes of the campaign run and its
+// fuzzing runs and the new fuzzer logs since the last poll
+func (f *follower) printChanges(campaignRun *api.CampaignRun) error {
+	if campaignRun.Status != f.campaignStatus {
+		f.campaignStatus = campaignRun.Status
+		log.Infof("Campaign run status: %s", campaignRun.Status)
+	}
+
+	for _, run := range campaignRun.Runs {
+		name := fuzzingRunDisplayName(run)
+		if run.Status != f.runStatuses[run.Name] {
+			f.runStatuses[run.Name] = run.Status
+			log.Infof("%s: %s", name, run.Status)
+		}
+		if f.printLogs && f.logsSupported {
+			err := f.printNewLogs(run.Name, name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// printNewFindings prints the findings of the campaign run which were
+// not printed before
+func (f *follower) printNewFindings(findings []api.Finding) {
+	for _, finding := range findings {
+		if finding.CampaignRun != f.campaignRun || f.findings[finding.Name] {
+			continue
+		}
+		f.findings[finding.Name] = true
+		msg := fmt.Sprintf("New finding %s in %s", finding.DisplayName, finding.FuzzTargetDisplayName)
+		if finding.ErrorReport != nil && finding.ErrorReport.ShortDescription != "" {
+			msg += ": " + finding.ErrorReport.ShortDescription
+		}
+		log.Warn(msg)
+	}
+}
+
+// printNewLogs prints the fuzzer logs of the fuzzing run which were
+// logged since the last call, prefixed with the display name of the run
+func (f *follower) printNewLogs(runName, displayName string) error {
+	logs, err := f.apiClient.GetFuzzingRunLogs(runName, f.pageTokens[runName], f.token)
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		log.Info("The server doesn't provide the fuzzer logs, only the status is shown")
+		f.logsSupported = false
+		return nil
+	}
+	var connErr *api.ConnectionError
+	if errors.As(err, &connErr) {
+		// The lines are printed with the next poll
+		log.Debugf("Failed to get the logs of fuzzing run %s: %v", runName, err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, line := range logs.Lines {
+		_, err = fmt.Fprintf(f.out, "%s | %s\n", displayName, line)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if logs.NextPageToken != "" {
+		f.pageTokens[runName] = logs.NextPageToken
+	}
+	return nil
+}
+
+// checkFindings returns an error if failOnFinding is set and the
+// campaign run reported any findings or its findings couldn't be
+// retrieved, as given by findingsErr
+func (f *follower) checkFindings(findingsErr error) error {
+	if findingsErr != nil {
+		if !f.failOnFinding {
+			log.Warn(findingsErr.Error())
+			return nil
+		}
+		log.Error(findingsErr)
+		return cmdutils.WrapSilentError(findingsErr)
+	}
+	if !f.failOnFinding || len(f.findings) == 0 {
+		return nil
+	}
+	err := errors.Errorf("Campaign run %s reported %d findings", f.campaignRun, len(f.findings))
+	log.Error(err)
+	return cmdutils.WrapSilentError(err)
+}
+
+func fuzzingRunDisplayName(run *api.FuzzingRun) string {
+	if run.FuzzTargetConfig != nil && run.FuzzTargetConfig.DisplayName != "" {
+		return run.FuzzTargetConfig.DisplayName
+	}
+	if run.DisplayName != "" {
+		return run.DisplayName
+	}
+	return run.Name
+}
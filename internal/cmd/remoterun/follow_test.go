@@ -0,0 +1,154 @@
+package remoterun
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/api"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+)
+
+func TestFollow(t *testing.T) {
+	followPollInterval = time.Millisecond
+	t.Cleanup(func() { followPollInterval = 10 * time.Second })
+
+	const campaignRun = "projects/my_project/campaign_runs/my-run"
+	const fuzzingRun = "projects/my_project/fuzzing_runs/my-fuzzing-run"
+
+	var mutex sync.Mutex
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch r.URL.Path {
+		case "/v1/" + campaignRun:
+			polls++
+			status := "RUNNING"
+			if polls == 3 {
+				status = "SUCCEEDED"
+			}
+			fmt.Fprintf(w, `{"name": %q, "status": %q, "runs": [{"name": %q, "status": %q, "fuzz_target_config": {"display_name": "my_fuzz_test"}}]}`,
+				campaignRun, status, fuzzingRun, status)
+		case "/v1/" + fuzzingRun + "/logs":
+			// Each poll returns the lines logged since the previous one
+			expectedToken := ""
+			if polls > 1 {
+				expectedToken = fmt.Sprintf("%d", polls-1)
+			}
+			assert.Equal(t, expectedToken, r.URL.Query().Get("page_token"))
+			fmt.Fprintf(w, `{"lines": ["line %d"], "next_page_token": "%d"}`, polls, polls)
+		case "/v1/projects/my_project/findings":
+			fmt.Fprintf(w, `{"findings": [{"name": "projects/my_project/findings/f1", "display_name": "f1", "campaign_run": %q}]}`, campaignRun)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	f := newFollower(api.NewClient(server.URL), "token", "my_project", campaignRun, &out)
+	err := f.follow()
+	require.NoError(t, err)
+	assert.Equal(t, "my_fuzz_test | line 1\nmy_fuzz_test | line 2\nmy_fuzz_test | line 3\n", out.String())
+	assert.Len(t, f.findings, 1)
+}
+
+func TestFollow_Failed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/projects/my_project/campaign_runs/my-run" {
+			fmt.Fprint(w, `{"status": "FAILED", "runs": [{"name": "projects/my_project/fuzzing_runs/my-fuzzing-run"}]}`)
+			return
+		}
+		// The server doesn't provide any logs or findings
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	f := newFollower(api.NewClient(server.URL), "token", "my_project", "projects/my_project/campaign_runs/my-run", &out)
+	err := f.follow()
+	require.Error(t, err)
+	var silentErr *cmdutils.SilentError
+	assert.ErrorAs(t, err, &silentErr)
+	assert.False(t, f.logsSupported)
+	assert.Empty(t, out.String())
+}
+
+func TestFollow_WaitFailOnFinding(t *testing.T) {
+	followPollInterval = time.Millisecond
+	t.Cleanup(func() { followPollInterval = 10 * time.Second })
+
+	const campaignRun = "projects/my_project/campaign_runs/my-run"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/" + campaignRun:
+			// The campaign run doesn't finish before the timeout
+			fmt.Fprint(w, `{"status": "RUNNING", "runs": [{"name": "projects/my_project/fuzzing_runs/my-fuzzing-run"}]}`)
+		case "/v1/projects/my_project/findings":
+			fmt.Fprintf(w, `{"findings": [{"name": "projects/my_project/findings/f1", "campaign_run": %q}, {"name": "projects/my_project/findings/f2", "campaign_run": "projects/my_project/campaign_runs/other-run"}]}`, campaignRun)
+		default:
+			// The logs are not requested without --follow
+			assert.Failf(t, "unexpected request", "%s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	f := newFollower(api.NewClient(server.URL), "token", "my_project", campaignRun, &out)
+	f.printLogs = false
+	f.timeout = 20 * time.Millisecond
+	f.failOnFinding = true
+	err := f.follow()
+	require.Error(t, err)
+	var silentErr *cmdutils.SilentError
+	assert.ErrorAs(t, err, &silentErr)
+	assert.Equal(t, map[string]bool{"projects/my_project/findings/f1": true}, f.findings)
+	assert.Empty(t, out.String())
+}
+
+func TestFollow_FailOnFindingWithoutFindings(t *testing.T) {
+	followPollInterval = time.Millisecond
+	t.Cleanup(func() { followPollInterval = 10 * time.Second })
+
+	// The findings endpoint fails, so the findings are unknown
+	viper.Set("api-max-retries", 0)
+	t.Cleanup(func() { viper.Set("api-max-retries", nil) })
+
+	const campaignRun = "projects/my_project/campaign_runs/my-run"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/"+campaignRun {
+			fmt.Fprint(w, `{"status": "SUCCEEDED"}`)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	f := newFollower(api.NewClient(server.URL), "token", "my_project", campaignRun, &out)
+	f.printLogs = false
+
+	// Without --fail-on=finding, the findings are only printed on a
+	// best-effort basis
+	err := f.follow()
+	require.NoError(t, err)
+
+	// With --fail-on=finding, the run must not pass without its
+	// findings having been checked
+	f.failOnFinding = true
+	err = f.follow()
+	require.Error(t, err)
+	var silentErr *cmdutils.SilentError
+	assert.ErrorAs(t, err, &silentErr)
+}
@@ -0,0 +1,290 @@
+package gate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/api"
+	"code-intelligence.com/cifuzz/internal/cmd/remoterun/poll"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/auth"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/webhook"
+)
+
+// The interval in which the campaign run and the findings are polled
+var pollInterval = time.Minute
+
+// The value of --max-severity which doesn't allow any findings
+const maxSeverityNone = "none"
+
+type options struct {
+	ProjectDir  string `mapstructure:"project-dir"`
+	ConfigDir   string `mapstructure:"config-dir"`
+	ProjectName string `mapstructure:"project"`
+	Server      string `mapstructure:"server"`
+
+	Campaign    string        `mapstructure:"-"`
+	MaxSeverity string        `mapstructure:"-"`
+	Timeout     time.Duration `mapstructure:"-"`
+
+	maxSeverity finding.SeverityLevel
+}
+
+type gateCmd struct {
+	*cobra.Command
+
+	opts      *options
+	apiClient *api.APIClient
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "gate [flags]",
+		Short: "Fail if a remote fuzzing run finds severe findings",
+		Long: `This command waits for a remote fuzzing run on CI Sense and exits
+with a non-zero exit code as soon as a finding with a severity higher
+than --max-severity is reported, so that release pipelines can be
+blocked on the results of continuous fuzzing.
+
+If --campaign is specified, the command waits until the campaign run
+has finished, or until --timeout has passed. The campaign run is the
+one printed by 'cifuzz remote-run --json', either as the full name or
+only as the ID in combination with --project.
+
+Without --campaign, all findings of the project which are reported
+within the --timeout monitoring window are checked.
+
+Findings without a severity are treated as critical findings. By
+default, any finding makes the command fail.
+
+If a notification webhook is configured in cifuzz.yaml, a message is
+posted to it for each finding which is reported while waiting.
+
+This command needs a token to access the API of the remote fuzzing
+server. You can specify this token via the CIFUZZ_API_TOKEN environment
+variable or by running 'cifuzz login' first. In CI jobs with an OpenID
+Connect ID token, like GitHub Actions workflows with the "id-token: write"
+permission or GitLab CI jobs which set CIFUZZ_OIDC_TOKEN via "id_tokens",
+the ID token is exchanged for an access token instead.`,
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			if opts.Campaign == "" && opts.Timeout <= 0 {
+				err := errors.New("Either --campaign or --timeout must be specified")
+				return cmdutils.WrapIncorrectUsageError(err)
+			}
+			if opts.Campaign != "" && !strings.HasPrefix(opts.Campaign, "projects/") {
+				if opts.ProjectName == "" {
+					err := errors.New("Flag \"project\" must be set if --campaign is not the full campaign run name")
+					return cmdutils.WrapIncorrectUsageError(err)
+				}
+				opts.Campaign = api.ConvertProjectNameForUseWithAPIV1V2(opts.ProjectName) + "/campaign_runs/" + opts.Campaign
+			}
+			if opts.Campaign != "" && opts.ProjectName == "" {
+				// The campaign run name has the format
+				// projects/<project>/campaign_runs/<id>
+				opts.ProjectName, err = api.ConvertProjectNameFromAPI(strings.SplitN(opts.Campaign, "/", 3)[1])
+				if err != nil {
+					return err
+				}
+			}
+			if opts.ProjectName == "" {
+				err := errors.New("Flag \"project\" must be set")
+				return cmdutils.WrapIncorrectUsageError(err)
+			}
+
+			if opts.MaxSeverity != maxSeverityNone {
+				opts.maxSeverity, err = finding.ParseSeverityLevel(opts.MaxSeverity)
+				if err != nil {
+					return cmdutils.WrapIncorrectUsageError(err)
+				}
+			}
+
+			if c := webhook.ConfigFromViper(); c != nil {
+				err = c.Validate()
+				if err != nil {
+					return cmdutils.WrapIncorrectUsageError(err)
+				}
+			}
+
+			opts.Server, err = api.ValidateAndNormalizeServerURL(opts.Server)
+			return err
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := gateCmd{Command: c, opts: opts}
+			cmd.apiClient = api.NewClient(opts.Server)
+			return cmd.run()
+		},
+	}
+
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddProjectFlag,
+		cmdutils.AddServerFlag,
+	)
+	cmd.Flags().StringVar(&opts.Campaign, "campaign", "",
+		"The campaign run to wait for, e.g. \"projects/my-project-c170bc17/campaign_runs/my-campaign-run\".")
+	cmd.Flags().StringVar(&opts.MaxSeverity, "max-severity", maxSeverityNone,
+		"The highest severity of findings which doesn't fail the command, one of \"none\", \"low\", \"medium\", \"high\" and \"critical\".")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", 0,
+		"Maximum time to wait, e.g. \"30m\", \"2h\". The default is to wait until the campaign run has finished.")
+
+	return cmd
+}
+
+func (c *gateCmd) run() error {
+	token, err := auth.GetValidToken(c.opts.Server)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	if c.opts.Campaign != "" {
+		log.Infof("Waiting for campaign run %s to finish...", c.opts.Campaign)
+	} else {
+		log.Infof("Monitoring findings of %s for %s...", c.opts.ProjectName, c.opts.Timeout)
+	}
+
+	// The findings which were already posted to the notification
+	// webhook
+	notified := map[string]bool{}
+
+	poller := &poll.Poller{
+		APIClient:   c.apiClient,
+		Token:       token,
+		ProjectName: c.opts.ProjectName,
+		CampaignRun: c.opts.Campaign,
+		Interval:    pollInterval,
+		Timeout:     c.opts.Timeout,
+	}
+	return poller.Run(func(state *poll.State) error {
+		if state.FindingsErr != nil {
+			// The gate must not pass without having checked the
+			// findings, so the error is only ignored while waiting
+			if state.Finished || state.TimedOut {
+				return state.FindingsErr
+			}
+			log.Warn(state.FindingsErr.Error())
+			return nil
+		}
+
+		for _, f := range findingsAboveThreshold(state.Findings, c.opts.Campaign, start, "") {
+			if !notified[f.Name] {
+				notified[f.Name] = true
+				webhook.Notify(c.findingNotification(&f))
+			}
+		}
+		failing := findingsAboveThreshold(state.Findings, c.opts.Campaign, start, c.opts.maxSeverity)
+		if len(failing) > 0 {
+			var lines []string
+			for _, f := range failing {
+				lines = append(lines, fmt.Sprintf("%s (%s) in %s", f.DisplayName, severityLevel(&f), f.FuzzTargetDisplayName))
+			}
+			return errors.Errorf("%d findings with a severity higher than %s were reported:\n  %s",
+				len(failing), c.opts.MaxSeverity, strings.Join(lines, "\n  "))
+		}
+
+		if state.Finished {
+			log.Successf("Campaign run finished without findings with a severity higher than %s", c.opts.MaxSeverity)
+		} else if state.TimedOut {
+			if c.opts.Campaign != "" {
+				log.Warnf("Campaign run %s did not finish within %s", c.opts.Campaign, c.opts.Timeout)
+			}
+			log.Successf("No findings with a severity higher than %s were reported", c.opts.MaxSeverity)
+		}
+		return nil
+	})
+}
+
+// findingsAboveThreshold returns the findings whose severity is higher
+// than the maximum severity. If a campaign run is specified, only its
+// findings are considered, else only the findings reported since the
+// given time are.
+func findingsAboveThreshold(findings []api.Finding, campaignRun string, since time.Time, maxSeverity finding.SeverityLevel) []api.Finding {
+	var result []api.Finding
+	for _, f := range findings {
+		if campaignRun != "" && f.CampaignRun != campaignRun {
+			continue
+		}
+		if campaignRun == "" {
+			timestamp, err := time.Parse(time.RFC3339, f.Timestamp)
+			// Findings with an invalid timestamp are considered, so
+			// that they don't slip through
+			if err == nil && timestamp.Before(since) {
+				continue
+			}
+		}
+		if maxSeverity != "" && !severityLevel(&f).IsHigherThan(maxSeverity) {
+			continue
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// findingNotification returns the message which is posted to the
+// notification webhook about a new remote finding
+func (c *gateCmd) findingNotification(f *api.Finding) *webhook.Message {
+	msg := &webhook.Message{
+		Title: fmt.Sprintf("New finding: %s (%s)", f.DisplayName, severityLevel(f)),
+		Fields: []*webhook.Field{
+			{Name: "Project", Value: c.opts.ProjectName},
+			{Name: "Fuzz test", Value: f.FuzzTargetDisplayName},
+		},
+	}
+	if f.ErrorReport != nil {
+		if f.ErrorReport.ShortDescription != "" {
+			msg.Fields = append(msg.Fields, &webhook.Field{Name: "Description", Value: f.ErrorReport.ShortDescription})
+		}
+		if f.ErrorReport.DebuggingInfo != nil {
+			for _, b := range f.ErrorReport.DebuggingInfo.BreakPoints {
+				frame := fmt.Sprintf("%s in %s", b.Function, b.SourceFilePath)
+				if b.Location != nil {
+					frame += fmt.Sprintf(":%d", b.Location.Line)
+				}
+				msg.StackTrace = append(msg.StackTrace, frame)
+			}
+		}
+	}
+	if f.CampaignRun != "" {
+		addr, err := cmdutils.BuildURLFromParts(c.opts.Server, "dashboard", f.CampaignRun, "overview")
+		if err == nil {
+			msg.URL = addr
+		}
+	}
+	return msg
+}
+
+// severityLevel returns the severity level of the finding. Findings
+// without a severity are treated as critical.
+func severityLevel(f *api.Finding) finding.SeverityLevel {
+	if f.ErrorReport == nil || f.ErrorReport.MoreDetails == nil || f.ErrorReport.MoreDetails.Severity == nil {
+		return finding.SeverityLevelCritical
+	}
+	level := f.ErrorReport.MoreDetails.Severity.EffectiveLevel()
+	if level == "" {
+		return finding.SeverityLevelCritical
+	}
+	return level
+}
@@ -0,0 +1,50 @@
+package gate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"code-intelligence.com/cifuzz/internal/api"
+	"code-intelligence.com/cifuzz/pkg/finding"
+)
+
+func TestFindingsAboveThreshold(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newFinding := func(name, campaignRun string, timestamp time.Time, severity *finding.Severity) api.Finding {
+		return api.Finding{
+			DisplayName: name,
+			CampaignRun: campaignRun,
+			Timestamp:   timestamp.Format(time.RFC3339),
+			ErrorReport: &api.ErrorReport{MoreDetails: &finding.ErrorDetails{Severity: severity}},
+		}
+	}
+	campaignRun := "projects/my-project/campaign_runs/my-run"
+	findings := []api.Finding{
+		newFinding("low", campaignRun, start.Add(time.Minute), &finding.Severity{Level: finding.SeverityLevelLow}),
+		newFinding("high", campaignRun, start.Add(time.Minute), &finding.Severity{Score: 7.5}),
+		newFinding("unknown", campaignRun, start.Add(time.Minute), nil),
+		newFinding("other-run", "projects/my-project/campaign_runs/other", start.Add(time.Minute), &finding.Severity{Score: 9.8}),
+		newFinding("old", "projects/my-project/campaign_runs/old", start.Add(-time.Hour), &finding.Severity{Score: 9.8}),
+	}
+
+	names := func(findings []api.Finding) []string {
+		var names []string
+		for _, f := range findings {
+			names = append(names, f.DisplayName)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"high", "unknown"},
+		names(findingsAboveThreshold(findings, campaignRun, start, finding.SeverityLevelMedium)))
+	assert.Equal(t, []string{"low", "high", "unknown"},
+		names(findingsAboveThreshold(findings, campaignRun, start, "")))
+	assert.Empty(t, findingsAboveThreshold(findings, campaignRun, start, finding.SeverityLevelCritical))
+
+	// Without a campaign run, only findings reported since the start
+	// are considered
+	assert.Equal(t, []string{"high", "unknown", "other-run"},
+		names(findingsAboveThreshold(findings, "", start, finding.SeverityLevelMedium)))
+}
@@ -0,0 +1,124 @@
+package poll
+
+import (
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/api"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// The number of times the findings are fetched on the last poll before
+// giving up, so that a finished campaign run isn't considered to have no
+// findings because of a single failed request
+var lastPollAttempts = 3
+
+// Poller polls the status of a campaign run and the findings of its
+// project, which is shared by the commands which wait for remote runs
+type Poller struct {
+	APIClient   *api.APIClient
+	Token       string
+	ProjectName string
+	// The campaign run to wait for. If it's empty, only the findings are
+	// polled until the timeout has passed.
+	CampaignRun string
+	Interval    time.Duration
+	// The maximum time to wait, or zero to wait until the campaign run
+	// has finished
+	Timeout time.Duration
+}
+
+// State is the result of a poll
+type State struct {
+	// The campaign run, which is nil if no campaign run is polled or it
+	// couldn't be retrieved because of a connection error
+	CampaignRun *api.CampaignRun
+	// The findings of the project, which are only valid if FindingsErr
+	// is nil
+	Findings    []api.Finding
+	FindingsErr error
+	// Finished is true if the campaign run has finished and TimedOut is
+	// true if the timeout has passed. In both cases, this is the last
+	// poll.
+	Finished bool
+	TimedOut bool
+}
+
+// Run polls the campaign run and the findings in the interval and calls
+// handle with the state after each poll, until the campaign run has
+// finished, the timeout has passed or handle returns an error. On the
+// last poll, fetching the findings is retried if it fails. If the
+// process receives a signal, a SignalError is returned.
+func (p *Poller) Run(handle func(*State) error) error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+	defer signal.Stop(sigs)
+
+	var deadline time.Time
+	if p.Timeout > 0 {
+		deadline = time.Now().Add(p.Timeout)
+	}
+
+	for {
+		state := &State{}
+		// Check the status before the findings, so that findings which
+		// are reported right before the campaign run finishes are not
+		// missed
+		if p.CampaignRun != "" {
+			campaignRun, err := p.APIClient.GetCampaignRun(p.CampaignRun, p.Token)
+			var connErr *api.ConnectionError
+			if errors.As(err, &connErr) {
+				// The status is retrieved again with the next poll
+				log.Warnf("Failed to get the status of campaign run %s: %v", p.CampaignRun, err)
+			} else if err != nil {
+				return err
+			} else {
+				state.CampaignRun = campaignRun
+				state.Finished = campaignRun.IsFinished()
+			}
+		}
+		state.TimedOut = !state.Finished && !deadline.IsZero() && time.Now().After(deadline)
+
+		attempts := 1
+		if state.Finished || state.TimedOut {
+			attempts = lastPollAttempts
+		}
+		state.Findings, state.FindingsErr = p.fetchFindings(attempts)
+
+		err := handle(state)
+		if err != nil || state.Finished || state.TimedOut {
+			return err
+		}
+
+		select {
+		case s := <-sigs:
+			return cmdutils.NewSignalError(s.(syscall.Signal))
+		case <-time.After(p.Interval):
+		}
+	}
+}
+
+// fetchFindings returns the findings of the project. Failed requests are
+// retried up to the given number of attempts, unless the server rejected
+// the request.
+func (p *Poller) fetchFindings(attempts int) ([]api.Finding, error) {
+	for attempt := 1; ; attempt++ {
+		remoteFindings, err := p.APIClient.DownloadRemoteFindings(p.ProjectName, p.Token)
+		if err == nil {
+			return remoteFindings.Findings, nil
+		}
+		var apiErr *api.APIError
+		isClientError := errors.As(err, &apiErr) && apiErr.StatusCode >= http.StatusBadRequest && apiErr.StatusCode < http.StatusInternalServerError
+		if attempt >= attempts || isClientError {
+			return nil, errors.WithMessagef(err, "Failed to get the findings of project %s", p.ProjectName)
+		}
+		log.Warnf("Failed to get the findings of project %s, retrying in %s: %v", p.ProjectName, p.Interval, err)
+		time.Sleep(p.Interval)
+	}
+}
@@ -0,0 +1,131 @@
+package poll
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/api"
+)
+
+const campaignRun = "projects/my_project/campaign_runs/my-run"
+
+func TestRun_RetriesFindingsOnLastPoll(t *testing.T) {
+	// Disable the retries of the API client, so that each poll sends
+	// exactly one request
+	viper.Set("api-max-retries", 0)
+	t.Cleanup(func() { viper.Set("api-max-retries", nil) })
+
+	var mutex sync.Mutex
+	findingsRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch r.URL.Path {
+		case "/v1/" + campaignRun:
+			fmt.Fprint(w, `{"status": "SUCCEEDED"}`)
+		case "/v1/projects/my_project/findings":
+			findingsRequests++
+			if findingsRequests < lastPollAttempts {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprintf(w, `{"findings": [{"name": "f1", "campaign_run": %q}]}`, campaignRun)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := &Poller{
+		APIClient:   api.NewClient(server.URL),
+		Token:       "token",
+		ProjectName: "my_project",
+		CampaignRun: campaignRun,
+		Interval:    time.Millisecond,
+	}
+	var states []*State
+	err := p.Run(func(state *State) error {
+		states = append(states, state)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	assert.True(t, states[0].Finished)
+	require.NoError(t, states[0].FindingsErr)
+	assert.Len(t, states[0].Findings, 1)
+	assert.Equal(t, lastPollAttempts, findingsRequests)
+}
+
+func TestRun_FindingsUnavailable(t *testing.T) {
+	viper.Set("api-max-retries", 0)
+	t.Cleanup(func() { viper.Set("api-max-retries", nil) })
+
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/"+campaignRun {
+			polls++
+			status := "RUNNING"
+			if polls == 2 {
+				status = "SUCCEEDED"
+			}
+			fmt.Fprintf(w, `{"status": %q}`, status)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	p := &Poller{
+		APIClient:   api.NewClient(server.URL),
+		Token:       "token",
+		ProjectName: "my_project",
+		CampaignRun: campaignRun,
+		Interval:    time.Millisecond,
+	}
+	var states []*State
+	err := p.Run(func(state *State) error {
+		states = append(states, state)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, states, 2)
+	assert.False(t, states[0].Finished)
+	assert.Error(t, states[0].FindingsErr)
+	// The last state reports the error, so that the caller can decide
+	// whether the result can be trusted
+	assert.True(t, states[1].Finished)
+	assert.Error(t, states[1].FindingsErr)
+}
+
+func TestRun_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"findings": []}`)
+	}))
+	defer server.Close()
+
+	// Without a campaign run, only the findings are polled until the
+	// timeout has passed
+	p := &Poller{
+		APIClient:   api.NewClient(server.URL),
+		Token:       "token",
+		ProjectName: "my_project",
+		Interval:    time.Millisecond,
+		Timeout:     10 * time.Millisecond,
+	}
+	var last *State
+	err := p.Run(func(state *State) error {
+		last = state
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, last.TimedOut)
+	assert.Nil(t, last.CampaignRun)
+}
@@ -43,3 +43,34 @@ func DrawProgressBar(w io.Writer, drawFormatBar ioprogress.DrawTextFormatFunc, s
 		return errors.WithStack(err)
 	}
 }
+
+// Bar is a progress bar for operations which don't read from a single
+// reader, like uploads in chunks, where the progress can go back if a
+// chunk has to be sent again
+type Bar struct {
+	draw     ioprogress.DrawFunc
+	total    int64
+	lastDraw time.Time
+}
+
+func NewBar(total int64, successMessage string) *Bar {
+	return &Bar{
+		draw:  DrawProgressBar(os.Stdout, ioprogress.DrawTextFormatBar(60), successMessage),
+		total: total,
+	}
+}
+
+// Set draws the progress bar with the given progress, at most every
+// 100 milliseconds
+func (b *Bar) Set(progress int64) {
+	if progress < b.total && time.Since(b.lastDraw) < 100*time.Millisecond {
+		return
+	}
+	b.lastDraw = time.Now()
+	_ = b.draw(progress, b.total)
+}
+
+// Done clears the progress bar and prints the success message
+func (b *Bar) Done() {
+	_ = b.draw(-1, -1)
+}
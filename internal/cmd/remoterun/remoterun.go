@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -14,6 +15,7 @@ import (
 	"code-intelligence.com/cifuzz/internal/api"
 	"code-intelligence.com/cifuzz/internal/bundler"
 	"code-intelligence.com/cifuzz/internal/cmd/bundle"
+	"code-intelligence.com/cifuzz/internal/cmd/remoterun/gate"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/cmdutils/auth"
 	"code-intelligence.com/cifuzz/internal/cmdutils/logging"
@@ -36,7 +38,11 @@ type remoteRunOpts struct {
 	// Fields which are not configurable via viper (i.e. via cifuzz.yaml
 	// and CIFUZZ_* environment variables), by setting
 	// mapstructure:"-"
-	BundlePath            string `mapstructure:"-"`
+	BundlePath            string        `mapstructure:"-"`
+	Follow                bool          `mapstructure:"-"`
+	Wait                  bool          `mapstructure:"-"`
+	WaitTimeout           time.Duration `mapstructure:"-"`
+	FailOn                string        `mapstructure:"-"`
 	ResolveSourceFilePath bool
 }
 
@@ -59,6 +65,15 @@ func (opts *remoteRunOpts) Validate() error {
 		}
 	}
 
+	if opts.FailOn != "" && opts.FailOn != failOnFinding {
+		msg := fmt.Sprintf("Invalid value %q for --fail-on, the only valid value is %q", opts.FailOn, failOnFinding)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if (opts.FailOn != "" || opts.WaitTimeout > 0) && !opts.Wait && !opts.Follow {
+		msg := "--fail-on and --wait-timeout can only be used with --wait or --follow"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
 	if opts.Interactive {
 		opts.Interactive = term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
 	}
@@ -100,6 +115,22 @@ dictionary locations can be found in the help message of the run command.
 If the --bundle flag is used, building and bundling is skipped and the
 specified bundle is uploaded to start a remote fuzzing run instead.
 
+With --follow, the command doesn't exit after starting the remote run,
+but prints the status of the run, the output of the fuzzers and new
+findings until the run has finished. The command fails if the run
+failed. Interrupting the command stops following the run, but not the
+run itself. --wait waits for the run in the same way, but only prints
+status changes and new findings.
+
+With --fail-on=finding, the command fails if the run reported any
+findings, so that CI pipelines can be gated on the results of remote
+fuzzing. Use --wait-timeout to limit how long the command waits, the
+findings reported until then decide about the exit code.
+
+The bundle is uploaded in chunks. If the upload of a chunk fails because
+of a network error or a temporary server error, the upload is resumed
+automatically from where it stopped.
+
 This command needs a token to access the API of the remote fuzzing
 server. You can specify this token via the CIFUZZ_API_TOKEN environment
 variable or by running 'cifuzz login' first.
@@ -190,6 +221,7 @@ variable or by running 'cifuzz login' first.
 		cmdutils.AddEngineArgFlag,
 		cmdutils.AddEnvFlag,
 		cmdutils.AddInteractiveFlag,
+		cmdutils.AddLabelFlag,
 		cmdutils.AddPrintJSONFlag,
 		cmdutils.AddProjectDirFlag,
 		cmdutils.AddProjectFlag,
@@ -199,6 +231,16 @@ variable or by running 'cifuzz login' first.
 		cmdutils.AddResolveSourceFileFlag,
 	)
 	cmd.Flags().StringVar(&opts.BundlePath, "bundle", "", "Path of an existing bundle to start a remote run with.")
+	cmd.Flags().BoolVar(&opts.Follow, "follow", false,
+		"Print the status and fuzzer logs of the remote run until it has finished.")
+	cmd.Flags().BoolVar(&opts.Wait, "wait", false,
+		"Wait until the remote run has finished and fail if it failed.")
+	cmd.Flags().DurationVar(&opts.WaitTimeout, "wait-timeout", 0,
+		"Maximum time to wait with --wait or --follow, e.g. \"30m\", \"2h\". The default is to wait until the remote run has finished.")
+	cmd.Flags().StringVar(&opts.FailOn, "fail-on", "",
+		"With \"finding\", fail if the remote run reported any findings while waiting with --wait or --follow.")
+
+	cmd.AddCommand(gate.New())
 
 	return cmd
 }
@@ -312,5 +354,18 @@ func (c *runRemoteCmd) run() error {
 `, addr)
 	}
 
+	if c.opts.Follow || c.opts.Wait {
+		// If --json was specified, only the result is printed to stdout
+		out := c.OutOrStdout()
+		if c.opts.PrintJSON {
+			out = c.ErrOrStderr()
+		}
+		f := newFollower(c.apiClient, token, c.opts.ProjectName, campaignRunName, out)
+		f.printLogs = c.opts.Follow
+		f.timeout = c.opts.WaitTimeout
+		f.failOnFinding = c.opts.FailOn == failOnFinding
+		return f.follow()
+	}
+
 	return nil
 }
@@ -0,0 +1,107 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/runsummary"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+)
+
+const (
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+	formatJSON     = "json"
+)
+
+var formats = []string{formatMarkdown, formatHTML, formatJSON}
+
+type options struct {
+	ProjectDir string `mapstructure:"project-dir"`
+	ConfigDir  string `mapstructure:"config-dir"`
+	Format     string `mapstructure:"-"`
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "report [flags]",
+		Short: "Summarize the results of the last run",
+		Long: `This command prints a summary of the last 'cifuzz run' in the project,
+which contains the findings of the run, the edge coverage and the growth
+of the corpus.
+
+The Markdown format (the default) is compact and suitable for posting
+the summary as a comment on a pull request, for example:
+
+    cifuzz run --regression my_fuzz_test
+    cifuzz report --format=markdown > summary.md
+
+The HTML format creates a standalone report, which additionally
+contains the stack traces and logs of the findings and links to download
+their crashing inputs, for sharing the results with people who don't
+use cifuzz:
+
+    cifuzz report --format=html > report.html`,
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			if !sliceutil.Contains(formats, opts.Format) {
+				msg := fmt.Sprintf("Invalid format %q, valid formats are: %s", opts.Format, strings.Join(formats, ", "))
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			summary, err := runsummary.LoadLast(opts.ProjectDir)
+			if os.IsNotExist(errors.Cause(err)) {
+				return errors.New("No run was recorded in this project yet, please run a fuzz test via 'cifuzz run' first")
+			}
+			if err != nil {
+				return err
+			}
+
+			switch opts.Format {
+			case formatHTML:
+				return summary.WriteHTML(c.OutOrStdout(), opts.ProjectDir)
+			case formatJSON:
+				bytes, err := json.MarshalIndent(summary, "", "  ")
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				_, err = fmt.Fprintln(c.OutOrStdout(), string(bytes))
+				return errors.WithStack(err)
+			}
+			_, err = fmt.Fprint(c.OutOrStdout(), summary.Markdown())
+			return errors.WithStack(err)
+		},
+	}
+
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+	)
+	cmd.Flags().StringVar(&opts.Format, "format", formatMarkdown,
+		fmt.Sprintf("The output format of the summary (%s).", strings.Join(formats, ", ")))
+
+	return cmd
+}
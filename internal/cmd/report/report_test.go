@@ -0,0 +1,50 @@
+package report
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/runsummary"
+)
+
+func TestReport(t *testing.T) {
+	projectDir := testutil.BootstrapEmptyProject(t, "test-report-")
+	opts := &options{
+		ProjectDir: projectDir,
+		ConfigDir:  projectDir,
+	}
+
+	// Without a recorded run, the command fails
+	_, _, err := cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin)
+	require.Error(t, err)
+
+	summary := &runsummary.Summary{
+		FuzzTest:             "my_fuzz_test",
+		Duration:             time.Minute,
+		CorpusEntriesAtStart: 2,
+		CorpusEntries:        5,
+	}
+	require.NoError(t, summary.Save(projectDir))
+
+	stdout, _, err := cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "--format=markdown")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "| Corpus entries | 2 → 5 (+3) |")
+
+	stdout, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "--format=html")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "<title>cifuzz report: my_fuzz_test</title>")
+
+	stdout, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "--format=json")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, `"fuzz_test": "my_fuzz_test"`)
+
+	_, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "--format=pdf")
+	var usageErr *cmdutils.IncorrectUsageError
+	require.ErrorAs(t, err, &usageErr)
+}
@@ -0,0 +1,133 @@
+package reproduce
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	findingCmd "code-intelligence.com/cifuzz/internal/cmd/finding"
+	"code-intelligence.com/cifuzz/internal/cmd/run/adapter"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/cmdutils/logging"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+type reproduceCmd struct {
+	*cobra.Command
+	opts    *adapter.RunOptions
+	finding *finding.Finding
+}
+
+func New() *cobra.Command {
+	opts := &adapter.RunOptions{}
+	var f *finding.Finding
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "reproduce [flags] <finding>",
+		Short: "Check if a finding still reproduces",
+		Long: `This command builds the fuzz test which found the finding in the same
+configuration as when the finding was found, i.e. with the same
+sanitizers, engine arguments and build system arguments, and runs it
+once on the crashing input of the finding.
+
+The state of the finding is updated accordingly: It's set to "fixed"
+if the crashing input doesn't trigger a crash anymore. If it still
+does and the finding was marked as fixed, it's set back to "new". The
+state is shown by 'cifuzz finding'.
+
+Reproducing findings is supported for C/C++ and Java projects.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ValidFindings,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			f, err = finding.LoadFinding(opts.ProjectDir, args[0], nil)
+			if finding.IsNotExistError(err) {
+				return errors.WithMessagef(err, "Finding %s does not exist", args[0])
+			}
+			if err != nil {
+				return err
+			}
+			err = opts.ApplyFindingConfig(f)
+			if err != nil {
+				return err
+			}
+
+			opts.BuildStdout = cmd.OutOrStdout()
+			opts.BuildStderr = cmd.OutOrStderr()
+			opts.Stdout = cmd.OutOrStdout()
+			opts.Stderr = cmd.OutOrStderr()
+
+			if logging.ShouldLogBuildToFile() {
+				opts.BuildStdout, err = logging.BuildOutputToFile(opts.ProjectDir, []string{opts.FuzzTest})
+				if err != nil {
+					return err
+				}
+				opts.BuildStderr = opts.BuildStdout
+			}
+
+			return opts.Validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := reproduceCmd{Command: c, opts: opts, finding: f}
+			return cmd.run()
+		},
+	}
+
+	// Note: If a flag should be configurable via cifuzz.yaml as well,
+	// bind it to viper in the PreRunE function.
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddCleanCommandFlag,
+		cmdutils.AddBuildJobsFlag,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddSandboxFlag,
+		cmdutils.AddUseSandboxFlag,
+	)
+	return cmd
+}
+
+func (c *reproduceCmd) run() error {
+	a, err := adapter.NewAdapter(c.opts)
+	if err != nil {
+		return err
+	}
+	defer a.Cleanup()
+
+	err = a.CheckDependencies(c.opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	reportHandler, err := a.Run(c.opts)
+	if err != nil {
+		return err
+	}
+
+	if len(reportHandler.Findings) > 0 {
+		if c.finding.State == finding.StateFixed {
+			// The bug was reintroduced or not fixed after all
+			c.finding.State = finding.StateNew
+		}
+		log.Warnf("Finding %s still reproduces", c.finding.Name)
+	} else {
+		c.finding.State = finding.StateFixed
+		log.Successf("Finding %s does not reproduce anymore", c.finding.Name)
+		// The crash might depend on the environment
+		findingCmd.PrintEnvironmentDiff(c.finding)
+	}
+	c.finding.LastReproduced = time.Now()
+	return c.finding.Save(c.opts.ProjectDir)
+}
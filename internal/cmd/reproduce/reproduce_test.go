@@ -0,0 +1,66 @@
+package reproduce
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/dependencies"
+	"code-intelligence.com/cifuzz/pkg/finding"
+)
+
+func TestMain(m *testing.M) {
+	viper.Set("verbose", true)
+	m.Run()
+}
+
+func TestFindingDoesNotExist(t *testing.T) {
+	testutil.BootstrapExampleProjectForTest(t, "reproduce-cmd-test", config.BuildSystemCMake)
+
+	_, _, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "no_such_finding")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Finding no_such_finding does not exist")
+}
+
+func TestClangMissing(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("clang is not needed on windows and will be provided by Visual Studio")
+	}
+
+	dependencies.TestMockAllDeps(t)
+	// let the clang dep fail
+	dependencies.OverwriteUninstalled(dependencies.GetDep(dependencies.Clang))
+
+	projectDir := testutil.BootstrapExampleProjectForTest(t, "reproduce-cmd-test", config.BuildSystemCMake)
+	f := &finding.Finding{
+		Name:      "test_finding",
+		FuzzTest:  "my_fuzz_test",
+		InputFile: ".cifuzz-findings/test_finding/crashing-input",
+		RunConfig: &finding.RunConfig{
+			BuildSystem: config.BuildSystemCMake,
+			Sanitizers:  []string{"address"},
+		},
+	}
+	err := f.Save(projectDir)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(projectDir, f.InputFile), []byte("crash"), 0o644)
+	require.NoError(t, err)
+
+	_, stdErr, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, f.Name)
+	require.Error(t, err)
+	assert.Contains(t, stdErr, fmt.Sprintf(dependencies.MessageMissing, "clang"))
+
+	// The state of the finding is only updated if the fuzz test was run
+	f, err = finding.LoadFinding(projectDir, f.Name, nil)
+	require.NoError(t, err)
+	assert.Empty(t, f.State)
+}
@@ -13,23 +13,44 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
+	analyzeCmd "code-intelligence.com/cifuzz/internal/cmd/analyze"
+	bisectCmd "code-intelligence.com/cifuzz/internal/cmd/bisect"
 	bundleCmd "code-intelligence.com/cifuzz/internal/cmd/bundle"
+	configCmd "code-intelligence.com/cifuzz/internal/cmd/config"
 	containerCmd "code-intelligence.com/cifuzz/internal/cmd/container"
+	corpusCmd "code-intelligence.com/cifuzz/internal/cmd/corpus"
 	coverageCmd "code-intelligence.com/cifuzz/internal/cmd/coverage"
 	createCmd "code-intelligence.com/cifuzz/internal/cmd/create"
+	debugCmd "code-intelligence.com/cifuzz/internal/cmd/debug"
+	dictCmd "code-intelligence.com/cifuzz/internal/cmd/dict"
 	executeCmd "code-intelligence.com/cifuzz/internal/cmd/execute"
+	exportCmd "code-intelligence.com/cifuzz/internal/cmd/export"
 	findingCmd "code-intelligence.com/cifuzz/internal/cmd/finding"
+	graphCmd "code-intelligence.com/cifuzz/internal/cmd/graph"
+	importCmd "code-intelligence.com/cifuzz/internal/cmd/import"
 	initCmd "code-intelligence.com/cifuzz/internal/cmd/init"
 	integrateCmd "code-intelligence.com/cifuzz/internal/cmd/integrate"
 	loginCmd "code-intelligence.com/cifuzz/internal/cmd/login"
 	printflagsCmds "code-intelligence.com/cifuzz/internal/cmd/print-flags"
+	projectCmd "code-intelligence.com/cifuzz/internal/cmd/project"
 	reloadCmd "code-intelligence.com/cifuzz/internal/cmd/reload"
 	remoteRunCmd "code-intelligence.com/cifuzz/internal/cmd/remoterun"
+	reportCmd "code-intelligence.com/cifuzz/internal/cmd/report"
+	reproduceCmd "code-intelligence.com/cifuzz/internal/cmd/reproduce"
 	runCmd "code-intelligence.com/cifuzz/internal/cmd/run"
+	statsCmd "code-intelligence.com/cifuzz/internal/cmd/stats"
+	toolsCmd "code-intelligence.com/cifuzz/internal/cmd/tools"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/toolchain"
 	"code-intelligence.com/cifuzz/internal/version"
 	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/tracing"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
 )
 
 func New() (*cobra.Command, error) {
@@ -41,6 +62,16 @@ func New() (*cobra.Command, error) {
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			switch viper.GetString("output-format") {
+			case outputText:
+			case outputJSON:
+				log.EnableJSONOutput()
+			default:
+				msg := fmt.Sprintf("Invalid output format %q, valid formats are: %s, %s",
+					viper.GetString("output-format"), outputText, outputJSON)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
 			log.Infof("cifuzz version %s", version.Version)
 			log.Debugf("Running on %s/%s", runtime.GOOS, runtime.GOARCH)
 
@@ -94,6 +125,15 @@ func New() (*cobra.Command, error) {
 		return nil, errors.WithStack(err)
 	}
 
+	// Commands which have their own --output flag for an output path
+	// shadow this flag, so it's bound to the "output-format" key, which
+	// can also be set via the CIFUZZ_OUTPUT_FORMAT environment variable
+	rootCmd.PersistentFlags().String("output", outputText,
+		"Output format (text, json). With json, all messages and results are printed as newline-delimited JSON events to stdout")
+	if err := viper.BindPFlag("output-format", rootCmd.PersistentFlags().Lookup("output")); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
 	rootCmd.SetFlagErrorFunc(rootFlagErrorFunc)
 	rootCmd.SetVersionTemplate(fmt.Sprintf("cifuzz version %s\nRunning on %s/%s\n", version.Version, runtime.GOOS, runtime.GOARCH))
 
@@ -104,11 +144,25 @@ func New() (*cobra.Command, error) {
 	rootCmd.AddCommand(createCmd.New())
 	rootCmd.AddCommand(runCmd.New())
 	rootCmd.AddCommand(remoteRunCmd.New())
+	rootCmd.AddCommand(projectCmd.New())
 	rootCmd.AddCommand(reloadCmd.New())
 	rootCmd.AddCommand(bundleCmd.New())
 	rootCmd.AddCommand(coverageCmd.New())
+	rootCmd.AddCommand(analyzeCmd.New())
+	rootCmd.AddCommand(corpusCmd.New())
 	rootCmd.AddCommand(findingCmd.New())
+	rootCmd.AddCommand(reproduceCmd.New())
+	rootCmd.AddCommand(reportCmd.New())
+	rootCmd.AddCommand(statsCmd.New())
+	rootCmd.AddCommand(debugCmd.New())
+	rootCmd.AddCommand(bisectCmd.New())
+	rootCmd.AddCommand(dictCmd.New())
+	rootCmd.AddCommand(graphCmd.New())
+	rootCmd.AddCommand(toolsCmd.New())
+	rootCmd.AddCommand(configCmd.New())
 	rootCmd.AddCommand(integrateCmd.New())
+	rootCmd.AddCommand(exportCmd.New())
+	rootCmd.AddCommand(importCmd.New())
 
 	for _, cmd := range printflagsCmds.New() {
 		rootCmd.AddCommand(cmd)
@@ -119,9 +173,35 @@ func New() (*cobra.Command, error) {
 		rootCmd.AddCommand(executeCmd.New())
 	}
 
+	setupToolchainBeforeRun(rootCmd)
+
 	return rootCmd, nil
 }
 
+// setupToolchainBeforeRun makes the commands use the C/C++ toolchain
+// installed via `cifuzz tools install cxxtoolchain` instead of the
+// system compiler if use-cxx-toolchain is set in cifuzz.yaml. This can't
+// be done in PersistentPreRunE, because the commands parse cifuzz.yaml
+// in their PreRunE, so their RunE is wrapped instead.
+func setupToolchainBeforeRun(cmd *cobra.Command) {
+	for _, c := range cmd.Commands() {
+		setupToolchainBeforeRun(c)
+	}
+	if cmd.RunE == nil {
+		return
+	}
+	runE := cmd.RunE
+	cmd.RunE = func(c *cobra.Command, args []string) error {
+		if cmdutils.NeedsConfig(c) && viper.GetBool("use-cxx-toolchain") {
+			err := toolchain.SetupEnv()
+			if err != nil {
+				return err
+			}
+		}
+		return runE(c, args)
+	}
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -131,8 +211,22 @@ func Execute() {
 		os.Exit(1)
 	}
 
-	var cmd *cobra.Command
-	if cmd, err = rootCmd.ExecuteC(); err != nil {
+	span := tracing.Start("cifuzz")
+	cmd, err := rootCmd.ExecuteC()
+	if cmd != nil {
+		span.SetName(cmd.CommandPath())
+	}
+	span.End(err)
+	// Tracing is opt-in, the spans are only exported if an OTLP
+	// endpoint is configured
+	if endpoint := viper.GetString("otlp-endpoint"); endpoint != "" {
+		exportErr := tracing.Export(endpoint, version.Version)
+		if exportErr != nil {
+			log.Warnf("Failed to export the traces: %v", exportErr)
+		}
+	}
+
+	if err != nil {
 		// Error types that need special handling
 		var usageErr *cmdutils.IncorrectUsageError
 		var couldBeSandboxError *cmdutils.CouldBeSandboxError
@@ -5,6 +5,7 @@ import (
 
 	"code-intelligence.com/cifuzz/internal/cmd/run/reporthandler"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/dependencies"
 )
 
 type Adapter interface {
@@ -33,3 +34,11 @@ func NewAdapter(opts *RunOptions) (Adapter, error) {
 	}
 	return adapter, nil
 }
+
+// checkSymbolizer checks if llvm-symbolizer is available. It's only
+// needed to symbolize the stack traces of findings, so fuzzing works
+// without it.
+func checkSymbolizer(projectDir string) {
+	dependencies.CheckOptional([]dependencies.Key{dependencies.LLVMSymbolizer}, projectDir,
+		"Symbolizing the stack traces of findings")
+}
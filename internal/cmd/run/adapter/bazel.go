@@ -100,7 +100,7 @@ func (r *BazelAdapter) build(opts *RunOptions) (*build.BuildResult, error) {
 	}
 
 	var buildResults []*build.BuildResult
-	buildResults, err = builder.BuildForRun([]string{opts.FuzzTest})
+	buildResults, err = builder.BuildForRun(opts.Sanitizers, []string{opts.FuzzTest})
 	if err != nil {
 		return nil, err
 	}
@@ -18,7 +18,6 @@ func (r *CMakeAdapter) CheckDependencies(projectDir string) error {
 	var deps []dependencies.Key
 	deps = []dependencies.Key{
 		dependencies.CMake,
-		dependencies.LLVMSymbolizer,
 	}
 	switch runtime.GOOS {
 	case "linux", "darwin":
@@ -27,7 +26,12 @@ func (r *CMakeAdapter) CheckDependencies(projectDir string) error {
 		deps = append(deps, dependencies.VisualStudio)
 	}
 
-	return dependencies.Check(deps, projectDir)
+	err := dependencies.Check(deps, projectDir)
+	if err != nil {
+		return err
+	}
+	checkSymbolizer(projectDir)
+	return nil
 }
 
 func (r *CMakeAdapter) Run(opts *RunOptions) (*reporthandler.ReportHandler, error) {
@@ -59,13 +63,11 @@ func (r *CMakeAdapter) Run(opts *RunOptions) (*reporthandler.ReportHandler, erro
 }
 
 func (r *CMakeAdapter) build(opts *RunOptions) (*build.CBuildResult, error) {
-	sanitizers := []string{"address", "undefined"}
-
 	var builder *cmake.Builder
 	builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
 		ProjectDir: opts.ProjectDir,
 		Args:       opts.ArgsToPass,
-		Sanitizers: sanitizers,
+		Sanitizers: opts.Sanitizers,
 		Parallel: cmake.ParallelOptions{
 			Enabled: viper.IsSet("build-jobs"),
 			NumJobs: opts.NumBuildJobs,
@@ -0,0 +1,199 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	fuzzer_runner "code-intelligence.com/cifuzz/pkg/runner"
+	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+const (
+	DebuggerGDB  = "gdb"
+	DebuggerLLDB = "lldb"
+)
+
+var Debuggers = []string{DebuggerGDB, DebuggerLLDB}
+
+type DebugOptions struct {
+	// The debugger which is used for C/C++ fuzz tests. If it's empty,
+	// lldb is used on macOS and gdb on other systems.
+	Debugger string
+	// The port on which the JVM of Java fuzz tests waits for the
+	// debugger to attach
+	JDWPPort int
+	// Instead of launching the debugger, print a VS Code launch
+	// configuration to Output. For Java fuzz tests, the fuzz test is
+	// still started and waits for VS Code to attach.
+	IDE    bool
+	Output io.Writer
+}
+
+// The subset of the VS Code launch.json format which we use, see
+// https://code.visualstudio.com/docs/editor/debugging#_launch-configurations
+type launchConfig struct {
+	Version        string            `json:"version"`
+	Configurations []*launchSettings `json:"configurations"`
+}
+
+type launchSettings struct {
+	Name        string              `json:"name"`
+	Type        string              `json:"type"`
+	Request     string              `json:"request"`
+	Program     string              `json:"program,omitempty"`
+	Args        []string            `json:"args,omitempty"`
+	Cwd         string              `json:"cwd,omitempty"`
+	Environment []launchEnvVariable `json:"environment,omitempty"`
+	MIMode      string              `json:"MIMode,omitempty"`
+	HostName    string              `json:"hostName,omitempty"`
+	Port        int                 `json:"port,omitempty"`
+}
+
+type launchEnvVariable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// debugLibfuzzer runs the fuzz test executable on opts.ReproduceInput
+// in gdb or lldb. The sanitizers are configured to abort on the first
+// error, so that the debugger stops at the crash.
+func debugLibfuzzer(opts *RunOptions, libfuzzerOpts *libfuzzer.RunnerOptions) error {
+	debugger := opts.Debug.Debugger
+	if debugger == "" {
+		debugger = DebuggerGDB
+		if runtime.GOOS == "darwin" {
+			debugger = DebuggerLLDB
+		}
+	}
+
+	env, err := libfuzzer.NewRunner(libfuzzerOpts).FuzzerEnvironment()
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{"ASAN_OPTIONS", "UBSAN_OPTIONS"} {
+		value := fuzzer_runner.SetSanitizerOptions(envutil.Getenv(env, key), nil, map[string]string{"abort_on_error": "1"})
+		env, err = envutil.Setenv(env, key, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The input must still exist when the IDE launches the fuzz test,
+	// so it's only removed when the debugger is launched by us
+	inputDir, err := os.MkdirTemp("", "cifuzz-debug-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !opts.Debug.IDE {
+		defer fileutil.Cleanup(inputDir)
+	}
+	input, err := copyInput(opts.ReproduceInput, inputDir)
+	if err != nil {
+		return err
+	}
+	// libFuzzer's timeout would kill the fuzz test while the debugger
+	// is paused
+	args := append([]string{}, libfuzzerOpts.EngineArgs...)
+	args = append(args, "-timeout=0", input)
+
+	if opts.Debug.IDE {
+		settings := &launchSettings{
+			Name:    "cifuzz: " + opts.FuzzTest,
+			Type:    "cppdbg",
+			Request: "launch",
+			Program: libfuzzerOpts.FuzzTarget,
+			Args:    args,
+			Cwd:     opts.ProjectDir,
+			MIMode:  debugger,
+		}
+		envMap := envutil.ToMap(env)
+		keys := make([]string, 0, len(envMap))
+		for key := range envMap {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			settings.Environment = append(settings.Environment, launchEnvVariable{Name: key, Value: envMap[key]})
+		}
+		return printLaunchConfig(opts.Debug.Output, settings)
+	}
+
+	var cmd *exec.Cmd
+	switch debugger {
+	case DebuggerGDB:
+		cmd = exec.Command(debugger, append([]string{"--args", libfuzzerOpts.FuzzTarget}, args...)...)
+	case DebuggerLLDB:
+		cmd = exec.Command(debugger, append([]string{"--", libfuzzerOpts.FuzzTarget}, args...)...)
+	default:
+		return errors.Errorf("Unsupported debugger %q", debugger)
+	}
+	cmd.Env, err = envutil.Copy(os.Environ(), env)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	log.Infof("Launching %s with the crashing input. Start the fuzz test with the \"run\" command.", debugger)
+	log.Debugf("Command: %s", envutil.QuotedCommandWithEnv(cmd.Args, env))
+	err = cmd.Run()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return errors.Errorf("%s was not found, please install it or select a different debugger via --debugger", debugger)
+		}
+		return cmdutils.WrapExecError(errors.WithStack(err), cmd)
+	}
+	return nil
+}
+
+// debugJazzer runs the fuzz test on opts.ReproduceInput in a JVM which
+// waits for a debugger to attach via JDWP before it starts
+func debugJazzer(opts *RunOptions, libfuzzerOpts *libfuzzer.RunnerOptions, newRunner func() FuzzerRunner) error {
+	agent := fmt.Sprintf("-agentlib:jdwp=transport=dt_socket,server=y,suspend=y,address=%d", opts.Debug.JDWPPort)
+	javaToolOptions := strings.TrimSpace(os.Getenv("JAVA_TOOL_OPTIONS") + " " + agent)
+	libfuzzerOpts.EnvVars = append(libfuzzerOpts.EnvVars, "JAVA_TOOL_OPTIONS="+javaToolOptions)
+	// Jazzer's timeout would kill the fuzz test while the debugger is
+	// paused
+	libfuzzerOpts.EngineArgs = append(libfuzzerOpts.EngineArgs, "-timeout=0")
+
+	if opts.Debug.IDE {
+		err := printLaunchConfig(opts.Debug.Output, &launchSettings{
+			Name:     "cifuzz: " + opts.FuzzTest,
+			Type:     "java",
+			Request:  "attach",
+			HostName: "localhost",
+			Port:     opts.Debug.JDWPPort,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Infof("Waiting for a debugger to attach on port %d", opts.Debug.JDWPPort)
+	return reproduceInput(opts, libfuzzerOpts, newRunner)
+}
+
+func printLaunchConfig(w io.Writer, settings *launchSettings) error {
+	bytes, err := json.MarshalIndent(&launchConfig{
+		Version:        "0.2.0",
+		Configurations: []*launchSettings{settings},
+	}, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = fmt.Fprintln(w, string(bytes))
+	return errors.WithStack(err)
+}
@@ -0,0 +1,59 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
+	"code-intelligence.com/cifuzz/util/envutil"
+)
+
+func TestDebugLibfuzzer_IDE(t *testing.T) {
+	projectDir := t.TempDir()
+	input := filepath.Join(projectDir, "crashing-input")
+	require.NoError(t, os.WriteFile(input, []byte("crash"), 0o644))
+
+	var out bytes.Buffer
+	opts := &RunOptions{
+		ProjectDir:     projectDir,
+		FuzzTest:       "my_fuzz_test",
+		ReproduceInput: input,
+		Debug:          &DebugOptions{Debugger: DebuggerLLDB, IDE: true, Output: &out},
+	}
+	libfuzzerOpts := &libfuzzer.RunnerOptions{
+		FuzzTarget: filepath.Join(projectDir, "my_fuzz_test"),
+		EngineArgs: []string{"-rss_limit_mb=4096"},
+	}
+	err := debugLibfuzzer(opts, libfuzzerOpts)
+	require.NoError(t, err)
+
+	var config launchConfig
+	require.NoError(t, json.Unmarshal(out.Bytes(), &config))
+	require.Len(t, config.Configurations, 1)
+	settings := config.Configurations[0]
+	assert.Equal(t, "cppdbg", settings.Type)
+	assert.Equal(t, DebuggerLLDB, settings.MIMode)
+	assert.Equal(t, libfuzzerOpts.FuzzTarget, settings.Program)
+	assert.Equal(t, projectDir, settings.Cwd)
+	require.Len(t, settings.Args, 3)
+	assert.Equal(t, []string{"-rss_limit_mb=4096", "-timeout=0"}, settings.Args[:2])
+
+	// The input is copied, so that it still exists when the IDE
+	// launches the fuzz test
+	t.Cleanup(func() { _ = os.RemoveAll(filepath.Dir(settings.Args[2])) })
+	data, err := os.ReadFile(settings.Args[2])
+	require.NoError(t, err)
+	assert.Equal(t, "crash", string(data))
+
+	var env []string
+	for _, v := range settings.Environment {
+		env = append(env, v.Name+"="+v.Value)
+	}
+	assert.Contains(t, envutil.Getenv(env, "ASAN_OPTIONS"), "abort_on_error=1")
+}
@@ -32,10 +32,16 @@ func (r *NodeJSAdapter) Run(opts *RunOptions) (*reporthandler.ReportHandler, err
 	if err != nil {
 		return nil, err
 	}
+	defer reportHandler.WaitForNotifications()
 
 	style := pterm.Style{pterm.Reset, pterm.FgLightBlue}
 	log.Infof("Running %s", style.Sprintf(opts.FuzzTest+":"+opts.TestNamePattern))
 
+	envVars, err := fuzzerEnvVars()
+	if err != nil {
+		return nil, err
+	}
+
 	runnerOpts := &jazzerjs.RunnerOptions{
 		PackageManager:  "npm",
 		TestPathPattern: opts.FuzzTest,
@@ -43,17 +49,20 @@ func (r *NodeJSAdapter) Run(opts *RunOptions) (*reporthandler.ReportHandler, err
 		LibfuzzerOptions: &libfuzzer.RunnerOptions{
 			Dictionary:     opts.Dictionary,
 			EngineArgs:     opts.EngineArgs,
-			EnvVars:        []string{"NO_CIFUZZ=1"},
+			EnvVars:        envVars,
 			KeepColor:      !opts.PrintJSON && !log.PlainStyle(),
 			ProjectDir:     opts.ProjectDir,
 			ReportHandler:  reportHandler,
+			ResourceLimits: opts.ResourceLimits,
 			SeedCorpusDirs: opts.SeedCorpusDirs,
 			Timeout:        opts.Timeout,
 			UseMinijail:    opts.UseSandbox,
-			Verbose:        viper.GetBool("verbose"),
+			Verbose:        viper.GetBool("verbose") && !showsDashboard(opts),
 		},
 	}
-	err = ExecuteFuzzerRunner(jazzerjs.NewRunner(runnerOpts))
+	err = executeFuzzerRunnerWithRestarts(opts, runnerOpts.LibfuzzerOptions, func() FuzzerRunner {
+		return jazzerjs.NewRunner(runnerOpts)
+	})
 	if err != nil {
 		return nil, err
 	}
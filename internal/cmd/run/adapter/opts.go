@@ -1,15 +1,33 @@
 package adapter
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 
+	"github.com/docker/go-units"
 	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
 
+	"code-intelligence.com/cifuzz/internal/build"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/corpus"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/webhook"
+	"code-intelligence.com/cifuzz/util/executil"
+)
+
+// The modes of the sandbox, see --sandbox
+const (
+	SandboxDefault    = "default"
+	SandboxNetworkOff = "network-off"
 )
 
 type RunOptions struct {
@@ -21,13 +39,51 @@ type RunOptions struct {
 	EngineArgs            []string      `mapstructure:"engine-args"`
 	SeedCorpusDirs        []string      `mapstructure:"seed-corpus-dirs"`
 	Timeout               time.Duration `mapstructure:"timeout"`
+	StopOnPlateau         time.Duration `mapstructure:"stop-on-plateau"`
 	Interactive           bool          `mapstructure:"interactive"`
 	Server                string        `mapstructure:"server"`
 	Project               string        `mapstructure:"project"`
 	UseSandbox            bool          `mapstructure:"use-sandbox"`
+	Sandbox               string        `mapstructure:"sandbox"`
 	PrintJSON             bool          `mapstructure:"print-json"`
 	BuildOnly             bool          `mapstructure:"build-only"`
+	Sanitizers            []string      `mapstructure:"sanitizers"`
+	MaxRestarts           uint          `mapstructure:"max-restarts"`
+	CorpusSync            string        `mapstructure:"corpus-sync"`
+	CorpusSyncPullOnly    bool          `mapstructure:"corpus-sync-pull-only"`
+	CrossPollinate        bool          `mapstructure:"cross-pollinate"`
+	TUI                   bool          `mapstructure:"tui"`
+	MaxMemory             string        `mapstructure:"max-memory"`
+	CPUs                  float64       `mapstructure:"cpus"`
 	ResolveSourceFilePath bool
+	Watch                 bool `mapstructure:"-"`
+	Regression            bool `mapstructure:"-"`
+	// Instead of fuzzing, prune the generated corpus of the fuzz test
+	// by running libFuzzer's merge mode, see `cifuzz corpus prune`
+	PruneCorpus bool `mapstructure:"-"`
+	// Only determine which entries pruning would keep, without
+	// modifying the corpus
+	PruneDryRun bool `mapstructure:"-"`
+	// Set by the adapter after pruning the corpus
+	PruneResult *PruneResult `mapstructure:"-"`
+	// Instead of fuzzing, only run the fuzz test once on this crashing
+	// input of a finding, see `cifuzz reproduce`
+	ReproduceInput string `mapstructure:"-"`
+	// Instead of only running the fuzz test on ReproduceInput, run it
+	// in a debugger, see `cifuzz debug`
+	Debug *DebugOptions `mapstructure:"-"`
+	// Reports of the run which are written after the run, in the
+	// format <format>:<path>, see `cifuzz run --report`
+	Reports []string `mapstructure:"-"`
+	// The CI system whose output format is used for the results of the
+	// run, see `cifuzz run --ci-format`
+	CIFormat string `mapstructure:"-"`
+
+	FuzzTestConfigs []*config.FuzzTestConfig `mapstructure:"fuzz-tests"`
+	// Set from the config of the fuzz test by ApplyFuzzTestConfig
+	CorpusPostProcessors []string `mapstructure:"-"`
+	// Set from MaxMemory and CPUs by Validate
+	ResourceLimits *executil.ResourceLimits `mapstructure:"-"`
 
 	ProjectDir      string
 	FuzzTest        string
@@ -35,6 +91,9 @@ type RunOptions struct {
 	TestNamePattern string
 	ArgsToPass      []string
 
+	// Context which stops the fuzz test when it's done
+	Context context.Context `mapstructure:"-"`
+
 	BuildStdout io.Writer
 	BuildStderr io.Writer
 
@@ -42,6 +101,13 @@ type RunOptions struct {
 	Stderr io.Writer
 }
 
+// PruneResult contains the stats of the corpus before and after it was
+// pruned
+type PruneResult struct {
+	Before *corpus.Stats
+	After  *corpus.Stats
+}
+
 func (opts *RunOptions) Validate() error {
 	var err error
 
@@ -76,10 +142,210 @@ func (opts *RunOptions) Validate() error {
 		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
 	}
 
+	switch opts.BuildSystem {
+	case config.BuildSystemCMake, config.BuildSystemBazel, config.BuildSystemOther:
+		if len(opts.Sanitizers) == 0 {
+			opts.Sanitizers = build.DefaultSanitizers
+		}
+		err = build.ValidateSanitizers(opts.Sanitizers)
+		if err != nil {
+			return cmdutils.WrapIncorrectUsageError(err)
+		}
+	}
+
+	if opts.Regression || opts.ReproduceInput != "" {
+		// Run each input of the corpus once without fuzzing. A -runs
+		// engine argument (e.g. from cifuzz.yaml) would enable fuzzing
+		// again, so we remove it.
+		var engineArgs []string
+		for _, arg := range opts.EngineArgs {
+			if !isRunsEngineArg(arg) {
+				engineArgs = append(engineArgs, arg)
+			}
+		}
+		opts.EngineArgs = append(engineArgs, "-runs=0")
+	}
+
+	if opts.CorpusSync != "" {
+		err = corpus.ValidateSyncURL(opts.CorpusSync)
+		if err != nil {
+			return cmdutils.WrapIncorrectUsageError(err)
+		}
+	}
+
+	// Fail early instead of only when the first finding is reported
+	if c := webhook.ConfigFromViper(); c != nil {
+		err = c.Validate()
+		if err != nil {
+			return cmdutils.WrapIncorrectUsageError(err)
+		}
+	}
+
+	if opts.PruneCorpus && opts.BuildSystem == config.BuildSystemNodeJS {
+		// Jazzer.js' Jest integration doesn't provide a way to run
+		// libFuzzer's merge mode
+		return errors.New("Pruning the corpus is not supported for Node.js projects")
+	}
+	if opts.CrossPollinate && opts.BuildSystem == config.BuildSystemNodeJS {
+		return errors.New("Cross-pollinating the corpus is not supported for Node.js projects")
+	}
+	if opts.Debug != nil && opts.BuildSystem == config.BuildSystemNodeJS {
+		return errors.New("Debugging findings is not supported for Node.js projects")
+	}
+	if opts.ReproduceInput != "" && opts.BuildSystem == config.BuildSystemNodeJS {
+		return errors.New("Reproducing findings is not supported for Node.js projects")
+	}
+
+	if opts.Watch && opts.BuildOnly {
+		msg := `Flags "watch" and "build-only" can't be used together`
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
 	if opts.Timeout != 0 && opts.Timeout < time.Second {
 		msg := fmt.Sprintf("invalid argument %q for \"--timeout\" flag: timeout can't be less than a second", opts.Timeout)
 		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
 	}
 
+	err = opts.validateSandbox()
+	if err != nil {
+		return err
+	}
+
+	err = opts.validateResourceLimits()
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
+
+func (opts *RunOptions) validateSandbox() error {
+	switch opts.Sandbox {
+	case "", SandboxDefault:
+		return nil
+	case SandboxNetworkOff:
+	default:
+		msg := fmt.Sprintf("Invalid sandbox mode %q, valid modes are: %s, %s", opts.Sandbox, SandboxDefault, SandboxNetworkOff)
+		if opts.Sandbox == "true" || opts.Sandbox == "false" {
+			// Older cifuzz versions used "sandbox" for the setting
+			// which is now called "use-sandbox"
+			msg += "\nRun 'cifuzz config migrate' to rename the \"sandbox\" setting in cifuzz.yaml to \"use-sandbox\""
+		}
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	// The network is isolated via a network namespace of the Minijail
+	// sandbox, which is only used for C/C++ fuzz tests on Linux
+	if runtime.GOOS != "linux" {
+		msg := fmt.Sprintf("Sandbox mode %q is only supported on Linux", opts.Sandbox)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	switch opts.BuildSystem {
+	case config.BuildSystemCMake, config.BuildSystemBazel, config.BuildSystemOther:
+	default:
+		msg := fmt.Sprintf("Sandbox mode %q is only supported for C/C++ projects", opts.Sandbox)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	if !opts.UseSandbox {
+		log.Infof("Enabling the sandbox, because sandbox mode %q was selected", opts.Sandbox)
+		opts.UseSandbox = true
+	}
+	return nil
+}
+
+func (opts *RunOptions) validateResourceLimits() error {
+	if opts.MaxMemory == "" && opts.CPUs == 0 {
+		return nil
+	}
+	if runtime.GOOS != "linux" && runtime.GOOS != "windows" {
+		msg := "Flags \"max-memory\" and \"cpus\" are only supported on Linux and Windows"
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	limits := &executil.ResourceLimits{CPUs: opts.CPUs}
+	if opts.MaxMemory != "" {
+		var err error
+		limits.Memory, err = units.RAMInBytes(opts.MaxMemory)
+		if err != nil || limits.Memory <= 0 {
+			msg := fmt.Sprintf("Invalid memory limit %q, valid limits are e.g. 512m or 4g", opts.MaxMemory)
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+	}
+	if opts.CPUs < 0 {
+		msg := fmt.Sprintf("Invalid number of CPUs %v, it must not be negative", opts.CPUs)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+	opts.ResourceLimits = limits
+	return nil
+}
+
+// ApplyFuzzTestConfig applies the settings from the "fuzz-tests"
+// section of cifuzz.yaml which match the fuzz test. Must be called
+// after the fuzz test was resolved. Settings which were passed via
+// command-line flags take precedence.
+func (opts *RunOptions) ApplyFuzzTestConfig(flags *pflag.FlagSet) {
+	names := []string{opts.FuzzTest}
+	if opts.TargetMethod != "" {
+		names = append([]string{opts.FuzzTest + "::" + opts.TargetMethod}, names...)
+	}
+	fuzzTestConfig := config.FindFuzzTestConfig(opts.FuzzTestConfigs, names...)
+	if fuzzTestConfig == nil {
+		return
+	}
+
+	if fuzzTestConfig.Timeout != 0 && !flags.Changed("timeout") {
+		opts.Timeout = fuzzTestConfig.Timeout
+	}
+
+	if fuzzTestConfig.Runs != 0 && !hasRunsEngineArg(opts.EngineArgs) {
+		opts.EngineArgs = append(opts.EngineArgs, fmt.Sprintf("-runs=%d", fuzzTestConfig.Runs))
+	}
+
+	opts.CorpusPostProcessors = fuzzTestConfig.CorpusPostProcessors
+
+	opts.SeedCorpusDirs = append(opts.SeedCorpusDirs, fuzzTestConfig.AbsSeedCorpusDirs(opts.ProjectDir)...)
+	if fuzzTestConfig.Dict != "" && !flags.Changed("dict") {
+		opts.Dictionary = fuzzTestConfig.AbsDict(opts.ProjectDir)
+	}
+}
+
+// ApplyFindingConfig sets the fuzz test and the crashing input of the
+// finding, and the configuration in which the finding was found, so
+// that the fuzz test is built and run the same way as back then
+func (opts *RunOptions) ApplyFindingConfig(f *finding.Finding) error {
+	if f.FuzzTest == "" || f.InputFile == "" {
+		return errors.Errorf("Finding %s can't be reproduced, because it doesn't record the fuzz test and crashing input", f.Name)
+	}
+	opts.FuzzTest = f.FuzzTest
+	opts.ReproduceInput = filepath.Join(opts.ProjectDir, filepath.FromSlash(f.InputFile))
+
+	if f.RunConfig == nil {
+		// The finding was created by an older version of cifuzz, so we
+		// use the configuration from cifuzz.yaml
+		log.Warnf("Finding %s doesn't record the configuration in which it was found, using the current configuration", f.Name)
+		return nil
+	}
+	if f.RunConfig.BuildSystem != "" && f.RunConfig.BuildSystem != opts.BuildSystem {
+		log.Warnf("Finding %s was found in a project using build system %q, but the build system is now %q",
+			f.Name, f.RunConfig.BuildSystem, opts.BuildSystem)
+	}
+	opts.Sanitizers = f.RunConfig.Sanitizers
+	opts.EngineArgs = f.RunConfig.EngineArgs
+	opts.ArgsToPass = f.RunConfig.BuildSystemArgs
+	opts.TargetMethod = f.RunConfig.TargetMethod
+	opts.TestNamePattern = f.RunConfig.TestNamePattern
+	return nil
+}
+
+func hasRunsEngineArg(engineArgs []string) bool {
+	for _, arg := range engineArgs {
+		if isRunsEngineArg(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+func isRunsEngineArg(arg string) bool {
+	return strings.HasPrefix(arg, "-runs=") || strings.HasPrefix(arg, "--runs=")
+}
@@ -21,14 +21,20 @@ func (r *OtherAdapter) CheckDependencies(projectDir string) error {
 	case "linux", "darwin":
 		deps = []dependencies.Key{
 			dependencies.Clang,
-			dependencies.LLVMSymbolizer,
 		}
 	case "windows":
 		deps = []dependencies.Key{
 			dependencies.VisualStudio,
 		}
 	}
-	return dependencies.Check(deps, projectDir)
+	err := dependencies.Check(deps, projectDir)
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS != "windows" {
+		checkSymbolizer(projectDir)
+	}
+	return nil
 }
 
 func (r *OtherAdapter) Run(opts *RunOptions) (*reporthandler.ReportHandler, error) {
@@ -65,14 +71,12 @@ func (r *OtherAdapter) build(opts *RunOptions) (*build.CBuildResult, error) {
 			"These arguments are ignored: %s", strings.Join(opts.ArgsToPass, " "))
 	}
 
-	sanitizers := []string{"address", "undefined"}
-
 	var builder *other.Builder
 	builder, err := other.NewBuilder(&other.BuilderOptions{
 		ProjectDir:   opts.ProjectDir,
 		BuildCommand: opts.BuildCommand,
 		CleanCommand: opts.CleanCommand,
-		Sanitizers:   sanitizers,
+		Sanitizers:   opts.Sanitizers,
 		Stdout:       opts.BuildStdout,
 		Stderr:       opts.BuildStderr,
 	})
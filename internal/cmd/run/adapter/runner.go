@@ -2,10 +2,19 @@ package adapter
 
 import (
 	"context"
+	"io"
+	"io/fs"
 	"os"
+	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/pterm/pterm"
@@ -19,10 +28,13 @@ import (
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/config"
 	"code-intelligence.com/cifuzz/internal/ldd"
+	"code-intelligence.com/cifuzz/pkg/corpus"
 	"code-intelligence.com/cifuzz/pkg/java/sourcemap"
 	"code-intelligence.com/cifuzz/pkg/log"
+	fuzzer_runner "code-intelligence.com/cifuzz/pkg/runner"
 	"code-intelligence.com/cifuzz/pkg/runner/jazzer"
 	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
+	"code-intelligence.com/cifuzz/pkg/tracing"
 	"code-intelligence.com/cifuzz/util/fileutil"
 )
 
@@ -32,9 +44,18 @@ type FuzzerRunner interface {
 }
 
 func ExecuteFuzzerRunner(runner FuzzerRunner) error {
+	return ExecuteFuzzerRunnerWithContext(context.Background(), runner)
+}
+
+// ExecuteFuzzerRunnerWithContext executes the fuzzer runner until it
+// exits or the context is done
+func ExecuteFuzzerRunnerWithContext(ctx context.Context, runner FuzzerRunner) (err error) {
+	span := tracing.Start("fuzz")
+	defer func() { span.End(err) }()
+
 	// Handle cleanup (terminating the fuzzer process) when receiving
 	// termination signals
-	signalHandlerCtx, cancelSignalHandler := context.WithCancel(context.Background())
+	signalHandlerCtx, cancelSignalHandler := context.WithCancel(ctx)
 	routines, routinesCtx := errgroup.WithContext(signalHandlerCtx)
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
@@ -58,7 +79,7 @@ func ExecuteFuzzerRunner(runner FuzzerRunner) error {
 		return runner.Run(routinesCtx)
 	})
 
-	err := routines.Wait()
+	err = routines.Wait()
 	// We use a separate variable to pass signal errors, because when
 	// a signal was received, the first goroutine terminates the second
 	// one, resulting in a race of which returns an error first. In that
@@ -81,8 +102,173 @@ func ExecuteFuzzerRunner(runner FuzzerRunner) error {
 	return err
 }
 
+// coverageMonitor is implemented by report handlers which track when
+// the fuzzer last found new coverage
+type coverageMonitor interface {
+	TimeSinceNewCoverage() time.Duration
+}
+
+var (
+	initialRestartBackoff = 5 * time.Second
+	maxRestartBackoff     = 5 * time.Minute
+)
+
+// The messages printed by Gradle and Maven when the daemon which runs
+// the build disappeared, e.g. because it was killed by the OOM killer
+var buildDaemonLostPattern = regexp.MustCompile(`(?i)(Gradle build daemon disappeared unexpectedly|daemon disappeared|lost connection to the daemon)`)
+
+// isEngineFailure returns true if the error is caused by an unexpected
+// exit of the fuzzing engine or the process which runs it, rather than
+// by a finding, which doesn't result in an error
+func isEngineFailure(err error) bool {
+	var execErr *cmdutils.ExecError
+	if errors.As(err, &execErr) {
+		return true
+	}
+	// libFuzzer exits with the OOM exit code without reporting a
+	// finding if it runs out of memory outside of the fuzz test, for
+	// example in the mutator
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == fuzzer_runner.LibFuzzerOOMExitCode {
+		return true
+	}
+	return err != nil && buildDaemonLostPattern.MatchString(err.Error())
+}
+
+// executeFuzzerRunnerWithRestarts executes the fuzzer runner returned
+// by newRunner. If the fuzzing engine exits unexpectedly without
+// reporting a finding, for example because it ran out of memory in the
+// mutator or the Gradle or Maven daemon disappeared, the runner is
+// restarted up to opts.MaxRestarts times with an increasing delay.
+// The restarted runner continues from the corpus generated so far and
+// only runs for the remainder of the timeout.
+func executeFuzzerRunnerWithRestarts(opts *RunOptions, libfuzzerOpts *libfuzzer.RunnerOptions, newRunner func() FuzzerRunner) error {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Deferred functions run in reverse order, so the new corpus
+	// entries are pushed after they were post-processed
+	corpusDir := libfuzzerOpts.GeneratedCorpusDir
+	if opts.CorpusSync != "" && corpusDir != "" {
+		syncURL := corpusSyncURL(opts, corpusDir)
+		span := tracing.Start("corpus pull", "cifuzz.corpus_sync", opts.CorpusSync)
+		err := corpus.Pull(ctx, syncURL, corpusDir)
+		span.End(err)
+		if err != nil {
+			log.Warnf("Failed to pull the shared corpus: %v", err)
+		}
+		if !opts.CorpusSyncPullOnly && !opts.Regression {
+			defer func() {
+				// The context is done if the fuzz test was stopped, but
+				// the corpus entries found so far should still be shared
+				span := tracing.Start("corpus push", "cifuzz.corpus_sync", opts.CorpusSync)
+				err := corpus.Push(context.Background(), syncURL, corpusDir)
+				span.End(err)
+				if err != nil {
+					log.Warnf("Failed to push the generated corpus: %v", err)
+				}
+			}()
+		}
+	}
+
+	// Large crashing inputs are stored compressed in the seed corpus,
+	// so they are decompressed to reproduce the findings
+	expandedCorpusDir, err := os.MkdirTemp("", "cifuzz-expanded-corpus-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(expandedCorpusDir)
+	libfuzzerOpts.SeedCorpusDirs, err = corpus.ExpandCompressedEntries(libfuzzerOpts.SeedCorpusDirs, expandedCorpusDir)
+	if err != nil {
+		return err
+	}
+
+	startedAt := time.Now()
+	if len(opts.CorpusPostProcessors) != 0 {
+		defer func() {
+			// The post-processors run on the corpus entries generated
+			// by all runs, even if the fuzz test was stopped
+			span := tracing.Start("corpus post-processing")
+			err := corpus.PostProcess(libfuzzerOpts.GeneratedCorpusDir, startedAt, opts.CorpusPostProcessors)
+			span.End(err)
+			if err != nil {
+				log.Warnf("Failed to post-process the generated corpus: %v", err)
+			}
+		}()
+	}
+
+	// Stop the fuzz test when the coverage reaches a plateau
+	var plateauReached atomic.Bool
+	if monitor, ok := libfuzzerOpts.ReportHandler.(coverageMonitor); ok && opts.StopOnPlateau != 0 && !opts.Regression {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if monitor.TimeSinceNewCoverage() >= opts.StopOnPlateau {
+						log.Infof("No new coverage was found for %s, stopping the fuzz test", opts.StopOnPlateau)
+						plateauReached.Store(true)
+						cancel()
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	backoff := initialRestartBackoff
+	var restarts uint
+	for {
+		err := ExecuteFuzzerRunnerWithContext(ctx, newRunner())
+		if plateauReached.Load() {
+			// Stopping on a plateau is a success
+			return nil
+		}
+		if ctx.Err() != nil {
+			// The fuzz test was stopped deliberately
+			return err
+		}
+
+		// Only errors due to an unexpected exit of the fuzzing engine
+		// are worth a restart. Signals and other errors are returned
+		// directly.
+		if !isEngineFailure(err) || restarts >= opts.MaxRestarts {
+			return err
+		}
+		if opts.Timeout != 0 {
+			remaining := opts.Timeout - time.Since(startedAt) - backoff
+			if remaining < time.Second {
+				return err
+			}
+			libfuzzerOpts.Timeout = remaining
+		}
+
+		restarts++
+		log.Warnf("The fuzzer exited unexpectedly: %v\nRestarting it from the current corpus in %s (restart %d of %d)",
+			err, backoff, restarts, opts.MaxRestarts)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxRestartBackoff {
+			backoff = maxRestartBackoff
+		}
+	}
+}
+
 func runLibfuzzer(opts *RunOptions, buildResult *build.BuildResult, reportHandler *reporthandler.ReportHandler) error {
 	var err error
+	defer reportHandler.WaitForNotifications()
 
 	style := pterm.Style{pterm.Reset, pterm.FgLightBlue}
 	log.Infof("Running %s", style.Sprintf(opts.FuzzTest))
@@ -119,10 +305,15 @@ func runLibfuzzer(opts *RunOptions, buildResult *build.BuildResult, reportHandle
 		}
 	}
 
+	envVars, err := fuzzerEnvVars()
+	if err != nil {
+		return err
+	}
+
 	runnerOpts := &libfuzzer.RunnerOptions{
 		Dictionary:         opts.Dictionary,
 		EngineArgs:         opts.EngineArgs,
-		EnvVars:            []string{"NO_CIFUZZ=1"},
+		EnvVars:            envVars,
 		FuzzTarget:         buildResult.Executable,
 		LibraryDirs:        libraryPaths,
 		GeneratedCorpusDir: buildResult.GeneratedCorpus,
@@ -130,17 +321,41 @@ func runLibfuzzer(opts *RunOptions, buildResult *build.BuildResult, reportHandle
 		ProjectDir:         opts.ProjectDir,
 		ReadOnlyBindings:   []string{buildResult.BuildDir},
 		ReportHandler:      reportHandler,
+		ResourceLimits:     opts.ResourceLimits,
 		SeedCorpusDirs:     opts.SeedCorpusDirs,
 		Timeout:            opts.Timeout,
 		UseMinijail:        opts.UseSandbox,
-		Verbose:            viper.GetBool("verbose"),
+		Verbose:            viper.GetBool("verbose") && !showsDashboard(opts),
+		NoNetwork:          opts.Sandbox == SandboxNetworkOff,
 	}
 
 	// TODO: Only set ReadOnlyBindings if buildResult.BuildDir != ""
-	return ExecuteFuzzerRunner(libfuzzer.NewRunner(runnerOpts))
+	newRunner := func() FuzzerRunner {
+		return libfuzzer.NewRunner(runnerOpts)
+	}
+	if opts.Debug != nil {
+		return debugLibfuzzer(opts, runnerOpts)
+	}
+	if opts.ReproduceInput != "" {
+		return reproduceInput(opts, runnerOpts, newRunner)
+	}
+	if opts.Regression {
+		return runRegression(opts, runnerOpts, newRunner)
+	}
+	if opts.PruneCorpus {
+		return pruneCorpus(opts, runnerOpts, buildResult.GeneratedCorpus, newRunner)
+	}
+	if opts.CrossPollinate {
+		err = crossPollinate(opts, runnerOpts, buildResult.GeneratedCorpus, newRunner)
+		if err != nil {
+			return err
+		}
+	}
+	return executeFuzzerRunnerWithRestarts(opts, runnerOpts, newRunner)
 }
 
 func runJazzer(opts *RunOptions, buildResult *build.BuildResult, reportHandler *reporthandler.ReportHandler) error {
+	defer reportHandler.WaitForNotifications()
 	style := pterm.Style{pterm.Reset, pterm.FgLightBlue}
 	log.Infof("Running %s", style.Sprintf(opts.FuzzTest+"::"+opts.TargetMethod))
 
@@ -178,7 +393,10 @@ func runJazzer(opts *RunOptions, buildResult *build.BuildResult, reportHandler *
 		return err
 	}
 
-	var fuzzerRunner FuzzerRunner
+	envVars, err := fuzzerEnvVars()
+	if err != nil {
+		return err
+	}
 
 	runnerOpts := &jazzer.RunnerOptions{
 		TargetClass:  opts.FuzzTest,
@@ -187,7 +405,7 @@ func runJazzer(opts *RunOptions, buildResult *build.BuildResult, reportHandler *
 		LibfuzzerOptions: &libfuzzer.RunnerOptions{
 			Dictionary:         opts.Dictionary,
 			EngineArgs:         opts.EngineArgs,
-			EnvVars:            []string{"NO_CIFUZZ=1"},
+			EnvVars:            envVars,
 			FuzzTarget:         buildResult.Executable,
 			GeneratedCorpusDir: buildResult.GeneratedCorpus,
 			KeepColor:          !opts.PrintJSON && !log.PlainStyle(),
@@ -195,13 +413,288 @@ func runJazzer(opts *RunOptions, buildResult *build.BuildResult, reportHandler *
 			SourceMap:          sourceMap,
 			ReadOnlyBindings:   []string{buildResult.BuildDir},
 			ReportHandler:      reportHandler,
+			ResourceLimits:     opts.ResourceLimits,
 			SeedCorpusDirs:     opts.SeedCorpusDirs,
 			Timeout:            opts.Timeout,
 			UseMinijail:        opts.UseSandbox,
-			Verbose:            viper.GetBool("verbose"),
+			Verbose:            viper.GetBool("verbose") && !showsDashboard(opts),
 		},
 	}
 
-	fuzzerRunner = jazzer.NewRunner(runnerOpts)
-	return ExecuteFuzzerRunner(fuzzerRunner)
+	newRunner := func() FuzzerRunner {
+		return jazzer.NewRunner(runnerOpts)
+	}
+	corpusDir := buildResult.GeneratedCorpus
+	if corpusDir == "" {
+		// By default, Jazzer stores the generated corpus in
+		// .cifuzz-corpus/<test class name>/<test method name>
+		corpusDir = filepath.Join(opts.ProjectDir, ".cifuzz-corpus", opts.FuzzTest, opts.TargetMethod)
+	}
+	if opts.Debug != nil {
+		return debugJazzer(opts, runnerOpts.LibfuzzerOptions, newRunner)
+	}
+	if opts.ReproduceInput != "" {
+		return reproduceInput(opts, runnerOpts.LibfuzzerOptions, newRunner)
+	}
+	if opts.Regression {
+		return runRegression(opts, runnerOpts.LibfuzzerOptions, newRunner)
+	}
+	if opts.PruneCorpus {
+		return pruneCorpus(opts, runnerOpts.LibfuzzerOptions, corpusDir, newRunner)
+	}
+	if opts.CrossPollinate {
+		err = crossPollinate(opts, runnerOpts.LibfuzzerOptions, corpusDir, newRunner)
+		if err != nil {
+			return err
+		}
+	}
+	return executeFuzzerRunnerWithRestarts(opts, runnerOpts.LibfuzzerOptions, newRunner)
+}
+
+// corpusSyncURL returns the URL under which the corpus of the fuzz
+// test is shared, which has the same layout as the .cifuzz-corpus
+// directory, e.g. <corpus-sync>/<class>/<method> for Java fuzz tests
+func corpusSyncURL(opts *RunOptions, corpusDir string) string {
+	name := opts.FuzzTest
+	corpusRoot := filepath.Join(opts.ProjectDir, ".cifuzz-corpus")
+	if rel, err := filepath.Rel(corpusRoot, corpusDir); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+		name = filepath.ToSlash(rel)
+	}
+	return strings.TrimRight(opts.CorpusSync, "/") + "/" + name
+}
+
+// reproduceInput runs the fuzz test once on opts.ReproduceInput. The
+// input is copied to an otherwise empty corpus directory, so that
+// neither the seed corpus nor the generated corpus are run. Compressed
+// inputs are decompressed.
+func reproduceInput(opts *RunOptions, libfuzzerOpts *libfuzzer.RunnerOptions, newRunner func() FuzzerRunner) error {
+	inputDir, err := os.MkdirTemp("", "cifuzz-reproduce-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(inputDir)
+	_, err = copyInput(opts.ReproduceInput, inputDir)
+	if err != nil {
+		return err
+	}
+
+	libfuzzerOpts.GeneratedCorpusDir = inputDir
+	libfuzzerOpts.SeedCorpusDirs = nil
+	libfuzzerOpts.Timeout = 0
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return ExecuteFuzzerRunnerWithContext(ctx, newRunner())
+}
+
+// runRegression runs the fuzz test once on each input of the seed
+// corpus without fuzzing. libFuzzer stops at the first crashing input,
+// so each input is run separately in an otherwise empty corpus
+// directory, which makes all crashing inputs show up as findings.
+// Compressed inputs are decompressed.
+func runRegression(opts *RunOptions, libfuzzerOpts *libfuzzer.RunnerOptions, newRunner func() FuzzerRunner) error {
+	var inputs []string
+	for _, dir := range libfuzzerOpts.SeedCorpusDirs {
+		exists, err := fileutil.Exists(dir)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if d.Type().IsRegular() {
+				inputs = append(inputs, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(inputs) == 0 {
+		log.Warnf("The seed corpus of %s is empty, there is nothing to run", opts.FuzzTest)
+		return nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "cifuzz-regression-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tmpDir)
+
+	libfuzzerOpts.SeedCorpusDirs = nil
+	libfuzzerOpts.Timeout = 0
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	log.Infof("Running %s on %d inputs of the seed corpus", opts.FuzzTest, len(inputs))
+	for i, input := range inputs {
+		inputDir := filepath.Join(tmpDir, strconv.Itoa(i))
+		err = os.Mkdir(inputDir, 0o755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		_, err = copyInput(input, inputDir)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Running %s", input)
+		libfuzzerOpts.GeneratedCorpusDir = inputDir
+		err = ExecuteFuzzerRunnerWithContext(ctx, newRunner())
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return errors.WithStack(ctx.Err())
+		}
+	}
+	return nil
+}
+
+// copyInput copies the input file to the directory and returns the path
+// of the copy. Compressed inputs are decompressed.
+func copyInput(input, dir string) (string, error) {
+	r, err := corpus.OpenEntry(input)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	path := filepath.Join(dir, strings.TrimSuffix(filepath.Base(input), corpus.CompressedSuffix))
+	dest, err := os.Create(path)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer dest.Close()
+	_, err = io.Copy(dest, r)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to copy %s", input)
+	}
+	return path, errors.WithStack(dest.Close())
+}
+
+// pruneCorpus removes the entries from the corpus directory which don't
+// add any coverage, by running libFuzzer's merge mode with the corpus
+// directory as input and an empty directory as output, which then
+// replaces the corpus directory. If opts.PruneDryRun is set, the corpus
+// directory is not modified. The stats of the corpus before and after
+// pruning are stored in opts.PruneResult.
+func pruneCorpus(opts *RunOptions, libfuzzerOpts *libfuzzer.RunnerOptions, corpusDir string, newRunner func() FuzzerRunner) error {
+	before, err := corpus.ComputeStats(corpusDir, time.Now())
+	if err != nil {
+		return err
+	}
+	if before.Entries == 0 {
+		opts.PruneResult = &PruneResult{Before: before, After: before}
+		if !opts.PruneDryRun {
+			log.Infof("The corpus of %s is empty, there is nothing to prune", opts.FuzzTest)
+		}
+		return nil
+	}
+
+	// Create the output directory next to the corpus directory, so
+	// that it can be renamed to the corpus directory afterwards
+	mergeDir, err := os.MkdirTemp(filepath.Dir(corpusDir), ".prune-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(mergeDir)
+
+	libfuzzerOpts.GeneratedCorpusDir = mergeDir
+	libfuzzerOpts.SeedCorpusDirs = []string{corpusDir}
+	libfuzzerOpts.EngineArgs = append(libfuzzerOpts.EngineArgs, "-merge=1")
+	libfuzzerOpts.Timeout = 0
+
+	log.Infof("Pruning the corpus of %s in %s", opts.FuzzTest, fileutil.PrettifyPath(corpusDir))
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	err = ExecuteFuzzerRunnerWithContext(ctx, newRunner())
+	if err != nil {
+		return err
+	}
+
+	after, err := corpus.ComputeStats(mergeDir, time.Now())
+	if err != nil {
+		return err
+	}
+	opts.PruneResult = &PruneResult{Before: before, After: after}
+	if opts.PruneDryRun {
+		return nil
+	}
+
+	err = fileutil.ReplaceDir(corpusDir, mergeDir)
+	if err != nil {
+		return err
+	}
+
+	log.Successf("Pruned the corpus of %s from %d entries (%d bytes) to %d entries (%d bytes)", opts.FuzzTest,
+		before.Entries, before.TotalSize, after.Entries, after.TotalSize)
+	return nil
+}
+
+// crossPollinate adds the inputs from the generated corpora of the
+// other fuzz tests of the project to the corpus directory, because
+// related fuzz tests often benefit from each other's inputs. The
+// inputs are replayed by libFuzzer's merge mode, which only adds the
+// inputs that increase the coverage of this fuzz test and skips inputs
+// that crash or time out.
+func crossPollinate(opts *RunOptions, libfuzzerOpts *libfuzzer.RunnerOptions, corpusDir string, newRunner func() FuzzerRunner) error {
+	dirs, err := corpus.FindDirs(filepath.Join(opts.ProjectDir, ".cifuzz-corpus"))
+	if err != nil {
+		return err
+	}
+	var otherDirs []string
+	for _, dir := range dirs {
+		if filepath.Clean(dir) != filepath.Clean(corpusDir) {
+			otherDirs = append(otherDirs, dir)
+		}
+	}
+	if len(otherDirs) == 0 {
+		log.Debugf("No corpora of other fuzz tests found for cross-pollination")
+		return nil
+	}
+
+	err = os.MkdirAll(corpusDir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	before, err := corpus.ComputeStats(corpusDir, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Restore the options afterwards, because the same options are used
+	// to run the fuzz test
+	origOpts := *libfuzzerOpts
+	defer func() { *libfuzzerOpts = origOpts }()
+	libfuzzerOpts.GeneratedCorpusDir = corpusDir
+	libfuzzerOpts.SeedCorpusDirs = otherDirs
+	libfuzzerOpts.EngineArgs = append(append([]string{}, libfuzzerOpts.EngineArgs...), "-merge=1")
+	libfuzzerOpts.Timeout = 0
+
+	log.Infof("Replaying the corpora of %d other fuzz tests to cross-pollinate %s", len(otherDirs), opts.FuzzTest)
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	err = ExecuteFuzzerRunnerWithContext(ctx, newRunner())
+	if err != nil {
+		return err
+	}
+
+	after, err := corpus.ComputeStats(corpusDir, time.Now())
+	if err != nil {
+		return err
+	}
+	log.Infof("Added %d inputs from the corpora of other fuzz tests", after.Entries-before.Entries)
+	return nil
 }
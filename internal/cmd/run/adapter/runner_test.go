@@ -0,0 +1,275 @@
+package adapter
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/corpus"
+	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
+)
+
+// fakeRunner returns the errors in the given order, one per run, and
+// nil once all errors were returned
+type fakeRunner struct {
+	errs []error
+	runs int
+}
+
+func (r *fakeRunner) Run(context.Context) error {
+	r.runs++
+	if r.runs > len(r.errs) {
+		return nil
+	}
+	return r.errs[r.runs-1]
+}
+
+func (r *fakeRunner) Cleanup(context.Context) {}
+
+// exitError returns the error of a command which exited with the given
+// exit code
+func exitError(t *testing.T, exitCode string) error {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	cmd := exec.Command(sh, "-c", "exit "+exitCode)
+	err = cmd.Run()
+	require.Error(t, err)
+	return err
+}
+
+func executeWithFakeRunner(ctx context.Context, maxRestarts uint, runner *fakeRunner) error {
+	opts := &RunOptions{Context: ctx, MaxRestarts: maxRestarts}
+	return executeFuzzerRunnerWithRestarts(opts, &libfuzzer.RunnerOptions{}, func() FuzzerRunner { return runner })
+}
+
+func TestExecuteFuzzerRunnerWithRestarts(t *testing.T) {
+	initialBackoff := initialRestartBackoff
+	initialRestartBackoff = time.Millisecond
+	t.Cleanup(func() { initialRestartBackoff = initialBackoff })
+
+	engineCrash := cmdutils.WrapExecError(errors.WithStack(exitError(t, "1")), exec.Command("my_fuzz_test"))
+	// This is how the libFuzzer runner reports an OOM without a finding
+	oom := errors.WithMessage(exitError(t, "71"), "libFuzzer exited with expected exit code 71 but no finding was reported")
+	daemonLost := errors.New("Gradle build daemon disappeared unexpectedly (it may have been killed or may have crashed)")
+
+	for _, tc := range []struct {
+		name         string
+		errs         []error
+		maxRestarts  uint
+		expectedRuns int
+		expectErr    bool
+	}{
+		{name: "restart after engine crash", errs: []error{engineCrash, engineCrash}, maxRestarts: 3, expectedRuns: 3},
+		{name: "restart after OOM", errs: []error{oom}, maxRestarts: 3, expectedRuns: 2},
+		{name: "restart after lost daemon", errs: []error{daemonLost}, maxRestarts: 3, expectedRuns: 2},
+		{name: "give up", errs: []error{engineCrash, engineCrash, engineCrash}, maxRestarts: 2, expectedRuns: 3, expectErr: true},
+		{name: "no restarts", errs: []error{engineCrash}, maxRestarts: 0, expectedRuns: 1, expectErr: true},
+		{name: "other errors", errs: []error{errors.New("invalid options")}, maxRestarts: 3, expectedRuns: 1, expectErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			runner := &fakeRunner{errs: tc.errs}
+			err := executeWithFakeRunner(context.Background(), tc.maxRestarts, runner)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedRuns, runner.runs)
+		})
+	}
+}
+
+func TestExecuteFuzzerRunnerWithRestarts_Cancel(t *testing.T) {
+	initialBackoff := initialRestartBackoff
+	initialRestartBackoff = time.Hour
+	t.Cleanup(func() { initialRestartBackoff = initialBackoff })
+
+	engineCrash := cmdutils.WrapExecError(errors.WithStack(exitError(t, "1")), exec.Command("my_fuzz_test"))
+	runner := &fakeRunner{errs: []error{engineCrash}}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	// The fuzz test is stopped while waiting for the restart
+	start := time.Now()
+	err := executeWithFakeRunner(ctx, 3, runner)
+	require.Error(t, err)
+	assert.Equal(t, 1, runner.runs)
+	assert.Less(t, time.Since(start), time.Minute)
+}
+
+// mergeRunner emulates libFuzzer's merge mode by copying the given
+// entries of the corpus to the output directory
+type mergeRunner struct {
+	libfuzzerOpts *libfuzzer.RunnerOptions
+	keep          []string
+}
+
+func (r *mergeRunner) Run(context.Context) error {
+	for _, entry := range r.keep {
+		data, err := os.ReadFile(filepath.Join(r.libfuzzerOpts.SeedCorpusDirs[0], entry))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = os.WriteFile(filepath.Join(r.libfuzzerOpts.GeneratedCorpusDir, entry), data, 0o644)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func (r *mergeRunner) Cleanup(context.Context) {}
+
+func TestPruneCorpus(t *testing.T) {
+	corpusParent := t.TempDir()
+	corpusDir := filepath.Join(corpusParent, "my_fuzz_test")
+	require.NoError(t, os.Mkdir(corpusDir, 0o755))
+	for _, entry := range []string{"first", "second", "third"} {
+		require.NoError(t, os.WriteFile(filepath.Join(corpusDir, entry), []byte(entry), 0o644))
+	}
+
+	libfuzzerOpts := &libfuzzer.RunnerOptions{}
+	runner := &mergeRunner{libfuzzerOpts: libfuzzerOpts, keep: []string{"first", "third"}}
+	opts := &RunOptions{FuzzTest: "my_fuzz_test"}
+	err := pruneCorpus(opts, libfuzzerOpts, corpusDir, func() FuzzerRunner { return runner })
+	require.NoError(t, err)
+	assert.Equal(t, 3, opts.PruneResult.Before.Entries)
+	assert.Equal(t, 2, opts.PruneResult.After.Entries)
+
+	entries, err := os.ReadDir(corpusDir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"first", "third"}, names)
+	// No temporary directories are left next to the corpus directory
+	entries, err = os.ReadDir(corpusParent)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+// hashMergeRunner emulates libFuzzer's merge mode by adding all
+// entries of the seed corpus directories to the output directory,
+// named by the SHA-1 of their content like libFuzzer does
+type hashMergeRunner struct {
+	libfuzzerOpts *libfuzzer.RunnerOptions
+	// The options with which the runner was run
+	runOpts libfuzzer.RunnerOptions
+}
+
+func (r *hashMergeRunner) Run(context.Context) error {
+	r.runOpts = *r.libfuzzerOpts
+	for _, dir := range r.libfuzzerOpts.SeedCorpusDirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, entry := range entries {
+			data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			name := fmt.Sprintf("%x", sha1.Sum(data))
+			err = os.WriteFile(filepath.Join(r.libfuzzerOpts.GeneratedCorpusDir, name), data, 0o644)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *hashMergeRunner) Cleanup(context.Context) {}
+
+func TestCrossPollinate(t *testing.T) {
+	projectDir := t.TempDir()
+	writeEntry := func(dir, name, content string) {
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	corpusRoot := filepath.Join(projectDir, ".cifuzz-corpus")
+	corpusDir := filepath.Join(corpusRoot, "my_fuzz_test")
+	writeEntry(corpusDir, "existing", "OWN")
+	otherDir := filepath.Join(corpusRoot, "other_fuzz_test")
+	writeEntry(otherDir, "existing", "OTHER")
+	writeEntry(otherDir, "new", "NEW")
+
+	libfuzzerOpts := &libfuzzer.RunnerOptions{
+		GeneratedCorpusDir: corpusDir,
+		SeedCorpusDirs:     []string{"seeds"},
+		EngineArgs:         []string{"-max_len=64"},
+		Timeout:            time.Minute,
+	}
+	runner := &hashMergeRunner{libfuzzerOpts: libfuzzerOpts}
+	opts := &RunOptions{ProjectDir: projectDir, FuzzTest: "my_fuzz_test"}
+	err := crossPollinate(opts, libfuzzerOpts, corpusDir, func() FuzzerRunner { return runner })
+	require.NoError(t, err)
+
+	// Only the corpora of the other fuzz tests are merged into the
+	// corpus of the fuzz test
+	assert.Equal(t, corpusDir, runner.runOpts.GeneratedCorpusDir)
+	assert.Equal(t, []string{otherDir}, runner.runOpts.SeedCorpusDirs)
+	assert.Equal(t, []string{"-max_len=64", "-merge=1"}, runner.runOpts.EngineArgs)
+
+	// The existing entries are kept and not overwritten
+	data, err := os.ReadFile(filepath.Join(corpusDir, "existing"))
+	require.NoError(t, err)
+	assert.Equal(t, "OWN", string(data))
+	entries, err := os.ReadDir(corpusDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+	// The other corpus is not modified
+	entries, err = os.ReadDir(otherDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// The options of the fuzzing run are restored
+	assert.Equal(t, []string{"seeds"}, libfuzzerOpts.SeedCorpusDirs)
+	assert.Equal(t, []string{"-max_len=64"}, libfuzzerOpts.EngineArgs)
+	assert.Equal(t, time.Minute, libfuzzerOpts.Timeout)
+}
+
+func TestRunRegression(t *testing.T) {
+	seedCorpusDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(seedCorpusDir, "first"), []byte("FIRST"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(seedCorpusDir, "subdir"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(seedCorpusDir, "subdir", "second"), []byte("SECOND"), 0o644))
+	uncompressed := filepath.Join(t.TempDir(), "third")
+	require.NoError(t, os.WriteFile(uncompressed, []byte("THIRD"), 0o644))
+	require.NoError(t, corpus.CompressFile(uncompressed, filepath.Join(seedCorpusDir, "third"+corpus.CompressedSuffix)))
+
+	libfuzzerOpts := &libfuzzer.RunnerOptions{SeedCorpusDirs: []string{seedCorpusDir}}
+	// Each run must only see a single input, because libFuzzer stops at
+	// the first crashing input
+	var runs [][]string
+	runner := &fakeRunner{}
+	err := runRegression(&RunOptions{}, libfuzzerOpts, func() FuzzerRunner {
+		require.Empty(t, libfuzzerOpts.SeedCorpusDirs)
+		entries, err := os.ReadDir(libfuzzerOpts.GeneratedCorpusDir)
+		require.NoError(t, err)
+		var inputs []string
+		for _, entry := range entries {
+			data, err := os.ReadFile(filepath.Join(libfuzzerOpts.GeneratedCorpusDir, entry.Name()))
+			require.NoError(t, err)
+			inputs = append(inputs, string(data))
+		}
+		runs = append(runs, inputs)
+		return runner
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, [][]string{{"FIRST"}, {"SECOND"}, {"THIRD"}}, runs)
+	assert.Equal(t, 3, runner.runs)
+}
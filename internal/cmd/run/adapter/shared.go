@@ -6,13 +6,16 @@ import (
 	"path/filepath"
 
 	"github.com/pkg/errors"
+	"golang.org/x/term"
 
 	"code-intelligence.com/cifuzz/internal/build"
 	"code-intelligence.com/cifuzz/internal/cmd/run/reporthandler"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
 	"code-intelligence.com/cifuzz/internal/cmdutils/logging"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/finding"
 	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/tracing"
 	"code-intelligence.com/cifuzz/util/fileutil"
 )
 
@@ -33,7 +36,9 @@ func wrapBuild[BR BuildResultType](opts *RunOptions, build func(*RunOptions) (*B
 	}
 	buildPrinter := logging.NewBuildPrinter(buildPrinterOutput, log.BuildInProgressMsg)
 
+	span := tracing.Start("build", "cifuzz.build_system", opts.BuildSystem, "cifuzz.fuzz_test", opts.FuzzTest)
 	cBuildResult, err := build(opts)
+	span.End(err)
 	if err != nil {
 		buildPrinter.StopOnError(log.BuildInProgressErrorMsg)
 	} else {
@@ -89,6 +94,15 @@ func prepareCorpusDir(opts *RunOptions, buildResult *build.BuildResult) error {
 	return nil
 }
 
+// showsDashboard returns true if the report handler shows a dashboard
+// instead of the metrics line, see reporthandler.ReportHandlerOptions
+func showsDashboard(opts *RunOptions) bool {
+	return opts.TUI && !opts.PrintJSON && !log.PlainStyle() && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// createReportHandler creates the report handler of the fuzzing run.
+// runLibfuzzer, runJazzer and the Node.js adapter wait for its webhook
+// notifications when the run ends.
 func createReportHandler(opts *RunOptions, buildResult *build.BuildResult) (*reporthandler.ReportHandler, error) {
 	printerOutput := os.Stdout
 	jsonOutput := io.Discard
@@ -97,6 +111,11 @@ func createReportHandler(opts *RunOptions, buildResult *build.BuildResult) (*rep
 		jsonOutput = os.Stdout
 	}
 
+	baseline, err := finding.LoadBaseline(opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize the report handler. Only do this right before we start
 	// the fuzz test, because this is storing a timestamp which is used
 	// to figure out how long the fuzzing run is running.
@@ -109,6 +128,31 @@ func createReportHandler(opts *RunOptions, buildResult *build.BuildResult) (*rep
 			GeneratedCorpusDir:   buildResult.GeneratedCorpus,
 			PrinterOutput:        printerOutput,
 			JSONOutput:           jsonOutput,
+			// When reproducing a finding, the finding already exists
+			SkipSavingFinding: opts.ReproduceInput != "",
+			Regression:        opts.Regression,
+			RunConfig: &finding.RunConfig{
+				BuildSystem:     opts.BuildSystem,
+				Sanitizers:      opts.Sanitizers,
+				EngineArgs:      opts.EngineArgs,
+				BuildSystemArgs: opts.ArgsToPass,
+				TargetMethod:    opts.TargetMethod,
+				TestNamePattern: opts.TestNamePattern,
+			},
+			Baseline:  baseline,
+			Dashboard: opts.TUI,
+			Timeout:   opts.Timeout,
 		},
 	)
 }
+
+// fuzzerEnvVars returns the environment variables which the runners
+// add to the environment of the fuzz test, including the sanitizer
+// options from cifuzz.yaml
+func fuzzerEnvVars() ([]string, error) {
+	sanitizerOptions, err := config.SanitizerOptionsEnvVars()
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{"NO_CIFUZZ=1"}, sanitizerOptions...), nil
+}
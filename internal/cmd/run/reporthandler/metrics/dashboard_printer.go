@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pterm/pterm"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+// The number of findings which are shown in the findings panel of the
+// dashboard, older findings are summarized
+const dashboardMaxFindings = 5
+
+// The interval in which the dashboard is redrawn. It's shorter than the
+// interval of the metrics, because log messages clear the dashboard.
+const dashboardRefreshInterval = 250 * time.Millisecond
+
+// NewDashboardPrinter returns a printer which shows the metrics of
+// the fuzzing run and the findings in a dashboard, which is redrawn in
+// place while the fuzz test is running. The output must be a terminal.
+func NewDashboardPrinter(output io.Writer, fuzzTest string, timeout time.Duration) *DashboardPrinter {
+	p := &DashboardPrinter{
+		output:   output,
+		fuzzTest: fuzzTest,
+		timeout:  timeout,
+		done:     make(chan struct{}),
+	}
+	log.ActiveUpdatingPrinter = p
+	return p
+}
+
+type DashboardPrinter struct {
+	output   io.Writer
+	fuzzTest string
+	timeout  time.Duration
+
+	mutex        sync.Mutex
+	started      bool
+	stopped      bool
+	startedAt    time.Time
+	firstMetrics *report.FuzzingMetric
+	lastMetrics  *report.FuzzingMetric
+	lastMetricAt time.Time
+	findings     []string
+	// The number of lines which the dashboard currently occupies on
+	// the terminal
+	numLines int
+	done     chan struct{}
+}
+
+func (p *DashboardPrinter) Start() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.started {
+		return
+	}
+	p.started = true
+	p.startedAt = time.Now()
+
+	go func() {
+		ticker := time.NewTicker(dashboardRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				p.mutex.Lock()
+				p.redraw()
+				p.mutex.Unlock()
+			}
+		}
+	}()
+}
+
+func (p *DashboardPrinter) PrintMetrics(metrics *report.FuzzingMetric) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.firstMetrics == nil {
+		p.firstMetrics = metrics
+	}
+	p.lastMetrics = metrics
+	p.lastMetricAt = time.Now()
+	p.redraw()
+}
+
+// AddFinding adds the description of a finding to the findings panel
+func (p *DashboardPrinter) AddFinding(description string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.findings = append(p.findings, description)
+	p.redraw()
+}
+
+// Clear removes the dashboard from the terminal, so that other output
+// can be printed. The dashboard is redrawn below it.
+func (p *DashboardPrinter) Clear() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.clear()
+}
+
+// Stop stops redrawing the dashboard and removes it from the terminal
+func (p *DashboardPrinter) Stop() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.stopped {
+		return nil
+	}
+	p.stopped = true
+	close(p.done)
+	p.clear()
+	if log.ActiveUpdatingPrinter == p {
+		log.ActiveUpdatingPrinter = nil
+	}
+	return nil
+}
+
+func (p *DashboardPrinter) clear() {
+	if p.numLines == 0 {
+		return
+	}
+	// Move the cursor to the first line of the dashboard and clear
+	// everything below it
+	_, _ = fmt.Fprintf(p.output, "\x1b[%dA\r\x1b[J", p.numLines)
+	p.numLines = 0
+}
+
+func (p *DashboardPrinter) redraw() {
+	if !p.started || p.stopped {
+		return
+	}
+	s := p.render(time.Now())
+	p.clear()
+	_, _ = fmt.Fprint(p.output, s)
+	p.numLines = strings.Count(s, "\n")
+}
+
+func (p *DashboardPrinter) render(now time.Time) string {
+	elapsed := now.Sub(p.startedAt).Truncate(time.Second)
+	remaining := "n/a"
+	if p.timeout != 0 {
+		r := p.timeout - elapsed
+		if r < 0 {
+			r = 0
+		}
+		remaining = r.Truncate(time.Second).String()
+	}
+
+	execsPerSecond, executions, edges, features, corpusSize, lastNewEdge := "n/a", "0", "0", "0", "0", "none yet"
+	if m := p.lastMetrics; m != nil {
+		execsPerSecond = fmt.Sprintf("%d", m.ExecutionsPerSecond)
+		executions = fmt.Sprintf("%d", m.TotalExecutions)
+		edges = fmt.Sprintf("%d (+%d)", m.Edges, m.Edges-p.firstMetrics.Edges)
+		features = fmt.Sprintf("%d", m.Features)
+		corpusSize = fmt.Sprintf("%d", m.CorpusSize)
+		// The metrics are only reported when the coverage increased or
+		// periodically, so we add the time since the last report
+		sinceLastEdge := time.Duration(m.SecondsSinceLastEdge)*time.Second + now.Sub(p.lastMetricAt)
+		lastNewEdge = sinceLastEdge.Truncate(time.Second).String() + " ago"
+	}
+
+	width := pterm.GetTerminalWidth()
+	if width <= 0 || width > 80 {
+		width = 80
+	}
+	separator := DelimString(strings.Repeat("─", width)) + "\n"
+
+	var b strings.Builder
+	b.WriteString(separator)
+	b.WriteString(pterm.Bold.Sprint("cifuzz run ") + pterm.FgLightBlue.Sprint(p.fuzzTest) + "\n")
+	b.WriteString(separator)
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	row := func(desc1, value1, desc2, value2 string) {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			DescString(desc1), NumberString("%s", value1), DescString(desc2), NumberString("%s", value2))
+	}
+	row("Elapsed:", elapsed.String(), "Remaining:", remaining)
+	row("Exec/s:", execsPerSecond, "Executions:", executions)
+	row("Edges:", edges, "Features:", features)
+	row("Corpus size:", corpusSize, "Last new edge:", lastNewEdge)
+	_ = w.Flush()
+
+	b.WriteString(separator)
+	b.WriteString(pterm.Bold.Sprintf("Findings (%d)", len(p.findings)) + "\n")
+	if len(p.findings) == 0 {
+		b.WriteString(DelimString("  none yet") + "\n")
+	}
+	findings := p.findings
+	if len(findings) > dashboardMaxFindings {
+		b.WriteString(DelimString("  ... %d more", len(findings)-dashboardMaxFindings) + "\n")
+		findings = findings[len(findings)-dashboardMaxFindings:]
+	}
+	for _, f := range findings {
+		b.WriteString(truncate("  💥 "+f, width) + "\n")
+	}
+	b.WriteString(separator)
+	return b.String()
+}
+
+// truncate shortens s to the width of the terminal, because lines which
+// wrap would break the redrawing of the dashboard
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
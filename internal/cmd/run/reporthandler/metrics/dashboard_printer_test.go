@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+func TestDashboardPrinter_Render(t *testing.T) {
+	p := NewDashboardPrinter(&bytes.Buffer{}, "my_fuzz_test", 10*time.Minute)
+	defer func() { require.NoError(t, p.Stop()) }()
+	startedAt := time.Now()
+	p.startedAt = startedAt
+	p.firstMetrics = &report.FuzzingMetric{Edges: 100}
+	p.lastMetrics = &report.FuzzingMetric{
+		ExecutionsPerSecond: 1500,
+		TotalExecutions:     90000,
+		Edges:               112,
+		Features:            300,
+		CorpusSize:          42,
+	}
+	p.lastMetricAt = startedAt.Add(time.Minute)
+	for i := 0; i < dashboardMaxFindings+2; i++ {
+		p.findings = append(p.findings, fmt.Sprintf("finding_%d", i))
+	}
+
+	out := pterm.RemoveColorFromString(p.render(startedAt.Add(90 * time.Second)))
+	assert.Contains(t, out, "cifuzz run my_fuzz_test")
+	assert.Regexp(t, `Elapsed:\s+1m30s\s+Remaining:\s+8m30s`, out)
+	assert.Regexp(t, `Exec/s:\s+1500\s+Executions:\s+90000`, out)
+	assert.Regexp(t, `Edges:\s+112 \(\+12\)\s+Features:\s+300`, out)
+	assert.Regexp(t, `Corpus size:\s+42\s+Last new edge:\s+30s ago`, out)
+	assert.Contains(t, out, "Findings (7)")
+	// Only the latest findings are shown
+	assert.Contains(t, out, "... 2 more")
+	assert.NotContains(t, out, "finding_1\n")
+	assert.Contains(t, out, "finding_6\n")
+	for _, line := range strings.Split(out, "\n") {
+		assert.LessOrEqual(t, len([]rune(line)), 80)
+	}
+}
+
+func TestDashboardPrinter_Redraw(t *testing.T) {
+	var out bytes.Buffer
+	p := NewDashboardPrinter(&out, "my_fuzz_test", 0)
+	p.Start()
+	p.PrintMetrics(&report.FuzzingMetric{Edges: 1})
+	numLines := p.numLines
+	require.Greater(t, numLines, 0)
+
+	// Redrawing moves the cursor up to the first line of the dashboard
+	out.Reset()
+	p.AddFinding("funny_elephant")
+	assert.True(t, strings.HasPrefix(out.String(), fmt.Sprintf("\x1b[%dA", numLines)))
+
+	// Stopping removes the dashboard
+	require.NoError(t, p.Stop())
+	assert.Equal(t, 0, p.numLines)
+}
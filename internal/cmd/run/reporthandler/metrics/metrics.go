@@ -15,6 +15,14 @@ type Printer interface {
 	PrintMetrics(metrics *report.FuzzingMetric)
 }
 
+// LivePrinter is a printer which updates its output in place on the
+// terminal
+type LivePrinter interface {
+	Printer
+	Clear()
+	Stop() error
+}
+
 func DescString(format string, a ...any) string {
 	return pterm.FgDefault.Sprintf(format, a...)
 }
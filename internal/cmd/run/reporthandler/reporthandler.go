@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"text/tabwriter"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
 	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/webhook"
 	"code-intelligence.com/cifuzz/util/fileutil"
 	"code-intelligence.com/cifuzz/util/stringutil"
 )
@@ -34,6 +36,22 @@ type ReportHandlerOptions struct {
 	JSONOutput           io.Writer
 	PrinterOutput        io.Writer
 	SkipSavingFinding    bool
+	// Only the existing corpus entries are run, so crashes are matched
+	// to the existing findings and crashing inputs are not added to
+	// the seed corpus
+	Regression bool
+	// The configuration of the fuzzing run, which is stored in the
+	// findings
+	RunConfig *finding.RunConfig
+	// The known findings, which are flagged as such. Nil if the project
+	// has no baseline file.
+	Baseline *finding.Baseline
+	// Show a dashboard with the metrics and findings instead of the
+	// metrics line if the output is a terminal
+	Dashboard bool
+	// The timeout of the fuzzing run, which is used to show the
+	// remaining time in the dashboard
+	Timeout time.Duration
 }
 
 type ReportHandler struct {
@@ -50,11 +68,38 @@ type ReportHandler struct {
 	ErrorDetails []*finding.ErrorDetails
 
 	numSeedsAtInit uint
+	// The number of corpus entries at the end of the run, which is
+	// counted by PrintFinalMetrics
+	numCorpusEntries uint
+
+	// The highest coverage reported so far and when it was reached,
+	// which is read concurrently via TimeSinceNewCoverage
+	coverageMutex     sync.Mutex
+	maxEdges          int32
+	maxFeatures       int32
+	lastNewCoverageAt time.Time
+
+	// The webhook notifications about new findings, which are posted in
+	// the background in the order of the findings, see notify
+	notifications     chan *webhookNotification
+	notificationsDone chan struct{}
 
 	FuzzTest string
 	Findings []*finding.Finding
 }
 
+// The maximum number of webhook notifications which are waiting to be
+// posted, see notify
+var maxPendingNotifications = 100
+
+type webhookNotification struct {
+	config *webhook.Config
+	msg    *webhook.Message
+}
+
+// NewReportHandler creates a report handler for a fuzzing run. The
+// caller must call WaitForNotifications at the end of the run, so that
+// the webhook notifications about the findings are not lost.
 func NewReportHandler(fuzzTest string, options *ReportHandlerOptions) (*ReportHandler, error) {
 	var err error
 	h := &ReportHandler{
@@ -74,9 +119,13 @@ func NewReportHandler(fuzzTest string, options *ReportHandlerOptions) (*ReportHa
 	// and plain style is not enabled
 
 	if file, ok := h.PrinterOutput.(*os.File); ok && term.IsTerminal(int(file.Fd())) && !log.PlainStyle() {
-		h.printer, err = metrics.NewUpdatingPrinter(h.PrinterOutput)
-		if err != nil {
-			return nil, err
+		if h.Dashboard {
+			h.printer = metrics.NewDashboardPrinter(h.PrinterOutput, fuzzTest, h.Timeout)
+		} else {
+			h.printer, err = metrics.NewUpdatingPrinter(h.PrinterOutput)
+			if err != nil {
+				return nil, err
+			}
 		}
 		h.usingUpdatingPrinter = true
 	} else {
@@ -108,6 +157,7 @@ func (h *ReportHandler) Handle(r *report.Report) error {
 		if r.NumSeeds == 0 {
 			log.Info("Starting from an empty corpus")
 			h.initFinished = true
+			h.updateCoverage(nil)
 		} else {
 			log.Info("Initializing fuzzer with ", pterm.FgLightCyan.Sprintf("%d", r.NumSeeds), " seed inputs")
 		}
@@ -120,6 +170,7 @@ func (h *ReportHandler) Handle(r *report.Report) error {
 	if r.Status == report.RunStatusRunning && !h.initFinished {
 		log.Info("Successfully initialized fuzzer with seed inputs")
 		h.initFinished = true
+		h.updateCoverage(nil)
 
 		// Ensure that the updating printer is started. It should already
 		// have been started above during initialization, but we do it
@@ -134,25 +185,29 @@ func (h *ReportHandler) Handle(r *report.Report) error {
 			h.FirstMetrics = r.Metric
 		}
 		h.printer.PrintMetrics(r.Metric)
+		log.Emit(log.EventMetrics, r.Metric)
+		h.updateCoverage(r.Metric)
 	}
 
 	if r.Finding != nil {
-		// save finding
-		h.Findings = append(h.Findings, r.Finding)
-
-		if len(h.Findings) == 1 {
-			h.PrintFindingInstruction()
-		}
-
 		err = h.handleFinding(r.Finding)
 		if err != nil {
 			return err
 		}
+
+		// Crashes with the same root cause as a finding which was already
+		// reported in this run are only counted once
+		if !h.isReported(r.Finding) {
+			h.Findings = append(h.Findings, r.Finding)
+			if len(h.Findings) == 1 {
+				h.PrintFindingInstruction()
+			}
+		}
 	}
 
 	if h.JSONOutput != io.Discard && h.usingUpdatingPrinter {
 		// Clear the updating printer
-		h.printer.(*metrics.UpdatingPrinter).Clear()
+		h.printer.(metrics.LivePrinter).Clear()
 	}
 
 	err = h.writeJSONReport(r)
@@ -211,26 +266,68 @@ func (h *ReportHandler) handleFinding(f *finding.Finding) error {
 	// anymore, but in a subsequent run the fuzzer finds a different
 	// crashing input which causes the crash again. We do want to
 	// produce a distinct new finding in that case.
+	f.NormalizePaths(h.ProjectDir)
 	nameSeed := append(stacktrace.EncodeStackTrace(f.StackTrace), f.InputData...)
 	f.Name = names.GetDeterministicName(nameSeed)
+	f.FuzzTest = h.FuzzTest
 
-	if f.InputFile != "" && !h.SkipSavingFinding {
-		if h.ManagedSeedCorpusDir == "" {
-			// Handle the case that the seed corpus directory was not set. In
-			// the case of Java fuzz tests, the seed corpus directory is
-			// printed by Jazzer. We parse that output and send it to the
-			// report handler via a report with an empty finding. If we did
-			// not receive that report yet, we cannot copy the input file to
-			// the seed corpus directory.
-			return errors.New("finding before seed corpus directory was set")
-		}
-		err = f.CopyInputFileAndUpdateFinding(h.ProjectDir, h.ManagedSeedCorpusDir)
+	// Different crashing inputs which trigger a crash with the same
+	// root cause are deduplicated via the stack hash, which only
+	// includes the top stack frames without lines. Instead of creating
+	// a new finding, the crashing input is attached to the existing
+	// finding.
+	f.StackHash = stacktrace.StackHash(string(f.Type), f.StackTrace)
+	if h.Regression {
+		return h.handleRegressionFinding(f)
+	}
+	var duplicateOf *finding.Finding
+	if !h.SkipSavingFinding {
+		duplicateOf, err = finding.FindDuplicate(h.ProjectDir, f)
 		if err != nil {
 			return err
 		}
+		if duplicateOf != nil {
+			reopen(duplicateOf)
+		}
+		if duplicateOf != nil && duplicateOf.Name == f.Name {
+			// The finding is overwritten, so we keep the crashing
+			// inputs which were attached to it and its state
+			f.AdditionalInputFiles = duplicateOf.AdditionalInputFiles
+			f.State = duplicateOf.State
+			duplicateOf = nil
+		}
 	}
 
-	f.FuzzTest = h.FuzzTest
+	if f.InputFile != "" && !h.SkipSavingFinding && h.ManagedSeedCorpusDir == "" {
+		// Handle the case that the seed corpus directory was not set. In
+		// the case of Java fuzz tests, the seed corpus directory is
+		// printed by Jazzer. We parse that output and send it to the
+		// report handler via a report with an empty finding. If we did
+		// not receive that report yet, we cannot copy the input file to
+		// the seed corpus directory.
+		return errors.New("finding before seed corpus directory was set")
+	}
+	if duplicateOf != nil {
+		// This also saves the existing finding, which might have been
+		// reopened
+		err = duplicateOf.AddDuplicate(h.ProjectDir, h.ManagedSeedCorpusDir, f)
+	} else if f.InputFile != "" && !h.SkipSavingFinding {
+		err = f.CopyInputFileAndUpdateFinding(h.ProjectDir, h.ManagedSeedCorpusDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	f.Environment = finding.CaptureEnvironment()
+	f.RunConfig = h.RunConfig
+
+	if duplicateOf != nil {
+		f.Name = duplicateOf.Name
+		log.Finding(f.ShortDescriptionWithName())
+		log.Infof("This is a duplicate of the existing finding %s, the crashing input is kept with it", f.Name)
+		log.Emit(log.EventFinding, &findingEvent{Finding: f, Duplicate: true})
+		return nil
+	}
 
 	// Do not mutate f after this call.
 	if !h.SkipSavingFinding {
@@ -240,13 +337,180 @@ func (h *ReportHandler) handleFinding(f *finding.Finding) error {
 		}
 	}
 
-	log.Finding(f.ShortDescriptionWithName())
+	if h.Baseline.Contains(f) {
+		log.Finding(f.ShortDescriptionWithName() + " (known from the baseline)")
+		log.Emit(log.EventFinding, &findingEvent{Finding: f, Known: true})
+		return nil
+	}
+	log.Emit(log.EventFinding, &findingEvent{Finding: f})
+	if dashboard, ok := h.printer.(*metrics.DashboardPrinter); ok {
+		dashboard.AddFinding(f.ShortDescriptionWithName())
+	}
+	if h.Baseline != nil {
+		log.Finding("New finding: " + f.ShortDescriptionWithName())
+	} else {
+		log.Finding(f.ShortDescriptionWithName())
+	}
 
 	desktop.Notify("cifuzz finding", f.ShortDescriptionWithName())
+	h.notify(findingNotification(f))
+
+	return nil
+}
+
+// notify posts the message to the configured webhook in the background,
+// so that a slow or unreachable webhook doesn't delay the handling of
+// the fuzzer output. If too many notifications are pending, the message
+// is dropped, for the same reason. WaitForNotifications must be called
+// at the end of the run.
+func (h *ReportHandler) notify(msg *webhook.Message) {
+	// The configuration is read here, because viper is not safe for
+	// concurrent use
+	c := webhook.ConfigFromViper()
+	if c == nil {
+		return
+	}
+	if h.notifications == nil {
+		h.notifications = make(chan *webhookNotification, maxPendingNotifications)
+		h.notificationsDone = make(chan struct{})
+		go func() {
+			defer close(h.notificationsDone)
+			for n := range h.notifications {
+				n.config.Notify(n.msg)
+			}
+		}()
+	}
+	select {
+	case h.notifications <- &webhookNotification{config: c, msg: msg}:
+	default:
+		log.Warnf("Too many pending notifications, not posting notification about %q", msg.Title)
+	}
+}
+
+// WaitForNotifications waits until all webhook notifications about the
+// findings of the run were posted
+func (h *ReportHandler) WaitForNotifications() {
+	if h.notifications == nil {
+		return
+	}
+	close(h.notifications)
+	<-h.notificationsDone
+	h.notifications = nil
+}
+
+// handleRegressionFinding handles a crash of a corpus entry in
+// regression mode. If the crash has the same root cause as an existing
+// finding, for example because the input is the crashing input of that
+// finding, the crash is attributed to the existing finding instead of
+// saving it again. Otherwise, the finding is saved without adding its
+// input to the seed corpus, which it's already part of.
+func (h *ReportHandler) handleRegressionFinding(f *finding.Finding) error {
+	f.Environment = finding.CaptureEnvironment()
+	f.RunConfig = h.RunConfig
+
+	existing, err := finding.FindDuplicate(h.ProjectDir, f)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		f.Name = existing.Name
+		log.Finding(f.ShortDescriptionWithName())
+		log.Infof("The crash reproduces the existing finding %s", f.Name)
+		log.Emit(log.EventFinding, &findingEvent{Finding: f, Duplicate: true})
+		return nil
+	}
 
+	if !h.SkipSavingFinding {
+		if f.InputFile != "" {
+			input, err := os.ReadFile(f.InputFile)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			err = f.SaveWithInput(h.ProjectDir, input)
+			if err != nil {
+				return err
+			}
+		} else {
+			err = f.Save(h.ProjectDir)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	log.Finding(f.ShortDescriptionWithName())
+	log.Emit(log.EventFinding, &findingEvent{Finding: f})
 	return nil
 }
 
+// findingNotification returns the message which is posted to the
+// notification webhook about a new finding
+func findingNotification(f *finding.Finding) *webhook.Message {
+	msg := &webhook.Message{
+		Title: "New finding: " + f.ShortDescriptionWithName(),
+		Fields: []*webhook.Field{
+			{Name: "Fuzz test", Value: f.FuzzTest},
+			{Name: "Location", Value: f.SourceLocation()},
+		},
+	}
+	for _, frame := range f.StackTrace {
+		msg.StackTrace = append(msg.StackTrace, fmt.Sprintf("#%d %s in %s:%d", frame.FrameNumber, frame.Function, frame.SourceFile, frame.Line))
+	}
+	return msg
+}
+
+// The data of the finding events in JSON output mode
+type findingEvent struct {
+	*finding.Finding
+	// The finding has the same root cause as an existing finding, to
+	// which its crashing input was added
+	Duplicate bool `json:"duplicate,omitempty"`
+	// The finding is listed in the baseline
+	Known bool `json:"known,omitempty"`
+}
+
+// reopen sets the state of a finding which was marked as fixed but
+// occurred again back to new
+func reopen(f *finding.Finding) {
+	if f.State == finding.StateFixed {
+		log.Warnf("Finding %s was marked as fixed, but occurred again", f.Name)
+		f.State = finding.StateNew
+	}
+}
+
+// NewFindings returns the findings which are not listed in the
+// baseline
+func (h *ReportHandler) NewFindings() []*finding.Finding {
+	var res []*finding.Finding
+	for _, f := range h.Findings {
+		if !h.Baseline.Contains(f) {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// isReported returns true if a finding with the same name or root cause
+// as the given finding was already reported in this run
+func (h *ReportHandler) isReported(f *finding.Finding) bool {
+	for _, reported := range h.Findings {
+		if reported.Name == f.Name || (f.StackHash != "" && reported.StackHash == f.StackHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// KnownFindings returns the findings which are listed in the baseline
+func (h *ReportHandler) KnownFindings() []*finding.Finding {
+	var res []*finding.Finding
+	for _, f := range h.Findings {
+		if h.Baseline.Contains(f) {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
 func (h *ReportHandler) PrintFindingInstruction() {
 	log.Note(`
 Use 'cifuzz finding <finding name>' for details on a finding.
@@ -288,8 +552,7 @@ func (h *ReportHandler) PrintFinalMetrics() error {
 
 	if h.usingUpdatingPrinter {
 		// Stop the updating printer
-		updatingPrinter := h.printer.(*metrics.UpdatingPrinter)
-		err := updatingPrinter.Stop()
+		err := h.printer.(metrics.LivePrinter).Stop()
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -301,10 +564,11 @@ func (h *ReportHandler) PrintFinalMetrics() error {
 		log.Print("\n")
 	}
 
-	numCorpusEntries, err := h.countCorpusEntries()
+	err := h.CountCorpusEntries()
 	if err != nil {
 		return err
 	}
+	numCorpusEntries := h.numCorpusEntries
 
 	duration := time.Since(h.startedAt)
 	newCorpusEntries := numCorpusEntries - h.numSeedsAtInit
@@ -370,6 +634,55 @@ func (h *ReportHandler) PrintFinalMetrics() error {
 	return nil
 }
 
+// updateCoverage records when the fuzzer found new coverage. The
+// coverage found while the fuzzer runs the seed corpus isn't considered
+// new, instead the time is recorded when the initialization finished.
+func (h *ReportHandler) updateCoverage(metric *report.FuzzingMetric) {
+	h.coverageMutex.Lock()
+	defer h.coverageMutex.Unlock()
+	if metric != nil {
+		if metric.Edges <= h.maxEdges && metric.Features <= h.maxFeatures {
+			return
+		}
+		h.maxEdges = max(h.maxEdges, metric.Edges)
+		h.maxFeatures = max(h.maxFeatures, metric.Features)
+	}
+	if h.initFinished {
+		h.lastNewCoverageAt = time.Now()
+	}
+}
+
+// TimeSinceNewCoverage returns how long ago the fuzzer last found new
+// coverage (edges or features). Before the fuzzer was initialized with
+// the seed corpus, it returns 0.
+func (h *ReportHandler) TimeSinceNewCoverage() time.Duration {
+	h.coverageMutex.Lock()
+	defer h.coverageMutex.Unlock()
+	if h.lastNewCoverageAt.IsZero() {
+		return 0
+	}
+	return time.Since(h.lastNewCoverageAt)
+}
+
+// CorpusEntries returns the number of corpus entries at the start and
+// the end of the run. The latter is only known after PrintFinalMetrics
+// or CountCorpusEntries was called.
+func (h *ReportHandler) CorpusEntries() (atStart uint, atEnd uint) {
+	return h.numSeedsAtInit, h.numCorpusEntries
+}
+
+// CountCorpusEntries counts the corpus entries at the end of the run,
+// which PrintFinalMetrics does as well, for runs whose final metrics
+// are not printed
+func (h *ReportHandler) CountCorpusEntries() error {
+	numCorpusEntries, err := h.countCorpusEntries()
+	if err != nil {
+		return err
+	}
+	h.numCorpusEntries = numCorpusEntries
+	return nil
+}
+
 func (h *ReportHandler) countCorpusEntries() (uint, error) {
 	var numSeeds uint
 	seedCorpusDirs := append(h.UserSeedCorpusDirs, h.ManagedSeedCorpusDir, h.GeneratedCorpusDir)
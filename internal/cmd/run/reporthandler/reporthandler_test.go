@@ -3,11 +3,15 @@ package reporthandler
 import (
 	"bytes"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/gookit/color"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -15,6 +19,7 @@ import (
 	"code-intelligence.com/cifuzz/internal/testutil"
 	"code-intelligence.com/cifuzz/pkg/finding"
 	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
 	"code-intelligence.com/cifuzz/pkg/report"
 )
 
@@ -111,6 +116,190 @@ func TestReportHandler_Finding(t *testing.T) {
 	checkOutput(t, logOutput, expectedOutputs...)
 }
 
+func TestReportHandler_DuplicateFinding(t *testing.T) {
+	testDir := testutil.ChdirToTempDir(t, "report-handler-test-")
+	h, err := NewReportHandler("", &ReportHandlerOptions{ProjectDir: testDir, ManagedSeedCorpusDir: "seed_corpus"})
+	require.NoError(t, err)
+
+	var reports []*report.Report
+	for _, input := range []string{"FIRST", "SECOND"} {
+		testfile := "crash_" + input
+		err = os.WriteFile(testfile, []byte(input), 0o644)
+		require.NoError(t, err)
+		r := &report.Report{
+			Status: report.RunStatusRunning,
+			Finding: &finding.Finding{
+				InputFile: testfile,
+				InputData: []byte(input),
+				StackTrace: []*stacktrace.StackFrame{
+					{SourceFile: "src/explore_me.cpp", Line: 13, Function: "exploreMe"},
+				},
+			},
+		}
+		err = h.Handle(r)
+		require.NoError(t, err)
+		reports = append(reports, r)
+
+		// Mark the first finding as fixed
+		if len(reports) == 1 {
+			r.Finding.State = finding.StateFixed
+			require.NoError(t, r.Finding.Save(testDir))
+		}
+	}
+
+	// Both crashing inputs are attached to the first finding, which
+	// was reopened
+	assert.Equal(t, reports[0].Finding.Name, reports[1].Finding.Name)
+	// and the finding is only counted once
+	assert.Len(t, h.Findings, 1)
+	findings, err := finding.LocalFindings(testDir, nil)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Len(t, findings[0].AdditionalInputFiles, 1)
+	assert.Equal(t, finding.StateNew, findings[0].State)
+}
+
+func TestReportHandler_Regression(t *testing.T) {
+	testDir := testutil.ChdirToTempDir(t, "report-handler-test-")
+	newFinding := func(input string) *finding.Finding {
+		testfile := "crash_" + input
+		require.NoError(t, os.WriteFile(testfile, []byte(input), 0o644))
+		return &finding.Finding{
+			InputFile: testfile,
+			InputData: []byte(input),
+			StackTrace: []*stacktrace.StackFrame{
+				{SourceFile: "src/explore_me.cpp", Line: 13, Function: "exploreMe"},
+			},
+		}
+	}
+
+	// The finding which was found by fuzzing before
+	h, err := NewReportHandler("", &ReportHandlerOptions{ProjectDir: testDir, ManagedSeedCorpusDir: "seed_corpus"})
+	require.NoError(t, err)
+	existing := newFinding("FIRST")
+	require.NoError(t, h.Handle(&report.Report{Status: report.RunStatusRunning, Finding: existing}))
+	seeds, err := os.ReadDir("seed_corpus")
+	require.NoError(t, err)
+	require.Len(t, seeds, 1)
+
+	// Crashes with the same root cause are attributed to the existing
+	// finding, without saving them or adding their inputs to the seed
+	// corpus
+	h, err = NewReportHandler("", &ReportHandlerOptions{ProjectDir: testDir, ManagedSeedCorpusDir: "seed_corpus", Regression: true})
+	require.NoError(t, err)
+	for _, input := range []string{"FIRST", "SECOND"} {
+		f := newFinding(input)
+		require.NoError(t, h.Handle(&report.Report{Status: report.RunStatusRunning, Finding: f}))
+		assert.Equal(t, existing.Name, f.Name)
+	}
+	// Both crashes are only counted once
+	require.Len(t, h.Findings, 1)
+
+	findings, err := finding.LocalFindings(testDir, nil)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Empty(t, findings[0].AdditionalInputFiles)
+	seeds, err = os.ReadDir("seed_corpus")
+	require.NoError(t, err)
+	assert.Len(t, seeds, 1)
+}
+
+func TestReportHandler_WebhookNotification(t *testing.T) {
+	testDir := testutil.ChdirToTempDir(t, "report-handler-test-")
+	release := make(chan struct{})
+	var posted atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		posted.Add(1)
+	}))
+	defer server.Close()
+	viper.Set("notification-webhook", server.URL)
+	t.Cleanup(func() { viper.Set("notification-webhook", "") })
+
+	h, err := NewReportHandler("", &ReportHandlerOptions{ProjectDir: testDir})
+	require.NoError(t, err)
+	for _, function := range []string{"exploreMe", "exploreMeToo"} {
+		f := &finding.Finding{
+			InputData:  []byte(function),
+			StackTrace: []*stacktrace.StackFrame{{SourceFile: "src/explore_me.cpp", Line: 13, Function: function}},
+		}
+		err = h.Handle(&report.Report{Status: report.RunStatusRunning, Finding: f})
+		require.NoError(t, err)
+	}
+
+	// Handling the findings doesn't wait for the webhook
+	assert.Equal(t, int32(0), posted.Load())
+	close(release)
+	h.WaitForNotifications()
+	assert.Equal(t, int32(2), posted.Load())
+}
+
+func TestReportHandler_WebhookNotification_Dropped(t *testing.T) {
+	testDir := testutil.ChdirToTempDir(t, "report-handler-test-")
+	received := make(chan struct{}, 10)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	defer server.Close()
+	viper.Set("notification-webhook", server.URL)
+	oldMaxPending := maxPendingNotifications
+	maxPendingNotifications = 1
+	t.Cleanup(func() {
+		viper.Set("notification-webhook", "")
+		maxPendingNotifications = oldMaxPending
+	})
+
+	h, err := NewReportHandler("", &ReportHandlerOptions{ProjectDir: testDir})
+	require.NoError(t, err)
+	handle := func(function string) {
+		f := &finding.Finding{
+			InputData:  []byte(function),
+			StackTrace: []*stacktrace.StackFrame{{SourceFile: "src/explore_me.cpp", Line: 13, Function: function}},
+		}
+		err := h.Handle(&report.Report{Status: report.RunStatusRunning, Finding: f})
+		require.NoError(t, err)
+	}
+
+	// The first notification is being posted, the second one is pending
+	// and the third one is dropped instead of blocking
+	handle("first")
+	<-received
+	handle("second")
+	handle("third")
+	checkOutput(t, logOutput, "Too many pending notifications")
+	close(release)
+	h.WaitForNotifications()
+	assert.Len(t, received, 1)
+}
+
+func TestReportHandler_Baseline(t *testing.T) {
+	testDir := testutil.ChdirToTempDir(t, "report-handler-test-")
+	knownStackTrace := []*stacktrace.StackFrame{{SourceFile: "src/explore_me.cpp", Line: 13, Function: "exploreMe"}}
+	h, err := NewReportHandler("", &ReportHandlerOptions{
+		ProjectDir: testDir,
+		Baseline: &finding.Baseline{Findings: []*finding.BaselineEntry{
+			{Signature: stacktrace.StackHash("", knownStackTrace)},
+		}},
+	})
+	require.NoError(t, err)
+
+	knownFinding := &finding.Finding{InputData: []byte("known"), StackTrace: knownStackTrace}
+	newFinding := &finding.Finding{
+		InputData:  []byte("new"),
+		StackTrace: []*stacktrace.StackFrame{{SourceFile: "src/explore_me.cpp", Line: 20, Function: "exploreMeToo"}},
+	}
+	for _, f := range []*finding.Finding{knownFinding, newFinding} {
+		err = h.Handle(&report.Report{Status: report.RunStatusRunning, Finding: f})
+		require.NoError(t, err)
+	}
+
+	assert.Len(t, h.Findings, 2)
+	assert.Equal(t, []*finding.Finding{newFinding}, h.NewFindings())
+	checkOutput(t, logOutput, "(known from the baseline)", "New finding: ["+newFinding.Name+"]")
+}
+
 func TestReportHandler_CorpusDirs(t *testing.T) {
 	h, err := NewReportHandler("", &ReportHandlerOptions{})
 	require.NoError(t, err)
@@ -179,3 +368,25 @@ func checkOutput(t *testing.T, r io.Reader, s ...string) {
 		require.Contains(t, string(output), str)
 	}
 }
+
+func TestReportHandler_TimeSinceNewCoverage(t *testing.T) {
+	testDir := testutil.ChdirToTempDir(t, "report-handler-test-")
+	h, err := NewReportHandler("", &ReportHandlerOptions{ProjectDir: testDir})
+	require.NoError(t, err)
+
+	// Before the initialization is finished, no time is counted
+	require.Zero(t, h.TimeSinceNewCoverage())
+
+	err = h.Handle(&report.Report{Status: report.RunStatusInitializing, NumSeeds: 0})
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	require.GreaterOrEqual(t, h.TimeSinceNewCoverage(), 10*time.Millisecond)
+
+	// A metric with new coverage resets the time
+	err = h.Handle(&report.Report{
+		Status: report.RunStatusRunning,
+		Metric: &report.FuzzingMetric{Timestamp: time.Now(), Edges: 10},
+	})
+	require.NoError(t, err)
+	require.Less(t, h.TimeSinceNewCoverage(), 10*time.Millisecond)
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/pterm/pterm"
@@ -21,13 +22,28 @@ import (
 	"code-intelligence.com/cifuzz/internal/cmdutils/resolve"
 	"code-intelligence.com/cifuzz/internal/completion"
 	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/internal/version"
 	"code-intelligence.com/cifuzz/pkg/dialog"
 	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/githubactions"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/runsummary"
 	"code-intelligence.com/cifuzz/util/sliceutil"
 )
 
+const (
+	reportFormatJUnit      = "junit"
+	reportFormatSARIF      = "sarif"
+	reportFormatGitLabSAST = "gitlab-sast"
+
+	ciFormatAuto   = "auto"
+	ciFormatGitHub = "github"
+	ciFormatNone   = "none"
+)
+
+var reportFormats = []string{reportFormatJUnit, reportFormatSARIF, reportFormatGitLabSAST}
+
 type runCmd struct {
 	*cobra.Command
 
@@ -36,6 +52,7 @@ type runCmd struct {
 	errorDetails []*finding.ErrorDetails
 
 	reportHandler *reporthandler.ReportHandler
+	startedAt     time.Time
 }
 
 func New() *cobra.Command {
@@ -208,9 +225,29 @@ depends on the build system configured for the project.
 				return err
 			}
 			opts.FuzzTest = fuzzTests[0]
+			opts.ApplyFuzzTestConfig(cmd.Flags())
 
 			opts.ArgsToPass = argsToPass
 
+			for _, r := range opts.Reports {
+				_, _, err = parseReport(r)
+				if err != nil {
+					return cmdutils.WrapIncorrectUsageError(err)
+				}
+			}
+			switch opts.CIFormat {
+			case ciFormatAuto:
+				opts.CIFormat = ciFormatNone
+				if githubactions.IsGitHubActions() {
+					opts.CIFormat = ciFormatGitHub
+				}
+			case ciFormatGitHub, ciFormatNone:
+			default:
+				msg := fmt.Sprintf("Invalid CI format %q, valid formats are: %s, %s, %s",
+					opts.CIFormat, ciFormatAuto, ciFormatGitHub, ciFormatNone)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+
 			if opts.PrintJSON {
 				// We only want JSON output on stdout, so we print the build
 				// output to stderr.
@@ -253,19 +290,47 @@ depends on the build system configured for the project.
 		cmdutils.AddCleanCommandFlag,
 		cmdutils.AddBuildJobsFlag,
 		cmdutils.AddBuildOnlyFlag,
+		cmdutils.AddCorpusSyncFlags,
+		cmdutils.AddCrossPollinateFlag,
 		cmdutils.AddDictFlag,
 		cmdutils.AddEngineArgFlag,
 		cmdutils.AddInteractiveFlag,
+		cmdutils.AddMaxRestartsFlag,
 		cmdutils.AddPrintJSONFlag,
 		cmdutils.AddProjectFlag,
 		cmdutils.AddProjectDirFlag,
+		cmdutils.AddResourceLimitFlags,
+		cmdutils.AddSandboxFlag,
+		cmdutils.AddSanitizerFlag,
 		cmdutils.AddSeedCorpusFlag,
 		cmdutils.AddServerFlag,
+		cmdutils.AddStopOnPlateauFlag,
 		cmdutils.AddTimeoutFlag,
+		cmdutils.AddTUIFlag,
 		cmdutils.AddUseSandboxFlag,
 		cmdutils.AddResolveSourceFileFlag,
 	}
 	bindFlags = cmdutils.AddFlags(cmd, funcs...)
+	cmd.Flags().BoolVar(&opts.Watch, "watch", false,
+		"Watch the source files of the project and rebuild and restart the fuzz test when they change.\n"+
+			"The generated corpus is kept between runs. Findings are not uploaded in watch mode.")
+	cmd.Flags().BoolVar(&opts.Regression, "regression", false,
+		"Only run the fuzz test once on each input of the seed corpus (which includes the\n"+
+			"inputs of previous findings) without fuzzing, and fail if any of them crashes.\n"+
+			"Findings are not uploaded in regression mode.")
+	cmd.Flags().StringArrayVar(&opts.Reports, "report", nil,
+		"Write a report of the run to a file, in the format <format>:<path>. Supported formats:\n"+
+			"  junit: A JUnit XML report with a failed test case if there are findings, e.g. for\n"+
+			"         the test panels of Jenkins and GitLab.\n"+
+			"  sarif: The findings of the run in SARIF format, e.g. for GitHub code scanning.\n"+
+			"  gitlab-sast: The findings of the run as a GitLab SAST report, which is shown in\n"+
+			"         the security widget of merge requests.\n"+
+			"Can be specified multiple times.")
+	cmd.Flags().StringVar(&opts.CIFormat, "ci-format", ciFormatAuto,
+		"Report the results of the run in the format of a CI system:\n"+
+			"  github: Create annotations for findings and add a job summary in GitHub Actions.\n"+
+			"  none:   Don't use the format of a CI system.\n"+
+			"  auto:   Detect the CI system, which is currently supported for GitHub Actions.")
 	return cmd
 }
 
@@ -276,6 +341,10 @@ func (c *runCmd) run() error {
 	}
 	c.errorDetails = errorDetails
 
+	if c.opts.Watch {
+		return c.runWatch()
+	}
+
 	adapter, err := adapter.NewAdapter(c.opts)
 	if err != nil {
 		return err
@@ -287,6 +356,7 @@ func (c *runCmd) run() error {
 		return err
 	}
 
+	c.startedAt = time.Now()
 	c.reportHandler, err = adapter.Run(c.opts)
 	if err != nil {
 		var exitErr *exec.ExitError
@@ -307,6 +377,146 @@ func (c *runCmd) run() error {
 		return err
 	}
 
+	// Store the results of the run, which `cifuzz report` summarizes
+	// and `cifuzz stats` shows the trends of
+	summary := c.runSummary()
+	err = summary.Save(c.opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+	err = summary.AppendToHistory(c.opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+	log.Emit(log.EventResult, summary)
+
+	for _, r := range c.opts.Reports {
+		err = c.writeReport(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.opts.CIFormat == ciFormatGitHub {
+		err = c.reportToGitHubActions(summary)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Findings which are listed in the baseline don't fail the run
+	newFindings := c.reportHandler.NewFindings()
+	numKnownFindings := len(c.reportHandler.Findings) - len(newFindings)
+	if numKnownFindings > 0 {
+		log.Infof("%d findings are known from the baseline %s", numKnownFindings, finding.BaselineFileName)
+	}
+
+	if c.opts.Regression {
+		// The findings were found before, so there is no need to
+		// upload them again
+		if len(newFindings) > 0 {
+			return errors.Errorf("Regression test of %s failed with %d findings", c.opts.FuzzTest, len(newFindings))
+		}
+		log.Successf("Regression test of %s passed", c.opts.FuzzTest)
+		return nil
+	}
+
+	err = c.maybeUploadFindings(token)
+	if err != nil {
+		return err
+	}
+
+	// Without a baseline, findings don't fail the run, to keep the
+	// behavior of projects which don't use a baseline
+	if c.reportHandler.Baseline != nil && len(newFindings) > 0 {
+		return errors.Errorf("%d findings are not listed in the baseline %s", len(newFindings), finding.BaselineFileName)
+	}
+	return nil
+}
+
+// parseReport parses a report specified via --report into its format
+// and path
+func parseReport(report string) (string, string, error) {
+	format, path, found := strings.Cut(report, ":")
+	if !found || path == "" {
+		return "", "", errors.Errorf("Invalid report %q, expected <format>:<path>", report)
+	}
+	if !sliceutil.Contains(reportFormats, format) {
+		return "", "", errors.Errorf("Invalid report format %q, supported formats are: %s",
+			format, strings.Join(reportFormats, ", "))
+	}
+	return format, path, nil
+}
+
+func (c *runCmd) writeReport(report string) error {
+	format, path, err := parseReport(report)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	switch format {
+	case reportFormatJUnit:
+		err = finding.WriteJUnit(file, []*finding.FuzzTestResult{{
+			FuzzTest:      c.opts.FuzzTest,
+			Duration:      time.Since(c.startedAt),
+			Findings:      c.reportHandler.NewFindings(),
+			KnownFindings: c.reportHandler.KnownFindings(),
+		}})
+	case reportFormatSARIF:
+		err = finding.WriteSARIF(file, c.reportHandler.Findings, c.opts.ProjectDir, version.Version)
+	case reportFormatGitLabSAST:
+		err = finding.WriteGitLabSAST(file, c.reportHandler.Findings, version.Version, c.startedAt, time.Now())
+	}
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return errors.WithStack(closeErr)
+	}
+	log.Infof("Wrote %s report to %s", format, path)
+	return nil
+}
+
+// reportToGitHubActions creates annotations for the findings, errors
+// for new findings and warnings for findings known from the baseline,
+// and adds the results to the job summary
+func (c *runCmd) reportToGitHubActions(summary *runsummary.Summary) error {
+	for _, f := range summary.NewFindings {
+		err := githubactions.PrintAnnotation(c.OutOrStdout(), githubactions.LevelError, c.opts.ProjectDir, f)
+		if err != nil {
+			return err
+		}
+	}
+	for _, f := range summary.KnownFindings {
+		err := githubactions.PrintAnnotation(c.OutOrStdout(), githubactions.LevelWarning, c.opts.ProjectDir, f)
+		if err != nil {
+			return err
+		}
+	}
+	return githubactions.WriteJobSummary(summary)
+}
+
+func (c *runCmd) runSummary() *runsummary.Summary {
+	corpusEntriesAtStart, corpusEntries := c.reportHandler.CorpusEntries()
+	return &runsummary.Summary{
+		FuzzTest:             c.opts.FuzzTest,
+		Regression:           c.opts.Regression,
+		StartedAt:            c.startedAt,
+		Duration:             time.Since(c.startedAt),
+		FirstMetrics:         c.reportHandler.FirstMetrics,
+		LastMetrics:          c.reportHandler.LastMetrics,
+		CorpusEntriesAtStart: corpusEntriesAtStart,
+		CorpusEntries:        corpusEntries,
+		NewFindings:          c.reportHandler.NewFindings(),
+		KnownFindings:        c.reportHandler.KnownFindings(),
+	}
+}
+
+func (c *runCmd) maybeUploadFindings(token string) error {
 	// We need this check, otherwise we might hang forever in CI
 	if c.opts.Project == "" && !c.opts.Interactive {
 		log.Info("Skipping upload of findings because no project was specified and running in non-interactive mode.")
@@ -319,12 +529,8 @@ func (c *runCmd) run() error {
 
 	// check if there are findings that should be uploaded
 	if token != "" && len(c.reportHandler.Findings) > 0 {
-		err = c.uploadFindings(c.getFuzzTestNameForCampaignRun(), c.opts.BuildSystem, c.reportHandler.FirstMetrics, c.reportHandler.LastMetrics, token)
-		if err != nil {
-			return err
-		}
+		return c.uploadFindings(c.getFuzzTestNameForCampaignRun(), c.opts.BuildSystem, c.reportHandler.FirstMetrics, c.reportHandler.LastMetrics, token)
 	}
-
 	return nil
 }
 
@@ -382,7 +588,7 @@ Findings have *not* been uploaded. Please check the 'project' entry in your cifu
 		if c.errorDetails != nil {
 			finding.EnhanceWithErrorDetails(c.errorDetails)
 		}
-		err = c.apiClient.UploadFinding(project, fuzzTarget, campaignRunName, fuzzingRunName, finding, token)
+		err = c.apiClient.UploadFinding(project, fuzzTarget, campaignRunName, fuzzingRunName, c.opts.ProjectDir, finding, token)
 		if err != nil {
 			return err
 		}
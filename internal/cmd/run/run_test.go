@@ -58,10 +58,13 @@ func TestLlvmSymbolizerVersion(t *testing.T) {
 	// project config... if there is none it will fail before the dependency check
 	testutil.BootstrapExampleProjectForTest(t, "run-cmd-test", config.BuildSystemCMake)
 
-	_, stdErr, err := cmdutils.ExecuteCommand(t, New(), os.Stdin, "my_fuzz_test")
-	require.Error(t, err)
+	// llvm-symbolizer is optional, so the command continues (and fails
+	// later because the dependencies are only mocked)
+	_, stdErr, _ := cmdutils.ExecuteCommand(t, New(), os.Stdin, "my_fuzz_test")
 	assert.Contains(t, stdErr,
 		fmt.Sprintf(dependencies.MessageVersion, "llvm-symbolizer", dep.MinVersion.String(), version))
+	assert.Contains(t, stdErr, "Symbolizing the stack traces of findings is disabled")
+	assert.NotContains(t, stdErr, "missing/invalid dependencies")
 }
 
 func TestVisualStudioMissing(t *testing.T) {
@@ -83,3 +86,24 @@ func TestVisualStudioMissing(t *testing.T) {
 	assert.Contains(t, stdErr,
 		fmt.Sprintf(dependencies.MessageVersion, "Visual Studio", dep.MinVersion.String(), version))
 }
+
+func TestParseReport(t *testing.T) {
+	format, path, err := parseReport("junit:out/cifuzz-junit.xml")
+	require.NoError(t, err)
+	assert.Equal(t, reportFormatJUnit, format)
+	assert.Equal(t, "out/cifuzz-junit.xml", path)
+
+	// Windows paths contain a colon as well
+	_, path, err = parseReport(`sarif:C:\out\findings.sarif`)
+	require.NoError(t, err)
+	assert.Equal(t, `C:\out\findings.sarif`, path)
+
+	format, _, err = parseReport("gitlab-sast:gl-sast-report.json")
+	require.NoError(t, err)
+	assert.Equal(t, reportFormatGitLabSAST, format)
+
+	_, _, err = parseReport("junit")
+	assert.Error(t, err)
+	_, _, err = parseReport("html:report.html")
+	assert.Error(t, err)
+}
@@ -0,0 +1,219 @@
+package run
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/cmd/run/adapter"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// Changes which happen within this period after the first change are
+// handled together, so that saving multiple files at once (or editors
+// writing files in multiple steps) only triggers a single rebuild
+const watchDebouncePeriod = 500 * time.Millisecond
+
+// Extensions of the files whose changes trigger a rebuild
+var watchedExtensions = []string{
+	".c", ".cc", ".cpp", ".cxx", ".h", ".hh", ".hpp", ".hxx", ".inc",
+	".java", ".kt",
+	".js", ".mjs", ".cjs", ".ts",
+	".cmake", ".bazel", ".bzl", ".gradle", ".kts",
+}
+
+// Names of the files whose changes trigger a rebuild
+var watchedFileNames = []string{
+	"CMakeLists.txt", "BUILD", "WORKSPACE", "pom.xml", "package.json", "Makefile",
+}
+
+// Directories which only contain build output or dependencies. Hidden
+// directories (including the ones created by cifuzz) are also skipped.
+var ignoredDirs = []string{"build", "target", "node_modules", "out"}
+
+type watcher struct {
+	*fsnotify.Watcher
+	// Receives the path of a changed file after each batch of changes
+	changes chan string
+}
+
+func newWatcher(projectDir string) (*watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	w := &watcher{Watcher: fsWatcher, changes: make(chan string, 1)}
+
+	err = w.addRecursive(projectDir)
+	if err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+
+	go w.handleEvents()
+	return w, nil
+}
+
+// addRecursive watches the directory and all its subdirectories which
+// are not ignored. fsnotify doesn't support watching directories
+// recursively.
+func (w *watcher) addRecursive(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && isIgnoredDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		return errors.WithStack(w.Add(path))
+	})
+}
+
+func (w *watcher) handleEvents() {
+	var changedFile string
+	var debounce <-chan time.Time
+	for {
+		select {
+		case event, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Create) && fileutil.IsDir(event.Name) {
+				if !isIgnoredDir(filepath.Base(event.Name)) {
+					err := w.addRecursive(event.Name)
+					if err != nil {
+						log.Debugf("Failed to watch %s: %v", event.Name, err)
+					}
+				}
+				continue
+			}
+			// Changes of only the file permissions are irrelevant
+			if event.Op == fsnotify.Chmod || !isWatchedFile(event.Name) {
+				continue
+			}
+			if changedFile == "" {
+				changedFile = event.Name
+				debounce = time.After(watchDebouncePeriod)
+			}
+		case <-debounce:
+			// Don't block if the previous change was not handled yet,
+			// it triggers a rebuild anyway
+			select {
+			case w.changes <- changedFile:
+			default:
+			}
+			changedFile = ""
+			debounce = nil
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			log.Debugf("Error while watching files: %v", err)
+		}
+	}
+}
+
+func isIgnoredDir(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "bazel-") {
+		return true
+	}
+	for _, ignored := range ignoredDirs {
+		if name == ignored {
+			return true
+		}
+	}
+	return false
+}
+
+func isWatchedFile(path string) bool {
+	name := filepath.Base(path)
+	for _, watched := range watchedFileNames {
+		if name == watched {
+			return true
+		}
+	}
+	ext := filepath.Ext(name)
+	for _, watched := range watchedExtensions {
+		if ext == watched {
+			return true
+		}
+	}
+	return false
+}
+
+// runWatch builds and runs the fuzz test and rebuilds and restarts it
+// whenever source files in the project change. The generated corpus is
+// kept between runs, so that fuzzing continues where it stopped.
+func (c *runCmd) runWatch() error {
+	w, err := newWatcher(c.opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	for {
+		// The adapters modify the options (e.g. add the seed corpus
+		// of the fuzz test), so each run gets its own copy
+		opts := *c.opts
+		opts.SeedCorpusDirs = append([]string{}, c.opts.SeedCorpusDirs...)
+		ctx, cancel := context.WithCancel(context.Background())
+		opts.Context = ctx
+
+		done := make(chan error, 1)
+		go func() {
+			done <- c.runOnce(&opts)
+		}()
+
+		select {
+		case changedFile := <-w.changes:
+			log.Infof("\n%s changed, rebuilding and restarting the fuzz test...", fileutil.PrettifyPath(changedFile))
+			cancel()
+			<-done
+			continue
+		case err := <-done:
+			cancel()
+			var signalErr *cmdutils.SignalError
+			if errors.As(err, &signalErr) {
+				return err
+			}
+			if err != nil {
+				// Errors like compilation errors are expected while
+				// working on the fuzz test, so we only print them
+				log.Error(err)
+			}
+		}
+
+		log.Info("Waiting for changes in the project (press Ctrl+C to exit)...")
+		changedFile := <-w.changes
+		log.Infof("\n%s changed, rebuilding and restarting the fuzz test...", fileutil.PrettifyPath(changedFile))
+	}
+}
+
+func (c *runCmd) runOnce(opts *adapter.RunOptions) error {
+	a, err := adapter.NewAdapter(opts)
+	if err != nil {
+		return err
+	}
+	defer a.Cleanup()
+
+	reportHandler, err := a.Run(opts)
+	if err != nil {
+		return err
+	}
+	if reportHandler == nil {
+		return nil
+	}
+	reportHandler.ErrorDetails = c.errorDetails
+	reportHandler.PrintCrashingInputNote()
+	return reportHandler.PrintFinalMetrics()
+}
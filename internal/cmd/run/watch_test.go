@@ -0,0 +1,44 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestWatcher(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, "", "test-watcher-")
+	srcDir := filepath.Join(projectDir, "src")
+	require.NoError(t, os.Mkdir(srcDir, 0o755))
+	buildDir := filepath.Join(projectDir, "build")
+	require.NoError(t, os.Mkdir(buildDir, 0o755))
+
+	w, err := newWatcher(projectDir)
+	require.NoError(t, err)
+	defer w.Close()
+
+	// Changes of build output and non-source files are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(buildDir, "parser.cpp"), []byte{}, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "notes.txt"), []byte{}, 0o644))
+	select {
+	case changedFile := <-w.changes:
+		t.Fatalf("Unexpected change of %s", changedFile)
+	case <-time.After(2 * watchDebouncePeriod):
+	}
+
+	// Changes of source files are reported
+	sourceFile := filepath.Join(srcDir, "parser.cpp")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("int x;"), 0o644))
+	select {
+	case changedFile := <-w.changes:
+		assert.Equal(t, sourceFile, changedFile)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Change was not reported")
+	}
+}
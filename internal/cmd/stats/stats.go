@@ -0,0 +1,204 @@
+package stats
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runsummary"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+type options struct {
+	ProjectDir string `mapstructure:"project-dir"`
+	ConfigDir  string `mapstructure:"config-dir"`
+	Format     string `mapstructure:"-"`
+	Last       int    `mapstructure:"-"`
+}
+
+type statsCmd struct {
+	*cobra.Command
+	opts *options
+}
+
+type fuzzTestHistory struct {
+	FuzzTest string                     `json:"fuzz_test"`
+	Runs     []*runsummary.HistoryEntry `json:"runs"`
+}
+
+func New() *cobra.Command {
+	return newWithOptions(&options{})
+}
+
+func newWithOptions(opts *options) *cobra.Command {
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "stats [flags] [<fuzz test>...]",
+		Short: "Show trends across the runs of fuzz tests",
+		Long: `This command shows the duration, the executions, the coverage, the
+corpus size and the findings of the last runs of the specified fuzz
+tests, or of all fuzz tests which were run in the project if no fuzz
+test is specified. For each run, the change compared to the previous
+run of the fuzz test is shown.
+
+The runs are recorded by 'cifuzz run' in the .cifuzz-build directory of
+the project. Use --last to select how many runs are shown per fuzz test.
+
+Use --format=json to get machine-readable output, for example for
+dashboards.`,
+		ValidArgsFunction: completion.ValidFuzzTests,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Bind viper keys to flags. We can't do this in the New
+			// function, because that would re-bind viper keys which
+			// were bound to the flags of other commands before.
+			bindFlags()
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				return err
+			}
+
+			if opts.Format != formatText && opts.Format != formatJSON {
+				msg := fmt.Sprintf("Invalid format %q, valid formats are: %s, %s", opts.Format, formatText, formatJSON)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			if opts.Last < 0 {
+				msg := fmt.Sprintf("Invalid number of runs %d, it must not be negative", opts.Last)
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := statsCmd{Command: c, opts: opts}
+			return cmd.run(args)
+		},
+	}
+
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddProjectDirFlag,
+	)
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", formatText,
+		fmt.Sprintf("Output format of the statistics (%s/%s).", formatText, formatJSON))
+	cmd.Flags().IntVarP(&opts.Last, "last", "n", 10,
+		"The number of most recent runs which are shown per fuzz test (0 shows all runs).")
+
+	return cmd
+}
+
+func (c *statsCmd) run(args []string) error {
+	history, err := runsummary.LoadHistory(c.opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+	histories := groupByFuzzTest(history, args, c.opts.Last)
+
+	if c.opts.Format == formatJSON {
+		s, err := stringutil.ToJSONString(histories)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(c.OutOrStdout(), s)
+		return errors.WithStack(err)
+	}
+
+	if len(histories) == 0 {
+		log.Print("No runs were recorded in this project yet, please run a fuzz test via 'cifuzz run' first")
+		return nil
+	}
+
+	for i, h := range histories {
+		if i > 0 {
+			_, err = fmt.Fprintln(c.OutOrStdout())
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		err = c.printHistory(h)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *statsCmd) printHistory(h *fuzzTestHistory) error {
+	_, err := fmt.Fprintln(c.OutOrStdout(), pterm.Style{pterm.Bold}.Sprint(h.FuzzTest))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	data := [][]string{{"Started", "Duration", "Exec/s", "Executions", "Edges", "Features", "Corpus", "Findings"}}
+	for i, run := range h.Runs {
+		var prev *runsummary.HistoryEntry
+		if i > 0 {
+			prev = h.Runs[i-1]
+		}
+		started := run.StartedAt.Local().Format("2006-01-02 15:04")
+		if run.Regression {
+			started += " (regression)"
+		}
+		data = append(data, []string{
+			started,
+			run.Duration.Round(time.Second).String(),
+			strconv.FormatUint(run.ExecutionsPerSecond(), 10),
+			strconv.FormatUint(run.Executions, 10),
+			withChange(int64(run.Edges), prev, func(e *runsummary.HistoryEntry) int64 { return int64(e.Edges) }),
+			withChange(int64(run.Features), prev, func(e *runsummary.HistoryEntry) int64 { return int64(e.Features) }),
+			withChange(int64(run.CorpusEntries), prev, func(e *runsummary.HistoryEntry) int64 { return int64(e.CorpusEntries) }),
+			fmt.Sprintf("%d new, %d known", run.NewFindings, run.KnownFindings),
+		})
+	}
+	return errors.WithStack(pterm.DefaultTable.WithHasHeader().WithData(data).WithWriter(c.OutOrStdout()).Render())
+}
+
+// withChange formats the value and its change compared to the previous
+// run, if there is one
+func withChange(value int64, prev *runsummary.HistoryEntry, field func(*runsummary.HistoryEntry) int64) string {
+	if prev == nil {
+		return strconv.FormatInt(value, 10)
+	}
+	return fmt.Sprintf("%d (%+d)", value, value-field(prev))
+}
+
+// groupByFuzzTest returns the last runs of each fuzz test, in the order
+// in which the fuzz tests were first run. If fuzz tests are specified,
+// only the runs of those fuzz tests are returned.
+func groupByFuzzTest(history []*runsummary.HistoryEntry, fuzzTests []string, last int) []*fuzzTestHistory {
+	var result []*fuzzTestHistory
+	byFuzzTest := map[string]*fuzzTestHistory{}
+	for _, run := range history {
+		if len(fuzzTests) > 0 && !sliceutil.Contains(fuzzTests, run.FuzzTest) {
+			continue
+		}
+		h, ok := byFuzzTest[run.FuzzTest]
+		if !ok {
+			h = &fuzzTestHistory{FuzzTest: run.FuzzTest}
+			byFuzzTest[run.FuzzTest] = h
+			result = append(result, h)
+		}
+		h.Runs = append(h.Runs, run)
+	}
+
+	if last > 0 {
+		for _, h := range result {
+			if len(h.Runs) > last {
+				h.Runs = h.Runs[len(h.Runs)-last:]
+			}
+		}
+	}
+	return result
+}
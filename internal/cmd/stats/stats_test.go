@@ -0,0 +1,71 @@
+package stats
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/runsummary"
+)
+
+func TestStats(t *testing.T) {
+	projectDir := testutil.BootstrapEmptyProject(t, "test-stats-")
+	opts := &options{
+		ProjectDir: projectDir,
+		ConfigDir:  projectDir,
+	}
+
+	for _, run := range []struct {
+		fuzzTest string
+		edges    int32
+	}{
+		{"my_fuzz_test", 100},
+		{"other_fuzz_test", 50},
+		{"my_fuzz_test", 120},
+	} {
+		summary := &runsummary.Summary{
+			FuzzTest:    run.fuzzTest,
+			StartedAt:   time.Now(),
+			Duration:    time.Minute,
+			LastMetrics: &report.FuzzingMetric{Edges: run.edges, TotalExecutions: 6000},
+		}
+		require.NoError(t, summary.AppendToHistory(projectDir))
+	}
+
+	stdout, _, err := cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "my_fuzz_test")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "my_fuzz_test")
+	assert.Contains(t, stdout, "120 (+20)")
+	assert.NotContains(t, stdout, "other_fuzz_test")
+
+	stdout, _, err = cmdutils.ExecuteCommand(t, newWithOptions(opts), os.Stdin, "--format=json", "--last=1")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, `"fuzz_test": "other_fuzz_test"`)
+	assert.Contains(t, stdout, `"edges": 120`)
+	assert.NotContains(t, stdout, `"edges": 100`)
+}
+
+func TestGroupByFuzzTest(t *testing.T) {
+	history := []*runsummary.HistoryEntry{
+		{FuzzTest: "b", Edges: 1},
+		{FuzzTest: "a", Edges: 2},
+		{FuzzTest: "b", Edges: 3},
+		{FuzzTest: "b", Edges: 4},
+	}
+
+	histories := groupByFuzzTest(history, nil, 2)
+	require.Len(t, histories, 2)
+	assert.Equal(t, "b", histories[0].FuzzTest)
+	assert.Equal(t, []*runsummary.HistoryEntry{history[2], history[3]}, histories[0].Runs)
+	assert.Equal(t, "a", histories[1].FuzzTest)
+
+	histories = groupByFuzzTest(history, []string{"a"}, 0)
+	require.Len(t, histories, 1)
+	assert.Equal(t, "a", histories[0].FuzzTest)
+}
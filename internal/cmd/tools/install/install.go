@@ -0,0 +1,49 @@
+package install
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/toolchain"
+)
+
+const toolCXXToolchain = "cxxtoolchain"
+
+var tools = []string{toolCXXToolchain}
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "install [flags] <tool>",
+		Short: "Install a tool into the cifuzz tools directory",
+		Long: fmt.Sprintf(`This command installs a tool into the cifuzz tools directory.
+
+Supported tools are:
+  %s  A pinned clang+llvm toolchain (LLVM %s).
+                To use it for building and running fuzz tests, add
+                "use-cxx-toolchain: true" to the cifuzz.yaml.
+
+The tools directory can be changed by setting the %s
+environment variable.`, toolCXXToolchain, toolchain.LLVMVersion, toolchain.ToolsDirEnv),
+		ValidArgs: tools,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				msg := fmt.Sprintf("Exactly one <tool> argument must be provided, got %d", len(args))
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			if args[0] != toolCXXToolchain {
+				msg := fmt.Sprintf("Unknown tool %q, supported tools are: %s", args[0], strings.Join(tools, ", "))
+				return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			return toolchain.Install()
+		},
+	}
+
+	return cmd
+}
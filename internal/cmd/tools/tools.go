@@ -0,0 +1,28 @@
+package tools
+
+import (
+	"github.com/spf13/cobra"
+
+	toolsInstallCmd "code-intelligence.com/cifuzz/internal/cmd/tools/install"
+)
+
+func New() *cobra.Command {
+	return newWithOptions()
+}
+
+func newWithOptions() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tools",
+		Short: "Manage additional tools used by cifuzz",
+		Long: `Manage additional tools which cifuzz can install into its tools
+directory, so that all users of a project use the same versions.`,
+		RunE: func(c *cobra.Command, args []string) error {
+			_ = c.Help()
+			return nil
+		},
+	}
+
+	cmd.AddCommand(toolsInstallCmd.New())
+
+	return cmd
+}
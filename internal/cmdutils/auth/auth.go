@@ -37,6 +37,12 @@ func GetToken(server string) (string, error) {
 		return token, nil
 	}
 
+	// Try the OIDC ID token of the CI job
+	token = getOIDCToken(server)
+	if token != "" {
+		return token, nil
+	}
+
 	// Try the access tokens config file
 	return tokenstorage.Get(server)
 }
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/api"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// The API access tokens which were obtained via OIDC, by server, so
+// that the ID token is only exchanged once per command. If that failed,
+// the server maps to an empty string.
+var oidcAccessTokens = map[string]string{}
+
+// getOIDCToken returns an API access token for the given server which
+// is obtained by exchanging the OpenID Connect ID token of the CI job,
+// so that CI jobs don't need a long-lived API access token. An empty
+// string is returned if no ID token is available or it couldn't be
+// exchanged, in which case a warning is printed, so that the access
+// token stored for the server can be used instead.
+func getOIDCToken(server string) string {
+	if token, ok := oidcAccessTokens[server]; ok {
+		return token
+	}
+
+	idToken, source, err := getIDToken(server)
+	if err != nil {
+		log.Warnf("Failed to get the OIDC ID token: %v", err)
+		oidcAccessTokens[server] = ""
+		return ""
+	}
+	if idToken == "" {
+		return ""
+	}
+
+	token, err := api.NewClient(server).ExchangeOIDCToken(idToken)
+	if err != nil {
+		log.Warnf("Failed to authenticate with the OIDC ID token from %s: %v", source, err)
+		oidcAccessTokens[server] = ""
+		return ""
+	}
+	log.Printf("Using OIDC ID token from %s", source)
+	oidcAccessTokens[server] = token
+	return token
+}
+
+// getIDToken returns the OIDC ID token of the CI job and a description
+// of where it was taken from, or an empty string if the job doesn't
+// have one. By default, the audience of the ID token is the server.
+func getIDToken(server string) (string, string, error) {
+	// The ID token can be passed explicitly, e.g. in GitLab CI, where
+	// ID tokens are configured via the "id_tokens" keyword:
+	//
+	//   id_tokens:
+	//     CIFUZZ_OIDC_TOKEN:
+	//       aud: https://app.code-intelligence.com
+	//
+	token := os.Getenv("CIFUZZ_OIDC_TOKEN")
+	if token != "" {
+		return token, "$CIFUZZ_OIDC_TOKEN", nil
+	}
+
+	audience := os.Getenv("CIFUZZ_OIDC_AUDIENCE")
+	if audience == "" {
+		audience = server
+	}
+
+	// In GitHub Actions, the ID token has to be requested from the
+	// runner, which is only possible if the workflow has the
+	// "id-token: write" permission
+	requestURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if requestURL != "" && requestToken != "" {
+		token, err := requestGitHubActionsIDToken(requestURL, requestToken, audience)
+		if err != nil {
+			return "", "", err
+		}
+		return token, "GitHub Actions", nil
+	}
+
+	return "", "", nil
+}
+
+// requestGitHubActionsIDToken requests an ID token for the given
+// audience from the GitHub Actions runner, see
+// https://docs.github.com/actions/deployment/security-hardening-your-deployments/about-security-hardening-with-openid-connect
+func requestGitHubActionsIDToken(requestURL string, requestToken string, audience string) (string, error) {
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	query := u.Query()
+	query.Set("audience", audience)
+	u.RawQuery = query.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to request the OIDC ID token from GitHub Actions")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if resp.StatusCode != 200 {
+		return "", errors.Errorf("Failed to request the OIDC ID token from GitHub Actions: %s: %s", resp.Status, string(body))
+	}
+
+	var tokenResp struct {
+		Value string `json:"value"`
+	}
+	err = json.Unmarshal(body, &tokenResp)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to parse the OIDC ID token response from GitHub Actions")
+	}
+	if tokenResp.Value == "" {
+		return "", errors.New("The OIDC ID token response from GitHub Actions doesn't include a token")
+	}
+	return tokenResp.Value, nil
+}
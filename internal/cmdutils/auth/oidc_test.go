@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOIDCToken_GitHubActions(t *testing.T) {
+	t.Setenv("CIFUZZ_OIDC_TOKEN", "")
+	t.Setenv("CIFUZZ_OIDC_AUDIENCE", "")
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/github-id-token":
+			assert.Equal(t, "Bearer request-token", r.Header.Get("Authorization"))
+			assert.Equal(t, "2.0", r.URL.Query().Get("api-version"))
+			assert.Equal(t, server.URL, r.URL.Query().Get("audience"))
+			_, _ = w.Write([]byte(`{"value": "id-token"}`))
+		case "/v1/auth/oidc/token":
+			var body map[string]string
+			err := json.NewDecoder(r.Body).Decode(&body)
+			assert.NoError(t, err)
+			assert.Equal(t, "id-token", body["id_token"])
+			_, _ = w.Write([]byte(`{"access_token": "access-token"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", server.URL+"/github-id-token?api-version=2.0")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+
+	token := getOIDCToken(server.URL)
+	assert.Equal(t, "access-token", token)
+	assert.Equal(t, "access-token", oidcAccessTokens[server.URL])
+}
+
+func TestGetOIDCToken_ExchangeFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"code": 16, "message": "untrusted issuer"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("CIFUZZ_OIDC_TOKEN", "id-token")
+
+	// The failure is only reported as a warning, so that the stored
+	// access token can be used instead
+	token := getOIDCToken(server.URL)
+	assert.Empty(t, token)
+	_, ok := oidcAccessTokens[server.URL]
+	assert.True(t, ok)
+}
+
+func TestGetOIDCToken_NoIDToken(t *testing.T) {
+	t.Setenv("CIFUZZ_OIDC_TOKEN", "")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", "")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "")
+
+	token := getOIDCToken("http://localhost:1")
+	assert.Empty(t, token)
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/build"
 )
 
 var BundleFlags = []string{
@@ -115,6 +117,28 @@ func AddCommitFlag(cmd *cobra.Command) func() {
 	}
 }
 
+func AddCorpusSyncFlags(cmd *cobra.Command) func() {
+	cmd.Flags().String("corpus-sync", "",
+		"URL of an S3 or GCS bucket (e.g. \"s3://my-bucket/corpus\") to share the generated corpus\n"+
+			"across CI runs. The shared corpus is pulled before fuzzing and new corpus entries\n"+
+			"are pushed after fuzzing. Requires the aws or gcloud CLI.")
+	cmd.Flags().Bool("corpus-sync-pull-only", false,
+		"Only pull the shared corpus, without pushing new corpus entries.")
+	return func() {
+		ViperMustBindPFlag("corpus-sync", cmd.Flags().Lookup("corpus-sync"))
+		ViperMustBindPFlag("corpus-sync-pull-only", cmd.Flags().Lookup("corpus-sync-pull-only"))
+	}
+}
+
+func AddCrossPollinateFlag(cmd *cobra.Command) func() {
+	cmd.Flags().Bool("cross-pollinate", false,
+		"Before fuzzing, add the inputs from the generated corpora of the other fuzz tests\n"+
+			"of the project which increase the coverage of this fuzz test.")
+	return func() {
+		ViperMustBindPFlag("cross-pollinate", cmd.Flags().Lookup("cross-pollinate"))
+	}
+}
+
 func AddDictFlag(cmd *cobra.Command) func() {
 	// TODO(afl): Also link to https://github.com/AFLplusplus/AFLplusplus/blob/stable/dictionaries/README.md
 	cmd.Flags().String("dict", "",
@@ -174,6 +198,15 @@ func AddEnvFlag(cmd *cobra.Command) func() {
 	}
 }
 
+func AddGitHubRepoFlag(cmd *cobra.Command) func() {
+	cmd.Flags().String("github-repo", "",
+		"The GitHub repository in the format <owner>/<name>.\n"+
+			"Defaults to the repository of the 'origin' Git remote.")
+	return func() {
+		ViperMustBindPFlag("github-repo", cmd.Flags().Lookup("github-repo"))
+	}
+}
+
 func AddInteractiveFlag(cmd *cobra.Command) func() {
 	cmd.Flags().Bool("interactive", true, "Toggle interactive prompting in the terminal")
 	return func() {
@@ -181,6 +214,64 @@ func AddInteractiveFlag(cmd *cobra.Command) func() {
 	}
 }
 
+func AddLabelFlag(cmd *cobra.Command) func() {
+	cmd.Flags().StringArray("label", nil,
+		"Add a label to the bundle metadata, e.g. '--label `key=value`', to tag the\n"+
+			"bundle with a team, component or ticket ID. Overrides labels with the same\n"+
+			"key from cifuzz.yaml. Labels are shown in CI Sense.\n"+
+			"This flag can be used multiple times.")
+	return func() {
+		// Bound to a different key than the "labels" setting, so that
+		// the labels from the flag are added to the ones from
+		// cifuzz.yaml instead of replacing them
+		ViperMustBindPFlag("label", cmd.Flags().Lookup("label"))
+	}
+}
+
+func AddMaxRestartsFlag(cmd *cobra.Command) func() {
+	cmd.Flags().Uint("max-restarts", 0,
+		"Maximum number of times to restart the fuzzer from the current corpus if the fuzzing\n"+
+			"engine exits unexpectedly without reporting a finding. The default is to not restart.")
+	return func() {
+		ViperMustBindPFlag("max-restarts", cmd.Flags().Lookup("max-restarts"))
+	}
+}
+
+func AddMinimizeSeedCorpusFlag(cmd *cobra.Command) func() {
+	cmd.Flags().Bool("minimize-seed-corpus", false,
+		"Minimize the seed corpus before adding it to the bundle, so that the bundle only\n"+
+			"contains seeds which contribute new coverage. This runs libFuzzer's merge mode\n"+
+			"and is only supported for C/C++ fuzz tests.")
+	return func() {
+		ViperMustBindPFlag("minimize-seed-corpus", cmd.Flags().Lookup("minimize-seed-corpus"))
+	}
+}
+
+func AddResourceLimitFlags(cmd *cobra.Command) func() {
+	cmd.Flags().String("max-memory", "",
+		"Maximum memory which the fuzz test and all processes started by it can use together,\n"+
+			"e.g. \"4g\". The processes are killed if they exceed it. The default is to not limit\n"+
+			"the memory. Only supported on Linux (via cgroup v2 and systemd-run) and Windows.")
+	cmd.Flags().Float64("cpus", 0,
+		"Maximum number of CPUs which the fuzz test and all processes started by it can use\n"+
+			"together, e.g. 1.5. The default is to not limit the CPUs. Only supported on Linux\n"+
+			"(via cgroup v2 and systemd-run) and Windows.")
+	return func() {
+		ViperMustBindPFlag("max-memory", cmd.Flags().Lookup("max-memory"))
+		ViperMustBindPFlag("cpus", cmd.Flags().Lookup("cpus"))
+	}
+}
+
+func AddReproducibleFlag(cmd *cobra.Command) func() {
+	cmd.Flags().Bool("reproducible", false,
+		"Create a reproducible bundle, i.e. a bundle which is byte-identical for the same inputs.\n"+
+			"The entries of the bundle are sorted and get fixed timestamps (taken from the\n"+
+			"SOURCE_DATE_EPOCH environment variable, if set) and normalized permissions.")
+	return func() {
+		ViperMustBindPFlag("reproducible", cmd.Flags().Lookup("reproducible"))
+	}
+}
+
 func AddPresetFlag(cmd *cobra.Command) func() {
 	cmd.Flags().String("preset", "", "Preset for a given environment to execute coverage with necessary flags.\n"+
 		"We recommend not using this flag with '--format' or '--output' because the preset will set these accordingly.\n"+
@@ -234,6 +325,30 @@ e.g. "my-project-c170bc17".`)
 	}
 }
 
+func AddSanitizerFlag(cmd *cobra.Command) func() {
+	cmd.Flags().StringSlice("sanitizer", build.DefaultSanitizers,
+		"The `sanitizer` to build the fuzz test with, one of \"address\", \"undefined\", \"thread\" and \"memory\".\n"+
+			"This flag can be used multiple times or with a comma-separated list to combine sanitizers.\n"+
+			"ASan, TSan and MSan can't be combined with each other.\n"+
+			"Only supported for C/C++ projects.")
+	return func() {
+		ViperMustBindPFlag("sanitizers", cmd.Flags().Lookup("sanitizer"))
+	}
+}
+
+func AddSandboxFlag(cmd *cobra.Command) func() {
+	cmd.Flags().String("sandbox", "",
+		"The mode of the sandbox (see --use-sandbox):\n"+
+			"  default:     Run the fuzz test with access to the network of the host.\n"+
+			"  network-off: Run the fuzz test in a network namespace in which only the loopback\n"+
+			"               interface is available, to isolate fuzz tests which accidentally\n"+
+			"               perform network I/O. Enables the sandbox.\n"+
+			"Only supported for C/C++ projects on Linux.")
+	return func() {
+		ViperMustBindPFlag("sandbox", cmd.Flags().Lookup("sandbox"))
+	}
+}
+
 func AddSeedCorpusFlag(cmd *cobra.Command) func() {
 	// TODO(afl): Also link to https://aflplus.plus/docs/fuzzing_in_depth/#a-collecting-inputs
 	cmd.Flags().StringArrayP("seed-corpus", "s", nil,
@@ -253,6 +368,15 @@ func AddServerFlag(cmd *cobra.Command) func() {
 	}
 }
 
+func AddStopOnPlateauFlag(cmd *cobra.Command) func() {
+	cmd.Flags().Duration("stop-on-plateau", 0,
+		"Stop the fuzz test successfully if no new coverage was found for the specified\n"+
+			"duration, e.g. \"10m\". The default is to not stop early.")
+	return func() {
+		ViperMustBindPFlag("stop-on-plateau", cmd.Flags().Lookup("stop-on-plateau"))
+	}
+}
+
 func AddTimeoutFlag(cmd *cobra.Command) func() {
 	cmd.Flags().Duration("timeout", 0,
 		"Maximum time to run the fuzz test, e.g. \"30m\", \"1h\". The default is to run indefinitely.")
@@ -261,6 +385,16 @@ func AddTimeoutFlag(cmd *cobra.Command) func() {
 	}
 }
 
+func AddTUIFlag(cmd *cobra.Command) func() {
+	cmd.Flags().Bool("tui", false,
+		"Show a dashboard with the metrics of the fuzzing run and the findings, which is\n"+
+			"updated in place, instead of the metrics line and the output of the fuzzer.\n"+
+			"Only applies if cifuzz runs in a terminal.")
+	return func() {
+		ViperMustBindPFlag("tui", cmd.Flags().Lookup("tui"))
+	}
+}
+
 func AddUseSandboxFlag(cmd *cobra.Command) func() {
 	cmd.Flags().Bool("use-sandbox", false,
 		"By default, fuzz tests are executed in a sandbox to prevent accidental damage to the system.\n"+
@@ -137,19 +137,21 @@ func ParseProjectConfig(configDir string, opts interface{}) error {
 
 	// Set defaults
 	useSandboxDefault := runtime.GOOS == "linux"
-	viper.SetDefault("sandbox", useSandboxDefault)
+	viper.SetDefault("use-sandbox", useSandboxDefault)
 
 	err := viper.ReadInConfig()
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	// viper.Unmarshal doesn't return an error if the timeout value is
+	// viper.Unmarshal doesn't return an error if a duration value is
 	// missing a unit, so we check that manually
-	if viper.GetString("timeout") != "" {
-		_, err = time.ParseDuration(viper.GetString("timeout"))
-		if err != nil {
-			return errors.Wrap(err, "error decoding 'timeout'")
+	for _, key := range []string{"timeout", "stop-on-plateau"} {
+		if viper.GetString(key) != "" {
+			_, err = time.ParseDuration(viper.GetString(key))
+			if err != nil {
+				return errors.Wrapf(err, "error decoding '%s'", key)
+			}
 		}
 	}
 
@@ -158,6 +160,11 @@ func ParseProjectConfig(configDir string, opts interface{}) error {
 		return errors.WithStack(err)
 	}
 
+	err = validateLSanSuppressions()
+	if err != nil {
+		return err
+	}
+
 	// If the build system was not set by the user, try to determine it
 	// automatically.
 	v := reflect.ValueOf(opts).Elem().FieldByName("BuildSystem")
@@ -175,6 +182,13 @@ func ParseProjectConfig(configDir string, opts interface{}) error {
 		v.SetString(configDir)
 	}
 
+	// Set the config dir, against which relative paths from cifuzz.yaml
+	// are resolved
+	v = reflect.ValueOf(opts).Elem().FieldByName("ConfigDir")
+	if v.IsValid() && v.String() == "" {
+		v.SetString(configDir)
+	}
+
 	return nil
 }
 
@@ -195,29 +209,49 @@ func ValidateBuildSystem(buildSystem string) error {
 	return nil
 }
 
+// The files which identify the build systems, in the order in which
+// the build systems are preferred if multiple are detected
+var buildSystemIdentifiers = []struct {
+	buildSystem string
+	files       []string
+}{
+	{BuildSystemBazel, []string{"WORKSPACE", "WORKSPACE.bazel"}},
+	{BuildSystemCMake, []string{"CMakeLists.txt"}},
+	{BuildSystemMaven, []string{"pom.xml"}},
+	{BuildSystemGradle, []string{"build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts"}},
+	{BuildSystemNodeJS, []string{"package.json", "package-lock.json", "yarn.lock", "node_modules/"}},
+}
+
 func DetermineBuildSystem(projectDir string) (string, error) {
-	buildSystemIdentifier := map[string][]string{
-		BuildSystemBazel:  {"WORKSPACE", "WORKSPACE.bazel"},
-		BuildSystemCMake:  {"CMakeLists.txt"},
-		BuildSystemNodeJS: {"package.json", "package-lock.json", "yarn.lock", "node_modules/"},
-		BuildSystemMaven:  {"pom.xml"},
-		BuildSystemGradle: {"build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts"},
+	buildSystems, err := DetectBuildSystems(projectDir)
+	if err != nil {
+		return "", err
+	}
+	if len(buildSystems) == 0 {
+		return BuildSystemOther, nil
 	}
+	return buildSystems[0], nil
+}
 
-	for buildSystem, files := range buildSystemIdentifier {
-		for _, f := range files {
+// DetectBuildSystems returns all build systems which are used in the
+// project directory, e.g. both CMake and Bazel, in the order in which
+// they are preferred
+func DetectBuildSystems(projectDir string) ([]string, error) {
+	var buildSystems []string
+	for _, identifier := range buildSystemIdentifiers {
+		for _, f := range identifier.files {
 			isBuildSystem, err := fileutil.Exists(filepath.Join(projectDir, f))
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 
 			if isBuildSystem {
-				return buildSystem, nil
+				buildSystems = append(buildSystems, identifier.buildSystem)
+				break
 			}
 		}
 	}
-
-	return BuildSystemOther, nil
+	return buildSystems, nil
 }
 
 func IsGradleMultiProject(projectDir string) (bool, error) {
@@ -280,14 +314,21 @@ func FindConfigDir() (string, error) {
 }
 
 func EnsureProjectEntry(configContent string, project string) string {
-	// check if there is already a project entry (with or without a comment)
-	re := regexp.MustCompile(`(?m)^#*\s*project:.*$`)
-	// if there  is not, append it
+	return EnsureConfigEntry(configContent, "project", project)
+}
+
+// EnsureConfigEntry sets the top-level key of the config to the value,
+// replacing the existing entry of the key, which might be commented
+// out, or appending an entry if there is none
+func EnsureConfigEntry(configContent string, key string, value string) string {
+	// check if there is already an entry (with or without a comment)
+	re := regexp.MustCompile(`(?m)^#*\s*` + regexp.QuoteMeta(key) + `:.*$`)
+	// if there is not, append it
 	if !re.MatchString(configContent) {
-		return fmt.Sprintf("%s\nproject: %s\n", configContent, project)
+		return fmt.Sprintf("%s\n%s: %s\n", configContent, key, value)
 	}
 	// if there is, set it
-	return re.ReplaceAllString(configContent, fmt.Sprintf(`project: %s`, project))
+	return re.ReplaceAllLiteralString(configContent, fmt.Sprintf(`%s: %s`, key, value))
 }
 
 func NotSupportedErrorMessage(tool string, platform string) string {
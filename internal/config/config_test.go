@@ -5,12 +5,15 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hectane/go-acl"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"code-intelligence.com/cifuzz/util/envutil"
 	"code-intelligence.com/cifuzz/util/fileutil"
 )
 
@@ -171,6 +174,152 @@ func TestParseProjectConfigCMake(t *testing.T) {
 	require.Equal(t, BuildSystemCMake, opts.BuildSystem)
 }
 
+func TestParseProjectConfig_FuzzTests(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+	defer fileutil.Cleanup(projectDir)
+
+	opts := &struct {
+		BuildSystem     string            `mapstructure:"build-system"`
+		FuzzTestConfigs []*FuzzTestConfig `mapstructure:"fuzz-tests"`
+	}{}
+
+	configFile := filepath.Join(projectDir, ProjectConfigFile)
+	err = os.WriteFile(configFile, []byte(`build-system: other
+fuzz-tests:
+  - name: my_fuzz_test
+    timeout: 2h
+  - name: com.example.FuzzTestCase
+    runs: 1000
+    owner: team-parsers@example.com
+`), 0o644)
+	require.NoError(t, err)
+
+	err = ParseProjectConfig(projectDir, opts)
+	require.NoError(t, err)
+
+	fuzzTestConfig := FindFuzzTestConfig(opts.FuzzTestConfigs, "my_fuzz_test")
+	require.NotNil(t, fuzzTestConfig)
+	assert.Equal(t, 2*time.Hour, fuzzTestConfig.Timeout)
+	assert.Equal(t, uint(0), fuzzTestConfig.Runs)
+
+	fuzzTestConfig = FindFuzzTestConfig(opts.FuzzTestConfigs, "com.example.FuzzTestCase::myFuzzTest", "com.example.FuzzTestCase")
+	require.NotNil(t, fuzzTestConfig)
+	assert.Equal(t, uint(1000), fuzzTestConfig.Runs)
+
+	assert.Nil(t, FindFuzzTestConfig(opts.FuzzTestConfigs, "other_fuzz_test"))
+
+	// The owner of a Java test class applies to all its methods
+	assert.Equal(t, "team-parsers@example.com", FuzzTestOwner(opts.FuzzTestConfigs, "com.example.FuzzTestCase::myFuzzTest"))
+	assert.Empty(t, FuzzTestOwner(opts.FuzzTestConfigs, "my_fuzz_test"))
+}
+
+func TestParseProjectConfig_SanitizerOptions(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+	defer fileutil.Cleanup(projectDir)
+
+	opts := &struct {
+		BuildSystem string `mapstructure:"build-system"`
+	}{}
+
+	configFile := filepath.Join(projectDir, ProjectConfigFile)
+	err = os.WriteFile(configFile, []byte(`build-system: other
+asan-options:
+  detect_leaks: 0
+  malloc_context_size: 30
+ubsan-options:
+  halt_on_error: 1
+`), 0o644)
+	require.NoError(t, err)
+
+	// Options set in the environment take precedence
+	t.Setenv("ASAN_OPTIONS", "detect_leaks=1")
+	t.Setenv("UBSAN_OPTIONS", "")
+
+	err = ParseProjectConfig(projectDir, opts)
+	require.NoError(t, err)
+
+	// Parsing the config doesn't modify the environment of the
+	// cifuzz process
+	assert.Equal(t, "detect_leaks=1", os.Getenv("ASAN_OPTIONS"))
+	assert.Empty(t, os.Getenv("UBSAN_OPTIONS"))
+
+	env, err := SanitizerOptionsEnvVars()
+	require.NoError(t, err)
+	asanOptions := strings.Split(envutil.Getenv(env, "ASAN_OPTIONS"), ":")
+	assert.ElementsMatch(t, []string{"detect_leaks=1", "malloc_context_size=30"}, asanOptions)
+	assert.Equal(t, "halt_on_error=1", envutil.Getenv(env, "UBSAN_OPTIONS"))
+
+	env, err = SetSanitizerOptionsEnv([]string{"UBSAN_OPTIONS=print_stacktrace=1"})
+	require.NoError(t, err)
+	assert.Contains(t, env, "UBSAN_OPTIONS=print_stacktrace=1:halt_on_error=1")
+}
+
+func TestParseProjectConfig_LSanSuppressions(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+	defer fileutil.Cleanup(projectDir)
+
+	opts := &struct {
+		BuildSystem string `mapstructure:"build-system"`
+	}{}
+
+	suppressionsFile := filepath.Join(projectDir, "lsan.supp")
+	configFile := filepath.Join(projectDir, ProjectConfigFile)
+	err = os.WriteFile(configFile, []byte("build-system: other\nlsan-suppressions: "+suppressionsFile+"\n"), 0o644)
+	require.NoError(t, err)
+
+	t.Setenv("LSAN_OPTIONS", "")
+
+	// The suppressions file must exist
+	err = ParseProjectConfig(projectDir, opts)
+	require.Error(t, err)
+
+	err = os.WriteFile(suppressionsFile, []byte("leak:libfoo.so\n"), 0o644)
+	require.NoError(t, err)
+	err = ParseProjectConfig(projectDir, opts)
+	require.NoError(t, err)
+	assert.Empty(t, os.Getenv("LSAN_OPTIONS"))
+	env, err := SanitizerOptionsEnvVars()
+	require.NoError(t, err)
+	assert.Contains(t, env, "LSAN_OPTIONS=suppressions="+suppressionsFile)
+}
+
+func TestParseProjectConfig_LSanSuppressionsFromSubdir(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+	defer fileutil.Cleanup(projectDir)
+
+	opts := &struct {
+		BuildSystem string `mapstructure:"build-system"`
+		ConfigDir   string `mapstructure:"config-dir"`
+	}{}
+
+	err = os.WriteFile(filepath.Join(projectDir, "lsan.supp"), []byte("leak:libfoo.so\n"), 0o644)
+	require.NoError(t, err)
+	configFile := filepath.Join(projectDir, ProjectConfigFile)
+	err = os.WriteFile(configFile, []byte("build-system: other\nlsan-suppressions: lsan.supp\n"), 0o644)
+	require.NoError(t, err)
+
+	// Relative paths are resolved against the config dir, not the
+	// working directory
+	subDir := filepath.Join(projectDir, "src")
+	require.NoError(t, os.Mkdir(subDir, 0o755))
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+	require.NoError(t, os.Chdir(subDir))
+
+	t.Setenv("LSAN_OPTIONS", "")
+	err = FindAndParseProjectConfig(opts)
+	require.NoError(t, err)
+	env, err := SanitizerOptionsEnvVars()
+	require.NoError(t, err)
+	assert.Contains(t, env, "LSAN_OPTIONS=suppressions="+filepath.Join(projectDir, "lsan.supp"))
+	assert.Equal(t, projectDir, opts.ConfigDir)
+}
+
 func TestDetermineBuildSystem_CMake(t *testing.T) {
 	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
 	require.NoError(t, err)
@@ -275,6 +424,41 @@ func TestIsGradleMultiProject_False(t *testing.T) {
 	assert.False(t, isGradleMultiProject)
 }
 
+func TestDetectBuildSystems(t *testing.T) {
+	projectDir, err := os.MkdirTemp(baseTempDir, "project-")
+	require.NoError(t, err)
+	defer fileutil.Cleanup(projectDir)
+
+	buildSystems, err := DetectBuildSystems(projectDir)
+	require.NoError(t, err)
+	assert.Empty(t, buildSystems)
+
+	for _, file := range []string{"CMakeLists.txt", "WORKSPACE.bazel", "package.json", "yarn.lock"} {
+		err = os.WriteFile(filepath.Join(projectDir, file), []byte{}, 0o644)
+		require.NoError(t, err)
+	}
+	buildSystems, err = DetectBuildSystems(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{BuildSystemBazel, BuildSystemCMake, BuildSystemNodeJS}, buildSystems)
+
+	buildSystem, err := DetermineBuildSystem(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, BuildSystemBazel, buildSystem)
+}
+
+func TestEnsureConfigEntry(t *testing.T) {
+	content := `## The build system
+#build-system: cmake
+`
+	content = EnsureConfigEntry(content, "build-system", "bazel")
+	content = EnsureConfigEntry(content, "fuzz-test-dir", "fuzz/$dir")
+	assert.Equal(t, `## The build system
+build-system: bazel
+
+fuzz-test-dir: fuzz/$dir
+`, content)
+}
+
 func TestEnsureProjectEntry(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -330,3 +514,72 @@ project: myProject
 		})
 	}
 }
+
+func TestMigrateProjectConfig(t *testing.T) {
+	content := `## Old project config
+project: projects/my-project-1a2b3c4d
+sandbox: false
+seed-corpus:
+ - seeds
+timeout: 300
+`
+	migrated, changes := MigrateProjectConfig(content)
+	assert.Equal(t, `## Old project config
+project: my-project-1a2b3c4d
+use-sandbox: false
+seed-corpus-dirs:
+ - seeds
+timeout: 300s
+`, migrated)
+	assert.Len(t, changes, 4)
+
+	// Migrating a migrated config doesn't change it
+	migratedAgain, changes := MigrateProjectConfig(migrated)
+	assert.Equal(t, migrated, migratedAgain)
+	assert.Empty(t, changes)
+
+	// Keys are not renamed if the new key is already set
+	content = "sandbox: false\nuse-sandbox: true\n"
+	migrated, changes = MigrateProjectConfig(content)
+	assert.Equal(t, content, migrated)
+	require.Len(t, changes, 1)
+	assert.Contains(t, changes[0], "please remove one of them manually")
+
+	// The current sandbox setting is not renamed
+	content = "sandbox: network-off\n"
+	migrated, changes = MigrateProjectConfig(content)
+	assert.Equal(t, content, migrated)
+	assert.Empty(t, changes)
+}
+
+func TestAddFuzzTestConfigs(t *testing.T) {
+	configs := []*FuzzTestConfig{
+		{Name: "my_fuzz_test", SeedCorpusDirs: []string{"fuzz/corpus/my_fuzz_test"}, Dict: "fuzz/my.dict"},
+	}
+
+	content := "build-system: cmake\n"
+	assert.Equal(t, `build-system: cmake
+
+fuzz-tests:
+  - name: "my_fuzz_test"
+    seed-corpus-dirs:
+      - "fuzz/corpus/my_fuzz_test"
+    dict: "fuzz/my.dict"
+`, AddFuzzTestConfigs(content, configs))
+
+	// The entries are added to the existing list with its indentation
+	content = `fuzz-tests:
+  # A comment
+ - name: other_fuzz_test
+   timeout: 2h
+`
+	assert.Equal(t, `fuzz-tests:
+ - name: "my_fuzz_test"
+   seed-corpus-dirs:
+     - "fuzz/corpus/my_fuzz_test"
+   dict: "fuzz/my.dict"
+  # A comment
+ - name: other_fuzz_test
+   timeout: 2h
+`, AddFuzzTestConfigs(content, configs))
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FuzzTestConfig contains settings from the "fuzz-tests" section of
+// cifuzz.yaml, which only apply to a single fuzz test.
+//
+// The section is a list instead of a map from fuzz test names to
+// settings, because viper converts map keys to lowercase and treats
+// dots in them as key delimiters, which would break Java class names.
+type FuzzTestConfig struct {
+	// The name of the fuzz test as passed to `cifuzz run`
+	Name string `mapstructure:"name"`
+	// Maximum time to run the fuzz test
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Maximum number of inputs to run the fuzz test on
+	Runs uint `mapstructure:"runs"`
+	// Format of the inputs of the fuzz test, which is used to decode
+	// crashing inputs when showing findings, e.g. "json", "png" or
+	// "protobuf:my.package.Message"
+	InputFormat string `mapstructure:"input-format"`
+	// Commands which post-process new entries of the generated corpus.
+	// Each command receives an entry on stdin and prints the processed
+	// entry to stdout.
+	CorpusPostProcessors []string `mapstructure:"corpus-post-processors"`
+	// The team or person (e.g. an email address) which owns the fuzz
+	// test, which is used to group and route findings
+	Owner string `mapstructure:"owner"`
+	// Directories containing seeds which are only used for the fuzz
+	// test, in addition to the global seed corpus directories
+	SeedCorpusDirs []string `mapstructure:"seed-corpus-dirs"`
+	// The dictionary of the fuzz test, which is used instead of the
+	// default dictionary next to the fuzz test
+	Dict string `mapstructure:"dict"`
+}
+
+// AbsSeedCorpusDirs returns the seed corpus directories of the fuzz
+// test, with relative paths resolved against the project directory
+func (c *FuzzTestConfig) AbsSeedCorpusDirs(projectDir string) []string {
+	var dirs []string
+	for _, dir := range c.SeedCorpusDirs {
+		dirs = append(dirs, absPath(projectDir, dir))
+	}
+	return dirs
+}
+
+// AbsDict returns the dictionary of the fuzz test, with a relative path
+// resolved against the project directory, or an empty string if no
+// dictionary is configured
+func (c *FuzzTestConfig) AbsDict(projectDir string) string {
+	if c.Dict == "" {
+		return ""
+	}
+	return absPath(projectDir, c.Dict)
+}
+
+func absPath(projectDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(projectDir, filepath.FromSlash(path))
+}
+
+// FindFuzzTestConfig returns the config of the first of the given
+// fuzz test names which has an entry in the list of fuzz test configs,
+// or nil if none of them has an entry.
+func FindFuzzTestConfig(configs []*FuzzTestConfig, names ...string) *FuzzTestConfig {
+	for _, name := range names {
+		for _, c := range configs {
+			if c != nil && c.Name == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// FuzzTestOwner returns the owner of the fuzz test, or an empty string
+// if no owner is configured. For Java fuzz tests with a method, the
+// owner of the test class is used if the method has no owner.
+func FuzzTestOwner(configs []*FuzzTestConfig, fuzzTest string) string {
+	names := []string{fuzzTest}
+	if class, _, found := strings.Cut(fuzzTest, "::"); found {
+		names = append(names, class)
+	}
+	for _, name := range names {
+		c := FindFuzzTestConfig(configs, name)
+		if c != nil && c.Owner != "" {
+			return c.Owner
+		}
+	}
+	return ""
+}
+
+// The "fuzz-tests" key of a project config, which starts the list of
+// fuzz test configs
+var fuzzTestsKeyRegex = regexp.MustCompile(`(?m)^fuzz-tests:[ \t]*(?:#.*)?$`)
+
+// The first item of the list following the "fuzz-tests" key, skipping
+// empty lines and comments
+var listItemRegex = regexp.MustCompile(`^(?:\n[ \t]*(?:#.*)?)*\n([ \t]*)- `)
+
+// AddFuzzTestConfigs adds entries for the given fuzz test configs to the
+// "fuzz-tests" section of the content of a project config, which is
+// created if it doesn't exist yet. Like MigrateProjectConfig, it edits
+// the content as text, so that comments and formatting are preserved.
+// Only the name, seed corpus directories and dictionary are written.
+func AddFuzzTestConfigs(content string, configs []*FuzzTestConfig) string {
+	if len(configs) == 0 {
+		return content
+	}
+
+	loc := fuzzTestsKeyRegex.FindStringIndex(content)
+	// The entries must be indented like the existing entries, otherwise
+	// they are not part of the same list
+	indent := "  "
+	if loc != nil {
+		if m := listItemRegex.FindStringSubmatch(content[loc[1]:]); m != nil {
+			indent = m[1]
+		}
+	}
+
+	var entries strings.Builder
+	for _, c := range configs {
+		fmt.Fprintf(&entries, "%s- name: %q\n", indent, c.Name)
+		if len(c.SeedCorpusDirs) > 0 {
+			fmt.Fprintf(&entries, "%s  seed-corpus-dirs:\n", indent)
+			for _, dir := range c.SeedCorpusDirs {
+				fmt.Fprintf(&entries, "%s    - %q\n", indent, dir)
+			}
+		}
+		if c.Dict != "" {
+			fmt.Fprintf(&entries, "%s  dict: %q\n", indent, c.Dict)
+		}
+	}
+
+	// The order of the entries doesn't matter, so the new entries are
+	// added directly after the key instead of after the existing ones
+	if loc != nil {
+		return content[:loc[1]] + "\n" + strings.TrimSuffix(entries.String(), "\n") + content[loc[1]:]
+	}
+	return ensureTrailingNewline(content) + "\nfuzz-tests:\n" + entries.String()
+}
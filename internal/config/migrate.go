@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Keys which were renamed in the project config. Older cifuzz versions
+// used the old names, which are ignored by the current version. If the
+// old name is used by a current key as well, the key is only renamed
+// if its value matches the pattern of the old values.
+var renamedKeys = []struct {
+	old   string
+	new   string
+	value string
+}{
+	// The sandbox setting now selects the sandbox mode
+	{"sandbox", "use-sandbox", `[ \t]*["']?(?:true|false)["']?[ \t]*(?:#.*)?$`},
+	{"seed-corpus", "seed-corpus-dirs", ""},
+	{"engine-arg", "engine-args", ""},
+}
+
+var (
+	// Older versions interpreted a timeout without a unit as seconds,
+	// the current version requires a unit
+	timeoutWithoutUnitRegex = regexp.MustCompile(`(?m)^timeout:[ \t]*["']?(\d+)["']?[ \t]*$`)
+	// Older versions stored the project name with the "projects/"
+	// prefix used by the API
+	projectWithPrefixRegex = regexp.MustCompile(`(?m)^project:([ \t]*["']?)projects/`)
+)
+
+// MigrateProjectConfig upgrades the content of a project config written
+// for an older cifuzz version to the current schema. The content is
+// edited line by line instead of being re-encoded, so that comments and
+// formatting are preserved. It returns the migrated content and a
+// description of each change.
+func MigrateProjectConfig(content string) (string, []string) {
+	var changes []string
+
+	for _, key := range renamedKeys {
+		oldKeyRegex := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(key.old) + `:(` + key.value + `)`)
+		if !oldKeyRegex.MatchString(content) {
+			continue
+		}
+		newKeyRegex := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(key.new) + `:`)
+		if newKeyRegex.MatchString(content) {
+			// We can't tell which of the values is the intended one, so
+			// the user has to resolve this manually
+			changes = append(changes, fmt.Sprintf(
+				"Not renamed '%s' to '%s' because '%s' is already set, please remove one of them manually",
+				key.old, key.new, key.new))
+			continue
+		}
+		content = oldKeyRegex.ReplaceAllString(content, key.new+":${1}")
+		changes = append(changes, fmt.Sprintf("Renamed '%s' to '%s'", key.old, key.new))
+	}
+
+	if timeoutWithoutUnitRegex.MatchString(content) {
+		content = timeoutWithoutUnitRegex.ReplaceAllString(content, "timeout: ${1}s")
+		changes = append(changes, "Added the unit 's' to the value of 'timeout'")
+	}
+
+	if projectWithPrefixRegex.MatchString(content) {
+		content = projectWithPrefixRegex.ReplaceAllString(content, "project:${1}")
+		changes = append(changes, "Removed the 'projects/' prefix from the value of 'project'")
+	}
+
+	return content, changes
+}
+
+// UnifiedDiff returns a unified diff of the old and new content of the
+// file with the given name, or an empty string if they are equal
+func UnifiedDiff(name, oldContent, newContent string) (string, error) {
+	if oldContent == newContent {
+		return "", nil
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(ensureTrailingNewline(oldContent)),
+		B:        difflib.SplitLines(ensureTrailingNewline(newContent)),
+		FromFile: "a/" + name,
+		ToFile:   "b/" + name,
+		Context:  3,
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return diff, nil
+}
+
+func ensureTrailingNewline(s string) string {
+	if s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
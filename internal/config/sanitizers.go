@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"golang.org/x/exp/maps"
+
+	"code-intelligence.com/cifuzz/util/envutil"
+)
+
+// The cifuzz.yaml keys of the sanitizer runtime options and the
+// environment variables via which they are passed to the sanitizers
+var sanitizerOptionsEnvVars = map[string]string{
+	"asan-options":  "ASAN_OPTIONS",
+	"ubsan-options": "UBSAN_OPTIONS",
+}
+
+// SetSanitizerOptionsEnv merges the sanitizer runtime options from
+// cifuzz.yaml into the ASAN_OPTIONS and UBSAN_OPTIONS of the given
+// environment. Options which are already set in the environment take
+// precedence over the ones from cifuzz.yaml.
+func SetSanitizerOptionsEnv(env []string) ([]string, error) {
+	var err error
+	for key, envVar := range sanitizerOptionsEnvVars {
+		options := viper.GetStringMapString(key)
+		if len(options) == 0 {
+			continue
+		}
+		env, err = envutil.Setenv(env, envVar, MergeSanitizerOptions(envutil.Getenv(env, envVar), options))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// SanitizerOptionsEnvVars returns the sanitizer runtime options from
+// cifuzz.yaml as KEY=VALUE environment variables, which the runners
+// add to the environment of the fuzz tests they execute. Options set
+// in the environment of the cifuzz process take precedence over the
+// ones from cifuzz.yaml.
+func SanitizerOptionsEnvVars() ([]string, error) {
+	var env []string
+	var err error
+	for _, envVar := range append(maps.Values(sanitizerOptionsEnvVars), "LSAN_OPTIONS") {
+		if value := os.Getenv(envVar); value != "" {
+			env, err = envutil.Setenv(env, envVar, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	env, err = SetSanitizerOptionsEnv(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// Known leaks can be suppressed via a LeakSanitizer suppressions
+	// file, see
+	// https://github.com/google/sanitizers/wiki/AddressSanitizerLeakSanitizer#suppressions
+	suppressions, err := lsanSuppressionsPath()
+	if err != nil {
+		return nil, err
+	}
+	if suppressions != "" {
+		lsanOptions := MergeSanitizerOptions(envutil.Getenv(env, "LSAN_OPTIONS"), map[string]string{"suppressions": suppressions})
+		env, err = envutil.Setenv(env, "LSAN_OPTIONS", lsanOptions)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// lsanSuppressionsPath returns the absolute path of the LeakSanitizer
+// suppressions file from cifuzz.yaml, or an empty string if none is
+// configured. The fuzz tests are not necessarily executed in the
+// current working directory, so relative paths can't be passed on.
+func lsanSuppressionsPath() (string, error) {
+	suppressions := viper.GetString("lsan-suppressions")
+	if suppressions == "" {
+		return "", nil
+	}
+	suppressions, err := filepath.Abs(ResolvePath(filepath.Dir(viper.ConfigFileUsed()), suppressions))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return suppressions, nil
+}
+
+// validateLSanSuppressions checks that the LeakSanitizer suppressions
+// file from cifuzz.yaml exists, so that a wrong path is reported when
+// the config is parsed instead of being ignored by the sanitizer
+func validateLSanSuppressions() error {
+	suppressions, err := lsanSuppressionsPath()
+	if err != nil {
+		return err
+	}
+	if suppressions == "" {
+		return nil
+	}
+	_, err = os.Stat(suppressions)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to access LeakSanitizer suppressions file %s", suppressions)
+	}
+	return nil
+}
+
+// ResolvePath returns the path from cifuzz.yaml, which is relative to
+// the config dir if it's not absolute, so that it doesn't depend on the
+// working directory from which cifuzz is executed
+func ResolvePath(configDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(configDir, path)
+}
+
+// MergeSanitizerOptions adds the given options to the colon-separated
+// list of existing options, unless they are already set there
+func MergeSanitizerOptions(existing string, options map[string]string) string {
+	var merged []string
+	if existing != "" {
+		merged = strings.Split(existing, ":")
+	}
+
+	// Sort the keys to produce a stable result
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if strings.HasPrefix(existing, key+"=") || strings.Contains(existing, ":"+key+"=") {
+			continue
+		}
+		merged = append(merged, key+"="+options[key])
+	}
+	return strings.Join(merged, ":")
+}
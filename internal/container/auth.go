@@ -23,9 +23,7 @@ func RegistryAuth(registry string) (string, error) {
 		return "", errors.WithStack(err)
 	}
 
-	// Strip the repository name from the registry
-	registry = strings.Split(registry, "/")[0]
-	a, err := cfg.GetAuthConfig(registry)
+	a, err := cfg.GetAuthConfig(registryKey(registry))
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
@@ -38,3 +36,17 @@ func RegistryAuth(registry string) (string, error) {
 
 	return base64.URLEncoding.EncodeToString(encodedConfig), nil
 }
+
+// registryKey returns the key of the registry in the Docker config file.
+// The repository name is stripped from the registry. Docker Hub uses the
+// URL of its index server as key, which must be kept as it is.
+func registryKey(registry string) string {
+	if registry == dockerHubRegistry {
+		return registry
+	}
+	host := strings.Split(registry, "/")[0]
+	if host == "docker.io" || host == "index.docker.io" {
+		return dockerHubRegistry
+	}
+	return host
+}
@@ -0,0 +1,52 @@
+package container
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	containerRegistry "github.com/docker/docker/api/types/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryKey(t *testing.T) {
+	for _, tc := range []struct {
+		registry string
+		expected string
+	}{
+		{dockerHubRegistry, dockerHubRegistry},
+		{"docker.io/my-org/fuzz-tests", dockerHubRegistry},
+		{"index.docker.io/my-org/fuzz-tests", dockerHubRegistry},
+		{"ghcr.io", "ghcr.io"},
+		{"ghcr.io/my-org/fuzz-tests", "ghcr.io"},
+		{"localhost:5000/fuzz-tests", "localhost:5000"},
+	} {
+		t.Run(tc.registry, func(t *testing.T) {
+			assert.Equal(t, tc.expected, registryKey(tc.registry))
+		})
+	}
+}
+
+func TestRegistryAuth_DockerHub(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("USERPROFILE", homeDir)
+	t.Setenv("DOCKER_CONFIG", "")
+	dockerConfigDir := filepath.Join(homeDir, ".docker")
+	require.NoError(t, os.Mkdir(dockerConfigDir, 0o755))
+	auth := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	config := `{"auths": {"` + dockerHubRegistry + `": {"auth": "` + auth + `"}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dockerConfigDir, "config.json"), []byte(config), 0o600))
+
+	encoded, err := RegistryAuth(dockerHubRegistry)
+	require.NoError(t, err)
+	decoded, err := base64.URLEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	var authConfig containerRegistry.AuthConfig
+	require.NoError(t, json.Unmarshal(decoded, &authConfig))
+	assert.Equal(t, "user", authConfig.Username)
+	assert.Equal(t, "secret", authConfig.Password)
+}
@@ -1,21 +1,98 @@
 package container
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
 	"github.com/docker/docker/client"
 	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
 )
 
 var dockerClient *client.Client
 
+// The socket of the Docker daemon, which is used if DOCKER_HOST is not set
+const dockerSocket = "/var/run/docker.sock"
+
 // GetDockerClient returns a docker client and will also handle its closing. It will take configuration options in the future.
+// If DOCKER_HOST is not set and the Docker daemon is not running, the
+// Docker-compatible API of Podman is used, if available.
 func GetDockerClient() (*client.Client, error) {
 	if dockerClient != nil {
 		return dockerClient, nil
 	}
-	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if host := podmanHost(); host != "" {
+		log.Debugf("Using the Podman socket %s", host)
+		opts = append(opts, client.WithHost(host))
+	}
+	var err error
+	dockerClient, err = client.NewClientWithOpts(opts...)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	defer dockerClient.Close()
 	return dockerClient, nil
 }
+
+// podmanHost returns the address of the Podman socket if DOCKER_HOST is
+// not set, the Docker socket doesn't exist and a Podman socket exists.
+// The Podman socket is provided by the podman.socket systemd unit or by
+// `podman system service`, on macOS by the Podman machine.
+func podmanHost() string {
+	if os.Getenv(client.EnvOverrideHost) != "" {
+		return ""
+	}
+	// The variable which Podman's remote client uses instead of
+	// DOCKER_HOST
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		return host
+	}
+	if exists, _ := fileutil.Exists(dockerSocket); exists {
+		return ""
+	}
+
+	// The sockets of rootless Podman, which is preferred if both are
+	// running, because it doesn't require root privileges
+	var sockets []string
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" && runtime.GOOS == "linux" {
+		runtimeDir = fmt.Sprintf("/run/user/%d", os.Getuid())
+	}
+	if runtimeDir != "" {
+		sockets = append(sockets, filepath.Join(runtimeDir, "podman", "podman.sock"))
+	}
+	if socket := podmanMachineSocket(); socket != "" {
+		sockets = append(sockets, socket)
+	}
+	sockets = append(sockets, "/run/podman/podman.sock")
+	for _, socket := range sockets {
+		if exists, _ := fileutil.Exists(socket); exists {
+			return "unix://" + socket
+		}
+	}
+	return ""
+}
+
+// podmanMachineSocket returns the path of the socket of the default
+// Podman machine, which runs the containers in a VM on macOS
+func podmanMachineSocket() string {
+	if runtime.GOOS != "darwin" {
+		return ""
+	}
+	podman, err := exec.LookPath("podman")
+	if err != nil {
+		return ""
+	}
+	out, err := exec.Command(podman, "machine", "inspect", "--format", "{{.ConnectionInfo.PodmanSocket.Path}}").Output()
+	if err != nil {
+		log.Debugf("Failed to get the socket of the Podman machine: %v", err)
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
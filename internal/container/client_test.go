@@ -0,0 +1,39 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+func TestPodmanHost(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Podman sockets are only looked up on Unix")
+	}
+	if exists, _ := fileutil.Exists(dockerSocket); exists {
+		t.Skip("The Docker socket exists, so Podman is not used")
+	}
+
+	runtimeDir := testutil.MkdirTemp(t, "", "podman-test-")
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("CONTAINER_HOST", "")
+	socket := filepath.Join(runtimeDir, "podman", "podman.sock")
+	require.NoError(t, os.MkdirAll(filepath.Dir(socket), 0o755))
+	require.NoError(t, os.WriteFile(socket, nil, 0o644))
+	assert.Equal(t, "unix://"+socket, podmanHost())
+
+	t.Setenv("CONTAINER_HOST", "unix:///tmp/podman.sock")
+	assert.Equal(t, "unix:///tmp/podman.sock", podmanHost())
+
+	// DOCKER_HOST takes precedence
+	t.Setenv("DOCKER_HOST", "tcp://localhost:2375")
+	assert.Empty(t, podmanHost())
+}
@@ -20,12 +20,28 @@ import (
 
 var ManagedSeedCorpusDir = "/tmp/managed-seed-corpus"
 
-func Create(imageID string, printJSON bool, bindMounts []string, args []string) (string, error) {
+// CreateOptions configures the fuzz container
+type CreateOptions struct {
+	PrintJSON  bool
+	BindMounts []string
+	Args       []string
+	// The memory limit of the container in bytes, zero means no limit
+	Memory int64
+	// The number of CPUs the container can use, zero means no limit
+	CPUs float64
+	// If true, the container has no network access
+	NoNetwork bool
+}
+
+func Create(imageID string, opts *CreateOptions) (string, error) {
 	cli, err := GetDockerClient()
 	if err != nil {
 		return "", err
 	}
 
+	bindMounts := opts.BindMounts
+	args := opts.Args
+
 	workDir, err := os.Getwd()
 	if err != nil {
 		return "", errors.WithStack(err)
@@ -40,6 +56,16 @@ func Create(imageID string, printJSON bool, bindMounts []string, args []string)
 
 	hostConfig := &container.HostConfig{
 		Binds: bindMounts,
+		Resources: container.Resources{
+			Memory:   opts.Memory,
+			NanoCPUs: int64(opts.CPUs * 1e9),
+		},
+		// Don't allow the fuzz test to gain privileges via setuid
+		// binaries
+		SecurityOpt: []string{"no-new-privileges"},
+	}
+	if opts.NoNetwork {
+		hostConfig.NetworkMode = "none"
 	}
 	containerConfig := &container.Config{
 		Image:        imageID,
@@ -52,7 +78,7 @@ func Create(imageID string, printJSON bool, bindMounts []string, args []string)
 	if viper.GetBool("verbose") {
 		containerConfig.Cmd = append(containerConfig.Cmd, "-v")
 	}
-	if printJSON {
+	if opts.PrintJSON {
 		containerConfig.Cmd = append(containerConfig.Cmd, "--json")
 	}
 	if log.PlainStyle() {
@@ -77,6 +103,12 @@ func Create(imageID string, printJSON bool, bindMounts []string, args []string)
 	}
 
 	ctx := context.Background()
+	engine, err := getEngineInfo(ctx, cli)
+	if err != nil {
+		return "", err
+	}
+	adaptToEngine(engine, containerConfig, hostConfig)
+
 	cont, err := cli.ContainerCreate(
 		ctx,
 		containerConfig,
@@ -89,7 +121,7 @@ func Create(imageID string, printJSON bool, bindMounts []string, args []string)
 		"", // TODO: should the container have a name?
 	)
 	if err != nil {
-		return "", errors.WithStack(err)
+		return "", wrapCreateError(engine, err)
 	}
 
 	log.Debugf("Created fuzz container %s based on image %s", cont.ID, containerConfig.Image)
@@ -177,3 +209,17 @@ Press Ctrl+C to stop the container.`, id)
 
 	return nil
 }
+
+// Remove removes the container, including its anonymous volumes
+func Remove(id string) error {
+	cli, err := GetDockerClient()
+	if err != nil {
+		return err
+	}
+	err = cli.ContainerRemove(context.Background(), id, types.ContainerRemoveOptions{RemoveVolumes: true, Force: true})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	log.Debugf("Removed container %s", id)
+	return nil
+}
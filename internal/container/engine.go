@@ -0,0 +1,122 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// engineInfo describes the container engine which runs the containers,
+// which is either Docker or Podman via its Docker-compatible API
+type engineInfo struct {
+	Podman bool
+	// If true, the engine runs as an unprivileged user, so that the
+	// root user in the container is mapped to that user
+	Rootless bool
+	SELinux  bool
+	CgroupV2 bool
+}
+
+func (e *engineInfo) String() string {
+	name := "Docker"
+	if e.Podman {
+		name = "Podman"
+	}
+	if e.Rootless {
+		name = "rootless " + name
+	}
+	return name
+}
+
+func getEngineInfo(ctx context.Context, cli *client.Client) (*engineInfo, error) {
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	version, err := cli.ServerVersion(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	e := &engineInfo{CgroupV2: info.CgroupVersion == "2"}
+	for _, component := range version.Components {
+		if strings.HasPrefix(component.Name, "Podman") {
+			e.Podman = true
+		}
+	}
+	securityOpts, err := types.DecodeSecurityOptions(info.SecurityOptions)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, opt := range securityOpts {
+		switch opt.Name {
+		case "rootless":
+			e.Rootless = true
+		case "selinux":
+			e.SELinux = true
+		}
+	}
+	log.Debugf("Using %s (cgroup v2: %t, SELinux: %t)", e, e.CgroupV2, e.SELinux)
+	return e, nil
+}
+
+// adaptToEngine adapts the container config to the quirks of rootless
+// engines, so that files which the fuzz container writes to the
+// mounted working directory are owned by the user on the host:
+//
+//   - Rootless Podman maps the user of the host to root in the container
+//     and all other users to subordinate IDs. With the "keep-id" user
+//     namespace, the user of the host is mapped to the same ID in the
+//     container instead.
+//   - Rootless Docker doesn't support "keep-id", so the container runs
+//     as root, which is the user of the host.
+//
+// SELinux prevents the container from accessing the mounted directory,
+// unless it's relabeled, so the labeling is disabled for the container
+// instead of changing the labels of the user's files.
+//
+// Resource limits require cgroup v2 with rootless engines. If they are
+// not supported, they are dropped with a warning.
+func adaptToEngine(e *engineInfo, containerConfig *container.Config, hostConfig *container.HostConfig) {
+	if e.Rootless {
+		if e.Podman {
+			hostConfig.UsernsMode = "keep-id"
+		} else {
+			containerConfig.User = "0:0"
+		}
+	}
+
+	if e.SELinux {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "label=disable")
+	}
+
+	if e.Rootless && !e.CgroupV2 && (hostConfig.Memory != 0 || hostConfig.NanoCPUs != 0) {
+		log.Warnf("Ignoring the memory and CPU limits, because %s only supports them with cgroup v2", e)
+		hostConfig.Memory = 0
+		hostConfig.NanoCPUs = 0
+	}
+}
+
+// wrapCreateError adds a hint to errors which are caused by resource
+// limits which the engine can't apply
+func wrapCreateError(e *engineInfo, err error) error {
+	msg := err.Error()
+	if e.Rootless && (strings.Contains(msg, "cgroup") || strings.Contains(msg, "controller")) {
+		hint := fmt.Sprintf(`%s can only apply memory and CPU limits if the cpu and memory cgroup
+controllers are delegated to the user, see
+https://rootlesscontaine.rs/getting-started/common/cgroup2/`, e)
+		if os.Getenv("XDG_RUNTIME_DIR") == "" {
+			hint += "\nNote that XDG_RUNTIME_DIR is not set, which is required by systemd to delegate cgroups."
+		}
+		return errors.Errorf("%v\n%s", err, hint)
+	}
+	return errors.WithStack(err)
+}
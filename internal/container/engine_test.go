@@ -0,0 +1,71 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptToEngine(t *testing.T) {
+	tests := []struct {
+		name       string
+		engine     engineInfo
+		user       string
+		usernsMode container.UsernsMode
+		securityOp []string
+		memory     int64
+	}{
+		{
+			name:       "Docker",
+			engine:     engineInfo{CgroupV2: true},
+			securityOp: []string{"no-new-privileges"},
+			memory:     1 << 30,
+		},
+		{
+			name:       "rootless Podman",
+			engine:     engineInfo{Podman: true, Rootless: true, CgroupV2: true},
+			usernsMode: "keep-id",
+			securityOp: []string{"no-new-privileges"},
+			memory:     1 << 30,
+		},
+		{
+			name:       "rootless Docker",
+			engine:     engineInfo{Rootless: true, CgroupV2: true},
+			user:       "0:0",
+			securityOp: []string{"no-new-privileges"},
+			memory:     1 << 30,
+		},
+		{
+			name:       "rootless Podman with SELinux and cgroup v1",
+			engine:     engineInfo{Podman: true, Rootless: true, SELinux: true},
+			usernsMode: "keep-id",
+			securityOp: []string{"no-new-privileges", "label=disable"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			containerConfig := &container.Config{}
+			hostConfig := &container.HostConfig{
+				SecurityOpt: []string{"no-new-privileges"},
+				Resources:   container.Resources{Memory: 1 << 30, NanoCPUs: 1e9},
+			}
+			adaptToEngine(&tt.engine, containerConfig, hostConfig)
+			assert.Equal(t, tt.user, containerConfig.User)
+			assert.Equal(t, tt.usernsMode, hostConfig.UsernsMode)
+			assert.Equal(t, tt.securityOp, hostConfig.SecurityOpt)
+			assert.Equal(t, tt.memory, hostConfig.Memory)
+		})
+	}
+}
+
+func TestWrapCreateError(t *testing.T) {
+	err := errors.New("crun: the requested cgroup controller `memory` is not available")
+	wrapped := wrapCreateError(&engineInfo{Podman: true, Rootless: true}, err)
+	assert.ErrorContains(t, wrapped, "rootless Podman can only apply memory and CPU limits")
+
+	wrapped = wrapCreateError(&engineInfo{}, err)
+	assert.Equal(t, err.Error(), wrapped.Error())
+}
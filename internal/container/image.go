@@ -4,12 +4,17 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"text/template"
 
+	"github.com/alessio/shellescape"
+	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
 	"github.com/otiai10/copy"
 	"github.com/pkg/errors"
 
@@ -30,15 +35,50 @@ var dockerfileTemplate string
 type dockerfileConfig struct {
 	CIFuzzImage string
 	Base        string
+	// The shell command which installs the additional packages, if any
+	InstallPackages string
 }
 
+// ImageOptions configures the fuzz container image
+type ImageOptions struct {
+	// Additional tags of the image, besides "cifuzz"
+	Tags []string
+	// System packages which are installed into the image with the
+	// package manager of the base image, e.g. runtime libraries which
+	// the fuzz tests need
+	Packages []string
+}
+
+// Package names with an optional version, like "libssl-dev" or
+// "libxml2=2.9.14", without any characters which have a special
+// meaning in the shell
+var packageRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9.+_:~=-]*$`)
+
+// The registry which is used for image references without a domain.
+// This is the key of Docker Hub in the Docker config file.
+const dockerHubRegistry = "https://index.docker.io/v1/"
+
 // BuildImageFromBundle creates an image based on an existing bundle.
-func BuildImageFromBundle(bundlePath string) (string, error) {
-	buildContextDir, err := prepareBuildContext(bundlePath)
+// The image is tagged "cifuzz" and with the additional tags of the
+// options.
+func BuildImageFromBundle(bundlePath string, opts *ImageOptions) (string, error) {
+	buildContextDir, err := prepareBuildContext(bundlePath, opts.Packages)
 	if err != nil {
 		return "", err
 	}
-	return buildImageFromDir(buildContextDir)
+	defer fileutil.Cleanup(buildContextDir)
+	return buildImageFromDir(buildContextDir, opts.Tags)
+}
+
+// ValidatePackages checks that the packages which should be installed
+// into the image are valid package names
+func ValidatePackages(packages []string) error {
+	for _, pkg := range packages {
+		if !packageRegex.MatchString(pkg) {
+			return errors.Errorf("Invalid package name %q in container-packages", pkg)
+		}
+	}
+	return nil
 }
 
 // UploadImage uploads an image to a registry.
@@ -60,13 +100,34 @@ func UploadImage(imageID string, registry string) error {
 		return errors.WithStack(err)
 	}
 
+	return pushImage(ctx, dockerClient, remoteTag, registry)
+}
+
+// PushImage pushes a tagged image, e.g. "ghcr.io/org/fuzz-tests:v1", to
+// the registry of the tag. The credentials of the registry are read
+// from the Docker config file, like for 'docker push'.
+func PushImage(tag string) error {
+	named, err := reference.ParseNormalizedNamed(tag)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	dockerClient, err := GetDockerClient()
+	if err != nil {
+		return err
+	}
+	return pushImage(context.Background(), dockerClient, tag, registryOfReference(named))
+}
+
+func pushImage(ctx context.Context, dockerClient *client.Client, tag string, registry string) error {
+	log.Debugf("Pushing image %s", tag)
 	regAuth, err := RegistryAuth(registry)
 	if err != nil {
 		return err
 	}
 
 	opts := types.ImagePushOptions{RegistryAuth: regAuth}
-	res, err := dockerClient.ImagePush(ctx, remoteTag, opts)
+	res, err := dockerClient.ImagePush(ctx, tag, opts)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -80,9 +141,46 @@ func UploadImage(imageID string, registry string) error {
 	return nil
 }
 
+// SaveImage writes the image with all its layers as a tar archive to
+// the given path, which can be loaded via 'docker load' or copied to a
+// registry with tools like skopeo or crane.
+func SaveImage(image string, path string) error {
+	dockerClient, err := GetDockerClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := dockerClient.ImageSave(context.Background(), []string{image})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = io.Copy(f, res)
+	if err != nil {
+		f.Close()
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(f.Close())
+}
+
+// registryOfReference returns the registry of the image reference as
+// it's used as key in the Docker config file
+func registryOfReference(named reference.Named) string {
+	domain := reference.Domain(named)
+	if domain == "docker.io" {
+		return dockerHubRegistry
+	}
+	return domain
+}
+
 // prepareBuildContext takes a existing artifact bundle, extracts it
 // and adds needed files/information.
-func prepareBuildContext(bundlePath string) (string, error) {
+func prepareBuildContext(bundlePath string, packages []string) (string, error) {
 	// extract bundle to a temporary directory
 	buildContextDir, err := os.MkdirTemp("", "bundle-extract")
 	if err != nil {
@@ -103,7 +201,7 @@ func prepareBuildContext(bundlePath string) (string, error) {
 
 	// add additional files needed for the image
 	// eg. build instructions and cifuzz executables
-	err = createDockerfile(filepath.Join(buildContextDir, "Dockerfile"), metadata.Docker)
+	err = createDockerfile(filepath.Join(buildContextDir, "Dockerfile"), metadata.Docker, packages)
 	if err != nil {
 		return "", err
 	}
@@ -118,7 +216,7 @@ func prepareBuildContext(bundlePath string) (string, error) {
 }
 
 // builds an image based on an existing directory
-func buildImageFromDir(buildContextDir string) (string, error) {
+func buildImageFromDir(buildContextDir string, tags []string) (string, error) {
 	imageTar, err := CreateImageTar(buildContextDir)
 	if err != nil {
 		return "", err
@@ -136,7 +234,7 @@ func buildImageFromDir(buildContextDir string) (string, error) {
 		Platform:    "linux/amd64",
 		Remove:      true,
 		ForceRemove: true,
-		Tags:        []string{"cifuzz"},
+		Tags:        append([]string{"cifuzz"}, tags...),
 	}
 	res, err := dockerClient.ImageBuild(ctx, imageTar, opts)
 	if err != nil {
@@ -176,12 +274,13 @@ func CreateImageTar(buildContextDir string) (*os.File, error) {
 	return file, nil
 }
 
-func createDockerfile(path string, baseImage string) error {
+func createDockerfile(path string, baseImage string, packages []string) error {
 	cifuzzImage := cifuzzImageBase + ":" + version.Version
 
 	dockerConfig := dockerfileConfig{
-		CIFuzzImage: cifuzzImage,
-		Base:        baseImage,
+		CIFuzzImage:     cifuzzImage,
+		Base:            baseImage,
+		InstallPackages: installPackagesCommand(packages),
 	}
 	tmpl, err := template.New("Dockerfile").Parse(dockerfileTemplate)
 	if err != nil {
@@ -203,6 +302,26 @@ func createDockerfile(path string, baseImage string) error {
 	return nil
 }
 
+// installPackagesCommand returns a shell command which installs the
+// packages with the package manager which is available in the base
+// image, or an empty string if there are no packages
+func installPackagesCommand(packages []string) string {
+	if len(packages) == 0 {
+		return ""
+	}
+	pkgs := shellescape.QuoteCommand(packages)
+	return strings.Join([]string{
+		"if command -v apt-get >/dev/null; then",
+		"apt-get update && DEBIAN_FRONTEND=noninteractive apt-get install -y --no-install-recommends " + pkgs + " && rm -rf /var/lib/apt/lists/*;",
+		"elif command -v apk >/dev/null; then apk add --no-cache " + pkgs + ";",
+		"elif command -v dnf >/dev/null; then dnf install -y " + pkgs + " && dnf clean all;",
+		"elif command -v microdnf >/dev/null; then microdnf install -y " + pkgs + " && microdnf clean all;",
+		"elif command -v yum >/dev/null; then yum install -y " + pkgs + " && yum clean all;",
+		"else echo 'No supported package manager (apt-get, apk, dnf, microdnf, yum) found in the base image' >&2; exit 1;",
+		"fi",
+	}, " \\\n    ")
+}
+
 func copyCifuzz(buildContextDir string) error {
 	// Add the CIFuzz binaries to the bundle if the version is "dev".
 	// TODO: this should work even if internal doesn't exist
@@ -0,0 +1,60 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/distribution/reference"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestCreateDockerfile_Packages(t *testing.T) {
+	testDir := testutil.MkdirTemp(t, "", "dockerfile-test-")
+	dockerfilePath := filepath.Join(testDir, "Dockerfile")
+	err := createDockerfile(dockerfilePath, "debian:bookworm", []string{"libssl3", "libxml2=2.9.14"})
+	require.NoError(t, err)
+	content, err := os.ReadFile(dockerfilePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "FROM debian:bookworm\n\nUSER root\nRUN if command -v apt-get >/dev/null; then \\\n")
+	assert.Contains(t, string(content), "apt-get install -y --no-install-recommends libssl3 libxml2=2.9.14 &&")
+	assert.Contains(t, string(content), "apk add --no-cache libssl3 libxml2=2.9.14;")
+
+	// Without packages, the base image is used as is
+	dockerfilePath = filepath.Join(testDir, "Dockerfile.without-packages")
+	err = createDockerfile(dockerfilePath, "debian:bookworm", nil)
+	require.NoError(t, err)
+	content, err = os.ReadFile(dockerfilePath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "RUN")
+}
+
+func TestValidatePackages(t *testing.T) {
+	assert.NoError(t, ValidatePackages([]string{"libssl-dev", "libxml2=2.9.14", "g++", "openssl1.1-compat"}))
+	assert.Error(t, ValidatePackages([]string{"libssl3; rm -rf /"}))
+	assert.Error(t, ValidatePackages([]string{"-y"}))
+}
+
+func TestRegistryOfReference(t *testing.T) {
+	for _, tc := range []struct {
+		ref      string
+		expected string
+	}{
+		{"fuzz-tests", dockerHubRegistry},
+		{"my-org/fuzz-tests:v1", dockerHubRegistry},
+		{"docker.io/my-org/fuzz-tests", dockerHubRegistry},
+		{"ghcr.io/my-org/fuzz-tests:latest", "ghcr.io"},
+		{"localhost:5000/fuzz-tests", "localhost:5000"},
+	} {
+		t.Run(tc.ref, func(t *testing.T) {
+			named, err := reference.ParseNormalizedNamed(tc.ref)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, registryOfReference(named))
+			// The registry must be a valid key in the Docker config
+			assert.Equal(t, tc.expected, registryKey(registryOfReference(named)))
+		})
+	}
+}
@@ -6,11 +6,23 @@ const FormatHTML = "html"
 const FormatLCOV = "lcov"
 const FormatJacocoXML = "jacocoxml"
 
+// Cobertura and SonarQube reports are converted from the LCOV report,
+// so they are supported for all build systems
+const FormatCobertura = "cobertura"
+const FormatSonarQube = "sonarqube"
+
+// The tools which collect the coverage of C/C++ projects built with
+// CMake. gcov is used for projects which must be built with GCC.
+const ToolLLVM = "llvm"
+const ToolGcov = "gcov"
+
+var Tools = []string{ToolLLVM, ToolGcov}
+
 var ValidOutputFormats = map[string][]string{
-	config.BuildSystemCMake:  {FormatHTML, FormatLCOV},
-	config.BuildSystemBazel:  {FormatHTML, FormatLCOV},
-	config.BuildSystemOther:  {FormatHTML, FormatLCOV},
-	config.BuildSystemMaven:  {FormatHTML, FormatLCOV, FormatJacocoXML},
-	config.BuildSystemGradle: {FormatHTML, FormatLCOV, FormatJacocoXML},
-	config.BuildSystemNodeJS: {FormatHTML, FormatLCOV},
+	config.BuildSystemCMake:  {FormatHTML, FormatLCOV, FormatCobertura, FormatSonarQube},
+	config.BuildSystemBazel:  {FormatHTML, FormatLCOV, FormatCobertura, FormatSonarQube},
+	config.BuildSystemOther:  {FormatHTML, FormatLCOV, FormatCobertura, FormatSonarQube},
+	config.BuildSystemMaven:  {FormatHTML, FormatLCOV, FormatJacocoXML, FormatCobertura, FormatSonarQube},
+	config.BuildSystemGradle: {FormatHTML, FormatLCOV, FormatJacocoXML, FormatCobertura, FormatSonarQube},
+	config.BuildSystemNodeJS: {FormatHTML, FormatLCOV, FormatCobertura, FormatSonarQube},
 }
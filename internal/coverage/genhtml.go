@@ -0,0 +1,48 @@
+package coverage
+
+import (
+	"os/exec"
+	"runtime"
+
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+)
+
+// GenHTMLCommand returns the command which creates an HTML report from
+// the lcov report via genhtml. In addition to the line coverage, the
+// report shows the branch coverage and a table of the functions of each
+// source file, and the columns of the tables can be sorted.
+func GenHTMLCommand(lcovReport, outputPath string) (*exec.Cmd, error) {
+	genHTML, err := runfiles.Finder.GenHTMLPath()
+	if err != nil {
+		return nil, err
+	}
+
+	// The function names in the lcov reports of llvm-cov are mangled,
+	// genhtml demangles them if c++filt is available
+	_, err = exec.LookPath("c++filt")
+	args := genHTMLArgs(lcovReport, outputPath, err == nil)
+
+	if runtime.GOOS == "windows" {
+		// genHTML is a perl script, which has to be started like
+		// "perl /path/to/genhtml args..." on Windows
+		perl, err := runfiles.Finder.PerlPath()
+		if err != nil {
+			return nil, err
+		}
+		return exec.Command(perl, append([]string{genHTML}, args...)...), nil
+	}
+	return exec.Command(genHTML, args...), nil
+}
+
+func genHTMLArgs(lcovReport, outputPath string, demangle bool) []string {
+	args := []string{
+		"--output", outputPath,
+		"--branch-coverage",
+		"--function-coverage",
+		"--sort",
+	}
+	if demangle {
+		args = append(args, "--demangle-cpp")
+	}
+	return append(args, lcovReport)
+}
@@ -0,0 +1,16 @@
+package coverage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenHTMLArgs(t *testing.T) {
+	args := genHTMLArgs("coverage.lcov", "report", false)
+	assert.Equal(t, []string{"--output", "report", "--branch-coverage", "--function-coverage", "--sort", "coverage.lcov"}, args)
+
+	args = genHTMLArgs("coverage.lcov", "report", true)
+	assert.Contains(t, args, "--demangle-cpp")
+	assert.Equal(t, "coverage.lcov", args[len(args)-1])
+}
@@ -0,0 +1,172 @@
+package depgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FormatDOT  = "dot"
+	FormatJSON = "json"
+)
+
+var Formats = []string{FormatDOT, FormatJSON}
+
+type NodeKind string
+
+const (
+	NodeKindFuzzTest   NodeKind = "fuzz_test"
+	NodeKindExecutable NodeKind = "executable"
+	NodeKindSourceFile NodeKind = "source_file"
+	NodeKindLibrary    NodeKind = "library"
+	NodeKindJar        NodeKind = "jar"
+	NodeKindClassDir   NodeKind = "class_dir"
+)
+
+type Node struct {
+	ID   string   `json:"id"`
+	Kind NodeKind `json:"kind"`
+	// Path of the file in forward-slash form, relative to the project
+	// directory if the file is located below it and absolute otherwise
+	Path string `json:"path,omitempty"`
+}
+
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph is the build and runtime dependency graph of a single fuzz test
+type Graph struct {
+	FuzzTest string  `json:"fuzz_test"`
+	Nodes    []*Node `json:"nodes"`
+	Edges    []*Edge `json:"edges"`
+
+	projectDir string
+	nodes      map[string]*Node
+}
+
+func New(fuzzTest, projectDir string) *Graph {
+	g := &Graph{
+		FuzzTest:   fuzzTest,
+		projectDir: projectDir,
+		nodes:      make(map[string]*Node),
+	}
+	g.addNode(&Node{ID: fuzzTest, Kind: NodeKindFuzzTest})
+	return g
+}
+
+// Root returns the ID of the node which represents the fuzz test itself
+func (g *Graph) Root() string {
+	return g.FuzzTest
+}
+
+// AddFile adds a node for the file at the given path (if it doesn't
+// exist yet) and an edge from the node with the ID "from" to it. It
+// returns the ID of the file node.
+func (g *Graph) AddFile(from string, kind NodeKind, path string) string {
+	relPath := g.relPath(path)
+	id := string(kind) + ":" + relPath
+	if _, exists := g.nodes[id]; !exists {
+		g.addNode(&Node{ID: id, Kind: kind, Path: relPath})
+	}
+	g.Edges = append(g.Edges, &Edge{From: from, To: id})
+	return id
+}
+
+// IsInProject returns true if the given path is located below the
+// project directory
+func (g *Graph) IsInProject(path string) bool {
+	return !filepath.IsAbs(g.relPath(path))
+}
+
+func (g *Graph) addNode(n *Node) {
+	g.nodes[n.ID] = n
+	g.Nodes = append(g.Nodes, n)
+}
+
+func (g *Graph) relPath(path string) string {
+	if g.projectDir != "" && filepath.IsAbs(path) {
+		rel, err := filepath.Rel(g.projectDir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// sort sorts nodes and edges to produce a stable output
+func (g *Graph) sort() {
+	sort.SliceStable(g.Nodes, func(i, j int) bool {
+		// Keep the fuzz test node first
+		if g.Nodes[i].Kind == NodeKindFuzzTest || g.Nodes[j].Kind == NodeKindFuzzTest {
+			return g.Nodes[i].Kind == NodeKindFuzzTest && g.Nodes[j].Kind != NodeKindFuzzTest
+		}
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	sort.SliceStable(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+}
+
+// Write writes the graph in the given format
+func (g *Graph) Write(w io.Writer, format string) error {
+	g.sort()
+	switch format {
+	case FormatJSON:
+		return g.writeJSON(w)
+	case FormatDOT:
+		return g.writeDOT(w)
+	default:
+		return errors.Errorf("unsupported graph format %q", format)
+	}
+}
+
+func (g *Graph) writeJSON(w io.Writer) error {
+	bytes, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = fmt.Fprintln(w, string(bytes))
+	return errors.WithStack(err)
+}
+
+var dotShapes = map[NodeKind]string{
+	NodeKindFuzzTest:   "doubleoctagon",
+	NodeKindExecutable: "box3d",
+	NodeKindSourceFile: "note",
+	NodeKindLibrary:    "component",
+	NodeKindJar:        "folder",
+	NodeKindClassDir:   "folder",
+}
+
+func (g *Graph) writeDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("digraph %s {\n", dotQuote(g.FuzzTest)))
+	for _, n := range g.Nodes {
+		label := n.Path
+		if label == "" {
+			label = n.ID
+		}
+		sb.WriteString(fmt.Sprintf("  %s [label=%s, shape=%s];\n", dotQuote(n.ID), dotQuote(label), dotShapes[n.Kind]))
+	}
+	for _, e := range g.Edges {
+		sb.WriteString(fmt.Sprintf("  %s -> %s;\n", dotQuote(e.From), dotQuote(e.To)))
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return errors.WithStack(err)
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
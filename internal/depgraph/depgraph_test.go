@@ -0,0 +1,61 @@
+package depgraph
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGraph(t *testing.T) *Graph {
+	projectDir, err := filepath.Abs("project")
+	require.NoError(t, err)
+	systemLib, err := filepath.Abs(filepath.Join("usr", "lib", "libz.so"))
+	require.NoError(t, err)
+
+	g := New("my_fuzz_test", projectDir)
+	exe := g.AddFile(g.Root(), NodeKindExecutable, filepath.Join(projectDir, "build", "my_fuzz_test"))
+	g.AddFile(exe, NodeKindSourceFile, filepath.Join(projectDir, "src", "parser.cpp"))
+	g.AddFile(exe, NodeKindSourceFile, filepath.Join(projectDir, "my_fuzz_test.cpp"))
+	g.AddFile(exe, NodeKindLibrary, systemLib)
+	// Adding the same file twice must not result in duplicate nodes
+	g.AddFile(exe, NodeKindSourceFile, filepath.Join(projectDir, "src", "parser.cpp"))
+	return g
+}
+
+func TestGraph_JSON(t *testing.T) {
+	g := newTestGraph(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, g.Write(&buf, FormatJSON))
+
+	var parsed Graph
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &parsed))
+	assert.Equal(t, "my_fuzz_test", parsed.FuzzTest)
+	require.Len(t, parsed.Nodes, 5)
+	assert.Equal(t, NodeKindFuzzTest, parsed.Nodes[0].Kind)
+	assert.Equal(t, "executable:build/my_fuzz_test", parsed.Nodes[1].ID)
+	assert.Equal(t, "src/parser.cpp", parsed.Nodes[4].Path)
+	assert.Len(t, parsed.Edges, 5)
+}
+
+func TestGraph_DOT(t *testing.T) {
+	g := newTestGraph(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, g.Write(&buf, FormatDOT))
+
+	out := buf.String()
+	assert.Contains(t, out, `digraph "my_fuzz_test" {`)
+	assert.Contains(t, out, `"my_fuzz_test" -> "executable:build/my_fuzz_test";`)
+	assert.Contains(t, out, `"source_file:src/parser.cpp" [label="src/parser.cpp", shape=note];`)
+}
+
+func TestGraph_UnsupportedFormat(t *testing.T) {
+	g := newTestGraph(t)
+	err := g.Write(&bytes.Buffer{}, "svg")
+	assert.Error(t, err)
+}
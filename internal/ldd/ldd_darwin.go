@@ -0,0 +1,12 @@
+//go:build darwin
+
+package ldd
+
+import (
+	"github.com/pkg/errors"
+)
+
+func NonSystemSharedLibraries(executable string) ([]string, error) {
+	sharedObjects, err := machOSharedLibraries(executable)
+	return sharedObjects, errors.WithMessage(err, "Failed to gather non system shared libraries")
+}
@@ -1,4 +1,4 @@
-//go:build !freebsd && !linux && !windows
+//go:build !darwin && !freebsd && !linux && !windows
 
 package ldd
 
@@ -12,14 +12,11 @@ import (
 	"code-intelligence.com/cifuzz/util/fileutil"
 )
 
-// For operating systems other than Linux and FreeBSD, we resort to scanning the working directory
-// for shared object. The ldd package from the u-root project only implements this functionality
-// correctly for these two systems.
+// For operating systems other than Linux, FreeBSD and macOS, we resort to scanning the working
+// directory for shared object. The ldd package from the u-root project only implements this
+// functionality correctly for Linux and FreeBSD, macOS is handled in macho.go.
 
-// TODO implement dependency resolution for other systems when needed. For darwin we can use
-// the standard debug/macho package and recursively list the dependencies from the main executable
-// and its transitive dependencies. We also need to resolve the variables @executable_path,
-// @loader_path and @rpath from install IDs of the dynamic libraries.
+// TODO implement dependency resolution for other systems when needed.
 func NonSystemSharedLibraries(executable string) ([]string, error) {
 	var sharedObjects []string
 	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
@@ -0,0 +1,157 @@
+package ldd
+
+import (
+	"debug/macho"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// macOS doesn't have ldd and the libraries in /System are part of the
+// dyld shared cache, so they don't exist as files. Instead, we read the
+// LC_LOAD_DYLIB and LC_RPATH load commands of the executable and its
+// dependencies via the debug/macho package and resolve the install
+// names the same way dyld does.
+
+// machOSharedLibraries returns the non-system shared libraries which the
+// Mach-O executable depends on, including its transitive dependencies
+func machOSharedLibraries(executable string) ([]string, error) {
+	executable, err := filepath.Abs(executable)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	type image struct {
+		path string
+		// The resolved run path search paths of the images which
+		// loaded this image, which are also searched for the @rpath
+		// dependencies of this image
+		rpaths []string
+	}
+
+	var sharedObjects []string
+	seen := map[string]bool{executable: true}
+	queue := []image{{path: executable}}
+	for len(queue) > 0 {
+		img := queue[0]
+		queue = queue[1:]
+
+		installNames, rpaths, err := readLoadCommands(img.path)
+		if err != nil {
+			return nil, err
+		}
+		for i, rpath := range rpaths {
+			rpaths[i] = substituteLoaderPaths(rpath, img.path, executable)
+		}
+		rpaths = append(rpaths, img.rpaths...)
+
+		for _, installName := range installNames {
+			if isSystemInstallName(installName) {
+				continue
+			}
+			path, err := resolveInstallName(installName, img.path, executable, rpaths)
+			if err != nil {
+				return nil, err
+			}
+			if path == "" {
+				return nil, errors.Errorf("%s: library %s not found", img.path, installName)
+			}
+			if seen[path] || fileutil.IsSystemLibrary(path) {
+				continue
+			}
+			seen[path] = true
+			sharedObjects = append(sharedObjects, path)
+			queue = append(queue, image{path: path, rpaths: rpaths})
+		}
+	}
+	return sharedObjects, nil
+}
+
+// readLoadCommands returns the install names of the libraries which the
+// Mach-O file links against and its run path search paths
+func readLoadCommands(path string) ([]string, []string, error) {
+	f, err := macho.Open(path)
+	if err != nil {
+		// Universal binaries contain one Mach-O file per architecture
+		fat, fatErr := macho.OpenFat(path)
+		if fatErr != nil {
+			return nil, nil, errors.Wrapf(err, "failed to read Mach-O file %s", path)
+		}
+		defer fat.Close()
+		f = fat.Arches[0].File
+		for _, arch := range fat.Arches {
+			if arch.Cpu == machOCPU() {
+				f = arch.File
+				break
+			}
+		}
+	} else {
+		defer f.Close()
+	}
+
+	installNames, err := f.ImportedLibraries()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to read Mach-O file %s", path)
+	}
+	var rpaths []string
+	for _, load := range f.Loads {
+		if rpath, ok := load.(*macho.Rpath); ok {
+			rpaths = append(rpaths, rpath.Path)
+		}
+	}
+	return installNames, rpaths, nil
+}
+
+func machOCPU() macho.Cpu {
+	if runtime.GOARCH == "arm64" {
+		return macho.CpuArm64
+	}
+	return macho.CpuAmd64
+}
+
+// resolveInstallName returns the path of the library with the given
+// install name which is loaded by the image at loaderPath, or an empty
+// string if the library doesn't exist
+func resolveInstallName(installName string, loaderPath string, executable string, rpaths []string) (string, error) {
+	var candidates []string
+	if rest, found := strings.CutPrefix(installName, "@rpath/"); found {
+		for _, rpath := range rpaths {
+			candidates = append(candidates, filepath.Join(rpath, rest))
+		}
+	} else {
+		candidates = []string{substituteLoaderPaths(installName, loaderPath, executable)}
+	}
+
+	for _, candidate := range candidates {
+		exists, err := fileutil.Exists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return filepath.Clean(candidate), nil
+		}
+	}
+	return "", nil
+}
+
+// substituteLoaderPaths replaces the @executable_path and @loader_path
+// prefixes of install names and run path search paths
+func substituteLoaderPaths(path string, loaderPath string, executable string) string {
+	if rest, found := strings.CutPrefix(path, "@executable_path"); found {
+		return filepath.Join(filepath.Dir(executable), rest)
+	}
+	if rest, found := strings.CutPrefix(path, "@loader_path"); found {
+		return filepath.Join(filepath.Dir(loaderPath), rest)
+	}
+	return path
+}
+
+// isSystemInstallName returns true for the install names of libraries
+// which are provided by the operating system
+func isSystemInstallName(installName string) bool {
+	return strings.HasPrefix(installName, "/System/") || fileutil.IsSystemLibrary(installName)
+}
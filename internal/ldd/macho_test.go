@@ -0,0 +1,66 @@
+package ldd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestResolveInstallName(t *testing.T) {
+	dir := testutil.MkdirTemp(t, "", "ldd-test-*")
+	for _, file := range []string{
+		filepath.Join("bin", "fuzz_test"),
+		filepath.Join("lib", "libfoo.dylib"),
+		filepath.Join("lib", "plugins", "libbar.dylib"),
+	} {
+		err := os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o755)
+		require.NoError(t, err)
+		err = os.WriteFile(filepath.Join(dir, file), nil, 0o644)
+		require.NoError(t, err)
+	}
+	executable := filepath.Join(dir, "bin", "fuzz_test")
+	libFoo := filepath.Join(dir, "lib", "libfoo.dylib")
+	libBar := filepath.Join(dir, "lib", "plugins", "libbar.dylib")
+	rpaths := []string{
+		filepath.Join(dir, "does-not-exist"),
+		substituteLoaderPaths("@executable_path/../lib", executable, executable),
+	}
+
+	testCases := []struct {
+		installName string
+		loaderPath  string
+		expected    string
+	}{
+		{libFoo, executable, libFoo},
+		{"@executable_path/../lib/libfoo.dylib", executable, libFoo},
+		{"@executable_path/../lib/libfoo.dylib", libBar, libFoo},
+		{"@loader_path/plugins/libbar.dylib", libFoo, libBar},
+		{"@rpath/libfoo.dylib", executable, libFoo},
+		{"@rpath/plugins/libbar.dylib", libFoo, libBar},
+		{"@rpath/libmissing.dylib", executable, ""},
+		{"@loader_path/libfoo.dylib", executable, ""},
+	}
+	for _, tc := range testCases {
+		path, err := resolveInstallName(tc.installName, tc.loaderPath, executable, rpaths)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, path, tc.installName)
+	}
+}
+
+func TestIsSystemInstallName(t *testing.T) {
+	assert.True(t, isSystemInstallName("/usr/lib/libSystem.B.dylib"))
+	assert.True(t, isSystemInstallName("/System/Library/Frameworks/CoreFoundation.framework/Versions/A/CoreFoundation"))
+	assert.False(t, isSystemInstallName("@rpath/libfoo.dylib"))
+	assert.False(t, isSystemInstallName("/opt/homebrew/lib/libfoo.dylib"))
+}
+
+func TestMachOSharedLibraries_NotMachO(t *testing.T) {
+	_, err := machOSharedLibraries("testdata/my_fuzz_test")
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "failed to read Mach-O file")
+}
@@ -0,0 +1,193 @@
+// Package ossfuzz creates the files which are needed to build and run
+// the fuzz tests of a project on OSS-Fuzz or ClusterFuzzLite, which
+// use the same build infrastructure.
+package ossfuzz
+
+import (
+	"bytes"
+	"embed"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/config"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Characters which are not allowed in the name of an OSS-Fuzz project,
+// which is also used as the name of the directory below $SRC which the
+// project is copied to
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// The sanitizers which OSS-Fuzz supports for C/C++ projects, in
+// addition to the coverage build
+var supportedSanitizers = []string{"address", "undefined", "memory"}
+
+// The sanitizers which are used if none of the sanitizers of the
+// project are supported
+var defaultSanitizers = []string{"address", "undefined"}
+
+type Options struct {
+	BuildSystem string
+	// The name of the OSS-Fuzz project
+	Name string
+	// Create the files for ClusterFuzzLite, which builds the project
+	// from the build context, instead of for OSS-Fuzz, which clones
+	// MainRepo
+	ClusterFuzzLite bool
+
+	// The fields of the project.yaml of OSS-Fuzz, see
+	// https://google.github.io/oss-fuzz/getting-started/new-project-guide/#projectyaml
+	Homepage       string
+	PrimaryContact string
+	MainRepo       string
+	Sanitizers     []string
+}
+
+// File is a file which is created in the directory of the OSS-Fuzz
+// project or in the project itself for ClusterFuzzLite
+type File struct {
+	// The path of the file, relative to the directory of the OSS-Fuzz
+	// project or the project itself
+	Path       string
+	Content    []byte
+	Executable bool
+}
+
+// ValidateBuildSystem returns an error if fuzz tests of projects with
+// the build system can't be built on OSS-Fuzz. The build script uses
+// the CMake integration or the Bazel rules of cifuzz, so that the fuzz
+// tests are built the same way as by `cifuzz run`.
+func ValidateBuildSystem(buildSystem string) error {
+	switch buildSystem {
+	case config.BuildSystemCMake, config.BuildSystemBazel:
+		return nil
+	}
+	return errors.Errorf("OSS-Fuzz and ClusterFuzzLite are only supported for CMake and Bazel projects, not for %s", buildSystem)
+}
+
+// Files returns the Dockerfile, build script and project.yaml of the
+// project and, for ClusterFuzzLite, a GitHub workflow which fuzzes pull
+// requests
+func Files(opts *Options) ([]*File, error) {
+	err := ValidateBuildSystem(opts.BuildSystem)
+	if err != nil {
+		return nil, err
+	}
+
+	data := *opts
+	data.Sanitizers = sanitizers(opts.Sanitizers)
+
+	dir := ""
+	if opts.ClusterFuzzLite {
+		dir = ".clusterfuzzlite/"
+	}
+	var files []*File
+	for _, f := range []struct {
+		path       string
+		template   string
+		executable bool
+	}{
+		{path: dir + "Dockerfile", template: "Dockerfile.tmpl"},
+		{path: dir + "build.sh", template: "build-" + opts.BuildSystem + ".sh", executable: true},
+		{path: dir + "project.yaml", template: "project.yaml.tmpl"},
+	} {
+		content, err := render(f.template, data)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &File{Path: f.path, Content: content, Executable: f.executable})
+	}
+
+	if opts.ClusterFuzzLite {
+		content, err := render("cflite_pr.yml", data)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &File{Path: ".github/workflows/cflite_pr.yml", Content: content})
+	}
+	return files, nil
+}
+
+func render(name string, data any) ([]byte, error) {
+	content, err := templates.ReadFile("templates/" + name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Only the files with the .tmpl extension are templates, the GitHub
+	// workflow contains expressions which use the same delimiters as Go
+	// templates
+	if !strings.HasSuffix(name, ".tmpl") {
+		return content, nil
+	}
+
+	t, err := template.New(name).Parse(string(content))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append(bytes.TrimRight(buf.Bytes(), "\n"), '\n'), nil
+}
+
+// sanitizers returns the sanitizers of the project which OSS-Fuzz
+// supports
+func sanitizers(projectSanitizers []string) []string {
+	var result []string
+	for _, s := range projectSanitizers {
+		for _, supported := range supportedSanitizers {
+			if s == supported {
+				result = append(result, s)
+			}
+		}
+	}
+	if len(result) == 0 {
+		return defaultSanitizers
+	}
+	return result
+}
+
+// ProjectName returns a valid name of an OSS-Fuzz project for the
+// project in the given directory
+func ProjectName(dir string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(filepath.Base(dir)), "-")
+	name = strings.Trim(name, "-")
+	if name == "" {
+		return "project"
+	}
+	return name
+}
+
+// Matches the scp-like syntax of Git remotes, e.g.
+// git@github.com:owner/repo.git
+var scpLikeURLRegex = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// HTTPSRepoURL returns the HTTPS URL of a Git repository which is
+// specified by the URL of a Git remote, which OSS-Fuzz needs to clone
+// the repository without credentials
+func HTTPSRepoURL(remoteURL string) string {
+	if !strings.Contains(remoteURL, "://") {
+		if m := scpLikeURLRegex.FindStringSubmatch(remoteURL); m != nil {
+			return "https://" + m[1] + "/" + m[2]
+		}
+		return remoteURL
+	}
+	u, err := url.Parse(remoteURL)
+	if err != nil || (u.Scheme != "ssh" && u.Scheme != "git") {
+		return remoteURL
+	}
+	u.Scheme = "https"
+	u.User = nil
+	// The port of the SSH server is not the port of the HTTPS server
+	u.Host = u.Hostname()
+	return u.String()
+}
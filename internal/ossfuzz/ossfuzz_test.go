@@ -0,0 +1,74 @@
+package ossfuzz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/config"
+)
+
+func TestFiles_OSSFuzz(t *testing.T) {
+	files, err := Files(&Options{
+		BuildSystem:    config.BuildSystemCMake,
+		Name:           "my-project",
+		Homepage:       "https://example.com",
+		PrimaryContact: "dev@example.com",
+		MainRepo:       "https://github.com/example/my-project.git",
+		Sanitizers:     []string{"address", "undefined", "coverage"},
+	})
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+
+	assert.Equal(t, "Dockerfile", files[0].Path)
+	assert.Contains(t, string(files[0].Content), "RUN git clone --depth 1 https://github.com/example/my-project.git my-project\n")
+	assert.Contains(t, string(files[0].Content), "COPY build.sh $SRC/\n")
+	assert.Equal(t, "build.sh", files[1].Path)
+	assert.True(t, files[1].Executable)
+	assert.Contains(t, string(files[1].Content), "-DCIFUZZ_ENGINE=libfuzzer")
+	assert.Equal(t, "project.yaml", files[2].Path)
+	assert.Equal(t, `homepage: "https://example.com"
+language: c++
+primary_contact: "dev@example.com"
+main_repo: "https://github.com/example/my-project.git"
+fuzzing_engines:
+  - libfuzzer
+sanitizers:
+  - address
+  - undefined
+`, string(files[2].Content))
+}
+
+func TestFiles_ClusterFuzzLite(t *testing.T) {
+	files, err := Files(&Options{BuildSystem: config.BuildSystemBazel, Name: "my-project", ClusterFuzzLite: true})
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+
+	assert.Equal(t, ".clusterfuzzlite/Dockerfile", files[0].Path)
+	assert.Contains(t, string(files[0].Content), "COPY . $SRC/my-project\n")
+	assert.Contains(t, string(files[1].Content), "bazel_build_fuzz_tests")
+	assert.Equal(t, "language: c++\n", string(files[2].Content))
+	assert.Equal(t, ".github/workflows/cflite_pr.yml", files[3].Path)
+
+	_, err = Files(&Options{BuildSystem: config.BuildSystemMaven})
+	assert.Error(t, err)
+}
+
+func TestProjectName(t *testing.T) {
+	assert.Equal(t, "my-project", ProjectName("/src/My Project"))
+	assert.Equal(t, "lib_foo", ProjectName("lib_foo"))
+	assert.Equal(t, "project", ProjectName("/"))
+}
+
+func TestHTTPSRepoURL(t *testing.T) {
+	for remote, expected := range map[string]string{
+		"git@github.com:owner/repo.git":          "https://github.com/owner/repo.git",
+		"ssh://git@github.com:22/owner/repo.git": "https://github.com/owner/repo.git",
+		"https://gitlab.com/group/repo.git":      "https://gitlab.com/group/repo.git",
+		"https://user@gitlab.com/group/repo.git": "https://user@gitlab.com/group/repo.git",
+		"/local/path/to/repo":                    "/local/path/to/repo",
+	} {
+		assert.Equal(t, expected, HTTPSRepoURL(remote), remote)
+	}
+}
@@ -0,0 +1,7 @@
+#!/bin/bash -eu
+# Builds all fuzz tests of the project, which are defined via the
+# cc_fuzz_test rule of rules_fuzzing (which the cifuzz_test rule of
+# cifuzz-bazel uses), and copies them to $OUT, together with their seed
+# corpora and dictionaries. The script is provided by the base image.
+
+bazel_build_fuzz_tests
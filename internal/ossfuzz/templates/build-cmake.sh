@@ -0,0 +1,34 @@
+#!/bin/bash -eu
+# Builds the fuzz tests of the project with the cifuzz CMake integration.
+# The sanitizers and the coverage instrumentation are selected by
+# OSS-Fuzz or ClusterFuzzLite via CC, CXX, CFLAGS and CXXFLAGS.
+
+build_dir="$WORK/cifuzz-build"
+cmake -S . -B "$build_dir" \
+  -DCMAKE_BUILD_TYPE=RelWithDebInfo \
+  -DBUILD_SHARED_LIBS=OFF \
+  -DCIFUZZ_TESTING=ON \
+  -DCIFUZZ_ENGINE=libfuzzer \
+  -DCIFUZZ_SANITIZERS=
+
+# The CMake integration creates an info directory for each fuzz test in
+# the configure step
+info_dir="$build_dir/.cifuzz/fuzz_tests"
+fuzz_tests=$(ls "$info_dir")
+cmake --build "$build_dir" --parallel "$(nproc)" --target $fuzz_tests
+
+for fuzz_test in $fuzz_tests; do
+  cp "$(cat "$info_dir/$fuzz_test/executable")" "$OUT/$fuzz_test"
+  if [ -f "$info_dir/$fuzz_test/seed_corpus" ]; then
+    seed_corpus=$(cat "$info_dir/$fuzz_test/seed_corpus")
+    if [ -d "$seed_corpus" ] && [ -n "$(ls -A "$seed_corpus")" ]; then
+      zip -q -j -r "$OUT/${fuzz_test}_seed_corpus.zip" "$seed_corpus"
+    fi
+  fi
+  if [ -f "$info_dir/$fuzz_test/dict" ]; then
+    dict=$(cat "$info_dir/$fuzz_test/dict")
+    if [ -f "$dict" ]; then
+      cp "$dict" "$OUT/$fuzz_test.dict"
+    fi
+  fi
+done
@@ -0,0 +1,49 @@
+package tokenstorage
+
+import (
+	"strings"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// The service name under which the access tokens are stored in the
+// credential manager of the operating system
+const keyringService = "cifuzz"
+
+// credentialStore is the credential manager of the operating system,
+// i.e. the Keychain on macOS, the Secret Service on Linux and the
+// Credential Manager on Windows
+type credentialStore interface {
+	// Name returns the name of the credential manager, which is shown
+	// to the user
+	Name() string
+	// Get returns the access token for the target or an empty string
+	// if the credential manager doesn't contain one
+	Get(target string) (string, error)
+	Set(target string, token string) error
+}
+
+// osKeyring is nil if the credential manager of the operating system is
+// not available, in which case the access tokens are stored in the
+// access tokens file
+var osKeyring = newOSKeyring()
+
+// getFromKeyring returns the access token for the target from the
+// credential manager, also trying the target with and without trailing
+// slash, like for the access tokens file
+func getFromKeyring(target string) string {
+	if osKeyring == nil {
+		return ""
+	}
+	for _, t := range []string{target, strings.TrimSuffix(target, "/"), target + "/"} {
+		token, err := osKeyring.Get(t)
+		if err != nil {
+			log.Debugf("Failed to read access token from %s: %v", osKeyring.Name(), err)
+			return ""
+		}
+		if token != "" {
+			return token
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package tokenstorage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The exit code of the security command if the item doesn't exist
+const securityItemNotFoundExitCode = 44
+
+// keychain stores the access tokens in the macOS Keychain via the
+// security command
+type keychain struct {
+	securityPath string
+}
+
+func newOSKeyring() credentialStore {
+	path, err := exec.LookPath("security")
+	if err != nil {
+		return nil
+	}
+	return &keychain{securityPath: path}
+}
+
+func (k *keychain) Name() string {
+	return "the macOS Keychain"
+}
+
+func (k *keychain) Get(target string) (string, error) {
+	cmd := exec.Command(k.securityPath, "find-generic-password", "-s", keyringService, "-a", target, "-w")
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == securityItemNotFoundExitCode {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (k *keychain) Set(target string, token string) error {
+	// The command is passed via stdin, so that the token doesn't
+	// show up in the list of processes. The token is hex encoded to
+	// avoid having to quote it.
+	cmd := exec.Command(k.securityPath, "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %q -X %s\n",
+		keyringService, target, hex.EncodeToString([]byte(token))))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "security add-generic-password failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+//go:build !darwin && !windows
+
+package tokenstorage
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// secretService stores the access tokens via the Secret Service API
+// (e.g. GNOME Keyring or KWallet), using the secret-tool command of
+// libsecret
+type secretService struct {
+	secretToolPath string
+}
+
+func newOSKeyring() credentialStore {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil
+	}
+	return &secretService{secretToolPath: path}
+}
+
+func (s *secretService) Name() string {
+	return "the Secret Service"
+}
+
+func (s *secretService) Get(target string) (string, error) {
+	cmd := exec.Command(s.secretToolPath, "lookup", "service", keyringService, "server", target)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// secret-tool exits with 1 and doesn't print anything if the
+		// secret doesn't exist
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && len(out) == 0 && stderr.Len() == 0 {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "secret-tool lookup failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (s *secretService) Set(target string, token string) error {
+	// The token is passed via stdin, so that it doesn't show up in the
+	// list of processes
+	cmd := exec.Command(s.secretToolPath, "store", "--label", "cifuzz API access token for "+target,
+		"service", keyringService, "server", target)
+	cmd.Stdin = strings.NewReader(token)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "secret-tool store failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
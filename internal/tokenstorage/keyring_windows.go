@@ -0,0 +1,105 @@
+package tokenstorage
+
+import (
+	"syscall"
+	"unsafe"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+// credential is the CREDENTIALW struct of the Windows API
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// credentialManager stores the access tokens in the Windows Credential
+// Manager
+type credentialManager struct{}
+
+func newOSKeyring() credentialStore {
+	if advapi32.Load() != nil {
+		return nil
+	}
+	return &credentialManager{}
+}
+
+func (c *credentialManager) Name() string {
+	return "the Windows Credential Manager"
+}
+
+func (c *credentialManager) Get(target string) (string, error) {
+	targetName, err := syscall.UTF16PtrFromString(credentialTargetName(target))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	var cred *credential
+	ret, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(targetName)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ret == 0 {
+		if errors.Is(err, errorNotFound) {
+			return "", nil
+		}
+		return "", errors.Wrap(err, "CredReadW failed")
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred))) //nolint:errcheck
+	if cred.CredentialBlobSize == 0 {
+		return "", nil
+	}
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+func (c *credentialManager) Set(target string, token string) error {
+	targetName, err := syscall.UTF16PtrFromString(credentialTargetName(target))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	userName, err := syscall.UTF16PtrFromString(target)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	blob := []byte(token)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         targetName,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+		UserName:           userName,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+	ret, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if ret == 0 {
+		return errors.Wrap(err, "CredWriteW failed")
+	}
+	return nil
+}
+
+// credentialTargetName returns the name under which the access token for
+// the target is stored in the Credential Manager
+func credentialTargetName(target string) string {
+	return keyringService + ":" + target
+}
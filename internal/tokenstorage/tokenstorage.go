@@ -54,19 +54,58 @@ func init() {
 	}
 }
 
+// Set stores the access token for the given target in the credential
+// manager of the operating system. If that is not available, the token
+// is stored in the access tokens file instead.
 func Set(target, token string) error {
+	if osKeyring != nil {
+		err := osKeyring.Set(target, token)
+		if err == nil {
+			// Remove tokens for the target which were stored in the
+			// access tokens file before
+			return removeFromFile(target)
+		}
+		log.Debugf("Failed to store access token in %s, storing it in %s instead: %v",
+			osKeyring.Name(), accessTokensFilePath, err)
+	}
+	return setInFile(target, token)
+}
+
+func setInFile(target, token string) error {
 	if filePathErr != nil {
 		return errors.WithMessage(filePathErr, "Can't set access token")
 	}
 
+	accessTokens[target] = token
+	return writeFile()
+}
+
+// removeFromFile removes the access tokens for the given target, with
+// and without trailing slash, from the access tokens file
+func removeFromFile(target string) error {
+	if readErr != nil {
+		return nil
+	}
+	removed := false
+	for _, t := range []string{target, strings.TrimSuffix(target, "/"), target + "/"} {
+		if _, ok := accessTokens[t]; ok {
+			delete(accessTokens, t)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+	return writeFile()
+}
+
+func writeFile() error {
 	// Ensure that the parent directory exists
 	err := os.MkdirAll(filepath.Dir(accessTokensFilePath), 0o755)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	accessTokens[target] = token
-
 	// Convert the access tokens to JSON
 	bytes, err := json.MarshalIndent(accessTokens, "", "  ")
 	if err != nil {
@@ -84,27 +123,78 @@ func Set(target, token string) error {
 // Get returns the access token for the given target
 // If the given target doesn't exist, try to add or remove a trailing slash
 // and return the access token for that target
+// Tokens which are found in the access tokens file are moved to the
+// credential manager of the operating system, if it's available.
 func Get(target string) (string, error) {
+	// A token in the access tokens file is always newer than a token in
+	// the credential manager, because storing a token in the credential
+	// manager removes it from the file. So if storing a token in the
+	// credential manager failed, an older token which is still stored
+	// there is not used.
+	if t, token, ok := getFromFile(target); ok {
+		migrateToKeyring(t, token)
+		return token, nil
+	}
+
+	if token := getFromKeyring(target); token != "" {
+		return token, nil
+	}
+
 	if readErr != nil {
 		return "", errors.WithMessage(readErr, "Can't get access token")
 	}
+	return "", nil
+}
 
-	if token, ok := accessTokens[target]; ok {
-		return token, nil
+// getFromFile returns the target under which the access token for the
+// given target is stored in the access tokens file and the token, also
+// trying the target with and without trailing slash
+func getFromFile(target string) (string, string, bool) {
+	if readErr != nil {
+		return "", "", false
 	}
-	if token, ok := accessTokens[strings.TrimSuffix(target, "/")]; ok {
-		return token, nil
+	for _, t := range []string{target, strings.TrimSuffix(target, "/"), target + "/"} {
+		if token, ok := accessTokens[t]; ok {
+			return t, token, true
+		}
 	}
-	if token, ok := accessTokens[target+"/"]; ok {
-		return token, nil
+	return "", "", false
+}
+
+// Location returns a description of where the access token for the
+// given target is stored, which is shown to the user
+func Location(target string) (string, error) {
+	if _, _, ok := getFromFile(target); !ok && getFromKeyring(target) != "" {
+		return osKeyring.Name(), nil
 	}
-	return "", nil
+	return accessTokensFilePath, filePathErr
 }
 
 func GetTokenFilePath() (string, error) {
 	return accessTokensFilePath, filePathErr
 }
 
+// migrateToKeyring moves an access token from the access tokens file to
+// the credential manager of the operating system. Errors are only
+// logged, because the token can still be read from the file.
+func migrateToKeyring(target, token string) {
+	if osKeyring == nil {
+		return
+	}
+	err := osKeyring.Set(target, token)
+	if err != nil {
+		log.Debugf("Failed to move access token to %s: %v", osKeyring.Name(), err)
+		return
+	}
+	delete(accessTokens, target)
+	err = writeFile()
+	if err != nil {
+		log.Debugf("Failed to remove access token from %s: %v", accessTokensFilePath, err)
+		return
+	}
+	log.Infof("Moved the API access token for %s from %s to %s", target, accessTokensFilePath, osKeyring.Name())
+}
+
 // migrateOldTokens migrates the old access tokens file to the new location
 func migrateOldTokens() {
 	oldTokensFilePath := os.ExpandEnv("$HOME/.config/cifuzz/access_tokens.json")
@@ -1,7 +1,9 @@
 package tokenstorage
 
 import (
+	"encoding/json"
 	"errors"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -16,6 +18,7 @@ func TestGetAndSet(t *testing.T) {
 	accessTokens = map[string]string{}
 	readErr = nil
 	filePathErr = nil
+	osKeyring = nil
 
 	token, err := Get("http://localhost:8000")
 	require.NoError(t, err)
@@ -53,6 +56,7 @@ func TestGet(t *testing.T) {
 		"app.staging.code-intelligence.com/": "789",
 	}
 	readErr = nil
+	osKeyring = nil
 
 	// Test exact match
 	token, err := Get("app.example.com")
@@ -74,3 +78,103 @@ func TestGet(t *testing.T) {
 	require.NoError(t, err)
 	require.Empty(t, token)
 }
+
+type fakeKeyring struct {
+	tokens map[string]string
+	err    error
+	// setErr is only returned by Set
+	setErr error
+}
+
+func (k *fakeKeyring) Name() string {
+	return "the fake keyring"
+}
+
+func (k *fakeKeyring) Get(target string) (string, error) {
+	return k.tokens[target], k.err
+}
+
+func (k *fakeKeyring) Set(target string, token string) error {
+	if k.err != nil {
+		return k.err
+	}
+	if k.setErr != nil {
+		return k.setErr
+	}
+	k.tokens[target] = token
+	return nil
+}
+
+func TestKeyring(t *testing.T) {
+	tempDir := testutil.MkdirTemp(t, "", "access-tokens-test-")
+	accessTokensFilePath = filepath.Join(tempDir, "access_tokens.json")
+	accessTokens = map[string]string{
+		"http://localhost:8000/": "old-token",
+		"app.example.com":        "123",
+	}
+	readErr = nil
+	filePathErr = nil
+	keyring := &fakeKeyring{tokens: map[string]string{}}
+	osKeyring = keyring
+	t.Cleanup(func() { osKeyring = nil })
+
+	readFile := func() map[string]string {
+		bytes, err := os.ReadFile(accessTokensFilePath)
+		require.NoError(t, err)
+		var tokens map[string]string
+		require.NoError(t, json.Unmarshal(bytes, &tokens))
+		return tokens
+	}
+
+	// Tokens from the access tokens file are moved to the keyring
+	token, err := Get("http://localhost:8000")
+	require.NoError(t, err)
+	require.Equal(t, "old-token", token)
+	require.Equal(t, map[string]string{"http://localhost:8000/": "old-token"}, keyring.tokens)
+	require.Equal(t, map[string]string{"app.example.com": "123"}, readFile())
+
+	location, err := Location("http://localhost:8000")
+	require.NoError(t, err)
+	require.Equal(t, "the fake keyring", location)
+
+	// Setting a token removes the tokens for the target from the file
+	err = Set("app.example.com", "456")
+	require.NoError(t, err)
+	require.Equal(t, "456", keyring.tokens["app.example.com"])
+	require.Empty(t, readFile())
+	token, err = Get("app.example.com/")
+	require.NoError(t, err)
+	require.Equal(t, "456", token)
+
+	// If the keyring can't be used, the file is used instead
+	keyring.err = errors.New("keyring error")
+	err = Set("app.example.com", "789")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"app.example.com": "789"}, readFile())
+	token, err = Get("app.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "789", token)
+	location, err = Location("app.example.com")
+	require.NoError(t, err)
+	require.Equal(t, accessTokensFilePath, location)
+
+	// If the keyring can be read again, the newer token from the file
+	// is used instead of the old one in the keyring and moved there
+	keyring.err = nil
+	token, err = Get("app.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "789", token)
+	require.Equal(t, "789", keyring.tokens["app.example.com"])
+	require.Empty(t, readFile())
+
+	// Same if only storing tokens in the keyring fails
+	keyring.setErr = errors.New("keyring error")
+	err = Set("app.example.com", "abc")
+	require.NoError(t, err)
+	token, err = Get("app.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "abc", token)
+	location, err = Location("app.example.com")
+	require.NoError(t, err)
+	require.Equal(t, accessTokensFilePath, location)
+}
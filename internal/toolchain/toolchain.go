@@ -0,0 +1,250 @@
+package toolchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/cmd/remoterun/progress"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// LLVMVersion is the version of the pinned clang+llvm toolchain. All
+// users of a project which sets use-cxx-toolchain build and run their
+// fuzz tests with this version, independent of the system compiler.
+const LLVMVersion = "17.0.6"
+
+// ToolsDirEnv can be set to change the directory to which cifuzz
+// installs tools
+const ToolsDirEnv = "CIFUZZ_TOOLS_DIR"
+
+var releaseURL = "https://github.com/llvm/llvm-project/releases/download/llvmorg-" + LLVMVersion
+
+// llvmArchive is a release archive of the pinned toolchain
+type llvmArchive struct {
+	Name string
+	// The SHA256 checksum of the archive, which is verified before the
+	// archive is extracted. It has to be updated together with
+	// LLVMVersion.
+	SHA256 string
+}
+
+// The release archives of the pinned toolchain per OS/architecture.
+// There are no release archives for macOS on amd64 and Windows.
+var archives = map[string]llvmArchive{
+	"linux/amd64": {
+		Name:   "clang+llvm-" + LLVMVersion + "-x86_64-linux-gnu-ubuntu-22.04.tar.xz",
+		SHA256: "",
+	},
+	"linux/arm64": {
+		Name:   "clang+llvm-" + LLVMVersion + "-aarch64-linux-gnu.tar.xz",
+		SHA256: "",
+	},
+	"darwin/arm64": {
+		Name:   "clang+llvm-" + LLVMVersion + "-arm64-apple-darwin22.0.tar.xz",
+		SHA256: "",
+	},
+}
+
+// The bin directory of the toolchain if the environment was already set
+// up via SetupEnv
+var envBinDir string
+
+// ToolsDir returns the directory to which cifuzz installs tools
+func ToolsDir() (string, error) {
+	if dir := os.Getenv(ToolsDirEnv); dir != "" {
+		return dir, nil
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return filepath.Join(cacheDir, "cifuzz", "tools"), nil
+}
+
+// Dir returns the directory of the pinned toolchain. The directory
+// only exists if the toolchain was installed.
+func Dir() (string, error) {
+	toolsDir, err := ToolsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(toolsDir, "llvm-"+LLVMVersion), nil
+}
+
+// IsInstalled returns true if the pinned toolchain was installed
+func IsInstalled() (bool, error) {
+	dir, err := Dir()
+	if err != nil {
+		return false, err
+	}
+	return fileutil.Exists(filepath.Join(dir, "bin", "clang"))
+}
+
+// Install downloads the pinned toolchain and extracts it to the tools
+// directory. It does nothing if the toolchain is already installed.
+func Install() error {
+	archive, ok := archives[platform()]
+	if !ok {
+		return errors.New(config.NotSupportedErrorMessage("the C/C++ toolchain", platform()))
+	}
+	if archive.SHA256 == "" {
+		return errors.Errorf("No checksum is pinned for %s, refusing to install an unverified toolchain", archive.Name)
+	}
+
+	installed, err := IsInstalled()
+	if err != nil {
+		return err
+	}
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if installed {
+		log.Infof("The C/C++ toolchain (LLVM %s) is already installed in %s", LLVMVersion, dir)
+		return nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(dir), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Download and extract to a temporary directory next to the
+	// destination, so that an interrupted installation doesn't leave a
+	// partial toolchain behind and the final rename is atomic.
+	tempDir, err := os.MkdirTemp(filepath.Dir(dir), "llvm-download-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tempDir)
+
+	archivePath := filepath.Join(tempDir, archive.Name)
+	err = download(releaseURL+"/"+archive.Name, archivePath, archive.SHA256)
+	if err != nil {
+		return err
+	}
+
+	// The release archives are xz compressed, which the Go standard
+	// library can't decompress, so we use the system tar.
+	extractDir := filepath.Join(tempDir, "llvm")
+	err = os.Mkdir(extractDir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	log.Infof("Extracting %s", archive.Name)
+	cmd := exec.Command("tar", "-xJf", archivePath, "-C", extractDir, "--strip-components=1")
+	cmd.Stderr = os.Stderr
+	log.Debugf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to extract %s", archive.Name)
+	}
+
+	err = os.Rename(extractDir, dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	log.Successf("Installed the C/C++ toolchain (LLVM %s) in %s", LLVMVersion, dir)
+	return nil
+}
+
+// SetupEnv makes the pinned toolchain the one used by cifuzz, by
+// setting CC and CXX and prepending its bin directory to the PATH, so
+// that the llvm tools are also found there. Calling it again has no
+// effect.
+func SetupEnv() error {
+	if _, ok := archives[platform()]; !ok {
+		return errors.New(config.NotSupportedErrorMessage("the C/C++ toolchain", platform()))
+	}
+	installed, err := IsInstalled()
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return errors.New(`The C/C++ toolchain is not installed but "use-cxx-toolchain" is set.
+Please install it with 'cifuzz tools install cxxtoolchain'.`)
+	}
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	binDir := filepath.Join(dir, "bin")
+	if envBinDir == binDir {
+		return nil
+	}
+
+	log.Debugf("Using the C/C++ toolchain in %s", dir)
+	err = os.Setenv("CC", filepath.Join(binDir, "clang"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.Setenv("CXX", filepath.Join(binDir, "clang++"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	path := envutil.AppendToPathList(binDir, filepath.SplitList(os.Getenv("PATH"))...)
+	err = os.Setenv("PATH", path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	envBinDir = binDir
+	return nil
+}
+
+// download downloads the file from the URL to dest and verifies that it
+// has the expected SHA256 checksum
+func download(url, dest, expectedSHA256 string) error {
+	log.Infof("Downloading %s", url)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("Failed to download %s: %s", url, resp.Status)
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	var body io.Reader = resp.Body
+	if resp.ContentLength > 0 {
+		body = progress.NewReader(resp.Body, resp.ContentLength, "Download completed")
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, hash), body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = f.Close()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if checksum != expectedSHA256 {
+		return errors.Errorf("The checksum of %s doesn't match the pinned checksum (expected %s, got %s)",
+			url, expectedSHA256, checksum)
+	}
+	return nil
+}
+
+func platform() string {
+	return fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,116 @@
+package toolchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupEnv(t *testing.T) {
+	if _, ok := archives[platform()]; !ok {
+		t.Skipf("The C/C++ toolchain is not supported on %s", platform())
+	}
+	toolsDir := t.TempDir()
+	t.Setenv(ToolsDirEnv, toolsDir)
+	t.Setenv("CC", "")
+	t.Setenv("CXX", "")
+	t.Setenv("PATH", os.Getenv("PATH"))
+
+	// The toolchain is not installed yet
+	err := SetupEnv()
+	require.Error(t, err)
+
+	// Fake an installed toolchain
+	binDir := filepath.Join(toolsDir, "llvm-"+LLVMVersion, "bin")
+	require.NoError(t, os.MkdirAll(binDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "clang"), nil, 0o755))
+
+	installed, err := IsInstalled()
+	require.NoError(t, err)
+	assert.True(t, installed)
+
+	err = SetupEnv()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(binDir, "clang"), os.Getenv("CC"))
+	assert.Equal(t, filepath.Join(binDir, "clang++"), os.Getenv("CXX"))
+	assert.True(t, strings.HasPrefix(os.Getenv("PATH"), binDir+string(os.PathListSeparator)))
+
+	// Setting up the environment again doesn't change it
+	path := os.Getenv("PATH")
+	err = SetupEnv()
+	require.NoError(t, err)
+	assert.Equal(t, path, os.Getenv("PATH"))
+}
+
+func TestDownload_Checksum(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("archive"))
+	}))
+	defer server.Close()
+
+	sum := sha256.Sum256([]byte("archive"))
+	dest := filepath.Join(t.TempDir(), "archive.tar.xz")
+	err := download(server.URL, dest, hex.EncodeToString(sum[:]))
+	require.NoError(t, err)
+	assert.FileExists(t, dest)
+
+	err = download(server.URL, dest, strings.Repeat("0", 64))
+	require.ErrorContains(t, err, "doesn't match the pinned checksum")
+}
+
+// The SHA256 checksum of testdata/clang+llvm-fixture.tar.xz, which has
+// the same layout as the release archives
+const fixtureSHA256 = "ab2f32ee6a22190c725c1f30599447674e0466b5c114c3643a28f632b3ea5292"
+
+func TestInstall(t *testing.T) {
+	if _, err := exec.LookPath("xz"); err != nil {
+		t.Skip("xz not found")
+	}
+	const fixtureName = "clang+llvm-fixture.tar.xz"
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+	oldReleaseURL := releaseURL
+	releaseURL = server.URL
+	oldArchive, hadArchive := archives[platform()]
+	t.Cleanup(func() {
+		releaseURL = oldReleaseURL
+		if hadArchive {
+			archives[platform()] = oldArchive
+		} else {
+			delete(archives, platform())
+		}
+	})
+	t.Setenv(ToolsDirEnv, t.TempDir())
+
+	// An archive which doesn't match the pinned checksum is not
+	// installed
+	archives[platform()] = llvmArchive{Name: fixtureName, SHA256: strings.Repeat("0", 64)}
+	err := Install()
+	require.ErrorContains(t, err, "doesn't match the pinned checksum")
+	installed, err := IsInstalled()
+	require.NoError(t, err)
+	assert.False(t, installed)
+
+	archives[platform()] = llvmArchive{Name: fixtureName, SHA256: fixtureSHA256}
+	err = Install()
+	require.NoError(t, err)
+	installed, err = IsInstalled()
+	require.NoError(t, err)
+	assert.True(t, installed)
+	dir, err := Dir()
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "bin", "clang"))
+	// Only the toolchain is left in the tools directory
+	entries, err := os.ReadDir(filepath.Dir(dir))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -1,8 +1,18 @@
 package binary
 
 import (
+	"debug/dwarf"
 	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	encodingbinary "encoding/binary"
+	"encoding/hex"
+	"path/filepath"
 	"runtime"
+	"sort"
+
+	"github.com/pkg/errors"
+	"golang.org/x/exp/maps"
 
 	"code-intelligence.com/cifuzz/pkg/log"
 )
@@ -44,3 +54,138 @@ func SupportsLlvmProfileContinuousMode(binary string) bool {
 	// https://github.com/llvm/llvm-project/blob/846709b287abe541fcad42e5a54d37a41dae3f67/compiler-rt/lib/profile/InstrProfilingFile.c#L574
 	return biasVarAddress != 0 && biasDefaultVarAddress != 0 && biasVarAddress != biasDefaultVarAddress
 }
+
+// ErrNoDWARF is returned by SourceFiles if the binary doesn't contain
+// DWARF debug information, e.g. because it was built with MSVC, which
+// stores the debug information in a separate PDB file
+var ErrNoDWARF = errors.New("binary doesn't contain DWARF debug information")
+
+// SourceFiles returns the paths of all source files which were compiled
+// into the given binary, as recorded in its DWARF debug information.
+// ELF, Mach-O and PE binaries are supported.
+func SourceFiles(binary string) ([]string, error) {
+	var data *dwarf.Data
+	if elfFile, err := elf.Open(binary); err == nil {
+		defer elfFile.Close()
+		data, err = elfFile.DWARF()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read debug information from %s", binary)
+		}
+	} else if machoFile, err := macho.Open(binary); err == nil {
+		defer machoFile.Close()
+		data, err = machoFile.DWARF()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read debug information from %s", binary)
+		}
+	} else if peFile, err := pe.Open(binary); err == nil {
+		defer peFile.Close()
+		if peFile.Section(".debug_info") == nil {
+			return nil, errors.Wrap(ErrNoDWARF, binary)
+		}
+		data, err = peFile.DWARF()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read debug information from %s", binary)
+		}
+	} else {
+		return nil, errors.Errorf("unsupported binary format: %s", binary)
+	}
+
+	files := make(map[string]struct{})
+	reader := data.Reader()
+	for {
+		entry, err := reader.Next()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if entry == nil {
+			break
+		}
+		if entry.Tag != dwarf.TagCompileUnit {
+			reader.SkipChildren()
+			continue
+		}
+
+		lineReader, err := data.LineReader(entry)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if lineReader != nil {
+			for _, f := range lineReader.Files() {
+				// The first entry of DWARF 5 line tables can be nil
+				if f != nil && f.Name != "" {
+					files[filepath.Clean(f.Name)] = struct{}{}
+				}
+			}
+		}
+		reader.SkipChildren()
+	}
+
+	res := maps.Keys(files)
+	sort.Strings(res)
+	return res, nil
+}
+
+// HasCoverageMapping returns true if the given ELF or Mach-O binary
+// contains the coverage mapping of clang's source-based code coverage,
+// i.e. if it was built with -fcoverage-mapping.
+func HasCoverageMapping(binary string) bool {
+	if elfFile, err := elf.Open(binary); err == nil {
+		defer elfFile.Close()
+		return elfFile.Section("__llvm_covmap") != nil
+	}
+	if machoFile, err := macho.Open(binary); err == nil {
+		defer machoFile.Close()
+		return machoFile.Section("__llvm_covmap") != nil
+	}
+	return false
+}
+
+// BuildID returns the GNU build ID of the given ELF binary as a hex
+// string, which LLVM records in raw profiles as the binary ID. An empty
+// string is returned if the binary has no build ID.
+func BuildID(binary string) (string, error) {
+	file, err := elf.Open(binary)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer file.Close()
+
+	section := file.Section(".note.gnu.build-id")
+	if section == nil {
+		return "", nil
+	}
+	data, err := section.Data()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return parseBuildIDNote(data, file.ByteOrder), nil
+}
+
+// parseBuildIDNote returns the build ID in the given ELF notes. An ELF
+// note consists of the sizes of the name and the descriptor, the type,
+// the name and the descriptor, which are padded to four bytes.
+func parseBuildIDNote(data []byte, byteOrder encodingbinary.ByteOrder) string {
+	for len(data) >= 12 {
+		nameSize := byteOrder.Uint32(data[0:4])
+		descSize := byteOrder.Uint32(data[4:8])
+		noteType := byteOrder.Uint32(data[8:12])
+		nameEnd := 12 + align4(nameSize)
+		descEnd := nameEnd + align4(descSize)
+		if uint64(len(data)) < descEnd {
+			break
+		}
+		name := string(data[12 : 12+nameSize])
+		if noteType == noteGNUBuildID && name == "GNU\x00" {
+			return hex.EncodeToString(data[nameEnd : nameEnd+uint64(descSize)])
+		}
+		data = data[descEnd:]
+	}
+	return ""
+}
+
+// The type of the ELF note which contains the build ID
+const noteGNUBuildID = 3
+
+func align4(n uint32) uint64 {
+	return (uint64(n) + 3) &^ 3
+}
@@ -0,0 +1,28 @@
+package binary
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBuildIDNote(t *testing.T) {
+	var data []byte
+	// A note of another type, with a name which has to be padded
+	data = binary.LittleEndian.AppendUint32(data, 5)
+	data = binary.LittleEndian.AppendUint32(data, 4)
+	data = binary.LittleEndian.AppendUint32(data, 1)
+	data = append(data, "Go\x00\x00\x00\x00\x00\x00"...)
+	data = append(data, 0xff, 0xff, 0xff, 0xff)
+	// The build ID note
+	data = binary.LittleEndian.AppendUint32(data, 4)
+	data = binary.LittleEndian.AppendUint32(data, 8)
+	data = binary.LittleEndian.AppendUint32(data, noteGNUBuildID)
+	data = append(data, "GNU\x00"...)
+	data = append(data, 0xde, 0xad, 0xbe, 0xef, 0x01, 0x23, 0x45, 0x67)
+
+	assert.Equal(t, "deadbeef01234567", parseBuildIDNote(data, binary.LittleEndian))
+	assert.Empty(t, parseBuildIDNote(data[:len(data)-4], binary.LittleEndian))
+	assert.Empty(t, parseBuildIDNote(nil, binary.LittleEndian))
+}
@@ -0,0 +1,194 @@
+package corpus
+
+import (
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// CompressedSuffix is the suffix of corpus entries which are stored
+// gzip-compressed because they are large, e.g. the crashing inputs of
+// findings. The fuzzing engines don't support compressed inputs, so
+// these entries are decompressed before a fuzz test is run, see
+// ExpandCompressedEntries.
+const CompressedSuffix = ".cifuzz.gz"
+
+// CompressFile writes the gzip-compressed content of src to dest
+func CompressFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer out.Close()
+
+	w := gzip.NewWriter(out)
+	_, err = io.Copy(w, in)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = w.Close()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(out.Close())
+}
+
+// OpenEntry opens the corpus entry for reading. Compressed entries are
+// decompressed while they are read.
+func OpenEntry(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !strings.HasSuffix(path, CompressedSuffix) {
+		return f, nil
+	}
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "Failed to decompress %s", path)
+	}
+	return &compressedEntryReader{Reader: r, file: f}, nil
+}
+
+type compressedEntryReader struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (r *compressedEntryReader) Close() error {
+	err := r.Reader.Close()
+	fileErr := r.file.Close()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(fileErr)
+}
+
+// ExpandCompressedEntries returns the corpus directories which can be
+// passed to the fuzzing engine. Directories which contain compressed
+// entries are replaced by a copy below tempDir with the same base name,
+// in which the compressed entries are decompressed, so that the engine
+// doesn't run the compressed data as inputs. Other directories are
+// returned unchanged.
+func ExpandCompressedEntries(dirs []string, tempDir string) ([]string, error) {
+	var expandedDirs []string
+	for _, dir := range dirs {
+		hasCompressedEntries, err := containsCompressedEntries(dir)
+		if err != nil {
+			return nil, err
+		}
+		if !hasCompressedEntries {
+			expandedDirs = append(expandedDirs, dir)
+			continue
+		}
+
+		// The parent directory avoids collisions between directories
+		// with the same base name
+		parentDir, err := os.MkdirTemp(tempDir, "expanded-corpus-")
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		expandedDir := filepath.Join(parentDir, filepath.Base(dir))
+		err = expandDir(dir, expandedDir)
+		if err != nil {
+			return nil, err
+		}
+		expandedDirs = append(expandedDirs, expandedDir)
+	}
+	return expandedDirs, nil
+}
+
+var errFoundCompressedEntry = errors.New("found compressed entry")
+
+func containsCompressedEntries(dir string) (bool, error) {
+	err := walkCompressedEntries(dir, func(string) error {
+		return errFoundCompressedEntry
+	})
+	if errors.Is(err, errFoundCompressedEntry) {
+		return true, nil
+	}
+	return false, err
+}
+
+// expandDir copies the entries of the corpus directory src to dest and
+// decompresses the compressed entries. Uncompressed entries are hard
+// linked if possible.
+func expandDir(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		target := filepath.Join(dest, relPath)
+		if d.IsDir() {
+			return errors.WithStack(os.MkdirAll(target, 0o755))
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), CompressedSuffix) {
+			if os.Link(path, target) == nil {
+				return nil
+			}
+		}
+		target = strings.TrimSuffix(target, CompressedSuffix)
+		err = copyEntry(path, target)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Copied corpus entry %s to %s", path, target)
+		return nil
+	})
+}
+
+// copyEntry copies the corpus entry to dest, decompressing it if it's
+// compressed
+func copyEntry(path, dest string) error {
+	r, err := OpenEntry(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer out.Close()
+	_, err = io.Copy(out, r)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to copy %s", path)
+	}
+	return errors.WithStack(out.Close())
+}
+
+func walkCompressedEntries(dir string, fn func(path string) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == dir {
+			return nil
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(d.Name(), CompressedSuffix) {
+			return nil
+		}
+		return fn(path)
+	})
+}
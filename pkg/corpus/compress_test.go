@@ -0,0 +1,73 @@
+package corpus
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressFile_OpenEntry(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "input")
+	require.NoError(t, os.WriteFile(src, []byte("some input"), 0o644))
+
+	dest := filepath.Join(dir, "input"+CompressedSuffix)
+	require.NoError(t, CompressFile(src, dest))
+	compressed, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.NotEqual(t, []byte("some input"), compressed)
+
+	for _, path := range []string{src, dest} {
+		r, err := OpenEntry(path)
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		assert.Equal(t, []byte("some input"), data)
+	}
+}
+
+func TestExpandCompressedEntries(t *testing.T) {
+	seedDir := filepath.Join(t.TempDir(), "seeds")
+	require.NoError(t, os.Mkdir(seedDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(seedDir, "plain"), []byte("plain"), 0o644))
+	otherDir := t.TempDir()
+	tempDir := t.TempDir()
+
+	// Without compressed entries, the directories are used as they are
+	dirs, err := ExpandCompressedEntries([]string{seedDir, filepath.Join(seedDir, "missing")}, tempDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{seedDir, filepath.Join(seedDir, "missing")}, dirs)
+
+	src := filepath.Join(t.TempDir(), "input")
+	require.NoError(t, os.WriteFile(src, []byte("large input"), 0o644))
+	require.NoError(t, CompressFile(src, filepath.Join(seedDir, "crash"+CompressedSuffix)))
+
+	dirs, err = ExpandCompressedEntries([]string{seedDir, otherDir}, tempDir)
+	require.NoError(t, err)
+	require.Len(t, dirs, 2)
+	assert.Equal(t, otherDir, dirs[1])
+
+	// The directory with the compressed entry is replaced by a copy
+	// which only contains uncompressed entries
+	expandedDir := dirs[0]
+	assert.NotEqual(t, seedDir, expandedDir)
+	assert.Equal(t, "seeds", filepath.Base(expandedDir))
+	entries, err := os.ReadDir(expandedDir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"crash", "plain"}, names)
+	data, err := os.ReadFile(filepath.Join(expandedDir, "crash"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("large input"), data)
+	data, err = os.ReadFile(filepath.Join(expandedDir, "plain"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("plain"), data)
+}
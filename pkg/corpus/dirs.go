@@ -0,0 +1,65 @@
+package corpus
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// FindDirs returns the generated corpus directories below the corpus
+// root directory (usually .cifuzz-corpus), which are the directories
+// which contain files or don't contain any subdirectories. For Java
+// fuzz tests, the corpus directories are nested in a directory per
+// test class.
+func FindDirs(corpusRoot string) ([]string, error) {
+	exists, err := fileutil.Exists(corpusRoot)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	var dirs []string
+	err = filepath.WalkDir(corpusRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !d.IsDir() || path == corpusRoot {
+			return nil
+		}
+		// Skip directories which are created temporarily by
+		// `cifuzz corpus prune`
+		if strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		isCorpus, err := isCorpusDir(path)
+		if err != nil {
+			return err
+		}
+		if !isCorpus {
+			return nil
+		}
+		dirs = append(dirs, path)
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dirs, nil
+}
+
+func isCorpusDir(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			return true, nil
+		}
+	}
+	return len(entries) == 0, nil
+}
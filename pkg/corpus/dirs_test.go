@@ -0,0 +1,66 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDirs(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		files    []string
+		dirs     []string
+		expected []string
+	}{
+		{
+			name:     "no corpus root",
+			expected: nil,
+		},
+		{
+			name:     "C/C++ fuzz tests",
+			files:    []string{"my_fuzz_test/input1", "my_fuzz_test/input2", "other_fuzz_test/input"},
+			expected: []string{"my_fuzz_test", "other_fuzz_test"},
+		},
+		{
+			name:     "empty corpus",
+			dirs:     []string{"my_fuzz_test"},
+			expected: []string{"my_fuzz_test"},
+		},
+		{
+			name:     "Java fuzz tests",
+			files:    []string{"com.example.FuzzTest/myFuzzTest/input", "com.example.FuzzTest/otherFuzzTest/input"},
+			expected: []string{"com.example.FuzzTest/myFuzzTest", "com.example.FuzzTest/otherFuzzTest"},
+		},
+		{
+			name:     "temporary directories of corpus prune",
+			files:    []string{"my_fuzz_test/input", ".prune-123/input", "my_fuzz_test/.old-456/input"},
+			expected: []string{"my_fuzz_test"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			corpusRoot := filepath.Join(t.TempDir(), ".cifuzz-corpus")
+			for _, file := range tc.files {
+				path := filepath.Join(corpusRoot, filepath.FromSlash(file))
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, os.WriteFile(path, nil, 0o644))
+			}
+			for _, dir := range tc.dirs {
+				require.NoError(t, os.MkdirAll(filepath.Join(corpusRoot, filepath.FromSlash(dir)), 0o755))
+			}
+
+			dirs, err := FindDirs(corpusRoot)
+			require.NoError(t, err)
+			var relDirs []string
+			for _, dir := range dirs {
+				rel, err := filepath.Rel(corpusRoot, dir)
+				require.NoError(t, err)
+				relDirs = append(relDirs, filepath.ToSlash(rel))
+			}
+			assert.Equal(t, tc.expected, relDirs)
+		})
+	}
+}
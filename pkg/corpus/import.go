@@ -0,0 +1,106 @@
+package corpus
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/util/archiveutil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// ImportZip imports the entries of the zip archive into the generated
+// corpus of the fuzz test in the project, see Import. It returns the
+// generated corpus directory and the number of imported and skipped
+// entries.
+func ImportZip(zipPath, projectDir, fuzzTest string) (string, int, int, error) {
+	// Java fuzz tests store their generated corpus in
+	// .cifuzz-corpus/<class>/<method>
+	corpusDir := filepath.Join(projectDir, ".cifuzz-corpus",
+		filepath.FromSlash(strings.Replace(fuzzTest, "::", "/", 1)))
+
+	extractDir, err := os.MkdirTemp("", "cifuzz-corpus-")
+	if err != nil {
+		return "", 0, 0, errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(extractDir)
+	err = archiveutil.Unzip(zipPath, extractDir)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	numImported, numSkipped, err := Import(extractDir, corpusDir)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	return corpusDir, numImported, numSkipped, nil
+}
+
+// Import copies the entries of the source directory (including its
+// subdirectories) into the corpus directory. Like libFuzzer does, the
+// imported entries are named after the SHA-1 hash of their content.
+// Entries whose content already exists in the corpus directory are
+// skipped. It returns the number of imported and skipped entries.
+func Import(srcDir, corpusDir string) (int, int, error) {
+	err := os.MkdirAll(corpusDir, 0o755)
+	if err != nil {
+		return 0, 0, errors.WithStack(err)
+	}
+
+	// The existing entries are not necessarily named after their hash,
+	// for example if they were added manually
+	hashes := map[string]struct{}{}
+	err = walkEntries(corpusDir, func(path string, data []byte) error {
+		hashes[contentHash(data)] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var numImported, numSkipped int
+	err = walkEntries(srcDir, func(path string, data []byte) error {
+		hash := contentHash(data)
+		if _, exists := hashes[hash]; exists {
+			numSkipped++
+			return nil
+		}
+		hashes[hash] = struct{}{}
+		numImported++
+		return errors.WithStack(os.WriteFile(filepath.Join(corpusDir, hash), data, 0o644))
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return numImported, numSkipped, nil
+}
+
+func walkEntries(dir string, fn func(path string, data []byte) error) error {
+	exists, err := fileutil.Exists(dir)
+	if err != nil || !exists {
+		return err
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return fn(path, data)
+	})
+}
+
+func contentHash(data []byte) string {
+	hash := sha1.Sum(data)
+	return hex.EncodeToString(hash[:])
+}
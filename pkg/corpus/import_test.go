@@ -0,0 +1,64 @@
+package corpus
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	srcDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "subdir"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "a"), []byte("foo"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "b"), []byte("bar"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "subdir", "c"), []byte("bar"), 0o644))
+
+	// The corpus directory already contains "foo" under a different name
+	corpusDir := filepath.Join(t.TempDir(), "corpus")
+	require.NoError(t, os.MkdirAll(corpusDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(corpusDir, "existing"), []byte("foo"), 0o644))
+
+	numImported, numSkipped, err := Import(srcDir, corpusDir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, numImported)
+	assert.Equal(t, 2, numSkipped)
+
+	entries, err := os.ReadDir(corpusDir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	// The SHA-1 hash of "bar"
+	assert.ElementsMatch(t, []string{"existing", "62cdb7020ff920e5aa642c3d4066950dd1f01f4d"}, names)
+}
+
+func TestImportZip(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "corpus.zip")
+	f, err := os.Create(zipPath)
+	require.NoError(t, err)
+	w := zip.NewWriter(f)
+	for name, content := range map[string]string{"a": "foo", "corpus/b": "bar"} {
+		entry, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = entry.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	// The corpus of Java fuzz tests is stored per method
+	projectDir := t.TempDir()
+	corpusDir, numImported, numSkipped, err := ImportZip(zipPath, projectDir, "com.example.FuzzTest::myFuzzTest")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(projectDir, ".cifuzz-corpus", "com.example.FuzzTest", "myFuzzTest"), corpusDir)
+	assert.Equal(t, 2, numImported)
+	assert.Equal(t, 0, numSkipped)
+	entries, err := os.ReadDir(corpusDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
@@ -0,0 +1,111 @@
+package corpus
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// PostProcess passes each entry of the corpus directory which was
+// created or modified since the given time through the post-processor
+// commands, in the given order. Each command receives the content of
+// the entry on stdin and prints the processed content to stdout.
+//
+// Like libFuzzer does, the processed entries are named after the SHA-1
+// hash of their content, so that entries which are equal after
+// post-processing are deduplicated.
+//
+// If a post-processor fails, the entry is kept unchanged and a warning
+// is printed, so that no corpus entries are lost.
+func PostProcess(dir string, since time.Time, postProcessors []string) error {
+	if len(postProcessors) == 0 {
+		return nil
+	}
+	exists, err := fileutil.Exists(dir)
+	if err != nil || !exists {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var numProcessed, numRemoved int
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if info.ModTime().Before(since) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		processed, err := runPostProcessors(data, postProcessors)
+		if err != nil {
+			log.Warnf("Failed to post-process corpus entry %s: %v", fileutil.PrettifyPath(path), err)
+			continue
+		}
+		numProcessed++
+
+		newPath := filepath.Join(dir, contentHash(processed))
+		if newPath == path {
+			continue
+		}
+		newPathExists, err := fileutil.Exists(newPath)
+		if err != nil {
+			return err
+		}
+		if !newPathExists {
+			err = os.WriteFile(newPath, processed, 0o644)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		} else {
+			numRemoved++
+		}
+		err = os.Remove(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	log.Debugf("Post-processed %d corpus entries in %s, %d duplicates were removed", numProcessed, dir, numRemoved)
+	return nil
+}
+
+func runPostProcessors(data []byte, postProcessors []string) ([]byte, error) {
+	for _, postProcessor := range postProcessors {
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd.exe", "/C", postProcessor)
+		} else {
+			cmd = exec.Command("/bin/sh", "-c", postProcessor)
+		}
+		cmd.Stdin = bytes.NewReader(data)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, errors.Wrapf(err, "%q failed: %s", postProcessor, bytes.TrimSpace(stderr.Bytes()))
+		}
+		data = out
+	}
+	return data, nil
+}
@@ -0,0 +1,49 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The post-processors of this test require a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old")
+	require.NoError(t, os.WriteFile(old, []byte("OLD"), 0o644))
+	oldTime := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(old, oldTime, oldTime))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a"), []byte("Foo 1"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b"), []byte("foo 2"), 0o644))
+
+	// Both new entries are equal after post-processing, so only one of
+	// them is kept. The old entry is not post-processed.
+	err := PostProcess(dir, time.Now().Add(-time.Minute), []string{"tr A-Z a-z", "tr -d 0-9"})
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	// The SHA-1 hash of "foo "
+	assert.ElementsMatch(t, []string{"old", "35cfc4968f77643bb7788c0057a141ccab2142db"}, names)
+	content, err := os.ReadFile(filepath.Join(dir, "35cfc4968f77643bb7788c0057a141ccab2142db"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo ", string(content))
+
+	// Entries are kept if a post-processor fails
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c"), []byte("bar"), 0o644))
+	err = PostProcess(dir, time.Now().Add(-time.Minute), []string{"false"})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "c"))
+}
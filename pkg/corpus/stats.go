@@ -0,0 +1,81 @@
+package corpus
+
+import (
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// Stats describes the entries of a corpus directory
+type Stats struct {
+	Entries   int   `json:"entries"`
+	TotalSize int64 `json:"total_size"`
+	// The modification times of the oldest and newest entry, only set
+	// if the corpus is not empty
+	Oldest *time.Time      `json:"oldest,omitempty"`
+	Newest *time.Time      `json:"newest,omitempty"`
+	Age    AgeDistribution `json:"age"`
+}
+
+// AgeDistribution counts the corpus entries by the time since they were
+// last modified
+type AgeDistribution struct {
+	LastDay   int `json:"last_day"`
+	LastWeek  int `json:"last_week"`
+	LastMonth int `json:"last_month"`
+	Older     int `json:"older"`
+}
+
+// ComputeStats returns the stats of the corpus directory, relative to
+// the given time. A corpus directory which doesn't exist is empty.
+func ComputeStats(dir string, now time.Time) (*Stats, error) {
+	stats := &Stats{}
+	exists, err := fileutil.Exists(dir)
+	if err != nil || !exists {
+		return stats, err
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		stats.Entries++
+		stats.TotalSize += info.Size()
+
+		modTime := info.ModTime()
+		if stats.Oldest == nil || modTime.Before(*stats.Oldest) {
+			stats.Oldest = &modTime
+		}
+		if stats.Newest == nil || modTime.After(*stats.Newest) {
+			stats.Newest = &modTime
+		}
+
+		age := now.Sub(modTime)
+		switch {
+		case age < 24*time.Hour:
+			stats.Age.LastDay++
+		case age < 7*24*time.Hour:
+			stats.Age.LastWeek++
+		case age < 30*24*time.Hour:
+			stats.Age.LastMonth++
+		default:
+			stats.Age.Older++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,43 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeStats(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	for name, age := range map[string]time.Duration{
+		"a": time.Hour,
+		"b": 2 * time.Hour,
+		"c": 3 * 24 * time.Hour,
+		"d": 10 * 24 * time.Hour,
+		"e": 100 * 24 * time.Hour,
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(name+name), 0o644))
+		modTime := now.Add(-age)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	stats, err := ComputeStats(dir, now)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.Entries)
+	assert.EqualValues(t, 10, stats.TotalSize)
+	assert.Equal(t, AgeDistribution{LastDay: 2, LastWeek: 1, LastMonth: 1, Older: 1}, stats.Age)
+	require.NotNil(t, stats.Oldest)
+	assert.WithinDuration(t, now.Add(-100*24*time.Hour), *stats.Oldest, time.Second)
+	require.NotNil(t, stats.Newest)
+	assert.WithinDuration(t, now.Add(-time.Hour), *stats.Newest, time.Second)
+
+	// A corpus directory which doesn't exist is empty
+	stats, err = ComputeStats(filepath.Join(dir, "does-not-exist"), now)
+	require.NoError(t, err)
+	assert.Equal(t, &Stats{}, stats)
+}
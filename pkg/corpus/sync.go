@@ -0,0 +1,78 @@
+package corpus
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+const (
+	s3Scheme  = "s3://"
+	gcsScheme = "gs://"
+)
+
+// ValidateSyncURL checks that the URL points to an S3 or GCS bucket,
+// which are the supported storage backends for sharing the corpus
+func ValidateSyncURL(url string) error {
+	if !strings.HasPrefix(url, s3Scheme) && !strings.HasPrefix(url, gcsScheme) {
+		return errors.Errorf("Invalid corpus sync URL %q, it must start with %s or %s", url, s3Scheme, gcsScheme)
+	}
+	if strings.TrimRight(strings.TrimPrefix(strings.TrimPrefix(url, s3Scheme), gcsScheme), "/") == "" {
+		return errors.Errorf("Invalid corpus sync URL %q, the bucket is missing", url)
+	}
+	return nil
+}
+
+// Pull downloads the corpus entries from the bucket URL into the local
+// corpus directory. Local entries are never removed.
+func Pull(ctx context.Context, url, dir string) error {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	log.Infof("Pulling the shared corpus from %s", url)
+	return runSync(ctx, url, url, dir)
+}
+
+// Push uploads the entries of the local corpus directory which don't
+// exist in the bucket yet. Remote entries are never removed, so that
+// runs of the fuzz test in parallel CI jobs don't remove each other's
+// entries.
+func Push(ctx context.Context, url, dir string) error {
+	log.Infof("Pushing new corpus entries to %s", url)
+	return runSync(ctx, url, dir, url)
+}
+
+// The command which syncs src to dest. We use the CLIs of the cloud
+// providers, which are usually installed and authenticated on CI
+// agents already. This is a variable so that it can be replaced in
+// tests.
+var syncCommand = func(ctx context.Context, url, src, dest string) *exec.Cmd {
+	if strings.HasPrefix(url, s3Scheme) {
+		return exec.CommandContext(ctx, "aws", "s3", "sync", "--only-show-errors", src, dest)
+	}
+	return exec.CommandContext(ctx, "gcloud", "storage", "rsync", "--quiet", src, dest)
+}
+
+func runSync(ctx context.Context, url, src, dest string) error {
+	err := ValidateSyncURL(url)
+	if err != nil {
+		return err
+	}
+
+	cmd := syncCommand(ctx, url, src, dest)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	log.Debugf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "%s failed: %s", cmd.Path, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package corpus
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSyncURL(t *testing.T) {
+	assert.NoError(t, ValidateSyncURL("s3://my-bucket/corpus"))
+	assert.NoError(t, ValidateSyncURL("gs://my-bucket"))
+	assert.Error(t, ValidateSyncURL("https://example.com/corpus"))
+	assert.Error(t, ValidateSyncURL("gs://"))
+}
+
+func TestPullAndPush(t *testing.T) {
+	var calls [][]string
+	origSyncCommand := syncCommand
+	defer func() { syncCommand = origSyncCommand }()
+	syncCommand = func(ctx context.Context, url, src, dest string) *exec.Cmd {
+		calls = append(calls, []string{src, dest})
+		// Any command which succeeds without arguments
+		return exec.CommandContext(ctx, os.Args[0], "-test.run=^$")
+	}
+
+	dir := filepath.Join(t.TempDir(), "corpus")
+	url := "s3://my-bucket/corpus/my_fuzz_test"
+	require.NoError(t, Pull(context.Background(), url, dir))
+	require.NoError(t, Push(context.Background(), url, dir))
+	assert.Equal(t, [][]string{{url, dir}, {dir, url}}, calls)
+	// The local corpus directory is created before pulling
+	assert.DirExists(t, dir)
+}
@@ -0,0 +1,169 @@
+package dataflow
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/util/envutil"
+	"code-intelligence.com/cifuzz/util/executil"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+type CollectOptions struct {
+	// The fuzz test executable which was built with the data-flow
+	// driver, see tools/dataflow/dataflow.c
+	Executable string
+	FuzzTest   string
+	ProjectDir string
+	CorpusDirs []string
+}
+
+// Collect runs the data-flow build of the fuzz test on each input of
+// the corpus directories and returns the resulting influence map.
+// Inputs on which the fuzz test crashes are skipped with a warning.
+func Collect(ctx context.Context, opts *CollectOptions) (*InfluenceMap, error) {
+	env, err := collectEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	functions, err := listFunctions(ctx, opts.Executable, env)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Found %d instrumented functions in %s", len(functions), opts.Executable)
+
+	tmpDir, err := os.MkdirTemp("", "cifuzz-dataflow-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(tmpDir)
+	tracePath := filepath.Join(tmpDir, "trace")
+
+	inputs, err := corpusInputs(opts.CorpusDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewInfluenceMap(opts.FuzzTest)
+	for _, input := range inputs {
+		var stderr bytes.Buffer
+		cmd := executil.CommandContext(ctx, opts.Executable, input, tracePath)
+		cmd.Env = env
+		cmd.Stderr = &stderr
+		log.Debugf("Command: %s", cmd.String())
+		err = cmd.Run()
+		if ctx.Err() != nil {
+			return nil, errors.WithStack(ctx.Err())
+		}
+		if err != nil {
+			log.Warnf("Failed to collect the data-flow trace of %s: %v\n%s", fileutil.PrettifyPath(input), err, stderr.String())
+			continue
+		}
+
+		f, err := os.Open(tracePath)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		trace, err := ParseTrace(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		// Store the inputs relative to the project directory, so that
+		// the map can be used on other machines
+		name := input
+		if rel, err := filepath.Rel(opts.ProjectDir, input); err == nil && !strings.HasPrefix(rel, "..") {
+			name = filepath.ToSlash(rel)
+		}
+		err = m.Add(name, trace, functions)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func collectEnv() ([]string, error) {
+	env, err := envutil.Setenv(os.Environ(), "NO_CIFUZZ", "1")
+	if err != nil {
+		return nil, err
+	}
+	// The driver uses the symbolizer of the sanitizer runtime to print
+	// the names of the functions
+	symbolizer, err := runfiles.Finder.LLVMSymbolizerPath()
+	if err == nil {
+		env, err = envutil.Setenv(env, "DFSAN_OPTIONS", "external_symbolizer_path="+symbolizer)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		log.Debugf("llvm-symbolizer not found: %v", err)
+	}
+	return env, nil
+}
+
+// listFunctions returns the names of the instrumented functions in the
+// order in which the data-flow driver numbers them
+func listFunctions(ctx context.Context, executable string, env []string) ([]string, error) {
+	env, err := envutil.Setenv(env, "CIFUZZ_DATAFLOW_PRINT_FUNCTIONS", "1")
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := executil.CommandContext(ctx, executable)
+	cmd.Env = env
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	log.Debugf("Command: %s", cmd.String())
+	err = cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list the functions of %s: %s", executable, stderr.String())
+	}
+
+	var functions []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		// DFSan adds the suffix ".dfsan" to the names of instrumented
+		// functions
+		functions = append(functions, strings.TrimSuffix(line, ".dfsan"))
+	}
+	return functions, nil
+}
+
+func corpusInputs(dirs []string) ([]string, error) {
+	var inputs []string
+	for _, dir := range dirs {
+		exists, err := fileutil.Exists(dir)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if d.Type().IsRegular() {
+				inputs = append(inputs, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inputs, nil
+}
@@ -0,0 +1,190 @@
+package dataflow
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Entries of libFuzzer dictionaries are limited to 64 bytes
+const maxTokenLen = 64
+
+// InfluenceMap maps the names of the functions of a fuzz test to the
+// bytes of the corpus inputs which influence the comparisons in them.
+// It can be used to find out which parts of the inputs the fuzzer has
+// to mutate to reach new code in a function.
+type InfluenceMap struct {
+	FuzzTest  string                  `json:"fuzz_test"`
+	Functions map[string][]*Influence `json:"functions"`
+}
+
+// Influence describes the bytes of an input which influence a function
+type Influence struct {
+	// The path of the input, relative to the project directory
+	Input string      `json:"input"`
+	Bytes []ByteRange `json:"bytes"`
+}
+
+// ByteRange is a range of input bytes, the end is exclusive
+type ByteRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+func NewInfluenceMap(fuzzTest string) *InfluenceMap {
+	return &InfluenceMap{FuzzTest: fuzzTest, Functions: map[string][]*Influence{}}
+}
+
+// Add adds the trace of the input to the map. The trace maps the
+// indices of the functions to the influencing bytes, the names of the
+// functions are looked up in the given list.
+func (m *InfluenceMap) Add(input string, trace map[int][]ByteRange, functions []string) error {
+	for index, ranges := range trace {
+		if index >= len(functions) {
+			return errors.Errorf("Invalid function index %d in the data-flow trace of %s", index, input)
+		}
+		name := functions[index]
+		m.Functions[name] = append(m.Functions[name], &Influence{Input: input, Bytes: ranges})
+	}
+	return nil
+}
+
+// FocusFunctions returns the names of the functions which are
+// influenced by the inputs, sorted by the number of inputs which
+// influence them in descending order
+func (m *InfluenceMap) FocusFunctions() []string {
+	var names []string
+	for name := range m.Functions {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ni, nj := len(m.Functions[names[i]]), len(m.Functions[names[j]])
+		if ni != nj {
+			return ni > nj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// Tokens returns the contiguous sequences of input bytes which
+// influence a function, which are likely compared to magic values and
+// are thus good dictionary entries. The paths of the inputs are
+// resolved relative to the project directory.
+func (m *InfluenceMap) Tokens(projectDir string) ([]string, error) {
+	contents := map[string][]byte{}
+	tokens := map[string]struct{}{}
+	for _, influences := range m.Functions {
+		for _, influence := range influences {
+			content, ok := contents[influence.Input]
+			if !ok {
+				var err error
+				content, err = os.ReadFile(filepath.Join(projectDir, filepath.FromSlash(influence.Input)))
+				if os.IsNotExist(err) {
+					// The input was removed from the corpus since the
+					// trace was collected
+					contents[influence.Input] = nil
+					continue
+				}
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				contents[influence.Input] = content
+			}
+			for _, r := range influence.Bytes {
+				// Single bytes are found by the fuzzer quickly anyway
+				if r.End-r.Start < 2 || r.End-r.Start > maxTokenLen || r.End > len(content) {
+					continue
+				}
+				tokens[string(content[r.Start:r.End])] = struct{}{}
+			}
+		}
+	}
+
+	var result []string
+	for token := range tokens {
+		result = append(result, token)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// Save writes the map to the file as JSON
+func (m *InfluenceMap) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(path, data, 0o644))
+}
+
+// Load reads a map which was written by Save
+func Load(path string) (*InfluenceMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	m := &InfluenceMap{}
+	err = json.Unmarshal(data, m)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse data-flow influence map %s", path)
+	}
+	return m, nil
+}
+
+// ParseTrace parses the output of the data-flow driver, which contains
+// a line "F<function index> <bytes>" for each function which is
+// influenced by the input, where <bytes> contains a '1' for each input
+// byte which influences the function and a '0' for all other bytes.
+func ParseTrace(r io.Reader) (map[int][]ByteRange, error) {
+	trace := map[int][]ByteRange{}
+	scanner := bufio.NewScanner(r)
+	// The lines are as long as the input
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		indexStr, bytes, ok := strings.Cut(line, " ")
+		if !ok || !strings.HasPrefix(indexStr, "F") {
+			return nil, errors.Errorf("Invalid line in data-flow trace: %q", line)
+		}
+		index, err := strconv.Atoi(strings.TrimPrefix(indexStr, "F"))
+		if err != nil {
+			return nil, errors.Errorf("Invalid function index in data-flow trace: %q", line)
+		}
+
+		var ranges []ByteRange
+		start := -1
+		for i, c := range bytes + "0" {
+			switch {
+			case c == '1' && start == -1:
+				start = i
+			case c == '0' && start != -1:
+				ranges = append(ranges, ByteRange{Start: start, End: i})
+				start = -1
+			case c != '0' && c != '1':
+				return nil, errors.Errorf("Invalid byte marker %q in data-flow trace", c)
+			}
+		}
+		if len(ranges) > 0 {
+			trace[index] = ranges
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return trace, nil
+}
@@ -0,0 +1,93 @@
+package dataflow
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrace(t *testing.T) {
+	trace, err := ParseTrace(strings.NewReader("F0 1100111\nF3 0000001\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[int][]ByteRange{
+		0: {{Start: 0, End: 2}, {Start: 4, End: 7}},
+		3: {{Start: 6, End: 7}},
+	}, trace)
+
+	_, err = ParseTrace(strings.NewReader("C0 1 2 3\n"))
+	assert.Error(t, err)
+	_, err = ParseTrace(strings.NewReader("F0 01x\n"))
+	assert.Error(t, err)
+}
+
+func TestInfluenceMap(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "input"), []byte("MAGICxyz"), 0o644))
+
+	m := NewInfluenceMap("my_fuzz_test")
+	err := m.Add("input", map[int][]ByteRange{
+		0: {{Start: 0, End: 5}},
+		1: {{Start: 0, End: 5}, {Start: 7, End: 8}},
+	}, []string{"parse_header", "check_magic"})
+	require.NoError(t, err)
+	err = m.Add("removed", map[int][]ByteRange{1: {{Start: 0, End: 3}}}, []string{"parse_header", "check_magic"})
+	require.NoError(t, err)
+	assert.Error(t, m.Add("input", map[int][]ByteRange{2: {{Start: 0, End: 1}}}, []string{"parse_header"}))
+
+	assert.Equal(t, []string{"check_magic", "parse_header"}, m.FocusFunctions())
+
+	// Single bytes and inputs which don't exist anymore are skipped
+	tokens, err := m.Tokens(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"MAGIC"}, tokens)
+
+	path := filepath.Join(projectDir, "dataflow", "my_fuzz_test.json")
+	require.NoError(t, m.Save(path))
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, m, loaded)
+}
+
+func TestCollect(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The fake data-flow driver of this test is a shell script")
+	}
+
+	projectDir := t.TempDir()
+	corpusDir := filepath.Join(projectDir, "corpus")
+	require.NoError(t, os.Mkdir(corpusDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(corpusDir, "a"), []byte("abcd"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(corpusDir, "crash"), []byte("crash"), 0o644))
+
+	// Emulates the data-flow driver in tools/dataflow/dataflow.c
+	executable := filepath.Join(projectDir, "fuzz_test")
+	script := `#!/bin/sh
+if [ -n "$CIFUZZ_DATAFLOW_PRINT_FUNCTIONS" ]; then
+  echo LLVMFuzzerTestOneInput.dfsan
+  echo parse
+  exit 0
+fi
+if [ "$(cat "$1")" = crash ]; then
+  exit 1
+fi
+echo "F1 0110" > "$2"
+`
+	require.NoError(t, os.WriteFile(executable, []byte(script), 0o755))
+
+	m, err := Collect(context.Background(), &CollectOptions{
+		Executable: executable,
+		FuzzTest:   "fuzz_test",
+		ProjectDir: projectDir,
+		CorpusDirs: []string{corpusDir, filepath.Join(projectDir, "does-not-exist")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]*Influence{
+		"parse": {{Input: "corpus/a", Bytes: []ByteRange{{Start: 1, End: 3}}}},
+	}, m.Functions)
+}
@@ -142,6 +142,26 @@ var deps = Dependencies{
 			return dep.checkFinder(dep.finder.GenHTMLPath)
 		},
 	},
+	Gcovr: {
+		Key: Gcovr,
+		// gcovr supports writing lcov reports since version 7.0
+		MinVersion: *semver.MustParse("7.0.0"),
+		GetVersion: func(dep *Dependency, projectDir string) (*semver.Version, error) {
+			path, err := dep.finder.GcovrPath()
+			if err != nil {
+				return nil, err
+			}
+			version, err := gcovrVersion(path, dep)
+			if err != nil {
+				return nil, err
+			}
+			log.Debugf("Found gcovr version %s in PATH: %s", version, path)
+			return version, nil
+		},
+		Installed: func(dep *Dependency, projectDir string) bool {
+			return dep.checkFinder(dep.finder.GcovrPath)
+		},
+	},
 	Perl: {
 		Key:        Perl,
 		MinVersion: *semver.MustParse("0.0.0"),
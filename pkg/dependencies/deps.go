@@ -26,6 +26,7 @@ const (
 	LLVMProfData   Key = "llvm-profdata"
 
 	GenHTML Key = "genhtml"
+	Gcovr   Key = "gcovr"
 	Perl    Key = "perl"
 
 	Java   Key = "java"
@@ -36,8 +37,9 @@ const (
 
 	VisualStudio Key = "Visual Studio"
 
-	MessageVersion = "cifuzz requires %s %s or higher, found %s"
-	MessageMissing = "cifuzz requires %s, but it is not installed"
+	MessageVersion  = "cifuzz requires %s %s or higher, found %s"
+	MessageMissing  = "cifuzz requires %s, but it is not installed"
+	MessageDisabled = "%s. %s is disabled."
 )
 
 // Dependency represents a single dependency
@@ -53,20 +55,20 @@ type Dependency struct {
 	Installed  func(*Dependency, string) bool
 }
 
-// Compares MinVersion against GetVersion
-func (dep *Dependency) checkVersion(projectDir string) bool {
+// Compares MinVersion against GetVersion and returns a description of
+// the problem if the version is too old
+func (dep *Dependency) checkVersion(projectDir string) string {
 	currentVersion, err := dep.GetVersion(dep, projectDir)
 	if err != nil {
 		log.Warnf("Unable to get current version for %s, message: %v", dep.Key, err)
 		// we want to be lenient if we were not able to extract the version
-		return true
+		return ""
 	}
 
 	if currentVersion.Compare(&dep.MinVersion) == -1 {
-		log.Warnf(MessageVersion, dep.Key, dep.MinVersion.String(), currentVersion.String())
-		return false
+		return fmt.Sprintf(MessageVersion, dep.Key, dep.MinVersion.String(), currentVersion.String())
 	}
-	return true
+	return ""
 }
 
 // helper to easily check against functions from the runfiles.RunfilesFinder interface
@@ -88,6 +90,14 @@ func Check(keys []Key, projectDir string) error {
 	return nil
 }
 
+// CheckOptional checks if the dependencies of an optional feature of a
+// command are fulfilled. If they are not, a note is printed that the
+// feature is disabled and false is returned, so that the command can
+// continue without the feature instead of failing.
+func CheckOptional(keys []Key, projectDir string, feature string) bool {
+	return checkOptional(keys, deps, runfiles.Finder, projectDir, feature)
+}
+
 func Version(key Key, projectDir string) (*semver.Version, error) {
 	dep, found := deps[key]
 	if !found {
@@ -101,33 +111,51 @@ func Version(key Key, projectDir string) (*semver.Version, error) {
 func check(keys []Key, deps Dependencies, finder runfiles.RunfilesFinder, projectDir string) error {
 	allFine := true
 	for _, key := range keys {
-		dep, found := deps[key]
-		if !found {
-			panic(fmt.Sprintf("Undefined dependency %s", key))
+		problem := checkDependency(key, deps, finder, projectDir)
+		if problem != "" {
+			log.Warn(problem)
+			allFine = false
 		}
+	}
 
-		dep.finder = finder
+	if !allFine {
+		return errDeps
+	}
+	return nil
+}
 
-		if !dep.Installed(dep, projectDir) {
-			log.Warnf(MessageMissing, dep.Key)
+func checkOptional(keys []Key, deps Dependencies, finder runfiles.RunfilesFinder, projectDir string, feature string) bool {
+	allFine := true
+	for _, key := range keys {
+		problem := checkDependency(key, deps, finder, projectDir)
+		if problem != "" {
+			log.Notef(MessageDisabled, problem, feature)
 			allFine = false
-			continue
 		}
+	}
+	return allFine
+}
 
-		if dep.MinVersion.Equal(semver.MustParse("0.0.0")) {
-			log.Debugf("Checking dependency: %s ", dep.Key)
-		} else {
-			log.Debugf("Checking dependency: %s version >= %s", dep.Key, dep.MinVersion.String())
-		}
+// checkDependency returns a description of the problem if the
+// dependency is not installed or its version is too old, else an empty
+// string
+func checkDependency(key Key, deps Dependencies, finder runfiles.RunfilesFinder, projectDir string) string {
+	dep, found := deps[key]
+	if !found {
+		panic(fmt.Sprintf("Undefined dependency %s", key))
+	}
 
-		if !dep.checkVersion(projectDir) {
-			allFine = false
-		}
+	dep.finder = finder
 
+	if !dep.Installed(dep, projectDir) {
+		return fmt.Sprintf(MessageMissing, dep.Key)
 	}
 
-	if !allFine {
-		return errDeps
+	if dep.MinVersion.Equal(semver.MustParse("0.0.0")) {
+		log.Debugf("Checking dependency: %s ", dep.Key)
+	} else {
+		log.Debugf("Checking dependency: %s version >= %s", dep.Key, dep.MinVersion.String())
 	}
-	return nil
+
+	return dep.checkVersion(projectDir)
 }
@@ -89,6 +89,24 @@ func TestCheck_UnableToGetVersion(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestCheckOptional(t *testing.T) {
+	keys := []Key{LLVMSymbolizer}
+	deps := getDeps(keys)
+
+	dep := deps[LLVMSymbolizer]
+	dep.GetVersion = func(d *Dependency, _ string) (*semver.Version, error) {
+		return &d.MinVersion, nil
+	}
+
+	finder := &mocks.RunfilesFinderMock{}
+	finder.On("LLVMSymbolizerPath").Return("llvm-symbolizer", nil)
+	require.True(t, checkOptional(keys, deps, finder, "", "Symbolizing"))
+
+	finder = &mocks.RunfilesFinderMock{}
+	finder.On("LLVMSymbolizerPath").Return("", errors.New("missing-error"))
+	require.False(t, checkOptional(keys, deps, finder, "", "Symbolizing"))
+}
+
 // TestCheck_SpecialCaseGradle tests if the special case of gradle is handled
 // correctly. The preferred way of using gradle is using the 'gradlew' (wrapper)
 // in the project dir and this should also work without needing gradle to be
@@ -31,6 +31,7 @@ var (
 
 	bazelRegex   = regexp.MustCompile(`(?m)bazel (?P<version>\d+(\.\d+\.\d+)?)`)
 	genHTMLRegex = regexp.MustCompile(`.*LCOV version (?P<version>\d+\.\d+(\.\d+)?)`)
+	gcovrRegex   = regexp.MustCompile(`(?m)^gcovr (?P<version>\d+\.\d+(\.\d+)?)`)
 
 	jazzerRegex = regexp.MustCompile(`jazzer-(?P<version>\d+\.\d+\.\d+).jar`)
 	junitRegex  = regexp.MustCompile(`junit-jupiter-engine-(?P<version>\d+\.\d+\.\d+).jar`)
@@ -143,6 +144,14 @@ func genHTMLVersion(path string, dep *Dependency) (*semver.Version, error) {
 	return version, nil
 }
 
+func gcovrVersion(path string, dep *Dependency) (*semver.Version, error) {
+	version, err := getVersionFromCommand(path, []string{"--version"}, gcovrRegex, dep.Key)
+	if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
 func cmakeVersion(dep *Dependency, projectDir string) (*semver.Version, error) {
 	path, err := exec.LookPath("cmake")
 	if err != nil {
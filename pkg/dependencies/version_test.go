@@ -95,6 +95,15 @@ OpenJDK 64-Bit Server VM (build 18+36-2087, mixed mode, sharing)`,
 		Output: `openjdk version "18.0.0.1" 2022-03-22
 OpenJDK Runtime Environment (build 18+36-2087)
 OpenJDK 64-Bit Server VM (build 18+36-2087, mixed mode, sharing)`,
+	},
+	// ---gcovr
+	{
+		Want:  semver.MustParse("7.2.0"),
+		Regex: gcovrRegex,
+		Output: `gcovr 7.2
+
+Copyright (c) 2013-2024 the gcovr authors
+Copyright (c) 2013 Sandia Corporation.`,
 	},
 	{
 		Want:   semver.MustParse("16.16.0"),
@@ -0,0 +1,214 @@
+package dictionary
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+const (
+	// Entries shorter than this are not worth adding to a dictionary,
+	// because libFuzzer finds them quickly on its own.
+	minEntryLength = 2
+	// Long string literals are usually log or error messages which
+	// don't help the fuzzer.
+	maxEntryLength = 64
+)
+
+// Directories which never contain sources of the code under test
+var skippedDirs = []string{
+	".git",
+	".cifuzz-build",
+	".cifuzz-corpus",
+	".cifuzz-findings",
+	"node_modules",
+	"build",
+	"target",
+}
+
+var sourceFileExtensions = []string{
+	".c", ".cc", ".cpp", ".cxx", ".h", ".hh", ".hpp", ".hxx",
+	".java", ".kt",
+	".js", ".jsx", ".ts", ".tsx",
+}
+
+var (
+	stringLiteralRegex = regexp.MustCompile(`"((?:[^"\\\n]|\\.)*)"`)
+	// Hex constants with at least two bytes, e.g. 0x89504e47
+	hexConstantRegex = regexp.MustCompile(`\b0[xX]([0-9a-fA-F]{4}|[0-9a-fA-F]{8}|[0-9a-fA-F]{16})[uUlL]*\b`)
+	// Field definitions in protobuf files, e.g. "optional string name = 1;"
+	protoFieldRegex = regexp.MustCompile(`^\s*(?:repeated\s+|optional\s+|required\s+)?[\w.]+\s+(\w+)\s*=\s*\d+`)
+	// Keys of JSON objects, e.g. "name":
+	jsonKeyRegex = regexp.MustCompile(`"((?:[^"\\\n]|\\.)*)"\s*:`)
+)
+
+// Generate scans the source files below the given directories and
+// returns the values of string literals, magic constants, protobuf
+// field names and JSON keys found in them, sorted and deduplicated.
+func Generate(dirs ...string) ([]string, error) {
+	entries := make(map[string]struct{})
+
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if d.IsDir() {
+				if path != dir && isSkippedDir(d.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			var extract func(line string) []string
+			switch ext := strings.ToLower(filepath.Ext(path)); {
+			case ext == ".proto":
+				extract = extractFromProto
+			case ext == ".json":
+				extract = extractFromJSON
+			case stringutil.Contains(sourceFileExtensions, ext):
+				extract = extractFromSource
+			default:
+				return nil
+			}
+
+			return scanFile(path, extract, entries)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var res []string
+	for entry := range entries {
+		res = append(res, entry)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// Write writes the entries in the libFuzzer dictionary format, see
+// https://llvm.org/docs/LibFuzzer.html#dictionaries
+func Write(w io.Writer, entries []string) error {
+	for _, entry := range entries {
+		_, err := fmt.Fprintf(w, "\"%s\"\n", Escape(entry))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// Escape escapes the value of a dictionary entry so that it can be
+// used between the double quotes of a dictionary line.
+func Escape(entry string) string {
+	var sb strings.Builder
+	for i := 0; i < len(entry); i++ {
+		c := entry[i]
+		switch {
+		case c == '\\' || c == '"':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			sb.WriteString(fmt.Sprintf("\\x%02X", c))
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func scanFile(path string, extract func(line string) []string, entries map[string]struct{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// Allow long lines, e.g. in minified JSON files
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		for _, entry := range extract(scanner.Text()) {
+			if len(entry) < minEntryLength || len(entry) > maxEntryLength {
+				continue
+			}
+			entries[entry] = struct{}{}
+		}
+	}
+	return errors.WithStack(scanner.Err())
+}
+
+func extractFromSource(line string) []string {
+	trimmed := strings.TrimSpace(line)
+	// Don't add include paths and similar preprocessor arguments
+	if strings.HasPrefix(trimmed, "#include") || strings.HasPrefix(trimmed, "import ") {
+		return nil
+	}
+
+	var res []string
+	for _, match := range stringLiteralRegex.FindAllStringSubmatch(line, -1) {
+		res = append(res, unescape(match[1]))
+	}
+	for _, match := range hexConstantRegex.FindAllStringSubmatch(line, -1) {
+		res = append(res, hexConstantBytes(match[1]))
+	}
+	return res
+}
+
+func extractFromProto(line string) []string {
+	match := protoFieldRegex.FindStringSubmatch(line)
+	if match == nil {
+		return nil
+	}
+	return []string{match[1]}
+}
+
+func extractFromJSON(line string) []string {
+	var res []string
+	for _, match := range jsonKeyRegex.FindAllStringSubmatch(line, -1) {
+		res = append(res, unescape(match[1]))
+	}
+	return res
+}
+
+// unescape resolves the escape sequences in the content of a string
+// literal. If the literal uses escape sequences we don't understand,
+// it is returned unchanged.
+func unescape(s string) string {
+	unquoted, err := strconv.Unquote(`"` + s + `"`)
+	if err != nil {
+		return s
+	}
+	return unquoted
+}
+
+// hexConstantBytes returns the little-endian byte representation of a
+// hex constant, which is how it's laid out in memory on all platforms
+// we support.
+func hexConstantBytes(hex string) string {
+	value, err := strconv.ParseUint(hex, 16, 64)
+	if err != nil {
+		return ""
+	}
+	size := len(hex) / 2
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, value)
+	return string(buf[:size])
+}
+
+func isSkippedDir(name string) bool {
+	return stringutil.Contains(skippedDirs, name) || strings.HasPrefix(name, ".cifuzz-")
+}
@@ -0,0 +1,54 @@
+package dictionary
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"src/parser.cpp": `#include "parser.h"
+if (memcmp(data, "MAGIC", 5) == 0 && header == 0x89504e47) {
+  parse("key\tvalue");
+}`,
+		"src/message.proto": `message Person {
+  optional string user_name = 1;
+  repeated int32 ids = 2;
+}`,
+		"testdata/config.json":      `{"timeout": 10, "mode": "fast"}`,
+		"node_modules/lib/index.js": `const ignored = "node_modules";`,
+		"README.md":                 `"not a source file"`,
+	}
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	entries, err := Generate(dir)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		"MAGIC",
+		"key\tvalue",
+		"\x47\x4e\x50\x89",
+		"user_name",
+		"ids",
+		"timeout",
+		"mode",
+	}, entries)
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, []string{`a"b\c`, "\x00\xff"})
+	require.NoError(t, err)
+	assert.Equal(t, "\"a\\\"b\\\\c\"\n\"\\x00\\xFF\"\n", buf.String())
+}
@@ -0,0 +1,69 @@
+package finding
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// BaselineFileName is the name of the file in the project directory
+// which lists the known findings
+const BaselineFileName = ".cifuzz-baseline.yaml"
+
+// A Baseline lists known findings, e.g. findings which are tracked in
+// an issue, which should not fail CI pipelines
+type Baseline struct {
+	Findings []*BaselineEntry `yaml:"findings"`
+}
+
+type BaselineEntry struct {
+	// The signature of the finding, as shown by `cifuzz finding`
+	Signature string `yaml:"signature"`
+	// If set, the entry only matches findings of this fuzz test
+	FuzzTest string `yaml:"fuzz_test,omitempty"`
+	// The name of the finding, which is only used for reference
+	Name string `yaml:"name,omitempty"`
+}
+
+// LoadBaseline parses the baseline file in the project directory. If
+// it doesn't exist, nil is returned.
+func LoadBaseline(projectDir string) (*Baseline, error) {
+	path := filepath.Join(projectDir, BaselineFileName)
+	bytes, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var b Baseline
+	err = yaml.Unmarshal(bytes, &b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse %s", path)
+	}
+	for _, entry := range b.Findings {
+		if entry.Signature == "" {
+			return nil, errors.Errorf("Failed to parse %s: Entry without signature", path)
+		}
+	}
+	return &b, nil
+}
+
+// Contains returns whether the finding is listed in the baseline
+func (b *Baseline) Contains(f *Finding) bool {
+	if b == nil {
+		return false
+	}
+	signature := f.Signature()
+	if signature == "" {
+		return false
+	}
+	for _, entry := range b.Findings {
+		if entry.Signature == signature && (entry.FuzzTest == "" || entry.FuzzTest == f.FuzzTest) {
+			return true
+		}
+	}
+	return false
+}
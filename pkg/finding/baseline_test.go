@@ -0,0 +1,58 @@
+package finding
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestLoadBaseline(t *testing.T) {
+	testDir := testutil.ChdirToTempDir(t, "baseline-test-")
+
+	// Without a baseline file, there is no baseline
+	baseline, err := LoadBaseline(testDir)
+	require.NoError(t, err)
+	assert.Nil(t, baseline)
+	assert.False(t, baseline.Contains(testFinding()))
+
+	content := `findings:
+  - signature: 1234
+  - signature: 5678
+    fuzz_test: my_fuzz_test
+`
+	err = os.WriteFile(filepath.Join(testDir, BaselineFileName), []byte(content), 0o644)
+	require.NoError(t, err)
+	baseline, err = LoadBaseline(testDir)
+	require.NoError(t, err)
+	require.Len(t, baseline.Findings, 2)
+
+	f := testFinding()
+	f.StackHash = "1234"
+	assert.True(t, baseline.Contains(f))
+
+	// The second entry only matches findings of my_fuzz_test
+	f.StackHash = "5678"
+	f.FuzzTest = "other_fuzz_test"
+	assert.False(t, baseline.Contains(f))
+	f.FuzzTest = "my_fuzz_test"
+	assert.True(t, baseline.Contains(f))
+
+	f.StackHash = "9999"
+	assert.False(t, baseline.Contains(f))
+}
+
+func TestLoadBaseline_MissingSignature(t *testing.T) {
+	testDir := testutil.ChdirToTempDir(t, "baseline-test-")
+	content := `findings:
+  - name: adventurous_pangolin
+`
+	err := os.WriteFile(filepath.Join(testDir, BaselineFileName), []byte(content), 0o644)
+	require.NoError(t, err)
+	_, err = LoadBaseline(testDir)
+	require.Error(t, err)
+}
@@ -0,0 +1,163 @@
+package finding
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// Environment describes the environment in which a finding was found.
+// It's stored with the finding, so that we can warn users if they try to
+// reproduce the finding in a different environment.
+type Environment struct {
+	OS   string `json:"os,omitempty"`
+	Arch string `json:"arch,omitempty"`
+	// The first line of the version output of the tools which are
+	// relevant for building and running fuzz tests and which were
+	// found in the environment, e.g. "clang" -> "clang version 17.0.6"
+	Tools map[string]string `json:"tools,omitempty"`
+	// The environment variables which affect how fuzz tests are built
+	// and run. Only variables which were set are recorded.
+	EnvVars map[string]string `json:"env_vars,omitempty"`
+}
+
+// The environment variables which are recorded in the environment of
+// findings
+var environmentVariables = []string{
+	"CC",
+	"CXX",
+	"CFLAGS",
+	"CXXFLAGS",
+	"LDFLAGS",
+	"ASAN_OPTIONS",
+	"UBSAN_OPTIONS",
+	"TSAN_OPTIONS",
+	"MSAN_OPTIONS",
+	"JAVA_HOME",
+	"JAVA_TOOL_OPTIONS",
+	"NODE_OPTIONS",
+}
+
+// CaptureEnvironment records the current environment. The version of
+// the C/C++ compiler is also the version of the sanitizer runtimes,
+// which are part of the compiler. The environment is only captured once
+// per process, because determining the tool versions spawns a
+// subprocess per tool and the environment doesn't change during a run.
+// The returned environment must not be modified.
+var CaptureEnvironment = sync.OnceValue(captureEnvironment)
+
+func captureEnvironment() *Environment {
+	env := &Environment{
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Tools:   map[string]string{},
+		EnvVars: map[string]string{},
+	}
+
+	for _, name := range environmentVariables {
+		if value := os.Getenv(name); value != "" {
+			env.EnvVars[name] = value
+		}
+	}
+
+	cc := os.Getenv("CC")
+	if cc == "" {
+		cc = "clang"
+	}
+	java := "java"
+	if javaHome := os.Getenv("JAVA_HOME"); javaHome != "" {
+		java = filepath.Join(javaHome, "bin", "java")
+	}
+	tools := map[string][]string{
+		"cc":   {cc, "--version"},
+		"java": {java, "-version"},
+		"node": {"node", "--version"},
+	}
+	for name, args := range tools {
+		version := toolVersion(args[0], args[1:]...)
+		if version != "" {
+			env.Tools[name] = version
+		}
+	}
+
+	return env
+}
+
+// Diff returns a human-readable description of the differences between
+// the environment e, in which the finding was found, and the current
+// environment.
+func (e *Environment) Diff(current *Environment) []string {
+	if e == nil || current == nil {
+		return nil
+	}
+
+	var diffs []string
+	if e.OS != current.OS || e.Arch != current.Arch {
+		diffs = append(diffs, fmt.Sprintf("Platform: %s/%s (now %s/%s)", e.OS, e.Arch, current.OS, current.Arch))
+	}
+	// Tools which were not available when the finding was found are
+	// irrelevant for it (e.g. node for a C/C++ fuzz test), so we only
+	// compare the recorded tools
+	diffs = append(diffs, diffMaps("Tool", e.Tools, current.Tools, false)...)
+	diffs = append(diffs, diffMaps("Environment variable", e.EnvVars, current.EnvVars, true)...)
+	return diffs
+}
+
+func diffMaps(kind string, recorded, current map[string]string, includeNewKeys bool) []string {
+	keys := make(map[string]bool)
+	for key := range recorded {
+		keys[key] = true
+	}
+	if includeNewKeys {
+		for key := range current {
+			keys[key] = true
+		}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var diffs []string
+	for _, key := range sortedKeys {
+		was, now := recorded[key], current[key]
+		if was == now {
+			continue
+		}
+		if was == "" {
+			was = "<not set>"
+		}
+		if now == "" {
+			now = "<not set>"
+		}
+		diffs = append(diffs, fmt.Sprintf("%s %s: %s (now %s)", kind, key, was, now))
+	}
+	return diffs
+}
+
+// toolVersion returns the first line of the version output of the
+// tool, or an empty string if the tool is not available
+func toolVersion(tool string, args ...string) string {
+	path, err := exec.LookPath(tool)
+	if err != nil {
+		return ""
+	}
+	cmd := exec.Command(path, args...)
+	// Java prints the version to stderr
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Debugf("Failed to get version of %s: %v", tool, err)
+		return ""
+	}
+	firstLine, _, _ := bytes.Cut(bytes.TrimSpace(out), []byte("\n"))
+	return strings.TrimSpace(string(firstLine))
+}
@@ -0,0 +1,50 @@
+package finding
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironment_Diff(t *testing.T) {
+	recorded := &Environment{
+		OS:      "linux",
+		Arch:    "amd64",
+		Tools:   map[string]string{"cc": "clang version 16.0.0"},
+		EnvVars: map[string]string{"ASAN_OPTIONS": "detect_leaks=0"},
+	}
+
+	current := &Environment{
+		OS:      "linux",
+		Arch:    "amd64",
+		Tools:   map[string]string{"cc": "clang version 16.0.0", "node": "v18.0.0"},
+		EnvVars: map[string]string{"ASAN_OPTIONS": "detect_leaks=0"},
+	}
+	assert.Empty(t, recorded.Diff(current))
+
+	current = &Environment{
+		OS:      "darwin",
+		Arch:    "arm64",
+		Tools:   map[string]string{},
+		EnvVars: map[string]string{"CFLAGS": "-O2"},
+	}
+	assert.Equal(t, []string{
+		"Platform: linux/amd64 (now darwin/arm64)",
+		"Tool cc: clang version 16.0.0 (now <not set>)",
+		"Environment variable ASAN_OPTIONS: detect_leaks=0 (now <not set>)",
+		"Environment variable CFLAGS: <not set> (now -O2)",
+	}, recorded.Diff(current))
+
+	// Findings created by older versions don't have an environment
+	var noEnvironment *Environment
+	assert.Empty(t, noEnvironment.Diff(current))
+}
+
+func TestCaptureEnvironment_Once(t *testing.T) {
+	env := CaptureEnvironment()
+	assert.Equal(t, runtime.GOOS, env.OS)
+	// The environment is only captured once, so that the tool versions
+	// are not determined again for every finding
+	assert.Same(t, env, CaptureEnvironment())
+}
@@ -3,8 +3,11 @@ package finding
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -13,6 +16,7 @@ import (
 	"github.com/otiai10/copy"
 	"github.com/pkg/errors"
 
+	"code-intelligence.com/cifuzz/pkg/corpus"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
 	"code-intelligence.com/cifuzz/util/fileutil"
@@ -23,8 +27,20 @@ const (
 	nameJSONFile      = "finding.json"
 	nameFindingsDir   = ".cifuzz-findings"
 	lockFile          = ".lock"
+
+	// Number of bytes of large crashing inputs which are stored in the
+	// JSON file of the finding as a preview
+	inputPreviewSize = 1024
 )
 
+var nonAlphanumericPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Crashing inputs which are larger than this are stored compressed in
+// the finding directory and the seed corpus, and only a preview of them
+// is stored in the JSON file of the finding, so that they don't blow up
+// git repositories and chat messages
+var compressionThreshold = 1 << 20
+
 type Finding struct {
 	Origin string
 
@@ -39,15 +55,82 @@ type Finding struct {
 
 	// Note: The following fields don't exist in the protobuf
 	// representation used in the Code Intelligence core repository.
-	CreatedAt  time.Time                `json:"created_at,omitempty"`
-	InputFile  string                   `json:"input_file,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	InputFile string    `json:"input_file,omitempty"`
+	// The size of the crashing input, which is only set if InputData
+	// only contains a preview of the input
+	InputSize  int                      `json:"input_size,omitempty"`
 	StackTrace []*stacktrace.StackFrame `json:"stack_trace,omitempty"`
+	// The normalized hash of the top stack frames, which is used to
+	// deduplicate findings with the same root cause
+	StackHash string `json:"stack_hash,omitempty"`
+	// Further crashing inputs which were found by later runs and
+	// trigger the same crash, relative to the project directory
+	AdditionalInputFiles []string `json:"additional_input_files,omitempty"`
+	// The environment in which the finding was found
+	Environment *Environment `json:"environment,omitempty"`
+	// The configuration of the fuzzing run which found the finding
+	RunConfig *RunConfig `json:"run_config,omitempty"`
+	// The lifecycle state of the finding, which is set via
+	// `cifuzz finding set-state` and `cifuzz reproduce`. Use GetState
+	// to get the state of findings without a state.
+	State State `json:"state,omitempty"`
+	// When `cifuzz reproduce` was last run on the finding
+	LastReproduced time.Time `json:"last_reproduced,omitempty"`
+	// The URL of the issue which was created for the finding via
+	// `cifuzz finding report`
+	IssueURL string `json:"issue_url,omitempty"`
 
 	seedPath string
 
 	// We also store the name of the fuzz test that found this finding so that
 	// we can show it in the finding overview.
 	FuzzTest string `json:"fuzz_test,omitempty"`
+	// The owner of the fuzz test as configured in cifuzz.yaml, which
+	// is set when listing findings, so that it's always up to date
+	Owner string `json:"owner,omitempty"`
+}
+
+// RunConfig contains the settings which are needed to build and run
+// the fuzz test in the same way as when the finding was found
+type RunConfig struct {
+	BuildSystem     string   `json:"build_system,omitempty"`
+	Sanitizers      []string `json:"sanitizers,omitempty"`
+	EngineArgs      []string `json:"engine_args,omitempty"`
+	BuildSystemArgs []string `json:"build_system_args,omitempty"`
+	TargetMethod    string   `json:"target_method,omitempty"`
+	TestNamePattern string   `json:"test_name_pattern,omitempty"`
+}
+
+type State string
+
+const (
+	// The finding was not triaged yet
+	StateNew State = "new"
+	// The finding was triaged and should be fixed
+	StateTriaged State = "triaged"
+	// The bug was fixed, or the crashing input doesn't trigger the
+	// crash anymore
+	StateFixed State = "fixed"
+	// The finding won't be fixed, e.g. because it's a false positive
+	StateWontFix State = "wontfix"
+)
+
+// The states in the order of the lifecycle of a finding
+var States = []State{StateNew, StateTriaged, StateFixed, StateWontFix}
+
+// ParseState parses a case-insensitive state like "triaged"
+func ParseState(s string) (State, error) {
+	for _, state := range States {
+		if strings.EqualFold(s, string(state)) {
+			return state, nil
+		}
+	}
+	var valid []string
+	for _, state := range States {
+		valid = append(valid, string(state))
+	}
+	return "", errors.Errorf("Invalid state %q, valid states are: %s", s, strings.Join(valid, ", "))
 }
 
 type ErrorType string
@@ -88,6 +171,50 @@ type Severity struct {
 	Score float32       `json:"score,omitempty"`
 }
 
+// The severity levels in ascending order
+var severityLevels = []SeverityLevel{
+	SeverityLevelLow,
+	SeverityLevelMedium,
+	SeverityLevelHigh,
+	SeverityLevelCritical,
+}
+
+// ParseSeverityLevel parses a case-insensitive severity level like
+// "medium"
+func ParseSeverityLevel(s string) (SeverityLevel, error) {
+	for _, level := range severityLevels {
+		if strings.EqualFold(s, string(level)) {
+			return level, nil
+		}
+	}
+	return "", errors.Errorf("Invalid severity level %q, valid levels are: low, medium, high, critical", s)
+}
+
+// IsHigherThan returns true if the severity level is higher than the
+// other one. Unknown levels are lower than all known levels.
+func (l SeverityLevel) IsHigherThan(other SeverityLevel) bool {
+	return slices.Index(severityLevels, l) > slices.Index(severityLevels, other)
+}
+
+// EffectiveLevel returns the level of the severity. If the level is
+// not set, it's derived from the score using the CVSS v3 ratings. An
+// empty level is returned if neither is set.
+func (s *Severity) EffectiveLevel() SeverityLevel {
+	switch {
+	case s.Level != "":
+		return s.Level
+	case s.Score >= 9.0:
+		return SeverityLevelCritical
+	case s.Score >= 7.0:
+		return SeverityLevelHigh
+	case s.Score >= 4.0:
+		return SeverityLevelMedium
+	case s.Score > 0:
+		return SeverityLevelLow
+	}
+	return ""
+}
+
 type ExternalDetail struct {
 	ID          int64  `json:"id,omitempty"`
 	Name        string `json:"name,omitempty"`
@@ -106,6 +233,15 @@ func (f *Finding) GetDetails() string {
 	return ""
 }
 
+// GetState returns the state of the finding, which is StateNew if the
+// state was never set
+func (f *Finding) GetState() State {
+	if f.State == "" {
+		return StateNew
+	}
+	return f.State
+}
+
 func (f *Finding) GetSeedPath() string {
 	if f != nil {
 		return f.seedPath
@@ -161,8 +297,108 @@ func (f *Finding) Remove(projectDir string) error {
 // CopyInputFileAndUpdateFinding copies the input file to the finding directory and
 // the seed corpus directory and adjusts the finding logs accordingly.
 func (f *Finding) CopyInputFileAndUpdateFinding(projectDir, seedCorpusDir string) error {
-	// Acquire a file lock to avoid races with other cifuzz processes
-	// running in parallel
+	return f.withLock(projectDir, func() error {
+		return f.copyInputFile(projectDir, seedCorpusDir, nameCrashingInput)
+	})
+}
+
+// AddDuplicate attaches the crashing input of the duplicate finding,
+// which triggers the same crash as f, to f. The input is copied to the
+// directory of f and the seed corpus directory, the fields of duplicate
+// are adjusted accordingly and f is saved.
+func (f *Finding) AddDuplicate(projectDir, seedCorpusDir string, duplicate *Finding) error {
+	return f.withLock(projectDir, func() error {
+		if duplicate.InputFile != "" {
+			inputName := nameCrashingInput + "-" + duplicate.Name
+			duplicate.Name = f.Name
+			err := duplicate.copyInputFile(projectDir, seedCorpusDir, inputName)
+			if err != nil {
+				return err
+			}
+			if !slices.Contains(f.AdditionalInputFiles, duplicate.InputFile) {
+				f.AdditionalInputFiles = append(f.AdditionalInputFiles, duplicate.InputFile)
+			}
+		}
+		return f.Save(projectDir)
+	})
+}
+
+// SaveWithInput stores the crashing input in the directory of the
+// finding, sets the InputFile field accordingly and saves the finding.
+// It's used for findings whose input doesn't exist as a file, like the
+// findings of remote runs.
+func (f *Finding) SaveWithInput(projectDir string, input []byte) error {
+	return f.withLock(projectDir, func() error {
+		path := filepath.Join(projectDir, nameFindingsDir, f.Name, nameCrashingInput)
+		err := os.WriteFile(path, input, 0o644)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if len(input) > compressionThreshold {
+			err = corpus.CompressFile(path, path+corpus.CompressedSuffix)
+			if err != nil {
+				return err
+			}
+			err = os.Remove(path)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			path += corpus.CompressedSuffix
+			f.InputSize = len(input)
+			if len(f.InputData) > inputPreviewSize {
+				f.InputData = f.InputData[:inputPreviewSize]
+			}
+		}
+
+		pathRelativeToProjectDir, err := filepath.Rel(projectDir, path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		f.InputFile = filepath.ToSlash(pathRelativeToProjectDir)
+		return f.Save(projectDir)
+	})
+}
+
+// FindDuplicate returns the local finding of the same fuzz test which
+// has the same stack hash as f. A finding of the same name is preferred,
+// followed by the oldest one. If there is no such finding or f has no
+// stack hash, nil is returned.
+func FindDuplicate(projectDir string, f *Finding) (*Finding, error) {
+	if f.StackHash == "" {
+		return nil, nil
+	}
+	findings, err := LocalFindings(projectDir, nil)
+	if err != nil {
+		return nil, err
+	}
+	var duplicate *Finding
+	for _, existing := range findings {
+		if existing.FuzzTest != f.FuzzTest || existing.Signature() != f.StackHash {
+			continue
+		}
+		if existing.Name == f.Name {
+			return existing, nil
+		}
+		// The findings are sorted starting with the newest
+		duplicate = existing
+	}
+	return duplicate, nil
+}
+
+// Signature returns the stack hash of the finding, which identifies
+// its root cause
+func (f *Finding) Signature() string {
+	if f.StackHash != "" {
+		return f.StackHash
+	}
+	// The finding was created before stack hashes were stored
+	return stacktrace.StackHash(string(f.Type), f.StackTrace)
+}
+
+// withLock calls fn while holding a file lock on the directory of the
+// finding, to avoid races with other cifuzz processes running in
+// parallel
+func (f *Finding) withLock(projectDir string, fn func() error) error {
 	findingDir := filepath.Join(projectDir, nameFindingsDir, f.Name)
 	err := os.MkdirAll(findingDir, 0o755)
 	if err != nil {
@@ -178,8 +414,7 @@ func (f *Finding) CopyInputFileAndUpdateFinding(projectDir, seedCorpusDir string
 		return errors.WithStack(err)
 	}
 
-	// Actually copy the input file
-	err = f.copyInputFile(projectDir, seedCorpusDir)
+	err = fn()
 
 	// Release the file lock
 	unlockErr := mutex.Close()
@@ -192,17 +427,32 @@ func (f *Finding) CopyInputFileAndUpdateFinding(projectDir, seedCorpusDir string
 	return err
 }
 
-func (f *Finding) copyInputFile(projectDir, seedCorpusDir string) error {
+func (f *Finding) copyInputFile(projectDir, seedCorpusDir, inputName string) error {
 	findingDir := filepath.Join(projectDir, nameFindingsDir, f.Name)
-	path := filepath.Join(findingDir, nameCrashingInput)
+	path := filepath.Join(findingDir, inputName)
 
-	// Copy the input file to the finding dir. We don't use os.Rename to
-	// avoid errors when source and target are not on the same mounted
-	// filesystem.
-	err := copy.Copy(f.InputFile, path)
+	info, err := os.Stat(f.InputFile)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	compress := info.Size() > int64(compressionThreshold)
+	copyInput := func(dest string) error {
+		if compress {
+			return corpus.CompressFile(f.InputFile, dest)
+		}
+		// We don't use os.Rename to avoid errors when source and
+		// target are not on the same mounted filesystem.
+		return errors.WithStack(copy.Copy(f.InputFile, dest))
+	}
+	if compress {
+		path += corpus.CompressedSuffix
+	}
+
+	// Copy the input file to the finding dir.
+	err = copyInput(path)
+	if err != nil {
+		return err
+	}
 
 	// Copy the input file to the seed corpus dir.
 	err = os.MkdirAll(seedCorpusDir, 0o755)
@@ -212,12 +462,23 @@ func (f *Finding) copyInputFile(projectDir, seedCorpusDir string) error {
 	// Different inputs can result in the same finding, so we append the
 	// original basename to avoid basename collisions.
 	f.seedPath = filepath.Join(seedCorpusDir, f.Name+"-"+filepath.Base(f.InputFile))
-	err = copy.Copy(f.InputFile, f.seedPath)
+	if compress {
+		f.seedPath += corpus.CompressedSuffix
+	}
+	err = copyInput(f.seedPath)
 	if err != nil {
-		return errors.WithStack(err)
+		return err
 	}
 	log.Debugf("Copied input file from %s to %s", f.InputFile, f.seedPath)
 
+	if compress {
+		log.Infof("The crashing input is larger than %d bytes, so it's stored compressed", compressionThreshold)
+		f.InputSize = int(info.Size())
+		if len(f.InputData) > inputPreviewSize {
+			f.InputData = f.InputData[:inputPreviewSize]
+		}
+	}
+
 	// Replace the old filename in the finding logs. Replace it with the
 	// relative path to not leak the directory structure of the current
 	// user in the finding logs (which might be shared with others).
@@ -235,15 +496,54 @@ func (f *Finding) copyInputFile(projectDir, seedCorpusDir string) error {
 	log.Debugf("Copied input file from %s to %s", f.InputFile, path)
 
 	// The path in the InputFile field is expected to be relative to the
-	// project directory
+	// project directory, with forward slashes so that findings from
+	// different OSes are comparable
 	pathRelativeToProjectDir, err := filepath.Rel(projectDir, path)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	f.InputFile = pathRelativeToProjectDir
+	f.InputFile = filepath.ToSlash(pathRelativeToProjectDir)
 	return nil
 }
 
+// NormalizePaths converts the source files in the stack trace to paths
+// relative to the project directory with forward slashes, so that
+// findings from different OSes are comparable. The absolute paths are
+// kept in AbsoluteSourceFile.
+func (f *Finding) NormalizePaths(projectDir string) {
+	for _, frame := range f.StackTrace {
+		if fileutil.IsAbs(frame.SourceFile) {
+			frame.AbsoluteSourceFile = frame.SourceFile
+		}
+		frame.SourceFile = fileutil.ProjectRelativePath(projectDir, frame.SourceFile)
+	}
+}
+
+// IsInputTruncated returns whether InputData only contains a preview
+// of the crashing input, because the input is too large
+func (f *Finding) IsInputTruncated() bool {
+	return f.InputSize > len(f.InputData)
+}
+
+// ReadInput returns the complete crashing input of the finding. If
+// only a preview of the input is stored in the finding, the input is
+// read from the input file, which is decompressed if necessary.
+func (f *Finding) ReadInput(projectDir string) ([]byte, error) {
+	if !f.IsInputTruncated() {
+		return f.InputData, nil
+	}
+	r, err := corpus.OpenEntry(filepath.Join(projectDir, filepath.FromSlash(f.InputFile)))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return data, nil
+}
+
 func (f *Finding) SourceLocation() string {
 	if f.StackTrace != nil && len(f.StackTrace) > 0 {
 		stackFrame := f.StackTrace[0]
@@ -307,6 +607,21 @@ func (f *Finding) ShortDescriptionColumns() []string {
 	return columns
 }
 
+// KindID returns an ID of the kind of the finding, e.g.
+// "heap-buffer-overflow", which is used as the rule ID in reports. If
+// the finding has error details, their ID is used.
+func (f *Finding) KindID() string {
+	if f.MoreDetails != nil && f.MoreDetails.ID != "" {
+		return f.MoreDetails.ID
+	}
+	description := f.ShortDescriptionColumns()[0]
+	id := strings.Trim(nonAlphanumericPattern.ReplaceAllString(strings.ToLower(description), "-"), "-")
+	if id == "" {
+		return "unknown-error"
+	}
+	return id
+}
+
 // LocalFindings parses the JSON files of all findings and returns the
 // result.
 func LocalFindings(projectDir string, errorDetails []*ErrorDetails) ([]*Finding, error) {
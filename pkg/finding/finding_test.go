@@ -1,15 +1,19 @@
 package finding
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"code-intelligence.com/cifuzz/internal/testutil"
+	"code-intelligence.com/cifuzz/pkg/corpus"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
 	"code-intelligence.com/cifuzz/util/stringutil"
 )
 
@@ -77,6 +81,133 @@ func TestFinding_MoveInputFile(t *testing.T) {
 	assert.Contains(t, finding.Logs[2], nameCrashingInput)
 }
 
+func TestFinding_MoveInputFile_Compressed(t *testing.T) {
+	origThreshold := compressionThreshold
+	defer func() { compressionThreshold = origThreshold }()
+	compressionThreshold = 2 * inputPreviewSize
+
+	testBaseDir := testutil.ChdirToTempDir(t, "finding-test-")
+	projectDir := testutil.MkdirTemp(t, testBaseDir, "move-test-project-dir-")
+	seedCorpusDir := testutil.MkdirTemp(t, testBaseDir, "move-test-seed-corpus-")
+
+	input := bytes.Repeat([]byte("A"), 3*inputPreviewSize)
+	testfile := "crash_123_test"
+	err := os.WriteFile(testfile, input, 0644)
+	require.NoError(t, err)
+
+	finding := testFinding()
+	finding.InputFile = testfile
+	finding.InputData = input
+	err = finding.CopyInputFileAndUpdateFinding(projectDir, seedCorpusDir)
+	require.NoError(t, err)
+
+	// The input is stored compressed and the finding only contains a
+	// preview of it
+	assert.Equal(t, nameFindingsDir+"/"+finding.Name+"/"+nameCrashingInput+corpus.CompressedSuffix, finding.InputFile)
+	assert.FileExists(t, filepath.Join(projectDir, filepath.FromSlash(finding.InputFile)))
+	assert.True(t, strings.HasSuffix(finding.GetSeedPath(), corpus.CompressedSuffix))
+	assert.FileExists(t, finding.GetSeedPath())
+	assert.True(t, finding.IsInputTruncated())
+	assert.Len(t, finding.InputData, inputPreviewSize)
+	assert.Equal(t, len(input), finding.InputSize)
+
+	data, err := finding.ReadInput(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, input, data)
+}
+
+func TestFinding_SaveWithInput(t *testing.T) {
+	origThreshold := compressionThreshold
+	defer func() { compressionThreshold = origThreshold }()
+	compressionThreshold = 2 * inputPreviewSize
+
+	projectDir := testutil.MkdirTemp(t, "", "save-with-input-test-")
+
+	finding := testFinding()
+	err := finding.SaveWithInput(projectDir, []byte("input"))
+	require.NoError(t, err)
+	assert.Equal(t, nameFindingsDir+"/"+finding.Name+"/"+nameCrashingInput, finding.InputFile)
+	data, err := os.ReadFile(filepath.Join(projectDir, filepath.FromSlash(finding.InputFile)))
+	require.NoError(t, err)
+	assert.Equal(t, "input", string(data))
+	loadedFinding, err := LoadFinding(projectDir, finding.Name, nil)
+	require.NoError(t, err)
+	assert.Equal(t, finding.InputFile, loadedFinding.InputFile)
+
+	// Large inputs are stored compressed
+	input := bytes.Repeat([]byte("A"), 3*inputPreviewSize)
+	finding = testFinding()
+	finding.Name = "large_input"
+	finding.InputData = input
+	err = finding.SaveWithInput(projectDir, input)
+	require.NoError(t, err)
+	assert.Equal(t, nameFindingsDir+"/"+finding.Name+"/"+nameCrashingInput+corpus.CompressedSuffix, finding.InputFile)
+	assert.True(t, finding.IsInputTruncated())
+	data, err = finding.ReadInput(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, input, data)
+}
+
+func TestFinding_AddDuplicate(t *testing.T) {
+	testBaseDir := testutil.ChdirToTempDir(t, "finding-test-")
+	projectDir := testutil.MkdirTemp(t, testBaseDir, "duplicate-test-project-dir-")
+	seedCorpusDir := testutil.MkdirTemp(t, testBaseDir, "duplicate-test-seed-corpus-")
+
+	existing := testFinding()
+	existing.StackHash = "1234"
+	err := existing.Save(projectDir)
+	require.NoError(t, err)
+
+	testfile := "crash_456_test"
+	err = os.WriteFile(testfile, []byte("other input"), 0644)
+	require.NoError(t, err)
+	duplicate := testFinding()
+	duplicate.Name = "other_name"
+	duplicate.StackHash = existing.StackHash
+	duplicate.InputFile = testfile
+
+	found, err := FindDuplicate(projectDir, duplicate)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, existing.Name, found.Name)
+
+	err = found.AddDuplicate(projectDir, seedCorpusDir, duplicate)
+	require.NoError(t, err)
+
+	// The input was added to the existing finding and no new finding
+	// was created
+	expectedInputFile := nameFindingsDir + "/" + existing.Name + "/" + nameCrashingInput + "-other_name"
+	assert.Equal(t, expectedInputFile, duplicate.InputFile)
+	assert.FileExists(t, filepath.Join(projectDir, filepath.FromSlash(expectedInputFile)))
+	assert.FileExists(t, duplicate.GetSeedPath())
+	findings, err := LocalFindings(projectDir, nil)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, []string{expectedInputFile}, findings[0].AdditionalInputFiles)
+
+	// Findings with a different stack hash are not duplicates
+	duplicate.StackHash = "5678"
+	found, err = FindDuplicate(projectDir, duplicate)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func TestFinding_NormalizePaths(t *testing.T) {
+	f := &Finding{StackTrace: []*stacktrace.StackFrame{
+		{SourceFile: `C:\Users\dev\project\src\parser.cpp`, Line: 12},
+		{SourceFile: `C:\Program Files\LLVM\include\string`, Line: 3},
+		{SourceFile: "src/main.cpp", Line: 7},
+	}}
+
+	f.NormalizePaths(`C:\Users\dev\project`)
+	assert.Equal(t, "src/parser.cpp", f.StackTrace[0].SourceFile)
+	assert.Equal(t, `C:\Users\dev\project\src\parser.cpp`, f.StackTrace[0].AbsoluteSourceFile)
+	assert.Equal(t, "C:/Program Files/LLVM/include/string", f.StackTrace[1].SourceFile)
+	assert.Equal(t, `C:\Program Files\LLVM\include\string`, f.StackTrace[1].AbsoluteSourceFile)
+	assert.Equal(t, "src/main.cpp", f.StackTrace[2].SourceFile)
+	assert.Empty(t, f.StackTrace[2].AbsoluteSourceFile)
+}
+
 func TestGetLocalFindings(t *testing.T) {
 	testBaseDir := testutil.ChdirToTempDir(t, "finding-test-")
 	finding := testFinding()
@@ -91,6 +222,23 @@ func TestGetLocalFindings(t *testing.T) {
 	require.Equal(t, finding, findings[0])
 }
 
+func TestSeverityLevel(t *testing.T) {
+	level, err := ParseSeverityLevel("medium")
+	require.NoError(t, err)
+	assert.Equal(t, SeverityLevelMedium, level)
+	_, err = ParseSeverityLevel("severe")
+	require.Error(t, err)
+
+	assert.True(t, SeverityLevelHigh.IsHigherThan(SeverityLevelMedium))
+	assert.False(t, SeverityLevelMedium.IsHigherThan(SeverityLevelMedium))
+	assert.False(t, SeverityLevel("").IsHigherThan(SeverityLevelLow))
+
+	assert.Equal(t, SeverityLevelLow, (&Severity{Level: SeverityLevelLow, Score: 9.8}).EffectiveLevel())
+	assert.Equal(t, SeverityLevelCritical, (&Severity{Score: 9.8}).EffectiveLevel())
+	assert.Equal(t, SeverityLevelMedium, (&Severity{Score: 4.0}).EffectiveLevel())
+	assert.Equal(t, SeverityLevel(""), (&Severity{}).EffectiveLevel())
+}
+
 func testFinding() *Finding {
 	return &Finding{
 		Origin: "Local",
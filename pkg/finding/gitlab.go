@@ -0,0 +1,171 @@
+package finding
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	gitLabReportVersion = "15.0.7"
+	// GitLab expects timestamps without time zone
+	gitLabTimeFormat = "2006-01-02T15:04:05"
+)
+
+// The subset of the GitLab security report format which we use, see
+// https://gitlab.com/gitlab-org/security-products/security-report-schemas/-/blob/master/dist/sast-report-format.json
+type gitLabReport struct {
+	Version         string                 `json:"version"`
+	Scan            gitLabScan             `json:"scan"`
+	Vulnerabilities []*gitLabVulnerability `json:"vulnerabilities"`
+}
+
+type gitLabScan struct {
+	Analyzer  gitLabTool `json:"analyzer"`
+	Scanner   gitLabTool `json:"scanner"`
+	Type      string     `json:"type"`
+	StartTime string     `json:"start_time"`
+	EndTime   string     `json:"end_time"`
+	Status    string     `json:"status"`
+}
+
+type gitLabTool struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name"`
+	Version string       `json:"version"`
+	Vendor  gitLabVendor `json:"vendor"`
+}
+
+type gitLabVendor struct {
+	Name string `json:"name"`
+}
+
+type gitLabVulnerability struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Severity    string              `json:"severity"`
+	Solution    string              `json:"solution,omitempty"`
+	Location    gitLabLocation      `json:"location"`
+	Identifiers []*gitLabIdentifier `json:"identifiers"`
+	Links       []*gitLabLink       `json:"links,omitempty"`
+}
+
+type gitLabLocation struct {
+	File      string `json:"file,omitempty"`
+	StartLine uint32 `json:"start_line,omitempty"`
+	Method    string `json:"method,omitempty"`
+}
+
+type gitLabIdentifier struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+type gitLabLink struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url"`
+}
+
+// WriteGitLabSAST writes the findings to w as a GitLab SAST report,
+// which GitLab shows in the security widget of merge requests
+func WriteGitLabSAST(w io.Writer, findings []*Finding, toolVersion string, startTime, endTime time.Time) error {
+	tool := gitLabTool{
+		ID:      "cifuzz",
+		Name:    "cifuzz",
+		Version: toolVersion,
+		Vendor:  gitLabVendor{Name: "Code Intelligence"},
+	}
+	r := &gitLabReport{
+		Version: gitLabReportVersion,
+		Scan: gitLabScan{
+			Analyzer:  tool,
+			Scanner:   tool,
+			Type:      "sast",
+			StartTime: startTime.UTC().Format(gitLabTimeFormat),
+			EndTime:   endTime.UTC().Format(gitLabTimeFormat),
+			Status:    "success",
+		},
+		Vulnerabilities: []*gitLabVulnerability{},
+	}
+	for _, f := range findings {
+		r.Vulnerabilities = append(r.Vulnerabilities, f.gitLabVulnerability())
+	}
+
+	bytes, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = fmt.Fprintln(w, string(bytes))
+	return errors.WithStack(err)
+}
+
+func (f *Finding) gitLabVulnerability() *gitLabVulnerability {
+	v := &gitLabVulnerability{
+		// The ID must be unique within the report
+		ID:          f.Name,
+		Name:        f.ShortDescriptionColumns()[0],
+		Description: fmt.Sprintf("%s (found by fuzz test %s)\n\n%s", f.ShortDescriptionWithName(), f.FuzzTest, strings.Join(f.Logs, "\n")),
+		Severity:    "Unknown",
+		Identifiers: []*gitLabIdentifier{{
+			Type:  "cifuzz",
+			Name:  f.ShortDescriptionColumns()[0],
+			Value: f.KindID(),
+		}},
+	}
+	if len(f.StackTrace) > 0 {
+		sf := f.StackTrace[0]
+		v.Location = gitLabLocation{
+			File:      filepath.ToSlash(sf.SourceFile),
+			StartLine: sf.Line,
+			Method:    sf.Function,
+		}
+	}
+
+	if d := f.MoreDetails; d != nil {
+		if d.Name != "" {
+			v.Name = d.Name
+		}
+		if d.Description != "" {
+			v.Description = d.Description + "\n\n" + v.Description
+		}
+		v.Solution = d.Mitigation
+		if d.Severity != nil {
+			v.Severity = gitLabSeverity(d.Severity.EffectiveLevel())
+		}
+		if d.CweDetails != nil && d.CweDetails.ID != 0 {
+			v.Identifiers = append(v.Identifiers, &gitLabIdentifier{
+				Type:  "cwe",
+				Name:  fmt.Sprintf("CWE-%d", d.CweDetails.ID),
+				Value: fmt.Sprintf("%d", d.CweDetails.ID),
+				URL:   fmt.Sprintf("https://cwe.mitre.org/data/definitions/%d.html", d.CweDetails.ID),
+			})
+		}
+		for _, link := range d.Links {
+			v.Links = append(v.Links, &gitLabLink{Name: link.Description, URL: link.URL})
+		}
+	}
+	return v
+}
+
+func gitLabSeverity(level SeverityLevel) string {
+	switch level {
+	case SeverityLevelCritical:
+		return "Critical"
+	case SeverityLevelHigh:
+		return "High"
+	case SeverityLevelMedium:
+		return "Medium"
+	case SeverityLevelLow:
+		return "Low"
+	default:
+		return "Unknown"
+	}
+}
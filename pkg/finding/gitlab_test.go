@@ -0,0 +1,50 @@
+package finding
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+)
+
+func TestWriteGitLabSAST(t *testing.T) {
+	f := &Finding{
+		Name:     "funny_elephant",
+		Type:     ErrorTypeCrash,
+		Details:  "heap-buffer-overflow",
+		FuzzTest: "my_fuzz_test",
+		StackTrace: []*stacktrace.StackFrame{
+			{SourceFile: "src/explore_me.cpp", Line: 13, Column: 11, Function: "exploreMe"},
+		},
+		MoreDetails: &ErrorDetails{
+			ID:         "heap_buffer_overflow",
+			Name:       "Heap Buffer Overflow",
+			Severity:   &Severity{Score: 9.5},
+			CweDetails: &ExternalDetail{ID: 122},
+		},
+	}
+
+	startTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	var out bytes.Buffer
+	err := WriteGitLabSAST(&out, []*Finding{f}, "1.0.0", startTime, startTime.Add(time.Minute))
+	require.NoError(t, err)
+
+	var r gitLabReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &r))
+	assert.Equal(t, "sast", r.Scan.Type)
+	assert.Equal(t, "2023-01-02T03:04:05", r.Scan.StartTime)
+	assert.Equal(t, "1.0.0", r.Scan.Scanner.Version)
+	require.Len(t, r.Vulnerabilities, 1)
+	v := r.Vulnerabilities[0]
+	assert.Equal(t, "Heap Buffer Overflow", v.Name)
+	assert.Equal(t, "Critical", v.Severity)
+	assert.Equal(t, gitLabLocation{File: "src/explore_me.cpp", StartLine: 13, Method: "exploreMe"}, v.Location)
+	require.Len(t, v.Identifiers, 2)
+	assert.Equal(t, "heap_buffer_overflow", v.Identifiers[0].Value)
+	assert.Equal(t, "CWE-122", v.Identifiers[1].Name)
+}
@@ -0,0 +1,110 @@
+package finding
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// A FuzzTestResult is the result of running a single fuzz test
+type FuzzTestResult struct {
+	FuzzTest string
+	Duration time.Duration
+	// The findings which fail the fuzz test
+	Findings []*Finding
+	// The findings which are listed in the baseline and therefore
+	// don't fail the fuzz test
+	KnownFindings []*Finding
+}
+
+// The subset of the JUnit XML format which is supported by Jenkins and
+// GitLab, see https://github.com/testmoapp/junitxml
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Time     string            `xml:"time,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Time      string           `xml:"time,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the results to w as a JUnit XML report with one
+// test case per fuzz test, which fails if the fuzz test has findings
+func WriteJUnit(w io.Writer, results []*FuzzTestResult) error {
+	suite := &junitTestSuite{Name: "cifuzz"}
+	var duration time.Duration
+	for _, r := range results {
+		testCase := &junitTestCase{
+			Name:      r.FuzzTest,
+			ClassName: "cifuzz",
+			Time:      junitTime(r.Duration),
+		}
+		if len(r.Findings) > 0 {
+			var descriptions, details []string
+			for _, f := range r.Findings {
+				descriptions = append(descriptions, f.ShortDescriptionWithName())
+				details = append(details, f.ShortDescriptionWithName()+"\n\n  "+strings.Join(f.Logs, "\n  "))
+			}
+			testCase.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d findings: %s", len(r.Findings), strings.Join(descriptions, ", ")),
+				Type:    "finding",
+				Text:    strings.Join(details, "\n\n"),
+			}
+			suite.Failures++
+		}
+		if len(r.KnownFindings) > 0 {
+			var descriptions []string
+			for _, f := range r.KnownFindings {
+				descriptions = append(descriptions, f.ShortDescriptionWithName())
+			}
+			testCase.SystemOut = "Findings known from the baseline:\n" + strings.Join(descriptions, "\n")
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+		suite.Tests++
+		duration += r.Duration
+	}
+	suite.Time = junitTime(duration)
+
+	bytes, err := xml.MarshalIndent(&junitTestSuites{
+		Name:     suite.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []*junitTestSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, bytes)
+	return errors.WithStack(err)
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,47 @@
+package finding
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	results := []*FuzzTestResult{
+		{
+			FuzzTest: "my_fuzz_test",
+			Duration: 1500 * time.Millisecond,
+			Findings: []*Finding{{
+				Name:    "funny_elephant",
+				Type:    ErrorTypeCrash,
+				Details: "heap-buffer-overflow",
+				Logs:    []string{"ERROR: AddressSanitizer: heap-buffer-overflow"},
+			}},
+		},
+		{
+			FuzzTest:      "other_fuzz_test",
+			Duration:      time.Second,
+			KnownFindings: []*Finding{{Name: "silly_giraffe", Type: ErrorTypeCrash, Details: "deadly-signal"}},
+		},
+	}
+
+	var out bytes.Buffer
+	err := WriteJUnit(&out, results)
+	require.NoError(t, err)
+	report := out.String()
+	assert.Contains(t, report, `<testsuites name="cifuzz" tests="2" failures="1" time="2.500">`)
+	assert.Contains(t, report, `<testcase name="my_fuzz_test" classname="cifuzz" time="1.500">`)
+	assert.Contains(t, report, `<failure message="1 findings: [funny_elephant] heap buffer overflow" type="finding">`)
+
+	var parsed junitTestSuites
+	require.NoError(t, xml.Unmarshal(out.Bytes(), &parsed))
+	testCases := parsed.Suites[0].TestCases
+	require.Len(t, testCases, 2)
+	assert.Contains(t, testCases[0].Failure.Text, "ERROR: AddressSanitizer: heap-buffer-overflow")
+	assert.Nil(t, testCases[1].Failure)
+	assert.Equal(t, "Findings known from the baseline:\n[silly_giraffe] deadly signal", testCases[1].SystemOut)
+}
@@ -0,0 +1,255 @@
+package finding
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	// The base URI of the source files in the stack traces, which are
+	// relative to the project directory
+	sarifSourceRoot = "%SRCROOT%"
+)
+
+// The subset of the SARIF 2.1.0 format which we use, see
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool      `json:"tool"`
+	Results []*sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	Version        string       `json:"version,omitempty"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string          `json:"id"`
+	Name             string          `json:"name,omitempty"`
+	ShortDescription *sarifMessage   `json:"shortDescription,omitempty"`
+	FullDescription  *sarifMessage   `json:"fullDescription,omitempty"`
+	Help             *sarifMessage   `json:"help,omitempty"`
+	HelpURI          string          `json:"helpUri,omitempty"`
+	Properties       *sarifRuleProps `json:"properties,omitempty"`
+}
+
+type sarifRuleProps struct {
+	Tags []string `json:"tags,omitempty"`
+	// The CVSS score, which GitHub code scanning uses to determine
+	// the severity of security issues
+	SecuritySeverity string `json:"security-severity,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []*sarifLocation  `json:"locations,omitempty"`
+	Stacks              []*sarifStack     `json:"stacks,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type sarifStack struct {
+	Message *sarifMessage      `json:"message,omitempty"`
+	Frames  []*sarifStackFrame `json:"frames"`
+}
+
+type sarifStackFrame struct {
+	Location *sarifLocation `json:"location"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation  `json:"physicalLocation,omitempty"`
+	LogicalLocations []*sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   uint32 `json:"startLine"`
+	StartColumn uint32 `json:"startColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// WriteSARIF writes the findings to w as a SARIF 2.1.0 log, which can be
+// uploaded to GitHub code scanning and other SARIF consumers. Findings
+// of the same kind share a rule. Source files are referenced relative to
+// the project directory.
+func WriteSARIF(w io.Writer, findings []*Finding, projectDir string, toolVersion string) error {
+	run := &sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "cifuzz",
+			Version:        toolVersion,
+			InformationURI: "https://github.com/CodeIntelligenceTesting/cifuzz",
+			Rules:          []*sarifRule{},
+		}},
+		Results: []*sarifResult{},
+	}
+
+	ruleIndices := map[string]int{}
+	for _, f := range findings {
+		rule := f.sarifRule()
+		index, ok := ruleIndices[rule.ID]
+		if !ok {
+			index = len(run.Tool.Driver.Rules)
+			ruleIndices[rule.ID] = index
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+		}
+		run.Results = append(run.Results, f.sarifResult(rule.ID, index, projectDir))
+	}
+
+	bytes, err := json.MarshalIndent(&sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []*sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = fmt.Fprintln(w, string(bytes))
+	return errors.WithStack(err)
+}
+
+func (f *Finding) sarifRule() *sarifRule {
+	rule := &sarifRule{
+		ID:               f.KindID(),
+		ShortDescription: &sarifMessage{Text: f.ShortDescriptionColumns()[0]},
+		Properties:       &sarifRuleProps{Tags: []string{"security", "fuzzing"}},
+	}
+
+	if d := f.MoreDetails; d != nil {
+		rule.Name = d.Name
+		if d.Description != "" {
+			rule.FullDescription = &sarifMessage{Text: d.Description}
+		}
+		if d.Mitigation != "" {
+			rule.Help = &sarifMessage{Text: d.Mitigation}
+		}
+		if len(d.Links) > 0 {
+			rule.HelpURI = d.Links[0].URL
+		}
+		if d.Severity != nil && d.Severity.Score > 0 {
+			rule.Properties.SecuritySeverity = fmt.Sprintf("%.1f", d.Severity.Score)
+		}
+	}
+	return rule
+}
+
+func (f *Finding) sarifResult(ruleID string, ruleIndex int, projectDir string) *sarifResult {
+	level := "error"
+	if f.Type == ErrorTypeWarning {
+		level = "warning"
+	}
+	result := &sarifResult{
+		RuleID:    ruleID,
+		RuleIndex: ruleIndex,
+		Level:     level,
+		Message:   sarifMessage{Text: fmt.Sprintf("%s (found by fuzz test %s)", f.ShortDescriptionWithName(), f.FuzzTest)},
+	}
+
+	var frames []*sarifStackFrame
+	for _, sf := range f.StackTrace {
+		location := sarifStackFrameLocation(sf, projectDir)
+		if location != nil {
+			frames = append(frames, &sarifStackFrame{Location: location})
+		}
+	}
+	if len(frames) > 0 {
+		// The top stack frame is the location of the crash
+		result.Locations = []*sarifLocation{frames[0].Location}
+		result.Stacks = []*sarifStack{{
+			Message: &sarifMessage{Text: "Stack trace"},
+			Frames:  frames,
+		}}
+	}
+
+	// Allows SARIF consumers to track the finding across runs, even if
+	// the source lines change
+	if signature := f.Signature(); signature != "" {
+		result.PartialFingerprints = map[string]string{"stackHash/v1": signature}
+	}
+	return result
+}
+
+// sarifStackFrameLocation returns the location of the stack frame, or
+// nil if it has neither a source file nor a function
+func sarifStackFrameLocation(sf *stacktrace.StackFrame, projectDir string) *sarifLocation {
+	location := &sarifLocation{}
+	if sf.SourceFile != "" {
+		location.PhysicalLocation = &sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocationOf(sf.SourceFile, projectDir),
+		}
+		// Lines and columns are 1-based in SARIF, so 0 means the line
+		// is unknown
+		if sf.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: sf.Line, StartColumn: sf.Column}
+		}
+	}
+	if sf.Function != "" {
+		location.LogicalLocations = []*sarifLogicalLocation{{FullyQualifiedName: sf.Function}}
+	}
+	if location.PhysicalLocation == nil && location.LogicalLocations == nil {
+		return nil
+	}
+	return location
+}
+
+// sarifArtifactLocationOf returns the location of the source file
+// relative to the project directory. Files outside of the project
+// directory, like system headers, are referenced via absolute file
+// URIs, because they can't be resolved relative to the source root.
+func sarifArtifactLocationOf(path string, projectDir string) sarifArtifactLocation {
+	path = fileutil.ProjectRelativePath(projectDir, path)
+	if !fileutil.IsAbs(path) {
+		return sarifArtifactLocation{URI: path, URIBaseID: sarifSourceRoot}
+	}
+	u := &url.URL{Scheme: "file", Path: path}
+	if uncPath, ok := strings.CutPrefix(path, "//"); ok {
+		// UNC paths like //server/share/file.c
+		host, rest, _ := strings.Cut(uncPath, "/")
+		u.Host = host
+		u.Path = "/" + rest
+	} else if !strings.HasPrefix(path, "/") {
+		// Windows paths like C:/file.c
+		u.Path = "/" + path
+	}
+	return sarifArtifactLocation{URI: u.String()}
+}
@@ -0,0 +1,83 @@
+package finding
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	f1 := &Finding{
+		Name:     "funny_elephant",
+		Type:     ErrorTypeCrash,
+		Details:  "heap-buffer-overflow on address 0x1234",
+		FuzzTest: "my_fuzz_test",
+		StackTrace: []*stacktrace.StackFrame{
+			{SourceFile: "src/explore_me.cpp", Line: 13, Column: 11, Function: "exploreMe"},
+			{SourceFile: "/project/my_fuzz_test.cpp", Line: 18, Column: 3, Function: "LLVMFuzzerTestOneInputNoReturn"},
+			{SourceFile: "/usr/include/c++/12/bits/std_function.h", Line: 290, Function: "std::function<void ()>::operator()() const"},
+			{SourceFile: `C:\Program Files\LLVM\lib\FuzzerLoop.cpp`, Line: 611, Function: "fuzzer::Fuzzer::ExecuteCallback"},
+		},
+		MoreDetails: &ErrorDetails{
+			ID:       "heap_buffer_overflow",
+			Name:     "Heap Buffer Overflow",
+			Severity: &Severity{Score: 9},
+		},
+	}
+	f2 := &Finding{
+		Name:     "silly_giraffe",
+		Type:     ErrorTypeCrash,
+		Details:  "heap-buffer-overflow on address 0x5678",
+		FuzzTest: "my_fuzz_test",
+		MoreDetails: &ErrorDetails{
+			ID: "heap_buffer_overflow",
+		},
+	}
+
+	var out bytes.Buffer
+	err := WriteSARIF(&out, []*Finding{f1, f2}, "/project", "1.0.0")
+	require.NoError(t, err)
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(out.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "1.0.0", run.Tool.Driver.Version)
+
+	// Both findings share a rule
+	require.Len(t, run.Tool.Driver.Rules, 1)
+	assert.Equal(t, "heap_buffer_overflow", run.Tool.Driver.Rules[0].ID)
+	assert.Equal(t, "9.0", run.Tool.Driver.Rules[0].Properties.SecuritySeverity)
+
+	require.Len(t, run.Results, 2)
+	result := run.Results[0]
+	assert.Equal(t, "heap_buffer_overflow", result.RuleID)
+	assert.Equal(t, "error", result.Level)
+	require.Len(t, result.Locations, 1)
+	assert.Equal(t, "src/explore_me.cpp", result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, uint32(13), result.Locations[0].PhysicalLocation.Region.StartLine)
+	require.Len(t, result.Stacks, 1)
+	frames := result.Stacks[0].Frames
+	require.Len(t, frames, 4)
+	// Files in the project are relative to the source root, other files
+	// are referenced via absolute URIs
+	assert.Equal(t, sarifArtifactLocation{URI: "src/explore_me.cpp", URIBaseID: sarifSourceRoot}, frames[0].Location.PhysicalLocation.ArtifactLocation)
+	assert.Equal(t, sarifArtifactLocation{URI: "my_fuzz_test.cpp", URIBaseID: sarifSourceRoot}, frames[1].Location.PhysicalLocation.ArtifactLocation)
+	assert.Equal(t, sarifArtifactLocation{URI: "file:///usr/include/c++/12/bits/std_function.h"}, frames[2].Location.PhysicalLocation.ArtifactLocation)
+	assert.Equal(t, sarifArtifactLocation{URI: "file:///C:/Program%20Files/LLVM/lib/FuzzerLoop.cpp"}, frames[3].Location.PhysicalLocation.ArtifactLocation)
+	assert.Equal(t, stacktrace.StackHash(string(f1.Type), f1.StackTrace), result.PartialFingerprints["stackHash/v1"])
+
+	// Findings without a stack trace have no location
+	assert.Empty(t, run.Results[1].Locations)
+}
+
+func TestWriteSARIF_RuleIDFromDescription(t *testing.T) {
+	f := &Finding{Type: ErrorTypeCrash, Details: "Security Issue: Remote Code Execution"}
+	assert.Equal(t, "security-issue-remote-code-execution", f.sarifRule().ID)
+}
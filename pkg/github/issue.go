@@ -0,0 +1,120 @@
+// Package github creates issues via the REST API of GitHub
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultAPIURL = "https://api.github.com"
+
+var requestTimeout = 30 * time.Second
+
+// Matches the owner and the name of the repository in the URLs of Git
+// remotes, e.g. git@github.com:owner/repo.git or
+// https://github.com/owner/repo
+var remoteURLPattern = regexp.MustCompile(`[:/]([^/:]+/[^/]+?)(?:\.git)?/?$`)
+
+type Issue struct {
+	Title  string   `json:"title"`
+	Body   string   `json:"body"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// APIURL returns the URL of the GitHub API, which can be set via the
+// GITHUB_API_URL environment variable for GitHub Enterprise Server,
+// like in GitHub Actions
+func APIURL() string {
+	if url := os.Getenv("GITHUB_API_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return defaultAPIURL
+}
+
+// ServerURL returns the URL of the GitHub web interface, which can be
+// set via the GITHUB_SERVER_URL environment variable
+func ServerURL() string {
+	if url := os.Getenv("GITHUB_SERVER_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return "https://github.com"
+}
+
+// ValidateRepo checks that the repository has the format owner/name
+func ValidateRepo(repo string) error {
+	owner, name, found := strings.Cut(repo, "/")
+	if !found || owner == "" || name == "" || strings.Contains(name, "/") {
+		return errors.Errorf("Invalid GitHub repository %q, it must have the format <owner>/<name>", repo)
+	}
+	return nil
+}
+
+// RepoFromRemoteURL returns the repository in the format owner/name
+// which the URL of a Git remote points to
+func RepoFromRemoteURL(remoteURL string) (string, error) {
+	match := remoteURLPattern.FindStringSubmatch(strings.TrimSpace(remoteURL))
+	if match == nil {
+		return "", errors.Errorf("Failed to determine the GitHub repository from the remote URL %q", remoteURL)
+	}
+	return match[1], nil
+}
+
+// CreateIssue creates the issue in the repository (in the format
+// owner/name) and returns the URL of the issue in the web interface
+func CreateIssue(apiURL, token, repo string, issue *Issue) (string, error) {
+	body, err := json.Marshal(issue)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	url := fmt.Sprintf("%s/repos/%s/issues", apiURL, repo)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = resp.Status
+		}
+		return "", errors.Errorf("Failed to create an issue in %s: %s", repo, apiErr.Message)
+	}
+
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+	err = json.Unmarshal(respBody, &created)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return created.HTMLURL, nil
+}
@@ -0,0 +1,61 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateIssue(t *testing.T) {
+	var issue Issue
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/issues", r.URL.Path)
+		assert.Equal(t, "Bearer my-token", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&issue))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"html_url": "https://github.com/owner/repo/issues/1"}`))
+	}))
+	defer server.Close()
+
+	url, err := CreateIssue(server.URL, "my-token", "owner/repo", &Issue{Title: "title", Body: "body", Labels: []string{"fuzzing"}})
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/owner/repo/issues/1", url)
+	assert.Equal(t, Issue{Title: "title", Body: "body", Labels: []string{"fuzzing"}}, issue)
+}
+
+func TestCreateIssue_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+	}))
+	defer server.Close()
+
+	_, err := CreateIssue(server.URL, "my-token", "owner/repo", &Issue{Title: "title"})
+	require.EqualError(t, err, "Failed to create an issue in owner/repo: Not Found")
+}
+
+func TestRepoFromRemoteURL(t *testing.T) {
+	for _, remoteURL := range []string{
+		"git@github.com:owner/repo.git",
+		"https://github.com/owner/repo",
+		"https://github.com/owner/repo.git",
+		"ssh://git@github.example.com/owner/repo.git\n",
+	} {
+		repo, err := RepoFromRemoteURL(remoteURL)
+		require.NoError(t, err, remoteURL)
+		assert.Equal(t, "owner/repo", repo, remoteURL)
+	}
+
+	_, err := RepoFromRemoteURL("repo")
+	require.Error(t, err)
+}
+
+func TestValidateRepo(t *testing.T) {
+	assert.NoError(t, ValidateRepo("owner/repo"))
+	assert.Error(t, ValidateRepo("repo"))
+	assert.Error(t, ValidateRepo("owner/repo/issues"))
+}
@@ -0,0 +1,99 @@
+// Package githubactions implements the output formats of GitHub
+// Actions, i.e. workflow commands which create annotations and the job
+// summary, see
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+package githubactions
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/cicheck"
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/runsummary"
+)
+
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+// IsGitHubActions returns true if cifuzz runs in a GitHub Actions
+// workflow
+func IsGitHubActions() bool {
+	return cicheck.CIName() == "github-actions"
+}
+
+// PrintAnnotation prints a workflow command which creates an annotation
+// of the finding, at the location of the top stack frame. The path of
+// the source file is made relative to the workspace of the workflow,
+// which GitHub expects.
+func PrintAnnotation(w io.Writer, level, projectDir string, f *finding.Finding) error {
+	properties := []string{"title=" + escapeProperty("cifuzz: "+f.ShortDescriptionColumns()[0])}
+	if len(f.StackTrace) > 0 && f.StackTrace[0].SourceFile != "" {
+		sf := f.StackTrace[0]
+		properties = append(properties, "file="+escapeProperty(workspacePath(projectDir, sf.SourceFile)))
+		if sf.Line > 0 {
+			properties = append(properties, fmt.Sprintf("line=%d", sf.Line))
+		}
+		if sf.Column > 0 {
+			properties = append(properties, fmt.Sprintf("col=%d", sf.Column))
+		}
+	}
+	message := fmt.Sprintf("%s (found by fuzz test %s)\n\n%s",
+		f.ShortDescriptionWithName(), f.FuzzTest, strings.Join(f.Logs, "\n"))
+	_, err := fmt.Fprintf(w, "::%s %s::%s\n", level, strings.Join(properties, ","), escapeData(message))
+	return errors.WithStack(err)
+}
+
+// WriteJobSummary appends the summary of the run as Markdown to the job
+// summary of the workflow step. If the job summary file isn't set, i.e.
+// when not running in GitHub Actions, it does nothing.
+func WriteJobSummary(s *runsummary.Summary) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = fmt.Fprint(file, s.Markdown())
+	closeErr := file.Close()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(closeErr)
+}
+
+// workspacePath returns the path of the source file, which is relative
+// to the project directory, relative to the workspace of the workflow
+func workspacePath(projectDir, sourceFile string) string {
+	workspace := os.Getenv("GITHUB_WORKSPACE")
+	if workspace == "" || filepath.IsAbs(sourceFile) {
+		return filepath.ToSlash(sourceFile)
+	}
+	path, err := filepath.Rel(workspace, filepath.Join(projectDir, sourceFile))
+	if err != nil || strings.HasPrefix(path, "..") {
+		return filepath.ToSlash(sourceFile)
+	}
+	return filepath.ToSlash(path)
+}
+
+// See https://github.com/actions/toolkit/blob/main/packages/core/src/command.ts
+func escapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+func escapeProperty(s string) string {
+	s = escapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
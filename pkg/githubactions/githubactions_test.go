@@ -0,0 +1,64 @@
+package githubactions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/pkg/runsummary"
+)
+
+func testFinding() *finding.Finding {
+	return &finding.Finding{
+		Name:     "funny_elephant",
+		Type:     finding.ErrorTypeCrash,
+		Details:  "heap-buffer-overflow",
+		FuzzTest: "my_fuzz_test",
+		Logs:     []string{"ERROR: AddressSanitizer: heap-buffer-overflow", "100% done"},
+		StackTrace: []*stacktrace.StackFrame{
+			{SourceFile: "src/explore_me.cpp", Line: 13, Column: 11, Function: "exploreMe"},
+		},
+	}
+}
+
+func TestPrintAnnotation(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("GITHUB_WORKSPACE", workspace)
+
+	var out bytes.Buffer
+	err := PrintAnnotation(&out, LevelError, filepath.Join(workspace, "project"), testFinding())
+	require.NoError(t, err)
+	assert.Equal(t,
+		"::error title=cifuzz%3A heap buffer overflow,file=project/src/explore_me.cpp,line=13,col=11::"+
+			"[funny_elephant] heap buffer overflow in exploreMe (src/explore_me.cpp:13:11) (found by fuzz test my_fuzz_test)"+
+			"%0A%0AERROR: AddressSanitizer: heap-buffer-overflow%0A100%25 done\n",
+		out.String())
+}
+
+func TestWriteJobSummary(t *testing.T) {
+	summaryFile := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryFile)
+
+	err := WriteJobSummary(&runsummary.Summary{
+		FuzzTest:     "my_fuzz_test",
+		Duration:     90 * time.Second,
+		FirstMetrics: &report.FuzzingMetric{Edges: 100},
+		LastMetrics:  &report.FuzzingMetric{Edges: 120, TotalExecutions: 5000},
+		NewFindings:  []*finding.Finding{testFinding()},
+	})
+	require.NoError(t, err)
+
+	bytes, err := os.ReadFile(summaryFile)
+	require.NoError(t, err)
+	summary := string(bytes)
+	assert.Contains(t, summary, "### cifuzz: my_fuzz_test\n")
+	assert.Contains(t, summary, "| Findings | 1 new, 0 known |\n")
+}
@@ -0,0 +1,191 @@
+package inputformat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatPNG      = "png"
+	FormatProtobuf = "protobuf"
+	FormatText     = "text"
+)
+
+// Formats are the input formats which can be decoded. Other formats
+// can be declared as well, inputs of those formats are shown as a hex
+// dump.
+var Formats = []string{FormatJSON, FormatPNG, FormatProtobuf, FormatText}
+
+// Format returns a human-readable representation of the input, decoded
+// according to the given format. The format is case-insensitive. For
+// protobuf inputs, the name of the message can be added after a colon,
+// e.g. "protobuf:my.package.Message".
+//
+// An error is returned if the input is not valid in the given format,
+// in which case callers should fall back to the hex dump returned by
+// HexDump.
+func Format(data []byte, format string) (string, error) {
+	name, message, _ := strings.Cut(format, ":")
+	switch strings.ToLower(name) {
+	case FormatJSON:
+		return formatJSON(data)
+	case FormatPNG:
+		return formatPNG(data)
+	case FormatProtobuf:
+		return formatProtobuf(data, message)
+	case FormatText:
+		if !utf8.Valid(data) {
+			return "", errors.New("Input is not valid UTF-8")
+		}
+		return string(data), nil
+	default:
+		return HexDump(data), nil
+	}
+}
+
+// HexDump returns a hex dump of the input in the format of
+// `hexdump -C`
+func HexDump(data []byte) string {
+	return hex.Dump(data)
+}
+
+func formatJSON(data []byte) (string, error) {
+	var buf bytes.Buffer
+	err := json.Indent(&buf, bytes.TrimSpace(data), "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "Input is not valid JSON")
+	}
+	return buf.String(), nil
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// formatPNG lists the chunks of the PNG image followed by a hex dump of
+// its data, so that corrupted chunks can be spotted easily.
+func formatPNG(data []byte) (string, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return "", errors.New("Input doesn't start with the PNG signature")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Offset      Chunk  Length      CRC\n")
+	offset := len(pngSignature)
+	for offset < len(data) {
+		if len(data)-offset < 8 {
+			sb.WriteString(fmt.Sprintf("0x%08x  truncated chunk header (%d bytes)\n", offset, len(data)-offset))
+			break
+		}
+		length := binary.BigEndian.Uint32(data[offset:])
+		chunkType := string(data[offset+4 : offset+8])
+		end := offset + 12 + int(length)
+		if length > uint32(len(data)) || end > len(data) || end < offset {
+			sb.WriteString(fmt.Sprintf("0x%08x  %-5s  %-10d  truncated, only %d bytes left\n",
+				offset, strconv.Quote(chunkType), length, len(data)-offset-8))
+			break
+		}
+		crc := binary.BigEndian.Uint32(data[end-4:])
+		sb.WriteString(fmt.Sprintf("0x%08x  %-5s  %-10d  0x%08x", offset, chunkType, length, crc))
+		if chunkType == "IHDR" && length >= 13 {
+			chunkData := data[offset+8:]
+			sb.WriteString(fmt.Sprintf("  width=%d height=%d bit_depth=%d color_type=%d",
+				binary.BigEndian.Uint32(chunkData), binary.BigEndian.Uint32(chunkData[4:]), chunkData[8], chunkData[9]))
+		}
+		sb.WriteString("\n")
+		offset = end
+	}
+	sb.WriteString("\n")
+	sb.WriteString(HexDump(data))
+	return sb.String(), nil
+}
+
+// formatProtobuf decodes the input as a serialized protobuf message.
+// We don't have access to the .proto files of the project, so the
+// fields are printed with their numbers instead of their names, like
+// `protoc --decode_raw` does.
+func formatProtobuf(data []byte, message string) (string, error) {
+	var sb strings.Builder
+	if message != "" {
+		sb.WriteString(fmt.Sprintf("# %s\n", message))
+	}
+	err := decodeProtobuf(&sb, data, 0)
+	if err != nil {
+		return "", errors.WithMessage(err, "Input is not a valid protobuf message")
+	}
+	return sb.String(), nil
+}
+
+// protobuf wire types, see
+// https://protobuf.dev/programming-guides/encoding/#structure
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+func decodeProtobuf(sb *strings.Builder, data []byte, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("invalid field key")
+		}
+		data = data[n:]
+		field := key >> 3
+		if field == 0 {
+			return errors.New("invalid field number 0")
+		}
+
+		switch key & 7 {
+		case wireVarint:
+			value, n := binary.Uvarint(data)
+			if n <= 0 {
+				return errors.Errorf("invalid varint in field %d", field)
+			}
+			data = data[n:]
+			sb.WriteString(fmt.Sprintf("%s%d: %d\n", indent, field, value))
+		case wireFixed64:
+			if len(data) < 8 {
+				return errors.Errorf("truncated fixed64 in field %d", field)
+			}
+			sb.WriteString(fmt.Sprintf("%s%d: 0x%016x\n", indent, field, binary.LittleEndian.Uint64(data)))
+			data = data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return errors.Errorf("truncated fixed32 in field %d", field)
+			}
+			sb.WriteString(fmt.Sprintf("%s%d: 0x%08x\n", indent, field, binary.LittleEndian.Uint32(data)))
+			data = data[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return errors.Errorf("invalid length in field %d", field)
+			}
+			value := data[n : n+int(length)]
+			data = data[n+int(length):]
+
+			// Length-delimited fields can be strings, bytes or nested
+			// messages. Like protoc, we try to decode them as a message
+			// first and fall back to printing them as a string.
+			var nested strings.Builder
+			if len(value) > 0 && decodeProtobuf(&nested, value, depth+1) == nil {
+				sb.WriteString(fmt.Sprintf("%s%d {\n%s%s}\n", indent, field, nested.String(), indent))
+			} else {
+				sb.WriteString(fmt.Sprintf("%s%d: %s\n", indent, field, strconv.Quote(string(value))))
+			}
+		default:
+			// Groups are deprecated and not supported
+			return errors.Errorf("unsupported wire type %d in field %d", key&7, field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package inputformat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat_JSON(t *testing.T) {
+	out, err := Format([]byte(`{"a":[1,2],"b":"c"}`), "JSON")
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": \"c\"\n}", out)
+
+	_, err = Format([]byte(`{"a":`), "json")
+	assert.Error(t, err)
+}
+
+func TestFormat_PNG(t *testing.T) {
+	data := append([]byte{}, pngSignature...)
+	// IHDR chunk of a 1x2 image with bit depth 8 and color type 6
+	data = append(data, 0, 0, 0, 13, 'I', 'H', 'D', 'R',
+		0, 0, 0, 1, 0, 0, 0, 2, 8, 6, 0, 0, 0,
+		0xde, 0xad, 0xbe, 0xef)
+	// Truncated IDAT chunk
+	data = append(data, 0, 0, 1, 0, 'I', 'D', 'A', 'T', 1, 2)
+
+	out, err := Format(data, "png")
+	require.NoError(t, err)
+	assert.Contains(t, out, "0x00000008  IHDR   13          0xdeadbeef  width=1 height=2 bit_depth=8 color_type=6")
+	assert.Contains(t, out, `"IDAT"  256         truncated, only 2 bytes left`)
+	assert.Contains(t, out, "00000000  89 50 4e 47")
+
+	_, err = Format([]byte("GIF89a"), "png")
+	assert.Error(t, err)
+}
+
+func TestFormat_Protobuf(t *testing.T) {
+	// Field 1: varint 150, field 2: string "testing", field 3: nested
+	// message with field 1: varint 1
+	data := []byte{0x08, 0x96, 0x01, 0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g', 0x1a, 0x02, 0x08, 0x01}
+
+	out, err := Format(data, "protobuf:my.pkg.Message")
+	require.NoError(t, err)
+	assert.Equal(t, "# my.pkg.Message\n1: 150\n2: \"testing\"\n3 {\n  1: 1\n}\n", out)
+
+	_, err = Format([]byte{0x12, 0x7f}, "protobuf")
+	assert.Error(t, err)
+}
+
+func TestFormat_Unknown(t *testing.T) {
+	out, err := Format([]byte("%PDF"), "pdf")
+	require.NoError(t, err)
+	assert.Equal(t, HexDump([]byte("%PDF")), out)
+}
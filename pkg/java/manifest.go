@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -72,8 +73,17 @@ func writeManifest(entries map[string]string, target io.Writer) error {
 }
 
 func entriesToString(entries map[string]string) (string, error) {
+	// Sort the entries so that the manifest is the same for the same
+	// entries, which is required for reproducible bundles
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
 	var content strings.Builder
-	for k, v := range entries {
+	for _, k := range keys {
+		v := entries[k]
 		// headers are not allowed to be wrapped, so without the ": "
 		// separator a header is not allowed to be longer than 70 bytes
 		if len(k) > 70 {
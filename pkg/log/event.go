@@ -0,0 +1,80 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pterm/pterm"
+)
+
+const (
+	EventLog     = "log"
+	EventMetrics = "metrics"
+	EventFinding = "finding"
+	EventResult  = "result"
+)
+
+// The output to which events are written in JSON output mode, or nil
+// if the JSON output mode is not enabled
+var eventOutput io.Writer
+var eventMutex sync.Mutex
+
+type event struct {
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Data    any       `json:"data,omitempty"`
+}
+
+// EnableJSONOutput switches to the JSON output mode, in which all log
+// messages and the events emitted via Emit are written as
+// newline-delimited JSON to stdout. To keep the event stream parsable,
+// os.Stdout is replaced with os.Stderr, so that all other output which
+// would be printed to stdout (e.g. the output of the build) is printed
+// to stderr instead.
+func EnableJSONOutput() {
+	eventOutput = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+// JSONOutput returns true if the JSON output mode is enabled
+func JSONOutput() bool {
+	return eventOutput != nil
+}
+
+// Emit writes an event of the specified type with the data as JSON if
+// the JSON output mode is enabled. Otherwise, it does nothing.
+func Emit(eventType string, data any) {
+	if eventOutput == nil {
+		return
+	}
+	writeEvent(&event{Type: eventType, Data: data})
+}
+
+func emitLog(level string, a ...any) {
+	msg := strings.TrimSuffix(pterm.RemoveColorFromString(fmt.Sprint(a...)), "\n")
+	if msg != "" {
+		writeEvent(&event{Type: EventLog, Level: level, Message: msg})
+	}
+	logToSecondaryOutput(a...)
+}
+
+func writeEvent(e *event) {
+	e.Time = time.Now()
+	bytes, err := json.Marshal(e)
+	if err != nil {
+		// The data of the events is always serializable, so this
+		// should never happen
+		bytes, _ = json.Marshal(&event{Type: EventLog, Time: e.Time, Level: "error", Message: err.Error()})
+	}
+
+	eventMutex.Lock()
+	defer eventMutex.Unlock()
+	_, _ = eventOutput.Write(append(bytes, '\n'))
+}
@@ -31,7 +31,12 @@ func init() {
 	disableColor = !term.IsTerminal(int(os.Stderr.Fd()))
 }
 
-func log(style pterm.Style, icon string, a ...any) {
+func log(level string, style pterm.Style, icon string, a ...any) {
+	if eventOutput != nil {
+		emitLog(level, a...)
+		return
+	}
+
 	s := fmt.Sprint(a...)
 	if len(s) == 0 || s[len(s)-1] != '\n' {
 		s += "\n"
@@ -108,7 +113,7 @@ func Successf(format string, a ...any) {
 }
 
 func Success(a ...any) {
-	log(pterm.Style{pterm.FgGreen}, "✅ ", a...)
+	log("success", pterm.Style{pterm.FgGreen}, "✅ ", a...)
 }
 
 // Warnf highlights a message as a warning
@@ -117,7 +122,7 @@ func Warnf(format string, a ...any) {
 }
 
 func Warn(a ...any) {
-	log(pterm.Style{pterm.Bold, pterm.FgYellow}, "🔔 ", a...)
+	log("warning", pterm.Style{pterm.Bold, pterm.FgYellow}, "🔔 ", a...)
 }
 
 // Notef highlights a message as a note
@@ -126,7 +131,7 @@ func Notef(format string, a ...any) {
 }
 
 func Note(a ...any) {
-	log(pterm.Style{pterm.FgLightYellow}, "", a...)
+	log("note", pterm.Style{pterm.FgLightYellow}, "", a...)
 }
 
 // Errorf highlights and formats a message as an error and
@@ -173,7 +178,7 @@ func ErrorMsgf(format string, a ...any) {
 
 // ErrorMsg highlights a message as an error.
 func ErrorMsg(a ...any) {
-	log(pterm.Style{pterm.Bold, pterm.FgRed}, "❌ ", a...)
+	log("error", pterm.Style{pterm.Bold, pterm.FgRed}, "❌ ", a...)
 }
 
 // Infof outputs a regular user message without any highlighting
@@ -182,7 +187,7 @@ func Infof(format string, a ...any) {
 }
 
 func Info(a ...any) {
-	log(pterm.Style{pterm.Fuzzy}, "", a...)
+	log("info", pterm.Style{pterm.Fuzzy}, "", a...)
 }
 
 // Debugf outputs additional information when the --verbose flag is active
@@ -192,7 +197,7 @@ func Debugf(format string, a ...any) {
 
 func Debug(a ...any) {
 	if viper.GetBool("verbose") {
-		log(pterm.Style{pterm.Fuzzy}, "🔍 ", a...)
+		log("debug", pterm.Style{pterm.Fuzzy}, "🔍 ", a...)
 		return
 	}
 
@@ -207,11 +212,11 @@ func Printf(format string, a ...any) {
 }
 
 func Print(a ...any) {
-	log(pterm.Style{pterm.FgDefault}, "", a...)
+	log("info", pterm.Style{pterm.FgDefault}, "", a...)
 }
 
 func Finding(a ...any) {
-	log(pterm.Style{pterm.FgDefault}, "💥 ", a...)
+	log("finding", pterm.Style{pterm.FgDefault}, "💥 ", a...)
 }
 
 func PlainStyle() bool {
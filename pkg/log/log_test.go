@@ -2,7 +2,9 @@ package log
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -117,3 +119,28 @@ func checkOutput(t *testing.T, a ...string) string {
 	}
 	return string(out)
 }
+
+func TestJSONOutput(t *testing.T) {
+	var events bytes.Buffer
+	eventOutput = &events
+	defer func() { eventOutput = nil }()
+
+	Infof("Test %d", 1)
+	Emit(EventMetrics, map[string]int{"edges": 42})
+
+	// Nothing is printed to the regular output
+	out, err := io.ReadAll(testOut)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+
+	lines := strings.Split(strings.TrimSpace(events.String()), "\n")
+	require.Len(t, lines, 2)
+	var e event
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &e))
+	assert.Equal(t, EventLog, e.Type)
+	assert.Equal(t, "info", e.Level)
+	assert.Equal(t, "Test 1", e.Message)
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+	assert.Equal(t, EventMetrics, e.Type)
+	assert.Equal(t, map[string]any{"edges": float64(42)}, e.Data)
+}
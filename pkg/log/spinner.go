@@ -64,7 +64,7 @@ func (p *SpinnerPrinter) Stop() {
 }
 
 func ShouldUseSpinnerPrinter() bool {
-	return !PlainStyle() && term.IsTerminal(int(os.Stdout.Fd()))
+	return !PlainStyle() && !JSONOutput() && term.IsTerminal(int(os.Stdout.Fd()))
 }
 
 func UpdateCurrentSpinnerPrinter(msg string) {
@@ -2,6 +2,7 @@ package messaging
 
 import (
 	_ "embed"
+	"strings"
 
 	"code-intelligence.com/cifuzz/internal/config"
 )
@@ -47,3 +48,63 @@ func Instructions(buildSystem string) string {
 		return ""
 	}
 }
+
+// CodeBlocks returns the snippets of the instructions, which are the
+// blocks of lines indented by four spaces, without the indentation
+func CodeBlocks(instructions string) []string {
+	var blocks []string
+	var block []string
+	endBlock := func() {
+		if len(block) > 0 {
+			blocks = append(blocks, strings.TrimRight(strings.Join(block, "\n"), "\n")+"\n")
+		}
+		block = nil
+	}
+	for _, line := range strings.Split(instructions, "\n") {
+		switch {
+		case strings.HasPrefix(line, "    "):
+			block = append(block, strings.TrimPrefix(line, "    "))
+		case strings.TrimSpace(line) == "":
+			// Empty lines only separate blocks if they are followed by
+			// a line which is not indented
+			if len(block) > 0 {
+				block = append(block, "")
+			}
+		default:
+			endBlock()
+		}
+	}
+	endBlock()
+	return blocks
+}
+
+// The insertion points of the snippets of instructions
+const (
+	// The snippet is appended to the file, which is created if it
+	// doesn't exist
+	InsertAtEnd = "end"
+	// The snippet is inserted after the project() call of the
+	// top-level CMakeLists.txt, before any targets are defined
+	InsertAfterCMakeProject = "after-cmake-project"
+	// The lines of the snippet are inserted into the block named by
+	// the Block field, e.g. the plugins block of a build.gradle or the
+	// <extensions> element of an extensions.xml
+	InsertIntoBlock = "block"
+	// The snippet has to be merged with the existing content of the
+	// file, or is the content of the file if it doesn't exist
+	InsertMerge = "merge"
+)
+
+// Instruction is a step of the instructions as structured data, which
+// is either a snippet which is inserted into a file or a command which
+// is run in the project directory
+type Instruction struct {
+	Description string `json:"description"`
+	// The path of the file, relative to the project directory
+	File   string `json:"file,omitempty"`
+	Insert string `json:"insert,omitempty"`
+	Block  string `json:"block,omitempty"`
+	// The snippet without indentation
+	Snippet string `json:"snippet,omitempty"`
+	Command string `json:"command,omitempty"`
+}
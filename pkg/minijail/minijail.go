@@ -138,6 +138,9 @@ type Options struct {
 	Args      []string
 	Bindings  []*Binding
 	OutputDir string
+	// Run the jailed process in a new network namespace, in which only
+	// the loopback interface is available
+	NoNetwork bool
 }
 
 type minijail struct {
@@ -209,6 +212,11 @@ func NewMinijail(opts *Options) (*minijail, error) {
 	// Change root filesystem to the chroot directory. See pivot_root(2).
 	minijailArgs = append(minijailArgs, "-P", chrootDir)
 
+	if opts.NoNetwork {
+		// Network namespace
+		minijailArgs = append(minijailArgs, "-e")
+	}
+
 	// -----------------------
 	// --- Set up bindings ---
 	// -----------------------
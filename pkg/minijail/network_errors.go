@@ -0,0 +1,59 @@
+package minijail
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// Error messages (as returned by strerror(3), gai_strerror(3) and
+// libcurl) which are printed when a process tries to access the network,
+// but no network interface other than loopback is available
+var networkErrorPattern = regexp.MustCompile(`(?i)network is unreachable|ENETUNREACH|temporary failure in name resolution|EAI_AGAIN|could not resolve host`)
+
+// NetworkErrorDetector is a writer which prints a warning when the
+// output of the jailed process indicates that it tried to access the
+// network, which is blocked if Options.NoNetwork is set. Otherwise, the
+// user would only see the error of the fuzz target, which doesn't tell
+// that it's caused by the sandbox.
+type NetworkErrorDetector struct {
+	buf      bytes.Buffer
+	detected bool
+}
+
+func NewNetworkErrorDetector() *NetworkErrorDetector {
+	return &NetworkErrorDetector{}
+}
+
+func (d *NetworkErrorDetector) Write(p []byte) (int, error) {
+	if d.detected {
+		// The warning is only printed once
+		return len(p), nil
+	}
+
+	d.buf.Write(p)
+	for {
+		line, err := d.buf.ReadString('\n')
+		if err != nil {
+			// Store the incomplete line until the rest is written
+			d.buf.WriteString(line)
+			break
+		}
+		if networkErrorPattern.MatchString(line) {
+			d.detected = true
+			d.buf.Reset()
+			log.Warnf(`The fuzz test tried to access the network, which is blocked by --sandbox=network-off:
+    %s
+Use --sandbox=default to run the fuzz test with network access.`, strings.TrimSpace(line))
+			break
+		}
+	}
+	return len(p), nil
+}
+
+// Detected returns true if the output indicated a network access
+func (d *NetworkErrorDetector) Detected() bool {
+	return d.detected
+}
@@ -0,0 +1,29 @@
+package minijail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkErrorDetector(t *testing.T) {
+	d := NewNetworkErrorDetector()
+	_, err := d.Write([]byte("INFO: Running with entropic power schedule\nconnect: Network is "))
+	assert.NoError(t, err)
+	assert.False(t, d.Detected())
+
+	// The message is only matched once the line is complete
+	_, err = d.Write([]byte("unreachable\n"))
+	assert.NoError(t, err)
+	assert.True(t, d.Detected())
+
+	d = NewNetworkErrorDetector()
+	_, err = d.Write([]byte("curl: (6) Could not resolve host: example.com\n"))
+	assert.NoError(t, err)
+	assert.True(t, d.Detected())
+
+	d = NewNetworkErrorDetector()
+	_, err = d.Write([]byte("connect: Connection refused\n"))
+	assert.NoError(t, err)
+	assert.False(t, d.Detected())
+}
@@ -52,6 +52,11 @@ func (m *RunfilesFinderMock) LLVMCovPath() (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+func (m *RunfilesFinderMock) LLVMObjdumpPath() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
 func (m *RunfilesFinderMock) LLVMProfDataPath() (string, error) {
 	args := m.Called()
 	return args.String(0), args.Error(1)
@@ -67,6 +72,11 @@ func (m *RunfilesFinderMock) GenHTMLPath() (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+func (m *RunfilesFinderMock) GcovrPath() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
 func (m *RunfilesFinderMock) PerlPath() (string, error) {
 	args := m.Called()
 	return args.String(0), args.Error(1)
@@ -107,6 +117,11 @@ func (m *RunfilesFinderMock) ReplayerSourcePath() (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+func (m *RunfilesFinderMock) RecorderSourcePath() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
 func (m *RunfilesFinderMock) ListFuzzTestsJarPath() (string, error) {
 	args := m.Called()
 	return args.String(0), args.Error(1)
@@ -9,6 +9,7 @@ const JazzerJSTestPathPattern string = "--testPathPattern"
 const JazzerJSReporters string = "--reporters"
 const JazzerJSCoverageDirectory string = "--coverageDirectory"
 const JazzerJSCoverageReporters string = "--coverageReporters"
+const JestCoverageProvider string = "--coverageProvider"
 const JestTestFailureExitCode string = "--testFailureExitCode"
 
 func JazzerJSTestNamePatternFlag(value string) string {
@@ -34,6 +35,10 @@ func JazzerJSCoverageReportersFlag(value string) string {
 	return JazzerJSCoverageReporters + fmt.Sprintf("='%s'", value)
 }
 
+func JestCoverageProviderFlag(value string) string {
+	return JestCoverageProvider + fmt.Sprintf("='%s'", value)
+}
+
 func JestTestFailureExitCodeFlag(value int) string {
 	return JestTestFailureExitCode + fmt.Sprintf("='%d'", value)
 }
@@ -0,0 +1,247 @@
+package coverage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// The subset of the Cobertura XML format which is understood by
+// Jenkins, Azure DevOps and GitLab, see
+// https://github.com/cobertura/web/blob/master/htdocs/xml/coverage-04.dtd
+type coberturaCoverage struct {
+	XMLName         xml.Name            `xml:"coverage"`
+	LineRate        string              `xml:"line-rate,attr"`
+	BranchRate      string              `xml:"branch-rate,attr"`
+	LinesCovered    int                 `xml:"lines-covered,attr"`
+	LinesValid      int                 `xml:"lines-valid,attr"`
+	BranchesCovered int                 `xml:"branches-covered,attr"`
+	BranchesValid   int                 `xml:"branches-valid,attr"`
+	Complexity      string              `xml:"complexity,attr"`
+	Version         string              `xml:"version,attr"`
+	Timestamp       int64               `xml:"timestamp,attr"`
+	Sources         []string            `xml:"sources>source"`
+	Packages        []*coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string            `xml:"name,attr"`
+	LineRate   string            `xml:"line-rate,attr"`
+	BranchRate string            `xml:"branch-rate,attr"`
+	Complexity string            `xml:"complexity,attr"`
+	Classes    []*coberturaClass `xml:"classes>class"`
+
+	counts coberturaCounts
+}
+
+type coberturaClass struct {
+	Name       string             `xml:"name,attr"`
+	Filename   string             `xml:"filename,attr"`
+	LineRate   string             `xml:"line-rate,attr"`
+	BranchRate string             `xml:"branch-rate,attr"`
+	Complexity string             `xml:"complexity,attr"`
+	Methods    []*coberturaMethod `xml:"methods>method"`
+	Lines      []*coberturaLine   `xml:"lines>line"`
+}
+
+type coberturaMethod struct {
+	Name       string           `xml:"name,attr"`
+	Signature  string           `xml:"signature,attr"`
+	LineRate   string           `xml:"line-rate,attr"`
+	BranchRate string           `xml:"branch-rate,attr"`
+	Complexity string           `xml:"complexity,attr"`
+	Lines      []*coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number            int    `xml:"number,attr"`
+	Hits              int    `xml:"hits,attr"`
+	Branch            bool   `xml:"branch,attr"`
+	ConditionCoverage string `xml:"condition-coverage,attr,omitempty"`
+}
+
+type coberturaCounts struct {
+	linesCovered, linesValid       int
+	branchesCovered, branchesValid int
+}
+
+func (c *coberturaCounts) add(other coberturaCounts) {
+	c.linesCovered += other.linesCovered
+	c.linesValid += other.linesValid
+	c.branchesCovered += other.branchesCovered
+	c.branchesValid += other.branchesValid
+}
+
+func (c *coberturaCounts) lineRate() string {
+	return rate(c.linesCovered, c.linesValid)
+}
+
+func (c *coberturaCounts) branchRate() string {
+	return rate(c.branchesCovered, c.branchesValid)
+}
+
+// rate formats the ratio like other Cobertura producers do. Without
+// any lines or branches, the rate is 1.
+func rate(covered, valid int) string {
+	if valid == 0 {
+		return "1"
+	}
+	return fmt.Sprintf("%.4g", float64(covered)/float64(valid))
+}
+
+// WriteCoberturaReportToFile writes the report in the Cobertura XML
+// format. The paths of the source files are made relative to the
+// source directory (usually the project directory), which is listed
+// as the source of the report.
+func (r *LCOVReport) WriteCoberturaReportToFile(file string, sourceDir string) error {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	err = r.WriteCoberturaReport(f, sourceDir, time.Now())
+	if err != nil {
+		return errors.Wrapf(err, "Failed to write to file '%s'", file)
+	}
+	log.Debugf("Successfully wrote Cobertura report to %s", file)
+	return nil
+}
+
+// WriteCoberturaReport writes the report in the Cobertura XML format,
+// see WriteCoberturaReportToFile
+func (r *LCOVReport) WriteCoberturaReport(w io.Writer, sourceDir string, timestamp time.Time) error {
+	report := &coberturaCoverage{
+		Complexity: "0",
+		Version:    "cifuzz",
+		Timestamp:  timestamp.UnixMilli(),
+		Sources:    []string{filepath.ToSlash(sourceDir)},
+	}
+
+	var total coberturaCounts
+	packages := map[string]*coberturaPackage{}
+	for _, sf := range r.SourceFiles {
+		filename := sf.Name
+		if filepath.IsAbs(filename) {
+			rel, err := filepath.Rel(sourceDir, filename)
+			if err == nil && !strings.HasPrefix(rel, "..") {
+				filename = rel
+			}
+		}
+		filename = filepath.ToSlash(filename)
+
+		// Like other converters, we use the directory of the source
+		// file as package and the file as class
+		pkgName := strings.ReplaceAll(path.Dir(filename), "/", ".")
+		pkg, ok := packages[pkgName]
+		if !ok {
+			pkg = &coberturaPackage{Name: pkgName, Complexity: "0"}
+			packages[pkgName] = pkg
+		}
+
+		class, counts := coberturaClassFromSourceFile(sf, filename)
+		pkg.Classes = append(pkg.Classes, class)
+		pkg.counts.add(counts)
+		total.add(counts)
+	}
+
+	for _, pkg := range packages {
+		pkg.LineRate = pkg.counts.lineRate()
+		pkg.BranchRate = pkg.counts.branchRate()
+		report.Packages = append(report.Packages, pkg)
+	}
+	sort.Slice(report.Packages, func(i, j int) bool {
+		return report.Packages[i].Name < report.Packages[j].Name
+	})
+
+	report.LinesCovered = total.linesCovered
+	report.LinesValid = total.linesValid
+	report.BranchesCovered = total.branchesCovered
+	report.BranchesValid = total.branchesValid
+	report.LineRate = total.lineRate()
+	report.BranchRate = total.branchRate()
+
+	_, err := io.WriteString(w, xml.Header+
+		`<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`+"\n")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(report)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = io.WriteString(w, "\n")
+	return errors.WithStack(err)
+}
+
+func coberturaClassFromSourceFile(sf *SourceFile, filename string) (*coberturaClass, coberturaCounts) {
+	var counts coberturaCounts
+
+	// Aggregate the branches per line
+	branchesCovered := map[int]int{}
+	branchesValid := map[int]int{}
+	for _, b := range sf.BranchInformation {
+		branchesValid[b.Line]++
+		if b.Executions > 0 {
+			branchesCovered[b.Line]++
+		}
+	}
+
+	class := &coberturaClass{
+		Name:       path.Base(filename),
+		Filename:   filename,
+		Complexity: "0",
+	}
+	lines := append([]Line{}, sf.LineInformation...)
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Number < lines[j].Number })
+	for _, l := range lines {
+		line := &coberturaLine{Number: l.Number, Hits: l.Executions}
+		counts.linesValid++
+		if l.Executions > 0 {
+			counts.linesCovered++
+		}
+		if valid := branchesValid[l.Number]; valid > 0 {
+			covered := branchesCovered[l.Number]
+			line.Branch = true
+			line.ConditionCoverage = fmt.Sprintf("%d%% (%d/%d)", covered*100/valid, covered, valid)
+			counts.branchesValid += valid
+			counts.branchesCovered += covered
+		}
+		class.Lines = append(class.Lines, line)
+	}
+
+	executions := map[string]int{}
+	for _, f := range sf.FunctionExecutions {
+		executions[f.Name] = f.Executions
+	}
+	for _, f := range sf.FunctionInformation {
+		hits := executions[f.Name]
+		lineRate := "0"
+		if hits > 0 {
+			lineRate = "1"
+		}
+		class.Methods = append(class.Methods, &coberturaMethod{
+			Name:       f.Name,
+			LineRate:   lineRate,
+			BranchRate: "1",
+			Complexity: "0",
+			Lines:      []*coberturaLine{{Number: f.Line, Hits: hits}},
+		})
+	}
+
+	class.LineRate = counts.lineRate()
+	class.BranchRate = counts.branchRate()
+	return class, counts
+}
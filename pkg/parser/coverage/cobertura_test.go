@@ -0,0 +1,54 @@
+package coverage
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCoberturaReport(t *testing.T) {
+	projectDir := filepath.Join(string(filepath.Separator), "project")
+	report := LCOVReport{
+		SourceFiles: []*SourceFile{
+			{
+				Name:                filepath.Join(projectDir, "src", "explore_me.cpp"),
+				FunctionInformation: []Function{{Name: "exploreMe", Line: 2}},
+				FunctionExecutions:  []FunctionExecution{{Name: "exploreMe", Executions: 3}},
+				LineInformation: []Line{
+					{Number: 3, Executions: 3},
+					{Number: 5, Executions: 0},
+					{Number: 4, Executions: 1},
+				},
+				BranchInformation: []Branch{
+					{Line: 4, Number: 0, Executions: 1},
+					{Line: 4, Number: 1, Executions: 0},
+				},
+			},
+			{
+				Name:            "main.cpp",
+				LineInformation: []Line{{Number: 1, Executions: 1}},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	err := report.WriteCoberturaReport(&out, projectDir, time.UnixMilli(1700000000000))
+	require.NoError(t, err)
+	xml := out.String()
+
+	assert.Contains(t, xml, `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`)
+	assert.Contains(t, xml, `<coverage line-rate="0.75" branch-rate="0.5" lines-covered="3" lines-valid="4" branches-covered="1" branches-valid="2" complexity="0" version="cifuzz" timestamp="1700000000000">`)
+	assert.Contains(t, xml, "<source>"+filepath.ToSlash(projectDir)+"</source>")
+	assert.Contains(t, xml, `<package name="." line-rate="1" branch-rate="1" complexity="0">`)
+	assert.Contains(t, xml, `<package name="src" line-rate="0.6667" branch-rate="0.5" complexity="0">`)
+	assert.Contains(t, xml, `<class name="explore_me.cpp" filename="src/explore_me.cpp" line-rate="0.6667" branch-rate="0.5" complexity="0">`)
+	assert.Contains(t, xml, `<method name="exploreMe" signature="" line-rate="1" branch-rate="1" complexity="0">`)
+	assert.Contains(t, xml, `<line number="4" hits="1" branch="true" condition-coverage="50% (1/2)"></line>`)
+	assert.Contains(t, xml, `<line number="5" hits="0" branch="false"></line>`)
+	// The lines are sorted
+	assert.Less(t, bytes.Index(out.Bytes(), []byte(`number="4"`)), bytes.Index(out.Bytes(), []byte(`number="5"`)))
+}
@@ -0,0 +1,110 @@
+package coverage
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// Comparison contains the lines and functions which are covered by only
+// one of two coverage reports
+type Comparison struct {
+	Files []*FileComparison
+	Old   Overview
+	New   Overview
+}
+
+type FileComparison struct {
+	Name string
+	// The lines and functions which are only covered by the new report
+	GainedLines     []int    `json:",omitempty"`
+	GainedFunctions []string `json:",omitempty"`
+	// The lines and functions which are only covered by the old report
+	LostLines     []int    `json:",omitempty"`
+	LostFunctions []string `json:",omitempty"`
+}
+
+// CompareLCOVReports compares the covered lines and functions of the
+// two reports. Source files which are only contained in one of the
+// reports are compared against an empty coverage. Only files with
+// gained or lost coverage are included in the result.
+func CompareLCOVReports(oldReport, newReport *LCOVReport) *Comparison {
+	result := &Comparison{}
+	oldFiles := map[string]*SourceFile{}
+	for _, sf := range oldReport.SourceFiles {
+		oldFiles[filepath.Clean(sf.Name)] = sf
+		addOverview(&result.Old, sf.Overview)
+	}
+	newFiles := map[string]*SourceFile{}
+	for _, sf := range newReport.SourceFiles {
+		newFiles[filepath.Clean(sf.Name)] = sf
+		addOverview(&result.New, sf.Overview)
+	}
+
+	names := map[string]bool{}
+	for name := range oldFiles {
+		names[name] = true
+	}
+	for name := range newFiles {
+		names[name] = true
+	}
+	for name := range names {
+		oldLines, oldFunctions := coveredLinesAndFunctions(oldFiles[name])
+		newLines, newFunctions := coveredLinesAndFunctions(newFiles[name])
+		file := &FileComparison{
+			Name:            filepath.ToSlash(name),
+			GainedLines:     setDifference(newLines, oldLines),
+			GainedFunctions: setDifference(newFunctions, oldFunctions),
+			LostLines:       setDifference(oldLines, newLines),
+			LostFunctions:   setDifference(oldFunctions, newFunctions),
+		}
+		if len(file.GainedLines)+len(file.GainedFunctions)+len(file.LostLines)+len(file.LostFunctions) == 0 {
+			continue
+		}
+		sort.Ints(file.GainedLines)
+		sort.Ints(file.LostLines)
+		sort.Strings(file.GainedFunctions)
+		sort.Strings(file.LostFunctions)
+		result.Files = append(result.Files, file)
+	}
+	sort.Slice(result.Files, func(i, j int) bool { return result.Files[i].Name < result.Files[j].Name })
+	return result
+}
+
+func addOverview(total *Overview, o Overview) {
+	total.FunctionsFound += o.FunctionsFound
+	total.FunctionsHit += o.FunctionsHit
+	total.LinesFound += o.LinesFound
+	total.LinesHit += o.LinesHit
+	total.BranchesFound += o.BranchesFound
+	total.BranchesHit += o.BranchesHit
+}
+
+func coveredLinesAndFunctions(sf *SourceFile) (map[int]bool, map[string]bool) {
+	lines := map[int]bool{}
+	functions := map[string]bool{}
+	if sf == nil {
+		return lines, functions
+	}
+	for _, l := range sf.LineInformation {
+		if l.Executions > 0 {
+			lines[l.Number] = true
+		}
+	}
+	for _, f := range sf.FunctionExecutions {
+		if f.Executions > 0 {
+			functions[f.Name] = true
+		}
+	}
+	return lines, functions
+}
+
+// setDifference returns the elements of a which are not in b
+func setDifference[T comparable](a, b map[T]bool) []T {
+	var result []T
+	for e := range a {
+		if !b[e] {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package coverage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareLCOVReports(t *testing.T) {
+	oldReport := &LCOVReport{SourceFiles: []*SourceFile{
+		{
+			Name:               "a.c",
+			LineInformation:    []Line{{Number: 1, Executions: 1}, {Number: 2, Executions: 1}, {Number: 3}},
+			FunctionExecutions: []FunctionExecution{{Name: "f", Executions: 1}, {Name: "g"}},
+			Overview:           Overview{LinesFound: 3, LinesHit: 2, FunctionsFound: 2, FunctionsHit: 1},
+		},
+		{
+			Name:            "removed.c",
+			LineInformation: []Line{{Number: 5, Executions: 1}},
+			Overview:        Overview{LinesFound: 1, LinesHit: 1},
+		},
+		{
+			Name:            "unchanged.c",
+			LineInformation: []Line{{Number: 1, Executions: 1}},
+			Overview:        Overview{LinesFound: 1, LinesHit: 1},
+		},
+	}}
+	newReport := &LCOVReport{SourceFiles: []*SourceFile{
+		{
+			Name:               "a.c",
+			LineInformation:    []Line{{Number: 1, Executions: 2}, {Number: 2}, {Number: 3, Executions: 1}},
+			FunctionExecutions: []FunctionExecution{{Name: "f", Executions: 1}, {Name: "g", Executions: 1}},
+			Overview:           Overview{LinesFound: 3, LinesHit: 2, FunctionsFound: 2, FunctionsHit: 2},
+		},
+		{
+			Name:            "unchanged.c",
+			LineInformation: []Line{{Number: 1, Executions: 5}},
+			Overview:        Overview{LinesFound: 1, LinesHit: 1},
+		},
+	}}
+
+	comparison := CompareLCOVReports(oldReport, newReport)
+	assert.Equal(t, []*FileComparison{
+		{Name: "a.c", GainedLines: []int{3}, GainedFunctions: []string{"g"}, LostLines: []int{2}},
+		{Name: "removed.c", LostLines: []int{5}},
+	}, comparison.Files)
+	assert.Equal(t, 4, comparison.Old.LinesHit)
+	assert.Equal(t, 3, comparison.New.LinesHit)
+	assert.Equal(t, 2, comparison.New.FunctionsHit)
+}
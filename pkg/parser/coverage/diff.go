@@ -0,0 +1,108 @@
+package coverage
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffCoverage is the coverage of the lines which were changed compared
+// to a base revision
+type DiffCoverage struct {
+	Files        []*FileDiffCoverage
+	LinesCovered int
+	LinesValid   int
+}
+
+type FileDiffCoverage struct {
+	// The path of the source file, relative to the source directory if
+	// the file is contained in it
+	Path           string
+	LinesCovered   int
+	LinesValid     int
+	UncoveredLines []int
+}
+
+// DiffCoverage returns the coverage of the changed lines. The keys of
+// changedLines are absolute paths, relative paths of source files in
+// the report are interpreted relative to the source directory. Changed
+// lines which are not instrumented, e.g. comments, and changed files
+// which are not contained in the report are not taken into account.
+func (r *LCOVReport) DiffCoverage(changedLines map[string][]int, sourceDir string) *DiffCoverage {
+	changed := map[string][]int{}
+	for path, lines := range changedLines {
+		changed[canonicalPath(path)] = lines
+	}
+
+	result := &DiffCoverage{}
+	for _, sf := range r.SourceFiles {
+		path := sf.Name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(sourceDir, path)
+		}
+		lines, ok := changed[canonicalPath(path)]
+		if !ok {
+			continue
+		}
+
+		executions := map[int]int{}
+		for _, l := range sf.LineInformation {
+			executions[l.Number] += l.Executions
+		}
+		file := &FileDiffCoverage{Path: sf.Name}
+		if rel, err := filepath.Rel(sourceDir, path); err == nil && filepath.IsLocal(rel) {
+			file.Path = filepath.ToSlash(rel)
+		}
+		for _, line := range lines {
+			hits, instrumented := executions[line]
+			if !instrumented {
+				continue
+			}
+			file.LinesValid++
+			if hits > 0 {
+				file.LinesCovered++
+			} else {
+				file.UncoveredLines = append(file.UncoveredLines, line)
+			}
+		}
+		if file.LinesValid == 0 {
+			continue
+		}
+		sort.Ints(file.UncoveredLines)
+		result.Files = append(result.Files, file)
+		result.LinesCovered += file.LinesCovered
+		result.LinesValid += file.LinesValid
+	}
+	sort.Slice(result.Files, func(i, j int) bool { return result.Files[i].Path < result.Files[j].Path })
+	return result
+}
+
+// canonicalPath resolves symlinks in the path, so that paths reported by
+// Git and by the coverage tools can be compared
+func canonicalPath(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return resolved
+}
+
+// LineRanges formats the sorted line numbers as ranges like "3-5, 8"
+func LineRanges(lines []int) string {
+	var ranges []string
+	for i := 0; i < len(lines); {
+		j := i
+		for j+1 < len(lines) && lines[j+1] == lines[j]+1 {
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, strconv.Itoa(lines[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", lines[i], lines[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ranges, ", ")
+}
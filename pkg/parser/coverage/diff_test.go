@@ -0,0 +1,60 @@
+package coverage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/internal/testutil"
+)
+
+func TestDiffCoverage(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, "", "diff-coverage-")
+	require.NoError(t, os.Mkdir(filepath.Join(projectDir, "src"), 0o755))
+	for _, name := range []string{"explore_me.cpp", "unchanged.cpp"} {
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, "src", name), nil, 0o644))
+	}
+
+	report := &LCOVReport{
+		SourceFiles: []*SourceFile{
+			{
+				Name: filepath.Join(projectDir, "src", "explore_me.cpp"),
+				LineInformation: []Line{
+					{Number: 3, Executions: 3},
+					{Number: 4, Executions: 0},
+					{Number: 5, Executions: 0},
+				},
+			},
+			{
+				// Relative paths are relative to the project directory
+				Name:            filepath.Join("src", "unchanged.cpp"),
+				LineInformation: []Line{{Number: 1, Executions: 0}},
+			},
+		},
+	}
+	changedLines := map[string][]int{
+		// Line 2 is not instrumented
+		filepath.Join(projectDir, "src", "explore_me.cpp"): {2, 3, 5},
+		filepath.Join(projectDir, "README.md"):             {1},
+	}
+
+	diff := report.DiffCoverage(changedLines, projectDir)
+	assert.Equal(t, 1, diff.LinesCovered)
+	assert.Equal(t, 2, diff.LinesValid)
+	require.Len(t, diff.Files, 1)
+	assert.Equal(t, &FileDiffCoverage{
+		Path:           "src/explore_me.cpp",
+		LinesCovered:   1,
+		LinesValid:     2,
+		UncoveredLines: []int{5},
+	}, diff.Files[0])
+}
+
+func TestLineRanges(t *testing.T) {
+	assert.Equal(t, "", LineRanges(nil))
+	assert.Equal(t, "3", LineRanges([]int{3}))
+	assert.Equal(t, "3-5, 8, 10-11", LineRanges([]int{3, 4, 5, 8, 10, 11}))
+}
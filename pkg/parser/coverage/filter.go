@@ -0,0 +1,117 @@
+package coverage
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FilterSourceFiles returns a report which only contains the source
+// files which match one of the include patterns, or all source files if
+// there are no include patterns, and none of the exclude patterns. The
+// patterns are glob patterns, which are matched against the paths of
+// the source files relative to the source directory, see
+// compilePathPattern. Source files outside of the source directory are
+// matched by their absolute path. The source files of the returned
+// report are copies, so that modifying them, e.g. via RemapPaths,
+// doesn't affect r.
+func (r *LCOVReport) FilterSourceFiles(include, exclude []string, sourceDir string) (*LCOVReport, error) {
+	includePatterns, err := compilePathPatterns(include)
+	if err != nil {
+		return nil, err
+	}
+	excludePatterns, err := compilePathPatterns(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := &LCOVReport{}
+	for _, sf := range r.SourceFiles {
+		path := sf.Name
+		if filepath.IsAbs(path) {
+			rel, err := filepath.Rel(sourceDir, path)
+			if err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+		path = filepath.ToSlash(path)
+
+		if len(includePatterns) > 0 && !matchesAny(includePatterns, path) {
+			continue
+		}
+		if matchesAny(excludePatterns, path) {
+			continue
+		}
+		copied := *sf
+		filtered.SourceFiles = append(filtered.SourceFiles, &copied)
+	}
+	return filtered, nil
+}
+
+// ValidatePathPatterns checks that the patterns can be used to filter
+// the source files of a report
+func ValidatePathPatterns(patterns []string) error {
+	_, err := compilePathPatterns(patterns)
+	return err
+}
+
+func compilePathPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := compilePathPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
+
+// compilePathPattern converts a glob pattern to a regular expression. In
+// the pattern, "**" matches any number of directories, "*" matches any
+// characters except for "/" and "?" matches a single character except
+// for "/". A pattern also matches all files in the directories it
+// matches, so "third_party" is equivalent to "third_party/**".
+func compilePathPattern(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+	if pattern == "" {
+		return nil, errors.New("Invalid empty path pattern")
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("(?:/.*)?$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid path pattern %q", pattern)
+	}
+	return re, nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, path string) bool {
+	for _, re := range patterns {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
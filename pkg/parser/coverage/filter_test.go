@@ -0,0 +1,54 @@
+package coverage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterSourceFiles(t *testing.T) {
+	projectDir := filepath.Join(string(filepath.Separator), "project")
+	report := &LCOVReport{
+		SourceFiles: []*SourceFile{
+			{Name: filepath.Join(projectDir, "src", "explore_me.cpp")},
+			{Name: filepath.Join(projectDir, "src", "generated", "parser.cpp")},
+			{Name: filepath.Join(projectDir, "third_party", "lib", "lib.cpp")},
+			{Name: filepath.Join("src", "main", "java", "App.java")},
+		},
+	}
+	names := func(r *LCOVReport) []string {
+		var names []string
+		for _, sf := range r.SourceFiles {
+			names = append(names, filepath.ToSlash(sf.Name))
+		}
+		return names
+	}
+
+	filtered, err := report.FilterSourceFiles(nil, []string{"third_party", "**/generated/**"}, projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.ToSlash(filepath.Join(projectDir, "src", "explore_me.cpp")),
+		"src/main/java/App.java",
+	}, names(filtered))
+
+	filtered, err = report.FilterSourceFiles([]string{"src/**"}, []string{"src/generated/**"}, projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.ToSlash(filepath.Join(projectDir, "src", "explore_me.cpp")),
+		"src/main/java/App.java",
+	}, names(filtered))
+
+	filtered, err = report.FilterSourceFiles([]string{"src/*.cpp"}, nil, projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.ToSlash(filepath.Join(projectDir, "src", "explore_me.cpp"))}, names(filtered))
+
+	filtered, err = report.FilterSourceFiles(nil, nil, projectDir)
+	require.NoError(t, err)
+	assert.Len(t, filtered.SourceFiles, 4)
+
+	// Modifying the filtered report doesn't modify the report
+	filtered.SourceFiles[0].Name = "renamed.cpp"
+	assert.Equal(t, filepath.Join(projectDir, "src", "explore_me.cpp"), report.SourceFiles[0].Name)
+}
@@ -0,0 +1,129 @@
+package coverage
+
+import (
+	"sort"
+)
+
+type branchKey struct {
+	line, block, number int
+}
+
+// MergeLCOVReports merges the reports of multiple runs, e.g. of
+// different fuzz tests, into a single report. The execution counts of
+// source files which are contained in multiple reports are summed up,
+// which is equivalent to merging the underlying profiles.
+func MergeLCOVReports(reports ...*LCOVReport) *LCOVReport {
+	merged := &LCOVReport{}
+	sourceFiles := map[string]*mergedSourceFile{}
+	for _, report := range reports {
+		if report == nil {
+			continue
+		}
+		for _, sf := range report.SourceFiles {
+			m, ok := sourceFiles[sf.Name]
+			if !ok {
+				m = newMergedSourceFile(sf.Name)
+				sourceFiles[sf.Name] = m
+				merged.SourceFiles = append(merged.SourceFiles, m.SourceFile)
+			}
+			m.add(sf)
+		}
+	}
+	for _, m := range sourceFiles {
+		m.finish()
+	}
+	return merged
+}
+
+type mergedSourceFile struct {
+	*SourceFile
+
+	functions  map[string]int
+	executions map[string]int
+	lines      map[int]int
+	branches   map[branchKey]int
+}
+
+func newMergedSourceFile(name string) *mergedSourceFile {
+	return &mergedSourceFile{
+		SourceFile: &SourceFile{Name: name},
+		functions:  map[string]int{},
+		executions: map[string]int{},
+		lines:      map[int]int{},
+		branches:   map[branchKey]int{},
+	}
+}
+
+func (m *mergedSourceFile) add(sf *SourceFile) {
+	for _, f := range sf.FunctionInformation {
+		if _, ok := m.functions[f.Name]; !ok {
+			m.functions[f.Name] = f.Line
+		}
+	}
+	for _, f := range sf.FunctionExecutions {
+		m.executions[f.Name] += f.Executions
+	}
+	for _, l := range sf.LineInformation {
+		m.lines[l.Number] += l.Executions
+	}
+	for _, b := range sf.BranchInformation {
+		m.branches[branchKey{b.Line, b.Block, b.Number}] += b.Executions
+	}
+}
+
+// finish creates the sorted coverage information and the overview of
+// the merged source file
+func (m *mergedSourceFile) finish() {
+	for name, line := range m.functions {
+		m.FunctionInformation = append(m.FunctionInformation, Function{Name: name, Line: line})
+	}
+	sort.Slice(m.FunctionInformation, func(i, j int) bool {
+		a, b := m.FunctionInformation[i], m.FunctionInformation[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Name < b.Name
+	})
+	for _, f := range m.FunctionInformation {
+		executions := m.executions[f.Name]
+		m.FunctionExecutions = append(m.FunctionExecutions, FunctionExecution{Name: f.Name, Executions: executions})
+		if executions > 0 {
+			m.FunctionsHit++
+		}
+	}
+	m.FunctionsFound = len(m.FunctionInformation)
+
+	for number, executions := range m.lines {
+		m.LineInformation = append(m.LineInformation, Line{Number: number, Executions: executions})
+		if executions > 0 {
+			m.LinesHit++
+		}
+	}
+	sort.Slice(m.LineInformation, func(i, j int) bool {
+		return m.LineInformation[i].Number < m.LineInformation[j].Number
+	})
+	m.LinesFound = len(m.LineInformation)
+
+	for key, executions := range m.branches {
+		m.BranchInformation = append(m.BranchInformation, Branch{
+			Line:       key.line,
+			Block:      key.block,
+			Number:     key.number,
+			Executions: executions,
+		})
+		if executions > 0 {
+			m.BranchesHit++
+		}
+	}
+	sort.Slice(m.BranchInformation, func(i, j int) bool {
+		a, b := m.BranchInformation[i], m.BranchInformation[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Block != b.Block {
+			return a.Block < b.Block
+		}
+		return a.Number < b.Number
+	})
+	m.BranchesFound = len(m.BranchInformation)
+}
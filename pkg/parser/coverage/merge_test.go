@@ -0,0 +1,61 @@
+package coverage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeLCOVReports(t *testing.T) {
+	first := &LCOVReport{
+		SourceFiles: []*SourceFile{
+			{
+				Name:                "src/explore_me.cpp",
+				FunctionInformation: []Function{{Name: "exploreMe", Line: 2}},
+				FunctionExecutions:  []FunctionExecution{{Name: "exploreMe", Executions: 3}},
+				LineInformation:     []Line{{Number: 3, Executions: 3}, {Number: 4, Executions: 0}},
+				BranchInformation: []Branch{
+					{Line: 3, Number: 0, Executions: 3},
+					{Line: 3, Number: 1, Executions: 0},
+				},
+			},
+		},
+	}
+	second := &LCOVReport{
+		SourceFiles: []*SourceFile{
+			{
+				Name:                "src/explore_me.cpp",
+				FunctionInformation: []Function{{Name: "exploreMe", Line: 2}, {Name: "other", Line: 10}},
+				FunctionExecutions:  []FunctionExecution{{Name: "exploreMe", Executions: 1}},
+				LineInformation:     []Line{{Number: 4, Executions: 2}, {Number: 11, Executions: 0}},
+				BranchInformation:   []Branch{{Line: 3, Number: 1, Executions: 1}},
+			},
+			{
+				Name:            "src/main.cpp",
+				LineInformation: []Line{{Number: 1, Executions: 1}},
+			},
+		},
+	}
+
+	merged := MergeLCOVReports(first, second)
+	require.Len(t, merged.SourceFiles, 2)
+
+	sf := merged.SourceFiles[0]
+	assert.Equal(t, "src/explore_me.cpp", sf.Name)
+	assert.Equal(t, []Function{{Name: "exploreMe", Line: 2}, {Name: "other", Line: 10}}, sf.FunctionInformation)
+	assert.Equal(t, []FunctionExecution{{Name: "exploreMe", Executions: 4}, {Name: "other", Executions: 0}}, sf.FunctionExecutions)
+	assert.Equal(t, []Line{{Number: 3, Executions: 3}, {Number: 4, Executions: 2}, {Number: 11, Executions: 0}}, sf.LineInformation)
+	assert.Equal(t, []Branch{{Line: 3, Number: 0, Executions: 3}, {Line: 3, Number: 1, Executions: 1}}, sf.BranchInformation)
+	assert.Equal(t, Overview{
+		FunctionsFound: 2,
+		FunctionsHit:   1,
+		LinesFound:     3,
+		LinesHit:       2,
+		BranchesFound:  2,
+		BranchesHit:    2,
+	}, sf.Overview)
+
+	assert.Equal(t, "src/main.cpp", merged.SourceFiles[1].Name)
+	assert.Equal(t, 1, merged.SourceFiles[1].LinesHit)
+}
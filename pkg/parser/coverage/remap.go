@@ -0,0 +1,65 @@
+package coverage
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PathMapping replaces the prefix From of the paths of source files
+// with To
+type PathMapping struct {
+	From string
+	To   string
+}
+
+// ParsePathMappings parses mappings of the form "<from>=<to>". A
+// relative <from> path is interpreted relative to the base directory,
+// so that ".=" makes the paths of all source files in the base
+// directory relative.
+func ParsePathMappings(specs []string, baseDir string) ([]*PathMapping, error) {
+	var mappings []*PathMapping
+	for _, spec := range specs {
+		from, to, ok := strings.Cut(spec, "=")
+		if !ok || from == "" {
+			return nil, errors.Errorf("Invalid path mapping %q, it must have the form <from>=<to>", spec)
+		}
+		if !filepath.IsAbs(from) {
+			from = filepath.Join(baseDir, from)
+		}
+		mappings = append(mappings, &PathMapping{From: filepath.Clean(from), To: to})
+	}
+	return mappings, nil
+}
+
+// RemapPaths applies the first matching mapping to the path of each
+// source file. A mapping matches if the path is the From path or
+// contained in it. Source files without a matching mapping are kept
+// unchanged.
+func (r *LCOVReport) RemapPaths(mappings []*PathMapping) {
+	for _, sf := range r.SourceFiles {
+		for _, m := range mappings {
+			if path, ok := remapPath(sf.Name, m); ok {
+				sf.Name = path
+				break
+			}
+		}
+	}
+}
+
+func remapPath(path string, m *PathMapping) (string, bool) {
+	// The paths in the report might have symlinks resolved, so we
+	// also try the canonical path of the mapping
+	for _, from := range []string{m.From, canonicalPath(m.From)} {
+		rel, err := filepath.Rel(from, filepath.Clean(path))
+		if err != nil || !filepath.IsLocal(rel) {
+			continue
+		}
+		if m.To == "" {
+			return rel, true
+		}
+		return filepath.Join(m.To, rel), true
+	}
+	return "", false
+}
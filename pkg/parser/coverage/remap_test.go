@@ -0,0 +1,42 @@
+package coverage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemapPaths(t *testing.T) {
+	projectDir := t.TempDir()
+	mappings, err := ParsePathMappings([]string{"/build/src=src", ".="}, projectDir)
+	require.NoError(t, err)
+
+	report := &LCOVReport{SourceFiles: []*SourceFile{
+		{Name: "/build/src/parser.c"},
+		{Name: filepath.Join(projectDir, "lib", "util.c")},
+		{Name: "/build/srcgen/other.c"},
+		{Name: "/usr/include/stdio.h"},
+	}}
+	report.RemapPaths(mappings)
+
+	var names []string
+	for _, sf := range report.SourceFiles {
+		names = append(names, sf.Name)
+	}
+	assert.Equal(t, []string{
+		filepath.Join("src", "parser.c"),
+		filepath.Join("lib", "util.c"),
+		// Only complete path components are matched
+		"/build/srcgen/other.c",
+		"/usr/include/stdio.h",
+	}, names)
+}
+
+func TestParsePathMappings_Invalid(t *testing.T) {
+	_, err := ParsePathMappings([]string{"/build/src"}, "/project")
+	assert.Error(t, err)
+	_, err = ParsePathMappings([]string{"=src"}, "/project")
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package coverage
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// The generic test coverage format of SonarQube, see
+// https://docs.sonarsource.com/sonarqube/latest/analyzing-source-code/test-coverage/generic-test-data/
+type sonarQubeCoverage struct {
+	XMLName xml.Name         `xml:"coverage"`
+	Version int              `xml:"version,attr"`
+	Files   []*sonarQubeFile `xml:"file"`
+}
+
+type sonarQubeFile struct {
+	Path  string                  `xml:"path,attr"`
+	Lines []*sonarQubeLineToCover `xml:"lineToCover"`
+}
+
+type sonarQubeLineToCover struct {
+	LineNumber int  `xml:"lineNumber,attr"`
+	Covered    bool `xml:"covered,attr"`
+	// Both attributes must be set for lines with branches
+	BranchesToCover *int `xml:"branchesToCover,attr"`
+	CoveredBranches *int `xml:"coveredBranches,attr"`
+}
+
+// WriteSonarQubeReportToFile writes the report in the generic test
+// coverage format of SonarQube. The paths of the source files are made
+// relative to the source directory, which should be the base directory
+// of the SonarQube project.
+func (r *LCOVReport) WriteSonarQubeReportToFile(file string, sourceDir string) error {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	err = r.WriteSonarQubeReport(f, sourceDir)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to write to file '%s'", file)
+	}
+	log.Debugf("Successfully wrote SonarQube report to %s", file)
+	return nil
+}
+
+// WriteSonarQubeReport writes the report in the generic test coverage
+// format of SonarQube, see WriteSonarQubeReportToFile
+func (r *LCOVReport) WriteSonarQubeReport(w io.Writer, sourceDir string) error {
+	report := &sonarQubeCoverage{Version: 1}
+	for _, sf := range r.SourceFiles {
+		filename := sf.Name
+		if filepath.IsAbs(filename) {
+			rel, err := filepath.Rel(sourceDir, filename)
+			if err == nil && !strings.HasPrefix(rel, "..") {
+				filename = rel
+			}
+		}
+
+		// Aggregate the branches per line
+		branchesCovered := map[int]int{}
+		branchesValid := map[int]int{}
+		for _, b := range sf.BranchInformation {
+			branchesValid[b.Line]++
+			if b.Executions > 0 {
+				branchesCovered[b.Line]++
+			}
+		}
+
+		file := &sonarQubeFile{Path: filepath.ToSlash(filename)}
+		lines := append([]Line{}, sf.LineInformation...)
+		sort.Slice(lines, func(i, j int) bool { return lines[i].Number < lines[j].Number })
+		for _, l := range lines {
+			line := &sonarQubeLineToCover{LineNumber: l.Number, Covered: l.Executions > 0}
+			if valid := branchesValid[l.Number]; valid > 0 {
+				covered := branchesCovered[l.Number]
+				line.BranchesToCover = &valid
+				line.CoveredBranches = &covered
+			}
+			file.Lines = append(file.Lines, line)
+		}
+		report.Files = append(report.Files, file)
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(report)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = io.WriteString(w, "\n")
+	return errors.WithStack(err)
+}
@@ -0,0 +1,42 @@
+package coverage
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSonarQubeReport(t *testing.T) {
+	projectDir := filepath.Join(string(filepath.Separator), "project")
+	report := LCOVReport{
+		SourceFiles: []*SourceFile{
+			{
+				Name: filepath.Join(projectDir, "src", "explore_me.cpp"),
+				LineInformation: []Line{
+					{Number: 5, Executions: 0},
+					{Number: 4, Executions: 1},
+				},
+				BranchInformation: []Branch{
+					{Line: 4, Number: 0, Executions: 1},
+					{Line: 4, Number: 1, Executions: 0},
+					{Line: 5, Number: 0, Executions: 0},
+				},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	err := report.WriteSonarQubeReport(&out, projectDir)
+	require.NoError(t, err)
+	xml := out.String()
+
+	assert.Contains(t, xml, `<coverage version="1">`)
+	assert.Contains(t, xml, `<file path="src/explore_me.cpp">`)
+	assert.Contains(t, xml, `<lineToCover lineNumber="4" covered="true" branchesToCover="2" coveredBranches="1"></lineToCover>`)
+	assert.Contains(t, xml, `<lineToCover lineNumber="5" covered="false" branchesToCover="1" coveredBranches="0"></lineToCover>`)
+	// The lines are sorted
+	assert.Less(t, bytes.Index(out.Bytes(), []byte(`lineNumber="4"`)), bytes.Index(out.Bytes(), []byte(`lineNumber="5"`)))
+}
@@ -16,8 +16,26 @@ type Summary struct {
 }
 
 type FileCoverage struct {
+	// The path of the file relative to the project directory, with
+	// forward slashes on all OSes
 	Filename string
-	Coverage Overview
+	// The absolute path of the file on the host which created the
+	// report, only set if the report contained absolute paths
+	AbsoluteFilename string `json:",omitempty"`
+	Coverage         Overview
+}
+
+// NormalizePaths converts the file names to paths relative to the
+// project directory with forward slashes, so that summaries created on
+// different OSes are comparable. The absolute paths are kept in
+// AbsoluteFilename.
+func (cs *Summary) NormalizePaths(projectDir string) {
+	for _, file := range cs.Files {
+		if fileutil.IsAbs(file.Filename) {
+			file.AbsoluteFilename = file.Filename
+		}
+		file.Filename = fileutil.ProjectRelativePath(projectDir, file.Filename)
+	}
 }
 
 func (cs *Summary) PrintTable(writer io.Writer) {
@@ -33,7 +51,7 @@ func (cs *Summary) PrintTable(writer io.Writer) {
 	tableData := pterm.TableData{{"File", "Functions Hit/Found", "Lines Hit/Found", "Branches Hit/Found"}}
 	for _, file := range cs.Files {
 		tableData = append(tableData, []string{
-			fileutil.PrettifyPath(file.Filename),
+			file.displayName(),
 			formatCell(file.Coverage.FunctionsHit, file.Coverage.FunctionsFound),
 			formatCell(file.Coverage.LinesHit, file.Coverage.LinesFound),
 			formatCell(file.Coverage.BranchesHit, file.Coverage.BranchesFound),
@@ -60,3 +78,10 @@ func (cs *Summary) PrintTable(writer io.Writer) {
 	}
 	log.Print("\n")
 }
+
+func (f *FileCoverage) displayName() string {
+	if fileutil.IsAbs(f.Filename) {
+		return fileutil.PrettifyPath(f.Filename)
+	}
+	return f.Filename
+}
@@ -3,6 +3,7 @@ package coverage
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -38,3 +39,29 @@ end_of_record
 	assert.Contains(t, out, "0 / 0 (100.0%)")
 	assert.Contains(t, out, "3 / 22")
 }
+
+func TestSummary_NormalizePaths(t *testing.T) {
+	projectDir := t.TempDir()
+	absPath := filepath.Join(projectDir, "src", "foo.cpp")
+	summary := &Summary{Files: []*FileCoverage{
+		{Filename: absPath},
+		{Filename: "bar.cpp"},
+	}}
+
+	summary.NormalizePaths(projectDir)
+	assert.Equal(t, "src/foo.cpp", summary.Files[0].Filename)
+	assert.Equal(t, absPath, summary.Files[0].AbsoluteFilename)
+	assert.Equal(t, "bar.cpp", summary.Files[1].Filename)
+	assert.Empty(t, summary.Files[1].AbsoluteFilename)
+}
+
+func TestSummary_NormalizePaths_WindowsPaths(t *testing.T) {
+	summary := &Summary{Files: []*FileCoverage{
+		{Filename: `C:\Users\dev\project\src\foo.cpp`},
+	}}
+
+	summary.NormalizePaths(`C:\Users\dev\project`)
+	assert.Equal(t, "src/foo.cpp", summary.Files[0].Filename)
+	assert.Equal(t, `C:\Users\dev\project\src\foo.cpp`, summary.Files[0].AbsoluteFilename)
+	assert.Equal(t, "src/foo.cpp", summary.Files[0].displayName())
+}
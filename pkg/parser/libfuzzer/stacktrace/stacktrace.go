@@ -1,6 +1,8 @@
 package stacktrace
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"path/filepath"
 	"regexp"
@@ -16,6 +18,13 @@ import (
 var framePattern = regexp.MustCompile(
 	`#(?P<frame_number>\d+)\s+0x[a-fA-F0-9]+\s+in\s+(?P<function>(\(anonymous namespace\))?[^(\s]+).*\s(?P<source_file>\S+?):(?P<line>\d+):?(?P<column>\d*)`)
 
+// Matches addresses and offsets in function names of frames without
+// symbols, e.g. "libfoo.so+0x1234"
+var addressPattern = regexp.MustCompile(`\+?0x[a-fA-F0-9]+`)
+
+// The number of top stack frames which are used for the stack hash
+const stackHashFrames = 5
+
 // Special pattern for Java stack traces
 var framePatternJava = regexp.MustCompile(`^\s*at\s+(?P<function>[^(]*)\((?P<source_file>[^:]*):(?P<line>\d*)\)\s*$`)
 
@@ -31,11 +40,14 @@ var ubSanDiagPattern = regexp.MustCompile(`^(?P<source_file>\S+?):((?P<line>\d+)
 
 // A StackFrame represents an element of the stack trace
 type StackFrame struct {
-	SourceFile  string
-	Line        uint32
-	Column      uint32
-	FrameNumber uint32
-	Function    string
+	SourceFile string
+	// The absolute path of the source file, if SourceFile was made
+	// relative to the project directory
+	AbsoluteSourceFile string `json:",omitempty"`
+	Line               uint32
+	Column             uint32
+	FrameNumber        uint32
+	Function           string
 }
 
 func EncodeStackTrace(stacktrace []*StackFrame) []byte {
@@ -46,6 +58,36 @@ func EncodeStackTrace(stacktrace []*StackFrame) []byte {
 	return out
 }
 
+// StackHash returns a hash of the error type and the top frames of the
+// stack trace which identifies the root cause of a crash independently
+// of the crashing input. Only the function names and source files are
+// used, without addresses, lines and columns, so that the hash stays
+// the same when unrelated code in the same source file changes. The
+// error type is included so that for example a timeout and a crash in
+// the same function are not considered the same finding. If the stack
+// trace is empty, an empty string is returned.
+func StackHash(errorType string, stacktrace []*StackFrame) string {
+	var frames []string
+	for _, sf := range stacktrace {
+		function := strings.TrimSpace(addressPattern.ReplaceAllString(sf.Function, ""))
+		if function == "" && sf.SourceFile == "" {
+			continue
+		}
+		frames = append(frames, function+"|"+filepath.ToSlash(sf.SourceFile))
+		if len(frames) == stackHashFrames {
+			break
+		}
+	}
+	if len(frames) == 0 {
+		return ""
+	}
+	if errorType != "" {
+		frames = append([]string{errorType}, frames...)
+	}
+	hash := sha256.Sum256([]byte(strings.Join(frames, "\n")))
+	return hex.EncodeToString(hash[:8])
+}
+
 type ParserOptions struct {
 	ProjectDir      string
 	SourceMap       *sourcemap.SourceMap
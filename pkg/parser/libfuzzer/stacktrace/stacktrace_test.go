@@ -276,3 +276,37 @@ func TestEncodeStackTrace(t *testing.T) {
 	// should countain (27 chars + 15 separators)
 	assert.Len(t, result, 42)
 }
+
+func TestStackHash(t *testing.T) {
+	assert.Empty(t, StackHash("", nil))
+
+	st := []*StackFrame{
+		{SourceFile: "src/explore_me.cpp", Line: 13, Column: 11, FrameNumber: 0, Function: "exploreMe"},
+		{SourceFile: "my_fuzz_test.cpp", Line: 18, Column: 3, FrameNumber: 1, Function: "LLVMFuzzerTestOneInputNoReturn"},
+	}
+	hash := StackHash("", st)
+	assert.NotEmpty(t, hash)
+
+	// Lines, columns and frame numbers don't affect the hash
+	moved := []*StackFrame{
+		{SourceFile: "src/explore_me.cpp", Line: 42, Column: 5, FrameNumber: 3, Function: "exploreMe"},
+		{SourceFile: "my_fuzz_test.cpp", Line: 20, Column: 3, FrameNumber: 4, Function: "LLVMFuzzerTestOneInputNoReturn"},
+	}
+	assert.Equal(t, hash, StackHash("", moved))
+
+	// A different function does
+	other := []*StackFrame{
+		{SourceFile: "src/explore_me.cpp", Line: 13, Column: 11, FrameNumber: 0, Function: "exploreMeToo"},
+		{SourceFile: "my_fuzz_test.cpp", Line: 18, Column: 3, FrameNumber: 1, Function: "LLVMFuzzerTestOneInputNoReturn"},
+	}
+	assert.NotEqual(t, hash, StackHash("", other))
+
+	// Addresses in frames without symbols are ignored
+	assert.Equal(t,
+		StackHash("", []*StackFrame{{Function: "libfoo.so+0x1234"}}),
+		StackHash("", []*StackFrame{{Function: "libfoo.so+0xabcd"}}))
+
+	// So does the error type, but only if there is a stack trace
+	assert.NotEqual(t, StackHash("RUNTIME_ERROR", st), StackHash("CRASH", st))
+	assert.Empty(t, StackHash("CRASH", nil))
+}
@@ -0,0 +1,169 @@
+package reachability
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+	"code-intelligence.com/cifuzz/util/stringutil"
+)
+
+var (
+	// The label of a function, e.g. "0000000000001140 <LLVMFuzzerTestOneInput>:"
+	functionLabelRegex = regexp.MustCompile(`^[0-9a-fA-F]+ <(.+)>:\s*$`)
+	// An instruction with a symbolic target, e.g.
+	// "    1150:       callq   0x1160 <_Z3fooPKhm>"
+	instructionRegex = regexp.MustCompile(`^\s*[0-9a-fA-F]+:\s+([a-z][a-z.]*)\s+.*<([^>]+)>\s*$`)
+)
+
+// CallGraph maps the names of functions to the names of the functions
+// they call directly
+type CallGraph map[string][]string
+
+// Disassemble creates the call graph of the binaries from their
+// disassembly by llvm-objdump
+func Disassemble(ctx context.Context, binaries []string) (CallGraph, error) {
+	llvmObjdump, err := runfiles.Finder.LLVMObjdumpPath()
+	if err != nil {
+		return nil, err
+	}
+	// Symbol names on macOS start with an underscore which isn't part
+	// of the function names in the coverage report
+	trimUnderscore := runtime.GOOS == "darwin"
+
+	graph := CallGraph{}
+	for _, binary := range binaries {
+		cmd := exec.CommandContext(ctx, llvmObjdump, "--disassemble", "--no-show-raw-insn", binary)
+		log.Debugf("Command: %s", strings.Join(stringutil.QuotedStrings(cmd.Args), " "))
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		err = cmd.Start()
+		if err != nil {
+			return nil, cmdutils.WrapExecError(errors.WithStack(err), cmd)
+		}
+		err = graph.parseDisassembly(stdout, trimUnderscore)
+		if err != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			return nil, err
+		}
+		err = cmd.Wait()
+		if err != nil {
+			return nil, cmdutils.WrapExecError(errors.WithStack(err), cmd)
+		}
+	}
+	return graph, nil
+}
+
+// ParseDisassembly creates the call graph from the output of
+// 'llvm-objdump --disassemble'. Only direct calls and tail calls to the
+// start of a function are recorded, indirect calls via function
+// pointers or virtual methods can't be resolved statically. If
+// trimUnderscore is true, the leading underscore which is added to the
+// symbol names on macOS is removed.
+func ParseDisassembly(r io.Reader, trimUnderscore bool) (CallGraph, error) {
+	graph := CallGraph{}
+	err := graph.parseDisassembly(r, trimUnderscore)
+	if err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+func (g CallGraph) parseDisassembly(r io.Reader, trimUnderscore bool) error {
+	var function string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := functionLabelRegex.FindStringSubmatch(line); match != nil {
+			function = symbolName(match[1], trimUnderscore)
+			if _, ok := g[function]; !ok {
+				g[function] = nil
+			}
+			continue
+		}
+		if function == "" {
+			continue
+		}
+		match := instructionRegex.FindStringSubmatch(line)
+		if match == nil || !isCall(match[1]) {
+			continue
+		}
+		// Targets with an offset, e.g. <foo+0x10>, are jumps within a
+		// function
+		if strings.Contains(match[2], "+") {
+			continue
+		}
+		callee := symbolName(match[2], trimUnderscore)
+		if callee != function && !sliceutil.Contains(g[function], callee) {
+			g[function] = append(g[function], callee)
+		}
+	}
+	return errors.WithStack(scanner.Err())
+}
+
+// Merge adds the functions and calls of the other graph
+func (g CallGraph) Merge(other CallGraph) {
+	for function, callees := range other {
+		if _, ok := g[function]; !ok {
+			g[function] = nil
+		}
+		for _, callee := range callees {
+			if !sliceutil.Contains(g[function], callee) {
+				g[function] = append(g[function], callee)
+			}
+		}
+	}
+}
+
+// Reachable returns the functions which are reachable from the entry
+// function, including the entry function itself
+func (g CallGraph) Reachable(entry string) map[string]bool {
+	reachable := map[string]bool{entry: true}
+	queue := []string{entry}
+	for len(queue) > 0 {
+		function := queue[0]
+		queue = queue[1:]
+		for _, callee := range g[function] {
+			if !reachable[callee] {
+				reachable[callee] = true
+				queue = append(queue, callee)
+			}
+		}
+	}
+	return reachable
+}
+
+// isCall returns true if the mnemonic is a call or an unconditional
+// jump, which is used for tail calls, on x86 or ARM
+func isCall(mnemonic string) bool {
+	return strings.HasPrefix(mnemonic, "call") ||
+		strings.HasPrefix(mnemonic, "jmp") ||
+		mnemonic == "bl" ||
+		mnemonic == "b"
+}
+
+// symbolName removes the suffixes which llvm-objdump adds to the names
+// of PLT entries and versioned symbols, e.g. "memcpy@plt" or
+// "memcpy@GLIBC_2.14", so that calls into shared libraries are resolved
+// to the functions they define
+func symbolName(name string, trimUnderscore bool) string {
+	name, _, _ = strings.Cut(name, "@")
+	if trimUnderscore {
+		name = strings.TrimPrefix(name, "_")
+	}
+	return name
+}
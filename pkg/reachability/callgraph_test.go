@@ -0,0 +1,97 @@
+package reachability
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const x86Disassembly = `
+my_fuzz_test:	file format elf64-x86-64
+
+Disassembly of section .plt:
+
+0000000000001020 <memcpy@plt>:
+    1020:      	jmpq	*0x2fe2(%rip)           # 0x4008 <memcpy@GLIBC_2.14>
+
+Disassembly of section .text:
+
+0000000000001140 <LLVMFuzzerTestOneInput>:
+    1140:      	pushq	%rbp
+    1144:      	callq	0x1180 <_Z5parsePKhm>
+    1149:      	je	0x1150 <LLVMFuzzerTestOneInput+0x10>
+    114b:      	callq	0x1020 <memcpy@plt>
+    1150:      	jmp	0x11c0 <unused_tail_call>
+
+0000000000001180 <_Z5parsePKhm>:
+    1180:      	call	0x11a0 <check_magic>
+    1185:      	call	0x11a0 <check_magic>
+    118a:      	callq	*%rax
+    118c:      	jmp	0x1180 <_Z5parsePKhm>
+
+00000000000011a0 <check_magic>:
+    11a0:      	retq
+
+00000000000011c0 <unused_tail_call>:
+    11c0:      	retq
+
+00000000000011e0 <unreachable>:
+    11e0:      	callq	0x11a0 <check_magic>
+`
+
+const arm64Disassembly = `
+my_fuzz_test:	file format mach-o arm64
+
+Disassembly of section __TEXT,__text:
+
+0000000100003f00 <_LLVMFuzzerTestOneInput>:
+100003f00:     	bl	0x100003f40 <__Z5parsePKhm>
+100003f04:     	b.eq	0x100003f0c <_LLVMFuzzerTestOneInput+0xc>
+100003f08:     	b	0x100003f60 <_check_magic>
+
+0000000100003f40 <__Z5parsePKhm>:
+100003f40:     	blr	x8
+100003f44:     	ret
+`
+
+func TestParseDisassembly_X86(t *testing.T) {
+	graph, err := ParseDisassembly(strings.NewReader(x86Disassembly), false)
+	require.NoError(t, err)
+
+	assert.Equal(t, CallGraph{
+		"memcpy":                 nil,
+		"LLVMFuzzerTestOneInput": {"_Z5parsePKhm", "memcpy", "unused_tail_call"},
+		"_Z5parsePKhm":           {"check_magic"},
+		"check_magic":            nil,
+		"unused_tail_call":       nil,
+		"unreachable":            {"check_magic"},
+	}, graph)
+}
+
+func TestParseDisassembly_ARM64(t *testing.T) {
+	graph, err := ParseDisassembly(strings.NewReader(arm64Disassembly), true)
+	require.NoError(t, err)
+
+	assert.Equal(t, CallGraph{
+		"LLVMFuzzerTestOneInput": {"_Z5parsePKhm", "check_magic"},
+		"_Z5parsePKhm":           nil,
+	}, graph)
+}
+
+func TestCallGraph_Reachable(t *testing.T) {
+	graph := CallGraph{
+		"LLVMFuzzerTestOneInput": {"parse"},
+		"parse":                  {"check_magic", "parse"},
+		"unreachable":            {"check_magic"},
+	}
+	graph.Merge(CallGraph{"check_magic": {"library_function"}})
+
+	assert.Equal(t, map[string]bool{
+		"LLVMFuzzerTestOneInput": true,
+		"parse":                  true,
+		"check_magic":            true,
+		"library_function":       true,
+	}, graph.Reachable("LLVMFuzzerTestOneInput"))
+}
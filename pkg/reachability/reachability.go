@@ -0,0 +1,182 @@
+package reachability
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	parser "code-intelligence.com/cifuzz/pkg/parser/coverage"
+)
+
+// The function which libFuzzer calls with each input
+const EntryFunction = "LLVMFuzzerTestOneInput"
+
+// Report lists the functions which are statically reachable from the
+// fuzz test but were never executed while running it on the corpus.
+// Only functions which are instrumented for coverage, i.e. the
+// functions of the project, are taken into account.
+type Report struct {
+	FuzzTest           string `json:"fuzz_test"`
+	ReachableFunctions int    `json:"reachable_functions"`
+	CoveredFunctions   int    `json:"covered_functions"`
+	// The uncovered functions which are called by covered functions,
+	// sorted by the number of functions which are blocked by them
+	Gaps []*Gap `json:"gaps"`
+}
+
+// Gap is an uncovered function which is called by a covered function.
+// The fuzzer reached the caller but never took the path to the call,
+// so the gap points at a condition which the fuzzer couldn't satisfy or
+// at a part of the API which the fuzz test doesn't exercise.
+type Gap struct {
+	Function   string `json:"function"`
+	SourceFile string `json:"source_file"`
+	Line       int    `json:"line"`
+	// The covered functions which call the function
+	CalledBy []string `json:"called_by"`
+	// The number of uncovered functions which are only reachable
+	// through uncovered functions from this one, including itself
+	BlockedFunctions int `json:"blocked_functions"`
+}
+
+type function struct {
+	name       string
+	sourceFile string
+	line       int
+	executions int
+}
+
+// Analyze combines the call graph of the fuzz test with the function
+// coverage of the lcov report. Source file paths are made relative to
+// the project directory.
+func Analyze(fuzzTest string, graph CallGraph, report *parser.LCOVReport, projectDir string) *Report {
+	functions := instrumentedFunctions(report, projectDir)
+	reachable := graph.Reachable(EntryFunction)
+
+	r := &Report{FuzzTest: fuzzTest, Gaps: []*Gap{}}
+	callers := map[string][]string{}
+	for caller := range reachable {
+		for _, callee := range graph[caller] {
+			callers[callee] = append(callers[callee], caller)
+		}
+	}
+
+	covered := func(name string) bool {
+		f, ok := functions[name]
+		return ok && f.executions > 0
+	}
+
+	for name := range reachable {
+		f, ok := functions[name]
+		if !ok {
+			continue
+		}
+		r.ReachableFunctions++
+		if f.executions > 0 {
+			r.CoveredFunctions++
+			continue
+		}
+
+		var coveredCallers []string
+		for _, caller := range callers[name] {
+			if covered(caller) {
+				coveredCallers = append(coveredCallers, caller)
+			}
+		}
+		if len(coveredCallers) == 0 {
+			continue
+		}
+		sort.Strings(coveredCallers)
+		r.Gaps = append(r.Gaps, &Gap{
+			Function:         name,
+			SourceFile:       f.sourceFile,
+			Line:             f.line,
+			CalledBy:         coveredCallers,
+			BlockedFunctions: blockedFunctions(graph, functions, name),
+		})
+	}
+
+	sort.Slice(r.Gaps, func(i, j int) bool {
+		if r.Gaps[i].BlockedFunctions != r.Gaps[j].BlockedFunctions {
+			return r.Gaps[i].BlockedFunctions > r.Gaps[j].BlockedFunctions
+		}
+		return r.Gaps[i].Function < r.Gaps[j].Function
+	})
+	return r
+}
+
+// Save writes the report in JSON format to the given path
+func (r *Report) Save(path string) error {
+	bytes, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.WriteFile(path, bytes, 0o644)
+	return errors.WithStack(err)
+}
+
+// blockedFunctions returns the number of uncovered instrumented
+// functions which are reachable from the given one without passing a
+// covered function
+func blockedFunctions(graph CallGraph, functions map[string]*function, start string) int {
+	visited := map[string]bool{start: true}
+	queue := []string{start}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, callee := range graph[name] {
+			if visited[callee] {
+				continue
+			}
+			f, ok := functions[callee]
+			if !ok || f.executions > 0 {
+				continue
+			}
+			visited[callee] = true
+			queue = append(queue, callee)
+		}
+	}
+	return len(visited)
+}
+
+// instrumentedFunctions returns the functions listed in the lcov report
+// by their symbol name
+func instrumentedFunctions(report *parser.LCOVReport, projectDir string) map[string]*function {
+	functions := map[string]*function{}
+	for _, sf := range report.SourceFiles {
+		sourceFile := sf.Name
+		if rel, err := filepath.Rel(projectDir, sourceFile); err == nil && !strings.HasPrefix(rel, "..") {
+			sourceFile = rel
+		}
+		for _, fn := range sf.FunctionInformation {
+			name := symbolNameFromCoverage(fn.Name)
+			functions[name] = &function{name: name, sourceFile: filepath.ToSlash(sourceFile), line: fn.Line}
+		}
+		for _, fn := range sf.FunctionExecutions {
+			name := symbolNameFromCoverage(fn.Name)
+			if f, ok := functions[name]; ok {
+				f.executions += fn.Executions
+			}
+		}
+	}
+	return functions
+}
+
+// symbolNameFromCoverage returns the symbol name of a function in the
+// coverage report. The names of functions with internal linkage are
+// prefixed with the source file, e.g. "foo.c:bar" (or "foo.c;bar" with
+// newer LLVM versions), which isn't part of the symbol name.
+func symbolNameFromCoverage(name string) string {
+	if i := strings.LastIndexAny(name, ":;"); i != -1 {
+		return name[i+1:]
+	}
+	return name
+}
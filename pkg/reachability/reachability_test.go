@@ -0,0 +1,70 @@
+package reachability
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	parser "code-intelligence.com/cifuzz/pkg/parser/coverage"
+)
+
+func TestAnalyze(t *testing.T) {
+	projectDir := t.TempDir()
+	graph := CallGraph{
+		"LLVMFuzzerTestOneInput": {"_Z5parsePKhm", "memcpy"},
+		"_Z5parsePKhm":           {"parse_header", "parse_body"},
+		"parse_header":           nil,
+		"parse_body":             {"parse_chunk", "parse_header"},
+		"parse_chunk":            {"decompress"},
+		"decompress":             nil,
+		"unreachable":            {"decompress"},
+	}
+	report := &parser.LCOVReport{SourceFiles: []*parser.SourceFile{
+		{
+			Name: filepath.Join(projectDir, "src", "parser.c"),
+			FunctionInformation: []parser.Function{
+				{Name: "_Z5parsePKhm", Line: 3},
+				{Name: "parser.c:parse_header", Line: 10},
+				{Name: "parse_body", Line: 20},
+				{Name: "parse_chunk", Line: 30},
+				{Name: "decompress", Line: 40},
+				{Name: "unreachable", Line: 50},
+			},
+			FunctionExecutions: []parser.FunctionExecution{
+				{Name: "_Z5parsePKhm", Executions: 10},
+				{Name: "parser.c:parse_header", Executions: 10},
+				{Name: "parse_body", Executions: 0},
+				{Name: "parse_chunk", Executions: 0},
+				{Name: "decompress", Executions: 0},
+				{Name: "unreachable", Executions: 0},
+			},
+		},
+		{
+			Name: filepath.Join(projectDir, "fuzz_test.cpp"),
+			FunctionInformation: []parser.Function{
+				{Name: "LLVMFuzzerTestOneInput", Line: 5},
+			},
+			FunctionExecutions: []parser.FunctionExecution{
+				{Name: "LLVMFuzzerTestOneInput", Executions: 10},
+			},
+		},
+	}}
+
+	r := Analyze("my_fuzz_test", graph, report, projectDir)
+
+	assert.Equal(t, &Report{
+		FuzzTest:           "my_fuzz_test",
+		ReachableFunctions: 6,
+		CoveredFunctions:   3,
+		Gaps: []*Gap{
+			{
+				Function:         "parse_body",
+				SourceFile:       "src/parser.c",
+				Line:             20,
+				CalledBy:         []string{"_Z5parsePKhm"},
+				BlockedFunctions: 3,
+			},
+		},
+	}, r)
+}
@@ -55,6 +55,11 @@ func (f RunfilesFinderImpl) LLVMCovPath() (string, error) {
 	return path, err
 }
 
+func (f RunfilesFinderImpl) LLVMObjdumpPath() (string, error) {
+	path, err := f.llvmToolPath("llvm-objdump")
+	return path, errors.WithStack(err)
+}
+
 func (f RunfilesFinderImpl) LLVMProfDataPath() (string, error) {
 	path, err := f.llvmToolPath("llvm-profdata")
 	return path, errors.WithStack(err)
@@ -86,6 +91,11 @@ func (f RunfilesFinderImpl) GenHTMLPath() (string, error) {
 	return path, errors.WithStack(err)
 }
 
+func (f RunfilesFinderImpl) GcovrPath() (string, error) {
+	path, err := exec.LookPath("gcovr")
+	return path, errors.WithStack(err)
+}
+
 func (f RunfilesFinderImpl) PerlPath() (string, error) {
 	path, err := exec.LookPath("perl")
 	return path, errors.WithStack(err)
@@ -135,6 +145,10 @@ func (f RunfilesFinderImpl) ReplayerSourcePath() (string, error) {
 	return f.findFollowSymlinks("src/replayer.c")
 }
 
+func (f RunfilesFinderImpl) RecorderSourcePath() (string, error) {
+	return f.findFollowSymlinks("src/recorder.c")
+}
+
 func (f RunfilesFinderImpl) ListFuzzTestsJarPath() (string, error) {
 	return f.findFollowSymlinks("share/java/list-fuzz-tests.jar")
 }
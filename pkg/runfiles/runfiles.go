@@ -16,14 +16,17 @@ type RunfilesFinder interface {
 	JacocoAgentJarPath() (string, error)
 	JacocoCLIJarPath() (string, error)
 	LLVMCovPath() (string, error)
+	LLVMObjdumpPath() (string, error)
 	LLVMProfDataPath() (string, error)
 	LLVMSymbolizerPath() (string, error)
 	GenHTMLPath() (string, error)
+	GcovrPath() (string, error)
 	PerlPath() (string, error)
 	Minijail0Path() (string, error)
 	ProcessWrapperPath() (string, error)
 	DumperPath() (string, error)
 	ReplayerSourcePath() (string, error)
+	RecorderSourcePath() (string, error)
 	ListFuzzTestsJarPath() (string, error)
 	VisualStudioPath() (string, error)
 	VSCodeTasksPath() (string, error)
@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/docker/go-units"
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
 
@@ -51,10 +52,14 @@ type RunnerOptions struct {
 	SourceMap          *sourcemap.SourceMap
 	ReadOnlyBindings   []string
 	ReportHandler      report.Handler
+	ResourceLimits     *executil.ResourceLimits
 	SeedCorpusDirs     []string
 	Timeout            time.Duration
 	UseMinijail        bool
 	Verbose            bool
+	// Run the fuzzer in a network namespace without network access.
+	// Only applies if UseMinijail is set.
+	NoNetwork bool
 	// The path to the coverage binary to use to produce a coverage
 	// report after the fuzzer has finished. If empty, no coverage
 	// report is produced.
@@ -174,6 +179,7 @@ func (r *Runner) Run(ctx context.Context) error {
 			Args:      libfuzzerArgs,
 			Bindings:  bindings,
 			OutputDir: outputDir,
+			NoNetwork: r.NoNetwork,
 		})
 		if err != nil {
 			return err
@@ -207,6 +213,7 @@ func (r *Runner) RunLibfuzzerAndReport(ctx context.Context, args []string, env [
 	}
 	defer cancelCmdCtx()
 	r.cmd = executil.CommandContext(cmdCtx, args[0], args[1:]...)
+	r.cmd.ResourceLimits = r.ResourceLimits
 	r.cmd.Env, err = envutil.Copy(os.Environ(), env)
 	if err != nil {
 		return err
@@ -287,7 +294,11 @@ func (r *Runner) RunLibfuzzerAndReport(ctx context.Context, args []string, env [
 
 		// Wait until the reporter has finished parsing stderr, so that
 		// we can check below whether the reporter has found something
-		err := reporter.Parse(routinesCtx, stderrPipe, reportsCh)
+		var output io.Reader = stderrPipe
+		if r.UseMinijail && r.NoNetwork {
+			output = io.TeeReader(stderrPipe, minijail.NewNetworkErrorDetector())
+		}
+		err := reporter.Parse(routinesCtx, output, reportsCh)
 		if err != nil {
 			return err
 		}
@@ -319,6 +330,10 @@ func (r *Runner) RunLibfuzzerAndReport(ctx context.Context, args []string, env [
 				if !r.Verbose {
 					log.Print(startupOutput.String())
 				}
+				if r.ResourceLimits != nil && r.ResourceLimits.Memory > 0 {
+					log.Warnf("The fuzzer might have been killed because it exceeded the memory limit of %s",
+						units.BytesSize(float64(r.ResourceLimits.Memory)))
+				}
 				return cmdutils.WrapExecError(errors.WithStack(err), r.cmd.Cmd)
 			}
 
@@ -383,16 +398,12 @@ func (r *Runner) FuzzerEnvironment() ([]string, error) {
 	// setting our defaults but before setting sanitizer options,
 	// because there we take care of overriding options which we need
 	// to override and keeping other options.
-	if os.Getenv("ASAN_OPTIONS") != "" {
-		env, err = envutil.Setenv(env, "ASAN_OPTIONS", os.Getenv("ASAN_OPTIONS"))
-		if err != nil {
-			return nil, err
-		}
-	}
-	if os.Getenv("UBSAN_OPTIONS") != "" {
-		env, err = envutil.Setenv(env, "UBSAN_OPTIONS", os.Getenv("UBSAN_OPTIONS"))
-		if err != nil {
-			return nil, err
+	for _, key := range []string{"ASAN_OPTIONS", "UBSAN_OPTIONS", "TSAN_OPTIONS", "MSAN_OPTIONS", "LSAN_OPTIONS"} {
+		if os.Getenv(key) != "" {
+			env, err = envutil.Setenv(env, key, os.Getenv(key))
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 	env, err = fuzzer_runner.AddEnvFlags(env, r.EnvVars)
@@ -410,6 +421,16 @@ func (r *Runner) FuzzerEnvironment() ([]string, error) {
 		return nil, err
 	}
 
+	env, err = fuzzer_runner.SetCommonTSANOptions(env)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err = fuzzer_runner.SetCommonMSANOptions(env)
+	if err != nil {
+		return nil, err
+	}
+
 	overrideOptions := map[string]string{
 		// Per default this is set to false, except for darwin.
 		// To have consistent behavior on all supported operating systems
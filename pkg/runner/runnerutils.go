@@ -162,6 +162,36 @@ func SetCommonUBSANOptions(env []string) ([]string, error) {
 	return envutil.Setenv(env, "UBSAN_OPTIONS", options)
 }
 
+// SetCommonTSANOptions sets the TSAN_OPTIONS which are required to
+// detect and parse data races found by ThreadSanitizer
+func SetCommonTSANOptions(env []string) ([]string, error) {
+	return setCommonSanitizerOptions(env, "TSAN_OPTIONS")
+}
+
+// SetCommonMSANOptions sets the MSAN_OPTIONS which are required to
+// detect and parse uninitialized reads found by MemorySanitizer
+func SetCommonMSANOptions(env []string) ([]string, error) {
+	return setCommonSanitizerOptions(env, "MSAN_OPTIONS")
+}
+
+func setCommonSanitizerOptions(env []string, envVar string) ([]string, error) {
+	defaultOptions := maps.Clone(defaultSanitizerOptions)
+	overrideOptions := map[string]string{
+		// Use the same exit code as for ASan, see SetCommonASANOptions
+		"exitcode": strconv.Itoa(SanitizerErrorExitCode),
+		// Logs must be written to stderr for us to parse them.
+		"log_path": "stderr",
+	}
+
+	if log.PlainStyle() {
+		overrideOptions["color"] = "never"
+	}
+
+	options := envutil.Getenv(env, envVar)
+	options = SetSanitizerOptions(options, defaultOptions, overrideOptions)
+	return envutil.Setenv(env, envVar, options)
+}
+
 func AddEnvFlags(env []string, envVars []string) ([]string, error) {
 	var err error
 	for _, e := range envVars {
@@ -185,9 +215,12 @@ func FuzzerEnvironment() ([]string, error) {
 
 	// Tell the address sanitizer where it can find llvm-symbolizer.
 	// See https://clang.llvm.org/docs/AddressSanitizer.html#symbolizing-the-reports
+	// The fuzzer also works without llvm-symbolizer, only the stack
+	// traces are not symbolized then.
 	llvmSymbolizerPath, err := runfiles.Finder.LLVMSymbolizerPath()
 	if err != nil {
-		return nil, err
+		log.Debugf("Not symbolizing stack traces: %v", err)
+		return env, nil
 	}
 	// Resolve the path to the llvm-symbolizer to ensure that the path
 	// can be accessed inside the sandbox
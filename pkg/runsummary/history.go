@@ -0,0 +1,116 @@
+package runsummary
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The path of the run history, relative to the project directory. The
+// history is stored as one JSON object per line, so that a run only
+// has to append to it.
+var historyPath = filepath.Join(".cifuzz-build", "history.jsonl")
+
+// HistoryEntry is the metadata of a single run which is kept in the run
+// history. In contrast to Summary, it doesn't contain the findings
+// themselves, to keep the history small.
+type HistoryEntry struct {
+	FuzzTest      string        `json:"fuzz_test"`
+	Regression    bool          `json:"regression,omitempty"`
+	StartedAt     time.Time     `json:"started_at"`
+	Duration      time.Duration `json:"duration"`
+	Executions    uint64        `json:"executions"`
+	Edges         int32         `json:"edges"`
+	Features      int32         `json:"features"`
+	CorpusEntries uint          `json:"corpus_entries"`
+	NewFindings   int           `json:"new_findings"`
+	KnownFindings int           `json:"known_findings"`
+}
+
+// HistoryEntry returns the metadata of the run which is kept in the
+// run history
+func (s *Summary) HistoryEntry() *HistoryEntry {
+	e := &HistoryEntry{
+		FuzzTest:      s.FuzzTest,
+		Regression:    s.Regression,
+		StartedAt:     s.StartedAt,
+		Duration:      s.Duration,
+		CorpusEntries: s.CorpusEntries,
+		NewFindings:   len(s.NewFindings),
+		KnownFindings: len(s.KnownFindings),
+	}
+	if s.LastMetrics != nil {
+		e.Executions = s.LastMetrics.TotalExecutions
+		e.Edges = s.LastMetrics.Edges
+		e.Features = s.LastMetrics.Features
+	}
+	return e
+}
+
+// ExecutionsPerSecond returns the average number of executions per
+// second during the run
+func (e *HistoryEntry) ExecutionsPerSecond() uint64 {
+	seconds := uint64(e.Duration / time.Second)
+	if seconds == 0 {
+		return 0
+	}
+	return e.Executions / seconds
+}
+
+// AppendToHistory adds the run to the run history of the project
+func (s *Summary) AppendToHistory(projectDir string) error {
+	path := filepath.Join(projectDir, historyPath)
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	bytes, err := json.Marshal(s.HistoryEntry())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = f.Write(append(bytes, '\n'))
+	if err != nil {
+		_ = f.Close()
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(f.Close())
+}
+
+// LoadHistory returns the runs of the project in the order in which
+// they were added to the history. If the project wasn't run yet, an
+// empty history is returned.
+func LoadHistory(projectDir string) ([]*HistoryEntry, error) {
+	f, err := os.Open(filepath.Join(projectDir, historyPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	var history []*HistoryEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e HistoryEntry
+		err = json.Unmarshal(scanner.Bytes(), &e)
+		if err != nil {
+			// A run which was interrupted while writing the history
+			// can leave a truncated line, which we skip
+			continue
+		}
+		history = append(history, &e)
+	}
+	return history, errors.WithStack(scanner.Err())
+}
@@ -0,0 +1,43 @@
+package runsummary
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendToHistoryAndLoadHistory(t *testing.T) {
+	projectDir := t.TempDir()
+
+	history, err := LoadHistory(projectDir)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	summary := testSummary()
+	err = summary.AppendToHistory(projectDir)
+	require.NoError(t, err)
+	summary.FuzzTest = "other_fuzz_test"
+	err = summary.AppendToHistory(projectDir)
+	require.NoError(t, err)
+
+	// A truncated line is skipped
+	f, err := os.OpenFile(filepath.Join(projectDir, historyPath), os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"fuzz_test": "trunc`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	history, err = LoadHistory(projectDir)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "my_fuzz_test", history[0].FuzzTest)
+	assert.Equal(t, "other_fuzz_test", history[1].FuzzTest)
+	assert.Equal(t, int32(120), history[0].Edges)
+	assert.Equal(t, uint(10), history[0].CorpusEntries)
+	assert.Equal(t, 1, history[0].NewFindings)
+	assert.Equal(t, uint64(55), history[0].ExecutionsPerSecond())
+	assert.True(t, summary.StartedAt.Equal(history[0].StartedAt))
+}
@@ -0,0 +1,181 @@
+package runsummary
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+// Crashing inputs up to this size are embedded in the HTML report, so
+// that the report can be shared as a single file. Larger inputs are
+// linked relative to the project directory instead.
+const maxEmbeddedInputSize = 10 << 20
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>cifuzz report: {{.FuzzTest}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; }
+pre { background: #f6f8fa; padding: 0.8em; overflow-x: auto; }
+.finding { border: 1px solid #ccc; border-radius: 4px; padding: 0 1em; margin-bottom: 1.5em; }
+.new { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>cifuzz: {{.FuzzTest}}{{if .Regression}} (regression test){{end}}</h1>
+<p>Run started at {{.StartedAt}}</p>
+<h2>Summary</h2>
+<table>
+<tr><th>Duration</th><td>{{.Duration}}</td></tr>
+{{- range .Metrics}}
+<tr><th>{{.Name}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+<tr><th>Findings</th><td>{{len .NewFindings}} new, {{len .KnownFindings}} known</td></tr>
+</table>
+{{- if or .NewFindings .KnownFindings}}
+<h2>Findings</h2>
+{{- range .NewFindings}}{{template "finding" .}}{{end}}
+{{- range .KnownFindings}}{{template "finding" .}}{{end}}
+{{- else}}
+<p>No findings.</p>
+{{- end}}
+</body>
+</html>
+{{define "finding"}}
+<div class="finding">
+<h3{{if .New}} class="new"{{end}}>{{.Name}}: {{.Description}}</h3>
+<table>
+<tr><th>State</th><td>{{.State}}</td></tr>
+{{- if .Location}}
+<tr><th>Location</th><td>{{.Location}}</td></tr>
+{{- end}}
+{{- if .Signature}}
+<tr><th>Signature</th><td>{{.Signature}}</td></tr>
+{{- end}}
+{{- if .Input.Name}}
+<tr><th>Crashing input</th><td><a href="{{.Input.URL}}"{{if .Input.Embedded}} download="{{.Input.Name}}"{{end}}>{{.Input.Name}}</a></td></tr>
+{{- end}}
+</table>
+{{- if .StackTrace}}
+<h4>Stack trace</h4>
+<pre>{{range .StackTrace}}{{.}}
+{{end}}</pre>
+{{- end}}
+{{- if .Logs}}
+<h4>Output</h4>
+<pre>{{range .Logs}}{{.}}
+{{end}}</pre>
+{{- end}}
+</div>
+{{end}}`))
+
+type htmlReport struct {
+	*Summary
+	StartedAt     string
+	Duration      time.Duration
+	Metrics       []htmlMetric
+	NewFindings   []*htmlFinding
+	KnownFindings []*htmlFinding
+}
+
+type htmlMetric struct {
+	Name  string
+	Value string
+}
+
+type htmlFinding struct {
+	Name        string
+	Description string
+	New         bool
+	State       string
+	Location    string
+	Signature   string
+	Input       htmlInput
+	StackTrace  []string
+	Logs        []string
+}
+
+type htmlInput struct {
+	Name     string
+	URL      template.URL
+	Embedded bool
+}
+
+// WriteHTML writes a standalone HTML report of the run to w, which
+// contains the findings with their stack traces and crashing inputs
+func (s *Summary) WriteHTML(w io.Writer, projectDir string) error {
+	report := &htmlReport{
+		Summary:   s,
+		StartedAt: s.StartedAt.Format(time.RFC1123),
+		Duration:  s.Duration.Round(time.Second),
+	}
+	if s.LastMetrics != nil {
+		report.Metrics = append(report.Metrics, htmlMetric{"Executions", fmt.Sprintf("%d", s.LastMetrics.TotalExecutions)})
+	}
+	if s.FirstMetrics != nil && s.LastMetrics != nil {
+		report.Metrics = append(report.Metrics,
+			htmlMetric{"Edge coverage", growth(int64(s.FirstMetrics.Edges), int64(s.LastMetrics.Edges))},
+			htmlMetric{"Features", growth(int64(s.FirstMetrics.Features), int64(s.LastMetrics.Features))},
+		)
+	}
+	report.Metrics = append(report.Metrics,
+		htmlMetric{"Corpus entries", growth(int64(s.CorpusEntriesAtStart), int64(s.CorpusEntries))})
+
+	for _, f := range s.NewFindings {
+		report.NewFindings = append(report.NewFindings, newHTMLFinding(f, projectDir, "new"))
+	}
+	for _, f := range s.KnownFindings {
+		report.KnownFindings = append(report.KnownFindings, newHTMLFinding(f, projectDir, "known from the baseline"))
+	}
+
+	return errors.WithStack(htmlTemplate.Execute(w, report))
+}
+
+func newHTMLFinding(f *finding.Finding, projectDir string, state string) *htmlFinding {
+	hf := &htmlFinding{
+		Name:        f.Name,
+		Description: f.ShortDescriptionColumns()[0],
+		New:         state == "new",
+		State:       state,
+		Location:    f.SourceLocation(),
+		Signature:   f.Signature(),
+		Logs:        f.Logs,
+	}
+	for _, frame := range f.StackTrace {
+		hf.StackTrace = append(hf.StackTrace, fmt.Sprintf("#%d %s %s:%d:%d",
+			frame.FrameNumber, frame.Function, frame.SourceFile, frame.Line, frame.Column))
+	}
+
+	if f.InputFile == "" && len(f.InputData) == 0 {
+		return hf
+	}
+	hf.Input.Name = "crashing-input-" + f.Name
+	data, err := f.ReadInput(projectDir)
+	if err != nil {
+		log.Debugf("Failed to read the crashing input of finding %s: %v", f.Name, err)
+	}
+	if err != nil || len(data) > maxEmbeddedInputSize {
+		// The input can't be embedded, so we link to the input file,
+		// which is available to everyone who has a checkout of the
+		// project
+		hf.Input.Name = path.Base(f.InputFile)
+		hf.Input.URL = template.URL(f.InputFile)
+		return hf
+	}
+	hf.Input.Embedded = true
+	hf.Input.URL = template.URL("data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(data))
+	return hf
+}
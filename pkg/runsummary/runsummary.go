@@ -0,0 +1,118 @@
+// Package runsummary stores the results of the last fuzzing run of a
+// project, which `cifuzz report` and the GitHub Actions job summary are
+// created from
+package runsummary
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+// The path of the summary of the last run, relative to the project
+// directory
+var lastRunPath = filepath.Join(".cifuzz-build", "last-run.json")
+
+type Summary struct {
+	FuzzTest   string        `json:"fuzz_test"`
+	Regression bool          `json:"regression,omitempty"`
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration"`
+
+	FirstMetrics *report.FuzzingMetric `json:"first_metrics,omitempty"`
+	LastMetrics  *report.FuzzingMetric `json:"last_metrics,omitempty"`
+
+	// The number of entries of the seed and generated corpus at the
+	// start and end of the run
+	CorpusEntriesAtStart uint `json:"corpus_entries_at_start"`
+	CorpusEntries        uint `json:"corpus_entries"`
+
+	// The findings which aren't listed in the baseline
+	NewFindings []*finding.Finding `json:"new_findings,omitempty"`
+	// The findings which are listed in the baseline
+	KnownFindings []*finding.Finding `json:"known_findings,omitempty"`
+}
+
+// Save stores the summary as the summary of the last run of the project
+func (s *Summary) Save(projectDir string) error {
+	path := filepath.Join(projectDir, lastRunPath)
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	bytes, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(path, bytes, 0o644))
+}
+
+// LoadLast returns the summary of the last run of the project. If the
+// project wasn't run yet, an error which satisfies os.IsNotExist is
+// returned.
+func LoadLast(projectDir string) (*Summary, error) {
+	bytes, err := os.ReadFile(filepath.Join(projectDir, lastRunPath))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var s Summary
+	err = json.Unmarshal(bytes, &s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &s, nil
+}
+
+// Markdown returns a compact summary of the run in Markdown, which is
+// suitable for job summaries and comments on pull requests
+func (s *Summary) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### cifuzz: %s", s.FuzzTest)
+	if s.Regression {
+		b.WriteString(" (regression test)")
+	}
+	b.WriteString("\n\n")
+	b.WriteString("| | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Duration | %s |\n", s.Duration.Round(time.Second))
+	if s.LastMetrics != nil {
+		fmt.Fprintf(&b, "| Executions | %d |\n", s.LastMetrics.TotalExecutions)
+	}
+	if s.FirstMetrics != nil && s.LastMetrics != nil {
+		fmt.Fprintf(&b, "| Edge coverage | %s |\n", growth(int64(s.FirstMetrics.Edges), int64(s.LastMetrics.Edges)))
+	}
+	fmt.Fprintf(&b, "| Corpus entries | %s |\n", growth(int64(s.CorpusEntriesAtStart), int64(s.CorpusEntries)))
+	fmt.Fprintf(&b, "| Findings | %d new, %d known |\n\n", len(s.NewFindings), len(s.KnownFindings))
+
+	if len(s.NewFindings)+len(s.KnownFindings) > 0 {
+		b.WriteString("| Finding | Description | Location | State |\n|---|---|---|---|\n")
+		for _, f := range s.NewFindings {
+			writeFindingRow(&b, f, "new")
+		}
+		for _, f := range s.KnownFindings {
+			writeFindingRow(&b, f, "known from the baseline")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func growth(start, end int64) string {
+	return fmt.Sprintf("%d → %d (%+d)", start, end, end-start)
+}
+
+func writeFindingRow(b *strings.Builder, f *finding.Finding, state string) {
+	fmt.Fprintf(b, "| `%s` | %s | %s | %s |\n",
+		f.Name, escapeMarkdownCell(f.ShortDescriptionColumns()[0]), escapeMarkdownCell(f.SourceLocation()), state)
+}
+
+func escapeMarkdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
@@ -0,0 +1,83 @@
+package runsummary
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/finding"
+	"code-intelligence.com/cifuzz/pkg/parser/libfuzzer/stacktrace"
+	"code-intelligence.com/cifuzz/pkg/report"
+)
+
+func testSummary() *Summary {
+	return &Summary{
+		FuzzTest:             "my_fuzz_test",
+		StartedAt:            time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC),
+		Duration:             90 * time.Second,
+		FirstMetrics:         &report.FuzzingMetric{Edges: 100},
+		LastMetrics:          &report.FuzzingMetric{Edges: 120, TotalExecutions: 5000},
+		CorpusEntriesAtStart: 3,
+		CorpusEntries:        10,
+		NewFindings: []*finding.Finding{{
+			Name:     "funny_elephant",
+			Type:     finding.ErrorTypeCrash,
+			Details:  "heap-buffer-overflow",
+			FuzzTest: "my_fuzz_test",
+			StackTrace: []*stacktrace.StackFrame{
+				{Function: "exploreMe", SourceFile: "src/explore_me.cpp", Line: 13, Column: 11},
+			},
+		}},
+	}
+}
+
+func TestSummary_Markdown(t *testing.T) {
+	markdown := testSummary().Markdown()
+	assert.Contains(t, markdown, "### cifuzz: my_fuzz_test\n")
+	assert.Contains(t, markdown, "| Duration | 1m30s |\n")
+	assert.Contains(t, markdown, "| Executions | 5000 |\n")
+	assert.Contains(t, markdown, "| Edge coverage | 100 → 120 (+20) |\n")
+	assert.Contains(t, markdown, "| Corpus entries | 3 → 10 (+7) |\n")
+	assert.Contains(t, markdown, "| Findings | 1 new, 0 known |\n")
+	assert.Contains(t, markdown, "| `funny_elephant` | heap buffer overflow | src/explore_me.cpp:13:11 | new |\n")
+}
+
+func TestSaveAndLoadLast(t *testing.T) {
+	projectDir := t.TempDir()
+
+	_, err := LoadLast(projectDir)
+	require.Error(t, err)
+	assert.True(t, os.IsNotExist(errors.Cause(err)))
+
+	summary := testSummary()
+	err = summary.Save(projectDir)
+	require.NoError(t, err)
+
+	loaded, err := LoadLast(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, summary.Markdown(), loaded.Markdown())
+}
+
+func TestSummary_WriteHTML(t *testing.T) {
+	summary := testSummary()
+	summary.NewFindings[0].InputData = []byte("crash")
+	summary.NewFindings[0].Logs = []string{"ERROR: AddressSanitizer: heap-buffer-overflow <0x1234>"}
+
+	var out bytes.Buffer
+	err := summary.WriteHTML(&out, t.TempDir())
+	require.NoError(t, err)
+	html := out.String()
+	assert.Contains(t, html, "<h1>cifuzz: my_fuzz_test</h1>")
+	assert.Contains(t, html, "<tr><th>Corpus entries</th><td>3 → 10 (&#43;7)</td></tr>")
+	assert.Contains(t, html, "funny_elephant: heap buffer overflow")
+	assert.Contains(t, html, "#0 exploreMe src/explore_me.cpp:13:11")
+	// The log output is escaped
+	assert.Contains(t, html, "heap-buffer-overflow &lt;0x1234&gt;")
+	// The crashing input is embedded
+	assert.Contains(t, html, `href="data:application/octet-stream;base64,Y3Jhc2g=" download="crashing-input-funny_elephant"`)
+}
@@ -1,9 +1,10 @@
 package stubs
 
 import (
-	_ "embed"
+	"embed"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -28,8 +29,46 @@ var javaScriptStub []byte
 //go:embed test.fuzz.ts.tmpl
 var typeScriptStub []byte
 
+// templates contains the templates which can be selected via
+// `cifuzz create --template`, with one directory per template and the
+// same file names as the default stubs
+//
+//go:embed templates
+var templates embed.FS
+
+// Template is a template for fuzz tests which generates a more complete
+// starting point than the default stub
+type Template struct {
+	Name        string
+	Description string
+}
+
+// Templates are the templates which are available for all test types
+var Templates = []Template{
+	{"parser", "Passes the input to a parser, limiting its size"},
+	{"roundtrip", "Checks that decoding an encoded value returns the original value"},
+	{"rest-endpoint", "Sends requests with a fuzzed method, path, query and body to a REST endpoint"},
+	{"deserializer", "Passes the input to a deserializer and catches the expected exceptions"},
+}
+
+// TemplateNames returns the names of the available templates
+func TemplateNames() []string {
+	var names []string
+	for _, t := range Templates {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
 // Create creates a stub based for the given test type
 func Create(path string, testType config.FuzzTestType) error {
+	return CreateFromTemplate(path, testType, "")
+}
+
+// CreateFromTemplate creates a fuzz test for the given test type from
+// the template with the given name, or from the default stub if the name
+// is empty
+func CreateFromTemplate(path string, testType config.FuzzTestType, template string) error {
 	exists, err := fileutil.Exists(path)
 	if err != nil {
 		return err
@@ -42,13 +81,21 @@ func Create(path string, testType config.FuzzTestType) error {
 	var content []byte
 	switch testType {
 	case config.CPP:
-		content = cppStub
+		content, err = readTemplate(template, "fuzz-test.cpp.tmpl", cppStub)
+		if err != nil {
+			return err
+		}
 	case config.Java, config.Kotlin:
 		{
-			stub := string(javaStub)
+			stubFile, defaultStub := "fuzzTest.java.tmpl", javaStub
 			if testType == config.Kotlin {
-				stub = string(kotlinStub)
+				stubFile, defaultStub = "fuzzTest.ktl.tmpl", kotlinStub
+			}
+			bytes, err := readTemplate(template, stubFile, defaultStub)
+			if err != nil {
+				return err
 			}
+			stub := string(bytes)
 			fileNameExtension, found := config.TestTypeFileNameExtension(testType)
 			if !found {
 				panic(fmt.Sprintf("no file name extension found for test type %s", testType))
@@ -72,9 +119,15 @@ func Create(path string, testType config.FuzzTestType) error {
 			}
 		}
 	case config.JavaScript:
-		content = javaScriptStub
+		content, err = readTemplate(template, "test.fuzz.js.tmpl", javaScriptStub)
+		if err != nil {
+			return err
+		}
 	case config.TypeScript:
-		content = typeScriptStub
+		content, err = readTemplate(template, "test.fuzz.ts.tmpl", typeScriptStub)
+		if err != nil {
+			return err
+		}
 	}
 
 	// write stub
@@ -86,10 +139,31 @@ func Create(path string, testType config.FuzzTestType) error {
 	return nil
 }
 
-// FuzzTestFilename returns a proposal for a filename,
-// depending on the test type and given directory.
+// readTemplate returns the content of the given stub file of the
+// template, or the default stub if no template is selected
+func readTemplate(template, stubFile string, defaultStub []byte) ([]byte, error) {
+	if template == "" {
+		return defaultStub, nil
+	}
+	content, err := templates.ReadFile(path.Join("templates", template, stubFile))
+	if err != nil {
+		return nil, errors.Errorf("unknown template %q, available templates: %s",
+			template, strings.Join(TemplateNames(), ", "))
+	}
+	return content, nil
+}
+
+// FuzzTestFilename returns a proposal for a filename in the current
+// working directory, depending on the test type.
 // The filename should follow the conventions of the type.
 func FuzzTestFilename(testType config.FuzzTestType) (string, error) {
+	return FuzzTestFilenameInDir(".", testType)
+}
+
+// FuzzTestFilenameInDir returns a proposal for a filename,
+// depending on the test type and given directory.
+// The filename should follow the conventions of the type.
+func FuzzTestFilenameInDir(dir string, testType config.FuzzTestType) (string, error) {
 	var filePattern, basename, ext, filename string
 
 	switch testType {
@@ -118,7 +192,7 @@ func FuzzTestFilename(testType config.FuzzTestType) (string, error) {
 	}
 
 	for counter := 1; ; counter++ {
-		filename = filepath.Join(".", fmt.Sprintf(filePattern, basename, counter, ext))
+		filename = filepath.Join(dir, fmt.Sprintf(filePattern, basename, counter, ext))
 		exists, err := fileutil.Exists(filename)
 		if err != nil {
 			return "", err
@@ -210,3 +210,47 @@ func TestCreateJavaFileAndClassName(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, strings.Contains(string(testFile), "class "+strings.TrimSuffix(stubName, ".java")))
 }
+
+func TestCreateFromTemplate(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, baseTempDir, "project-")
+
+	stubFiles := map[config.FuzzTestType]string{
+		config.CPP:        "fuzz_test.cpp",
+		config.Java:       "FuzzTestCase.java",
+		config.Kotlin:     "FuzzTestCase.kt",
+		config.JavaScript: "FuzzTestCase.fuzz.js",
+		config.TypeScript: "FuzzTestCase.fuzz.ts",
+	}
+	for _, template := range TemplateNames() {
+		dir := filepath.Join(projectDir, template)
+		err := os.Mkdir(dir, 0o755)
+		require.NoError(t, err)
+
+		for testType, name := range stubFiles {
+			stubFile := filepath.Join(dir, name)
+			err = CreateFromTemplate(stubFile, testType, template)
+			require.NoError(t, err, "template %s, test type %s", template, testType)
+
+			content, err := os.ReadFile(stubFile)
+			require.NoError(t, err)
+			assert.NotContains(t, string(content), "__CLASS_NAME__")
+			assert.NotContains(t, string(content), "__PACKAGE__")
+			if testType == config.Java || testType == config.Kotlin {
+				assert.Contains(t, string(content), "class FuzzTestCase")
+			}
+		}
+	}
+}
+
+func TestCreateFromTemplate_Unknown(t *testing.T) {
+	projectDir := testutil.MkdirTemp(t, baseTempDir, "project-")
+
+	stubFile := filepath.Join(projectDir, "fuzz_test.cpp")
+	err := CreateFromTemplate(stubFile, config.CPP, "unknown")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parser")
+
+	exists, err := fileutil.Exists(stubFile)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,195 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The timeout for exporting the spans, which delays the exit of cifuzz
+// if the endpoint is not reachable
+const exportTimeout = 10 * time.Second
+
+// The subset of the OTLP/HTTP JSON encoding which we use, see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type exportRequest struct {
+	ResourceSpans []*resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource      `json:"resource"`
+	ScopeSpans []*scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []*attribute `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope       `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+type scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string       `json:"traceId"`
+	SpanID            string       `json:"spanId"`
+	ParentSpanID      string       `json:"parentSpanId,omitempty"`
+	Name              string       `json:"name"`
+	Kind              int          `json:"kind"`
+	StartTimeUnixNano string       `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string       `json:"endTimeUnixNano"`
+	Attributes        []*attribute `json:"attributes,omitempty"`
+	Status            status       `json:"status"`
+}
+
+type attribute struct {
+	Key   string         `json:"key"`
+	Value attributeValue `json:"value"`
+}
+
+type attributeValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	spanKindInternal = 1
+	statusCodeOK     = 1
+	statusCodeError  = 2
+)
+
+// Export sends the ended spans to the OTLP/HTTP endpoint (e.g.
+// http://localhost:4318) and discards them. Headers which the endpoint
+// requires, e.g. for authentication, can be set via the standard
+// OTEL_EXPORTER_OTLP_HEADERS environment variable.
+func Export(endpoint string, version string) error {
+	mutex.Lock()
+	spans := ended
+	ended = nil
+	mutex.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(newExportRequest(spans, version))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	tracesURL := strings.TrimRight(endpoint, "/") + "/v1/traces"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tracesURL, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")) {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("Exporting the spans to %s failed: %s", tracesURL, resp.Status)
+	}
+	return nil
+}
+
+func newExportRequest(spans []*Span, version string) *exportRequest {
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = "cifuzz"
+	}
+	res := resource{Attributes: []*attribute{
+		newAttribute("service.name", serviceName),
+		newAttribute("service.version", version),
+	}}
+
+	ss := &scopeSpans{Scope: scope{Name: "code-intelligence.com/cifuzz", Version: version}}
+	for _, s := range spans {
+		span := &otlpSpan{
+			TraceID:           s.traceID,
+			SpanID:            s.spanID,
+			ParentSpanID:      s.parentID,
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Status:            status{Code: statusCodeOK},
+		}
+		if s.err != nil {
+			span.Status = status{Code: statusCodeError, Message: s.err.Error()}
+		}
+		keys := make([]string, 0, len(s.attrs))
+		for key := range s.attrs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			span.Attributes = append(span.Attributes, newAttribute(key, s.attrs[key]))
+		}
+		ss.Spans = append(ss.Spans, span)
+	}
+
+	return &exportRequest{ResourceSpans: []*resourceSpans{{Resource: res, ScopeSpans: []*scopeSpans{ss}}}}
+}
+
+func newAttribute(key string, value any) *attribute {
+	a := &attribute{Key: key}
+	switch v := value.(type) {
+	case bool:
+		a.Value.BoolValue = &v
+	case int, int32, int64, uint, uint32, uint64:
+		s := fmt.Sprint(v)
+		a.Value.IntValue = &s
+	default:
+		s := fmt.Sprint(v)
+		a.Value.StringValue = &s
+	}
+	return a
+}
+
+// parseHeaders parses headers in the format of the
+// OTEL_EXPORTER_OTLP_HEADERS environment variable, i.e. a
+// comma-separated list of key=value pairs
+func parseHeaders(s string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, found := strings.Cut(pair, "=")
+		if !found || strings.TrimSpace(key) == "" {
+			continue
+		}
+		// The values are URL-encoded
+		value = strings.TrimSpace(value)
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		headers[strings.TrimSpace(key)] = value
+	}
+	return headers
+}
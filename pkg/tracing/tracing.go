@@ -0,0 +1,99 @@
+// Package tracing records the phases of a cifuzz command (e.g. building
+// and running the fuzz test) as OpenTelemetry spans, which are exported
+// to an OTLP endpoint when the command exits.
+//
+// cifuzz executes its phases one after the other, so instead of passing
+// the parent span via a context, the span which was started last and
+// not ended yet is the parent of new spans.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Span struct {
+	traceID  string
+	spanID   string
+	parentID string
+	name     string
+	start    time.Time
+	end      time.Time
+	attrs    map[string]any
+	err      error
+	parent   *Span
+}
+
+var (
+	mutex   sync.Mutex
+	traceID string
+	current *Span
+	ended   []*Span
+)
+
+// Start starts a span with the specified name and attributes, which
+// are passed as key-value pairs. The span must be ended via End.
+func Start(name string, keyValues ...any) *Span {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if traceID == "" {
+		traceID = randomID(16)
+	}
+	s := &Span{
+		traceID: traceID,
+		spanID:  randomID(8),
+		name:    name,
+		start:   time.Now(),
+		attrs:   map[string]any{},
+		parent:  current,
+	}
+	if current != nil {
+		s.parentID = current.spanID
+	}
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		s.attrs[fmt.Sprint(keyValues[i])] = keyValues[i+1]
+	}
+	current = s
+	return s
+}
+
+// SetName changes the name of the span
+func (s *Span) SetName(name string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	s.name = name
+}
+
+// SetAttribute adds an attribute to the span
+func (s *Span) SetAttribute(key string, value any) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	s.attrs[key] = value
+}
+
+// End ends the span. If err is not nil, the span is marked as failed.
+func (s *Span) End(err error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if !s.end.IsZero() {
+		return
+	}
+	s.end = time.Now()
+	s.err = err
+	ended = append(ended, s)
+	if current == s {
+		current = s.parent
+	}
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	// crypto/rand.Read never returns an error on supported platforms
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	var req *exportRequest
+	var authHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		authHeader = r.Header.Get("Authorization")
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &req))
+	}))
+	defer server.Close()
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Bearer%20secret")
+
+	root := Start("cifuzz run")
+	build := Start("build", "cifuzz.fuzz_test", "my_fuzz_test")
+	build.End(nil)
+	fuzz := Start("fuzz")
+	fuzz.End(errors.New("fuzzer crashed"))
+	root.End(nil)
+
+	err := Export(server.URL, "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret", authHeader)
+
+	require.Len(t, req.ResourceSpans, 1)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 3)
+	byName := map[string]*otlpSpan{}
+	for _, s := range spans {
+		byName[s.Name] = s
+	}
+
+	rootSpan := byName["cifuzz run"]
+	require.NotNil(t, rootSpan)
+	assert.Empty(t, rootSpan.ParentSpanID)
+	assert.Len(t, rootSpan.TraceID, 32)
+	assert.Len(t, rootSpan.SpanID, 16)
+
+	buildSpan := byName["build"]
+	require.NotNil(t, buildSpan)
+	assert.Equal(t, rootSpan.TraceID, buildSpan.TraceID)
+	assert.Equal(t, rootSpan.SpanID, buildSpan.ParentSpanID)
+	assert.Equal(t, statusCodeOK, buildSpan.Status.Code)
+	require.Len(t, buildSpan.Attributes, 1)
+	assert.Equal(t, "my_fuzz_test", *buildSpan.Attributes[0].Value.StringValue)
+
+	// The fuzz span was started after the build span ended, so its
+	// parent is the root span as well
+	fuzzSpan := byName["fuzz"]
+	require.NotNil(t, fuzzSpan)
+	assert.Equal(t, rootSpan.SpanID, fuzzSpan.ParentSpanID)
+	assert.Equal(t, statusCodeError, fuzzSpan.Status.Code)
+	assert.Equal(t, "fuzzer crashed", fuzzSpan.Status.Message)
+
+	// The exported spans are discarded
+	req = nil
+	err = Export(server.URL, "1.0.0")
+	require.NoError(t, err)
+	assert.Nil(t, req)
+}
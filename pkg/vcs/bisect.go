@@ -0,0 +1,118 @@
+package vcs
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+)
+
+type BisectVerdict string
+
+const (
+	BisectGood BisectVerdict = "good"
+	BisectBad  BisectVerdict = "bad"
+	// The commit can't be tested, e.g. because it doesn't build
+	BisectSkip BisectVerdict = "skip"
+)
+
+// GitBisect uses `git bisect` to find the first commit between the
+// good and the bad revision for which test returns BisectBad. The
+// commit to test is checked out in the Git repository containing dir
+// before test is called. It returns the full SHA of the first bad
+// commit. When the bisection is done or failed, the working tree is
+// reset to the commit which was checked out before. The working tree
+// must not contain uncommitted changes, which would otherwise be
+// carried along to the tested commits.
+func GitBisect(dir, good, bad string, test func(commit string) (BisectVerdict, error)) (string, error) {
+	cmd := exec.Command("git", "status", "--porcelain")
+	cmd.Dir = dir
+	status, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrap(cmdutils.WrapExecError(errors.WithStack(err), cmd), "Failed to get the status of the working tree")
+	}
+	if len(strings.TrimSpace(string(status))) != 0 {
+		return "", errors.Errorf("The working tree contains uncommitted changes, please commit or stash them before bisecting:\n%s",
+			strings.TrimRight(string(status), "\n"))
+	}
+
+	// git bisect start might leave a bisection behind even if it fails,
+	// so it's always reset
+	defer func() {
+		_, err := runGitBisect(dir, "reset")
+		if err != nil {
+			log.Warnf("Failed to reset the bisection: %v", err)
+		}
+	}()
+	out, err := runGitBisect(dir, "start", bad, good)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		firstBad, done, err := parseGitBisectOutput(out)
+		if err != nil || done {
+			return firstBad, err
+		}
+
+		commit, err := gitHead(dir)
+		if err != nil {
+			return "", err
+		}
+		verdict, err := test(commit)
+		if err != nil {
+			return "", err
+		}
+		out, err = runGitBisect(dir, string(verdict))
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+func gitHead(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", cmdutils.WrapExecError(errors.WithStack(err), cmd)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func runGitBisect(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"bisect"}, args...)...)
+	cmd.Dir = dir
+	log.Debugf("Command: %s", cmd.String())
+	out, err := cmd.CombinedOutput()
+	log.Debug(string(out))
+	// git bisect exits with a non-zero exit code if only skipped
+	// commits are left, which is handled by parseGitBisectOutput
+	if err != nil && !strings.Contains(string(out), "We cannot bisect more") {
+		return "", errors.Wrapf(err, "git bisect %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// parseGitBisectOutput returns the first bad commit and true if the
+// output of git bisect says that the bisection is done
+func parseGitBisectOutput(out string) (string, bool, error) {
+	if strings.Contains(out, "We cannot bisect more") {
+		// The output contains the commits which could be the first bad
+		// commit, which we pass on to the user
+		return "", true, errors.Errorf("The first bad commit can't be determined, because commits were skipped:\n%s",
+			strings.TrimSpace(out))
+	}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		commit, found := strings.CutSuffix(scanner.Text(), " is the first bad commit")
+		if found {
+			return commit, true, nil
+		}
+	}
+	return "", false, nil
+}
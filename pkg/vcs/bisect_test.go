@@ -0,0 +1,86 @@
+package vcs_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/pkg/vcs"
+)
+
+func TestGitBisect(t *testing.T) {
+	repo := createGitRepoWithCommits(t)
+	err := os.Chdir(repo)
+	require.NoError(t, err)
+
+	// Create commits which write their number to a file. The bug is
+	// introduced by commit 5.
+	var commits []string
+	for i := 1; i <= 8; i++ {
+		err = os.WriteFile(filepath.Join(repo, "version"), []byte(strconv.Itoa(i)), 0o644)
+		require.NoError(t, err)
+		runGit(t, repo, "add", "version")
+		runGit(t, repo, "commit", "-m", fmt.Sprintf("Commit %d", i))
+		commit, err := vcs.GitCommit()
+		require.NoError(t, err)
+		commits = append(commits, commit)
+	}
+
+	test := func(commit string) (vcs.BisectVerdict, error) {
+		content, err := os.ReadFile(filepath.Join(repo, "version"))
+		if err != nil {
+			return "", err
+		}
+		version, err := strconv.Atoi(strings.TrimSpace(string(content)))
+		if err != nil {
+			return "", err
+		}
+		if version >= 5 {
+			return vcs.BisectBad, nil
+		}
+		return vcs.BisectGood, nil
+	}
+	// The bisection runs in the given directory, independently of the
+	// current working directory
+	err = os.Chdir(t.TempDir())
+	require.NoError(t, err)
+	firstBad, err := vcs.GitBisect(repo, commits[0], commits[7], test)
+	require.NoError(t, err)
+	assert.Equal(t, commits[4], firstBad)
+
+	// The previously checked out commit is restored
+	err = os.Chdir(repo)
+	require.NoError(t, err)
+	commit, err := vcs.GitCommit()
+	require.NoError(t, err)
+	assert.Equal(t, commits[7], commit)
+
+	// If the first bad commit is surrounded by skipped commits, it
+	// can't be determined
+	testWithSkips := func(commit string) (vcs.BisectVerdict, error) {
+		if commit == commits[4] || commit == commits[3] {
+			return vcs.BisectSkip, nil
+		}
+		return test(commit)
+	}
+	_, err = vcs.GitBisect(repo, commits[0], commits[7], testWithSkips)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "commits were skipped")
+
+	// Bisecting a working tree with uncommitted changes fails without
+	// checking out any commit
+	err = os.WriteFile(filepath.Join(repo, "version"), []byte("42"), 0o644)
+	require.NoError(t, err)
+	_, err = vcs.GitBisect(repo, commits[0], commits[7], test)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "uncommitted changes")
+	commit, err = vcs.GitCommit()
+	require.NoError(t, err)
+	assert.Equal(t, commits[7], commit)
+}
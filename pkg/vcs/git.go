@@ -2,6 +2,9 @@ package vcs
 
 import (
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -33,6 +36,94 @@ func GitBranch() (string, error) {
 	return strings.TrimSpace(string(branch)), nil
 }
 
+// GitRootDir returns the top-level directory of the Git repository
+// which contains the working directory.
+func GitRootDir() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	dir, err := cmd.Output()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return strings.TrimSpace(string(dir)), nil
+}
+
+// GitRemoteURL returns the URL of the remote with the given name, e.g. "origin".
+func GitRemoteURL(remote string) (string, error) {
+	cmd := exec.Command("git", "remote", "get-url", remote)
+	url, err := cmd.Output()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return strings.TrimSpace(string(url)), nil
+}
+
+// GitChangedLines returns the lines which were added or modified in the
+// Git repository which contains dir, compared to the merge base of
+// baseRef and HEAD. Uncommitted changes of tracked files are included.
+// The keys of the returned map are the absolute paths of the files.
+func GitChangedLines(dir, baseRef string) (map[string][]int, error) {
+	cmd := exec.Command("git", "merge-base", baseRef, "HEAD")
+	cmd.Dir = dir
+	mergeBase, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, errors.Errorf("Failed to find the merge base of %q and HEAD: %s", baseRef, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	cmd = exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = dir
+	rootDir, err := cmd.Output()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	cmd = exec.Command("git", "-c", "core.quotePath=false", "diff", "--unified=0", "--no-color", "--no-ext-diff", strings.TrimSpace(string(mergeBase)))
+	cmd.Dir = dir
+	diff, err := cmd.Output()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return parseChangedLines(string(diff), strings.TrimSpace(string(rootDir))), nil
+}
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// parseChangedLines returns the added lines of a diff with zero lines of
+// context
+func parseChangedLines(diff, rootDir string) map[string][]int {
+	changedLines := map[string][]int{}
+	var file string
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "+++ ") {
+			file = ""
+			path := strings.TrimPrefix(line, "+++ ")
+			if strings.HasPrefix(path, "b/") {
+				file = filepath.Join(rootDir, filepath.FromSlash(strings.TrimPrefix(path, "b/")))
+			}
+			continue
+		}
+		if file == "" {
+			continue
+		}
+		match := hunkHeaderPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		start, _ := strconv.Atoi(match[1])
+		count := 1
+		if match[2] != "" {
+			count, _ = strconv.Atoi(match[2])
+		}
+		for i := 0; i < count; i++ {
+			changedLines[file] = append(changedLines[file], start+i)
+		}
+	}
+	return changedLines
+}
+
 // GitIsDirty returns true if and only if the current working directory is contained in a Git repository that has
 // uncommitted changes and/or untracked files.
 func GitIsDirty() bool {
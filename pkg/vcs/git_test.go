@@ -49,6 +49,55 @@ func TestGitCommit(t *testing.T) {
 	require.NotEqual(t, commit1, commit2)
 }
 
+func TestGitRemoteURLAndRootDir(t *testing.T) {
+	repo := createGitRepoWithCommits(t)
+	err := os.Chdir(repo)
+	require.NoError(t, err)
+
+	runGit(t, "", "remote", "add", "origin", "git@github.com:owner/repo.git")
+	url, err := vcs.GitRemoteURL("origin")
+	require.NoError(t, err)
+	require.Equal(t, "git@github.com:owner/repo.git", url)
+	_, err = vcs.GitRemoteURL("other")
+	require.Error(t, err)
+
+	require.NoError(t, os.Mkdir("subdir", 0o755))
+	require.NoError(t, os.Chdir("subdir"))
+	rootDir, err := vcs.GitRootDir()
+	require.NoError(t, err)
+	expected, err := filepath.EvalSymlinks(repo)
+	require.NoError(t, err)
+	require.Equal(t, expected, rootDir)
+}
+
+func TestGitChangedLines(t *testing.T) {
+	repo := createGitRepoWithCommits(t)
+	err := os.Chdir(repo)
+	require.NoError(t, err)
+
+	err = os.WriteFile("lines", []byte("1\n2\n3\n4\n"), 0o644)
+	require.NoError(t, err)
+	runGit(t, "", "add", "lines")
+	runGit(t, "", "commit", "-m", "Add lines")
+	runGit(t, "", "checkout", "-b", "feature")
+
+	// A committed and an uncommitted change
+	err = os.WriteFile("lines", []byte("1\nchanged\n3\n4\nadded\n"), 0o644)
+	require.NoError(t, err)
+	runGit(t, "", "commit", "-am", "Change lines")
+	err = os.WriteFile("lines", []byte("1\nchanged\n3\n4\nadded\nuncommitted\n"), 0o644)
+	require.NoError(t, err)
+
+	changedLines, err := vcs.GitChangedLines(repo, "main")
+	require.NoError(t, err)
+	rootDir, err := filepath.EvalSymlinks(repo)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]int{filepath.Join(rootDir, "lines"): {2, 5, 6}}, changedLines)
+
+	_, err = vcs.GitChangedLines(repo, "does-not-exist")
+	require.Error(t, err)
+}
+
 func TestGitIsDirty(t *testing.T) {
 	repo := createGitRepoWithCommits(t)
 	err := os.Chdir(repo)
@@ -0,0 +1,245 @@
+// Package webhook posts notifications about new findings to chat
+// services like Slack and Microsoft Teams via their incoming webhooks
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/sliceutil"
+)
+
+const (
+	FormatSlack = "slack"
+	FormatTeams = "teams"
+)
+
+var Formats = []string{FormatSlack, FormatTeams}
+
+// The maximum number of stack frames which are included in a message
+const maxStackFrames = 5
+
+var postTimeout = 10 * time.Second
+
+type Message struct {
+	Title string
+	// Details like the fuzz test and the location of the finding
+	Fields []*Field
+	// The frames of the stack trace, starting with the innermost one
+	StackTrace []string
+	// An optional link to more details, e.g. on the remote fuzzing
+	// server
+	URL string
+}
+
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Config is the webhook configuration, which is read from the
+// notification-* keys of cifuzz.yaml (or the corresponding CIFUZZ_*
+// environment variables)
+type Config struct {
+	URL string
+	// The channel to post to, overriding the default channel of the
+	// webhook. Only supported by Slack.
+	Channel string
+	// One of Formats. If it's empty, the format is determined from the
+	// URL of the webhook, with Slack as fallback, which is also
+	// understood by Mattermost and Rocket.Chat.
+	Format string
+}
+
+// ConfigFromViper returns the configured webhook or nil if no webhook
+// is configured
+func ConfigFromViper() *Config {
+	if viper.GetString("notification-webhook") == "" {
+		return nil
+	}
+	return &Config{
+		URL:     viper.GetString("notification-webhook"),
+		Channel: viper.GetString("notification-channel"),
+		Format:  viper.GetString("notification-format"),
+	}
+}
+
+// Validate checks that the URL and the format of the webhook are valid
+func (c *Config) Validate() error {
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.Errorf("Invalid notification webhook URL %q, it must be an http(s) URL", c.URL)
+	}
+	if c.Format != "" && !sliceutil.Contains(Formats, c.Format) {
+		return errors.Errorf("Invalid notification format %q, valid formats are: %s", c.Format, strings.Join(Formats, ", "))
+	}
+	return nil
+}
+
+// Notify posts the message to the webhook configured via cifuzz.yaml,
+// if any. Posting the message is not critical, so errors are only
+// logged.
+func Notify(msg *Message) {
+	c := ConfigFromViper()
+	if c == nil {
+		return
+	}
+	c.Notify(msg)
+}
+
+// Notify posts the message to the webhook. Like the Notify function, it
+// only logs errors.
+func (c *Config) Notify(msg *Message) {
+	err := c.Validate()
+	if err == nil {
+		err = c.Post(msg)
+	}
+	if err != nil {
+		log.Warnf("Failed to post notification about %q: %v", msg.Title, err)
+	}
+}
+
+// Post sends the message to the webhook
+func (c *Config) Post(msg *Message) error {
+	body, err := json.Marshal(c.payload(msg))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), postTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The error contains the URL, which includes the secret of the
+		// webhook, so we only return the cause
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return errors.WithStack(urlErr.Err)
+		}
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("The webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (c *Config) format() string {
+	if c.Format != "" {
+		return c.Format
+	}
+	u, err := url.Parse(c.URL)
+	if err == nil && (strings.HasSuffix(u.Hostname(), ".webhook.office.com") || u.Hostname() == "outlook.office.com") {
+		return FormatTeams
+	}
+	return FormatSlack
+}
+
+func (c *Config) payload(msg *Message) any {
+	frames := msg.StackTrace
+	if len(frames) > maxStackFrames {
+		frames = append(frames[:maxStackFrames:maxStackFrames], fmt.Sprintf("... %d more", len(msg.StackTrace)-maxStackFrames))
+	}
+
+	if c.format() == FormatTeams {
+		return teamsPayload(msg, frames)
+	}
+	return slackPayload(msg, frames, c.Channel)
+}
+
+// The payload of Slack's incoming webhooks, see
+// https://api.slack.com/messaging/webhooks
+type slackMessage struct {
+	Channel string `json:"channel,omitempty"`
+	Text    string `json:"text"`
+}
+
+func slackPayload(msg *Message, frames []string, channel string) *slackMessage {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*\n", msg.Title)
+	for _, f := range msg.Fields {
+		fmt.Fprintf(&b, "*%s:* %s\n", f.Name, f.Value)
+	}
+	if len(frames) > 0 {
+		fmt.Fprintf(&b, "```\n%s\n```\n", strings.Join(frames, "\n"))
+	}
+	if msg.URL != "" {
+		fmt.Fprintf(&b, "<%s|View details>\n", msg.URL)
+	}
+	return &slackMessage{Channel: channel, Text: strings.TrimSuffix(b.String(), "\n")}
+}
+
+// The message card format of Microsoft Teams' incoming webhooks, see
+// https://learn.microsoft.com/en-us/outlook/actionable-messages/message-card-reference
+type teamsMessageCard struct {
+	Type     string          `json:"@type"`
+	Context  string          `json:"@context"`
+	Summary  string          `json:"summary"`
+	Title    string          `json:"title"`
+	Sections []*teamsSection `json:"sections,omitempty"`
+	Actions  []*teamsAction  `json:"potentialAction,omitempty"`
+}
+
+type teamsSection struct {
+	Facts []*teamsFact `json:"facts,omitempty"`
+	Text  string       `json:"text,omitempty"`
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type teamsAction struct {
+	Type    string         `json:"@type"`
+	Name    string         `json:"name"`
+	Targets []*teamsTarget `json:"targets"`
+}
+
+type teamsTarget struct {
+	OS  string `json:"os"`
+	URI string `json:"uri"`
+}
+
+func teamsPayload(msg *Message, frames []string) *teamsMessageCard {
+	section := &teamsSection{}
+	for _, f := range msg.Fields {
+		section.Facts = append(section.Facts, &teamsFact{Name: f.Name, Value: f.Value})
+	}
+	if len(frames) > 0 {
+		section.Text = "<pre>" + html.EscapeString(strings.Join(frames, "\n")) + "</pre>"
+	}
+	card := &teamsMessageCard{
+		Type:     "MessageCard",
+		Context:  "https://schema.org/extensions",
+		Summary:  msg.Title,
+		Title:    msg.Title,
+		Sections: []*teamsSection{section},
+	}
+	if msg.URL != "" {
+		card.Actions = []*teamsAction{{
+			Type:    "OpenUri",
+			Name:    "View details",
+			Targets: []*teamsTarget{{OS: "default", URI: msg.URL}},
+		}}
+	}
+	return card
+}
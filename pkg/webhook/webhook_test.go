@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMessage = &Message{
+	Title:      "New finding: [funny_elephant] heap buffer overflow",
+	Fields:     []*Field{{Name: "Fuzz test", Value: "my_fuzz_test"}},
+	StackTrace: []string{"#0 a", "#1 b", "#2 c", "#3 d", "#4 e", "#5 f<int>"},
+	URL:        "https://app.example.com/dashboard/run/overview",
+}
+
+func TestPost_Slack(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		bytes, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(bytes, &body))
+	}))
+	defer server.Close()
+
+	c := &Config{URL: server.URL, Channel: "#fuzzing"}
+	require.NoError(t, c.Validate())
+	require.NoError(t, c.Post(testMessage))
+
+	assert.Equal(t, "#fuzzing", body["channel"])
+	assert.Equal(t, "*New finding: [funny_elephant] heap buffer overflow*\n"+
+		"*Fuzz test:* my_fuzz_test\n"+
+		"```\n#0 a\n#1 b\n#2 c\n#3 d\n#4 e\n... 1 more\n```\n"+
+		"<https://app.example.com/dashboard/run/overview|View details>", body["text"])
+}
+
+func TestPost_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	c := &Config{URL: server.URL + "/secret"}
+	err := c.Post(testMessage)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+func TestPayload_Teams(t *testing.T) {
+	c := &Config{URL: "https://example.webhook.office.com/webhookb2/123"}
+	card, ok := c.payload(testMessage).(*teamsMessageCard)
+	require.True(t, ok)
+	assert.Equal(t, testMessage.Title, card.Title)
+	assert.Equal(t, "my_fuzz_test", card.Sections[0].Facts[0].Value)
+	assert.Contains(t, card.Sections[0].Text, "... 1 more")
+	assert.Equal(t, testMessage.URL, card.Actions[0].Targets[0].URI)
+
+	// The stack trace is HTML-escaped
+	card = c.payload(&Message{StackTrace: []string{"#0 f<int>"}}).(*teamsMessageCard)
+	assert.Equal(t, "<pre>#0 f&lt;int&gt;</pre>", card.Sections[0].Text)
+
+	// The format can be set explicitly for other URLs
+	c = &Config{URL: "https://teams-proxy.example.com", Format: FormatTeams}
+	_, ok = c.payload(testMessage).(*teamsMessageCard)
+	assert.True(t, ok)
+}
+
+func TestValidate(t *testing.T) {
+	assert.Error(t, (&Config{URL: "hooks.slack.com/services/123"}).Validate())
+	assert.Error(t, (&Config{URL: "https://hooks.slack.com/services/123", Format: "discord"}).Validate())
+	assert.NoError(t, (&Config{URL: "https://hooks.slack.com/services/123", Format: FormatSlack}).Validate())
+}
@@ -11,6 +11,10 @@ else()
     file(REAL_PATH "${CMAKE_CURRENT_LIST_DIR}" CIFUZZ_CMAKE_DIR)
 endif()
 set(CIFUZZ_INCLUDE_DIR "${CIFUZZ_CMAKE_DIR}/../../include" CACHE INTERNAL "The include directory for the cifuzz headers")
+set(CIFUZZ_DATAFLOW_C_SRC "${CIFUZZ_CMAKE_DIR}/../../src/dataflow.c" CACHE INTERNAL "The path of the data-flow driver as a C source file.")
+set(CIFUZZ_DATAFLOW_CXX_SRC "${CIFUZZ_CMAKE_DIR}/../../src/dataflow.cpp" CACHE INTERNAL "The path of the data-flow driver as a CXX source file.")
+set(CIFUZZ_DATAFLOW_CALLBACKS_C_SRC "${CIFUZZ_CMAKE_DIR}/../../src/dataflow_callbacks.c" CACHE INTERNAL "The path of the data-flow callbacks as a C source file.")
+set(CIFUZZ_DATAFLOW_CALLBACKS_CXX_SRC "${CIFUZZ_CMAKE_DIR}/../../src/dataflow_callbacks.cpp" CACHE INTERNAL "The path of the data-flow callbacks as a CXX source file.")
 set(CIFUZZ_DUMPER_C_SRC "${CIFUZZ_CMAKE_DIR}/../../src/dumper.c" CACHE INTERNAL "The path of the dumper as a C source file.")
 set(CIFUZZ_DUMPER_CXX_SRC "${CIFUZZ_CMAKE_DIR}/../../src/dumper.cpp" CACHE INTERNAL "The path of the dumper as a CXX source file.")
 set(CIFUZZ_LAUNCHER_C_SRC "${CIFUZZ_CMAKE_DIR}/../../src/launcher.c" CACHE INTERNAL "The path of the launcher as a C source file.")
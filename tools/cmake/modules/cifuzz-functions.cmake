@@ -66,9 +66,9 @@ function(enable_fuzz_testing)
   endif()
 
   if(CIFUZZ_ENGINE STREQUAL libfuzzer)
-    # We also use the libfuzzer engine in coverage mode, but don't want fuzzing instrumentation to be applied in that
-    # case.
-    if(NOT coverage IN_LIST CIFUZZ_SANITIZERS)
+    # We also use the libfuzzer engine in coverage and data-flow mode, but don't want fuzzing instrumentation to be
+    # applied in that case.
+    if((NOT coverage IN_LIST CIFUZZ_SANITIZERS) AND (NOT dataflow IN_LIST CIFUZZ_SANITIZERS))
       add_compile_options(-fsanitize=fuzzer)
       if(WIN32)
           # On Windows the option "-fsanitize=fuzzer" doesn't take care of linking the runtime libraries
@@ -105,6 +105,18 @@ function(enable_fuzz_testing)
       if(NOT WIN32)
         add_link_options(-fsanitize=undefined)
       endif()
+    elseif(sanitizer STREQUAL thread)
+      add_compile_options(-fsanitize=thread)
+      add_link_options(-fsanitize=thread)
+    elseif(sanitizer STREQUAL memory)
+      add_compile_options(
+          -fsanitize=memory
+          -fsanitize-memory-track-origins
+          # Source fortification is not supported in combination with MSan
+          # either, see the comment for ASan above.
+          -U_FORTIFY_SOURCE
+      )
+      add_link_options(-fsanitize=memory)
     elseif(sanitizer STREQUAL coverage)
       add_compile_options(
           -fprofile-instr-generate
@@ -128,6 +140,16 @@ function(enable_fuzz_testing)
           "clang_rt.profile-x86_64.lib"
         )
       endif()
+    elseif(sanitizer STREQUAL dataflow)
+      if(WIN32)
+        message(FATAL_ERROR "cifuzz: data-flow builds are not supported on Windows")
+      endif()
+      # Track which input bytes influence the comparisons in each function, see src/dataflow.c.
+      add_compile_options(
+          -fsanitize=dataflow
+          -fsanitize-coverage=trace-pc-guard,pc-table,bb,trace-cmp
+      )
+      add_link_options(-fsanitize=dataflow)
     elseif(sanitizer STREQUAL gcov)
       if(WIN32)
         message(FATAL_ERROR "cifuzz: coverage builds are not yet supported")
@@ -208,6 +230,26 @@ function(add_fuzz_test name)
     elseif(CIFUZZ_SANITIZERS)
       target_compile_definitions("${name}" PRIVATE CIFUZZ_HAS_SANITIZER)
     endif()
+  elseif(CIFUZZ_ENGINE STREQUAL libfuzzer AND dataflow IN_LIST CIFUZZ_SANITIZERS)
+    # Data-flow builds are run by the data-flow driver instead of libFuzzer.
+    if(C IN_LIST _enabled_languages)
+      set(_dataflow_src "${CIFUZZ_DATAFLOW_C_SRC}")
+      set(_dataflow_callbacks_src "${CIFUZZ_DATAFLOW_CALLBACKS_C_SRC}")
+    else()
+      if (NOT CXX IN_LIST _enabled_languages)
+        message(FATAL "cifuzz: At least one of C and CXX has to be an enabled language")
+      endif()
+      set(_dataflow_src "${CIFUZZ_DATAFLOW_CXX_SRC}")
+      set(_dataflow_callbacks_src "${CIFUZZ_DATAFLOW_CALLBACKS_CXX_SRC}")
+    endif()
+    # The driver must not be instrumented for coverage and the callbacks must not be instrumented at all.
+    set_source_files_properties("${_dataflow_src}"
+                                PROPERTIES COMPILE_FLAGS
+                                "-fno-sanitize-coverage=trace-pc-guard,pc-table,trace-cmp")
+    set_source_files_properties("${_dataflow_callbacks_src}"
+                                PROPERTIES COMPILE_FLAGS
+                                "-fno-sanitize=dataflow -fno-sanitize-coverage=trace-pc-guard,pc-table,trace-cmp")
+    target_sources("${name}" PRIVATE "${_dataflow_src}" "${_dataflow_callbacks_src}")
   elseif(CIFUZZ_ENGINE STREQUAL libfuzzer)
     if(CMAKE_CXX_COMPILER_ID STREQUAL "Clang" OR ((NOT "CXX" IN_LIST _enabled_languages) AND (CMAKE_C_COMPILER_ID STREQUAL "Clang")))
       if(NOT WIN32)
@@ -240,7 +282,8 @@ function(add_fuzz_test name)
                                   "-fno-profile-instr-generate -fno-coverage-mapping")
     endif()
     target_sources("${name}" PRIVATE "${_launcher_src}")
-    if((address IN_LIST CIFUZZ_SANITIZERS) OR (undefined IN_LIST CIFUZZ_SANITIZERS))
+    if((address IN_LIST CIFUZZ_SANITIZERS) OR (undefined IN_LIST CIFUZZ_SANITIZERS) OR
+       (thread IN_LIST CIFUZZ_SANITIZERS) OR (memory IN_LIST CIFUZZ_SANITIZERS))
       # The macOS linker doesn't support --wrap, so we fall back to a different strategy that doesn't require any linker
       # flags.
       # See src/dumper.c for details.
@@ -0,0 +1,150 @@
+/*
+ * Based on:
+ * https://github.com/llvm/llvm-project/blob/main/compiler-rt/lib/fuzzer/dataflow/DataFlow.cpp
+ *
+ * Runs a fuzz test which was built with -fsanitize=dataflow on a single
+ * input and records which bytes of the input influence the comparisons
+ * in each function of the fuzz test.
+ *
+ * Usage: <fuzz test> <input file> <output file>
+ *
+ * The output file contains a line for each function whose comparisons
+ * are influenced by the input:
+ *
+ *   F<function index> <bytes>
+ *
+ * <bytes> contains one character per input byte, which is '1' if the
+ * byte influences the function and '0' otherwise. The functions are
+ * numbered in the order of the instrumentation. If the environment
+ * variable CIFUZZ_DATAFLOW_PRINT_FUNCTIONS is set, the names of the
+ * functions are printed in that order instead, see dataflow_callbacks.c.
+ *
+ * This file must be compiled with -fsanitize=dataflow but without
+ * -fsanitize-coverage, dataflow_callbacks.c without any instrumentation.
+ */
+#include <sanitizer/dfsan_interface.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+int LLVMFuzzerTestOneInput(const unsigned char *data, size_t size);
+__attribute__((weak)) int LLVMFuzzerInitialize(int *argc, char ***argv);
+
+/* Defined in dataflow_callbacks.c */
+extern size_t __cifuzz_dft_num_funcs;
+extern dfsan_label *__cifuzz_dft_func_labels;
+
+#ifdef __cplusplus
+}
+#endif
+
+static unsigned char *read_input(const char *path, size_t *size) {
+  FILE *f;
+  long len;
+  unsigned char *data;
+
+  f = fopen(path, "rb");
+  if (f == NULL) {
+    fprintf(stderr, "cifuzz: failed to open %s\n", path);
+    return NULL;
+  }
+  fseek(f, 0, SEEK_END);
+  len = ftell(f);
+  fseek(f, 0, SEEK_SET);
+  /* Allocate at least one byte so that malloc doesn't return NULL */
+  data = (unsigned char *) malloc(len + 1);
+  if (data == NULL || fread(data, 1, len, f) != (size_t) len) {
+    fprintf(stderr, "cifuzz: failed to read %s\n", path);
+    fclose(f);
+    free(data);
+    return NULL;
+  }
+  fclose(f);
+  *size = (size_t) len;
+  return data;
+}
+
+int main(int argc, char **argv) {
+  unsigned char *input;
+  unsigned char *data;
+  size_t size;
+  size_t num_iterations;
+  size_t iteration;
+  size_t i;
+  size_t func;
+  int influenced;
+  dfsan_label *labels;
+  FILE *out;
+
+  if (LLVMFuzzerInitialize) {
+    LLVMFuzzerInitialize(&argc, &argv);
+  }
+  if (argc != 3) {
+    fprintf(stderr, "Usage: %s <input file> <output file>\n", argv[0]);
+    return 1;
+  }
+
+  input = read_input(argv[1], &size);
+  if (input == NULL) {
+    return 1;
+  }
+
+  /* DFSan only supports 8 different labels, so the fuzz test is run
+   * once for each 8 bytes of the input, with one label per byte. */
+  num_iterations = (size + 7) / 8;
+  labels = (dfsan_label *) calloc(__cifuzz_dft_num_funcs * num_iterations + 1, sizeof(dfsan_label));
+  __cifuzz_dft_func_labels = (dfsan_label *) calloc(__cifuzz_dft_num_funcs + 1, sizeof(dfsan_label));
+  data = (unsigned char *) malloc(size + 1);
+  if (labels == NULL || __cifuzz_dft_func_labels == NULL || data == NULL) {
+    fprintf(stderr, "cifuzz: out of memory\n");
+    return 1;
+  }
+
+  for (iteration = 0; iteration < num_iterations; iteration++) {
+    /* Use a fresh copy of the input in each iteration in case the fuzz
+     * test modifies it */
+    memcpy(data, input, size);
+    dfsan_set_label(0, data, size);
+    for (i = iteration * 8; i < size && i < (iteration + 1) * 8; i++) {
+      dfsan_set_label((dfsan_label) (1 << (i % 8)), data + i, 1);
+    }
+    memset(__cifuzz_dft_func_labels, 0, __cifuzz_dft_num_funcs * sizeof(dfsan_label));
+    LLVMFuzzerTestOneInput(data, size);
+    for (func = 0; func < __cifuzz_dft_num_funcs; func++) {
+      labels[func * num_iterations + iteration] = __cifuzz_dft_func_labels[func];
+    }
+  }
+
+  out = fopen(argv[2], "w");
+  if (out == NULL) {
+    fprintf(stderr, "cifuzz: failed to open %s\n", argv[2]);
+    return 1;
+  }
+  for (func = 0; func < __cifuzz_dft_num_funcs; func++) {
+    influenced = 0;
+    for (iteration = 0; iteration < num_iterations; iteration++) {
+      if (labels[func * num_iterations + iteration] != 0) {
+        influenced = 1;
+      }
+    }
+    if (!influenced) {
+      continue;
+    }
+    fprintf(out, "F%lu ", (unsigned long) func);
+    for (i = 0; i < size; i++) {
+      fputc((labels[func * num_iterations + i / 8] & (1 << (i % 8))) ? '1' : '0', out);
+    }
+    fputc('\n', out);
+  }
+  fclose(out);
+
+  free(data);
+  free(labels);
+  free(input);
+  return 0;
+}
@@ -0,0 +1 @@
+dataflow.c
\ No newline at end of file
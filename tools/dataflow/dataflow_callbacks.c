@@ -0,0 +1,129 @@
+/*
+ * Based on:
+ * https://github.com/llvm/llvm-project/blob/main/compiler-rt/lib/fuzzer/dataflow/DataFlowCallbacks.cpp
+ *
+ * Coverage callbacks of the data-flow driver in dataflow.c, which track
+ * the function which is currently executed and collect the labels of
+ * the operands of its comparisons. This file must be compiled without
+ * any instrumentation.
+ */
+#include <sanitizer/common_interface_defs.h>
+#include <sanitizer/dfsan_interface.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+size_t __cifuzz_dft_num_funcs;
+/* The labels of the operands of the comparisons in each function. This
+ * is set by dataflow.c once the input was read. */
+dfsan_label *__cifuzz_dft_func_labels;
+
+static uint32_t *guards_begin;
+static size_t num_guards;
+/* The index of the current function plus one, or zero if no function
+ * was entered yet */
+static uint32_t current_func;
+
+/* The PCs in the PC table point to the beginning of a basic block,
+ * whereas the symbolizer expects return addresses */
+#if defined(__aarch64__) || defined(__arm__)
+#define NEXT_INSTRUCTION_PC(pc) ((pc) + 4)
+#else
+#define NEXT_INSTRUCTION_PC(pc) ((pc) + 1)
+#endif
+
+static void print_functions(const uintptr_t *pcs_begin, size_t num_pcs) {
+  char name[4096];
+  size_t i;
+
+  for (i = 0; i < num_pcs; i++) {
+    /* The lowest bit of the flags marks the entry block of a function */
+    if (!(pcs_begin[i * 2 + 1] & 1)) {
+      continue;
+    }
+    __sanitizer_symbolize_pc((void *) NEXT_INSTRUCTION_PC(pcs_begin[i * 2]), "%f", name, sizeof(name));
+    printf("%s\n", name);
+  }
+}
+
+void __sanitizer_cov_trace_pc_guard_init(uint32_t *start, uint32_t *stop) {
+  if (num_guards != 0) {
+    fprintf(stderr, "cifuzz: data-flow tracing only supports a single instrumented module\n");
+    abort();
+  }
+  guards_begin = start;
+  num_guards = stop - start;
+}
+
+void __sanitizer_cov_pcs_init(const uintptr_t *pcs_begin, const uintptr_t *pcs_end) {
+  size_t num_pcs = (pcs_end - pcs_begin) / 2;
+  size_t i;
+
+  if (num_pcs != num_guards) {
+    fprintf(stderr, "cifuzz: the number of PCs doesn't match the number of guards\n");
+    abort();
+  }
+  /* Number the guards of the function entry blocks, so that the guard
+   * callback can tell which function is entered */
+  for (i = 0; i < num_guards; i++) {
+    if (pcs_begin[i * 2 + 1] & 1) {
+      guards_begin[i] = (uint32_t) ++__cifuzz_dft_num_funcs;
+    } else {
+      guards_begin[i] = 0;
+    }
+  }
+
+  if (getenv("CIFUZZ_DATAFLOW_PRINT_FUNCTIONS") != NULL) {
+    print_functions(pcs_begin, num_pcs);
+    exit(0);
+  }
+}
+
+void __sanitizer_cov_trace_pc_guard(uint32_t *guard) {
+  if (*guard != 0) {
+    current_func = *guard;
+  }
+}
+
+void __sanitizer_cov_trace_pc_indir(uintptr_t callee) {
+  (void) callee;
+}
+
+static void add_labels(dfsan_label l1, dfsan_label l2) {
+  if (__cifuzz_dft_func_labels != NULL && current_func != 0) {
+    __cifuzz_dft_func_labels[current_func - 1] |= l1 | l2;
+  }
+}
+
+/* The comparison callbacks are called with the labels of their
+ * arguments, see the ABI list of DFSan */
+#define CIFUZZ_DFT_CMP_HOOK(name, type)                                              \
+  void __dfsw___sanitizer_cov_trace_##name(type a, type b, dfsan_label l1, dfsan_label l2) { \
+    (void) a;                                                                        \
+    (void) b;                                                                        \
+    add_labels(l1, l2);                                                              \
+  }
+
+CIFUZZ_DFT_CMP_HOOK(cmp1, uint8_t)
+CIFUZZ_DFT_CMP_HOOK(cmp2, uint16_t)
+CIFUZZ_DFT_CMP_HOOK(cmp4, uint32_t)
+CIFUZZ_DFT_CMP_HOOK(cmp8, uint64_t)
+CIFUZZ_DFT_CMP_HOOK(const_cmp1, uint8_t)
+CIFUZZ_DFT_CMP_HOOK(const_cmp2, uint16_t)
+CIFUZZ_DFT_CMP_HOOK(const_cmp4, uint32_t)
+CIFUZZ_DFT_CMP_HOOK(const_cmp8, uint64_t)
+
+void __dfsw___sanitizer_cov_trace_switch(uint64_t val, uint64_t *cases, dfsan_label l1, dfsan_label l2) {
+  (void) val;
+  (void) cases;
+  (void) l2;
+  add_labels(l1, 0);
+}
+
+#ifdef __cplusplus
+}
+#endif
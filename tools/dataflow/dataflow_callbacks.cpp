@@ -0,0 +1 @@
+dataflow_callbacks.c
\ No newline at end of file
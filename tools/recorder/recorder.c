@@ -0,0 +1,78 @@
+#define _GNU_SOURCE
+#include <dlfcn.h>
+#include <stddef.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <unistd.h>
+
+/*
+ * The recorder is preloaded into the unit tests of a project by
+ * `cifuzz corpus extract` to collect seed inputs for a fuzz test. It
+ * defines the function whose inputs are recorded, so that calls of the
+ * function which are resolved by the dynamic linker are redirected to
+ * it. Each call writes the data passed to the function to a new file in
+ * the directory specified by the CIFUZZ_RECORDER_OUTPUT_DIR environment
+ * variable and then calls the original function.
+ *
+ * The name of the function is passed via the CIFUZZ_RECORDED_FUNCTION
+ * macro when the recorder is compiled, for C++ functions it's the
+ * mangled name. The function must take a pointer to the data as its
+ * first and the size of the data as its second argument and return
+ * void, an integer or a pointer. Up to four additional integer or
+ * pointer arguments are passed to the original function unchanged.
+ */
+
+#ifndef CIFUZZ_RECORDED_FUNCTION
+#error "CIFUZZ_RECORDED_FUNCTION must be defined"
+#endif
+
+#define STRINGIFY_(x) #x
+#define STRINGIFY(x) STRINGIFY_(x)
+
+typedef uintptr_t (*recorded_function_t)(const void *, size_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t);
+
+static recorded_function_t original_function = NULL;
+static unsigned long num_recorded = 0;
+
+static void record_input(const void *data, size_t size) {
+  const char *output_dir = getenv("CIFUZZ_RECORDER_OUTPUT_DIR");
+  if (output_dir == NULL || (data == NULL && size != 0)) {
+    return;
+  }
+
+  const char *max_size = getenv("CIFUZZ_RECORDER_MAX_SIZE");
+  if (max_size != NULL && *max_size != '\0' && size > strtoull(max_size, NULL, 10)) {
+    return;
+  }
+
+  /*
+   * The unit tests might run in multiple processes, so the process ID
+   * is part of the file name. The files are renamed after the hash of
+   * their content by cifuzz afterwards.
+   */
+  char path[4096];
+  unsigned long n = __atomic_fetch_add(&num_recorded, 1, __ATOMIC_RELAXED);
+  snprintf(path, sizeof(path), "%s/input-%ld-%lu", output_dir, (long) getpid(), n);
+  FILE *file = fopen(path, "wb");
+  if (file == NULL) {
+    return;
+  }
+  if (size > 0) {
+    fwrite(data, 1, size, file);
+  }
+  fclose(file);
+}
+
+uintptr_t CIFUZZ_RECORDED_FUNCTION(const void *data, size_t size, uintptr_t arg2, uintptr_t arg3, uintptr_t arg4,
+                                   uintptr_t arg5) {
+  if (original_function == NULL) {
+    original_function = (recorded_function_t) dlsym(RTLD_NEXT, STRINGIFY(CIFUZZ_RECORDED_FUNCTION));
+    if (original_function == NULL) {
+      fprintf(stderr, "cifuzz recorder: failed to find %s: %s\n", STRINGIFY(CIFUZZ_RECORDED_FUNCTION), dlerror());
+      abort();
+    }
+  }
+  record_input(data, size);
+  return original_function(data, size, arg2, arg3, arg4, arg5);
+}
@@ -73,7 +73,13 @@ func Untar(r io.Reader, dest string) error {
 
 	// Create the hard links
 	for linkpath, targetpath := range hardlinks {
-		err := os.Link(targetpath, linkpath)
+		// The directory of the link doesn't necessarily contain any
+		// other files
+		err := os.MkdirAll(filepath.Dir(linkpath), 0755)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = os.Link(targetpath, linkpath)
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -33,6 +33,22 @@ type Cmd struct {
 	signalErr                       <-chan error
 	terminatedAfterContextDone      bool
 	terminatedAfterContextDoneMutex sync.Mutex
+	// Platform-specific state which is needed to terminate the process
+	// group
+	processGroup processGroup
+	// If set, the resources which the process of the command and all
+	// its child processes can use together are limited, via a cgroup on
+	// Linux and via the job object on Windows
+	ResourceLimits *ResourceLimits
+}
+
+// ResourceLimits are limits of the resources which the process tree of
+// a command can use
+type ResourceLimits struct {
+	// The maximum memory in bytes, or 0 to not limit the memory
+	Memory int64
+	// The maximum number of CPUs, e.g. 1.5, or 0 to not limit the CPUs
+	CPUs float64
 }
 
 func Command(name string, arg ...string) *Cmd {
@@ -113,6 +129,14 @@ func (c *Cmd) Start() error {
 		}
 	}
 
+	if c.ResourceLimits != nil && c.Cmd.Err == nil {
+		err := c.applyResourceLimits()
+		if err != nil {
+			c.closeDescriptors(c.CloseAfterWait)
+			return err
+		}
+	}
+
 	c.waitDone = make(chan struct{}, 1)
 
 	c.prepareProcessGroupTermination()
@@ -126,6 +150,15 @@ func (c *Cmd) Start() error {
 		return errors.WithStack(err)
 	}
 
+	err = c.assignProcessGroup()
+	if err != nil {
+		// Don't let the process run without the requested resource
+		// limits
+		_ = c.Process.Kill()
+		_ = c.Wait()
+		return err
+	}
+
 	if c.ctx != nil {
 		go func() {
 			select {
@@ -164,6 +197,7 @@ func (c *Cmd) Wait() error {
 	defer c.closeDescriptors(c.CloseAfterWait)
 
 	err := c.Cmd.Wait()
+	c.releaseProcessGroup()
 	if c.waitDone != nil {
 		close(c.waitDone)
 	}
@@ -96,6 +96,54 @@ func TestCmd_TerminateProcessGroup_With_StdoutTeePipe(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestCmd_TerminateProcessGroup_TerminatesChildren(t *testing.T) {
+	// Start a shell which starts a process which prints output and never
+	// exits on its own. The shell command must not be the last command,
+	// else the shell executes it directly instead of starting a child
+	// process.
+	path := buildYes(t)
+	var cmd *Cmd
+	if runtime.GOOS == "windows" {
+		cmd = Command("cmd.exe", "/d", "/c", path+" & exit 0")
+	} else {
+		cmd = Command("sh", "-c", path+"; true")
+	}
+	cmd.Stderr = os.Stderr
+	pipe, err := cmd.StdoutTeePipe(io.Discard)
+	require.NoError(t, err)
+
+	err = cmd.Start()
+	require.NoError(t, err)
+
+	// Wait until the child process printed output
+	reader := bufio.NewReader(pipe)
+	_, err = reader.ReadString('\n')
+	require.NoError(t, err)
+
+	go func() {
+		err := cmd.TerminateProcessGroup()
+		require.NoError(t, err)
+	}()
+	err = cmd.Wait()
+	require.Error(t, err)
+
+	// The pipe is only closed once the child process has exited as well
+	readDone := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, reader)
+		readDone <- err
+	}()
+	select {
+	case err = <-readDone:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "The child process was not terminated")
+	}
+
+	err = pipe.Close()
+	require.NoError(t, err)
+}
+
 func TestCmd_StdoutTeePipe_ReadAsync(t *testing.T) {
 	// Pipe stdout to a file
 	outFile, err := os.CreateTemp("", "outFile")
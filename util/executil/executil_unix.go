@@ -67,6 +67,10 @@ func (c *Cmd) TerminateProcessGroup() error {
 	return nil
 }
 
+// On Unix, the process group is identified by the process ID of the
+// command, so no additional state is needed
+type processGroup struct{}
+
 func (c *Cmd) prepareProcessGroupTermination() {
 	if c.SysProcAttr == nil {
 		c.SysProcAttr = &syscall.SysProcAttr{}
@@ -80,3 +84,13 @@ func (c *Cmd) prepareProcessGroupTermination() {
 	// (see setpgid(2)).
 	c.SysProcAttr.Pgid = 0
 }
+
+func (c *Cmd) assignProcessGroup() error {
+	// The process group is created by the kernel, see
+	// prepareProcessGroupTermination
+	return nil
+}
+
+func (c *Cmd) releaseProcessGroup() {
+	// Nothing to release on Unix
+}
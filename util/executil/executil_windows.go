@@ -1,20 +1,42 @@
 package executil
 
 import (
+	"math"
 	"os"
 	"os/exec"
+	"runtime"
 	"strconv"
+	"sync"
 	"syscall"
+	"unsafe"
 
 	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+
+	"code-intelligence.com/cifuzz/pkg/log"
 )
 
+// On Windows, there are no process groups like on Unix which can be
+// terminated as a whole, so the process of the command is assigned to
+// a job object. Child processes which the command starts are assigned
+// to the job object automatically, which allows terminating all of
+// them, even if the process of the command already exited or was
+// terminated before its children (e.g. java.exe or node.exe started by
+// a wrapper script).
+type processGroup struct {
+	// The job object which contains the process of the command, or 0
+	// if the job object couldn't be created. The mutex guards against
+	// terminating the job object while it's released by Wait.
+	job   windows.Handle
+	mutex sync.Mutex
+}
+
 // IsTerminatedExitErr returns true if the wait status is the
 // wait status that is expected when the process was terminated via
 // Cmd.TerminateProcessGroup.
 //
-// On Windows, processes which are terminated via taskkill exit with
-// exit code 1.
+// On Windows, processes which are terminated via their job object or
+// via taskkill exit with exit code 1.
 func IsTerminatedExitErr(err error) bool {
 	var exitErr *exec.ExitError
 	if !errors.As(err, &exitErr) {
@@ -23,18 +45,35 @@ func IsTerminatedExitErr(err error) bool {
 	return exitErr.Sys().(syscall.WaitStatus).ExitCode == 1
 }
 
-// TerminateProcessGroup uses the taskkill command with the /t and /f
-// parameters to forcefully terminate the process of the command and
-// any child processes started by it.
+// TerminateProcessGroup terminates the process of the command and all
+// child processes started by it, by terminating the job object which
+// they were assigned to. If the job object couldn't be created, the
+// taskkill command with the /t and /f parameters is used instead, which
+// can't terminate child processes whose parent already exited.
 // See https://docs.microsoft.com/en-us/windows-server/administration/windows-commands/taskkill
 //
+// In contrast to Unix, Windows has no graceful termination via SIGTERM,
+// so the processes are terminated immediately.
+//
 // Important: Note that on Windows, when using StdoutTeePipe or
-// StderrTeePipe, TerminateProcessGroup doesn't cause the process
-// to exit if the pipes were not closed yet. In that case, Wait will
-// block until the pipes are closed.
+// StderrTeePipe and the process was not assigned to a job object,
+// TerminateProcessGroup doesn't cause the process to exit if the pipes
+// were not closed yet. In that case, Wait will block until the pipes
+// are closed.
 func (c *Cmd) TerminateProcessGroup() error {
 	defer c.closeDescriptors(c.CloseAfterWait)
 
+	c.processGroup.mutex.Lock()
+	defer c.processGroup.mutex.Unlock()
+	if c.processGroup.job != 0 {
+		log.Infof("Terminating job object of process %d", c.Process.Pid)
+		err := windows.TerminateJobObject(c.processGroup.job, 1)
+		if err == nil {
+			return nil
+		}
+		log.Debugf("Failed to terminate job object, falling back to taskkill: %v", err)
+	}
+
 	// Based on https://stackoverflow.com/a/44551450/2804197
 	// Original author: https://stackoverflow.com/users/301049/rots
 	kill := exec.Command("TASKKILL", "/T", "/F", "/PID", strconv.Itoa(c.Process.Pid))
@@ -50,5 +89,130 @@ func (c *Cmd) TerminateProcessGroup() error {
 }
 
 func (c *Cmd) prepareProcessGroupTermination() {
-	// Nothing to prepare on Windows
+	// The job object can only be assigned after the process was
+	// started, see assignProcessGroup
+}
+
+// applyResourceLimits doesn't need to change the command, because the
+// resource limits are enforced by the job object, see
+// assignProcessGroup
+func (c *Cmd) applyResourceLimits() error {
+	return nil
+}
+
+// assignProcessGroup assigns the started process to a new job object.
+// Child processes which the process started before it was assigned are
+// not part of the job object, but the window for that is very small.
+//
+// The job object is configured to terminate all its processes when the
+// last handle to it is closed, so that they are also terminated when
+// cifuzz itself is killed. It also enforces the resource limits of the
+// command, if any. If the job object can't be created, the process
+// keeps running without it, unless resource limits were requested, in
+// which case an error is returned.
+func (c *Cmd) assignProcessGroup() error {
+	job, err := c.createJobObject()
+	if err != nil {
+		if c.ResourceLimits != nil {
+			return errors.WithMessage(err, "Failed to apply resource limits")
+		}
+		log.Debug(err)
+		return nil
+	}
+
+	c.processGroup.mutex.Lock()
+	c.processGroup.job = job
+	c.processGroup.mutex.Unlock()
+	return nil
+}
+
+func (c *Cmd) createJobObject() (windows.Handle, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to create job object")
+	}
+	err = setJobLimits(job, true, c.ResourceLimits)
+	if err != nil {
+		_ = windows.CloseHandle(job)
+		return 0, errors.WithMessage(err, "Failed to configure job object")
+	}
+
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(c.Process.Pid))
+	if err != nil {
+		_ = windows.CloseHandle(job)
+		return 0, errors.Wrapf(err, "Failed to open process %d", c.Process.Pid)
+	}
+	defer windows.CloseHandle(process)
+	err = windows.AssignProcessToJobObject(job, process)
+	if err != nil {
+		_ = windows.CloseHandle(job)
+		return 0, errors.Wrapf(err, "Failed to assign process %d to job object", c.Process.Pid)
+	}
+	return job, nil
+}
+
+// releaseProcessGroup closes the job object after the process of the
+// command exited. Like on Unix, child processes which are still running
+// (e.g. a daemon started by the command) are not terminated.
+func (c *Cmd) releaseProcessGroup() {
+	c.processGroup.mutex.Lock()
+	defer c.processGroup.mutex.Unlock()
+	if c.processGroup.job == 0 {
+		return
+	}
+	err := setJobLimits(c.processGroup.job, false, nil)
+	if err != nil {
+		log.Debugf("Failed to configure job object: %v", err)
+	}
+	_ = windows.CloseHandle(c.processGroup.job)
+	c.processGroup.job = 0
+}
+
+// The flags of JOBOBJECT_CPU_RATE_CONTROL_INFORMATION, which are not
+// defined by golang.org/x/sys/windows
+const (
+	jobObjectCPURateControlEnable  = 0x1
+	jobObjectCPURateControlHardCap = 0x4
+)
+
+// jobObjectCPURateControlInformation is the
+// JOBOBJECT_CPU_RATE_CONTROL_INFORMATION structure, with the union
+// member CpuRate as the value
+type jobObjectCPURateControlInformation struct {
+	ControlFlags uint32
+	CPURate      uint32
+}
+
+// setJobLimits configures whether the processes of the job object are
+// terminated when the job object is closed and the resource limits of
+// the job object. The limits are not changed if they are nil.
+func setJobLimits(job windows.Handle, killOnJobClose bool, limits *ResourceLimits) error {
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{}
+	if killOnJobClose {
+		info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	}
+	if limits != nil && limits.Memory > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_JOB_MEMORY
+		info.JobMemoryLimit = uintptr(limits.Memory)
+	}
+	_, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if limits != nil && limits.CPUs > 0 {
+		// The CPU rate is the percentage of all CPUs times 100
+		rate := uint32(math.Ceil(limits.CPUs / float64(runtime.NumCPU()) * 10000))
+		cpuInfo := jobObjectCPURateControlInformation{
+			ControlFlags: jobObjectCPURateControlEnable | jobObjectCPURateControlHardCap,
+			CPURate:      min(max(rate, 1), 10000),
+		}
+		_, err = windows.SetInformationJobObject(job, windows.JobObjectCpuRateControlInformation,
+			uintptr(unsafe.Pointer(&cpuInfo)), uint32(unsafe.Sizeof(cpuInfo)))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
 }
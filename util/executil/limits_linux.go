@@ -0,0 +1,67 @@
+package executil
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// The file which only exists if the unified cgroup v2 hierarchy is
+// mounted
+const cgroupV2ControllersFile = "/sys/fs/cgroup/cgroup.controllers"
+
+// applyResourceLimits runs the command in a transient systemd scope,
+// which is a cgroup with the resource limits. Unprivileged processes
+// can't create cgroups themselves, but the systemd user instance can,
+// if the memory and cpu controllers are delegated to it, which is the
+// default on current distributions.
+//
+// The scope is created via systemd-run, which then executes the
+// command, so that the process of the command is still the process
+// started by Start and the process group still works as before.
+func (c *Cmd) applyResourceLimits() error {
+	if exists, _ := fileutil.Exists(cgroupV2ControllersFile); !exists {
+		return errors.New("Resource limits are only supported with cgroup v2")
+	}
+	systemdRun, err := exec.LookPath("systemd-run")
+	if err != nil {
+		return errors.New("Resource limits require systemd-run, which was not found in PATH")
+	}
+
+	args := []string{systemdRun, "--scope", "--quiet", "--collect"}
+	if os.Geteuid() != 0 {
+		args = append(args, "--user")
+	}
+	args = append(args, systemdProperties(c.ResourceLimits)...)
+	args = append(args, "--", c.Path)
+	args = append(args, c.Args[1:]...)
+	log.Debugf("Running command in systemd scope with resource limits: %v", args)
+	c.Path = systemdRun
+	c.Args = args
+	return nil
+}
+
+// systemdProperties returns the arguments for systemd-run which set the
+// properties of the scope that enforce the limits, see
+// systemd.resource-control(5)
+func systemdProperties(limits *ResourceLimits) []string {
+	var args []string
+	if limits.Memory > 0 {
+		// Disallow swapping, because otherwise a runaway process would
+		// slow down the machine instead of being killed
+		args = append(args,
+			"--property", fmt.Sprintf("MemoryMax=%d", limits.Memory),
+			"--property", "MemorySwapMax=0")
+	}
+	if limits.CPUs > 0 {
+		// CPUQuota is specified relative to a single CPU
+		args = append(args, "--property", fmt.Sprintf("CPUQuota=%d%%", int(math.Ceil(limits.CPUs*100))))
+	}
+	return args
+}
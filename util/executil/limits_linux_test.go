@@ -0,0 +1,41 @@
+package executil
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+func TestSystemdProperties(t *testing.T) {
+	assert.Equal(t, []string{
+		"--property", "MemoryMax=1073741824",
+		"--property", "MemorySwapMax=0",
+		"--property", "CPUQuota=150%",
+	}, systemdProperties(&ResourceLimits{Memory: 1 << 30, CPUs: 1.5}))
+	assert.Equal(t, []string{"--property", "CPUQuota=34%"}, systemdProperties(&ResourceLimits{CPUs: 0.333}))
+	assert.Empty(t, systemdProperties(&ResourceLimits{}))
+}
+
+func TestCmd_ApplyResourceLimits(t *testing.T) {
+	if exists, _ := fileutil.Exists(cgroupV2ControllersFile); !exists {
+		t.Skip("Resource limits require cgroup v2")
+	}
+	systemdRun, err := exec.LookPath("systemd-run")
+	if err != nil {
+		t.Skip("Resource limits require systemd-run")
+	}
+
+	truePath, err := exec.LookPath("true")
+	require.NoError(t, err)
+	cmd := Command(truePath, "arg")
+	cmd.ResourceLimits = &ResourceLimits{Memory: 1 << 30}
+	require.NoError(t, cmd.applyResourceLimits())
+	assert.Equal(t, systemdRun, cmd.Path)
+	assert.Equal(t, systemdRun, cmd.Args[0])
+	assert.Equal(t, []string{"--", truePath, "arg"}, cmd.Args[len(cmd.Args)-3:])
+	assert.Contains(t, cmd.Args, "MemoryMax=1073741824")
+}
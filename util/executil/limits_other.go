@@ -0,0 +1,13 @@
+//go:build aix || darwin || dragonfly || freebsd || netbsd || openbsd || solaris
+
+package executil
+
+import (
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+func (c *Cmd) applyResourceLimits() error {
+	return errors.Errorf("Resource limits are not supported on %s", runtime.GOOS)
+}
@@ -65,6 +65,35 @@ func Cleanup(path string) {
 	}
 }
 
+// ReplaceDir replaces the directory dir with the directory newDir,
+// which must be on the same filesystem. The old directory is renamed
+// aside before newDir is renamed to dir and only deleted afterwards, so
+// that dir is never left partially deleted. If renaming newDir fails,
+// the old directory is restored.
+func ReplaceDir(dir, newDir string) error {
+	oldParent, err := os.MkdirTemp(filepath.Dir(dir), ".old-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	oldDir := filepath.Join(oldParent, filepath.Base(dir))
+	err = os.Rename(dir, oldDir)
+	if err != nil {
+		Cleanup(oldParent)
+		return errors.WithStack(err)
+	}
+	err = os.Rename(newDir, dir)
+	if err != nil {
+		restoreErr := os.Rename(oldDir, dir)
+		if restoreErr != nil {
+			// Keep the old directory, so that it can be restored manually
+			log.Errorf(restoreErr, "Failed to restore %s from %s: %v", dir, oldDir, restoreErr)
+			return errors.WithStack(err)
+		}
+	}
+	Cleanup(oldParent)
+	return errors.WithStack(err)
+}
+
 // PrettifyPath prints a possibly shortened path for display purposes.
 // If path is located under the current working directory, the relative path to
 // it is returned, otherwise or in case of an error the path is returned
@@ -84,6 +113,69 @@ func PrettifyPath(path string) string {
 	return rel
 }
 
+// windowsAbsPath matches absolute Windows paths with a drive letter and
+// UNC paths, which are not recognized as absolute by the filepath
+// package on other OSes
+var windowsAbsPath = regexp.MustCompile(`^([A-Za-z]:[\\/]|[\\/]{2})`)
+
+// ToSlash is like filepath.ToSlash, but also converts the separators of
+// absolute Windows paths on other OSes, so that paths from findings and
+// reports created on Windows are normalized on any host.
+func ToSlash(path string) string {
+	if windowsAbsPath.MatchString(path) {
+		return strings.ReplaceAll(path, `\`, "/")
+	}
+	return filepath.ToSlash(path)
+}
+
+// IsAbs is like filepath.IsAbs, but also returns true for absolute
+// Windows paths on other OSes
+func IsAbs(path string) bool {
+	return filepath.IsAbs(path) || windowsAbsPath.MatchString(path)
+}
+
+// ProjectRelativePath returns the path relative to the project
+// directory with forward slashes, so that paths in reports are the
+// same regardless of the host OS. Relative paths are expected to be
+// relative to the project directory already. Paths which are not below
+// the project directory are returned as absolute paths with forward
+// slashes.
+func ProjectRelativePath(projectDir, path string) string {
+	path = ToSlash(path)
+	if !IsAbs(path) {
+		return path
+	}
+	dirs := []string{projectDir}
+	// Compilers and coverage tools often report paths with the symlinks
+	// resolved, for example /private/var instead of /var on macOS
+	realDir, err := filepath.EvalSymlinks(projectDir)
+	if err == nil && realDir != projectDir {
+		dirs = append(dirs, realDir)
+	}
+	for _, dir := range dirs {
+		rel, ok := cutDir(ToSlash(dir), path)
+		if ok {
+			return rel
+		}
+	}
+	return path
+}
+
+// cutDir returns the path relative to dir if it's below dir. Both paths
+// must use forward slashes. Windows paths are compared
+// case-insensitively.
+func cutDir(dir, path string) (string, bool) {
+	dir = strings.TrimSuffix(dir, "/")
+	if len(path) <= len(dir)+1 || path[len(dir)] != '/' {
+		return "", false
+	}
+	prefix := path[:len(dir)]
+	if prefix != dir && !(windowsAbsPath.MatchString(dir) && strings.EqualFold(prefix, dir)) {
+		return "", false
+	}
+	return path[len(dir)+1:], true
+}
+
 // IsBelow returns true if and only if path lies below or is the path root.
 // path and root must be either both absolute or both relative.
 func IsBelow(path string, root string) (bool, error) {
@@ -45,6 +45,78 @@ func TestIsBelow(t *testing.T) {
 	assert.False(t, isBelow)
 }
 
+func TestProjectRelativePath(t *testing.T) {
+	projectDir := t.TempDir()
+
+	path := ProjectRelativePath(projectDir, filepath.Join(projectDir, "src", "parser.cpp"))
+	assert.Equal(t, "src/parser.cpp", path)
+
+	path = ProjectRelativePath(projectDir, filepath.Join("src", "parser.cpp"))
+	assert.Equal(t, "src/parser.cpp", path)
+
+	outside := filepath.Join(filepath.Dir(projectDir), "other", "lib.cpp")
+	path = ProjectRelativePath(projectDir, outside)
+	assert.Equal(t, filepath.ToSlash(outside), path)
+}
+
+func TestProjectRelativePath_WindowsPaths(t *testing.T) {
+	projectDir := `C:\Users\dev\project`
+
+	for _, tc := range []struct {
+		path     string
+		expected string
+	}{
+		{`C:\Users\dev\project\src\parser.cpp`, "src/parser.cpp"},
+		{`c:\users\dev\Project\src\parser.cpp`, "src/parser.cpp"},
+		{"C:/Users/dev/project/src/parser.cpp", "src/parser.cpp"},
+		{`C:\Users\dev\project2\src\parser.cpp`, "C:/Users/dev/project2/src/parser.cpp"},
+		{`D:\lib\lib.cpp`, "D:/lib/lib.cpp"},
+		{`\\server\share\lib.cpp`, "//server/share/lib.cpp"},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ProjectRelativePath(projectDir, tc.path))
+		})
+	}
+}
+
+func TestProjectRelativePath_Symlink(t *testing.T) {
+	realDir := t.TempDir()
+	projectDir := filepath.Join(t.TempDir(), "project")
+	err := os.Symlink(realDir, projectDir)
+	require.NoError(t, err)
+
+	path := ProjectRelativePath(projectDir, filepath.Join(realDir, "src", "parser.cpp"))
+	assert.Equal(t, "src/parser.cpp", path)
+}
+
+func TestReplaceDir(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "corpus")
+	require.NoError(t, os.Mkdir(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "old"), nil, 0o644))
+	newDir := filepath.Join(parent, "new")
+	require.NoError(t, os.Mkdir(newDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(newDir, "new"), nil, 0o644))
+
+	err := ReplaceDir(dir, newDir)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "new"))
+	assert.NoFileExists(t, filepath.Join(dir, "old"))
+	// The old directory was deleted
+	entries, err := os.ReadDir(parent)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "corpus", entries[0].Name())
+
+	// If the new directory can't be renamed, the old directory is kept
+	err = ReplaceDir(dir, filepath.Join(parent, "does-not-exist"))
+	require.Error(t, err)
+	assert.FileExists(t, filepath.Join(dir, "new"))
+	entries, err = os.ReadDir(parent)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
 func TestForceSymlink(t *testing.T) {
 	var err error
 